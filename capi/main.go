@@ -0,0 +1,128 @@
+//go:build cgo
+
+// Command capi builds the converter as a C shared library for services
+// written in other languages:
+//
+//	go build -buildmode=c-shared -o libmdtopdf.so ./capi
+//
+// The build writes libmdtopdf.h beside the library. The preamble below is
+// copied into that header, so it documents the C API.
+package main
+
+/*
+#include <stdlib.h>
+
+// md-to-pdf C API
+//
+// ConvertBytes converts markdown to a PDF in memory:
+//
+//   markdown, markdownLen  the markdown source, not NUL-terminated
+//   configJSON             NUL-terminated settings in the config file format
+//                          as JSON, e.g. {"font_size": 11, "extends":
+//                          "github"}; NULL or "" for the defaults. Plugins
+//                          are never loaded, so no shared object from the
+//                          host's working directory is opened
+//   pdfOut, pdfLenOut      set to the PDF and its length on success
+//   messageOut             set to the error message on failure; on success
+//                          set to the conversion warnings, one per line, or
+//                          NULL when there are none. May be NULL to ignore
+//
+// It returns one of the MDTOPDF_* codes below and never keeps references to
+// its arguments, so they may be freed as soon as it returns. It may be called
+// from several threads at once.
+//
+// Memory ownership: *pdfOut and *messageOut are allocated by the library and
+// owned by the caller, who releases each with FreeBuffer (not free, which
+// may use a different allocator on some platforms). On failure *pdfOut is
+// set to NULL and *pdfLenOut to 0.
+
+enum {
+	MDTOPDF_OK = 0,               // The PDF was written to *pdfOut
+	MDTOPDF_ERR_ARGUMENT = 1,     // markdown, pdfOut or pdfLenOut is NULL
+	MDTOPDF_ERR_CONFIG = 2,       // configJSON has invalid or unknown settings
+	MDTOPDF_ERR_CONVERSION = 3,   // The markdown could not be converted
+};
+
+// FreeBuffer releases a buffer returned by ConvertBytes. NULL is ignored.
+*/
+import "C"
+
+import (
+	"strings"
+	"unsafe"
+
+	"github.com/fredcamaral/md-to-pdf/internal/config"
+	"github.com/fredcamaral/md-to-pdf/internal/core"
+)
+
+// Result codes, mirroring the MDTOPDF_* enum of the header
+const (
+	resultOK         = C.MDTOPDF_OK
+	resultArgument   = C.MDTOPDF_ERR_ARGUMENT
+	resultConfig     = C.MDTOPDF_ERR_CONFIG
+	resultConversion = C.MDTOPDF_ERR_CONVERSION
+)
+
+//export ConvertBytes
+func ConvertBytes(markdown *C.char, markdownLen C.size_t, configJSON *C.char, pdfOut **C.uchar, pdfLenOut *C.size_t, messageOut **C.char) C.int {
+	if messageOut != nil {
+		*messageOut = nil
+	}
+	if pdfOut == nil || pdfLenOut == nil {
+		return resultArgument
+	}
+	*pdfOut, *pdfLenOut = nil, 0
+	if markdown == nil && markdownLen > 0 {
+		setMessage(messageOut, "markdown is NULL")
+		return resultArgument
+	}
+
+	settings := ""
+	if configJSON != nil {
+		settings = C.GoString(configJSON)
+	}
+	cfg, err := config.ConfigFromSettings([]byte(settings))
+	if err != nil {
+		setMessage(messageOut, err.Error())
+		return resultConfig
+	}
+	// The host's working directory is not ours to load plugins from
+	cfg.Plugins.Enabled = false
+	engine, err := core.NewEngine(cfg)
+	if err != nil {
+		setMessage(messageOut, err.Error())
+		return resultConfig
+	}
+	defer engine.Close()
+	var warnings []string
+	engine.SetWarningHandler(func(file, message string) {
+		warnings = append(warnings, message)
+	})
+
+	pdf, err := engine.ConvertBytes(C.GoBytes(unsafe.Pointer(markdown), C.int(markdownLen)), "document.md")
+	if err != nil {
+		setMessage(messageOut, err.Error())
+		return resultConversion
+	}
+
+	*pdfOut = (*C.uchar)(C.CBytes(pdf))
+	*pdfLenOut = C.size_t(len(pdf))
+	if len(warnings) > 0 {
+		setMessage(messageOut, strings.Join(warnings, "\n"))
+	}
+	return resultOK
+}
+
+//export FreeBuffer
+func FreeBuffer(buffer unsafe.Pointer) {
+	C.free(buffer)
+}
+
+// setMessage stores message in *messageOut for the caller to free.
+func setMessage(messageOut **C.char, message string) {
+	if messageOut != nil {
+		*messageOut = C.CString(message)
+	}
+}
+
+func main() {}
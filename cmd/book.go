@@ -0,0 +1,158 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/fredcamaral/md-to-pdf/internal/book"
+	"github.com/fredcamaral/md-to-pdf/internal/config"
+	"github.com/fredcamaral/md-to-pdf/internal/core"
+	"github.com/fredcamaral/md-to-pdf/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+// bookCommand holds the state of the book command.
+type bookCommand struct {
+	outputPath string
+	pluginDir  string
+}
+
+// newBookCommand creates the book command, which builds one PDF from the
+// chapters listed in a book.yaml file.
+func newBookCommand() *cobra.Command {
+	c := &bookCommand{}
+
+	cmd := &cobra.Command{
+		Use:   "book [book.yaml]",
+		Short: "Build a multi-chapter book into a single PDF",
+		Long: `Build a single PDF from the chapters listed in a book file.
+
+The book file (default book.yaml) lists the chapters in order, with optional
+front matter, a cover, a theme and the output name:
+
+  title: The Guide
+  author: Jane Doe
+  output: guide.pdf
+  theme: theme.yaml        # built-in theme or settings in the format of
+                           # ~/.md-to-pdf/config.yaml
+  style:                   # settings overriding the theme's
+    font_size: 11
+  cover: cover.png         # image or Markdown file
+  front_matter: [preface.md]
+  chapters:
+    - intro.md
+    - install.md
+    - file: install-docker.md
+      shift_headings: 1    # nest under the previous chapter
+  toc: true
+  toc_depth: 2
+
+Every chapter starts on a new page after a table of contents, except chapters
+with shift_headings, whose headings are demoted so they continue the chapter
+before them as a section. Links between chapters (install.md,
+install.md#linux) become links within the PDF.
+
+Examples:
+  md-to-pdf book
+  md-to-pdf book docs/book.yaml -o dist/guide.pdf`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: c.run,
+	}
+
+	cmd.Flags().StringVarP(&c.outputPath, "output", "o", "", "Output PDF path (default: output from the book file)")
+	cmd.Flags().StringVarP(&c.pluginDir, "plugins", "p", "./plugins", "Plugin directory path")
+
+	return cmd
+}
+
+// run builds the book.
+func (c *bookCommand) run(_ *cobra.Command, args []string) error {
+	bookPath := "book.yaml"
+	if len(args) == 1 {
+		bookPath = args[0]
+	}
+
+	b, err := book.Load(bookPath)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := c.bookConfig(b)
+	if err != nil {
+		return err
+	}
+
+	content, err := b.Assemble()
+	if err != nil {
+		return err
+	}
+
+	outputPath := c.outputPath
+	if outputPath == "" {
+		outputPath = b.Path(b.Output)
+	}
+
+	engine, err := core.NewEngine(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create engine: %w", err)
+	}
+
+	uiOutput := ui.NewOutput()
+	warnings := newWarningCollector(uiOutput, false)
+	engine.SetWarningHandler(warnings.handle)
+	engine.SetPluginLogHandler(newPluginLogHandler(uiOutput, false, false))
+	splits := newSplitCollector()
+	engine.SetSplitHandler(splits.handle)
+
+	if err := engine.ConvertSource(content, bookPath, outputPath); err != nil {
+		return fmt.Errorf("conversion failed: %w", err)
+	}
+
+	uiOutput.Successf("Built: %s -> %s (%d chapters)", filepath.Base(bookPath), splits.describe(outputPath), len(b.Chapters))
+	return nil
+}
+
+// bookConfig layers the user config, the book theme, the book style and the
+// book metadata over the defaults.
+func (c *bookCommand) bookConfig(b *book.Book) (*core.Config, error) {
+	cfg := core.DefaultConfig()
+
+	userConfig, err := config.LoadResolvedUserConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load user config: %w", err)
+	}
+	config.ApplyUserConfig(cfg, userConfig)
+
+	if b.Theme != "" {
+		theme, _, err := config.LoadTheme(b.Theme, b.Path("."))
+		if err != nil {
+			return nil, fmt.Errorf("failed to load theme %s: %w", b.Theme, err)
+		}
+		config.ApplyUserConfig(cfg, theme)
+	}
+	// The book's own style overrides single settings of its theme
+	config.ApplyUserConfig(cfg, &b.Style)
+
+	if b.Title != "" {
+		cfg.Document.Title = b.Title
+	}
+	if b.Author != "" {
+		cfg.Document.Author = b.Author
+	}
+	if b.Subject != "" {
+		cfg.Document.Subject = b.Subject
+	}
+	if b.TOCDepth > 0 {
+		cfg.Renderer.TOC.Depth = b.TOCDepth
+	}
+	// The assembled book places its table of contents with a marker
+	cfg.Renderer.TOC.Enabled = false
+
+	cfg.Plugins.Directory = c.pluginDir
+
+	return cfg, nil
+}
+
+func init() {
+	rootCmd.AddCommand(newBookCommand())
+}
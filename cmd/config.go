@@ -7,7 +7,9 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/fredcamaral/md-to-pdf/internal/colorutil"
 	"github.com/fredcamaral/md-to-pdf/internal/config"
+	"github.com/fredcamaral/md-to-pdf/internal/contentscan"
 	"github.com/fredcamaral/md-to-pdf/internal/core"
 	"github.com/fredcamaral/md-to-pdf/internal/ui"
 	"github.com/spf13/cobra"
@@ -20,6 +22,12 @@ const (
 	configKeyString configKeyType = iota
 	configKeyFloat64
 	configKeyPageSize
+	configKeyEnum
+	configKeyBool
+	configKeyByteSize
+	configKeyColor
+	configKeyInt
+	configKeyFileMode
 )
 
 // configCategory groups related configuration keys.
@@ -28,9 +36,18 @@ type configCategory string
 const (
 	categoryTypography configCategory = "Typography"
 	categoryCode       configCategory = "Code Styling"
+	categoryColors     configCategory = "Colors"
 	categoryPage       configCategory = "Page Layout"
 	categoryMetadata   configCategory = "PDF Metadata"
 	categoryMermaid    configCategory = "Mermaid Settings"
+	categoryHeader     configCategory = "Header & Footer"
+	categoryBanner     configCategory = "Classification Banner"
+	categoryRules      configCategory = "Horizontal Rules"
+	categoryQuotes     configCategory = "Blockquotes"
+	categoryTOC        configCategory = "Table of Contents"
+	categorySecurity   configCategory = "Security"
+	categoryOutput     configCategory = "Output"
+	categoryNetwork    configCategory = "Network"
 )
 
 // configKeyDef defines metadata for a configuration key including validation rules.
@@ -42,6 +59,7 @@ type configKeyDef struct {
 	defaultValue interface{}
 	minValue     float64
 	maxValue     float64
+	allowed      []string // Valid values for configKeyEnum keys
 	getter       func(*config.UserConfig) interface{}
 	setter       func(*config.UserConfig, interface{})
 	resetter     func(*config.UserConfig)
@@ -50,6 +68,16 @@ type configKeyDef struct {
 // configKeys is the single source of truth for all configuration keys.
 var configKeys = []configKeyDef{
 	// Typography & Fonts
+	{
+		name:         "extends",
+		category:     categoryTypography,
+		description:  "Theme the other settings apply over: a built-in theme (md-to-pdf theme list) or a theme file",
+		keyType:      configKeyString,
+		defaultValue: "",
+		getter:       func(c *config.UserConfig) interface{} { return c.Extends },
+		setter:       func(c *config.UserConfig, v interface{}) { c.Extends = v.(string) },
+		resetter:     func(c *config.UserConfig) { c.Extends = "" },
+	},
 	{
 		name:         "font-family",
 		category:     categoryTypography,
@@ -84,6 +112,30 @@ var configKeys = []configKeyDef{
 		setter:       func(c *config.UserConfig, v interface{}) { c.HeadingScale = v.(float64) },
 		resetter:     func(c *config.UserConfig) { c.HeadingScale = 0 },
 	},
+	{
+		name:         "heading-min-size",
+		category:     categoryTypography,
+		description:  "Smallest size in points long headings shrink to, 0=wrap only (range: 0-72)",
+		keyType:      configKeyFloat64,
+		defaultValue: 0.0,
+		minValue:     0,
+		maxValue:     core.FontSizeMax,
+		getter:       func(c *config.UserConfig) interface{} { return c.HeadingMinSize },
+		setter:       func(c *config.UserConfig, v interface{}) { c.HeadingMinSize = v.(float64) },
+		resetter:     func(c *config.UserConfig) { c.HeadingMinSize = 0 },
+	},
+	{
+		name:         "shift-headings",
+		category:     categoryTypography,
+		description:  "Levels every heading is demoted by, negative promotes (range: -5 to 5)",
+		keyType:      configKeyInt,
+		defaultValue: 0,
+		minValue:     -core.HeadingShiftMax,
+		maxValue:     core.HeadingShiftMax,
+		getter:       func(c *config.UserConfig) interface{} { return c.ShiftHeadings },
+		setter:       func(c *config.UserConfig, v interface{}) { c.ShiftHeadings = v.(int) },
+		resetter:     func(c *config.UserConfig) { c.ShiftHeadings = 0 },
+	},
 	{
 		name:         "line-spacing",
 		category:     categoryTypography,
@@ -119,6 +171,67 @@ var configKeys = []configKeyDef{
 		setter:       func(c *config.UserConfig, v interface{}) { c.CodeSize = v.(float64) },
 		resetter:     func(c *config.UserConfig) { c.CodeSize = 0 },
 	},
+	// Colors
+	{
+		name:         "text-color",
+		category:     categoryColors,
+		description:  "Body text color (hex or color name)",
+		keyType:      configKeyColor,
+		defaultValue: "black",
+		getter:       func(c *config.UserConfig) interface{} { return c.TextColor },
+		setter:       func(c *config.UserConfig, v interface{}) { c.TextColor = v.(string) },
+		resetter:     func(c *config.UserConfig) { c.TextColor = "" },
+	},
+	{
+		name:         "background-color",
+		category:     categoryColors,
+		description:  "Page background color (hex or color name)",
+		keyType:      configKeyColor,
+		defaultValue: "white",
+		getter:       func(c *config.UserConfig) interface{} { return c.BackgroundColor },
+		setter:       func(c *config.UserConfig, v interface{}) { c.BackgroundColor = v.(string) },
+		resetter:     func(c *config.UserConfig) { c.BackgroundColor = "" },
+	},
+	{
+		name:         "link-color",
+		category:     categoryColors,
+		description:  "Link text color (hex or color name)",
+		keyType:      configKeyColor,
+		defaultValue: "#0000ee",
+		getter:       func(c *config.UserConfig) interface{} { return c.LinkColor },
+		setter:       func(c *config.UserConfig, v interface{}) { c.LinkColor = v.(string) },
+		resetter:     func(c *config.UserConfig) { c.LinkColor = "" },
+	},
+	{
+		name:         "code-color",
+		category:     categoryColors,
+		description:  "Code text color, defaults to the body text color (hex or color name)",
+		keyType:      configKeyColor,
+		defaultValue: "",
+		getter:       func(c *config.UserConfig) interface{} { return c.CodeColor },
+		setter:       func(c *config.UserConfig, v interface{}) { c.CodeColor = v.(string) },
+		resetter:     func(c *config.UserConfig) { c.CodeColor = "" },
+	},
+	{
+		name:         "code-background",
+		category:     categoryColors,
+		description:  "Background of code blocks and code spans (hex or color name)",
+		keyType:      configKeyColor,
+		defaultValue: "#f5f5f5",
+		getter:       func(c *config.UserConfig) interface{} { return c.CodeBackground },
+		setter:       func(c *config.UserConfig, v interface{}) { c.CodeBackground = v.(string) },
+		resetter:     func(c *config.UserConfig) { c.CodeBackground = "" },
+	},
+	{
+		name:         "image-backdrop",
+		category:     categoryColors,
+		description:  "Color to place transparent images with light strokes on, which would not show on the page (hex or color name, empty = warn only)",
+		keyType:      configKeyColor,
+		defaultValue: "",
+		getter:       func(c *config.UserConfig) interface{} { return c.ImageBackdrop },
+		setter:       func(c *config.UserConfig, v interface{}) { c.ImageBackdrop = v.(string) },
+		resetter:     func(c *config.UserConfig) { c.ImageBackdrop = "" },
+	},
 	// Page layout
 	{
 		name:         "page-size",
@@ -178,6 +291,50 @@ var configKeys = []configKeyDef{
 		setter:       func(c *config.UserConfig, v interface{}) { c.MarginRight = v.(float64) },
 		resetter:     func(c *config.UserConfig) { c.MarginRight = 0 },
 	},
+	{
+		name:         "baseline-grid",
+		category:     categoryPage,
+		description:  "Snap block spacing to multiples of the line height (true, false)",
+		keyType:      configKeyBool,
+		defaultValue: false,
+		getter:       func(c *config.UserConfig) interface{} { return c.BaselineGrid },
+		setter:       func(c *config.UserConfig, v interface{}) { c.BaselineGrid = v.(bool) },
+		resetter:     func(c *config.UserConfig) { c.BaselineGrid = false },
+	},
+	{
+		name:         "sidenote-side",
+		category:     categoryPage,
+		description:  "Margin for ^[sidenotes] (outer, right, left)",
+		keyType:      configKeyEnum,
+		defaultValue: "outer",
+		allowed:      core.ValidSidenoteSides,
+		getter:       func(c *config.UserConfig) interface{} { return c.SidenoteSide },
+		setter:       func(c *config.UserConfig, v interface{}) { c.SidenoteSide = v.(string) },
+		resetter:     func(c *config.UserConfig) { c.SidenoteSide = "" },
+	},
+	{
+		name:         "keep-with-next",
+		category:     categoryPage,
+		description:  "Keep headings on the page of the text after them (headings, none)",
+		keyType:      configKeyEnum,
+		defaultValue: "headings",
+		allowed:      core.ValidKeepWithNext,
+		getter:       func(c *config.UserConfig) interface{} { return c.KeepWithNext },
+		setter:       func(c *config.UserConfig, v interface{}) { c.KeepWithNext = v.(string) },
+		resetter:     func(c *config.UserConfig) { c.KeepWithNext = "" },
+	},
+	{
+		name:         "min-lines-after-heading",
+		category:     categoryPage,
+		description:  "Lines of text that must fit below a heading to keep it on its page (range: 1-10)",
+		keyType:      configKeyInt,
+		defaultValue: 1,
+		minValue:     1,
+		maxValue:     core.MinLinesAfterHeadingMax,
+		getter:       func(c *config.UserConfig) interface{} { return c.MinLinesAfterHeading },
+		setter:       func(c *config.UserConfig, v interface{}) { c.MinLinesAfterHeading = v.(int) },
+		resetter:     func(c *config.UserConfig) { c.MinLinesAfterHeading = 0 },
+	},
 	// PDF metadata
 	{
 		name:         "title",
@@ -209,6 +366,26 @@ var configKeys = []configKeyDef{
 		setter:       func(c *config.UserConfig, v interface{}) { c.Subject = v.(string) },
 		resetter:     func(c *config.UserConfig) { c.Subject = "" },
 	},
+	{
+		name:         "date-format",
+		category:     categoryMetadata,
+		description:  "Go time layout for the {date} header/footer variable (e.g. 02.01.2006)",
+		keyType:      configKeyString,
+		defaultValue: "2006-01-02",
+		getter:       func(c *config.UserConfig) interface{} { return c.DateFormat },
+		setter:       func(c *config.UserConfig, v interface{}) { c.DateFormat = v.(string) },
+		resetter:     func(c *config.UserConfig) { c.DateFormat = "" },
+	},
+	{
+		name:         "title-from-h1",
+		category:     categoryMetadata,
+		description:  "Use a leading H1 as the title and output file name, removing it from the body (true, false)",
+		keyType:      configKeyBool,
+		defaultValue: false,
+		getter:       func(c *config.UserConfig) interface{} { return c.TitleFromH1 },
+		setter:       func(c *config.UserConfig, v interface{}) { c.TitleFromH1 = v.(bool) },
+		resetter:     func(c *config.UserConfig) { c.TitleFromH1 = false },
+	},
 	// Mermaid settings
 	{
 		name:         "mermaid-scale",
@@ -246,6 +423,422 @@ var configKeys = []configKeyDef{
 		setter:       func(c *config.UserConfig, v interface{}) { c.MermaidMaxHeight = v.(float64) },
 		resetter:     func(c *config.UserConfig) { c.MermaidMaxHeight = 0 },
 	},
+	{
+		name:         "mermaid-theme",
+		category:     categoryMermaid,
+		description:  "Diagram variant for light or dark documents (light, dark)",
+		keyType:      configKeyString,
+		defaultValue: "light",
+		allowed:      core.ValidMermaidThemes,
+		getter:       func(c *config.UserConfig) interface{} { return c.MermaidTheme },
+		setter:       func(c *config.UserConfig, v interface{}) { c.MermaidTheme = v.(string) },
+		resetter:     func(c *config.UserConfig) { c.MermaidTheme = "" },
+	},
+	{
+		name:         "mermaid-wide-strategy",
+		category:     categoryMermaid,
+		description:  "Placement of diagrams too wide for the text column (shrink, rotate, landscape)",
+		keyType:      configKeyString,
+		defaultValue: "shrink",
+		allowed:      core.ValidMermaidWideStrategies,
+		getter:       func(c *config.UserConfig) interface{} { return c.MermaidWideStrategy },
+		setter:       func(c *config.UserConfig, v interface{}) { c.MermaidWideStrategy = v.(string) },
+		resetter:     func(c *config.UserConfig) { c.MermaidWideStrategy = "" },
+	},
+	// Header & footer
+	{
+		name:         "header",
+		category:     categoryHeader,
+		description:  "Markdown snippet rendered at the top of every page ({page}, {pages}, {title}, {author}, {date})",
+		keyType:      configKeyString,
+		defaultValue: "",
+		getter:       func(c *config.UserConfig) interface{} { return c.Header },
+		setter:       func(c *config.UserConfig, v interface{}) { c.Header = v.(string) },
+		resetter:     func(c *config.UserConfig) { c.Header = "" },
+	},
+	{
+		name:         "footer",
+		category:     categoryHeader,
+		description:  "Markdown snippet rendered at the bottom of every page (same variables as header)",
+		keyType:      configKeyString,
+		defaultValue: "",
+		getter:       func(c *config.UserConfig) interface{} { return c.Footer },
+		setter:       func(c *config.UserConfig, v interface{}) { c.Footer = v.(string) },
+		resetter:     func(c *config.UserConfig) { c.Footer = "" },
+	},
+	{
+		name:         "header-align",
+		category:     categoryHeader,
+		description:  "Header alignment (left, center, right)",
+		keyType:      configKeyEnum,
+		defaultValue: "left",
+		allowed:      core.ValidAlignments,
+		getter:       func(c *config.UserConfig) interface{} { return c.HeaderAlign },
+		setter:       func(c *config.UserConfig, v interface{}) { c.HeaderAlign = v.(string) },
+		resetter:     func(c *config.UserConfig) { c.HeaderAlign = "" },
+	},
+	{
+		name:         "footer-align",
+		category:     categoryHeader,
+		description:  "Footer alignment (left, center, right)",
+		keyType:      configKeyEnum,
+		defaultValue: "center",
+		allowed:      core.ValidAlignments,
+		getter:       func(c *config.UserConfig) interface{} { return c.FooterAlign },
+		setter:       func(c *config.UserConfig, v interface{}) { c.FooterAlign = v.(string) },
+		resetter:     func(c *config.UserConfig) { c.FooterAlign = "" },
+	},
+	// Classification banner
+	{
+		name:         "banner",
+		category:     categoryBanner,
+		description:  "Banner stamped on every page, e.g. CONFIDENTIAL (same variables as header)",
+		keyType:      configKeyString,
+		defaultValue: "",
+		getter:       func(c *config.UserConfig) interface{} { return c.Banner.Text },
+		setter:       func(c *config.UserConfig, v interface{}) { c.Banner.Text = v.(string) },
+		resetter:     func(c *config.UserConfig) { c.Banner.Text = "" },
+	},
+	{
+		name:         "banner-position",
+		category:     categoryBanner,
+		description:  "Page edges the banner is stamped on (top, bottom, both)",
+		keyType:      configKeyEnum,
+		defaultValue: "both",
+		allowed:      core.ValidBannerPositions,
+		getter:       func(c *config.UserConfig) interface{} { return c.Banner.Position },
+		setter:       func(c *config.UserConfig, v interface{}) { c.Banner.Position = v.(string) },
+		resetter:     func(c *config.UserConfig) { c.Banner.Position = "" },
+	},
+	{
+		name:         "banner-color",
+		category:     categoryBanner,
+		description:  "Banner text color (hex or color name, default body text color)",
+		keyType:      configKeyColor,
+		defaultValue: "",
+		getter:       func(c *config.UserConfig) interface{} { return c.Banner.Color },
+		setter:       func(c *config.UserConfig, v interface{}) { c.Banner.Color = v.(string) },
+		resetter:     func(c *config.UserConfig) { c.Banner.Color = "" },
+	},
+	{
+		name:         "banner-background",
+		category:     categoryBanner,
+		description:  "Color of the band behind the banner (hex, color name or none)",
+		keyType:      configKeyColor,
+		defaultValue: "none",
+		allowed:      []string{"none"},
+		getter:       func(c *config.UserConfig) interface{} { return c.Banner.Background },
+		setter:       func(c *config.UserConfig, v interface{}) { c.Banner.Background = v.(string) },
+		resetter:     func(c *config.UserConfig) { c.Banner.Background = "" },
+	},
+	// Horizontal rules
+	{
+		name:         "rule-style",
+		category:     categoryRules,
+		description:  "Horizontal rule style (solid, dashed, dotted, ornament)",
+		keyType:      configKeyEnum,
+		defaultValue: "solid",
+		allowed:      core.ValidRuleStyles,
+		getter:       func(c *config.UserConfig) interface{} { return c.RuleStyle },
+		setter:       func(c *config.UserConfig, v interface{}) { c.RuleStyle = v.(string) },
+		resetter:     func(c *config.UserConfig) { c.RuleStyle = "" },
+	},
+	{
+		name:         "rule-thickness",
+		category:     categoryRules,
+		description:  "Horizontal rule line width in mm (range: 0.05-5)",
+		keyType:      configKeyFloat64,
+		defaultValue: 0.2,
+		minValue:     core.RuleThicknessMin,
+		maxValue:     core.RuleThicknessMax,
+		getter:       func(c *config.UserConfig) interface{} { return c.RuleThickness },
+		setter:       func(c *config.UserConfig, v interface{}) { c.RuleThickness = v.(float64) },
+		resetter:     func(c *config.UserConfig) { c.RuleThickness = 0 },
+	},
+	{
+		name:         "rule-color",
+		category:     categoryRules,
+		description:  "Horizontal rule color (hex like #c8c8c8 or a color name)",
+		keyType:      configKeyColor,
+		defaultValue: "#c8c8c8",
+		getter:       func(c *config.UserConfig) interface{} { return c.RuleColor },
+		setter:       func(c *config.UserConfig, v interface{}) { c.RuleColor = v.(string) },
+		resetter:     func(c *config.UserConfig) { c.RuleColor = "" },
+	},
+	{
+		name:         "rule-width",
+		category:     categoryRules,
+		description:  "Horizontal rule width as a percentage of the text width (range: 1-100)",
+		keyType:      configKeyFloat64,
+		defaultValue: 100.0,
+		minValue:     core.RuleWidthMin,
+		maxValue:     core.RuleWidthMax,
+		getter:       func(c *config.UserConfig) interface{} { return c.RuleWidth },
+		setter:       func(c *config.UserConfig, v interface{}) { c.RuleWidth = v.(float64) },
+		resetter:     func(c *config.UserConfig) { c.RuleWidth = 0 },
+	},
+	{
+		name:         "rule-ornament",
+		category:     categoryRules,
+		description:  "Text centered in place of the line for the ornament style",
+		keyType:      configKeyString,
+		defaultValue: "* * *",
+		getter:       func(c *config.UserConfig) interface{} { return c.RuleOrnament },
+		setter:       func(c *config.UserConfig, v interface{}) { c.RuleOrnament = v.(string) },
+		resetter:     func(c *config.UserConfig) { c.RuleOrnament = "" },
+	},
+	// Blockquotes
+	{
+		name:         "quote-bar-color",
+		category:     categoryQuotes,
+		description:  "Color of the bar left of blockquotes (hex, color name or none)",
+		keyType:      configKeyColor,
+		defaultValue: "#c8c8c8",
+		allowed:      []string{"none"},
+		getter:       func(c *config.UserConfig) interface{} { return c.QuoteStyle.BarColor },
+		setter:       func(c *config.UserConfig, v interface{}) { c.QuoteStyle.BarColor = v.(string) },
+		resetter:     func(c *config.UserConfig) { c.QuoteStyle.BarColor = "" },
+	},
+	{
+		name:         "quote-background",
+		category:     categoryQuotes,
+		description:  "Background tint behind blockquotes (hex, color name or none)",
+		keyType:      configKeyColor,
+		defaultValue: "none",
+		allowed:      []string{"none"},
+		getter:       func(c *config.UserConfig) interface{} { return c.QuoteStyle.Background },
+		setter:       func(c *config.UserConfig, v interface{}) { c.QuoteStyle.Background = v.(string) },
+		resetter:     func(c *config.UserConfig) { c.QuoteStyle.Background = "" },
+	},
+	{
+		name:         "quote-font-style",
+		category:     categoryQuotes,
+		description:  "Blockquote text style (italic, normal)",
+		keyType:      configKeyEnum,
+		defaultValue: "italic",
+		allowed:      core.ValidQuoteFontStyles,
+		getter:       func(c *config.UserConfig) interface{} { return c.QuoteStyle.FontStyle },
+		setter:       func(c *config.UserConfig, v interface{}) { c.QuoteStyle.FontStyle = v.(string) },
+		resetter:     func(c *config.UserConfig) { c.QuoteStyle.FontStyle = "" },
+	},
+	// Table of contents
+	{
+		name:         "toc",
+		category:     categoryTOC,
+		description:  "Add a table of contents with page numbers at the start of the document",
+		keyType:      configKeyBool,
+		defaultValue: false,
+		getter:       func(c *config.UserConfig) interface{} { return c.TOC },
+		setter:       func(c *config.UserConfig, v interface{}) { c.TOC = v.(bool) },
+		resetter:     func(c *config.UserConfig) { c.TOC = false },
+	},
+	{
+		name:         "toc-depth",
+		category:     categoryTOC,
+		description:  "Deepest heading level listed in the table of contents (range: 1-6)",
+		keyType:      configKeyInt,
+		defaultValue: 3,
+		minValue:     1,
+		maxValue:     6,
+		getter:       func(c *config.UserConfig) interface{} { return c.TOCDepth },
+		setter:       func(c *config.UserConfig, v interface{}) { c.TOCDepth = v.(int) },
+		resetter:     func(c *config.UserConfig) { c.TOCDepth = 0 },
+	},
+	{
+		name:         "toc-title",
+		category:     categoryTOC,
+		description:  "Title above the table of contents",
+		keyType:      configKeyString,
+		defaultValue: "Contents",
+		getter:       func(c *config.UserConfig) interface{} { return c.TOCTitle },
+		setter:       func(c *config.UserConfig, v interface{}) { c.TOCTitle = v.(string) },
+		resetter:     func(c *config.UserConfig) { c.TOCTitle = "" },
+	},
+	// Security
+	{
+		name:         "image-policy",
+		category:     categorySecurity,
+		description:  "How to handle images with active content such as scripts or appended payloads (warn, sanitize, refuse)",
+		keyType:      configKeyEnum,
+		defaultValue: "sanitize",
+		allowed:      contentscan.ValidPolicies,
+		getter:       func(c *config.UserConfig) interface{} { return c.ImagePolicy },
+		setter:       func(c *config.UserConfig, v interface{}) { c.ImagePolicy = v.(string) },
+		resetter:     func(c *config.UserConfig) { c.ImagePolicy = "" },
+	},
+
+	// Output
+	{
+		name:         "max-output-size",
+		category:     categoryOutput,
+		description:  "Split PDFs at page boundaries into numbered parts no larger than this (e.g. 10MB, empty = no limit)",
+		keyType:      configKeyByteSize,
+		defaultValue: "",
+		getter:       func(c *config.UserConfig) interface{} { return c.MaxOutputSize },
+		setter:       func(c *config.UserConfig, v interface{}) { c.MaxOutputSize = v.(string) },
+		resetter:     func(c *config.UserConfig) { c.MaxOutputSize = "" },
+	},
+	{
+		name:         "linearize",
+		category:     categoryOutput,
+		description:  "Linearize PDFs for fast web view so the first page shows while the rest downloads",
+		keyType:      configKeyBool,
+		defaultValue: false,
+		getter:       func(c *config.UserConfig) interface{} { return c.Linearize },
+		setter:       func(c *config.UserConfig, v interface{}) { c.Linearize = v.(bool) },
+		resetter:     func(c *config.UserConfig) { c.Linearize = false },
+	},
+	{
+		name:         "manifest",
+		category:     categoryOutput,
+		description:  "Attach a manifest with the hashes of the source and images to PDFs, checked by md-to-pdf verify (true, false)",
+		keyType:      configKeyBool,
+		defaultValue: false,
+		getter:       func(c *config.UserConfig) interface{} { return c.Manifest },
+		setter:       func(c *config.UserConfig, v interface{}) { c.Manifest = v.(bool) },
+		resetter:     func(c *config.UserConfig) { c.Manifest = false },
+	},
+	{
+		name:         "cache-dir",
+		category:     categoryOutput,
+		description:  "Directory of rendered PDFs reused when the markdown, images, settings and plugins are unchanged (empty = no cache)",
+		keyType:      configKeyString,
+		defaultValue: "",
+		getter:       func(c *config.UserConfig) interface{} { return c.CacheDir },
+		setter:       func(c *config.UserConfig, v interface{}) { c.CacheDir = v.(string) },
+		resetter:     func(c *config.UserConfig) { c.CacheDir = "" },
+	},
+	{
+		name:         "summary-page",
+		category:     categoryOutput,
+		description:  "Add a closing page with document statistics, a config fingerprint and a QR link to the source",
+		keyType:      configKeyBool,
+		defaultValue: false,
+		getter:       func(c *config.UserConfig) interface{} { return c.SummaryPage },
+		setter:       func(c *config.UserConfig, v interface{}) { c.SummaryPage = v.(bool) },
+		resetter:     func(c *config.UserConfig) { c.SummaryPage = false },
+	},
+	{
+		name:         "summary-repo-url",
+		category:     categoryOutput,
+		description:  "Repository URL for the summary page QR code (empty = git remote of the input)",
+		keyType:      configKeyString,
+		defaultValue: "",
+		getter:       func(c *config.UserConfig) interface{} { return c.SummaryRepoURL },
+		setter:       func(c *config.UserConfig, v interface{}) { c.SummaryRepoURL = v.(string) },
+		resetter:     func(c *config.UserConfig) { c.SummaryRepoURL = "" },
+	},
+	{
+		name:         "source-appendix",
+		category:     categoryOutput,
+		description:  "Append the markdown source as a line-numbered appendix (true, false)",
+		keyType:      configKeyBool,
+		defaultValue: false,
+		getter:       func(c *config.UserConfig) interface{} { return c.SourceAppendix },
+		setter:       func(c *config.UserConfig, v interface{}) { c.SourceAppendix = v.(bool) },
+		resetter:     func(c *config.UserConfig) { c.SourceAppendix = false },
+	},
+	{
+		name:         "output-mode",
+		category:     categoryOutput,
+		description:  "Octal permissions of written PDFs, less the umask (e.g. 0640, empty = 0600)",
+		keyType:      configKeyFileMode,
+		defaultValue: "",
+		getter:       func(c *config.UserConfig) interface{} { return c.OutputMode },
+		setter:       func(c *config.UserConfig, v interface{}) { c.OutputMode = v.(string) },
+		resetter:     func(c *config.UserConfig) { c.OutputMode = "" },
+	},
+	{
+		name:         "on-collision",
+		category:     categoryOutput,
+		description:  "When inputs of a batch derive the same PDF name: fail, rename the later PDF after its directory, or overwrite (error, rename, overwrite)",
+		keyType:      configKeyEnum,
+		defaultValue: "error",
+		allowed:      core.ValidCollisionPolicies,
+		getter:       func(c *config.UserConfig) interface{} { return c.OnCollision },
+		setter:       func(c *config.UserConfig, v interface{}) { c.OnCollision = v.(string) },
+		resetter:     func(c *config.UserConfig) { c.OnCollision = "" },
+	},
+	{
+		name:         "preserve-mode",
+		category:     categoryOutput,
+		description:  "Give PDFs the permissions, and where allowed the owner and group, of their input file (true, false)",
+		keyType:      configKeyBool,
+		defaultValue: false,
+		getter:       func(c *config.UserConfig) interface{} { return c.PreserveMode },
+		setter:       func(c *config.UserConfig, v interface{}) { c.PreserveMode = v.(bool) },
+		resetter:     func(c *config.UserConfig) { c.PreserveMode = false },
+	},
+
+	// Network
+	{
+		name:         "network-timeout",
+		category:     categoryNetwork,
+		description:  "Seconds a request for a remote resource may take (range: 1-600)",
+		keyType:      configKeyInt,
+		defaultValue: 30,
+		minValue:     1,
+		maxValue:     core.MaxNetworkTimeout,
+		getter:       func(c *config.UserConfig) interface{} { return c.NetworkTimeout },
+		setter:       func(c *config.UserConfig, v interface{}) { c.NetworkTimeout = v.(int) },
+		resetter:     func(c *config.UserConfig) { c.NetworkTimeout = 0 },
+	},
+	{
+		name:         "proxy",
+		category:     categoryNetwork,
+		description:  "Proxy URL for remote resources (empty = HTTP_PROXY and HTTPS_PROXY)",
+		keyType:      configKeyString,
+		defaultValue: "",
+		getter:       func(c *config.UserConfig) interface{} { return c.Proxy },
+		setter:       func(c *config.UserConfig, v interface{}) { c.Proxy = v.(string) },
+		resetter:     func(c *config.UserConfig) { c.Proxy = "" },
+	},
+	{
+		name:         "offline",
+		category:     categoryNetwork,
+		description:  "Use only previously fetched remote resources, without network access (true, false)",
+		keyType:      configKeyBool,
+		defaultValue: false,
+		getter:       func(c *config.UserConfig) interface{} { return c.Offline },
+		setter:       func(c *config.UserConfig, v interface{}) { c.Offline = v.(bool) },
+		resetter:     func(c *config.UserConfig) { c.Offline = false },
+	},
+	{
+		name:         "network-rate-limit",
+		category:     categoryNetwork,
+		description:  "Most requests per second for remote resources (range: 0-1000, 0 = unlimited)",
+		keyType:      configKeyFloat64,
+		defaultValue: 0.0,
+		minValue:     0,
+		maxValue:     core.MaxNetworkRateLimit,
+		getter:       func(c *config.UserConfig) interface{} { return c.NetworkRateLimit },
+		setter:       func(c *config.UserConfig, v interface{}) { c.NetworkRateLimit = v.(float64) },
+		resetter:     func(c *config.UserConfig) { c.NetworkRateLimit = 0 },
+	},
+	{
+		name:         "network-attempts",
+		category:     categoryNetwork,
+		description:  "Times a failed request is sent, waiting longer before each retry (range: 1-10)",
+		keyType:      configKeyInt,
+		defaultValue: 3,
+		minValue:     1,
+		maxValue:     core.MaxNetworkAttempts,
+		getter:       func(c *config.UserConfig) interface{} { return c.NetworkAttempts },
+		setter:       func(c *config.UserConfig, v interface{}) { c.NetworkAttempts = v.(int) },
+		resetter:     func(c *config.UserConfig) { c.NetworkAttempts = 0 },
+	},
+	{
+		name:         "network-concurrency",
+		category:     categoryNetwork,
+		description:  "Most remote resources downloaded at once (range: 1-32)",
+		keyType:      configKeyInt,
+		defaultValue: 4,
+		minValue:     1,
+		maxValue:     core.MaxNetworkConcurrency,
+		getter:       func(c *config.UserConfig) interface{} { return c.NetworkConcurrency },
+		setter:       func(c *config.UserConfig, v interface{}) { c.NetworkConcurrency = v.(int) },
+		resetter:     func(c *config.UserConfig) { c.NetworkConcurrency = 0 },
+	},
 }
 
 // findConfigKey looks up a config key definition by name.
@@ -280,9 +873,18 @@ func getKeysByCategory() map[configCategory][]configKeyDef {
 var categoryOrder = []configCategory{
 	categoryTypography,
 	categoryCode,
+	categoryColors,
 	categoryPage,
 	categoryMetadata,
 	categoryMermaid,
+	categoryHeader,
+	categoryBanner,
+	categoryRules,
+	categoryQuotes,
+	categoryTOC,
+	categorySecurity,
+	categoryOutput,
+	categoryNetwork,
 }
 
 var configCmd = &cobra.Command{
@@ -303,37 +905,16 @@ var configListCmd = &cobra.Command{
 		fmt.Println("Current configuration:")
 		fmt.Printf("Config file: %s\n\n", config.GetConfigPath())
 
-		// Typography & Fonts
-		fmt.Println("Typography & Fonts:")
-		printConfigValueFromKey(userConfig, "font-family")
-		printConfigValueFromKey(userConfig, "font-size")
-		printConfigValueFromKey(userConfig, "heading-scale")
-		printConfigValueFromKey(userConfig, "line-spacing")
-
-		// Code styling
-		fmt.Println("\nCode Styling:")
-		printConfigValueFromKey(userConfig, "code-font")
-		printConfigValueFromKey(userConfig, "code-size")
-
-		// Page layout
-		fmt.Println("\nPage Layout:")
-		printConfigValueFromKey(userConfig, "page-size")
-		printConfigValueFromKey(userConfig, "margin-top")
-		printConfigValueFromKey(userConfig, "margin-bottom")
-		printConfigValueFromKey(userConfig, "margin-left")
-		printConfigValueFromKey(userConfig, "margin-right")
-
-		// PDF metadata
-		fmt.Println("\nPDF Metadata:")
-		printConfigValueFromKey(userConfig, "title")
-		printConfigValueFromKey(userConfig, "author")
-		printConfigValueFromKey(userConfig, "subject")
-
-		// Mermaid settings
-		fmt.Println("\nMermaid Settings:")
-		printConfigValueFromKey(userConfig, "mermaid-scale")
-		printConfigValueFromKey(userConfig, "mermaid-max-width")
-		printConfigValueFromKey(userConfig, "mermaid-max-height")
+		keysByCategory := getKeysByCategory()
+		for i, cat := range categoryOrder {
+			if i > 0 {
+				fmt.Println()
+			}
+			fmt.Printf("%s:\n", cat)
+			for _, k := range keysByCategory[cat] {
+				printConfigValueFromKey(userConfig, k.name)
+			}
+		}
 
 		return nil
 	},
@@ -363,10 +944,32 @@ var configSetCmd = &cobra.Command{
 		}
 
 		fmt.Printf("Set %s = %s\n", key, value)
+		warnSavedColorIssues(ui.NewOutput())
 		return nil
 	},
 }
 
+// warnSavedColorIssues warns about the colors of the saved configuration,
+// with its theme applied, that would be hard to read. Colors are still
+// saved: a darker or lighter companion color may be set next.
+func warnSavedColorIssues(output *ui.Output) {
+	userConfig, err := config.LoadResolvedUserConfig()
+	if err != nil {
+		return
+	}
+	cfg := core.DefaultConfig()
+	config.ApplyUserConfig(cfg, userConfig)
+	warnColorIssues(output, cfg)
+}
+
+// warnColorIssues warns about the configured color pairs whose contrast is
+// too low to read comfortably.
+func warnColorIssues(output *ui.Output, cfg *core.Config) {
+	for _, issue := range core.ColorIssues(cfg) {
+		output.Warnf("configuration: %s", issue)
+	}
+}
+
 var configResetCmd = &cobra.Command{
 	Use:   "reset [key]",
 	Short: "Reset configuration to defaults",
@@ -405,6 +1008,80 @@ var configResetCmd = &cobra.Command{
 	},
 }
 
+// Flags of the import command
+var (
+	configImportFrom   string
+	configImportDryRun bool
+)
+
+var configImportCmd = &cobra.Command{
+	Use:   "import --from <tool> <file>",
+	Short: "Import settings from another tool's configuration",
+	Long: `Map the layout, font, table of contents and metadata options of another
+tool's configuration to md-to-pdf configuration keys and save them:
+
+  pandoc  a defaults file (pandoc --defaults)
+  mdpdf   mdpdf options as JSON or YAML
+
+Options without an md-to-pdf equivalent are listed and skipped.`,
+	Example: "  md-to-pdf config import --from pandoc defaults.yaml\n  md-to-pdf config import --from mdpdf mdpdf.json --dry-run",
+	Args:    cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		data, err := os.ReadFile(args[0]) // #nosec G304 - file named by the user
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", args[0], err)
+		}
+		result, err := config.ImportConfig(configImportFrom, data)
+		if err != nil {
+			return err
+		}
+
+		userConfig, err := config.LoadUserConfig()
+		if err != nil {
+			return err
+		}
+		applied, skipped := importSettings(userConfig, result)
+
+		output := ui.NewOutput()
+		verb := "Set"
+		if configImportDryRun {
+			verb = "Would set"
+		}
+		for _, setting := range applied {
+			fmt.Printf("%s %s = %s (from %s)\n", verb, setting.Key, setting.Value, setting.Source)
+		}
+		for _, reason := range skipped {
+			output.Warn("skipped %s", reason)
+		}
+		if configImportDryRun {
+			output.Info("Dry run: %s was not changed", config.GetConfigPath())
+			return nil
+		}
+		if len(applied) == 0 {
+			return fmt.Errorf("%s has no options md-to-pdf can import", args[0])
+		}
+		return config.SaveUserConfig(userConfig)
+	},
+}
+
+// importSettings sets the imported settings that pass validation, as
+// "config set" would, and returns them with the reasons for skipping the
+// others.
+func importSettings(userConfig *config.UserConfig, result *config.ImportResult) ([]config.ImportedSetting, []string) {
+	var applied []config.ImportedSetting
+	skipped := result.Ignored
+	for _, setting := range result.Settings {
+		if err := setConfigValue(userConfig, setting.Key, setting.Value); err != nil {
+			// Only the first line: unknown keys list the valid ones
+			message, _, _ := strings.Cut(err.Error(), "\n")
+			skipped = append(skipped, fmt.Sprintf("%s: %s", setting.Source, message))
+			continue
+		}
+		applied = append(applied, setting)
+	}
+	return applied, skipped
+}
+
 // configKeysJSONMode tracks whether to output JSON format
 var configKeysJSONMode bool
 
@@ -459,6 +1136,7 @@ type configKeyJSON struct {
 	DefaultValue interface{} `json:"default"`
 	MinValue     *float64    `json:"min,omitempty"`
 	MaxValue     *float64    `json:"max,omitempty"`
+	Values       []string    `json:"values,omitempty"`
 }
 
 // printConfigKeysJSON outputs configuration keys in JSON format.
@@ -484,8 +1162,26 @@ func printConfigKeysJSON() error {
 			maxVal := k.maxValue
 			keyJSON.MinValue = &minVal
 			keyJSON.MaxValue = &maxVal
+		case configKeyInt:
+			keyJSON.Type = "integer"
+			minVal := k.minValue
+			maxVal := k.maxValue
+			keyJSON.MinValue = &minVal
+			keyJSON.MaxValue = &maxVal
 		case configKeyPageSize:
 			keyJSON.Type = "enum"
+			keyJSON.Values = core.ValidPageSizes
+		case configKeyEnum:
+			keyJSON.Type = "enum"
+			keyJSON.Values = k.allowed
+		case configKeyBool:
+			keyJSON.Type = "boolean"
+		case configKeyByteSize:
+			keyJSON.Type = "size"
+		case configKeyColor:
+			keyJSON.Type = "color"
+		case configKeyFileMode:
+			keyJSON.Type = "mode"
 		}
 
 		keys = append(keys, keyJSON)
@@ -540,6 +1236,8 @@ func isZeroValue(value interface{}) bool {
 		return v == 0
 	case int:
 		return v == 0
+	case bool:
+		return !v
 	default:
 		return false
 	}
@@ -565,16 +1263,68 @@ func setConfigValue(userConfig *config.UserConfig, key, value string) error {
 		}
 		keyDef.setter(userConfig, v)
 
+	case configKeyInt:
+		v, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("invalid %s: %s (must be a whole number)", key, value)
+		}
+		if float64(v) < keyDef.minValue || float64(v) > keyDef.maxValue {
+			return fmt.Errorf("%s must be between %d and %d, got %d", key, int(keyDef.minValue), int(keyDef.maxValue), v)
+		}
+		keyDef.setter(userConfig, v)
+
 	case configKeyPageSize:
 		if !core.IsValidPageSize(value) {
 			return fmt.Errorf("invalid page-size: %s (valid: %s)", value, core.ValidPageSizesString())
 		}
 		keyDef.setter(userConfig, value)
+
+	case configKeyEnum:
+		if !containsString(keyDef.allowed, value) {
+			return fmt.Errorf("invalid %s: %s (valid: %s)", key, value, strings.Join(keyDef.allowed, ", "))
+		}
+		keyDef.setter(userConfig, value)
+
+	case configKeyBool:
+		v, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("invalid %s: %s (must be true or false)", key, value)
+		}
+		keyDef.setter(userConfig, v)
+
+	case configKeyByteSize:
+		if _, err := core.ParseByteSize(value); err != nil {
+			return fmt.Errorf("invalid %s: %s (must be a size like 10MB or 500KB)", key, value)
+		}
+		keyDef.setter(userConfig, value)
+
+	case configKeyColor:
+		// Some color keys also accept keywords such as "none"
+		if !colorutil.IsValid(value) && !containsString(keyDef.allowed, value) {
+			return fmt.Errorf("invalid %s: %s (must be a hex color like #c8c8c8 or a color name)", key, value)
+		}
+		keyDef.setter(userConfig, value)
+
+	case configKeyFileMode:
+		if _, err := core.ParseFileMode(value); err != nil {
+			return fmt.Errorf("invalid %s: %s (must be an octal permission like 0640)", key, value)
+		}
+		keyDef.setter(userConfig, value)
 	}
 
 	return nil
 }
 
+// containsString reports whether values contains s.
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
 func resetConfigValue(userConfig *config.UserConfig, key string) error {
 	keyDef := findConfigKey(key)
 	if keyDef == nil {
@@ -598,7 +1348,12 @@ func init() {
 	configCmd.AddCommand(configSetCmd)
 	configCmd.AddCommand(configResetCmd)
 	configCmd.AddCommand(configKeysCmd)
+	configCmd.AddCommand(configImportCmd)
 
 	// Add --json flag to keys command
 	configKeysCmd.Flags().BoolVar(&configKeysJSONMode, "json", false, "Output in JSON format")
+
+	configImportCmd.Flags().StringVar(&configImportFrom, "from", "", "Tool the configuration comes from: "+strings.Join(config.ImportFormats, ", "))
+	configImportCmd.Flags().BoolVar(&configImportDryRun, "dry-run", false, "Show the settings without saving them")
+	_ = configImportCmd.MarkFlagRequired("from")
 }
@@ -141,6 +141,14 @@ func TestSetConfigValue_ValidValues(t *testing.T) {
 				return c.Subject == "Test Subject"
 			},
 		},
+		{
+			name:  "date-format",
+			key:   "date-format",
+			value: "02.01.2006",
+			validate: func(c *config.UserConfig) bool {
+				return c.DateFormat == "02.01.2006"
+			},
+		},
 		// Mermaid settings
 		{
 			name:  "mermaid-scale",
@@ -800,3 +808,172 @@ func TestCategoryOrder(t *testing.T) {
 		}
 	}
 }
+
+func TestSetConfigValue_EnumKeys(t *testing.T) {
+	tests := []struct {
+		name      string
+		key       string
+		value     string
+		expectErr bool
+	}{
+		{"valid_header_align", "header-align", "right", false},
+		{"valid_footer_align", "footer-align", "center", false},
+		{"invalid_header_align", "header-align", "middle", true},
+		{"invalid_footer_align", "footer-align", "Left", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			userConfig := &config.UserConfig{}
+			err := setConfigValue(userConfig, tt.key, tt.value)
+			if (err != nil) != tt.expectErr {
+				t.Errorf("setConfigValue(%s, %s) error = %v, expectErr %v", tt.key, tt.value, err, tt.expectErr)
+			}
+			if err != nil && !strings.Contains(err.Error(), "left, center, right") {
+				t.Errorf("error should list valid values, got: %v", err)
+			}
+		})
+	}
+}
+
+func TestSetConfigValue_ImagePolicy(t *testing.T) {
+	userConfig := &config.UserConfig{}
+	if err := setConfigValue(userConfig, "image-policy", "refuse"); err != nil {
+		t.Fatalf("setConfigValue(image-policy, refuse) failed: %v", err)
+	}
+	if userConfig.ImagePolicy != "refuse" {
+		t.Errorf("ImagePolicy = %q, want %q", userConfig.ImagePolicy, "refuse")
+	}
+
+	err := setConfigValue(userConfig, "image-policy", "ignore")
+	if err == nil || !strings.Contains(err.Error(), "warn, sanitize, refuse") {
+		t.Errorf("expected error listing valid policies, got %v", err)
+	}
+}
+
+func TestSetConfigValue_BaselineGrid(t *testing.T) {
+	userConfig := &config.UserConfig{}
+	if err := setConfigValue(userConfig, "baseline-grid", "true"); err != nil {
+		t.Fatalf("setConfigValue(baseline-grid, true) failed: %v", err)
+	}
+	if !userConfig.BaselineGrid {
+		t.Error("BaselineGrid should be enabled")
+	}
+
+	err := setConfigValue(userConfig, "baseline-grid", "sometimes")
+	if err == nil || !strings.Contains(err.Error(), "must be true or false") {
+		t.Errorf("expected boolean parse error, got %v", err)
+	}
+}
+
+func TestSetConfigValue_RuleColor(t *testing.T) {
+	userConfig := &config.UserConfig{}
+	if err := setConfigValue(userConfig, "rule-color", "#336699"); err != nil {
+		t.Fatalf("setConfigValue(rule-color, #336699) failed: %v", err)
+	}
+	if userConfig.RuleColor != "#336699" {
+		t.Errorf("RuleColor = %q, want %q", userConfig.RuleColor, "#336699")
+	}
+
+	err := setConfigValue(userConfig, "rule-color", "#33669")
+	if err == nil || !strings.Contains(err.Error(), "must be a hex color") {
+		t.Errorf("expected color parse error, got %v", err)
+	}
+}
+
+func TestSetConfigValue_QuoteColors(t *testing.T) {
+	userConfig := &config.UserConfig{}
+	if err := setConfigValue(userConfig, "quote-bar-color", "none"); err != nil {
+		t.Fatalf("setConfigValue(quote-bar-color, none) failed: %v", err)
+	}
+	if err := setConfigValue(userConfig, "quote-background", "#f4f4f4"); err != nil {
+		t.Fatalf("setConfigValue(quote-background, #f4f4f4) failed: %v", err)
+	}
+	if userConfig.QuoteStyle.BarColor != "none" || userConfig.QuoteStyle.Background != "#f4f4f4" {
+		t.Errorf("QuoteStyle = %+v", userConfig.QuoteStyle)
+	}
+
+	// Only the quote colors can be turned off
+	if err := setConfigValue(userConfig, "rule-color", "none"); err == nil {
+		t.Error("expected rule-color to reject none")
+	}
+}
+
+func TestSetConfigValue_MaxOutputSize(t *testing.T) {
+	userConfig := &config.UserConfig{}
+	if err := setConfigValue(userConfig, "max-output-size", "10MB"); err != nil {
+		t.Fatalf("setConfigValue(max-output-size, 10MB) failed: %v", err)
+	}
+	if userConfig.MaxOutputSize != "10MB" {
+		t.Errorf("MaxOutputSize = %q, want %q", userConfig.MaxOutputSize, "10MB")
+	}
+
+	err := setConfigValue(userConfig, "max-output-size", "huge")
+	if err == nil || !strings.Contains(err.Error(), "must be a size") {
+		t.Errorf("expected size parse error, got %v", err)
+	}
+}
+
+func TestSetConfigValue_TOCDepth(t *testing.T) {
+	userConfig := &config.UserConfig{}
+	if err := setConfigValue(userConfig, "toc-depth", "2"); err != nil {
+		t.Fatalf("setConfigValue(toc-depth, 2) failed: %v", err)
+	}
+	if userConfig.TOCDepth != 2 {
+		t.Errorf("TOCDepth = %d, want 2", userConfig.TOCDepth)
+	}
+
+	err := setConfigValue(userConfig, "toc-depth", "2.5")
+	if err == nil || !strings.Contains(err.Error(), "must be a whole number") {
+		t.Errorf("expected integer parse error, got %v", err)
+	}
+	err = setConfigValue(userConfig, "toc-depth", "7")
+	if err == nil || !strings.Contains(err.Error(), "between 1 and 6") {
+		t.Errorf("expected range error, got %v", err)
+	}
+}
+
+func TestSetConfigValue_HeaderFooter(t *testing.T) {
+	userConfig := &config.UserConfig{}
+
+	if err := setConfigValue(userConfig, "header", "**{title}**"); err != nil {
+		t.Fatalf("setConfigValue(header) failed: %v", err)
+	}
+	if err := setConfigValue(userConfig, "footer", "Page {page}"); err != nil {
+		t.Fatalf("setConfigValue(footer) failed: %v", err)
+	}
+
+	if userConfig.Header != "**{title}**" {
+		t.Errorf("Header = %q, want %q", userConfig.Header, "**{title}**")
+	}
+	if userConfig.Footer != "Page {page}" {
+		t.Errorf("Footer = %q, want %q", userConfig.Footer, "Page {page}")
+	}
+
+	if err := resetConfigValue(userConfig, "header"); err != nil {
+		t.Fatalf("resetConfigValue(header) failed: %v", err)
+	}
+	if userConfig.Header != "" {
+		t.Errorf("Header should be empty after reset, got %q", userConfig.Header)
+	}
+}
+
+func TestImportSettings(t *testing.T) {
+	result, err := config.ImportConfig("pandoc", []byte("toc-depth: 9\nvariables:\n  papersize: a4\n  linestretch: 1.5\n"))
+	if err != nil {
+		t.Fatalf("ImportConfig() error: %v", err)
+	}
+
+	userConfig := &config.UserConfig{}
+	applied, skipped := importSettings(userConfig, result)
+	if len(applied) != 2 || userConfig.PageSize != "A4" || userConfig.LineSpacing != 1.5 {
+		t.Errorf("applied = %v, config = %+v, want page size and line spacing", applied, userConfig)
+	}
+	// Imported values are validated like "config set" values
+	if len(skipped) != 1 || !strings.Contains(skipped[0], "toc-depth: toc-depth must be between 1 and 6") {
+		t.Errorf("skipped = %v, want the out of range toc-depth", skipped)
+	}
+	if userConfig.TOCDepth != 0 {
+		t.Errorf("TOCDepth = %d, want it unset", userConfig.TOCDepth)
+	}
+}
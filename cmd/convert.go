@@ -2,18 +2,28 @@ package cmd
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"os/signal"
 	"path/filepath"
+	"sort"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 
+	"github.com/fredcamaral/md-to-pdf/internal/accessibility"
 	"github.com/fredcamaral/md-to-pdf/internal/config"
 	"github.com/fredcamaral/md-to-pdf/internal/core"
+	"github.com/fredcamaral/md-to-pdf/internal/inputs"
+	"github.com/fredcamaral/md-to-pdf/internal/network"
 	"github.com/fredcamaral/md-to-pdf/internal/output"
+	"github.com/fredcamaral/md-to-pdf/internal/parser"
+	"github.com/fredcamaral/md-to-pdf/internal/plugins"
+	"github.com/fredcamaral/md-to-pdf/internal/profile"
 	"github.com/fredcamaral/md-to-pdf/internal/ui"
 	"github.com/fredcamaral/md-to-pdf/internal/watcher"
 	"github.com/spf13/cobra"
@@ -25,11 +35,14 @@ type convertCommand struct {
 	outputPath string
 	pluginDir  string
 	verbose    bool
+	orderFile  string
 
 	// Typography & Fonts
 	fontFamily   string
 	fontSize     float64
 	headingScale float64
+	headingMin   float64
+	headingShift int
 	lineSpacing  float64
 
 	// Code styling
@@ -42,14 +55,106 @@ type convertCommand struct {
 	marginBottom float64
 	marginLeft   float64
 	marginRight  float64
+	baselineGrid bool
+	sidenoteSide string
+
+	// Heading widow control
+	keepWithNext         string
+	minLinesAfterHeading int
 
 	// PDF metadata
-	title   string
-	author  string
-	subject string
+	title       string
+	author      string
+	subject     string
+	titleFromH1 bool
 
 	// Mermaid settings
-	mermaidScale float64
+	mermaidScale        float64
+	mermaidTheme        string
+	mermaidWideStrategy string
+
+	// Header & footer
+	header      string
+	footer      string
+	headerAlign string
+	footerAlign string
+
+	// Horizontal rules
+	ruleStyle     string
+	ruleThickness float64
+	ruleColor     string
+	ruleWidth     float64
+	ruleOrnament  string
+
+	// Blockquotes
+	quoteBarColor   string
+	quoteBackground string
+	quoteFontStyle  string
+
+	// Classification banner
+	banner           string
+	bannerPosition   string
+	bannerColor      string
+	bannerBackground string
+
+	// Table of contents
+	toc      bool
+	tocDepth int
+	tocTitle string
+
+	// Review artifacts
+	outlineOut     string
+	contactSheet   string
+	sourceAppendix bool
+	sourcePDF      string
+
+	// Output size
+	maxOutputSize string
+
+	// Fast web view
+	linearize bool
+
+	// Source manifest
+	manifest bool
+
+	// Render cache
+	cacheDir string
+
+	// Output permissions
+	outputMode   string
+	preserveMode bool
+
+	// Output path collisions
+	onCollision string
+
+	// Summary page
+	summaryPage    bool
+	summaryRepoURL string
+
+	// Multi-language builds
+	locales    []string
+	dateFormat string
+
+	// Conditional content
+	defines []string
+
+	// Security
+	imagePolicy string
+
+	// Network
+	networkTimeout     int
+	proxy              string
+	offline            bool
+	networkRateLimit   float64
+	networkAttempts    int
+	networkConcurrency int
+
+	// Accessibility
+	check bool
+
+	// Diagnostics
+	profileKind string
+	profileOut  string
 
 	// New features
 	watch    bool
@@ -67,14 +172,35 @@ func newConvertCommand() *cobra.Command {
 
 Use "-" as input to read from stdin (requires --output flag).
 
+Glob patterns (*, ?, [...] and ** for any depth) are expanded natively, so
+quoting them works in every shell. Matches are converted in natural order
+(ch2.md before ch10.md); --order-file lists files in an explicit order.
+
 Examples:
   md-to-pdf convert document.md
   md-to-pdf convert doc1.md doc2.md
+  md-to-pdf convert "chapters/*.md"
+  md-to-pdf convert --order-file chapters/ORDER
   md-to-pdf convert document.md -o output.pdf
   md-to-pdf convert document.md --watch
   echo "# Hello" | md-to-pdf convert - -o hello.pdf
-  md-to-pdf convert document.md --json`,
-		Args: cobra.MinimumNArgs(1),
+  md-to-pdf convert document.md --json
+  md-to-pdf convert document.md --outline-out outline.json
+  md-to-pdf convert document.md --contact-sheet pages.png
+  md-to-pdf convert document.md --locales en,de
+  md-to-pdf convert report.md --max-output-size 10MB
+  md-to-pdf convert manual.md --linearize
+  md-to-pdf convert "docs/*.md" --cache-dir .md-to-pdf-cache
+  md-to-pdf convert manual.md --toc --toc-depth 2
+  md-to-pdf convert report.md --summary-page
+  md-to-pdf convert large.md --profile cpu
+  md-to-pdf convert "docs/*.md" --check --json`,
+		Args: func(_ *cobra.Command, args []string) error {
+			if len(args) == 0 && c.orderFile == "" {
+				return fmt.Errorf("requires at least one input file, glob pattern or --order-file")
+			}
+			return nil
+		},
 		RunE: c.run,
 	}
 
@@ -82,11 +208,14 @@ Examples:
 	cmd.Flags().StringVarP(&c.outputPath, "output", "o", "", "Output PDF file path")
 	cmd.Flags().StringVarP(&c.pluginDir, "plugins", "p", "./plugins", "Plugin directory path")
 	cmd.Flags().BoolVarP(&c.verbose, "verbose", "v", false, "Enable verbose output")
+	cmd.Flags().StringVar(&c.orderFile, "order-file", "", "File listing input files one per line, in conversion order (relative to the file)")
 
 	// Typography & Fonts
 	cmd.Flags().StringVar(&c.fontFamily, "font-family", "", "Font family (Arial, Times, Helvetica, etc.)")
 	cmd.Flags().Float64Var(&c.fontSize, "font-size", 0, "Base font size in points")
 	cmd.Flags().Float64Var(&c.headingScale, "heading-scale", 0, "Heading size multiplier (e.g., 1.5 = 50% bigger)")
+	cmd.Flags().Float64Var(&c.headingMin, "heading-min-size", 0, "Smallest font size long headings may shrink to before wrapping (0 = wrap only)")
+	cmd.Flags().IntVar(&c.headingShift, "shift-headings", 0, "Demote every heading by this many levels (negative promotes), e.g. 1 turns # into ##")
 	cmd.Flags().Float64Var(&c.lineSpacing, "line-spacing", 0, "Line spacing multiplier (e.g., 1.2 = 20% spacing)")
 
 	// Code styling
@@ -99,14 +228,104 @@ Examples:
 	cmd.Flags().Float64Var(&c.marginBottom, "margin-bottom", 0, "Bottom margin in mm")
 	cmd.Flags().Float64Var(&c.marginLeft, "margin-left", 0, "Left margin in mm")
 	cmd.Flags().Float64Var(&c.marginRight, "margin-right", 0, "Right margin in mm")
+	cmd.Flags().BoolVar(&c.baselineGrid, "baseline-grid", false, "Snap block spacing to multiples of the line height for a consistent vertical rhythm")
+	cmd.Flags().StringVar(&c.sidenoteSide, "sidenote-side", "", "Margin for ^[sidenotes]: outer (right on odd pages, left on even), right or left")
+	cmd.Flags().StringVar(&c.keepWithNext, "keep-with-next", "", "Keep headings on the page of the text after them (headings, none)")
+	cmd.Flags().IntVar(&c.minLinesAfterHeading, "min-lines-after-heading", 0, "Lines of text that must fit below a heading to keep it on its page (1-10, default 1)")
 
 	// PDF metadata
 	cmd.Flags().StringVar(&c.title, "title", "", "PDF document title")
 	cmd.Flags().StringVar(&c.author, "author", "", "PDF document author")
 	cmd.Flags().StringVar(&c.subject, "subject", "", "PDF document subject")
+	cmd.Flags().BoolVar(&c.titleFromH1, "title-from-h1", false, "Use a leading H1 as the title and output file name, removing it from the body")
 
 	// Mermaid settings
 	cmd.Flags().Float64Var(&c.mermaidScale, "mermaid-scale", 0, "Mermaid diagram scale factor (e.g., 1.0=original size, 2.2=default size, 3.0=even bigger)")
+	cmd.Flags().StringVar(&c.mermaidTheme, "mermaid-theme", "", "Diagram variant for light or dark documents: light (default) or dark, picking name.dark.png over name.png")
+	cmd.Flags().StringVar(&c.mermaidWideStrategy, "mermaid-wide-strategy", "", "Placement of diagrams too wide for the text column: shrink (default), rotate or landscape")
+
+	// Header & footer
+	cmd.Flags().StringVar(&c.header, "header", "", "Markdown snippet for the page header (supports {page}, {pages}, {title}, {author}, {date})")
+	cmd.Flags().StringVar(&c.footer, "footer", "", "Markdown snippet for the page footer (same variables as --header)")
+	cmd.Flags().StringVar(&c.headerAlign, "header-align", "", "Header alignment (left, center, right)")
+	cmd.Flags().StringVar(&c.footerAlign, "footer-align", "", "Footer alignment (left, center, right)")
+
+	// Horizontal rules
+	cmd.Flags().StringVar(&c.ruleStyle, "rule-style", "", "Horizontal rule style (solid, dashed, dotted, ornament)")
+	cmd.Flags().Float64Var(&c.ruleThickness, "rule-thickness", 0, "Horizontal rule line width in mm")
+	cmd.Flags().StringVar(&c.ruleColor, "rule-color", "", "Horizontal rule color (hex like #c8c8c8 or a color name)")
+	cmd.Flags().Float64Var(&c.ruleWidth, "rule-width", 0, "Horizontal rule width as a percentage of the text width")
+	cmd.Flags().StringVar(&c.ruleOrnament, "rule-ornament", "", "Text centered in place of the line for --rule-style ornament (default \"* * *\")")
+
+	// Blockquotes
+	cmd.Flags().StringVar(&c.quoteBarColor, "quote-bar-color", "", "Color of the bar left of blockquotes (hex, color name or none)")
+	cmd.Flags().StringVar(&c.quoteBackground, "quote-background", "", "Background tint behind blockquotes (hex, color name or none)")
+	cmd.Flags().StringVar(&c.quoteFontStyle, "quote-font-style", "", "Blockquote text style (italic, normal)")
+
+	// Classification banner
+	cmd.Flags().StringVar(&c.banner, "banner", "", "Classification banner stamped on every page, e.g. CONFIDENTIAL (same variables as --header)")
+	cmd.Flags().StringVar(&c.bannerPosition, "banner-position", "", "Page edges the banner is stamped on (top, bottom, both)")
+	cmd.Flags().StringVar(&c.bannerColor, "banner-color", "", "Banner text color (hex or color name, default body text color)")
+	cmd.Flags().StringVar(&c.bannerBackground, "banner-background", "", "Color of the band behind the banner (hex, color name or none)")
+
+	// Table of contents
+	cmd.Flags().BoolVar(&c.toc, "toc", false, "Add a table of contents with page numbers (or place it with a <!-- toc --> marker)")
+	cmd.Flags().IntVar(&c.tocDepth, "toc-depth", 0, "Deepest heading level listed in the table of contents (1-6, default 3)")
+	cmd.Flags().StringVar(&c.tocTitle, "toc-title", "", "Title above the table of contents (default \"Contents\")")
+
+	// Review artifacts
+	cmd.Flags().StringVar(&c.outlineOut, "outline-out", "", "Write the heading outline with page numbers to this file (.json, .yaml or .yml)")
+	cmd.Flags().StringVar(&c.contactSheet, "contact-sheet", "", "Write a PNG contact sheet of page thumbnails to this file")
+	cmd.Flags().BoolVar(&c.sourceAppendix, "source-appendix", false, "Append the markdown source as a line-numbered appendix")
+	cmd.Flags().StringVar(&c.sourcePDF, "source-pdf", "", "Write the markdown source as a line-numbered PDF to this file")
+
+	// Output size
+	cmd.Flags().StringVar(&c.maxOutputSize, "max-output-size", "", "Split the PDF at page boundaries into numbered parts no larger than this (e.g. 10MB)")
+
+	// Fast web view
+	cmd.Flags().BoolVar(&c.linearize, "linearize", false, "Linearize the PDF for fast web view (first page shows before the download completes)")
+
+	// Source manifest
+	cmd.Flags().BoolVar(&c.manifest, "manifest", false, "Attach a manifest with the hashes of the source and images, checked by md-to-pdf verify")
+
+	// Render cache
+	cmd.Flags().StringVar(&c.cacheDir, "cache-dir", "", "Reuse PDFs rendered from identical inputs, kept in this directory")
+
+	// Output permissions
+	cmd.Flags().StringVar(&c.outputMode, "output-mode", "", "Octal permissions of written PDFs, less the umask (e.g. 0640, default 0600)")
+	cmd.Flags().BoolVar(&c.preserveMode, "preserve-mode", false, "Give each PDF the permissions, and where allowed the owner and group, of its input file")
+
+	// Output path collisions
+	cmd.Flags().StringVar(&c.onCollision, "on-collision", "", "When inputs derive the same PDF name: error (default), rename or overwrite")
+
+	// Summary page
+	cmd.Flags().BoolVar(&c.summaryPage, "summary-page", false, "Add a closing page with document statistics and a QR link to the source")
+	cmd.Flags().StringVar(&c.summaryRepoURL, "summary-repo-url", "", "Repository URL for the summary page QR code (default: the git remote of the input)")
+
+	// Multi-language builds
+	cmd.Flags().StringSliceVar(&c.locales, "locales", nil, "Build one PDF per locale (e.g. en,de); uses doc.<locale>.md when present")
+	cmd.Flags().StringVar(&c.dateFormat, "date-format", "", "Go time layout for the {date} variable (e.g. 02.01.2006)")
+
+	// Conditional content
+	cmd.Flags().StringSliceVar(&c.defines, "define", nil, "Keep the <!-- if:name --> blocks of this name (repeatable, or comma-separated)")
+
+	// Security
+	cmd.Flags().StringVar(&c.imagePolicy, "image-policy", "", "How to handle images with active content: warn, sanitize (default) or refuse")
+
+	// Network
+	cmd.Flags().IntVar(&c.networkTimeout, "network-timeout", 0, "Seconds a request for a remote resource may take (1-600, default 30)")
+	cmd.Flags().StringVar(&c.proxy, "proxy", "", "Fetch remote resources through this proxy URL (default: HTTP_PROXY/HTTPS_PROXY)")
+	cmd.Flags().BoolVar(&c.offline, "offline", false, "Use only previously fetched remote resources, without network access")
+	cmd.Flags().Float64Var(&c.networkRateLimit, "network-rate-limit", 0, "Most requests per second for remote resources (0 = unlimited)")
+	cmd.Flags().IntVar(&c.networkAttempts, "network-attempts", 0, "Times a failed request is sent, waiting longer before each retry (1-10, default 3)")
+	cmd.Flags().IntVar(&c.networkConcurrency, "network-concurrency", 0, "Most remote resources downloaded at once (1-32, default 4)")
+
+	// Accessibility
+	cmd.Flags().BoolVar(&c.check, "check", false, "Report images without alt text, skipped heading levels, empty links and low-contrast colors instead of converting")
+
+	// Diagnostics
+	cmd.Flags().StringVar(&c.profileKind, "profile", "", "Record a profile of the run: cpu, mem or trace")
+	cmd.Flags().StringVar(&c.profileOut, "profile-out", "", "Profile output file (default md-to-pdf.<kind>.pprof, or md-to-pdf.trace.out)")
 
 	// New features
 	cmd.Flags().BoolVarP(&c.watch, "watch", "w", false, "Watch input files for changes and re-convert automatically")
@@ -116,18 +335,43 @@ Examples:
 }
 
 // run executes the convert command logic.
-func (c *convertCommand) run(cmd *cobra.Command, args []string) error {
+func (c *convertCommand) run(cmd *cobra.Command, args []string) (err error) {
+	// A mistake in a document is printed once, with its source snippet, by
+	// Execute: the usage text would push it out of sight
+	defer func() {
+		var sourceErr *parser.SourceError
+		if errors.As(err, &sourceErr) {
+			cmd.SilenceErrors = true
+			cmd.SilenceUsage = true
+		}
+	}()
+
+	args, err = c.expandInputs(args)
+	if err != nil {
+		return err
+	}
+
 	// Check for stdin input
 	isStdin := len(args) == 1 && args[0] == "-"
 
 	// Validate stdin requirements
 	if isStdin {
-		if c.outputPath == "" {
+		if c.outputPath == "" && !c.check {
 			return fmt.Errorf("--output flag is required when reading from stdin")
 		}
 		if c.watch {
 			return fmt.Errorf("--watch flag cannot be used with stdin input")
 		}
+		if len(c.locales) > 0 {
+			return fmt.Errorf("--locales flag cannot be used with stdin input")
+		}
+	}
+
+	// Validate: locale codes become part of output file names
+	for _, locale := range c.locales {
+		if !core.IsValidLocale(locale) {
+			return fmt.Errorf("invalid locale code %q", locale)
+		}
 	}
 
 	// Validate: cannot use --output with multiple input files
@@ -135,16 +379,61 @@ func (c *convertCommand) run(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("cannot use --output with multiple input files; omit --output to generate individual PDFs")
 	}
 
+	// Validate: a single outline or contact sheet cannot describe several PDFs
+	if len(args) > 1 && c.outlineOut != "" {
+		return fmt.Errorf("cannot use --outline-out with multiple input files")
+	}
+	if len(args) > 1 && c.contactSheet != "" {
+		return fmt.Errorf("cannot use --contact-sheet with multiple input files")
+	}
+	if len(args) > 1 && c.sourcePDF != "" {
+		return fmt.Errorf("cannot use --source-pdf with multiple input files")
+	}
+
+	// Validate: checks audit the base document once, writing nothing
+	if c.check && c.watch {
+		return fmt.Errorf("--check cannot be used with --watch")
+	}
+	if c.check && len(c.locales) > 0 {
+		return fmt.Errorf("--check cannot be used with --locales")
+	}
+
 	// Validate: watch mode with multiple files generates individual PDFs
 	if c.watch && c.outputPath != "" && len(args) > 1 {
 		return fmt.Errorf("cannot use --output with --watch and multiple input files")
 	}
 
+	// Validate profiling options
+	if c.profileKind != "" && !profile.IsValidKind(c.profileKind) {
+		return fmt.Errorf("invalid profile kind %q (must be one of: %s)", c.profileKind, strings.Join(profile.ValidKinds, ", "))
+	}
+	if c.profileKind == "" && c.profileOut != "" {
+		return fmt.Errorf("--profile-out requires --profile")
+	}
+
+	// Profile the whole run, including engine setup
+	if c.profileKind != "" {
+		session, startErr := profile.Start(profile.Kind(c.profileKind), c.profileOut)
+		if startErr != nil {
+			return startErr
+		}
+		defer func() {
+			if stopErr := session.Stop(); stopErr != nil {
+				if err == nil {
+					err = stopErr
+				}
+				return
+			}
+			// Stderr keeps --json output on stdout parseable
+			fmt.Fprintf(os.Stderr, "%s profile written to %s\n", c.profileKind, session.Path())
+		}()
+	}
+
 	// Load base configuration
 	baseConfig := core.DefaultConfig()
 
 	// Load user configuration
-	userConfig, err := config.LoadUserConfig()
+	userConfig, err := config.LoadResolvedUserConfig()
 	if err != nil {
 		return fmt.Errorf("failed to load user config: %w", err)
 	}
@@ -160,6 +449,24 @@ func (c *convertCommand) run(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to create engine: %w", err)
 	}
 
+	// Audit instead of converting
+	if c.check {
+		return c.runCheck(engine, baseConfig, args)
+	}
+
+	// Unreadable colors are converted as configured, with a warning
+	if !c.jsonMode {
+		warnColorIssues(ui.NewOutput(), baseConfig)
+	}
+
+	// Fail before converting anything when inputs would overwrite each
+	// other's PDFs; title-derived names are checked as they are converted
+	if c.outputPath == "" && !baseConfig.Document.TitleFromH1 && (baseConfig.Output.OnCollision == "" || baseConfig.Output.OnCollision == "error") {
+		if err := checkOutputCollisions(args); err != nil {
+			return err
+		}
+	}
+
 	// Handle stdin input
 	if isStdin {
 		return c.runStdin(engine)
@@ -171,7 +478,39 @@ func (c *convertCommand) run(cmd *cobra.Command, args []string) error {
 	}
 
 	// Normal conversion
-	return c.runConvert(engine, args)
+	err = c.runConvert(engine, args)
+	if errors.Is(err, errInterrupted) {
+		// The summary is printed, Execute only sets the exit code
+		cmd.SilenceErrors = true
+		cmd.SilenceUsage = true
+	}
+	return err
+}
+
+// expandInputs expands glob patterns in args and applies --order-file.
+// Without arguments, the order file lists the inputs.
+func (c *convertCommand) expandInputs(args []string) ([]string, error) {
+	files, err := inputs.Expand(args)
+	if err != nil {
+		return nil, err
+	}
+	if c.orderFile == "" {
+		return files, nil
+	}
+
+	order, err := inputs.ReadOrderFile(c.orderFile)
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return order, nil
+	}
+	for _, file := range files {
+		if file == "-" {
+			return nil, fmt.Errorf("--order-file cannot be used with stdin input")
+		}
+	}
+	return inputs.ApplyOrder(files, order)
 }
 
 // runStdin handles conversion from stdin.
@@ -200,27 +539,113 @@ func (c *convertCommand) runStdin(engine *core.Engine) error {
 		return convErr
 	}
 
+	warnings := newWarningCollector(ui.NewOutput(), c.jsonMode)
+	engine.SetWarningHandler(warnings.handle)
+	engine.SetPluginLogHandler(newPluginLogHandler(ui.NewOutput(), c.verbose, c.jsonMode))
+	splits := newSplitCollector()
+	engine.SetSplitHandler(splits.handle)
+	cache := newCacheCollector()
+	engine.SetCacheHandler(cache.handle)
+	activity := newNetworkCollector(engine)
+
 	err = engine.ConvertFromContent(content, c.outputPath)
 	duration := time.Since(startTime)
 
 	if err != nil {
 		formatter.RecordError("stdin", duration, err)
+		activity.record(formatter, "stdin")
 		if c.jsonMode {
 			return formatter.Print()
 		}
 		return fmt.Errorf("conversion failed: %w", err)
 	}
 
-	formatter.RecordSuccess("stdin", c.outputPath, duration)
+	formatter.RecordSuccess("stdin", c.outputPath, duration, warnings.take()...)
+	splits.record(formatter, c.outputPath)
+	cache.record(formatter, c.outputPath)
+	activity.record(formatter, "stdin")
 
 	if c.jsonMode {
 		return formatter.Print()
 	}
 
 	if c.verbose {
-		fmt.Printf("Converted stdin to %s\n", c.outputPath)
+		fmt.Printf("Converted stdin to %s\n", splits.describe(c.outputPath))
+		if summary, ok := activity.stats(); ok {
+			fmt.Println(summary)
+		}
+	}
+
+	return nil
+}
+
+// runCheck audits the inputs for accessibility issues instead of converting
+// them. It fails when an issue is found, so checks can gate CI builds.
+func (c *convertCommand) runCheck(engine *core.Engine, cfg *core.Config, args []string) error {
+	formatter := output.NewFormatter(c.jsonMode)
+	uiOutput := ui.NewOutput()
+
+	// Color issues belong to the configuration: listed once in text output,
+	// and in the report of every input in JSON output
+	colorIssues := core.ColorIssues(cfg)
+	found := len(colorIssues) > 0
+	if !c.jsonMode {
+		warnColorIssues(uiOutput, cfg)
+	}
+
+	for _, inputFile := range args {
+		startTime := time.Now()
+
+		var issues []accessibility.Issue
+		var err error
+		if inputFile == "-" {
+			inputFile = "stdin"
+			var content []byte
+			content, err = io.ReadAll(os.Stdin)
+			if err == nil {
+				issues, err = engine.CheckSource(content, inputFile)
+			}
+		} else {
+			issues, err = engine.CheckFile(inputFile)
+		}
+		duration := time.Since(startTime)
+
+		if err != nil {
+			formatter.RecordError(inputFile, duration, err)
+			if !c.jsonMode {
+				return fmt.Errorf("check failed: %w", err)
+			}
+			continue
+		}
+
+		found = found || len(issues) > 0
+		report := make([]output.AccessibilityIssue, 0, len(issues)+len(colorIssues))
+		for _, issue := range append(issues, colorIssues...) {
+			report = append(report, output.AccessibilityIssue{Rule: issue.Rule, Line: issue.Line, Message: issue.Message})
+		}
+		formatter.RecordCheck(inputFile, duration, report)
+
+		if !c.jsonMode {
+			for _, issue := range issues {
+				uiOutput.Warnf("%s: %s", inputFile, issue)
+			}
+			if len(issues) == 0 {
+				uiOutput.Successf("Checked: %s", filepath.Base(inputFile))
+			}
+		}
 	}
 
+	if c.jsonMode {
+		if err := formatter.Print(); err != nil {
+			return err
+		}
+		if formatter.HasErrors() {
+			return fmt.Errorf("one or more checks failed")
+		}
+	}
+	if found {
+		return fmt.Errorf("accessibility issues found")
+	}
 	return nil
 }
 
@@ -233,6 +658,8 @@ func (c *convertCommand) runWatch(engine *core.Engine, args []string) error {
 		}
 	}
 
+	engine.SetPluginLogHandler(newPluginLogHandler(ui.NewOutput(), c.verbose, false))
+
 	// Create convert function for watcher
 	convertFunc := func(inputFile string) error {
 		opts := core.ConversionOptions{
@@ -240,6 +667,7 @@ func (c *convertCommand) runWatch(engine *core.Engine, args []string) error {
 			OutputPath: c.outputPath,
 			PluginDir:  c.pluginDir,
 			Verbose:    false, // Watcher handles its own output
+			Locales:    c.locales,
 		}
 		return engine.Convert(opts)
 	}
@@ -303,38 +731,131 @@ func (c *convertCommand) runConvert(engine *core.Engine, args []string) error {
 		batchProgress.SetEnabled(false)
 	}
 
-	for i, inputFile := range args {
-		startTime := time.Now()
+	warnings := newWarningCollector(uiOutput, c.jsonMode)
+	engine.SetWarningHandler(warnings.handle)
+	engine.SetPluginLogHandler(newPluginLogHandler(uiOutput, c.verbose, c.jsonMode))
+	splits := newSplitCollector()
+	engine.SetSplitHandler(splits.handle)
+	cache := newCacheCollector()
+	engine.SetCacheHandler(cache.handle)
+	activity := newNetworkCollector(engine)
+
+	// The first Ctrl+C lets the file in progress finish and skips the rest,
+	// a second one aborts it and removes what it wrote. The loop holds mu
+	// except while a file converts, so an abort never races its results.
+	var stopping atomic.Bool
+	var current atomic.Int64
+	var mu sync.Mutex
+	mu.Lock()
+	defer mu.Unlock()
+	sigChan := make(chan os.Signal, 2)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(sigChan)
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			case <-sigChan:
+			}
+			inputFile := args[current.Load()]
+			if stopping.CompareAndSwap(false, true) {
+				if !c.jsonMode {
+					uiOutput.Warnf("interrupted, finishing %s (press Ctrl+C again to abort)", filepath.Base(inputFile))
+				}
+				continue
+			}
 
-		// Determine output path before conversion
-		outputPath := c.outputPath
-		if outputPath == "" {
-			outputPath = deriveOutputPath(inputFile)
+			mu.Lock()
+			select {
+			case <-done:
+				// The batch completed meanwhile
+				mu.Unlock()
+				return
+			default:
+			}
+			batchProgress.Stop()
+			for _, removed := range engine.RemovePartialOutputs() {
+				if !c.jsonMode {
+					uiOutput.Warnf("removed partial output %s", removed)
+				}
+			}
+			for _, skipped := range args[current.Load():] {
+				formatter.RecordSkipped(skipped)
+			}
+			_ = reportInterrupted(uiOutput, formatter, len(args))
+			os.Exit(exitInterrupted)
 		}
+	}()
+
+	for i, inputFile := range args {
+		if stopping.Load() {
+			for _, skipped := range args[i:] {
+				formatter.RecordSkipped(skipped)
+			}
+			batchProgress.Stop()
+			if err := reportInterrupted(uiOutput, formatter, len(args)); err != nil {
+				return err
+			}
+			return errInterrupted
+		}
+		current.Store(int64(i))
+		startTime := time.Now()
 
 		// Start progress for this file
 		batchProgress.StartFile(filepath.Base(inputFile))
 
+		// Localized builds produce one PDF per locale
+		var outputs []string
+		var outputWarnings [][]string
 		opts := core.ConversionOptions{
 			InputFiles: []string{inputFile},
 			OutputPath: c.outputPath,
 			PluginDir:  c.pluginDir,
 			Verbose:    false, // We handle verbose output ourselves for JSON support
+			Locales:    c.locales,
+			OnComplete: func(_, _ int, _, outputFile string) {
+				outputs = append(outputs, outputFile)
+				outputWarnings = append(outputWarnings, warnings.take())
+			},
 		}
 
+		mu.Unlock()
 		err := engine.Convert(opts)
+		mu.Lock()
 		duration := time.Since(startTime)
 
 		if err != nil {
+			warnings.take()
 			batchProgress.Error(err)
 			formatter.RecordError(inputFile, duration, err)
+			activity.record(formatter, inputFile)
 			if !c.jsonMode {
 				return fmt.Errorf("conversion failed: %w", err)
 			}
 			continue
 		}
 
-		formatter.RecordSuccess(inputFile, outputPath, duration)
+		// Describe the written PDFs, which may be named after their titles
+		outputPath := outputs[0]
+		if len(c.locales) > 0 {
+			described := make([]string, len(outputs))
+			for j, localized := range outputs {
+				formatter.RecordSuccess(inputFile, localized, duration, outputWarnings[j]...)
+				splits.record(formatter, localized)
+				cache.record(formatter, localized)
+				described[j] = splits.describe(localized)
+			}
+			outputPath = strings.Join(described, ", ")
+		} else {
+			formatter.RecordSuccess(inputFile, outputPath, duration, outputWarnings[0]...)
+			splits.record(formatter, outputPath)
+			cache.record(formatter, outputPath)
+			outputPath = splits.describe(outputPath)
+		}
+		activity.record(formatter, inputFile)
 
 		// Show completion for non-TTY (TTY shows spinner instead)
 		if !batchProgress.IsEnabled() && !c.jsonMode {
@@ -362,6 +883,66 @@ func (c *convertCommand) runConvert(engine *core.Engine, args []string) error {
 		return nil
 	}
 
+	if cache.used() {
+		uiOutput.Infof("%s", cache.stats())
+	}
+	if summary, ok := activity.stats(); ok && c.verbose {
+		uiOutput.Infof("%s", summary)
+	}
+
+	return nil
+}
+
+// checkOutputCollisions returns an error listing the inputs that would
+// write the same PDF.
+func checkOutputCollisions(inputs []string) error {
+	collisions := core.OutputCollisions(inputs)
+	if len(collisions) == 0 {
+		return nil
+	}
+	outputs := make([]string, 0, len(collisions))
+	for output := range collisions {
+		outputs = append(outputs, output)
+	}
+	sort.Strings(outputs)
+
+	var b strings.Builder
+	for _, output := range outputs {
+		fmt.Fprintf(&b, "\n  %s <- %s", output, strings.Join(collisions[output], ", "))
+	}
+	return fmt.Errorf("several inputs would write the same PDF:%s\nuse --on-collision rename to name them after their directories, or --on-collision overwrite", b.String())
+}
+
+// errInterrupted reports a batch stopped with Ctrl+C. Its summary has
+// already been printed, so Execute only sets the exit code.
+var errInterrupted = errors.New("conversion interrupted")
+
+// exitInterrupted is the exit code of an interrupted batch, as for a shell
+// command killed by SIGINT.
+const exitInterrupted = 130
+
+// reportInterrupted prints what an interrupted batch of total files
+// converted and skipped, as a JSON batch result in JSON mode.
+func reportInterrupted(uiOutput *ui.Output, formatter *output.Formatter, total int) error {
+	formatter.SetInterrupted()
+	if formatter.IsJSON() {
+		return formatter.Print()
+	}
+
+	// Localized builds record one result per locale
+	converted := make(map[string]bool)
+	var skipped []string
+	for _, result := range formatter.Results() {
+		if result.Success {
+			converted[result.Input] = true
+		} else if result.Skipped {
+			skipped = append(skipped, result.Input)
+		}
+	}
+	uiOutput.Warnf("batch interrupted: converted %d of %d files, skipped %d", len(converted), total, len(skipped))
+	for _, file := range skipped {
+		fmt.Fprintf(uiOutput.Stderr(), "  skipped: %s\n", file)
+	}
 	return nil
 }
 
@@ -382,6 +963,15 @@ func (c *convertCommand) applyOverrides(cmd *cobra.Command, cfg *core.Config) {
 	if cmd.Flags().Changed("heading-scale") {
 		cfg.Renderer.HeadingScale = c.headingScale
 	}
+	if cmd.Flags().Changed("heading-min-size") {
+		cfg.Renderer.HeadingMinSize = c.headingMin
+	}
+	if cmd.Flags().Changed("shift-headings") {
+		cfg.Parser.HeadingShift = c.headingShift
+	}
+	if cmd.Flags().Changed("define") {
+		cfg.Parser.Defines = c.defines
+	}
 	if cmd.Flags().Changed("line-spacing") {
 		cfg.Renderer.LineSpacing = c.lineSpacing
 	}
@@ -410,6 +1000,18 @@ func (c *convertCommand) applyOverrides(cmd *cobra.Command, cfg *core.Config) {
 	if cmd.Flags().Changed("margin-right") {
 		cfg.Renderer.Margins.Right = c.marginRight
 	}
+	if cmd.Flags().Changed("baseline-grid") {
+		cfg.Renderer.BaselineGrid = c.baselineGrid
+	}
+	if cmd.Flags().Changed("sidenote-side") {
+		cfg.Renderer.SidenoteSide = c.sidenoteSide
+	}
+	if cmd.Flags().Changed("keep-with-next") {
+		cfg.Renderer.KeepWithNext = c.keepWithNext
+	}
+	if cmd.Flags().Changed("min-lines-after-heading") {
+		cfg.Renderer.MinLinesAfterHeading = c.minLinesAfterHeading
+	}
 
 	// PDF metadata
 	if cmd.Flags().Changed("title") {
@@ -421,17 +1023,343 @@ func (c *convertCommand) applyOverrides(cmd *cobra.Command, cfg *core.Config) {
 	if cmd.Flags().Changed("subject") {
 		cfg.Document.Subject = c.subject
 	}
+	if cmd.Flags().Changed("title-from-h1") {
+		cfg.Document.TitleFromH1 = c.titleFromH1
+	}
+	if cmd.Flags().Changed("date-format") {
+		cfg.Document.DateFormat = c.dateFormat
+	}
 
 	// Mermaid settings
 	if cmd.Flags().Changed("mermaid-scale") {
 		cfg.Renderer.Mermaid.Scale = c.mermaidScale
 	}
+	if cmd.Flags().Changed("mermaid-theme") {
+		cfg.Renderer.Mermaid.Theme = c.mermaidTheme
+	}
+	if cmd.Flags().Changed("mermaid-wide-strategy") {
+		cfg.Renderer.Mermaid.WideStrategy = c.mermaidWideStrategy
+	}
+
+	// Header & footer
+	if cmd.Flags().Changed("header") {
+		cfg.Renderer.HeaderFooter.Header = c.header
+	}
+	if cmd.Flags().Changed("footer") {
+		cfg.Renderer.HeaderFooter.Footer = c.footer
+	}
+	if cmd.Flags().Changed("header-align") {
+		cfg.Renderer.HeaderFooter.HeaderAlign = c.headerAlign
+	}
+	if cmd.Flags().Changed("footer-align") {
+		cfg.Renderer.HeaderFooter.FooterAlign = c.footerAlign
+	}
+
+	// Horizontal rules
+	if cmd.Flags().Changed("rule-style") {
+		cfg.Renderer.ThematicBreak.Style = c.ruleStyle
+	}
+	if cmd.Flags().Changed("rule-thickness") {
+		cfg.Renderer.ThematicBreak.Thickness = c.ruleThickness
+	}
+	if cmd.Flags().Changed("rule-color") {
+		cfg.Renderer.ThematicBreak.Color = c.ruleColor
+	}
+	if cmd.Flags().Changed("rule-width") {
+		cfg.Renderer.ThematicBreak.Width = c.ruleWidth
+	}
+	if cmd.Flags().Changed("rule-ornament") {
+		cfg.Renderer.ThematicBreak.Ornament = c.ruleOrnament
+	}
+
+	// Blockquotes
+	if cmd.Flags().Changed("quote-bar-color") {
+		cfg.Renderer.QuoteStyle.BarColor = c.quoteBarColor
+	}
+	if cmd.Flags().Changed("quote-background") {
+		cfg.Renderer.QuoteStyle.Background = c.quoteBackground
+	}
+	if cmd.Flags().Changed("quote-font-style") {
+		cfg.Renderer.QuoteStyle.FontStyle = c.quoteFontStyle
+	}
+
+	// Classification banner
+	if cmd.Flags().Changed("banner") {
+		cfg.Renderer.Banner.Text = c.banner
+	}
+	if cmd.Flags().Changed("banner-position") {
+		cfg.Renderer.Banner.Position = c.bannerPosition
+	}
+	if cmd.Flags().Changed("banner-color") {
+		cfg.Renderer.Banner.Color = c.bannerColor
+	}
+	if cmd.Flags().Changed("banner-background") {
+		cfg.Renderer.Banner.Background = c.bannerBackground
+	}
+
+	// Table of contents
+	if cmd.Flags().Changed("toc") {
+		cfg.Renderer.TOC.Enabled = c.toc
+	}
+	if cmd.Flags().Changed("toc-depth") {
+		cfg.Renderer.TOC.Depth = c.tocDepth
+	}
+	if cmd.Flags().Changed("toc-title") {
+		cfg.Renderer.TOC.Title = c.tocTitle
+	}
+
+	// Review artifacts
+	if cmd.Flags().Changed("outline-out") {
+		cfg.Output.OutlinePath = c.outlineOut
+	}
+	if cmd.Flags().Changed("contact-sheet") {
+		cfg.Output.ContactSheetPath = c.contactSheet
+	}
+	if cmd.Flags().Changed("source-appendix") {
+		cfg.Output.SourceAppendix = c.sourceAppendix
+	}
+	if cmd.Flags().Changed("source-pdf") {
+		cfg.Output.SourcePDFPath = c.sourcePDF
+	}
+
+	// Output size
+	if cmd.Flags().Changed("max-output-size") {
+		cfg.Output.MaxSize = c.maxOutputSize
+	}
+
+	// Fast web view
+	if cmd.Flags().Changed("linearize") {
+		cfg.Output.Linearize = c.linearize
+	}
+
+	// Source manifest
+	if cmd.Flags().Changed("manifest") {
+		cfg.Output.Manifest = c.manifest
+	}
+
+	// Render cache
+	if cmd.Flags().Changed("cache-dir") {
+		cfg.Output.CacheDir = c.cacheDir
+	}
+
+	// Output permissions
+	if cmd.Flags().Changed("output-mode") {
+		cfg.Output.FileMode = c.outputMode
+	}
+	if cmd.Flags().Changed("preserve-mode") {
+		cfg.Output.PreserveMode = c.preserveMode
+	}
+
+	// Output path collisions
+	if cmd.Flags().Changed("on-collision") {
+		cfg.Output.OnCollision = c.onCollision
+	}
+
+	// Summary page
+	if cmd.Flags().Changed("summary-page") {
+		cfg.Output.Summary.Enabled = c.summaryPage
+	}
+	if cmd.Flags().Changed("summary-repo-url") {
+		cfg.Output.Summary.RepoURL = c.summaryRepoURL
+	}
+	cfg.Output.Summary.ToolVersion = resolvedVersion()
+
+	// Security
+	if cmd.Flags().Changed("image-policy") {
+		cfg.Renderer.ImagePolicy = c.imagePolicy
+	}
+
+	// Network
+	if cmd.Flags().Changed("network-timeout") {
+		cfg.Network.Timeout = c.networkTimeout
+	}
+	if cmd.Flags().Changed("proxy") {
+		cfg.Network.Proxy = c.proxy
+	}
+	if cmd.Flags().Changed("offline") {
+		cfg.Network.Offline = c.offline
+	}
+	if cmd.Flags().Changed("network-rate-limit") {
+		cfg.Network.RateLimit = c.networkRateLimit
+	}
+	if cmd.Flags().Changed("network-attempts") {
+		cfg.Network.Attempts = c.networkAttempts
+	}
+	if cmd.Flags().Changed("network-concurrency") {
+		cfg.Network.Concurrency = c.networkConcurrency
+	}
+}
+
+// warningCollector gathers conversion warnings for the JSON report and prints
+// them as they happen unless JSON output is requested.
+type warningCollector struct {
+	output   *ui.Output
+	jsonMode bool
+	warnings []string
+}
+
+func newWarningCollector(output *ui.Output, jsonMode bool) *warningCollector {
+	return &warningCollector{output: output, jsonMode: jsonMode}
+}
+
+// newPluginLogHandler shows the info messages of plugins, and their debug
+// messages when verbose. JSON output shows neither, so it stays parseable.
+func newPluginLogHandler(output *ui.Output, verbose, jsonMode bool) plugins.LogHandler {
+	return func(plugin string, level plugins.LogLevel, message string) {
+		if jsonMode || (level == plugins.LogDebug && !verbose) {
+			return
+		}
+		// Stderr keeps plugin messages out of PDFs written to stdout
+		_, _ = fmt.Fprintf(output.Stderr(), "[%s] %s\n", plugin, message)
+	}
+}
+
+// handle is an engine warning handler.
+func (w *warningCollector) handle(file, message string) {
+	w.warnings = append(w.warnings, message)
+	if w.jsonMode {
+		return
+	}
+	if file == "" {
+		w.output.Warnf("%s", message)
+		return
+	}
+	w.output.Warnf("%s: %s", file, message)
+}
+
+// take returns the warnings collected since the last call and resets them.
+func (w *warningCollector) take() []string {
+	warnings := w.warnings
+	w.warnings = nil
+	return warnings
+}
+
+// splitCollector remembers the parts written for PDFs split by
+// --max-output-size, keyed by the output path they replace.
+type splitCollector struct {
+	parts map[string][]core.OutputPart
+}
+
+func newSplitCollector() *splitCollector {
+	return &splitCollector{parts: make(map[string][]core.OutputPart)}
+}
+
+// handle is an engine split handler.
+func (s *splitCollector) handle(_, outputPath string, parts []core.OutputPart) {
+	s.parts[outputPath] = parts
 }
 
-// deriveOutputPath generates the output PDF path from an input markdown path.
-func deriveOutputPath(inputPath string) string {
-	baseName := strings.TrimSuffix(filepath.Base(inputPath), filepath.Ext(inputPath))
-	return baseName + ".pdf"
+// record adds the parts of outputPath, if it was split, to its JSON result.
+func (s *splitCollector) record(formatter *output.Formatter, outputPath string) {
+	parts, ok := s.parts[outputPath]
+	if !ok {
+		return
+	}
+	results := make([]output.PartResult, len(parts))
+	for i, part := range parts {
+		results[i] = output.PartResult{Path: part.Path, FirstPage: part.FirstPage, LastPage: part.LastPage}
+	}
+	formatter.RecordParts(outputPath, results)
+}
+
+// describe returns outputPath for messages, naming the part range instead
+// when the PDF was split.
+func (s *splitCollector) describe(outputPath string) string {
+	parts, ok := s.parts[outputPath]
+	if !ok {
+		return outputPath
+	}
+	return fmt.Sprintf("%s .. %s (%d parts)", parts[0].Path, parts[len(parts)-1].Path, len(parts))
+}
+
+// cacheCollector counts the PDFs taken from and added to the render cache.
+type cacheCollector struct {
+	results map[string]bool // Whether the PDF at each output path was a hit
+	hits    int
+	misses  int
+}
+
+func newCacheCollector() *cacheCollector {
+	return &cacheCollector{results: make(map[string]bool)}
+}
+
+// handle is an engine cache handler.
+func (c *cacheCollector) handle(_, outputPath string, hit bool) {
+	c.results[outputPath] = hit
+	if hit {
+		c.hits++
+	} else {
+		c.misses++
+	}
+}
+
+// record marks the JSON result of outputPath as a cache hit or miss.
+func (c *cacheCollector) record(formatter *output.Formatter, outputPath string) {
+	if hit, ok := c.results[outputPath]; ok {
+		formatter.RecordCache(outputPath, hit)
+	}
+}
+
+// used reports whether the render cache was consulted.
+func (c *cacheCollector) used() bool {
+	return c.hits+c.misses > 0
+}
+
+// stats summarizes the cache hits and misses.
+func (c *cacheCollector) stats() string {
+	return fmt.Sprintf("Render cache: %d hit(s), %d miss(es)", c.hits, c.misses)
+}
+
+// networkCollector attributes the network activity of an engine to the
+// inputs it converts.
+type networkCollector struct {
+	engine *core.Engine
+	start  network.Stats
+	last   network.Stats
+}
+
+func newNetworkCollector(engine *core.Engine) *networkCollector {
+	stats := engine.NetworkStats()
+	return &networkCollector{engine: engine, start: stats, last: stats}
+}
+
+// record attaches the activity since the previous call to the JSON result
+// of input.
+func (n *networkCollector) record(formatter *output.Formatter, input string) {
+	stats := n.engine.NetworkStats()
+	activity := stats.Sub(n.last)
+	n.last = stats
+	if activity.Empty() {
+		return
+	}
+	formatter.RecordNetwork(input, output.NetworkActivity{
+		Requests:    activity.Requests,
+		Downloads:   activity.Downloads,
+		Bytes:       activity.Bytes,
+		CacheHits:   activity.CacheHits,
+		Revalidated: activity.Revalidated,
+		Stale:       activity.Stale,
+		Retries:     activity.Retries,
+		Resumed:     activity.Resumed,
+		Failures:    activity.Failures,
+		ThrottledMs: activity.Throttled.Milliseconds(),
+	})
+}
+
+// stats summarizes the activity of the whole run, and reports whether
+// there was any.
+func (n *networkCollector) stats() (string, bool) {
+	activity := n.engine.NetworkStats().Sub(n.start)
+	if activity.Empty() {
+		return "", false
+	}
+	summary := fmt.Sprintf("Network: %d request(s), %d download(s) of %d byte(s), %d cache hit(s), %d revalidated, %d stale, %d retried, %d resumed, %d failed",
+		activity.Requests, activity.Downloads, activity.Bytes, activity.CacheHits,
+		activity.Revalidated, activity.Stale, activity.Retries, activity.Resumed, activity.Failures)
+	if activity.Throttled > 0 {
+		summary += fmt.Sprintf(", %s waiting for the rate limit", activity.Throttled.Round(time.Millisecond))
+	}
+	return summary, true
 }
 
 func init() {
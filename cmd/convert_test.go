@@ -110,6 +110,117 @@ func TestMultipleFilesWithOutputFlagReturnsError(t *testing.T) {
 	}
 }
 
+func TestMultipleFilesWithOutlineOutReturnsError(t *testing.T) {
+	cmd := newConvertCommand()
+	cmd.SetArgs([]string{"file1.md", "file2.md", "--outline-out", "outline.json"})
+
+	err := cmd.Execute()
+	if err == nil {
+		t.Fatal("expected error when using --outline-out with multiple input files")
+	}
+
+	expectedMsg := "cannot use --outline-out with multiple input files"
+	if !strings.Contains(err.Error(), expectedMsg) {
+		t.Errorf("expected error containing %q, got: %v", expectedMsg, err)
+	}
+}
+
+func TestMultipleFilesWithContactSheetReturnsError(t *testing.T) {
+	cmd := newConvertCommand()
+	cmd.SetArgs([]string{"file1.md", "file2.md", "--contact-sheet", "sheet.png"})
+
+	err := cmd.Execute()
+	if err == nil {
+		t.Fatal("expected error when using --contact-sheet with multiple input files")
+	}
+	if !strings.Contains(err.Error(), "cannot use --contact-sheet with multiple input files") {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestInvalidLocaleReturnsError(t *testing.T) {
+	cmd := newConvertCommand()
+	cmd.SetArgs([]string{"file1.md", "--locales", "en,../de"})
+
+	err := cmd.Execute()
+	if err == nil {
+		t.Fatal("expected error for invalid locale code")
+	}
+	if !strings.Contains(err.Error(), "invalid locale code") {
+		t.Errorf("expected invalid locale error, got: %v", err)
+	}
+}
+
+func TestCheckReportsIssuesWithoutConverting(t *testing.T) {
+	tempDir := t.TempDir()
+	input := filepath.Join(tempDir, "doc.md")
+	if err := os.WriteFile(input, []byte("# Title\n\n### Skipped\n\n![](chart.png)\n"), 0644); err != nil {
+		t.Fatalf("failed to create test file: %v", err)
+	}
+
+	cmd := newConvertCommand()
+	cmd.SetArgs([]string{input, "--check", "--json"})
+	err := cmd.Execute()
+	if err == nil || !strings.Contains(err.Error(), "accessibility issues found") {
+		t.Errorf("expected accessibility issues error, got: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(tempDir, "doc.pdf")); !os.IsNotExist(err) {
+		t.Error("--check should not write a PDF")
+	}
+
+	cmd = newConvertCommand()
+	cmd.SetArgs([]string{input, "--check", "--watch"})
+	if err := cmd.Execute(); err == nil || !strings.Contains(err.Error(), "--check cannot be used with --watch") {
+		t.Errorf("expected --watch error, got: %v", err)
+	}
+}
+
+func TestGlobInputsAreExpanded(t *testing.T) {
+	tempDir := t.TempDir()
+	for _, name := range []string{"ch1.md", "ch2.md"} {
+		if err := os.WriteFile(filepath.Join(tempDir, name), []byte("# "+name), 0644); err != nil {
+			t.Fatalf("failed to create test file: %v", err)
+		}
+	}
+
+	// Two matches with one output path is caught like two literal files
+	cmd := newConvertCommand()
+	cmd.SetArgs([]string{filepath.Join(tempDir, "*.md"), "-o", "output.pdf"})
+	err := cmd.Execute()
+	if err == nil || !strings.Contains(err.Error(), "cannot use --output with multiple input files") {
+		t.Errorf("expected multiple input error, got: %v", err)
+	}
+
+	cmd = newConvertCommand()
+	cmd.SetArgs([]string{filepath.Join(tempDir, "*.txt")})
+	err = cmd.Execute()
+	if err == nil || !strings.Contains(err.Error(), "no files match") {
+		t.Errorf("expected no match error, got: %v", err)
+	}
+}
+
+func TestOrderFileListsInputs(t *testing.T) {
+	tempDir := t.TempDir()
+	order := filepath.Join(tempDir, "ORDER")
+	if err := os.WriteFile(order, []byte("b.md\na.md\n"), 0644); err != nil {
+		t.Fatalf("failed to create order file: %v", err)
+	}
+
+	c := &convertCommand{orderFile: order}
+	files, err := c.expandInputs(nil)
+	if err != nil {
+		t.Fatalf("expandInputs failed: %v", err)
+	}
+	want := []string{filepath.Join(tempDir, "b.md"), filepath.Join(tempDir, "a.md")}
+	if strings.Join(files, ",") != strings.Join(want, ",") {
+		t.Errorf("expandInputs = %v, want %v", files, want)
+	}
+
+	if _, err := c.expandInputs([]string{"-"}); err == nil {
+		t.Error("expected error when combining --order-file with stdin")
+	}
+}
+
 func TestMultipleFilesWithoutOutputFlag(t *testing.T) {
 	// When multiple files are provided without -o flag,
 	// each file should generate its own PDF
@@ -383,3 +494,40 @@ func TestApplyOverridesWithChanged(t *testing.T) {
 			originalMermaidScale, cfg.Renderer.Mermaid.Scale)
 	}
 }
+
+func TestInvalidProfileKindReturnsError(t *testing.T) {
+	cmd := newConvertCommand()
+	cmd.SetArgs([]string{"file1.md", "--profile", "block"})
+
+	err := cmd.Execute()
+	if err == nil {
+		t.Fatal("expected error for invalid profile kind")
+	}
+	if !strings.Contains(err.Error(), "invalid profile kind") {
+		t.Errorf("expected invalid profile kind error, got: %v", err)
+	}
+}
+
+func TestProfileWritesFile(t *testing.T) {
+	tempDir := t.TempDir()
+	inputFile := filepath.Join(tempDir, "doc.md")
+	if err := os.WriteFile(inputFile, []byte("# Profiled\n\nSome text."), 0644); err != nil {
+		t.Fatalf("failed to create test file: %v", err)
+	}
+	profilePath := filepath.Join(tempDir, "cpu.pprof")
+
+	cmd := newConvertCommand()
+	cmd.SetArgs([]string{inputFile, "-o", filepath.Join(tempDir, "doc.pdf"), "--profile", "cpu", "--profile-out", profilePath})
+
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("conversion failed: %v", err)
+	}
+
+	info, err := os.Stat(profilePath)
+	if err != nil {
+		t.Fatalf("profile not written: %v", err)
+	}
+	if info.Size() == 0 {
+		t.Error("profile file is empty")
+	}
+}
@@ -0,0 +1,199 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/fredcamaral/md-to-pdf/internal/config"
+	"github.com/fredcamaral/md-to-pdf/internal/core"
+	"github.com/fredcamaral/md-to-pdf/internal/daemon"
+	"github.com/spf13/cobra"
+)
+
+// daemonCommand holds the state of the daemon command.
+type daemonCommand struct {
+	listen         string
+	metricsListen  string
+	pluginDir      string
+	maxConcurrent  int
+	maxQueued      int
+	maxRequestSize string
+}
+
+// newDaemonCommand creates the daemon command, which serves conversions over
+// JSON-RPC to editor extensions and build servers.
+func newDaemonCommand() *cobra.Command {
+	c := &daemonCommand{}
+
+	cmd := &cobra.Command{
+		Use:   "daemon",
+		Short: "Serve conversions over JSON-RPC to long-running clients",
+		Long: `Run md-to-pdf as a daemon answering JSON-RPC 2.0 requests, one JSON object
+per line. By default requests are read from stdin and answered on stdout, for
+clients that start the daemon themselves; --listen serves a Unix socket or a
+TCP address instead.
+
+Methods:
+  convert        {input, content, output, locales, options} -> {job_id}
+  job.status     {job_id} -> job
+  job.wait       {job_id} -> job, once it has finished
+  plugins.list   -> [{name, version, description}]
+  version        -> {version}
+  shutdown       finish queued jobs, then exit
+
+Jobs start in submission order and --max-concurrent of them run at once
+(default 1). Once --max-queued jobs are waiting, convert is refused with
+error -32002 and data {"retry_after": seconds}, the time after which to
+submit again; requests larger than --max-request-size are refused with error
+-32004. The client that submitted a job is sent job.progress
+notifications as it is queued, runs, starts each file, raises warnings and
+succeeds or fails. Options use the keys of the config file (font_size, toc,
+...) and apply to that job only. Relative paths are resolved against the
+daemon's working directory. Finished jobs are kept for an hour, at most the
+1024 most recent.
+
+--metrics-listen serves HTTP on another address for operating the daemon as a
+service: /metrics reports jobs, PDFs written, job durations, render cache hits
+and misses, refused requests, queue depth and running jobs in the Prometheus text format, and
+/readyz answers 200 until the daemon starts shutting down.
+
+The daemon reads and writes any file its user can, so only listen on
+addresses trusted clients can reach.
+
+Examples:
+  md-to-pdf daemon
+  md-to-pdf daemon --listen unix:/tmp/md-to-pdf.sock
+  md-to-pdf daemon --listen 127.0.0.1:7650
+  md-to-pdf daemon --listen 0.0.0.0:7650 --metrics-listen :9090
+  md-to-pdf daemon --listen 127.0.0.1:7650 --max-concurrent 4 --max-queued 32`,
+		Args: cobra.NoArgs,
+		RunE: c.run,
+	}
+
+	cmd.Flags().StringVar(&c.listen, "listen", "stdio", "Where to serve requests: stdio, unix:<socket path> or a TCP host:port")
+	cmd.Flags().StringVar(&c.metricsListen, "metrics-listen", "", "Serve /metrics and /readyz over HTTP on this host:port")
+	cmd.Flags().StringVarP(&c.pluginDir, "plugins", "p", "./plugins", "Plugin directory path")
+	cmd.Flags().IntVar(&c.maxConcurrent, "max-concurrent", 1, "Number of jobs converted at once")
+	cmd.Flags().IntVar(&c.maxQueued, "max-queued", 256, "Number of jobs waiting to run before submissions are refused")
+	cmd.Flags().StringVar(&c.maxRequestSize, "max-request-size", "64MB", "Largest request accepted, which bounds documents sent as content (e.g. 10MB)")
+
+	return cmd
+}
+
+// run serves requests until a client asks for shutdown, stdin is closed in
+// stdio mode and the jobs and job.wait requests read from it are answered,
+// or the daemon is interrupted.
+func (c *daemonCommand) run(_ *cobra.Command, _ []string) error {
+	limits, err := c.limits()
+	if err != nil {
+		return err
+	}
+	server := daemon.NewServer(c.config, resolvedVersion(), limits)
+
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(sigChan)
+	go func() {
+		<-sigChan
+		server.Shutdown()
+	}()
+
+	if c.metricsListen != "" {
+		if err := serveMetrics(server, c.metricsListen); err != nil {
+			return err
+		}
+	}
+
+	if c.listen == "stdio" {
+		// Stdout carries the protocol; anything else printed goes to stderr
+		stdout := os.Stdout
+		os.Stdout = os.Stderr
+		defer func() { os.Stdout = stdout }()
+
+		go func() {
+			if err := server.ServeConn(os.Stdin, stdout); err != nil {
+				fmt.Fprintf(os.Stderr, "md-to-pdf daemon: %v\n", err)
+			}
+			server.Shutdown()
+		}()
+		<-server.Done()
+		return nil
+	}
+
+	network, address := "tcp", c.listen
+	if path, ok := strings.CutPrefix(c.listen, "unix:"); ok {
+		network, address = "unix", path
+	}
+	listener, err := net.Listen(network, address)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", c.listen, err)
+	}
+	fmt.Fprintf(os.Stderr, "md-to-pdf daemon listening on %s\n", listener.Addr())
+
+	if err := server.Serve(listener); err != nil {
+		return fmt.Errorf("daemon stopped: %w", err)
+	}
+	return nil
+}
+
+// limits returns the limits set by the flags.
+func (c *daemonCommand) limits() (daemon.Limits, error) {
+	if c.maxConcurrent < 1 {
+		return daemon.Limits{}, fmt.Errorf("--max-concurrent must be at least 1, got %d", c.maxConcurrent)
+	}
+	if c.maxQueued < 1 {
+		return daemon.Limits{}, fmt.Errorf("--max-queued must be at least 1, got %d", c.maxQueued)
+	}
+	size, err := core.ParseByteSize(c.maxRequestSize)
+	if err != nil {
+		return daemon.Limits{}, fmt.Errorf("invalid --max-request-size: %w", err)
+	}
+	return daemon.Limits{MaxConcurrent: c.maxConcurrent, MaxQueued: c.maxQueued, MaxRequestSize: int(size)}, nil
+}
+
+// serveMetrics serves the metrics endpoints of server on address until the
+// server is done.
+func serveMetrics(server *daemon.Server, address string) error {
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", address, err)
+	}
+	fmt.Fprintf(os.Stderr, "md-to-pdf daemon serving metrics on http://%s/metrics\n", listener.Addr())
+
+	httpServer := &http.Server{Handler: server.MetricsHandler(), ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		if err := httpServer.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			fmt.Fprintf(os.Stderr, "md-to-pdf daemon: metrics: %v\n", err)
+		}
+	}()
+	go func() {
+		<-server.Done()
+		_ = httpServer.Close()
+	}()
+	return nil
+}
+
+// config returns the settings each job starts from: the defaults with the
+// user config applied, read again for every job.
+func (c *daemonCommand) config() (*core.Config, error) {
+	cfg := core.DefaultConfig()
+	userConfig, err := config.LoadResolvedUserConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load user config: %w", err)
+	}
+	config.ApplyUserConfig(cfg, userConfig)
+	cfg.Plugins.Directory = c.pluginDir
+	cfg.Output.Summary.ToolVersion = resolvedVersion()
+	return cfg, nil
+}
+
+func init() {
+	rootCmd.AddCommand(newDaemonCommand())
+}
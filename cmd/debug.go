@@ -0,0 +1,153 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/fredcamaral/md-to-pdf/internal/config"
+	"github.com/fredcamaral/md-to-pdf/internal/core"
+	"github.com/fredcamaral/md-to-pdf/internal/debugbundle"
+	"github.com/spf13/cobra"
+)
+
+// debugBundleCommand holds the state of the debug bundle command.
+type debugBundleCommand struct {
+	output    string
+	input     string
+	rawInput  bool
+	logs      []string
+	pluginDir string
+}
+
+// newDebugCommand creates the debug command, which groups the tools for
+// reporting problems.
+func newDebugCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "debug",
+		Short: "Tools for reporting problems",
+	}
+	cmd.AddCommand(newDebugBundleCommand())
+	return cmd
+}
+
+// newDebugBundleCommand creates the debug bundle command, which gathers
+// what a bug report needs into one tarball.
+func newDebugBundleCommand() *cobra.Command {
+	c := &debugBundleCommand{}
+
+	cmd := &cobra.Command{
+		Use:   "bundle",
+		Short: "Write a tarball to attach to bug reports",
+		Long: `Write a gzipped tarball with what is needed to reproduce a problem:
+
+  version.json           version, commit, Go version, platform and config fingerprint
+  config.yaml            the effective configuration, themes applied
+  plugins.json           the plugins that would load, with SHA256 checksums
+  security-events.json   the security events of loading them
+  input/<file>           the --input document, sanitized
+  logs/<n>-<file>        the end of each --log file
+
+The input is sanitized unless --raw-input is given: letters become x and
+digits 0, keeping markdown syntax, code fence languages and markers, so the
+document lays out the same without revealing its text. User names and
+passwords in the proxy and repository URLs are redacted. Check the bundle
+before sharing it.`,
+		Example: "  md-to-pdf debug bundle\n  md-to-pdf debug bundle --input report.md --log daemon.log -o bug.tar.gz",
+		Args:    cobra.NoArgs,
+		RunE:    c.run,
+	}
+
+	cmd.Flags().StringVarP(&c.output, "output", "o", "", "Bundle path (default md-to-pdf-debug-<time>.tar.gz)")
+	cmd.Flags().StringVar(&c.input, "input", "", "Markdown file to include, sanitized")
+	cmd.Flags().BoolVar(&c.rawInput, "raw-input", false, "Include --input as it is instead of sanitized")
+	cmd.Flags().StringArrayVar(&c.logs, "log", nil, "Log file to include the end of (repeatable)")
+	cmd.Flags().StringVarP(&c.pluginDir, "plugins", "p", "./plugins", "Plugin directory path")
+
+	return cmd
+}
+
+func (c *debugBundleCommand) run(_ *cobra.Command, _ []string) error {
+	bundle, err := c.bundle()
+	if err != nil {
+		return err
+	}
+
+	output := c.output
+	if output == "" {
+		output = fmt.Sprintf("md-to-pdf-debug-%s.tar.gz", time.Now().Format("20060102-150405"))
+	}
+	file, err := os.OpenFile(output, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600) // #nosec G304 - output path is named by the user
+	if err != nil {
+		return fmt.Errorf("failed to create bundle: %w", err)
+	}
+	if err := bundle.Write(file); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to write bundle: %w", err)
+	}
+
+	uiOutput.Success("Debug bundle written to %s", output)
+	if c.input != "" && c.rawInput {
+		uiOutput.Warnf("The bundle holds %s as it is; check it before sharing", c.input)
+	}
+	return nil
+}
+
+// bundle gathers the content of the bundle, loading the plugins the user
+// config and --plugins select.
+func (c *debugBundleCommand) bundle() (*debugbundle.Bundle, error) {
+	userConfig, err := config.LoadResolvedUserConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load user config: %w", err)
+	}
+	cfg := core.DefaultConfig()
+	config.ApplyUserConfig(cfg, userConfig)
+	cfg.Plugins.Directory = c.pluginDir
+
+	engine, err := core.NewEngine(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create engine: %w", err)
+	}
+	diagnostics, err := engine.PluginDiagnostics()
+	if err != nil {
+		return nil, err
+	}
+
+	bundle := &debugbundle.Bundle{
+		Version: debugbundle.NewVersionInfo(resolvedVersion(), Commit, Date, cfg),
+		Config:  userConfig,
+		Plugins: debugbundle.PluginsFrom(diagnostics),
+		Events:  debugbundle.EventsFrom(diagnostics),
+	}
+
+	if c.input != "" {
+		content, err := os.ReadFile(c.input) // #nosec G304 - input path is named by the user
+		if err != nil {
+			return nil, fmt.Errorf("failed to read input: %w", err)
+		}
+		if !c.rawInput {
+			content = debugbundle.SanitizeMarkdown(content)
+		}
+		bundle.Files = append(bundle.Files, debugbundle.File{Name: "input/" + filepath.Base(c.input), Data: content})
+	}
+
+	for i, log := range c.logs {
+		content, err := debugbundle.ReadLogTail(log)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read log: %w", err)
+		}
+		// Numbered, so logs with the same name from different directories
+		// do not overwrite each other
+		name := fmt.Sprintf("logs/%d-%s", i+1, filepath.Base(log))
+		bundle.Files = append(bundle.Files, debugbundle.File{Name: name, Data: content})
+	}
+	return bundle, nil
+}
+
+func init() {
+	rootCmd.AddCommand(newDebugCommand())
+}
@@ -0,0 +1,200 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/fredcamaral/md-to-pdf/internal/config"
+	"github.com/fredcamaral/md-to-pdf/internal/core"
+	"github.com/fredcamaral/md-to-pdf/internal/plugins"
+	"github.com/spf13/cobra"
+)
+
+// featuresCommand holds the state of the features command.
+type featuresCommand struct {
+	json      bool
+	pluginDir string
+}
+
+// markdownFeatures lists the markdown syntax the parser and renderer
+// support, and markdownExtensions the syntax beyond CommonMark.
+var (
+	markdownFeatures = []string{
+		"headings", "emphasis", "lists", "links", "images", "inline-code",
+		"code-blocks", "blockquotes", "horizontal-rules",
+	}
+	markdownExtensions = []string{
+		"sidenotes", "redactions", "internal-links", "linked-figures",
+		"blockquote-attributions", "toc-marker", "pagebreak-marker", "keep-together-marker",
+		"conditional-blocks",
+	}
+)
+
+// outputFormats lists the files a conversion can write.
+var outputFormats = []string{
+	"pdf", "pdf-parts", "linearized-pdf", "source-pdf",
+	"outline-json", "outline-yaml", "contact-sheet-png",
+}
+
+// pluginInterfaces lists the plugin interfaces the plugin manager runs.
+var pluginInterfaces = []string{"ast-transformer", "document-transformer", "content-generator"}
+
+// featureReport describes what this binary and configuration support.
+type featureReport struct {
+	Version          string               `json:"version"`
+	Markdown         []string             `json:"markdown"`
+	Extensions       []string             `json:"extensions"`
+	OutputFormats    []string             `json:"output_formats"`
+	PageSizes        []string             `json:"page_sizes"`
+	Fonts            []string             `json:"fonts"`
+	PluginInterfaces []string             `json:"plugin_interfaces"`
+	Plugins          []plugins.PluginInfo `json:"plugins"`
+	// PluginSupport lists the plugin file formats this build can load
+	PluginSupport plugins.PluginSupport `json:"plugin_support"`
+	// FencedLanguages lists the code block languages with a handler; others
+	// are rendered as plain code with a warning
+	FencedLanguages []plugins.RegisteredLanguage `json:"fenced_languages"`
+	ConfigKeys      []string                     `json:"config_keys"`
+}
+
+// newFeaturesCommand creates the features command, which lets wrapper
+// tooling detect features instead of comparing version numbers.
+func newFeaturesCommand() *cobra.Command {
+	c := &featuresCommand{}
+
+	cmd := &cobra.Command{
+		Use:   "features",
+		Short: "Report the markdown features, outputs, fonts and plugins supported",
+		Long: `Report what this md-to-pdf binary supports with the current configuration:
+markdown syntax and extensions, output formats, page sizes, fonts, plugin
+interfaces, the plugins that would be loaded and the configuration keys.
+
+With --json the report is a single JSON object, so scripts and editor
+extensions can check for a feature instead of parsing version numbers.`,
+		Example: "  md-to-pdf features\n  md-to-pdf features --json -p ./plugins",
+		Args:    cobra.NoArgs,
+		RunE:    c.run,
+	}
+
+	cmd.Flags().BoolVar(&c.json, "json", false, "Output the report as JSON")
+	cmd.Flags().StringVarP(&c.pluginDir, "plugins", "p", "./plugins", "Plugin directory path")
+
+	return cmd
+}
+
+func (c *featuresCommand) run(_ *cobra.Command, _ []string) error {
+	report, err := c.report()
+	if err != nil {
+		return err
+	}
+	if c.json {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(report)
+	}
+	printFeatureReport(report)
+	return nil
+}
+
+// report builds the feature report, loading the plugins the user config
+// and --plugins select.
+func (c *featuresCommand) report() (*featureReport, error) {
+	cfg := core.DefaultConfig()
+	userConfig, err := config.LoadResolvedUserConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load user config: %w", err)
+	}
+	config.ApplyUserConfig(cfg, userConfig)
+	cfg.Plugins.Directory = c.pluginDir
+
+	engine, err := core.NewEngine(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create engine: %w", err)
+	}
+	list, err := engine.Plugins()
+	if err != nil {
+		return nil, err
+	}
+	if list == nil {
+		list = []plugins.PluginInfo{}
+	}
+	languages, err := engine.Languages()
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]string, 0, len(configKeys))
+	for _, key := range configKeys {
+		keys = append(keys, key.name)
+	}
+
+	return &featureReport{
+		Version:          resolvedVersion(),
+		Markdown:         markdownFeatures,
+		Extensions:       markdownExtensions,
+		OutputFormats:    outputFormats,
+		PageSizes:        core.ValidPageSizes,
+		Fonts:            core.BuiltinFonts,
+		PluginInterfaces: pluginInterfaces,
+		Plugins:          list,
+		PluginSupport:    plugins.Support(),
+		FencedLanguages:  languages,
+		ConfigKeys:       keys,
+	}, nil
+}
+
+// printFeatureReport prints the report for people.
+func printFeatureReport(report *featureReport) {
+	fmt.Printf("md-to-pdf %s\n\n", report.Version)
+	sections := []struct {
+		title  string
+		values []string
+	}{
+		{"Markdown", report.Markdown},
+		{"Extensions", report.Extensions},
+		{"Output formats", report.OutputFormats},
+		{"Page sizes", report.PageSizes},
+		{"Fonts", report.Fonts},
+		{"Plugin interfaces", report.PluginInterfaces},
+	}
+	for _, section := range sections {
+		fmt.Printf("%s: %s\n", section.title, strings.Join(section.values, ", "))
+	}
+
+	fmt.Println()
+	support := report.PluginSupport
+	if len(support.Formats) == 0 {
+		fmt.Printf("Plugin files: none can be loaded on %s\n", support.Platform)
+	} else {
+		fmt.Printf("Plugin files: %s\n", strings.Join(support.Formats, ", "))
+	}
+	unsupported := make([]string, 0, len(support.Unsupported))
+	for suffix := range support.Unsupported {
+		unsupported = append(unsupported, suffix)
+	}
+	sort.Strings(unsupported)
+	for _, suffix := range unsupported {
+		fmt.Printf("  %s files are not loaded: %s\n", suffix, support.Unsupported[suffix])
+	}
+	if len(report.Plugins) == 0 {
+		fmt.Println("Plugins: none loaded")
+	} else {
+		fmt.Println("Plugins:")
+		for _, p := range report.Plugins {
+			fmt.Printf("  %s %s - %s\n", p.Name, p.Version, p.Description)
+		}
+	}
+
+	fmt.Println("\nFenced code block languages:")
+	for _, language := range report.FencedLanguages {
+		fmt.Printf("  %s - %s (%s)\n", language.Language, language.Kind, language.Handler)
+	}
+	fmt.Printf("\nConfiguration keys: %d (see md-to-pdf config keys)\n", len(report.ConfigKeys))
+}
+
+func init() {
+	rootCmd.AddCommand(newFeaturesCommand())
+}
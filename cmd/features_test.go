@@ -0,0 +1,43 @@
+package cmd
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestFeaturesReport(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	c := &featuresCommand{pluginDir: t.TempDir()}
+	report, err := c.report()
+	if err != nil {
+		t.Fatalf("report() error: %v", err)
+	}
+
+	data, err := json.Marshal(report)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	for _, field := range []string{"version", "markdown", "extensions", "output_formats", "page_sizes", "fonts", "plugin_interfaces", "plugins", "plugin_support", "fenced_languages", "config_keys"} {
+		if _, ok := decoded[field]; !ok {
+			t.Errorf("report has no %q field", field)
+		}
+	}
+	// No plugins is an empty list, not null, so clients can iterate it
+	if plugins, ok := decoded["plugins"].([]interface{}); !ok || len(plugins) != 0 {
+		t.Errorf("plugins = %v, want an empty list", decoded["plugins"])
+	}
+	found := false
+	for _, language := range report.FencedLanguages {
+		found = found || language.Language == "text"
+	}
+	if !found {
+		t.Errorf("fenced_languages should list the built-in languages, got %+v", report.FencedLanguages)
+	}
+	if len(report.ConfigKeys) != len(configKeys) {
+		t.Errorf("report lists %d config keys, want %d", len(report.ConfigKeys), len(configKeys))
+	}
+}
@@ -1,8 +1,10 @@
 package cmd
 
 import (
+	"errors"
 	"os"
 
+	"github.com/fredcamaral/md-to-pdf/internal/parser"
 	"github.com/fredcamaral/md-to-pdf/internal/ui"
 	"github.com/spf13/cobra"
 )
@@ -26,11 +28,24 @@ Use "md-to-pdf convert" to convert files, or "md-to-pdf --help" for more informa
 // Execute runs the root command.
 func Execute() {
 	if err := rootCmd.Execute(); err != nil {
-		uiOutput.Errorf("%v", err)
+		if errors.Is(err, errInterrupted) {
+			os.Exit(exitInterrupted)
+		}
+		printError(uiOutput, err)
 		os.Exit(1)
 	}
 }
 
+// printError prints err, followed by the source snippet showing where it
+// is when it is a mistake in a markdown document.
+func printError(out *ui.Output, err error) {
+	out.Errorf("%v", err)
+	var sourceErr *parser.SourceError
+	if errors.As(err, &sourceErr) {
+		out.Snippet(sourceErr.Location(), sourceErr.Snippet())
+	}
+}
+
 // GetUIOutput returns the shared UI output instance.
 func GetUIOutput() *ui.Output {
 	return uiOutput
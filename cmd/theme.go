@@ -0,0 +1,104 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/fredcamaral/md-to-pdf/internal/config"
+	"github.com/fredcamaral/md-to-pdf/internal/core"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+var themeCmd = &cobra.Command{
+	Use:   "theme",
+	Short: "List and inspect themes",
+	Long: `Themes are settings in the format of the config file. A settings file, the
+config file or a book theme, uses one with "extends: <theme>" and overrides
+single values of it with its own settings. A theme file may itself extend a
+built-in theme or another theme file.`,
+}
+
+var themeListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the built-in themes",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		for _, name := range config.ThemeNames() {
+			fmt.Printf("%-10s %s\n", name, config.ThemeDescription(name))
+		}
+	},
+}
+
+// themeShowResolved is the --resolved flag of theme show.
+var themeShowResolved bool
+
+var themeShowCmd = &cobra.Command{
+	Use:   "show [theme]",
+	Short: "Print the settings of a theme, or of the config file",
+	Long: `Print the settings of a built-in theme or theme file, or of the config file
+when no theme is named.
+
+With --resolved, print the effective style instead: every style setting, with
+the defaults, the themes extended and the settings overriding them merged in
+order.`,
+	Example: "  md-to-pdf theme show github\n  md-to-pdf theme show --resolved\n  md-to-pdf theme show docs/theme.yaml --resolved",
+	Args:    cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		settings, chain, err := loadThemeForShow(args, themeShowResolved)
+		if err != nil {
+			return err
+		}
+		if themeShowResolved {
+			cfg := core.DefaultConfig()
+			config.ApplyUserConfig(cfg, settings)
+			settings = config.StyleSettings(cfg)
+			if len(chain) > 0 {
+				fmt.Printf("# Resolved from: defaults, %s\n", strings.Join(chain, ", "))
+			}
+		}
+		data, err := yaml.Marshal(settings)
+		if err != nil {
+			return fmt.Errorf("failed to marshal settings: %w", err)
+		}
+		fmt.Print(string(data))
+		return nil
+	},
+}
+
+// loadThemeForShow loads the theme named by args, or the config file. The
+// settings are resolved, and the chain of themes returned, when resolve is
+// set.
+func loadThemeForShow(args []string, resolve bool) (*config.UserConfig, []string, error) {
+	if len(args) == 0 {
+		if !resolve {
+			userConfig, err := config.LoadUserConfig()
+			return userConfig, nil, err
+		}
+		resolved, chain, err := config.LoadResolvedUserConfigChain()
+		if err != nil {
+			return nil, nil, err
+		}
+		return resolved, append(chain, config.GetConfigPath()), nil
+	}
+
+	ref := args[0]
+	if !resolve && config.IsThemeFile(ref) {
+		// The file as written, with what it extends
+		userConfig, err := config.LoadUserConfigFile(ref)
+		return userConfig, nil, err
+	}
+	dir, err := os.Getwd()
+	if err != nil {
+		return nil, nil, err
+	}
+	return config.LoadTheme(ref, dir)
+}
+
+func init() {
+	themeShowCmd.Flags().BoolVar(&themeShowResolved, "resolved", false, "Print every style setting in effect, with the themes extended merged in")
+	rootCmd.AddCommand(themeCmd)
+	themeCmd.AddCommand(themeListCmd)
+	themeCmd.AddCommand(themeShowCmd)
+}
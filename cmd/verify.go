@@ -0,0 +1,125 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/fredcamaral/md-to-pdf/internal/manifest"
+	"github.com/spf13/cobra"
+)
+
+// verifyCommand holds the state of the verify command.
+type verifyCommand struct {
+	dir    string
+	source string
+	json   bool
+
+	// recorded is the source path in the manifest, read from --source
+	recorded string
+}
+
+// verifyReport is the JSON output of the verify command.
+type verifyReport struct {
+	PDF      string             `json:"pdf"`
+	Manifest *manifest.Manifest `json:"manifest"`
+	Files    []manifest.Check   `json:"files"`
+	Verified bool               `json:"verified"`
+}
+
+// newVerifyCommand creates the verify command, which checks a PDF against
+// the sources named in its manifest.
+func newVerifyCommand() *cobra.Command {
+	c := &verifyCommand{}
+
+	cmd := &cobra.Command{
+		Use:   "verify <file.pdf>",
+		Short: "Check a PDF against the sources recorded in its manifest",
+		Long: `Check that a PDF converted with --manifest was produced from the files
+next to it. The manifest attached to the PDF records the SHA-256 hash of
+the markdown source and of every image embedded, the md-to-pdf version and
+the config fingerprint. verify hashes the files again and fails unless they
+all match.
+
+Paths in the manifest are as given to the conversion, so run verify from
+the same directory or name it with --dir. --source checks another markdown
+file instead of the recorded one, such as a document converted from stdin.`,
+		Example: "  md-to-pdf verify report.pdf\n  md-to-pdf verify build/report.pdf --dir docs --json",
+		Args:    cobra.ExactArgs(1),
+		RunE:    c.run,
+	}
+
+	cmd.Flags().StringVar(&c.dir, "dir", ".", "Directory relative paths in the manifest are resolved from")
+	cmd.Flags().StringVar(&c.source, "source", "", "Markdown file to check instead of the recorded source")
+	cmd.Flags().BoolVar(&c.json, "json", false, "Output the manifest and results as JSON")
+
+	return cmd
+}
+
+func (c *verifyCommand) run(cmd *cobra.Command, args []string) error {
+	// A PDF that fails the check is not a usage error
+	cmd.SilenceUsage = true
+	data, err := os.ReadFile(args[0]) // #nosec G304 - PDF path is named by the user
+	if err != nil {
+		return fmt.Errorf("failed to read PDF: %w", err)
+	}
+	m, err := manifest.Read(data)
+	if errors.Is(err, manifest.ErrNoManifest) {
+		return fmt.Errorf("%s has no manifest; convert it with --manifest", args[0])
+	}
+	if err != nil {
+		return err
+	}
+
+	c.recorded = m.Source.Path
+	checks := m.Verify(c.read)
+	failed := 0
+	for _, check := range checks {
+		if check.Status != manifest.StatusMatch {
+			failed++
+		}
+	}
+
+	if c.json {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(verifyReport{PDF: args[0], Manifest: m, Files: checks, Verified: failed == 0}); err != nil {
+			return err
+		}
+	} else {
+		uiOutput.Info("%s: md-to-pdf %s, config fingerprint %s", args[0], m.ToolVersion, m.ConfigFingerprint)
+		for _, check := range checks {
+			switch check.Status {
+			case manifest.StatusMatch:
+				uiOutput.Success("%-8s %s", check.Status, check.Path)
+			default:
+				uiOutput.Error("%-8s %s", check.Status, check.Path)
+			}
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d files do not match the manifest", failed, len(checks))
+	}
+	if !c.json {
+		uiOutput.Success("All %d files match the manifest", len(checks))
+	}
+	return nil
+}
+
+// read reads a file named in the manifest, resolving relative paths from
+// --dir and reading --source in place of the source.
+func (c *verifyCommand) read(path string) ([]byte, error) {
+	if c.source != "" && path == c.recorded {
+		path = c.source
+	} else if !filepath.IsAbs(path) {
+		path = filepath.Join(c.dir, path)
+	}
+	return os.ReadFile(path) // #nosec G304 - paths from the manifest of a PDF the user checks
+}
+
+func init() {
+	rootCmd.AddCommand(newVerifyCommand())
+}
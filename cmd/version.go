@@ -19,16 +19,7 @@ var versionCmd = &cobra.Command{
 	Short: "Print the version number",
 	Long:  "Print the version, commit hash, and build date of md-to-pdf",
 	Run: func(cmd *cobra.Command, args []string) {
-		// Try to get version from build info if not set via ldflags
-		if Version == "dev" {
-			if info, ok := debug.ReadBuildInfo(); ok {
-				if info.Main.Version != "" && info.Main.Version != "(devel)" {
-					Version = info.Main.Version
-				}
-			}
-		}
-
-		fmt.Printf("md-to-pdf version %s\n", Version)
+		fmt.Printf("md-to-pdf version %s\n", resolvedVersion())
 		if Commit != "unknown" {
 			fmt.Printf("  commit: %s\n", Commit)
 		}
@@ -38,6 +29,19 @@ var versionCmd = &cobra.Command{
 	},
 }
 
+// resolvedVersion returns the version set via ldflags, falling back to the
+// module version from the build info for go install builds.
+func resolvedVersion() string {
+	if Version == "dev" {
+		if info, ok := debug.ReadBuildInfo(); ok {
+			if info.Main.Version != "" && info.Main.Version != "(devel)" {
+				return info.Main.Version
+			}
+		}
+	}
+	return Version
+}
+
 func init() {
 	rootCmd.AddCommand(versionCmd)
 }
@@ -0,0 +1,270 @@
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/fredcamaral/md-to-pdf/internal/config"
+	"github.com/fredcamaral/md-to-pdf/internal/core"
+	"github.com/fredcamaral/md-to-pdf/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+// wizardStep is one question of the config wizard. The answer is set to
+// every key listed, through the same validation as "config set".
+type wizardStep struct {
+	question string
+	hint     string // Choices or format shown with the question
+	keys     []string
+}
+
+// wizardSteps are the questions of the config wizard, in order.
+var wizardSteps = []wizardStep{
+	{question: "Theme", hint: strings.Join(config.ThemeNames(), ", ") + " or a theme file", keys: []string{"extends"}},
+	{question: "Page size", hint: core.ValidPageSizesString(), keys: []string{"page-size"}},
+	{question: "Body font", hint: "Arial, Times, Helvetica, Courier", keys: []string{"font-family"}},
+	{question: "Font size in points", hint: "for example 11 or 12", keys: []string{"font-size"}},
+	{question: "Code font", hint: "Courier is monospace", keys: []string{"code-font"}},
+	{question: "Page margins in mm", hint: "all four sides", keys: []string{"margin-top", "margin-bottom", "margin-left", "margin-right"}},
+	{question: "Default author", keys: []string{"author"}},
+	{question: "Default subject", keys: []string{"subject"}},
+	{question: "Date format for {date} in headers and footers", hint: "Go layout, e.g. 02 Jan 2006", keys: []string{"date-format"}},
+}
+
+// wizardSample is the markdown rendered for the preview page.
+const wizardSample = `# Sample Document
+
+This page shows how your documents will look with these settings. Body text
+uses the chosen font and size, with **bold**, *italic* and ` + "`inline code`" + `.
+
+## A Second-Level Heading
+
+- A bulleted list item
+- Another item
+
+> A blockquote, for notes and citations.
+
+` + "```" + `
+func main() {
+    fmt.Println("Code blocks use the code font")
+}
+` + "```" + `
+`
+
+// Flags of the wizard command
+var (
+	configWizardPreviewOut string
+	configWizardNoPreview  bool
+)
+
+var configWizardCmd = &cobra.Command{
+	Use:   "wizard",
+	Short: "Set up the configuration by answering questions",
+	Long: `Ask for the theme, page size, fonts, margins and metadata defaults one at a
+time, preview a sample page rendered with the answers and save them to the
+config file.
+
+Press Enter to keep the value in brackets, or type - to clear a setting.
+Invalid answers are explained and asked again.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		userConfig, err := config.LoadUserConfig()
+		if err != nil {
+			return err
+		}
+
+		wizard := &configWizard{in: bufio.NewReader(os.Stdin), out: os.Stdout}
+		fmt.Printf("Configuring %s\n\n", config.GetConfigPath())
+		if err := wizard.run(userConfig); err != nil {
+			return err
+		}
+
+		output := ui.NewOutput()
+		if !configWizardNoPreview {
+			if err := renderWizardPreview(userConfig, configWizardPreviewOut); err != nil {
+				output.Warnf("could not render the preview: %v", err)
+			} else {
+				fmt.Printf("\nPreview written to %s\n", configWizardPreviewOut)
+			}
+		}
+
+		save, err := wizard.confirm(fmt.Sprintf("Save these settings to %s?", config.GetConfigPath()))
+		if err != nil {
+			return err
+		}
+		if !save {
+			output.Info("Nothing was saved")
+			return nil
+		}
+		if err := config.SaveUserConfig(userConfig); err != nil {
+			return err
+		}
+		output.Success("Saved %s", config.GetConfigPath())
+		warnSavedColorIssues(output)
+		return nil
+	},
+}
+
+// configWizard asks the wizard questions on in and writes prompts to out.
+type configWizard struct {
+	in  *bufio.Reader
+	out io.Writer
+}
+
+// run asks every wizard step and sets the answers in userConfig.
+func (w *configWizard) run(userConfig *config.UserConfig) error {
+	for _, step := range wizardSteps {
+		if err := w.ask(userConfig, step); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ask asks one step until the answer is valid for all of its keys.
+func (w *configWizard) ask(userConfig *config.UserConfig, step wizardStep) error {
+	prompt := step.question
+	if step.hint != "" {
+		prompt += " (" + step.hint + ")"
+	}
+	if current := wizardCurrentValue(userConfig, effectiveStyle(userConfig), step.keys[0]); current != "" {
+		prompt += " [" + current + "]"
+	}
+
+	for {
+		answer, err := w.readLine(prompt + ": ")
+		if err != nil {
+			return err
+		}
+		switch answer {
+		case "":
+			return nil
+		case "-":
+			for _, key := range step.keys {
+				if err := resetConfigValue(userConfig, key); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+
+		// Validate against a copy so a rejected answer changes nothing
+		updated := *userConfig
+		err = setWizardValue(&updated, step.keys, answer)
+		if err == nil {
+			// A theme is only known to exist once it loads
+			_, _, err = config.ResolveUserConfig(&updated, filepath.Dir(config.GetConfigPath()))
+		}
+		if err != nil {
+			message, _, _ := strings.Cut(err.Error(), "\n")
+			fmt.Fprintf(w.out, "  %s\n", message)
+			continue
+		}
+		*userConfig = updated
+		return nil
+	}
+}
+
+// setWizardValue sets value to every key.
+func setWizardValue(userConfig *config.UserConfig, keys []string, value string) error {
+	for _, key := range keys {
+		if err := setConfigValue(userConfig, key, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// confirm asks a yes/no question, defaulting to yes.
+func (w *configWizard) confirm(question string) (bool, error) {
+	for {
+		answer, err := w.readLine(question + " [Y/n]: ")
+		if err != nil {
+			return false, err
+		}
+		switch strings.ToLower(answer) {
+		case "", "y", "yes":
+			return true, nil
+		case "n", "no":
+			return false, nil
+		}
+	}
+}
+
+// readLine prints prompt and reads one trimmed line. Input ending without
+// an answer is an error, so the wizard never saves half-answered settings.
+func (w *configWizard) readLine(prompt string) (string, error) {
+	fmt.Fprint(w.out, prompt)
+	line, err := w.in.ReadString('\n')
+	if err != nil && (!errors.Is(err, io.EOF) || line == "") {
+		fmt.Fprintln(w.out)
+		return "", fmt.Errorf("config wizard cancelled: no answer")
+	}
+	return strings.TrimSpace(line), nil
+}
+
+// wizardCurrentValue returns the configured value of key, or else the value
+// in effect through the theme or by default, as shown in brackets after a
+// question.
+func wizardCurrentValue(userConfig, effective *config.UserConfig, key string) string {
+	keyDef := findConfigKey(key)
+	if keyDef == nil {
+		return ""
+	}
+	value := keyDef.getter(userConfig)
+	if isZeroValue(value) && effective != nil {
+		value = keyDef.getter(effective)
+	}
+	if isZeroValue(value) {
+		value = keyDef.defaultValue
+	}
+	if s, ok := value.(string); ok {
+		return s
+	}
+	return strings.Trim(formatDefaultValue(value), `"`)
+}
+
+// effectiveStyle returns the style settings in effect with userConfig and
+// the theme it extends, or nil when the theme cannot be loaded.
+func effectiveStyle(userConfig *config.UserConfig) *config.UserConfig {
+	resolved, _, err := config.ResolveUserConfig(userConfig, filepath.Dir(config.GetConfigPath()))
+	if err != nil {
+		return nil
+	}
+	cfg := core.DefaultConfig()
+	config.ApplyUserConfig(cfg, resolved)
+	return config.StyleSettings(cfg)
+}
+
+// renderWizardPreview renders the sample page with the wizard's settings,
+// and the theme they extend, to path.
+func renderWizardPreview(userConfig *config.UserConfig, path string) error {
+	resolved, _, err := config.ResolveUserConfig(userConfig, filepath.Dir(config.GetConfigPath()))
+	if err != nil {
+		return err
+	}
+	// Only the look carries over: no plugins, cache, splitting or extra pages
+	applied := core.DefaultConfig()
+	config.ApplyUserConfig(applied, resolved)
+	cfg := core.DefaultConfig()
+	cfg.Renderer = applied.Renderer
+	cfg.Document = applied.Document
+	cfg.Plugins.Enabled = false
+
+	engine, err := core.NewEngine(cfg)
+	if err != nil {
+		return err
+	}
+	return engine.ConvertSource([]byte(wizardSample), "preview.md", path)
+}
+
+func init() {
+	configCmd.AddCommand(configWizardCmd)
+	configWizardCmd.Flags().StringVar(&configWizardPreviewOut, "preview-out", filepath.Join(os.TempDir(), "md-to-pdf-preview.pdf"), "Where to write the sample page preview")
+	configWizardCmd.Flags().BoolVar(&configWizardNoPreview, "no-preview", false, "Save without rendering a sample page")
+}
@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/fredcamaral/md-to-pdf/internal/config"
+)
+
+func runWizard(t *testing.T, userConfig *config.UserConfig, input string) (string, error) {
+	t.Helper()
+	var out bytes.Buffer
+	wizard := &configWizard{in: bufio.NewReader(strings.NewReader(input)), out: &out}
+	err := wizard.run(userConfig)
+	return out.String(), err
+}
+
+func TestConfigWizard_Answers(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	userConfig := &config.UserConfig{FontSize: 14, Subject: "Old"}
+
+	// Theme, page size, font, size (kept), code font, margins, author,
+	// subject (cleared), date format
+	out, err := runWizard(t, userConfig, "github\nLetter\nTimes\n\nCourier\n25\nJane Smith\n-\n\n")
+	if err != nil {
+		t.Fatalf("run() error: %v", err)
+	}
+
+	if userConfig.Extends != "github" || userConfig.PageSize != "Letter" || userConfig.FontFamily != "Times" {
+		t.Errorf("answers not set: %+v", userConfig)
+	}
+	if userConfig.FontSize != 14 {
+		t.Errorf("FontSize = %v, want the kept 14", userConfig.FontSize)
+	}
+	if userConfig.MarginTop != 25 || userConfig.MarginBottom != 25 || userConfig.MarginLeft != 25 || userConfig.MarginRight != 25 {
+		t.Errorf("margins = %v %v %v %v, want 25 on every side", userConfig.MarginTop, userConfig.MarginBottom, userConfig.MarginLeft, userConfig.MarginRight)
+	}
+	if userConfig.Author != "Jane Smith" || userConfig.Subject != "" {
+		t.Errorf("Author = %q, Subject = %q, want Jane Smith and cleared", userConfig.Author, userConfig.Subject)
+	}
+	if !strings.Contains(out, "Font size in points (for example 11 or 12) [14]: ") {
+		t.Errorf("prompt should show the configured font size, got:\n%s", out)
+	}
+}
+
+func TestConfigWizard_ReasksInvalidAnswers(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	userConfig := &config.UserConfig{}
+
+	out, err := runWizard(t, userConfig, "nope\n\nB7\nA5\n\n200\n\n\n\n\n\n\n")
+	if err != nil {
+		t.Fatalf("run() error: %v", err)
+	}
+	if !strings.Contains(out, `unknown theme "nope"`) {
+		t.Errorf("unknown theme should be explained, got:\n%s", out)
+	}
+	if !strings.Contains(out, "invalid page-size: B7") {
+		t.Errorf("invalid page size should be explained, got:\n%s", out)
+	}
+	if userConfig.Extends != "" || userConfig.PageSize != "A5" || userConfig.FontSize != 0 {
+		t.Errorf("only valid answers should be set: %+v", userConfig)
+	}
+}
+
+func TestConfigWizard_EndOfInput(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	if _, err := runWizard(t, &config.UserConfig{}, "github\n"); err == nil {
+		t.Error("expected an error when input ends before every question is answered")
+	}
+}
+
+func TestConfigWizard_ShowsThemeValues(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	out, err := runWizard(t, &config.UserConfig{}, "compact\n\n\n\n\n\n\n\n\n")
+	if err != nil {
+		t.Fatalf("run() error: %v", err)
+	}
+	if !strings.Contains(out, "Body font (Arial, Times, Helvetica, Courier) [Helvetica]: ") {
+		t.Errorf("prompt should show the theme's font, got:\n%s", out)
+	}
+}
+
+func TestRenderWizardPreview(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	path := filepath.Join(t.TempDir(), "preview.pdf")
+	if err := renderWizardPreview(&config.UserConfig{Extends: "academic", PageSize: "A5"}, path); err != nil {
+		t.Fatalf("renderWizardPreview() error: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("preview not written: %v", err)
+	}
+	if !bytes.HasPrefix(data, []byte("%PDF-")) {
+		t.Error("preview should be a PDF")
+	}
+}
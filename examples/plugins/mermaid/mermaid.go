@@ -6,16 +6,22 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strings"
+	"sync"
 
 	"github.com/fredcamaral/md-to-pdf/pkg/plugin"
 	"github.com/yuin/goldmark/ast"
 )
 
-// MermaidPlugin transforms mermaid code blocks into diagram images
+// MermaidPlugin transforms mermaid code blocks into diagram images. It is
+// safe for concurrent conversions: diagrams are passed to mmdc on stdin and
+// written to unique temporary files that are renamed into place.
 type MermaidPlugin struct {
 	*plugin.BasePlugin
 	outputDir string
-	images    []ImageInfo // Store images to embed
+
+	mu     sync.Mutex
+	images []ImageInfo // Store images to embed
 }
 
 type ImageInfo struct {
@@ -37,18 +43,20 @@ func NewPlugin() plugin.Plugin {
 	}
 }
 
-func (p *MermaidPlugin) Init(config map[string]interface{}) error {
-	// Create output directory for mermaid diagrams
-	err := os.MkdirAll(p.outputDir, 0750)
-	if err != nil {
-		return fmt.Errorf("failed to create mermaid output directory: %w", err)
+// Capabilities declares that the plugin writes diagrams to its output
+// directory and runs the mermaid CLI.
+func (p *MermaidPlugin) Capabilities() plugin.Capabilities {
+	return plugin.Capabilities{
+		Write: []string{p.outputDir},
+		Exec:  []string{"mmdc"},
 	}
+}
 
+func (p *MermaidPlugin) Init(config map[string]interface{}) error {
 	// Check if mermaid CLI is available
-	_, err = exec.LookPath("mmdc")
+	_, err := exec.LookPath("mmdc")
 	if err != nil {
-		fmt.Println("Warning: mermaid CLI (mmdc) not found. Install with: npm install -g @mermaid-js/mermaid-cli")
-		fmt.Println("Mermaid blocks will be rendered as placeholders")
+		p.Logger().Warnf("mermaid CLI (mmdc) not found, mermaid blocks will be rendered as placeholders. Install with: npm install -g @mermaid-js/mermaid-cli")
 	}
 
 	return nil
@@ -74,24 +82,28 @@ func (p *MermaidPlugin) Transform(node ast.Node, ctx *plugin.TransformContext) (
 	}
 
 	// Generate diagram
-	imagePath, err := p.generateDiagram(content)
+	theme, _ := ctx.Config["mermaid_theme"].(string)
+	background, _ := ctx.Config["background_color"].(string)
+	imagePath, err := p.generateDiagram(ctx.Host, content, theme, background)
 	if err != nil {
 		// If diagram generation fails, return original node with error info
-		fmt.Printf("Warning: failed to generate mermaid diagram: %v\n", err)
+		ctx.Logger.Warnf("failed to generate mermaid diagram: %v", err)
 		return node, nil
 	}
 
 	// Store image info for later embedding
+	p.mu.Lock()
 	p.images = append(p.images, ImageInfo{
 		OriginalNode: node,
 		FilePath:     imagePath,
 		Content:      content,
 	})
+	p.mu.Unlock()
 
 	// Create a special marker paragraph that the renderer can recognize
 	paragraph := ast.NewParagraph()
 
-	fmt.Printf("Generated mermaid diagram: %s\n", imagePath)
+	ctx.Logger.Debugf("generated mermaid diagram: %s", imagePath)
 
 	// Store the marker in the paragraph's attributes for the renderer to find
 	paragraph.SetAttribute([]byte("data-mermaid-image"), []byte(imagePath))
@@ -107,64 +119,99 @@ func (p *MermaidPlugin) SupportedNodes() []ast.NodeKind {
 	return []ast.NodeKind{ast.KindFencedCodeBlock}
 }
 
+// FencedLanguages registers the plugin as the handler of mermaid blocks, so
+// they are not reported as code in an unknown language.
+func (p *MermaidPlugin) FencedLanguages() []plugin.FencedLanguage {
+	return []plugin.FencedLanguage{{Name: "mermaid", Kind: plugin.KindDiagram}}
+}
+
 // Note: We don't implement ContentGenerator anymore since we're embedding
 // images directly during AST transformation via paragraph attributes
 
-func (p *MermaidPlugin) generateDiagram(content string) (string, error) {
-	// Generate a unique filename based on content hash
-	hash := sha256.Sum256([]byte(content))
-	filename := fmt.Sprintf("mermaid-%x.png", hash)
-	outputPath := filepath.Join(p.outputDir, filename)
+// diagramVariants maps the mermaid_theme of the document to the mermaid
+// theme and default background of its diagrams. The renderer embeds
+// mermaid-<hash>.dark.png instead of mermaid-<hash>.png for documents with
+// the dark mermaid theme.
+var diagramVariants = map[string]struct {
+	suffix     string
+	theme      string
+	background string
+}{
+	"light": {"", "default", "white"},
+	"dark":  {".dark", "dark", "transparent"},
+}
+
+// generateDiagram renders the variant of a diagram the document embeds, for
+// its mermaid theme ("light" when empty) on its page background ("" for the
+// theme's default), and returns the path the renderer picks the variant
+// from.
+func (p *MermaidPlugin) generateDiagram(host *plugin.Host, content, theme, background string) (string, error) {
+	variant, ok := diagramVariants[theme]
+	if !ok {
+		variant = diagramVariants["light"]
+	}
+	if background == "" {
+		background = variant.background
+	}
+
+	// Create output directory for mermaid diagrams
+	if err := host.MkdirAll(p.outputDir, 0750); err != nil {
+		return "", fmt.Errorf("failed to create mermaid output directory: %w", err)
+	}
+
+	// Generate unique filenames based on content hash, and the background
+	// when it is not the default one
+	key := content
+	if background != variant.background {
+		key += "\x00" + background
+	}
+	hash := sha256.Sum256([]byte(key))
+	base := filepath.Join(p.outputDir, fmt.Sprintf("mermaid-%x", hash))
+	outputPath := base + variant.suffix + ".png"
 
 	// Check if file already exists
 	if _, err := os.Stat(outputPath); err == nil {
-		return outputPath, nil
+		return base + ".png", nil
 	}
 
 	// Try to use mermaid CLI if available
-	if err := p.generateWithCLI(content, outputPath); err == nil {
-		return outputPath, nil
+	if err := p.generateWithCLI(host, content, outputPath, variant.theme, background); err != nil {
+		// Fallback: create a placeholder file
+		return p.createPlaceholder(host, content, outputPath)
 	}
-
-	// Fallback: create a placeholder file
-	return p.createPlaceholder(content, outputPath)
+	return base + ".png", nil
 }
 
-func (p *MermaidPlugin) generateWithCLI(content, outputPath string) error {
+func (p *MermaidPlugin) generateWithCLI(host *plugin.Host, content, outputPath, theme, background string) error {
 	// Check if mmdc is available
 	_, err := exec.LookPath("mmdc")
 	if err != nil {
 		return err
 	}
 
-	// Create temporary input file
-	tempInput := filepath.Join(p.outputDir, "temp.mmd")
-	err = os.WriteFile(tempInput, []byte(content), 0600)
-	if err != nil {
-		return err
-	}
-	defer func() {
-		if err := os.Remove(tempInput); err != nil {
-			fmt.Printf("Warning: failed to remove temp file %s: %v\n", tempInput, err)
+	// Run mermaid CLI, reading the diagram from stdin
+	return writeAtomically(host, outputPath, func(tempPath string) error {
+		cmd, err := host.Command("mmdc", "-i", "-", "-o", tempPath, "-t", theme, "-b", background)
+		if err != nil {
+			return err
 		}
-	}()
-
-	// Run mermaid CLI
-	cmd := exec.Command("mmdc", "-i", tempInput, "-o", outputPath, "-b", "white") // #nosec G204 - command arguments are controlled
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		return fmt.Errorf("mermaid CLI failed: %w, output: %s", err, output)
-	}
-
-	return nil
+		cmd.Stdin = strings.NewReader(content)
+		output, err := cmd.CombinedOutput()
+		if err != nil {
+			return fmt.Errorf("mermaid CLI failed: %w, output: %s", err, output)
+		}
+		return nil
+	})
 }
 
-func (p *MermaidPlugin) createPlaceholder(content, outputPath string) (string, error) {
+func (p *MermaidPlugin) createPlaceholder(host *plugin.Host, content, outputPath string) (string, error) {
 	// Create a simple text file as placeholder
 	placeholderContent := fmt.Sprintf("Mermaid Diagram Placeholder\n\nContent:\n%s\n\nTo generate actual diagrams, install mermaid CLI:\n npm install -g @mermaid-js/mermaid-cli", content)
 
 	placeholderPath := outputPath + ".txt"
-	err := os.WriteFile(placeholderPath, []byte(placeholderContent), 0600)
+	err := writeAtomically(host, placeholderPath, func(tempPath string) error {
+		return host.WriteFile(tempPath, []byte(placeholderContent), 0600)
+	})
 	if err != nil {
 		return "", err
 	}
@@ -172,6 +219,41 @@ func (p *MermaidPlugin) createPlaceholder(content, outputPath string) (string, e
 	return placeholderPath, nil
 }
 
+// writeAtomically lets write create a unique temporary file beside path,
+// then renames it to path. Documents converted at the same time that share
+// a diagram each write their own file, and readers never see a partial one.
+func writeAtomically(host *plugin.Host, path string, write func(tempPath string) error) error {
+	// The temporary name keeps the extension, which mmdc takes the format from
+	temp, err := host.CreateTemp(filepath.Dir(path), ".tmp-*-"+filepath.Base(path))
+	if err != nil {
+		return err
+	}
+	tempPath := temp.Name()
+	if err := temp.Close(); err != nil {
+		_ = host.Remove(tempPath)
+		return err
+	}
+
+	if err := write(tempPath); err != nil {
+		_ = host.Remove(tempPath)
+		return err
+	}
+	if err := host.Rename(tempPath, path); err != nil {
+		_ = host.Remove(tempPath)
+		return err
+	}
+	return nil
+}
+
+// Reset forgets the diagrams of the previous document, so the plugin
+// instance can be reused for the next conversion.
+func (p *MermaidPlugin) Reset() error {
+	p.mu.Lock()
+	p.images = p.images[:0]
+	p.mu.Unlock()
+	return nil
+}
+
 func (p *MermaidPlugin) Cleanup() error {
 	// Optionally clean up temporary files
 	// For now, we'll keep the generated diagrams
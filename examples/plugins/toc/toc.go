@@ -25,25 +25,23 @@ func NewPlugin() plugin.Plugin {
 	}
 }
 
-// Implement ASTTransformer interface
-func (p *TOCPlugin) Transform(node ast.Node, ctx *plugin.TransformContext) (ast.Node, error) {
-	// Collect headings for TOC generation
-	if node.Kind() == ast.KindHeading {
-		heading := node.(*ast.Heading)
-		text := plugin.ExtractText(node, ctx.Source)
-		p.headings = append(p.headings, fmt.Sprintf("%s %s",
-			getHeadingPrefix(heading.Level), text))
-	}
-
-	return node, nil
+// Implement DocumentTransformer interface: the headings are collected from
+// the whole document at once, after the other transformers have run
+func (p *TOCPlugin) TransformDocument(doc ast.Node, ctx *plugin.TransformContext) (ast.Node, error) {
+	err := ast.Walk(doc, func(node ast.Node, entering bool) (ast.WalkStatus, error) {
+		if heading, ok := node.(*ast.Heading); ok && entering {
+			text := plugin.ExtractText(heading, ctx.Source)
+			p.headings = append(p.headings, fmt.Sprintf("%s %s",
+				getHeadingPrefix(heading.Level), text))
+			return ast.WalkSkipChildren, nil
+		}
+		return ast.WalkContinue, nil
+	})
+	return doc, err
 }
 
 func (p *TOCPlugin) Priority() int {
-	return 10 // Lower priority, runs after other transformers
-}
-
-func (p *TOCPlugin) SupportedNodes() []ast.NodeKind {
-	return []ast.NodeKind{ast.KindHeading}
+	return 10
 }
 
 // Implement ContentGenerator interface
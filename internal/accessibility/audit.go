@@ -0,0 +1,156 @@
+// Package accessibility finds problems that make generated PDFs hard to use
+// with screen readers or hard to read: images without alt text, skipped
+// heading levels, links without text and low-contrast colors.
+package accessibility
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/fredcamaral/md-to-pdf/internal/colorutil"
+	"github.com/fredcamaral/md-to-pdf/internal/parser"
+	"github.com/yuin/goldmark/ast"
+)
+
+// Rules reported in issues.
+const (
+	RuleMissingAlt  = "missing-alt"
+	RuleHeadingSkip = "heading-skip"
+	RuleEmptyLink   = "empty-link"
+	RuleLowContrast = "low-contrast"
+)
+
+// MinTextContrast is the WCAG AA contrast ratio for body text.
+const MinTextContrast = 4.5
+
+// MinNonTextContrast is the WCAG AA contrast ratio for graphics, such as
+// rules and borders.
+const MinNonTextContrast = 3.0
+
+// Issue is one accessibility problem.
+type Issue struct {
+	Rule    string
+	Line    int // Source line, or 0 for configuration issues
+	Message string
+}
+
+// String formats the issue as "line N: message", or just the message when
+// it has no line.
+func (i Issue) String() string {
+	if i.Line == 0 {
+		return i.Message
+	}
+	return fmt.Sprintf("line %d: %s", i.Line, i.Message)
+}
+
+// AuditDocument reports the images without alt text, headings that skip a
+// level on the way down (H1 to H3) and links without text of doc, in
+// source order. Inline issues carry the first line of their paragraph.
+func AuditDocument(doc ast.Node, source []byte) []Issue {
+	var issues []Issue
+	previous := 0
+	_ = ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		switch n := n.(type) {
+		case *ast.Heading:
+			if previous > 0 && n.Level > previous+1 {
+				issues = append(issues, Issue{
+					Rule:    RuleHeadingSkip,
+					Line:    line(n, source),
+					Message: fmt.Sprintf("heading level skips from H%d to H%d", previous, n.Level),
+				})
+			}
+			previous = n.Level
+		case *ast.Image:
+			if len(bytes.TrimSpace(n.Text(source))) == 0 {
+				issues = append(issues, Issue{
+					Rule:    RuleMissingAlt,
+					Line:    line(n, source),
+					Message: fmt.Sprintf("image %q has no alt text", n.Destination),
+				})
+			}
+		case *ast.Link:
+			if !hasText(n, source) {
+				issues = append(issues, Issue{
+					Rule:    RuleEmptyLink,
+					Line:    line(n, source),
+					Message: fmt.Sprintf("link to %q has no text", n.Destination),
+				})
+			}
+		}
+		return ast.WalkContinue, nil
+	})
+	return issues
+}
+
+// hasText reports whether a link has text a screen reader can announce:
+// words, the alt text of an image, or a redacted span.
+func hasText(link ast.Node, source []byte) bool {
+	if len(bytes.TrimSpace(link.Text(source))) > 0 {
+		return true
+	}
+	found := false
+	_ = ast.Walk(link, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if _, ok := n.(*parser.Redaction); ok && entering {
+			found = true
+			return ast.WalkStop, nil
+		}
+		return ast.WalkContinue, nil
+	})
+	return found
+}
+
+// line returns the source line of n, or of the block holding it since
+// inline nodes have no position of their own. It returns 0 when unknown.
+func line(n ast.Node, source []byte) int {
+	for ; n != nil; n = n.Parent() {
+		if n.Type() != ast.TypeBlock {
+			continue
+		}
+		if lines := n.Lines(); lines != nil && lines.Len() > 0 {
+			return bytes.Count(source[:lines.At(0).Start], []byte("\n")) + 1
+		}
+	}
+	return 0
+}
+
+// ColorPair is a text or graphics color and the background it is drawn on.
+type ColorPair struct {
+	Name       string // What the colors are used for, e.g. "blockquote text"
+	Foreground string
+	Background string
+	NonText    bool // A rule or border rather than text
+}
+
+// AuditColors reports the pairs whose contrast ratio is below
+// MinTextContrast, or MinNonTextContrast for rules and borders. Pairs with a
+// color that does not parse are skipped; configuration validation reports
+// those.
+func AuditColors(pairs []ColorPair) []Issue {
+	var issues []Issue
+	for _, pair := range pairs {
+		minimum := MinTextContrast
+		if pair.NonText {
+			minimum = MinNonTextContrast
+		}
+		fg, err := colorutil.Parse(pair.Foreground)
+		if err != nil {
+			continue
+		}
+		bg, err := colorutil.Parse(pair.Background)
+		if err != nil {
+			continue
+		}
+		if ratio := colorutil.ContrastRatio(fg, bg); ratio < minimum {
+			issues = append(issues, Issue{
+				Rule: RuleLowContrast,
+				Message: fmt.Sprintf("%s (%s on %s) has a contrast ratio of %.2f:1, below %.1f:1",
+					pair.Name, strings.ToLower(pair.Foreground), strings.ToLower(pair.Background), ratio, minimum),
+			})
+		}
+	}
+	return issues
+}
@@ -0,0 +1,54 @@
+package accessibility
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/fredcamaral/md-to-pdf/internal/parser"
+)
+
+func TestAuditDocument(t *testing.T) {
+	source := "# Guide\n\n### Details\n\nSee ![](chart.png) and ![Sales chart](sales.png).\n\n" +
+		"Text\nwith [](https://example.com) and [ ![](logo.png) ](/home).\n\n" +
+		"[Docs](/docs), [![Logo](logo.png)](/home), [||secret||](/x) and `[]`.\n\n## Usage\n\n#### Flags\n"
+	doc, err := parser.NewMarkdownParser().Parse([]byte(source))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	got := AuditDocument(doc, []byte(source))
+	want := []Issue{
+		{RuleHeadingSkip, 3, "heading level skips from H1 to H3"},
+		{RuleMissingAlt, 5, `image "chart.png" has no alt text`},
+		{RuleEmptyLink, 7, `link to "https://example.com" has no text`},
+		{RuleEmptyLink, 7, `link to "/home" has no text`},
+		{RuleMissingAlt, 7, `image "logo.png" has no alt text`},
+		{RuleHeadingSkip, 14, "heading level skips from H2 to H4"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("AuditDocument() = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("issue %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestAuditColors(t *testing.T) {
+	issues := AuditColors([]ColorPair{
+		{"blockquote text", "black", "#f5f5f5", false},
+		{"rule ornament", "#C8C8C8", "white", true},
+		{"quote border", "#888888", "white", true},
+		{"invalid", "nope", "white", false},
+	})
+	if len(issues) != 1 {
+		t.Fatalf("AuditColors() = %v, want one issue", issues)
+	}
+	if issues[0].Rule != RuleLowContrast || issues[0].Line != 0 {
+		t.Errorf("issue = %+v, want a low-contrast configuration issue", issues[0])
+	}
+	if !strings.Contains(issues[0].String(), "rule ornament (#c8c8c8 on white) has a contrast ratio of 1.67:1, below 3.0:1") {
+		t.Errorf("message = %q", issues[0].String())
+	}
+}
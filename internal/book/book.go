@@ -0,0 +1,351 @@
+// Package book assembles a multi-chapter book described by a book.yaml file
+// into a single Markdown document, so it converts to one PDF with a table of
+// contents and links between chapters.
+package book
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/fredcamaral/md-to-pdf/internal/config"
+	"github.com/fredcamaral/md-to-pdf/internal/outline"
+	"github.com/fredcamaral/md-to-pdf/internal/parser"
+	"github.com/yuin/goldmark/ast"
+	"gopkg.in/yaml.v3"
+)
+
+// Book is the contents of a book.yaml file. Paths are relative to the
+// directory of the file.
+type Book struct {
+	Title   string `yaml:"title"`
+	Author  string `yaml:"author"`
+	Subject string `yaml:"subject"`
+
+	// Output is the PDF written (default: the book file name with .pdf)
+	Output string `yaml:"output"`
+	// Theme is a built-in theme or a config file in the format of
+	// ~/.md-to-pdf/config.yaml applied to every chapter
+	Theme string `yaml:"theme"`
+	// Style holds settings in the same format applied over the theme, to
+	// override some of its values for this book
+	Style config.UserConfig `yaml:"style"`
+	// Cover is a Markdown file or an image on the first page
+	Cover string `yaml:"cover"`
+	// FrontMatter lists Markdown files (preface, acknowledgements) placed
+	// before the table of contents
+	FrontMatter []string  `yaml:"front_matter"`
+	Chapters    []Chapter `yaml:"chapters"`
+
+	// TOC adds a table of contents before the first chapter (default true)
+	TOC      *bool `yaml:"toc"`
+	TOCDepth int   `yaml:"toc_depth"`
+
+	dir string
+}
+
+// Chapter is a chapter file. In book.yaml it is written as its path, or as
+// a mapping with file and shift_headings keys when it has options.
+type Chapter struct {
+	File string `yaml:"file"`
+	// ShiftHeadings demotes the chapter's headings by this many levels, so
+	// it nests as a section of the chapter before it instead of starting a
+	// new page
+	ShiftHeadings int `yaml:"shift_headings"`
+}
+
+// UnmarshalYAML accepts a chapter written as a plain path.
+func (c *Chapter) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		return value.Decode(&c.File)
+	}
+	type plain Chapter
+	return value.Decode((*plain)(c))
+}
+
+// maxHeadingShift is the largest number of levels a chapter can be shifted.
+const maxHeadingShift = 5
+
+// imageExtensions are the cover files placed as an image rather than
+// read as Markdown.
+var imageExtensions = map[string]bool{".png": true, ".jpg": true, ".jpeg": true, ".gif": true, ".svg": true}
+
+const pageBreak = "\n\n<!-- pagebreak -->\n\n"
+
+// Load reads and validates a book file.
+func Load(path string) (*Book, error) {
+	data, err := os.ReadFile(path) // #nosec G304 - book path comes from user CLI input
+	if err != nil {
+		return nil, fmt.Errorf("failed to read book file: %w", err)
+	}
+
+	var b Book
+	if err := yaml.Unmarshal(data, &b); err != nil {
+		return nil, fmt.Errorf("failed to parse book file: %w", err)
+	}
+	b.dir = filepath.Dir(path)
+
+	if len(b.Chapters) == 0 {
+		return nil, fmt.Errorf("%s lists no chapters", path)
+	}
+	if b.TOCDepth < 0 || b.TOCDepth > 6 {
+		return nil, fmt.Errorf("toc_depth must be between 1 and 6")
+	}
+	for _, chapter := range b.Chapters {
+		if chapter.File == "" {
+			return nil, fmt.Errorf("%s lists a chapter without a file", path)
+		}
+		if chapter.ShiftHeadings < -maxHeadingShift || chapter.ShiftHeadings > maxHeadingShift {
+			return nil, fmt.Errorf("%s: shift_headings must be between %d and %d", chapter.File, -maxHeadingShift, maxHeadingShift)
+		}
+	}
+	files := b.files()
+	for _, file := range files {
+		if !isMarkdown(file) {
+			return nil, fmt.Errorf("%s is not a Markdown file", file)
+		}
+	}
+	if b.Cover != "" {
+		files = append(files, b.Cover)
+	}
+	if b.Theme != "" && config.IsThemeFile(b.Theme) {
+		files = append(files, b.Theme)
+	}
+	for _, file := range files {
+		if _, err := os.Stat(b.Path(file)); err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+	}
+	if b.Cover != "" && !isMarkdown(b.Cover) && !imageExtensions[strings.ToLower(filepath.Ext(b.Cover))] {
+		return nil, fmt.Errorf("cover must be a Markdown file or an image: %s", b.Cover)
+	}
+
+	if b.Output == "" {
+		b.Output = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path)) + ".pdf"
+	}
+	return &b, nil
+}
+
+// Path resolves a path from the book file against the book directory.
+func (b *Book) Path(file string) string {
+	if filepath.IsAbs(file) {
+		return file
+	}
+	return filepath.Join(b.dir, file)
+}
+
+// files returns the front matter and chapter files in book order.
+func (b *Book) files() []string {
+	files := append([]string{}, b.FrontMatter...)
+	for _, chapter := range b.Chapters {
+		files = append(files, chapter.File)
+	}
+	return files
+}
+
+// HasTOC reports whether the book gets a table of contents.
+func (b *Book) HasTOC() bool {
+	return b.TOC == nil || *b.TOC
+}
+
+// Assemble joins the cover, front matter, table of contents and chapters
+// into one Markdown document, each starting on a new page except chapters
+// with shifted headings. Links to other files of the book become links to
+// their headings, and relative image paths are made absolute so they
+// resolve from any working directory.
+func (b *Book) Assemble() ([]byte, error) {
+	files := b.files()
+
+	// Headings get their slugs in the order of the assembled document, so a
+	// title repeated across chapters gets the same unique slug the renderer
+	// gives it. targets maps the absolute path of each file to its anchors.
+	slugger := outline.NewSlugger()
+	targets := make(map[string]fileAnchors, len(files)+1)
+	sources := make(map[string][]byte, len(files)+1)
+	cover := ""
+	if b.Cover != "" {
+		var err error
+		cover, err = filepath.Abs(b.Path(b.Cover))
+		if err != nil {
+			return nil, err
+		}
+		if isMarkdown(cover) {
+			content, err := os.ReadFile(cover) // #nosec G304 - cover path comes from the user's book file
+			if err != nil {
+				return nil, fmt.Errorf("failed to read %s: %w", b.Cover, err)
+			}
+			sources[cover] = content
+			targets[cover] = headingAnchors(content, slugger)
+		}
+	}
+	for _, file := range files {
+		path, err := filepath.Abs(b.Path(file))
+		if err != nil {
+			return nil, err
+		}
+		content, err := os.ReadFile(path) // #nosec G304 - chapter paths come from the user's book file
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", file, err)
+		}
+		sources[path] = content
+		targets[path] = headingAnchors(content, slugger)
+	}
+
+	var doc bytes.Buffer
+	if cover != "" {
+		if content, ok := sources[cover]; ok {
+			doc.Write(rewriteLinks(content, cover, targets))
+		} else {
+			fmt.Fprintf(&doc, "![%s](<%s>)", b.Title, filepath.ToSlash(cover))
+		}
+		doc.WriteString(pageBreak)
+	}
+
+	for i, file := range files {
+		if i == len(b.FrontMatter) && b.HasTOC() {
+			doc.WriteString("<!-- toc -->\n\n")
+		}
+		shift := b.headingShift(i)
+		if shift != 0 {
+			fmt.Fprintf(&doc, "<!-- shift-headings: %d -->\n\n", shift)
+		}
+		path, _ := filepath.Abs(b.Path(file))
+		doc.Write(bytes.TrimSpace(rewriteLinks(sources[path], path, targets)))
+		if shift != 0 {
+			doc.WriteString("\n\n<!-- shift-headings: 0 -->")
+		}
+		if i < len(files)-1 {
+			if b.headingShift(i+1) > 0 {
+				doc.WriteString("\n\n")
+			} else {
+				doc.WriteString(pageBreak)
+			}
+		}
+	}
+	doc.WriteString("\n")
+	return doc.Bytes(), nil
+}
+
+// headingShift returns the heading shift of the i-th file of the book.
+func (b *Book) headingShift(i int) int {
+	if i < len(b.FrontMatter) {
+		return 0
+	}
+	return b.Chapters[i-len(b.FrontMatter)].ShiftHeadings
+}
+
+func isMarkdown(file string) bool {
+	switch strings.ToLower(filepath.Ext(file)) {
+	case ".md", ".markdown":
+		return true
+	}
+	return false
+}
+
+// fileAnchors are the anchors of the headings of one file in the assembled
+// book.
+type fileAnchors struct {
+	first string            // Slug of the first heading, the target of links to the file
+	slugs map[string]string // Slug within the file to slug within the book
+}
+
+// headingAnchors gives the headings of content their slugs in the book from
+// slugger, the same way the renderer derives them, and maps the slugs they
+// have in the file on its own to those.
+func headingAnchors(content []byte, slugger *outline.Slugger) fileAnchors {
+	node, _ := parser.NewMarkdownParser().Parse(content)
+	anchors := fileAnchors{slugs: make(map[string]string)}
+	local := outline.NewSlugger()
+	_ = ast.Walk(node, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		heading, ok := n.(*ast.Heading)
+		if !entering || !ok {
+			return ast.WalkContinue, nil
+		}
+		var title strings.Builder
+		_ = ast.Walk(heading, func(c ast.Node, entering bool) (ast.WalkStatus, error) {
+			if text, ok := c.(*ast.Text); ok && entering {
+				title.Write(text.Segment.Value(content))
+			}
+			return ast.WalkContinue, nil
+		})
+		slug := slugger.Slug(title.String())
+		if anchors.first == "" {
+			anchors.first = slug
+		}
+		anchors.slugs[local.Slug(title.String())] = slug
+		return ast.WalkContinue, nil
+	})
+	return anchors
+}
+
+// inlineLink matches [text](destination) and ![alt](destination), with an
+// optional title after the destination.
+var inlineLink = regexp.MustCompile(`(!?)\[([^\]]*)\]\(([^)\s]+)(\s+"[^"]*")?\)`)
+
+// fence matches the opening or closing line of a fenced code block.
+var fence = regexp.MustCompile("^\\s{0,3}(```|~~~)")
+
+// rewriteLinks rewrites the links of the file at path for the assembled
+// book, leaving fenced code blocks untouched.
+func rewriteLinks(content []byte, path string, targets map[string]fileAnchors) []byte {
+	lines := bytes.SplitAfter(content, []byte("\n"))
+	inFence := false
+	for i, line := range lines {
+		if fence.Match(line) {
+			inFence = !inFence
+			continue
+		}
+		if inFence {
+			continue
+		}
+		lines[i] = inlineLink.ReplaceAllFunc(line, func(match []byte) []byte {
+			m := inlineLink.FindSubmatch(match)
+			destination := rewriteDestination(string(m[3]), len(m[1]) > 0, path, targets)
+			return []byte(fmt.Sprintf("%s[%s](%s%s)", m[1], m[2], destination, m[4]))
+		})
+	}
+	return bytes.Join(lines, nil)
+}
+
+// rewriteDestination points links to book files at their headings, links
+// to headings at the slugs they have in the book, and makes relative image
+// paths absolute. Other destinations are unchanged.
+func rewriteDestination(destination string, image bool, file string, targets map[string]fileAnchors) string {
+	if fragment, ok := strings.CutPrefix(destination, "#"); ok {
+		if slug, ok := targets[file].slugs[fragment]; ok {
+			return "#" + slug
+		}
+		return destination
+	}
+	if strings.Contains(destination, "://") ||
+		strings.HasPrefix(destination, "mailto:") || strings.HasPrefix(destination, "data:") {
+		return destination
+	}
+	path, fragment, _ := strings.Cut(destination, "#")
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(filepath.Dir(file), filepath.FromSlash(path))
+	}
+
+	if image {
+		if strings.ContainsAny(path, " ()") {
+			return "<" + filepath.ToSlash(path) + ">"
+		}
+		return filepath.ToSlash(path)
+	}
+	anchors, ok := targets[path]
+	if !ok {
+		return destination
+	}
+	if fragment == "" {
+		fragment = anchors.first
+	} else if slug, ok := anchors.slugs[fragment]; ok {
+		fragment = slug
+	}
+	if fragment == "" {
+		return destination
+	}
+	return "#" + fragment
+}
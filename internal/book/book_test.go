@@ -0,0 +1,213 @@
+package book
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeFiles creates files under dir from a map of relative paths to content.
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"guide.yaml":    "title: Guide\nchapters: [ch/one.md]\n",
+		"ch/one.md":     "# One\n",
+		"missing.yaml":  "chapters: [one.md, two.md]\n",
+		"empty.yaml":    "title: Nothing\n",
+		"notes.yaml":    "chapters: [notes.txt]\n",
+		"notes.txt":     "text",
+		"cover.yaml":    "cover: notes.txt\nchapters: [ch/one.md]\n",
+		"depth.yaml":    "toc_depth: 9\nchapters: [ch/one.md]\n",
+		"settings.yaml": "output: out/guide.pdf\ntoc: false\nchapters: [ch/one.md]\n",
+		"shift.yaml":    "chapters:\n  - file: ch/one.md\n    shift_headings: 7\n",
+		"themed.yaml":   "theme: github\nstyle:\n  font_size: 10\nchapters: [ch/one.md]\n",
+		"nostyle.yaml":  "theme: style/theme.yaml\nchapters: [ch/one.md]\n",
+	})
+
+	b, err := Load(filepath.Join(dir, "guide.yaml"))
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if b.Title != "Guide" || b.Output != "guide.pdf" || !b.HasTOC() {
+		t.Errorf("unexpected book: %+v", b)
+	}
+	if got := b.Path(b.Output); got != filepath.Join(dir, "guide.pdf") {
+		t.Errorf("output should resolve next to the book file, got %s", got)
+	}
+
+	b, err = Load(filepath.Join(dir, "settings.yaml"))
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if b.Output != "out/guide.pdf" || b.HasTOC() {
+		t.Errorf("unexpected book: %+v", b)
+	}
+
+	// A built-in theme is not a file next to the book
+	b, err = Load(filepath.Join(dir, "themed.yaml"))
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if b.Theme != "github" || b.Style.FontSize != 10 {
+		t.Errorf("unexpected book: %+v", b)
+	}
+
+	for name, want := range map[string]string{
+		"missing.yaml": "one.md",
+		"empty.yaml":   "lists no chapters",
+		"notes.yaml":   "not a Markdown file",
+		"cover.yaml":   "cover must be",
+		"depth.yaml":   "toc_depth",
+		"shift.yaml":   "shift_headings must be between -5 and 5",
+		"absent.yaml":  "failed to read book file",
+		"nostyle.yaml": "style/theme.yaml",
+	} {
+		_, err := Load(filepath.Join(dir, name))
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%s: expected error containing %q, got %v", name, want, err)
+		}
+	}
+}
+
+func TestAssemble(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"book.yaml":     "cover: cover.md\nfront_matter: [preface.md]\nchapters: [ch/intro.md, ch/install.md]\n",
+		"cover.md":      "# The Guide\n",
+		"preface.md":    "# Preface\n\nRead [the intro](ch/intro.md) first.\n",
+		"ch/intro.md":   "# Getting *Started*\n\nSee [Linux](install.md#linux), [install](install.md \"Install\") and [site](https://example.com/install.md).\n\n![Logo](../img/logo.png)\n\n```\n[kept](install.md)\n```\n",
+		"ch/install.md": "Intro text.\n\n## Linux\n\nBack to [intro](./intro.md) or [notes](notes.md).\n",
+	})
+
+	b, err := Load(filepath.Join(dir, "book.yaml"))
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	data, err := b.Assemble()
+	if err != nil {
+		t.Fatalf("Assemble failed: %v", err)
+	}
+	doc := string(data)
+
+	order := []string{"# The Guide", "<!-- pagebreak -->", "# Preface", "<!-- toc -->", "# Getting *Started*", "<!-- pagebreak -->", "## Linux"}
+	pos := 0
+	for _, part := range order {
+		i := strings.Index(doc[pos:], part)
+		if i < 0 {
+			t.Fatalf("%q missing or out of order in:\n%s", part, doc)
+		}
+		pos += i + len(part)
+	}
+
+	logo := filepath.ToSlash(filepath.Join(dir, "img", "logo.png"))
+	for _, want := range []string{
+		"[the intro](#getting-started)",
+		"[Linux](#linux)",
+		"[install](#linux \"Install\")",
+		"[site](https://example.com/install.md)",
+		"![Logo](" + logo + ")",
+		"[kept](install.md)",
+		"[intro](#getting-started)",
+		"[notes](notes.md)",
+	} {
+		if !strings.Contains(doc, want) {
+			t.Errorf("expected %q in:\n%s", want, doc)
+		}
+	}
+}
+
+func TestAssemble_ShiftedChapter(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"book.yaml": "chapters:\n  - api.md\n  - file: auth.md\n    shift_headings: 1\n  - cli.md\n",
+		"api.md":    "# API\n",
+		"auth.md":   "# Authentication\n",
+		"cli.md":    "# CLI\n",
+	})
+
+	b, err := Load(filepath.Join(dir, "book.yaml"))
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if len(b.Chapters) != 3 || b.Chapters[1].File != "auth.md" || b.Chapters[1].ShiftHeadings != 1 {
+		t.Fatalf("unexpected chapters: %+v", b.Chapters)
+	}
+	data, err := b.Assemble()
+	if err != nil {
+		t.Fatalf("Assemble failed: %v", err)
+	}
+
+	want := "# API\n\n<!-- shift-headings: 1 -->\n\n# Authentication\n\n<!-- shift-headings: 0 -->\n\n<!-- pagebreak -->\n\n# CLI"
+	if !strings.Contains(string(data), want) {
+		t.Errorf("expected the shifted chapter nested without a page break, got:\n%s", data)
+	}
+}
+
+func TestAssemble_DuplicateHeadings(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"book.yaml": "chapters: [one.md, two.md]\n",
+		"one.md":    "# One\n\n## Overview\n\nSee [its overview](two.md#overview) and [mine](#overview).\n",
+		"two.md":    "# Two\n\n## Overview\n\nBack to [the top](#overview) and [one](one.md#overview).\n",
+	})
+
+	b, err := Load(filepath.Join(dir, "book.yaml"))
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	data, err := b.Assemble()
+	if err != nil {
+		t.Fatalf("Assemble failed: %v", err)
+	}
+
+	for _, want := range []string{
+		"[its overview](#overview-1)",
+		"[mine](#overview)",
+		"[the top](#overview-1)",
+		"[one](#overview)",
+	} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("expected %q in:\n%s", want, data)
+		}
+	}
+}
+
+func TestAssemble_ImageCoverWithoutTOC(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"book.yaml": "title: Guide\ncover: cover.png\ntoc: false\nchapters: [one.md]\n",
+		"cover.png": "not decoded here",
+		"one.md":    "# One\n",
+	})
+
+	b, err := Load(filepath.Join(dir, "book.yaml"))
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	data, err := b.Assemble()
+	if err != nil {
+		t.Fatalf("Assemble failed: %v", err)
+	}
+	doc := string(data)
+	cover := filepath.ToSlash(filepath.Join(dir, "cover.png"))
+	if !strings.HasPrefix(doc, "![Guide](<"+cover+">)") {
+		t.Errorf("expected the cover image first, got:\n%s", doc)
+	}
+	if strings.Contains(doc, "<!-- toc -->") {
+		t.Error("toc: false should leave out the table of contents")
+	}
+}
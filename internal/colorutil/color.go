@@ -0,0 +1,90 @@
+// Package colorutil parses the color values accepted in configuration, such
+// as "#c8c8c8", "#ccc" or "gray".
+package colorutil
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Color is an RGB color with 8-bit channels.
+type Color struct {
+	R, G, B int
+}
+
+// names maps the supported color names to their values.
+var names = map[string]Color{
+	"black":     {0, 0, 0},
+	"white":     {255, 255, 255},
+	"gray":      {128, 128, 128},
+	"grey":      {128, 128, 128},
+	"lightgray": {211, 211, 211},
+	"lightgrey": {211, 211, 211},
+	"darkgray":  {169, 169, 169},
+	"darkgrey":  {169, 169, 169},
+	"red":       {255, 0, 0},
+	"green":     {0, 128, 0},
+	"blue":      {0, 0, 255},
+	"navy":      {0, 0, 128},
+	"teal":      {0, 128, 128},
+	"maroon":    {128, 0, 0},
+	"orange":    {255, 165, 0},
+}
+
+// Parse parses a hex color ("#rrggbb" or "#rgb", the "#" is optional) or one
+// of a small set of color names. Matching is case-insensitive.
+func Parse(value string) (Color, error) {
+	s := strings.ToLower(strings.TrimSpace(value))
+	if c, ok := names[s]; ok {
+		return c, nil
+	}
+
+	hex := strings.TrimPrefix(s, "#")
+	if len(hex) == 3 {
+		hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+	}
+	if len(hex) != 6 {
+		return Color{}, fmt.Errorf("invalid color %q (use #rrggbb, #rgb or a color name)", value)
+	}
+	n, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return Color{}, fmt.Errorf("invalid color %q (use #rrggbb, #rgb or a color name)", value)
+	}
+	return Color{R: int(n >> 16 & 0xff), G: int(n >> 8 & 0xff), B: int(n & 0xff)}, nil
+}
+
+// IsValid reports whether value is a color Parse accepts.
+func IsValid(value string) bool {
+	_, err := Parse(value)
+	return err == nil
+}
+
+// Hex formats the color as "#rrggbb".
+func (c Color) Hex() string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
+
+// Luminance returns the relative luminance of the color, from 0 for black
+// to 1 for white, as defined by WCAG 2.
+func (c Color) Luminance() float64 {
+	channel := func(v int) float64 {
+		s := float64(v) / 255
+		if s <= 0.03928 {
+			return s / 12.92
+		}
+		return math.Pow((s+0.055)/1.055, 2.4)
+	}
+	return 0.2126*channel(c.R) + 0.7152*channel(c.G) + 0.0722*channel(c.B)
+}
+
+// ContrastRatio returns the WCAG 2 contrast ratio of two colors, from 1 for
+// identical colors to 21 for black on white. The order does not matter.
+func ContrastRatio(a, b Color) float64 {
+	lighter, darker := a.Luminance(), b.Luminance()
+	if darker > lighter {
+		lighter, darker = darker, lighter
+	}
+	return (lighter + 0.05) / (darker + 0.05)
+}
@@ -0,0 +1,61 @@
+package colorutil
+
+import (
+	"math"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		input string
+		want  Color
+	}{
+		{"#c8c8c8", Color{200, 200, 200}},
+		{"C8C8C8", Color{200, 200, 200}},
+		{"#f80", Color{255, 136, 0}},
+		{"Navy", Color{0, 0, 128}},
+		{" gray ", Color{128, 128, 128}},
+	}
+	for _, tt := range tests {
+		got, err := Parse(tt.input)
+		if err != nil {
+			t.Errorf("Parse(%q) returned error: %v", tt.input, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Parse(%q) = %+v, want %+v", tt.input, got, tt.want)
+		}
+	}
+
+	for _, input := range []string{"", "#12345", "#gggggg", "chartreuse-ish"} {
+		if IsValid(input) {
+			t.Errorf("Parse(%q) should fail", input)
+		}
+	}
+}
+
+func TestHex(t *testing.T) {
+	if got := (Color{200, 8, 255}).Hex(); got != "#c808ff" {
+		t.Errorf("Hex() = %q, want %q", got, "#c808ff")
+	}
+}
+
+func TestContrastRatio(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want float64
+	}{
+		{"black", "white", 21},
+		{"white", "black", 21},
+		{"#777", "white", 4.48},
+		{"#c8c8c8", "white", 1.67},
+		{"navy", "navy", 1},
+	}
+	for _, tt := range tests {
+		a, _ := Parse(tt.a)
+		b, _ := Parse(tt.b)
+		if got := ContrastRatio(a, b); math.Abs(got-tt.want) > 0.01 {
+			t.Errorf("ContrastRatio(%s, %s) = %.2f, want %.2f", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
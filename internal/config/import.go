@@ -0,0 +1,308 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/fredcamaral/md-to-pdf/internal/core"
+	"gopkg.in/yaml.v3"
+)
+
+// ImportFormats lists the tools whose configuration can be imported.
+var ImportFormats = []string{"pandoc", "mdpdf"}
+
+// ImportedSetting is an option of another tool mapped to a config key.
+type ImportedSetting struct {
+	Key    string // Config key, as for "config set" (e.g. "margin-top")
+	Value  string
+	Source string // Option it was mapped from (e.g. "variables.geometry")
+}
+
+// ImportResult holds the settings mapped from another tool's configuration
+// and the options without an equivalent, with the reason.
+type ImportResult struct {
+	Settings []ImportedSetting
+	Ignored  []string
+}
+
+func (r *ImportResult) set(key, value, source string) {
+	r.Settings = append(r.Settings, ImportedSetting{Key: key, Value: value, Source: source})
+}
+
+func (r *ImportResult) ignore(format string, args ...interface{}) {
+	r.Ignored = append(r.Ignored, fmt.Sprintf(format, args...))
+}
+
+// ImportConfig maps the configuration of another tool, in the given format,
+// to config keys: a pandoc defaults file, or mdpdf options as JSON or YAML.
+func ImportConfig(format string, data []byte) (*ImportResult, error) {
+	var options map[string]interface{}
+	if err := yaml.Unmarshal(data, &options); err != nil {
+		return nil, fmt.Errorf("failed to parse %s configuration: %w", format, err)
+	}
+
+	result := &ImportResult{}
+	switch format {
+	case "pandoc":
+		importPandoc(options, result)
+	case "mdpdf":
+		importMdpdf(options, result)
+	default:
+		return nil, fmt.Errorf("unknown format %q (valid: %s)", format, strings.Join(ImportFormats, ", "))
+	}
+	return result, nil
+}
+
+// importPandoc maps a pandoc defaults file. Layout options are template
+// variables, document information is metadata.
+func importPandoc(options map[string]interface{}, result *ImportResult) {
+	for _, key := range sortedKeys(options) {
+		value := options[key]
+		switch key {
+		case "variables":
+			importPandocVariables(asMap(value), result)
+		case "metadata":
+			importPandocMetadata(asMap(value), result)
+		case "toc", "table-of-contents":
+			result.set("toc", scalar(value), key)
+		case "toc-depth":
+			result.set("toc-depth", scalar(value), key)
+		case "shift-heading-level-by":
+			result.set("shift-headings", scalar(value), key)
+		default:
+			result.ignore("%s: no md-to-pdf equivalent", key)
+		}
+	}
+}
+
+func importPandocVariables(variables map[string]interface{}, result *ImportResult) {
+	for _, key := range sortedKeys(variables) {
+		value := variables[key]
+		source := "variables." + key
+		switch key {
+		case "papersize":
+			importPageSize(scalar(value), source, result)
+		case "geometry":
+			importGeometry(value, source, result)
+		case "margin-top", "margin-bottom", "margin-left", "margin-right":
+			importMargin(key, scalar(value), source, result)
+		case "mainfont":
+			importFont("font-family", scalar(value), source, result)
+		case "monofont":
+			importFont("code-font", scalar(value), source, result)
+		case "fontsize":
+			if size, ok := parseLength(scalar(value), "pt"); ok {
+				result.set("font-size", formatNumber(size), source)
+			} else {
+				result.ignore("%s: %q is not a size in points", source, scalar(value))
+			}
+		case "linestretch":
+			result.set("line-spacing", scalar(value), source)
+		case "toc-title":
+			result.set("toc-title", scalar(value), source)
+		case "title", "author", "subject":
+			importMetadata(key, value, source, result)
+		default:
+			result.ignore("%s: no md-to-pdf equivalent", source)
+		}
+	}
+}
+
+func importPandocMetadata(metadata map[string]interface{}, result *ImportResult) {
+	for _, key := range sortedKeys(metadata) {
+		source := "metadata." + key
+		switch key {
+		case "title", "author", "subject":
+			importMetadata(key, metadata[key], source, result)
+		case "toc-title":
+			result.set("toc-title", scalar(metadata[key]), source)
+		default:
+			result.ignore("%s: no md-to-pdf equivalent", source)
+		}
+	}
+}
+
+// importGeometry maps the options of the LaTeX geometry package, given as
+// one comma-separated string or a list.
+func importGeometry(value interface{}, source string, result *ImportResult) {
+	var options []string
+	if list, ok := value.([]interface{}); ok {
+		for _, item := range list {
+			options = append(options, strings.Split(scalar(item), ",")...)
+		}
+	} else {
+		options = strings.Split(scalar(value), ",")
+	}
+
+	sides := map[string][]string{
+		"margin":  {"top", "bottom", "left", "right"},
+		"vmargin": {"top", "bottom"},
+		"hmargin": {"left", "right"},
+		"top":     {"top"},
+		"bottom":  {"bottom"},
+		"left":    {"left"},
+		"right":   {"right"},
+		"inner":   {"left"},
+		"outer":   {"right"},
+	}
+	for _, option := range options {
+		option = strings.TrimSpace(option)
+		name, length, _ := strings.Cut(option, "=")
+		if strings.HasSuffix(name, "paper") {
+			importPageSize(strings.TrimSuffix(name, "paper"), source, result)
+			continue
+		}
+		margins, ok := sides[strings.TrimSpace(name)]
+		if !ok {
+			result.ignore("%s: %q has no md-to-pdf equivalent", source, option)
+			continue
+		}
+		for _, side := range margins {
+			importMargin("margin-"+side, length, source, result)
+		}
+	}
+}
+
+// importMdpdf maps mdpdf options, given at the top level or under "pdf"
+// as in its programmatic API.
+func importMdpdf(options map[string]interface{}, result *ImportResult) {
+	for _, key := range sortedKeys(options) {
+		value := options[key]
+		switch key {
+		case "pdf":
+			importMdpdf(asMap(value), result)
+		case "format":
+			importPageSize(scalar(value), key, result)
+		case "border":
+			if sides, ok := value.(map[string]interface{}); ok {
+				for _, side := range sortedKeys(sides) {
+					importMargin("margin-"+side, scalar(sides[side]), key+"."+side, result)
+				}
+				continue
+			}
+			for _, side := range []string{"top", "bottom", "left", "right"} {
+				importMargin("margin-"+side, scalar(value), key, result)
+			}
+		case "border-top", "border-bottom", "border-left", "border-right":
+			importMargin("margin-"+strings.TrimPrefix(key, "border-"), scalar(value), key, result)
+		case "borderTop", "borderBottom", "borderLeft", "borderRight":
+			importMargin("margin-"+strings.ToLower(strings.TrimPrefix(key, "border")), scalar(value), key, result)
+		default:
+			result.ignore("%s: no md-to-pdf equivalent", key)
+		}
+	}
+}
+
+// importPageSize maps a paper size name such as "a4" or "letter".
+func importPageSize(name, source string, result *ImportResult) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "a3":
+		result.set("page-size", "A3", source)
+	case "a4":
+		result.set("page-size", "A4", source)
+	case "a5":
+		result.set("page-size", "A5", source)
+	case "letter", "us-letter":
+		result.set("page-size", "Letter", source)
+	case "legal", "us-legal":
+		result.set("page-size", "Legal", source)
+	case "tabloid":
+		result.set("page-size", "Tabloid", source)
+	default:
+		result.ignore("%s: paper size %q is not supported", source, name)
+	}
+}
+
+// importMargin maps a length with a unit to a margin key in millimeters.
+func importMargin(key, length, source string, result *ImportResult) {
+	mm, ok := parseLength(length, "mm")
+	if !ok {
+		result.ignore("%s: %q is not a length with a unit such as 2cm or 1in", source, length)
+		return
+	}
+	result.set(key, formatNumber(mm), source)
+}
+
+// coreFonts maps the names of the font families every PDF viewer provides,
+// which are the only ones md-to-pdf renders with, to their config value.
+var coreFonts = map[string]string{
+	"arial":           "Arial",
+	"courier":         "Courier",
+	"courier new":     "Courier",
+	"helvetica":       "Helvetica",
+	"times":           "Times",
+	"times new roman": "Times",
+}
+
+func importFont(key, family, source string, result *ImportResult) {
+	if font, ok := coreFonts[strings.ToLower(strings.TrimSpace(family))]; ok {
+		result.set(key, font, source)
+		return
+	}
+	result.ignore("%s: font %q is not one of %s", source, family, strings.Join(core.BuiltinFonts, ", "))
+}
+
+// importMetadata maps a title, author or subject; several authors are
+// joined with commas.
+func importMetadata(key string, value interface{}, source string, result *ImportResult) {
+	if list, ok := value.([]interface{}); ok {
+		names := make([]string, 0, len(list))
+		for _, item := range list {
+			// An author may be a map with a name
+			if author, ok := item.(map[string]interface{}); ok {
+				item = author["name"]
+			}
+			names = append(names, scalar(item))
+		}
+		result.set(key, strings.Join(names, ", "), source)
+		return
+	}
+	result.set(key, scalar(value), source)
+}
+
+// parseLength converts a length such as "2cm", "1in" or "72pt" to unit,
+// "mm" or "pt". A length without a unit is not accepted.
+func parseLength(length, unit string) (float64, bool) {
+	perMM := map[string]float64{"mm": 1, "cm": 10, "in": 25.4, "pt": 25.4 / 72}
+	length = strings.TrimSpace(length)
+	for suffix, factor := range perMM {
+		if number, ok := strings.CutSuffix(length, suffix); ok {
+			value, err := strconv.ParseFloat(strings.TrimSpace(number), 64)
+			if err != nil {
+				return 0, false
+			}
+			return value * factor / perMM[unit], true
+		}
+	}
+	return 0, false
+}
+
+// formatNumber formats a converted length to two decimals, without
+// trailing zeros.
+func formatNumber(value float64) string {
+	return strconv.FormatFloat(float64(int64(value*100+0.5))/100, 'f', -1, 64)
+}
+
+// scalar returns a YAML scalar as a string.
+func scalar(value interface{}) string {
+	if value == nil {
+		return ""
+	}
+	return fmt.Sprint(value)
+}
+
+func asMap(value interface{}) map[string]interface{} {
+	m, _ := value.(map[string]interface{})
+	return m
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,108 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// settingMap returns the imported settings by key.
+func settingMap(result *ImportResult) map[string]string {
+	settings := make(map[string]string)
+	for _, setting := range result.Settings {
+		settings[setting.Key] = setting.Value
+	}
+	return settings
+}
+
+func TestImportConfig_Pandoc(t *testing.T) {
+	defaults := `
+toc: true
+toc-depth: 2
+pdf-engine: xelatex
+variables:
+  papersize: letter
+  geometry: "margin=1in,top=2cm"
+  mainfont: Times New Roman
+  monofont: Fira Code
+  fontsize: 11pt
+metadata:
+  title: User Guide
+  author: [Ada, Grace]
+`
+	result, err := ImportConfig("pandoc", []byte(defaults))
+	if err != nil {
+		t.Fatalf("ImportConfig() error: %v", err)
+	}
+
+	want := map[string]string{
+		"toc":           "true",
+		"toc-depth":     "2",
+		"page-size":     "Letter",
+		"margin-top":    "20",
+		"margin-bottom": "25.4",
+		"margin-left":   "25.4",
+		"margin-right":  "25.4",
+		"font-family":   "Times",
+		"font-size":     "11",
+		"title":         "User Guide",
+		"author":        "Ada, Grace",
+	}
+	if got := settingMap(result); !reflect.DeepEqual(got, want) {
+		t.Errorf("settings = %v, want %v", got, want)
+	}
+
+	ignored := strings.Join(result.Ignored, "\n")
+	for _, option := range []string{"pdf-engine", "variables.monofont"} {
+		if !strings.Contains(ignored, option) {
+			t.Errorf("%s should be listed as ignored, got %v", option, result.Ignored)
+		}
+	}
+}
+
+func TestImportConfig_Mdpdf(t *testing.T) {
+	options := `{"format": "A5", "ghStyle": true, "pdf": {"border": {"top": "0.5in", "left": "12mm"}}}`
+	result, err := ImportConfig("mdpdf", []byte(options))
+	if err != nil {
+		t.Fatalf("ImportConfig() error: %v", err)
+	}
+
+	want := map[string]string{"page-size": "A5", "margin-top": "12.7", "margin-left": "12"}
+	if got := settingMap(result); !reflect.DeepEqual(got, want) {
+		t.Errorf("settings = %v, want %v", got, want)
+	}
+	if len(result.Ignored) != 1 || !strings.HasPrefix(result.Ignored[0], "ghStyle") {
+		t.Errorf("Ignored = %v, want ghStyle", result.Ignored)
+	}
+}
+
+func TestImportConfig_Errors(t *testing.T) {
+	if _, err := ImportConfig("grip", []byte("{}")); err == nil || !strings.Contains(err.Error(), "unknown format") {
+		t.Errorf("expected an unknown format error, got %v", err)
+	}
+	if _, err := ImportConfig("pandoc", []byte("variables: [")); err == nil {
+		t.Error("expected a parse error")
+	}
+}
+
+func TestParseLength(t *testing.T) {
+	tests := []struct {
+		length string
+		unit   string
+		want   float64
+		ok     bool
+	}{
+		{"2cm", "mm", 20, true},
+		{"1in", "mm", 25.4, true},
+		{" 15 mm", "mm", 15, true},
+		{"12pt", "pt", 12, true},
+		{"20", "mm", 0, false},
+		{"wide", "mm", 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := parseLength(tt.length, tt.unit)
+		if ok != tt.ok || (ok && formatNumber(got) != formatNumber(tt.want)) {
+			t.Errorf("parseLength(%q, %q) = %v, %v, want %v, %v", tt.length, tt.unit, got, ok, tt.want, tt.ok)
+		}
+	}
+}
@@ -1,11 +1,15 @@
 package config
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 
 	"github.com/fredcamaral/md-to-pdf/internal/core"
+	"github.com/fredcamaral/md-to-pdf/internal/plugins"
 	"gopkg.in/yaml.v3"
 )
 
@@ -15,32 +19,151 @@ const (
 )
 
 type UserConfig struct {
+	// Extends names a built-in theme or a theme file whose settings apply
+	// under these ones
+	Extends string `yaml:"extends,omitempty"`
+
 	// Typography & Fonts
-	FontFamily   string  `yaml:"font_family,omitempty"`
-	FontSize     float64 `yaml:"font_size,omitempty"`
-	HeadingScale float64 `yaml:"heading_scale,omitempty"`
-	LineSpacing  float64 `yaml:"line_spacing,omitempty"`
+	FontFamily     string  `yaml:"font_family,omitempty"`
+	FontSize       float64 `yaml:"font_size,omitempty"`
+	HeadingScale   float64 `yaml:"heading_scale,omitempty"`
+	HeadingMinSize float64 `yaml:"heading_min_size,omitempty"`
+	ShiftHeadings  int     `yaml:"shift_headings,omitempty"`
+	LineSpacing    float64 `yaml:"line_spacing,omitempty"`
+
+	// Heading levels remapped by source level (e.g. 1: 2), in place of
+	// shift_headings for the listed levels
+	HeadingMap map[int]int `yaml:"heading_map,omitempty"`
+
+	// Names whose <!-- if:name --> blocks are kept
+	Defines []string `yaml:"defines,omitempty"`
 
 	// Code styling
 	CodeFont string  `yaml:"code_font,omitempty"`
 	CodeSize float64 `yaml:"code_size,omitempty"`
 
+	// Colors (empty keeps black text on a white page)
+	TextColor       string `yaml:"text_color,omitempty"`
+	BackgroundColor string `yaml:"background_color,omitempty"`
+	LinkColor       string `yaml:"link_color,omitempty"`
+	CodeColor       string `yaml:"code_color,omitempty"`
+	CodeBackground  string `yaml:"code_background,omitempty"`
+	ImageBackdrop   string `yaml:"image_backdrop,omitempty"`
+
 	// Page layout
 	PageSize     string  `yaml:"page_size,omitempty"`
 	MarginTop    float64 `yaml:"margin_top,omitempty"`
 	MarginBottom float64 `yaml:"margin_bottom,omitempty"`
 	MarginLeft   float64 `yaml:"margin_left,omitempty"`
 	MarginRight  float64 `yaml:"margin_right,omitempty"`
+	BaselineGrid bool    `yaml:"baseline_grid,omitempty"`
+	SidenoteSide string  `yaml:"sidenote_side,omitempty"`
+
+	// Heading widow control
+	KeepWithNext         string `yaml:"keep_with_next,omitempty"`
+	MinLinesAfterHeading int    `yaml:"min_lines_after_heading,omitempty"`
 
 	// PDF metadata
-	Title   string `yaml:"title,omitempty"`
-	Author  string `yaml:"author,omitempty"`
-	Subject string `yaml:"subject,omitempty"`
+	Title      string `yaml:"title,omitempty"`
+	Author     string `yaml:"author,omitempty"`
+	Subject    string `yaml:"subject,omitempty"`
+	DateFormat string `yaml:"date_format,omitempty"`
+	// TitleFromH1 uses the leading H1 as the title and output file name
+	TitleFromH1 bool `yaml:"title_from_h1,omitempty"`
 
 	// Mermaid settings
-	MermaidScale     float64 `yaml:"mermaid_scale,omitempty"`
-	MermaidMaxWidth  float64 `yaml:"mermaid_max_width,omitempty"`
-	MermaidMaxHeight float64 `yaml:"mermaid_max_height,omitempty"`
+	MermaidScale        float64 `yaml:"mermaid_scale,omitempty"`
+	MermaidMaxWidth     float64 `yaml:"mermaid_max_width,omitempty"`
+	MermaidMaxHeight    float64 `yaml:"mermaid_max_height,omitempty"`
+	MermaidTheme        string  `yaml:"mermaid_theme,omitempty"`
+	MermaidWideStrategy string  `yaml:"mermaid_wide_strategy,omitempty"`
+
+	// Header & footer (markdown snippets)
+	Header      string `yaml:"header,omitempty"`
+	Footer      string `yaml:"footer,omitempty"`
+	HeaderAlign string `yaml:"header_align,omitempty"`
+	FooterAlign string `yaml:"footer_align,omitempty"`
+
+	// Thematic breaks (horizontal rules)
+	RuleStyle     string  `yaml:"rule_style,omitempty"`
+	RuleThickness float64 `yaml:"rule_thickness,omitempty"`
+	RuleColor     string  `yaml:"rule_color,omitempty"`
+	RuleWidth     float64 `yaml:"rule_width,omitempty"`
+	RuleOrnament  string  `yaml:"rule_ornament,omitempty"`
+
+	// Blockquotes
+	QuoteStyle QuoteStyle `yaml:"quote_style,omitempty"`
+
+	// Classification banner
+	Banner Banner `yaml:"banner,omitempty"`
+
+	// Table of contents
+	TOC      bool   `yaml:"toc,omitempty"`
+	TOCDepth int    `yaml:"toc_depth,omitempty"`
+	TOCTitle string `yaml:"toc_title,omitempty"`
+
+	// Security
+	ImagePolicy string `yaml:"image_policy,omitempty"`
+
+	// Output
+	MaxOutputSize  string `yaml:"max_output_size,omitempty"`
+	Linearize      bool   `yaml:"linearize,omitempty"`
+	Manifest       bool   `yaml:"manifest,omitempty"`
+	CacheDir       string `yaml:"cache_dir,omitempty"`
+	SummaryPage    bool   `yaml:"summary_page,omitempty"`
+	SummaryRepoURL string `yaml:"summary_repo_url,omitempty"`
+	SourceAppendix bool   `yaml:"source_appendix,omitempty"`
+	OutputMode     string `yaml:"output_mode,omitempty"`
+	PreserveMode   bool   `yaml:"preserve_mode,omitempty"`
+	OnCollision    string `yaml:"on_collision,omitempty"`
+
+	// Network
+	NetworkTimeout     int     `yaml:"network_timeout,omitempty"`
+	Proxy              string  `yaml:"proxy,omitempty"`
+	Offline            bool    `yaml:"offline,omitempty"`
+	NetworkRateLimit   float64 `yaml:"network_rate_limit,omitempty"`
+	NetworkAttempts    int     `yaml:"network_attempts,omitempty"`
+	NetworkConcurrency int     `yaml:"network_concurrency,omitempty"`
+
+	// Capabilities granted to plugins, keyed by plugin name
+	PluginGrants map[string]PluginGrant `yaml:"plugin_grants,omitempty"`
+
+	// Per-locale overrides for multi-language builds, keyed by locale code
+	Locales map[string]LocaleUserConfig `yaml:"locales,omitempty"`
+}
+
+// LocaleUserConfig holds the overrides applied when building one locale.
+type LocaleUserConfig struct {
+	FontFamily   string            `yaml:"font_family,omitempty"`
+	Title        string            `yaml:"title,omitempty"`
+	Author       string            `yaml:"author,omitempty"`
+	Subject      string            `yaml:"subject,omitempty"`
+	DateFormat   string            `yaml:"date_format,omitempty"`
+	Translations map[string]string `yaml:"translations,omitempty"`
+}
+
+// PluginGrant lists the capabilities granted to one plugin. Once any grant
+// is configured, plugins without one get no capabilities.
+type PluginGrant struct {
+	Read    []string `yaml:"read,omitempty"`
+	Write   []string `yaml:"write,omitempty"`
+	Network bool     `yaml:"network,omitempty"`
+	Exec    []string `yaml:"exec,omitempty"`
+}
+
+// QuoteStyle is the quote_style block of the config file.
+type QuoteStyle struct {
+	BarColor   string `yaml:"bar_color,omitempty"`
+	Background string `yaml:"background,omitempty"`
+	FontStyle  string `yaml:"font_style,omitempty"`
+}
+
+// Banner is the banner block of the config file.
+type Banner struct {
+	Text       string `yaml:"text,omitempty"`
+	Position   string `yaml:"position,omitempty"`
+	Color      string `yaml:"color,omitempty"`
+	Background string `yaml:"background,omitempty"`
 }
 
 func GetConfigPath() string {
@@ -59,20 +182,63 @@ func LoadUserConfig() (*UserConfig, error) {
 		return &UserConfig{}, nil
 	}
 
-	data, err := os.ReadFile(configPath) // #nosec G304 - config path is generated from user's home directory
+	return LoadUserConfigFile(configPath)
+}
+
+// LoadUserConfigFile reads settings in the user config format from path,
+// such as a theme shared by the chapters of a book.
+func LoadUserConfigFile(configPath string) (*UserConfig, error) {
+	config, _, err := readUserConfigFile(configPath)
+	return config, err
+}
+
+// readUserConfigFile is LoadUserConfigFile, also returning the settings as
+// written in the file.
+func readUserConfigFile(configPath string) (*UserConfig, []byte, error) {
+	data, err := os.ReadFile(configPath) // #nosec G304 - config path is the user's config or a file they named
 	if err != nil {
-		return nil, fmt.Errorf("failed to read config file: %w", err)
+		return nil, nil, fmt.Errorf("failed to read config file: %w", err)
 	}
 
 	var config UserConfig
 	err = yaml.Unmarshal(data, &config)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse config file: %w", err)
+		return nil, nil, fmt.Errorf("failed to parse config file: %w", err)
 	}
 
+	return &config, data, nil
+}
+
+// ParseUserConfig parses settings in the user config format, as YAML or
+// JSON, for callers that pass settings rather than files. Unknown settings
+// are an error.
+func ParseUserConfig(data []byte) (*UserConfig, error) {
+	var config UserConfig
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&config); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("invalid settings: %w", err)
+	}
 	return &config, nil
 }
 
+// ConfigFromSettings returns the default configuration with settings in the
+// user config format, and the theme they extend, applied over it. Relative
+// theme files are resolved against the working directory.
+func ConfigFromSettings(data []byte) (*core.Config, error) {
+	userConfig, err := ParseUserConfig(data)
+	if err != nil {
+		return nil, err
+	}
+	resolved, _, err := resolveUserSettings(userConfig, data, ".")
+	if err != nil {
+		return nil, err
+	}
+	cfg := core.DefaultConfig()
+	ApplyUserConfig(cfg, resolved)
+	return cfg, nil
+}
+
 func SaveUserConfig(config *UserConfig) error {
 	configPath := GetConfigPath()
 	configDir := filepath.Dir(configPath)
@@ -107,6 +273,18 @@ func ApplyUserConfig(baseConfig *core.Config, userConfig *UserConfig) {
 	if userConfig.HeadingScale > 0 {
 		baseConfig.Renderer.HeadingScale = userConfig.HeadingScale
 	}
+	if userConfig.HeadingMinSize > 0 {
+		baseConfig.Renderer.HeadingMinSize = userConfig.HeadingMinSize
+	}
+	if userConfig.ShiftHeadings != 0 {
+		baseConfig.Parser.HeadingShift = userConfig.ShiftHeadings
+	}
+	if len(userConfig.HeadingMap) > 0 {
+		baseConfig.Parser.HeadingMap = userConfig.HeadingMap
+	}
+	if len(userConfig.Defines) > 0 {
+		baseConfig.Parser.Defines = userConfig.Defines
+	}
 	if userConfig.LineSpacing > 0 {
 		baseConfig.Renderer.LineSpacing = userConfig.LineSpacing
 	}
@@ -135,6 +313,18 @@ func ApplyUserConfig(baseConfig *core.Config, userConfig *UserConfig) {
 	if userConfig.MarginRight > 0 {
 		baseConfig.Renderer.Margins.Right = userConfig.MarginRight
 	}
+	if userConfig.BaselineGrid {
+		baseConfig.Renderer.BaselineGrid = true
+	}
+	if userConfig.SidenoteSide != "" {
+		baseConfig.Renderer.SidenoteSide = userConfig.SidenoteSide
+	}
+	if userConfig.KeepWithNext != "" {
+		baseConfig.Renderer.KeepWithNext = userConfig.KeepWithNext
+	}
+	if userConfig.MinLinesAfterHeading > 0 {
+		baseConfig.Renderer.MinLinesAfterHeading = userConfig.MinLinesAfterHeading
+	}
 
 	// PDF metadata
 	if userConfig.Title != "" {
@@ -146,6 +336,12 @@ func ApplyUserConfig(baseConfig *core.Config, userConfig *UserConfig) {
 	if userConfig.Subject != "" {
 		baseConfig.Document.Subject = userConfig.Subject
 	}
+	if userConfig.DateFormat != "" {
+		baseConfig.Document.DateFormat = userConfig.DateFormat
+	}
+	if userConfig.TitleFromH1 {
+		baseConfig.Document.TitleFromH1 = true
+	}
 
 	// Mermaid settings
 	if userConfig.MermaidScale > 0 {
@@ -157,4 +353,182 @@ func ApplyUserConfig(baseConfig *core.Config, userConfig *UserConfig) {
 	if userConfig.MermaidMaxHeight > 0 {
 		baseConfig.Renderer.Mermaid.MaxHeight = userConfig.MermaidMaxHeight
 	}
+	if userConfig.MermaidTheme != "" {
+		baseConfig.Renderer.Mermaid.Theme = userConfig.MermaidTheme
+	}
+	if userConfig.MermaidWideStrategy != "" {
+		baseConfig.Renderer.Mermaid.WideStrategy = userConfig.MermaidWideStrategy
+	}
+
+	// Header & footer
+	if userConfig.Header != "" {
+		baseConfig.Renderer.HeaderFooter.Header = userConfig.Header
+	}
+	if userConfig.Footer != "" {
+		baseConfig.Renderer.HeaderFooter.Footer = userConfig.Footer
+	}
+	if userConfig.HeaderAlign != "" {
+		baseConfig.Renderer.HeaderFooter.HeaderAlign = userConfig.HeaderAlign
+	}
+	if userConfig.FooterAlign != "" {
+		baseConfig.Renderer.HeaderFooter.FooterAlign = userConfig.FooterAlign
+	}
+
+	// Thematic breaks
+	if userConfig.RuleStyle != "" {
+		baseConfig.Renderer.ThematicBreak.Style = userConfig.RuleStyle
+	}
+	if userConfig.RuleThickness != 0 {
+		baseConfig.Renderer.ThematicBreak.Thickness = userConfig.RuleThickness
+	}
+	if userConfig.RuleColor != "" {
+		baseConfig.Renderer.ThematicBreak.Color = userConfig.RuleColor
+	}
+	if userConfig.RuleWidth != 0 {
+		baseConfig.Renderer.ThematicBreak.Width = userConfig.RuleWidth
+	}
+	if userConfig.RuleOrnament != "" {
+		baseConfig.Renderer.ThematicBreak.Ornament = userConfig.RuleOrnament
+	}
+
+	// Blockquotes
+	if userConfig.QuoteStyle.BarColor != "" {
+		baseConfig.Renderer.QuoteStyle.BarColor = userConfig.QuoteStyle.BarColor
+	}
+	if userConfig.QuoteStyle.Background != "" {
+		baseConfig.Renderer.QuoteStyle.Background = userConfig.QuoteStyle.Background
+	}
+	if userConfig.QuoteStyle.FontStyle != "" {
+		baseConfig.Renderer.QuoteStyle.FontStyle = userConfig.QuoteStyle.FontStyle
+	}
+
+	// Classification banner
+	if userConfig.Banner.Text != "" {
+		baseConfig.Renderer.Banner.Text = userConfig.Banner.Text
+	}
+	if userConfig.Banner.Position != "" {
+		baseConfig.Renderer.Banner.Position = userConfig.Banner.Position
+	}
+	if userConfig.Banner.Color != "" {
+		baseConfig.Renderer.Banner.Color = userConfig.Banner.Color
+	}
+	if userConfig.Banner.Background != "" {
+		baseConfig.Renderer.Banner.Background = userConfig.Banner.Background
+	}
+
+	// Colors
+	if userConfig.TextColor != "" {
+		baseConfig.Renderer.Colors.Text = userConfig.TextColor
+	}
+	if userConfig.BackgroundColor != "" {
+		baseConfig.Renderer.Colors.Background = userConfig.BackgroundColor
+	}
+	if userConfig.LinkColor != "" {
+		baseConfig.Renderer.Colors.Link = userConfig.LinkColor
+	}
+	if userConfig.CodeColor != "" {
+		baseConfig.Renderer.Colors.CodeText = userConfig.CodeColor
+	}
+	if userConfig.CodeBackground != "" {
+		baseConfig.Renderer.Colors.CodeBackground = userConfig.CodeBackground
+	}
+	if userConfig.ImageBackdrop != "" {
+		baseConfig.Renderer.Colors.ImageBackdrop = userConfig.ImageBackdrop
+	}
+
+	// Table of contents
+	if userConfig.TOC {
+		baseConfig.Renderer.TOC.Enabled = true
+	}
+	if userConfig.TOCDepth > 0 {
+		baseConfig.Renderer.TOC.Depth = userConfig.TOCDepth
+	}
+	if userConfig.TOCTitle != "" {
+		baseConfig.Renderer.TOC.Title = userConfig.TOCTitle
+	}
+
+	// Security
+	if userConfig.ImagePolicy != "" {
+		baseConfig.Renderer.ImagePolicy = userConfig.ImagePolicy
+	}
+
+	// Output
+	if userConfig.MaxOutputSize != "" {
+		baseConfig.Output.MaxSize = userConfig.MaxOutputSize
+	}
+	if userConfig.Linearize {
+		baseConfig.Output.Linearize = true
+	}
+	if userConfig.Manifest {
+		baseConfig.Output.Manifest = true
+	}
+	if userConfig.CacheDir != "" {
+		baseConfig.Output.CacheDir = userConfig.CacheDir
+	}
+	if userConfig.SummaryPage {
+		baseConfig.Output.Summary.Enabled = true
+	}
+	if userConfig.SummaryRepoURL != "" {
+		baseConfig.Output.Summary.RepoURL = userConfig.SummaryRepoURL
+	}
+	if userConfig.SourceAppendix {
+		baseConfig.Output.SourceAppendix = true
+	}
+	if userConfig.OutputMode != "" {
+		baseConfig.Output.FileMode = userConfig.OutputMode
+	}
+	if userConfig.PreserveMode {
+		baseConfig.Output.PreserveMode = true
+	}
+	if userConfig.OnCollision != "" {
+		baseConfig.Output.OnCollision = userConfig.OnCollision
+	}
+
+	// Network
+	if userConfig.NetworkTimeout > 0 {
+		baseConfig.Network.Timeout = userConfig.NetworkTimeout
+	}
+	if userConfig.Proxy != "" {
+		baseConfig.Network.Proxy = userConfig.Proxy
+	}
+	if userConfig.Offline {
+		baseConfig.Network.Offline = true
+	}
+	if userConfig.NetworkRateLimit > 0 {
+		baseConfig.Network.RateLimit = userConfig.NetworkRateLimit
+	}
+	if userConfig.NetworkAttempts > 0 {
+		baseConfig.Network.Attempts = userConfig.NetworkAttempts
+	}
+	if userConfig.NetworkConcurrency > 0 {
+		baseConfig.Network.Concurrency = userConfig.NetworkConcurrency
+	}
+
+	// Plugin capabilities
+	if len(userConfig.PluginGrants) > 0 {
+		baseConfig.Plugins.Grants = make(map[string]plugins.Capabilities, len(userConfig.PluginGrants))
+		for name, grant := range userConfig.PluginGrants {
+			baseConfig.Plugins.Grants[name] = plugins.Capabilities{
+				Read:    grant.Read,
+				Write:   grant.Write,
+				Network: grant.Network,
+				Exec:    grant.Exec,
+			}
+		}
+	}
+
+	// Locales
+	if len(userConfig.Locales) > 0 {
+		baseConfig.Locales = make(map[string]core.LocaleConfig, len(userConfig.Locales))
+		for locale, lc := range userConfig.Locales {
+			baseConfig.Locales[locale] = core.LocaleConfig{
+				FontFamily:   lc.FontFamily,
+				Title:        lc.Title,
+				Author:       lc.Author,
+				Subject:      lc.Subject,
+				DateFormat:   lc.DateFormat,
+				Translations: lc.Translations,
+			}
+		}
+	}
 }
@@ -98,3 +98,137 @@ func TestApplyUserConfig_ZeroValues(t *testing.T) {
 		t.Errorf("Expected FontSize to remain %f, got %f", originalFontSize, baseConfig.Renderer.FontSize)
 	}
 }
+
+func TestApplyUserConfig_HeaderFooter(t *testing.T) {
+	baseConfig := core.DefaultConfig()
+	userConfig := &UserConfig{
+		Header:      "![logo](logo.png) **{title}**",
+		Footer:      "Page {page} of {pages}",
+		FooterAlign: "right",
+	}
+
+	ApplyUserConfig(baseConfig, userConfig)
+
+	hf := baseConfig.Renderer.HeaderFooter
+	if hf.Header != userConfig.Header {
+		t.Errorf("Expected Header %q, got %q", userConfig.Header, hf.Header)
+	}
+	if hf.Footer != userConfig.Footer {
+		t.Errorf("Expected Footer %q, got %q", userConfig.Footer, hf.Footer)
+	}
+	if hf.HeaderAlign != "left" {
+		t.Errorf("Expected HeaderAlign to keep default 'left', got %q", hf.HeaderAlign)
+	}
+	if hf.FooterAlign != "right" {
+		t.Errorf("Expected FooterAlign 'right', got %q", hf.FooterAlign)
+	}
+}
+
+func TestApplyUserConfig_Locales(t *testing.T) {
+	baseConfig := core.DefaultConfig()
+	userConfig := &UserConfig{
+		DateFormat: "January 2, 2006",
+		Locales: map[string]LocaleUserConfig{
+			"de": {
+				Title:        "Handbuch",
+				DateFormat:   "02.01.2006",
+				Translations: map[string]string{"greeting": "Hallo"},
+			},
+		},
+	}
+
+	ApplyUserConfig(baseConfig, userConfig)
+
+	if baseConfig.Document.DateFormat != "January 2, 2006" {
+		t.Errorf("Expected DateFormat %q, got %q", "January 2, 2006", baseConfig.Document.DateFormat)
+	}
+
+	de, ok := baseConfig.Locales["de"]
+	if !ok {
+		t.Fatal("Expected locale 'de' to be applied")
+	}
+	if de.Title != "Handbuch" || de.DateFormat != "02.01.2006" {
+		t.Errorf("Unexpected locale overrides: %+v", de)
+	}
+	if de.Translations["greeting"] != "Hallo" {
+		t.Errorf("Expected translation 'Hallo', got %q", de.Translations["greeting"])
+	}
+}
+
+func TestApplyUserConfig_PluginGrants(t *testing.T) {
+	baseConfig := core.DefaultConfig()
+	if baseConfig.Plugins.Grants != nil {
+		t.Fatal("Expected no grants by default, so plugins get what they declare")
+	}
+
+	userConfig := &UserConfig{
+		PluginGrants: map[string]PluginGrant{
+			"mermaid": {Write: []string{"./mermaid-output"}, Exec: []string{"mmdc"}},
+		},
+	}
+	ApplyUserConfig(baseConfig, userConfig)
+
+	grant, ok := baseConfig.Plugins.Grants["mermaid"]
+	if !ok {
+		t.Fatal("Expected a grant for 'mermaid'")
+	}
+	if len(grant.Exec) != 1 || grant.Exec[0] != "mmdc" || grant.Network {
+		t.Errorf("Unexpected grant: %+v", grant)
+	}
+}
+
+func TestApplyUserConfig_Banner(t *testing.T) {
+	cfg := core.DefaultConfig()
+	ApplyUserConfig(cfg, &UserConfig{Banner: Banner{Text: "CONFIDENTIAL", Position: "top"}})
+
+	banner := cfg.Renderer.Banner
+	if banner.Text != "CONFIDENTIAL" || banner.Position != "top" {
+		t.Errorf("banner not applied: %+v", banner)
+	}
+	if banner.Background != "none" {
+		t.Errorf("unset banner keys should keep their defaults, got background %q", banner.Background)
+	}
+}
+
+func TestApplyUserConfig_KeepWithNext(t *testing.T) {
+	cfg := core.DefaultConfig()
+	ApplyUserConfig(cfg, &UserConfig{})
+	if cfg.Renderer.KeepWithNext != "headings" || cfg.Renderer.MinLinesAfterHeading != 1 {
+		t.Errorf("defaults changed: %q, %d", cfg.Renderer.KeepWithNext, cfg.Renderer.MinLinesAfterHeading)
+	}
+
+	ApplyUserConfig(cfg, &UserConfig{KeepWithNext: "none", MinLinesAfterHeading: 3})
+	if cfg.Renderer.KeepWithNext != "none" || cfg.Renderer.MinLinesAfterHeading != 3 {
+		t.Errorf("settings not applied: %q, %d", cfg.Renderer.KeepWithNext, cfg.Renderer.MinLinesAfterHeading)
+	}
+}
+
+func TestApplyUserConfig_Network(t *testing.T) {
+	cfg := core.DefaultConfig()
+	ApplyUserConfig(cfg, &UserConfig{NetworkRateLimit: 2.5, NetworkAttempts: 1, NetworkConcurrency: 8})
+	if cfg.Network.RateLimit != 2.5 || cfg.Network.Attempts != 1 || cfg.Network.Concurrency != 8 {
+		t.Errorf("settings not applied: %+v", cfg.Network)
+	}
+}
+
+func TestConfigFromSettings(t *testing.T) {
+	cfg, err := ConfigFromSettings([]byte(`{"extends": "compact", "font_size": 9, "quote_style": {"font_style": "normal"}}`))
+	if err != nil {
+		t.Fatalf("ConfigFromSettings() error: %v", err)
+	}
+	if cfg.Renderer.FontSize != 9 || cfg.Renderer.QuoteStyle.FontStyle != "normal" {
+		t.Errorf("settings not applied: font size %v, quote style %q", cfg.Renderer.FontSize, cfg.Renderer.QuoteStyle.FontStyle)
+	}
+	if cfg.Renderer.Margins.Top != 12 {
+		t.Errorf("Margins.Top = %v, want 12 from the compact theme", cfg.Renderer.Margins.Top)
+	}
+
+	cfg, err = ConfigFromSettings(nil)
+	if err != nil || cfg.Renderer.FontSize != 12 {
+		t.Errorf("no settings should give the defaults, got %v, %v", cfg, err)
+	}
+
+	if _, err := ConfigFromSettings([]byte(`{"font_sise": 9}`)); err == nil {
+		t.Error("expected an error for an unknown setting")
+	}
+}
@@ -0,0 +1,331 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/fredcamaral/md-to-pdf/internal/core"
+	"gopkg.in/yaml.v3"
+)
+
+// builtinThemes are the themes settings files can extend by name, in the
+// format of the user config.
+var builtinThemes = map[string]struct {
+	description string
+	settings    string
+}{
+	"github": {
+		description: "Sans-serif text and light gray rules and quotes, as on GitHub",
+		settings: `font_family: Helvetica
+font_size: 11
+heading_scale: 1.6
+line_spacing: 1.5
+code_font: Courier
+code_size: 9
+margin_top: 20
+margin_bottom: 20
+margin_left: 20
+margin_right: 20
+rule_thickness: 0.5
+rule_color: "#d0d7de"
+quote_style:
+  bar_color: "#d0d7de"
+  font_style: normal
+`,
+	},
+	"academic": {
+		description: "Serif text, wide margins and a table of contents, for papers",
+		settings: `font_family: Times
+font_size: 12
+heading_scale: 1.3
+line_spacing: 1.5
+margin_top: 25
+margin_bottom: 25
+margin_left: 30
+margin_right: 30
+rule_style: ornament
+toc: true
+quote_style:
+  bar_color: "#808080"
+  font_style: italic
+`,
+	},
+	"compact": {
+		description: "Small text and narrow margins, to print fewer pages",
+		settings: `font_family: Helvetica
+font_size: 10
+heading_scale: 1.3
+line_spacing: 1.1
+code_size: 8
+margin_top: 12
+margin_bottom: 12
+margin_left: 12
+margin_right: 12
+`,
+	},
+}
+
+// maxThemeDepth bounds a chain of themes extending one another.
+const maxThemeDepth = 10
+
+// ThemeNames returns the names of the built-in themes, sorted.
+func ThemeNames() []string {
+	names := make([]string, 0, len(builtinThemes))
+	for name := range builtinThemes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ThemeDescription describes a built-in theme.
+func ThemeDescription(name string) string {
+	return builtinThemes[name].description
+}
+
+// IsThemeFile reports whether a theme reference names a file rather than a
+// built-in theme: it has a .yaml or .yml extension or a directory.
+func IsThemeFile(ref string) bool {
+	ext := strings.ToLower(filepath.Ext(ref))
+	return ext == ".yaml" || ext == ".yml" || strings.ContainsAny(ref, `/\`)
+}
+
+// LoadTheme returns the settings of a built-in theme or a theme file, with
+// the themes it extends merged under them, and the chain of themes from the
+// base theme to ref. Relative theme files are resolved against dir.
+func LoadTheme(ref, dir string) (*UserConfig, []string, error) {
+	settings, chain, err := loadThemeSettings(ref, dir, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	theme, err := decodeSettings(settings)
+	if err != nil {
+		return nil, nil, fmt.Errorf("theme %s: %w", ref, err)
+	}
+	return theme, chain, nil
+}
+
+// ResolveUserConfig returns userConfig with the theme it extends merged
+// under its own settings, so that they override the theme's. Relative theme
+// files are resolved against dir. Settings of userConfig left false or 0
+// are taken as unset and keep the theme's; settings read from a file are
+// resolved as written, with LoadResolvedUserConfig or ConfigFromSettings.
+func ResolveUserConfig(userConfig *UserConfig, dir string) (*UserConfig, []string, error) {
+	if userConfig.Extends == "" {
+		return userConfig, nil, nil
+	}
+	own, err := encodeSettings(userConfig)
+	if err != nil {
+		return nil, nil, err
+	}
+	return resolveSettings(userConfig.Extends, own, dir)
+}
+
+// LoadResolvedUserConfig loads the user config and merges the theme it
+// extends under it, for applying to a conversion. Commands that edit the
+// config file use LoadUserConfig, so the theme's settings are not copied
+// into it.
+func LoadResolvedUserConfig() (*UserConfig, error) {
+	resolved, _, err := LoadResolvedUserConfigChain()
+	return resolved, err
+}
+
+// LoadResolvedUserConfigChain is LoadResolvedUserConfig, also returning the
+// chain of themes the config file extends.
+func LoadResolvedUserConfigChain() (*UserConfig, []string, error) {
+	configPath := GetConfigPath()
+	if _, err := os.Stat(configPath); os.IsNotExist(err) {
+		return &UserConfig{}, nil, nil
+	}
+	userConfig, data, err := readUserConfigFile(configPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	resolved, chain, err := resolveUserSettings(userConfig, data, filepath.Dir(configPath))
+	if err != nil {
+		return nil, nil, fmt.Errorf("config file: %w", err)
+	}
+	return resolved, chain, nil
+}
+
+// resolveUserSettings is ResolveUserConfig for userConfig parsed from data.
+// The theme is merged under the settings as written rather than under
+// userConfig, so that a setting written as false or 0 overrides the theme's
+// and falls back to the default, as it would with no theme.
+func resolveUserSettings(userConfig *UserConfig, data []byte, dir string) (*UserConfig, []string, error) {
+	if userConfig.Extends == "" {
+		return userConfig, nil, nil
+	}
+	var own map[string]interface{}
+	if err := yaml.Unmarshal(data, &own); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse config: %w", err)
+	}
+	delete(own, "extends")
+	return resolveSettings(userConfig.Extends, own, dir)
+}
+
+// resolveSettings merges the theme ref under the settings own.
+func resolveSettings(ref string, own map[string]interface{}, dir string) (*UserConfig, []string, error) {
+	base, chain, err := loadThemeSettings(ref, dir, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	resolved, err := decodeSettings(mergeSettings(base, own))
+	if err != nil {
+		return nil, nil, err
+	}
+	return resolved, chain, nil
+}
+
+// loadThemeSettings reads a theme as a settings map and merges it over the
+// theme it extends. seen holds the themes of the chain already loaded.
+func loadThemeSettings(ref, dir string, seen []string) (map[string]interface{}, []string, error) {
+	var data []byte
+	key := ref
+	if IsThemeFile(ref) {
+		path := ref
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(dir, path)
+		}
+		key = path
+		var err error
+		data, err = os.ReadFile(path) // #nosec G304 - theme path comes from the user's settings
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read theme: %w", err)
+		}
+		dir = filepath.Dir(path)
+	} else {
+		theme, ok := builtinThemes[ref]
+		if !ok {
+			return nil, nil, fmt.Errorf("unknown theme %q (built-in themes: %s; theme files end in .yaml)", ref, strings.Join(ThemeNames(), ", "))
+		}
+		data = []byte(theme.settings)
+	}
+
+	for _, previous := range seen {
+		if previous == key {
+			return nil, nil, fmt.Errorf("theme %s extends itself through %s", ref, strings.Join(append(seen, key), " -> "))
+		}
+	}
+	if len(seen) >= maxThemeDepth {
+		return nil, nil, fmt.Errorf("theme %s: more than %d themes extend one another", ref, maxThemeDepth)
+	}
+	seen = append(seen, key)
+
+	var settings map[string]interface{}
+	if err := yaml.Unmarshal(data, &settings); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse theme %s: %w", ref, err)
+	}
+	if settings == nil {
+		settings = map[string]interface{}{}
+	}
+
+	parent, _ := settings["extends"].(string)
+	delete(settings, "extends")
+	if parent == "" {
+		return settings, []string{ref}, nil
+	}
+	base, chain, err := loadThemeSettings(parent, dir, seen)
+	if err != nil {
+		return nil, nil, err
+	}
+	return mergeSettings(base, settings), append(chain, ref), nil
+}
+
+// mergeSettings returns base with the settings of override over it. Nested
+// sections such as quote_style are merged key by key; lists are replaced.
+func mergeSettings(base, override map[string]interface{}) map[string]interface{} {
+	merged := make(map[string]interface{}, len(base)+len(override))
+	for key, value := range base {
+		merged[key] = value
+	}
+	for key, value := range override {
+		baseSection, baseOK := merged[key].(map[string]interface{})
+		section, ok := value.(map[string]interface{})
+		if baseOK && ok {
+			merged[key] = mergeSettings(baseSection, section)
+			continue
+		}
+		merged[key] = value
+	}
+	return merged
+}
+
+// encodeSettings returns the settings of a user config as a map, without
+// the ones left unset.
+func encodeSettings(userConfig *UserConfig) (map[string]interface{}, error) {
+	data, err := yaml.Marshal(userConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal config: %w", err)
+	}
+	var settings map[string]interface{}
+	if err := yaml.Unmarshal(data, &settings); err != nil {
+		return nil, fmt.Errorf("failed to parse config: %w", err)
+	}
+	delete(settings, "extends")
+	return settings, nil
+}
+
+func decodeSettings(settings map[string]interface{}) (*UserConfig, error) {
+	data, err := yaml.Marshal(settings)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal settings: %w", err)
+	}
+	var userConfig UserConfig
+	if err := yaml.Unmarshal(data, &userConfig); err != nil {
+		return nil, fmt.Errorf("invalid settings: %w", err)
+	}
+	return &userConfig, nil
+}
+
+// StyleSettings returns the style settings in effect in cfg, those a theme
+// sets, in the format of the user config.
+func StyleSettings(cfg *core.Config) *UserConfig {
+	r := cfg.Renderer
+	return &UserConfig{
+		FontFamily:           r.FontFamily,
+		FontSize:             r.FontSize,
+		HeadingScale:         r.HeadingScale,
+		HeadingMinSize:       r.HeadingMinSize,
+		LineSpacing:          r.LineSpacing,
+		CodeFont:             r.CodeFont,
+		CodeSize:             r.CodeSize,
+		PageSize:             r.PageSize,
+		MarginTop:            r.Margins.Top,
+		MarginBottom:         r.Margins.Bottom,
+		MarginLeft:           r.Margins.Left,
+		MarginRight:          r.Margins.Right,
+		BaselineGrid:         r.BaselineGrid,
+		SidenoteSide:         r.SidenoteSide,
+		KeepWithNext:         r.KeepWithNext,
+		MinLinesAfterHeading: r.MinLinesAfterHeading,
+		MermaidTheme:         r.Mermaid.Theme,
+		MermaidWideStrategy:  r.Mermaid.WideStrategy,
+		Header:               r.HeaderFooter.Header,
+		Footer:               r.HeaderFooter.Footer,
+		HeaderAlign:          r.HeaderFooter.HeaderAlign,
+		FooterAlign:          r.HeaderFooter.FooterAlign,
+		RuleStyle:            r.ThematicBreak.Style,
+		RuleThickness:        r.ThematicBreak.Thickness,
+		RuleColor:            r.ThematicBreak.Color,
+		RuleWidth:            r.ThematicBreak.Width,
+		RuleOrnament:         r.ThematicBreak.Ornament,
+		QuoteStyle: QuoteStyle{
+			BarColor:   r.QuoteStyle.BarColor,
+			Background: r.QuoteStyle.Background,
+			FontStyle:  r.QuoteStyle.FontStyle,
+		},
+		TextColor:       r.Colors.Text,
+		BackgroundColor: r.Colors.Background,
+		LinkColor:       r.Colors.Link,
+		CodeColor:       r.Colors.CodeText,
+		CodeBackground:  r.Colors.CodeBackground,
+		ImageBackdrop:   r.Colors.ImageBackdrop,
+		TOC:             r.TOC.Enabled,
+		TOCDepth:        r.TOC.Depth,
+		TOCTitle:        r.TOC.Title,
+	}
+}
@@ -0,0 +1,134 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/fredcamaral/md-to-pdf/internal/core"
+)
+
+func writeTheme(t *testing.T, dir, name, content string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoadTheme_Extends(t *testing.T) {
+	dir := t.TempDir()
+	writeTheme(t, dir, "base.yaml", "extends: github\nfont_size: 10\nquote_style:\n  bar_color: \"#ff0000\"\n")
+	writeTheme(t, dir, "book.yaml", "extends: base.yaml\nline_spacing: 1.3\n")
+
+	theme, chain, err := LoadTheme("book.yaml", dir)
+	if err != nil {
+		t.Fatalf("LoadTheme failed: %v", err)
+	}
+	if want := []string{"github", "base.yaml", "book.yaml"}; !reflect.DeepEqual(chain, want) {
+		t.Errorf("chain = %v, want %v", chain, want)
+	}
+
+	// Each theme overrides single values of the one it extends
+	if theme.FontFamily != "Helvetica" || theme.FontSize != 10 || theme.LineSpacing != 1.3 {
+		t.Errorf("theme = %+v, want github's font at size 10 with line spacing 1.3", theme)
+	}
+	if theme.QuoteStyle.BarColor != "#ff0000" || theme.QuoteStyle.FontStyle != "normal" {
+		t.Errorf("quote style = %+v, want the bar color overridden and github's font style kept", theme.QuoteStyle)
+	}
+	if theme.Extends != "" {
+		t.Errorf("resolved theme still extends %q", theme.Extends)
+	}
+}
+
+func TestLoadTheme_Errors(t *testing.T) {
+	dir := t.TempDir()
+	writeTheme(t, dir, "a.yaml", "extends: b.yaml\n")
+	writeTheme(t, dir, "b.yaml", "extends: a.yaml\n")
+	writeTheme(t, dir, "unknown.yaml", "extends: solarized\n")
+
+	tests := map[string]string{
+		"a.yaml":       "extends itself",
+		"unknown.yaml": `unknown theme "solarized"`,
+		"missing.yaml": "failed to read theme",
+	}
+	for ref, want := range tests {
+		if _, _, err := LoadTheme(ref, dir); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%s: error = %v, want %q", ref, err, want)
+		}
+	}
+}
+
+func TestBuiltinThemesAreValid(t *testing.T) {
+	for _, name := range ThemeNames() {
+		theme, _, err := LoadTheme(name, "")
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		cfg := core.DefaultConfig()
+		ApplyUserConfig(cfg, theme)
+		if err := core.ValidateConfig(cfg); err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+	}
+}
+
+func TestResolveUserConfig(t *testing.T) {
+	userConfig := &UserConfig{Extends: "academic", FontSize: 11, Author: "Jane Doe"}
+	resolved, chain, err := ResolveUserConfig(userConfig, t.TempDir())
+	if err != nil {
+		t.Fatalf("ResolveUserConfig failed: %v", err)
+	}
+	if len(chain) != 1 || chain[0] != "academic" {
+		t.Errorf("chain = %v, want [academic]", chain)
+	}
+	if resolved.FontFamily != "Times" || resolved.FontSize != 11 || resolved.Author != "Jane Doe" || !resolved.TOC {
+		t.Errorf("resolved = %+v, want academic with the font size and author of the config", resolved)
+	}
+	if userConfig.FontFamily != "" {
+		t.Error("the user config itself should not be changed")
+	}
+
+	plain := &UserConfig{FontSize: 11}
+	if resolved, _, err := ResolveUserConfig(plain, ""); err != nil || resolved != plain {
+		t.Errorf("a config extending no theme should be returned as is, got %+v, %v", resolved, err)
+	}
+}
+
+func TestResolveUserSettings_FalseAndZero(t *testing.T) {
+	data := []byte("extends: academic\ntoc: false\nmargin_left: 0\nquote_style:\n  bar_color: \"\"\n")
+	userConfig, err := ParseUserConfig(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resolved, _, err := resolveUserSettings(userConfig, data, t.TempDir())
+	if err != nil {
+		t.Fatalf("resolveUserSettings failed: %v", err)
+	}
+	if resolved.TOC || resolved.MarginLeft != 0 || resolved.QuoteStyle.BarColor != "" {
+		t.Errorf("resolved = %+v, want the theme's toc, left margin and bar color overridden", resolved)
+	}
+	if resolved.FontFamily != "Times" || resolved.MarginRight != 30 || resolved.QuoteStyle.FontStyle != "italic" {
+		t.Errorf("resolved = %+v, want academic's other settings kept", resolved)
+	}
+
+	// Overridden settings fall back to the defaults, not to the theme
+	cfg, err := ConfigFromSettings(data)
+	if err != nil {
+		t.Fatalf("ConfigFromSettings failed: %v", err)
+	}
+	defaults := core.DefaultConfig().Renderer
+	if cfg.Renderer.TOC.Enabled || cfg.Renderer.Margins.Left != defaults.Margins.Left || cfg.Renderer.Margins.Right != 30 {
+		t.Errorf("toc %v, margins %+v, want no toc and the default left margin", cfg.Renderer.TOC.Enabled, cfg.Renderer.Margins)
+	}
+}
+
+func TestStyleSettings(t *testing.T) {
+	cfg := core.DefaultConfig()
+	ApplyUserConfig(cfg, &UserConfig{FontFamily: "Times", QuoteStyle: QuoteStyle{FontStyle: "normal"}})
+	style := StyleSettings(cfg)
+	if style.FontFamily != "Times" || style.FontSize != 12 || style.QuoteStyle.FontStyle != "normal" || style.QuoteStyle.BarColor != "#c8c8c8" {
+		t.Errorf("style = %+v, want the overrides over the defaults", style)
+	}
+}
@@ -0,0 +1,279 @@
+package contentscan
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"regexp"
+)
+
+var pngSignature = []byte{0x89, 'P', 'N', 'G', '\r', '\n', 0x1A, '\n'}
+
+// processPNG walks the PNG chunks, dropping text chunks that carry active
+// markers and anything after the IEND chunk.
+func processPNG(data []byte) ([]byte, []Finding) {
+	var findings []Finding
+	out := append([]byte{}, pngSignature...)
+
+	pos := len(pngSignature)
+	for pos+8 <= len(data) {
+		length := int(binary.BigEndian.Uint32(data[pos:]))
+		chunkType := string(data[pos+4 : pos+8])
+		end := pos + 12 + length
+		if length < 0 || end > len(data) {
+			// Truncated chunk: leave the rest for the decoder to reject
+			return append(out, data[pos:]...), findings
+		}
+		chunk := data[pos:end]
+
+		switch chunkType {
+		case "tEXt", "zTXt", "iTXt":
+			if marker, ok := findMarker(chunk[8 : 8+length]); ok {
+				findings = append(findings, Finding{
+					Kind:   "metadata",
+					Detail: fmt.Sprintf("PNG %s chunk contains %q", chunkType, marker),
+				})
+				pos = end
+				continue
+			}
+		}
+
+		out = append(out, chunk...)
+		pos = end
+
+		if chunkType == "IEND" {
+			if f, ok := trailingFinding(data[pos:]); ok {
+				findings = append(findings, f)
+			}
+			return out, findings
+		}
+	}
+
+	return append(out, data[min(pos, len(data)):]...), findings
+}
+
+// processJPEG walks the JPEG segments, dropping comment and application
+// segments that carry active markers and anything after the EOI marker.
+func processJPEG(data []byte) ([]byte, []Finding) {
+	var findings []Finding
+	out := []byte{0xFF, 0xD8}
+
+	pos := 2
+	for pos+2 <= len(data) {
+		if data[pos] != 0xFF {
+			return append(out, data[pos:]...), findings
+		}
+		marker := data[pos+1]
+
+		switch {
+		case marker == 0xFF:
+			// Fill byte before a marker
+			pos++
+			continue
+		case marker == 0xD9: // EOI
+			out = append(out, 0xFF, 0xD9)
+			if f, ok := trailingFinding(data[pos+2:]); ok {
+				findings = append(findings, f)
+			}
+			return out, findings
+		case marker == 0x01 || (marker >= 0xD0 && marker <= 0xD7):
+			// Markers without a length field
+			out = append(out, data[pos:pos+2]...)
+			pos += 2
+			continue
+		}
+
+		if pos+4 > len(data) {
+			return append(out, data[pos:]...), findings
+		}
+		length := int(binary.BigEndian.Uint16(data[pos+2:]))
+		end := pos + 2 + length
+		if length < 2 || end > len(data) {
+			return append(out, data[pos:]...), findings
+		}
+		segment := data[pos:end]
+
+		if marker == 0xFE || (marker >= 0xE0 && marker <= 0xEF) {
+			if found, ok := findMarker(segment[4:]); ok {
+				findings = append(findings, Finding{
+					Kind:   "metadata",
+					Detail: fmt.Sprintf("JPEG %s segment contains %q", jpegSegmentName(marker), found),
+				})
+				pos = end
+				continue
+			}
+		}
+
+		out = append(out, segment...)
+		pos = end
+
+		if marker == 0xDA { // SOS: entropy-coded data follows until the next marker
+			scanEnd := entropyEnd(data, pos)
+			out = append(out, data[pos:scanEnd]...)
+			pos = scanEnd
+		}
+	}
+
+	return append(out, data[min(pos, len(data)):]...), findings
+}
+
+// entropyEnd returns the offset of the first marker after entropy-coded data
+// starting at pos. Stuffed bytes (FF 00) and restart markers are skipped.
+func entropyEnd(data []byte, pos int) int {
+	for pos+1 < len(data) {
+		if data[pos] == 0xFF {
+			next := data[pos+1]
+			if next != 0x00 && (next < 0xD0 || next > 0xD7) {
+				return pos
+			}
+		}
+		pos++
+	}
+	return len(data)
+}
+
+func jpegSegmentName(marker byte) string {
+	if marker == 0xFE {
+		return "COM"
+	}
+	return fmt.Sprintf("APP%d", marker-0xE0)
+}
+
+// processGIF walks the GIF blocks, dropping comment and application
+// extensions that carry active markers and anything after the trailer.
+func processGIF(data []byte) ([]byte, []Finding) {
+	const headerSize = 13 // Signature, version and logical screen descriptor
+	if len(data) < headerSize {
+		return data, nil
+	}
+
+	var findings []Finding
+	out := append([]byte{}, data[:headerSize]...)
+	pos := headerSize
+	if flags := data[10]; flags&0x80 != 0 {
+		tableSize := 3 << ((flags & 0x07) + 1)
+		if pos+tableSize > len(data) {
+			return data, nil
+		}
+		out = append(out, data[pos:pos+tableSize]...)
+		pos += tableSize
+	}
+
+	for pos < len(data) {
+		start := pos
+		switch data[pos] {
+		case 0x3B: // Trailer
+			out = append(out, 0x3B)
+			if f, ok := trailingFinding(data[pos+1:]); ok {
+				findings = append(findings, f)
+			}
+			return out, findings
+
+		case 0x21: // Extension
+			if pos+2 > len(data) {
+				return append(out, data[start:]...), findings
+			}
+			label := data[pos+1]
+			end, ok := skipSubBlocks(data, pos+2)
+			if !ok {
+				return append(out, data[start:]...), findings
+			}
+			if label == 0xFE || label == 0xFF {
+				if marker, found := findMarker(data[pos+2 : end]); found {
+					name := "comment"
+					if label == 0xFF {
+						name = "application"
+					}
+					findings = append(findings, Finding{
+						Kind:   "metadata",
+						Detail: fmt.Sprintf("GIF %s extension contains %q", name, marker),
+					})
+					pos = end
+					continue
+				}
+			}
+			out = append(out, data[start:end]...)
+			pos = end
+
+		case 0x2C: // Image descriptor
+			if pos+10 > len(data) {
+				return append(out, data[start:]...), findings
+			}
+			flags := data[pos+9]
+			pos += 10
+			if flags&0x80 != 0 {
+				pos += 3 << ((flags & 0x07) + 1)
+			}
+			pos++ // LZW minimum code size
+			end, ok := skipSubBlocks(data, pos)
+			if !ok {
+				return append(out, data[start:]...), findings
+			}
+			out = append(out, data[start:end]...)
+			pos = end
+
+		default:
+			return append(out, data[start:]...), findings
+		}
+	}
+
+	return out, findings
+}
+
+// skipSubBlocks returns the offset after a sequence of GIF data sub-blocks.
+func skipSubBlocks(data []byte, pos int) (int, bool) {
+	for pos < len(data) {
+		size := int(data[pos])
+		pos++
+		if size == 0 {
+			return pos, true
+		}
+		pos += size
+	}
+	return pos, false
+}
+
+var (
+	svgScriptElement  = regexp.MustCompile(`(?is)<script\b[^>]*/>|<script\b.*?</script\s*>`)
+	svgForeignObject  = regexp.MustCompile(`(?is)<foreignObject\b[^>]*/>|<foreignObject\b.*?</foreignObject\s*>`)
+	svgEventHandler   = regexp.MustCompile(`(?i)\s+on[a-z]+\s*=\s*(?:"[^"]*"|'[^']*')`)
+	svgScriptURL      = regexp.MustCompile(`(?i)\s+(?:xlink:)?href\s*=\s*(?:"\s*(?:javascript|data:text/html)[^"]*"|'\s*(?:javascript|data:text/html)[^']*')`)
+	svgEntityDocument = regexp.MustCompile(`(?is)<!DOCTYPE[^>]*\[.*?\]\s*>`)
+	svgOpenTag        = regexp.MustCompile(`(?i)<svg[\s>]`)
+)
+
+// looksLikeSVG reports whether data starts like an SVG document.
+func looksLikeSVG(data []byte) bool {
+	head := data
+	if len(head) > 1024 {
+		head = head[:1024]
+	}
+	return svgOpenTag.Match(head)
+}
+
+// processSVG removes scripts, event handlers, script URLs, embedded HTML and
+// entity declarations from an SVG document.
+func processSVG(data []byte) ([]byte, []Finding) {
+	var findings []Finding
+	out := data
+
+	strip := func(pattern *regexp.Regexp, kind, detail string) {
+		matches := pattern.FindAll(out, -1)
+		if len(matches) == 0 {
+			return
+		}
+		findings = append(findings, Finding{Kind: kind, Detail: fmt.Sprintf("%s (%d)", detail, len(matches))})
+		out = pattern.ReplaceAll(out, nil)
+	}
+
+	strip(svgEntityDocument, "entity", "SVG DOCTYPE declares entities")
+	strip(svgScriptElement, "script", "SVG <script> element")
+	strip(svgForeignObject, "script", "SVG <foreignObject> with embedded HTML")
+	strip(svgEventHandler, "script", "SVG event handler attribute")
+	strip(svgScriptURL, "script", "SVG link to a javascript: or HTML data URL")
+
+	if len(findings) == 0 {
+		return data, nil
+	}
+	return bytes.Clone(out), findings
+}
@@ -0,0 +1,167 @@
+// Package contentscan detects and removes active content hidden in files that
+// are embedded into generated PDFs, such as scripts in SVG images, markup in
+// image metadata, or payloads appended after the end of an image (polyglots).
+package contentscan
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// Policy decides what happens when active content is found.
+type Policy string
+
+const (
+	// PolicyWarn embeds the file unchanged and reports a warning.
+	PolicyWarn Policy = "warn"
+	// PolicySanitize strips the active content, embeds the cleaned file and
+	// reports a warning.
+	PolicySanitize Policy = "sanitize"
+	// PolicyRefuse fails the conversion.
+	PolicyRefuse Policy = "refuse"
+)
+
+// DefaultPolicy is used when no policy is configured.
+const DefaultPolicy = PolicySanitize
+
+// ValidPolicies lists the accepted policy names.
+var ValidPolicies = []string{string(PolicyWarn), string(PolicySanitize), string(PolicyRefuse)}
+
+// IsValidPolicy checks if the given policy name is valid.
+// An empty policy is accepted and treated as DefaultPolicy.
+func IsValidPolicy(policy string) bool {
+	if policy == "" {
+		return true
+	}
+	for _, valid := range ValidPolicies {
+		if valid == policy {
+			return true
+		}
+	}
+	return false
+}
+
+// Supported formats, matching the image types used by the renderer.
+const (
+	FormatPNG = "PNG"
+	FormatJPG = "JPG"
+	FormatGIF = "GIF"
+	FormatSVG = "SVG"
+)
+
+// Finding describes one piece of active content.
+type Finding struct {
+	Kind   string // Short category, e.g. "trailing-data" or "script"
+	Detail string // Human-readable description
+}
+
+func (f Finding) String() string {
+	return fmt.Sprintf("%s: %s", f.Kind, f.Detail)
+}
+
+// ActiveContentError is returned when a file is refused by policy.
+type ActiveContentError struct {
+	Source   string
+	Findings []Finding
+}
+
+func (e *ActiveContentError) Error() string {
+	return fmt.Sprintf("refused %s: active content detected (%s)", e.Source, Summarize(e.Findings))
+}
+
+// Summarize joins findings into a single line for warnings and errors.
+func Summarize(findings []Finding) string {
+	parts := make([]string, len(findings))
+	for i, f := range findings {
+		parts[i] = f.String()
+	}
+	return strings.Join(parts, "; ")
+}
+
+// activeMarkers are byte sequences that indicate executable content when they
+// appear in image metadata or appended data. They are matched
+// case-insensitively.
+var activeMarkers = []string{
+	"<script",
+	"javascript:",
+	"<?php",
+	"<iframe",
+	"<object",
+	"<embed",
+	"%pdf-",
+	"/javascript",
+	"/launch",
+}
+
+// DetectFormat identifies the format of data from its signature, falling
+// back to the given format name when the signature is unknown.
+func DetectFormat(data []byte, fallback string) string {
+	switch {
+	case bytes.HasPrefix(data, pngSignature):
+		return FormatPNG
+	case bytes.HasPrefix(data, []byte{0xFF, 0xD8, 0xFF}):
+		return FormatJPG
+	case bytes.HasPrefix(data, []byte("GIF87a")), bytes.HasPrefix(data, []byte("GIF89a")):
+		return FormatGIF
+	case looksLikeSVG(data):
+		return FormatSVG
+	}
+	return fallback
+}
+
+// Scan reports active content found in data without modifying it.
+func Scan(data []byte, format string) []Finding {
+	_, findings := process(data, format)
+	return findings
+}
+
+// Sanitize returns data with active content removed, together with the
+// findings describing what was removed. Data without findings is returned
+// unchanged.
+func Sanitize(data []byte, format string) ([]byte, []Finding) {
+	cleaned, findings := process(data, format)
+	if len(findings) == 0 {
+		return data, nil
+	}
+	return cleaned, findings
+}
+
+// process scans data and builds the sanitized copy in one pass.
+func process(data []byte, format string) ([]byte, []Finding) {
+	switch DetectFormat(data, format) {
+	case FormatPNG:
+		return processPNG(data)
+	case FormatJPG:
+		return processJPEG(data)
+	case FormatGIF:
+		return processGIF(data)
+	case FormatSVG:
+		return processSVG(data)
+	}
+	return data, nil
+}
+
+// findMarker returns the first active marker contained in data.
+func findMarker(data []byte) (string, bool) {
+	lower := bytes.ToLower(data)
+	for _, marker := range activeMarkers {
+		if bytes.Contains(lower, []byte(marker)) {
+			return marker, true
+		}
+	}
+	return "", false
+}
+
+// trailingFinding describes data appended after the end of an image.
+// Short runs of padding are common and harmless.
+func trailingFinding(trailer []byte) (Finding, bool) {
+	if len(bytes.Trim(trailer, "\x00\r\n\t ")) == 0 {
+		return Finding{}, false
+	}
+	detail := fmt.Sprintf("%d bytes appended after the end of the image", len(trailer))
+	if marker, ok := findMarker(trailer); ok {
+		detail += fmt.Sprintf(" (contains %q)", marker)
+	}
+	return Finding{Kind: "trailing-data", Detail: detail}, true
+}
@@ -0,0 +1,237 @@
+package contentscan
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"strings"
+	"testing"
+)
+
+func testImage() image.Image {
+	img := image.NewPaletted(image.Rect(0, 0, 4, 4), color.Palette{color.White, color.Black})
+	img.SetColorIndex(1, 1, 1)
+	return img
+}
+
+func encodePNG(t *testing.T) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, testImage()); err != nil {
+		t.Fatalf("failed to encode PNG: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func encodeJPEG(t *testing.T) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, testImage(), nil); err != nil {
+		t.Fatalf("failed to encode JPEG: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func encodeGIF(t *testing.T) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := gif.Encode(&buf, testImage(), nil); err != nil {
+		t.Fatalf("failed to encode GIF: %v", err)
+	}
+	return buf.Bytes()
+}
+
+// pngChunk builds a PNG chunk with a valid CRC.
+func pngChunk(chunkType string, data []byte) []byte {
+	chunk := make([]byte, 8, 12+len(data))
+	binary.BigEndian.PutUint32(chunk, uint32(len(data)))
+	copy(chunk[4:], chunkType)
+	chunk = append(chunk, data...)
+	crc := crc32.ChecksumIEEE(chunk[4:])
+	return binary.BigEndian.AppendUint32(chunk, crc)
+}
+
+// insertAfterIHDR inserts a chunk after the PNG header chunk.
+func insertAfterIHDR(data, chunk []byte) []byte {
+	ihdrEnd := len(pngSignature) + 8 + 13 + 4
+	out := append([]byte{}, data[:ihdrEnd]...)
+	out = append(out, chunk...)
+	return append(out, data[ihdrEnd:]...)
+}
+
+const payload = "<script>alert(1)</script>"
+
+func TestSanitize_CleanImages(t *testing.T) {
+	tests := map[string][]byte{
+		FormatPNG: encodePNG(t),
+		FormatJPG: encodeJPEG(t),
+		FormatGIF: encodeGIF(t),
+		FormatSVG: []byte(`<svg xmlns="http://www.w3.org/2000/svg"><rect width="10" height="10"/></svg>`),
+	}
+
+	for format, data := range tests {
+		t.Run(format, func(t *testing.T) {
+			cleaned, findings := Sanitize(data, format)
+			if len(findings) != 0 {
+				t.Errorf("unexpected findings for clean %s: %v", format, findings)
+			}
+			if !bytes.Equal(cleaned, data) {
+				t.Errorf("clean %s should be returned unchanged", format)
+			}
+		})
+	}
+}
+
+func TestSanitize_TrailingData(t *testing.T) {
+	tests := []struct {
+		format string
+		data   []byte
+		decode func([]byte) error
+	}{
+		{FormatPNG, encodePNG(t), func(b []byte) error { _, err := png.Decode(bytes.NewReader(b)); return err }},
+		{FormatJPG, encodeJPEG(t), func(b []byte) error { _, err := jpeg.Decode(bytes.NewReader(b)); return err }},
+		{FormatGIF, encodeGIF(t), func(b []byte) error { _, err := gif.Decode(bytes.NewReader(b)); return err }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			polyglot := append(append([]byte{}, tt.data...), []byte(payload)...)
+
+			cleaned, findings := Sanitize(polyglot, tt.format)
+			if len(findings) != 1 || findings[0].Kind != "trailing-data" {
+				t.Fatalf("expected one trailing-data finding, got %v", findings)
+			}
+			if !strings.Contains(findings[0].Detail, "<script") {
+				t.Errorf("finding should name the marker, got %q", findings[0].Detail)
+			}
+			if !bytes.Equal(cleaned, tt.data) {
+				t.Errorf("sanitized %s should equal the original image", tt.format)
+			}
+			if err := tt.decode(cleaned); err != nil {
+				t.Errorf("sanitized %s no longer decodes: %v", tt.format, err)
+			}
+		})
+	}
+}
+
+func TestSanitize_PaddingIsIgnored(t *testing.T) {
+	data := append(encodePNG(t), '\n', 0, 0)
+	if findings := Scan(data, FormatPNG); len(findings) != 0 {
+		t.Errorf("padding after IEND should not be reported, got %v", findings)
+	}
+}
+
+func TestSanitize_PNGTextChunk(t *testing.T) {
+	original := encodePNG(t)
+	data := insertAfterIHDR(original, pngChunk("tEXt", []byte("Comment\x00"+payload)))
+
+	cleaned, findings := Sanitize(data, FormatPNG)
+	if len(findings) != 1 || findings[0].Kind != "metadata" {
+		t.Fatalf("expected one metadata finding, got %v", findings)
+	}
+	if !bytes.Equal(cleaned, original) {
+		t.Error("sanitized PNG should drop the text chunk")
+	}
+
+	// Harmless text chunks are kept
+	harmless := insertAfterIHDR(original, pngChunk("tEXt", []byte("Author\x00Jane")))
+	if findings := Scan(harmless, FormatPNG); len(findings) != 0 {
+		t.Errorf("harmless text chunk reported: %v", findings)
+	}
+}
+
+func TestSanitize_JPEGComment(t *testing.T) {
+	original := encodeJPEG(t)
+	comment := []byte{0xFF, 0xFE, 0, byte(len(payload) + 2)}
+	comment = append(comment, payload...)
+	data := append([]byte{0xFF, 0xD8}, comment...)
+	data = append(data, original[2:]...)
+
+	cleaned, findings := Sanitize(data, FormatJPG)
+	if len(findings) != 1 || !strings.Contains(findings[0].Detail, "COM") {
+		t.Fatalf("expected COM finding, got %v", findings)
+	}
+	if !bytes.Equal(cleaned, original) {
+		t.Error("sanitized JPEG should drop the comment segment")
+	}
+}
+
+func TestSanitize_SVG(t *testing.T) {
+	svg := `<svg xmlns="http://www.w3.org/2000/svg" onload="steal()">` +
+		`<script type="text/javascript">alert(1)</script>` +
+		`<a xlink:href="javascript:alert(2)"><rect width="10" height="10"/></a>` +
+		`<foreignObject><iframe src="x"></iframe></foreignObject>` +
+		`</svg>`
+
+	cleaned, findings := Sanitize([]byte(svg), FormatSVG)
+	if len(findings) != 4 {
+		t.Errorf("expected 4 findings, got %d: %v", len(findings), findings)
+	}
+
+	lower := strings.ToLower(string(cleaned))
+	for _, forbidden := range []string{"<script", "onload", "javascript:", "foreignobject"} {
+		if strings.Contains(lower, forbidden) {
+			t.Errorf("sanitized SVG still contains %q: %s", forbidden, cleaned)
+		}
+	}
+	if !strings.Contains(string(cleaned), `<rect width="10" height="10"/>`) {
+		t.Errorf("sanitized SVG lost harmless content: %s", cleaned)
+	}
+}
+
+func TestDetectFormat(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     []byte
+		fallback string
+		want     string
+	}{
+		{"png", encodePNG(t), FormatJPG, FormatPNG},
+		{"jpeg", encodeJPEG(t), FormatPNG, FormatJPG},
+		{"gif", encodeGIF(t), FormatPNG, FormatGIF},
+		{"svg", []byte(`<?xml version="1.0"?><svg width="1"></svg>`), FormatPNG, FormatSVG},
+		{"unknown", []byte("plain text"), FormatPNG, FormatPNG},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DetectFormat(tt.data, tt.fallback); got != tt.want {
+				t.Errorf("DetectFormat = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsValidPolicy(t *testing.T) {
+	for _, policy := range []string{"", "warn", "sanitize", "refuse"} {
+		if !IsValidPolicy(policy) {
+			t.Errorf("IsValidPolicy(%q) = false, want true", policy)
+		}
+	}
+	for _, policy := range []string{"ignore", "Refuse"} {
+		if IsValidPolicy(policy) {
+			t.Errorf("IsValidPolicy(%q) = true, want false", policy)
+		}
+	}
+}
+
+func TestActiveContentError(t *testing.T) {
+	var err error = &ActiveContentError{
+		Source:   "logo.png",
+		Findings: []Finding{{Kind: "trailing-data", Detail: "12 bytes"}},
+	}
+
+	var target *ActiveContentError
+	if !errors.As(err, &target) {
+		t.Fatal("errors.As should match ActiveContentError")
+	}
+	if !strings.Contains(err.Error(), "logo.png") || !strings.Contains(err.Error(), "trailing-data: 12 bytes") {
+		t.Errorf("unexpected error message: %v", err)
+	}
+}
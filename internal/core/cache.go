@@ -0,0 +1,151 @@
+package core
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/fredcamaral/md-to-pdf/internal/fsutil"
+	"github.com/fredcamaral/md-to-pdf/internal/outline"
+	"github.com/fredcamaral/md-to-pdf/internal/renderer"
+	"github.com/yuin/goldmark/ast"
+)
+
+// CacheHandler receives whether the rendered PDF for outputPath came from
+// the render cache.
+type CacheHandler func(file, outputPath string, hit bool)
+
+// cachedRender is what the render cache keeps beside each PDF, so a cache
+// hit reports the same warnings and exports the same outline as a render.
+type cachedRender struct {
+	Headings []outline.Heading `json:"headings"`
+	Warnings []string          `json:"warnings"`
+}
+
+// cacheKey hashes everything a render depends on: the tool version, the
+// source name and markdown, the images it references, the settings that
+// affect rendering and the plugins that would be loaded. Output settings
+// applied after rendering (splitting, linearization, exports) are left out.
+func (e *Engine) cacheKey(content []byte, node ast.Node, sourceName string) (string, error) {
+	hash := sha256.New()
+	write := func(label string, data []byte) {
+		fmt.Fprintf(hash, "%s %d\n", label, len(data))
+		hash.Write(data)
+	}
+
+	// Summary carries the md-to-pdf version, set by the CLI for every run
+	settings, err := json.Marshal(struct {
+		Parser       ParserConfig
+		Renderer     RenderConfig
+		Plugins      PluginConfig
+		Document     DocumentConfig
+		Summary      SummaryConfig
+		Manifest     bool
+		Translations map[string]string
+	}{e.config.Parser, e.config.Renderer, e.config.Plugins, e.config.Document, e.config.Output.Summary, e.config.Output.Manifest, e.translations})
+	if err != nil {
+		return "", fmt.Errorf("failed to encode settings: %w", err)
+	}
+	write("settings", settings)
+	write("source", []byte(sourceName))
+	write("markdown", content)
+
+	for _, asset := range e.assets(node) {
+		data, err := os.ReadFile(asset) // #nosec G304 - image paths come from the document being converted
+		if err != nil {
+			// A missing image renders as alt text; hash that it was missing
+			write("missing "+asset, nil)
+			continue
+		}
+		write("asset "+asset, data)
+	}
+
+	checksums, err := e.plugins.Checksums()
+	if err != nil {
+		return "", err
+	}
+	for _, name := range sortedKeys(checksums) {
+		write("plugin "+name, []byte(checksums[name]))
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// assets returns the local images referenced by the document and its header
+// and footer snippets, sorted and without duplicates.
+func (e *Engine) assets(node ast.Node) []string {
+	seen := make(map[string]bool)
+	collect := func(node ast.Node) {
+		_ = ast.Walk(node, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+			if image, ok := n.(*ast.Image); ok && entering {
+				destination := string(image.Destination)
+				if destination != "" && !strings.Contains(destination, "://") && !strings.HasPrefix(destination, "data:") {
+					seen[renderer.ThemedImagePath(destination, e.config.Renderer.Mermaid.Theme)] = true
+				}
+			}
+			return ast.WalkContinue, nil
+		})
+	}
+
+	collect(node)
+	for _, snippet := range []string{e.config.Renderer.HeaderFooter.Header, e.config.Renderer.HeaderFooter.Footer} {
+		if snippet != "" {
+			if parsed, err := e.parser.Parse([]byte(snippet)); err == nil {
+				collect(parsed)
+			}
+		}
+	}
+	return sortedKeys(seen)
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// loadCached returns the cached PDF for key, or false when there is none.
+func (e *Engine) loadCached(key string) ([]byte, *cachedRender, bool) {
+	base := filepath.Join(e.config.Output.CacheDir, key)
+	data, err := os.ReadFile(base + ".pdf") // #nosec G304 - cache path is built from the cache directory and a hash
+	if err != nil {
+		return nil, nil, false
+	}
+	meta, err := os.ReadFile(base + ".json") // #nosec G304 - cache path is built from the cache directory and a hash
+	if err != nil {
+		return nil, nil, false
+	}
+	var entry cachedRender
+	if err := json.Unmarshal(meta, &entry); err != nil {
+		return nil, nil, false
+	}
+	return data, &entry, true
+}
+
+// storeCached saves a rendered PDF under key. Files are written under a
+// temporary name and renamed, so concurrent builds sharing a cache never
+// read a partial entry. The metadata is written last as it marks the entry
+// complete.
+func (e *Engine) storeCached(key string, data []byte, entry *cachedRender) error {
+	dir := e.config.Output.CacheDir
+	if err := os.MkdirAll(dir, 0750); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+	meta, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	base := filepath.Join(dir, key)
+	if err := fsutil.WriteFile(base+".pdf", data, 0600); err != nil {
+		return err
+	}
+	return fsutil.WriteFile(base+".json", meta, 0600)
+}
@@ -0,0 +1,92 @@
+package core
+
+import (
+	"os"
+
+	"github.com/fredcamaral/md-to-pdf/internal/accessibility"
+)
+
+// defaultLinkColor is the color links are drawn in unless configured.
+const defaultLinkColor = "#0000ee"
+
+// defaultCodeBackground is the color code blocks are drawn on unless
+// configured.
+const defaultCodeBackground = "#f5f5f5"
+
+// CheckFile audits a markdown file for accessibility problems without
+// rendering it. Errors that would fail the conversion, such as unbalanced
+// conditional blocks, are returned as a ConversionError.
+func (e *Engine) CheckFile(inputPath string) ([]accessibility.Issue, error) {
+	content, err := os.ReadFile(inputPath) // #nosec G304 - file path comes from user CLI input
+	if err != nil {
+		return nil, &ConversionError{
+			File:    inputPath,
+			Phase:   "file reading",
+			Message: "could not read input file",
+			Cause:   err,
+		}
+	}
+	return e.CheckSource(content, inputPath)
+}
+
+// CheckSource audits markdown content like CheckFile, reporting errors
+// against sourceName. The document is audited as it would be rendered:
+// excluded conditional blocks are left out and headings are shifted.
+func (e *Engine) CheckSource(content []byte, sourceName string) ([]accessibility.Issue, error) {
+	node, content, _, err := e.parse(content, sourceName)
+	if err != nil {
+		return nil, err
+	}
+	return accessibility.AuditDocument(node, content), nil
+}
+
+// ColorIssues reports the configured colors that text and rules are drawn
+// in, or on, with too little contrast to read comfortably. Without color
+// settings body text is black on a white page, links are blue, code is on
+// light gray and rules are light gray; pairs using only built-in colors are
+// not reported.
+func ColorIssues(config *Config) []accessibility.Issue {
+	colors := config.Renderer.Colors
+	text := colorOr(colors.Text, "black")
+	page := colorOr(colors.Background, "white")
+	link := colorOr(colors.Link, defaultLinkColor)
+	codeText := colorOr(colors.CodeText, text)
+	codeBackground := colorOr(colors.CodeBackground, defaultCodeBackground)
+
+	var pairs []accessibility.ColorPair
+	if colors.Text != "" || colors.Background != "" {
+		pairs = append(pairs, accessibility.ColorPair{Name: "body text", Foreground: text, Background: page})
+	}
+	if colors.Link != "" || colors.Background != "" {
+		pairs = append(pairs, accessibility.ColorPair{Name: "links", Foreground: link, Background: page})
+	}
+	if colors.Text != "" || colors.CodeText != "" || colors.CodeBackground != "" {
+		pairs = append(pairs, accessibility.ColorPair{Name: "code text", Foreground: codeText, Background: codeBackground})
+	}
+	if background := config.Renderer.QuoteStyle.Background; background != "" && background != "none" {
+		pairs = append(pairs,
+			accessibility.ColorPair{Name: "blockquote text", Foreground: text, Background: background},
+			accessibility.ColorPair{Name: "blockquote links", Foreground: link, Background: background},
+		)
+	}
+	rule := config.Renderer.ThematicBreak
+	if rule.Style == "ornament" && (rule.Color != DefaultConfig().Renderer.ThematicBreak.Color || colors.Background != "") {
+		pairs = append(pairs, accessibility.ColorPair{Name: "horizontal rule ornament", Foreground: rule.Color, Background: page, NonText: true})
+	}
+	if banner := config.Renderer.Banner; banner.Text != "" && (banner.Color != "" || banner.Background != "none") {
+		background := banner.Background
+		if background == "" || background == "none" {
+			background = page
+		}
+		pairs = append(pairs, accessibility.ColorPair{Name: "classification banner", Foreground: colorOr(banner.Color, text), Background: background})
+	}
+	return accessibility.AuditColors(pairs)
+}
+
+// colorOr returns value, or fallback when value is empty.
+func colorOr(value, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+	return value
+}
@@ -0,0 +1,95 @@
+package core
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// outputClaims records the input each derived output path was written
+// from, so a batch notices when two inputs, such as docs/a/readme.md and
+// docs/b/readme.md, would both write readme.pdf.
+type outputClaims struct {
+	mu      sync.Mutex
+	sources map[string]string // Cleaned output path -> absolute input path
+}
+
+// claim returns the path the PDF of source may be written to instead of
+// the derived outputPath, following policy, and the input that already
+// claimed outputPath when the PDF was renamed. A path derived again from
+// the same source, as in watch mode, stays its own.
+func (c *outputClaims) claim(source, outputPath, policy string) (string, string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.sources == nil {
+		c.sources = make(map[string]string)
+	}
+
+	source = absPath(source)
+	key := filepath.Clean(outputPath)
+	owner, taken := c.sources[key]
+	if !taken || owner == source || policy == "overwrite" {
+		c.sources[key] = source
+		return outputPath, "", nil
+	}
+
+	if policy != "rename" {
+		return "", "", fmt.Errorf("%s is also the output of %s; set on-collision to rename or overwrite, or convert them separately with --output", outputPath, owner)
+	}
+
+	// Prefix the name with the input's directory, then number it
+	dir, name := filepath.Split(outputPath)
+	prefixed := filepath.Base(filepath.Dir(source)) + "-" + name
+	renamed := filepath.Join(dir, prefixed)
+	ext := filepath.Ext(prefixed)
+	for n := 2; ; n++ {
+		if owner, taken := c.sources[filepath.Clean(renamed)]; !taken || owner == source {
+			break
+		}
+		renamed = filepath.Join(dir, fmt.Sprintf("%s_%d%s", strings.TrimSuffix(prefixed, ext), n, ext))
+	}
+	c.sources[filepath.Clean(renamed)] = source
+	return renamed, owner, nil
+}
+
+// absPath returns path made absolute, or path itself when that fails.
+func absPath(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return path
+}
+
+// OutputCollisions returns the inputs that would write the same PDF when
+// converted without --output, keyed by that PDF and sorted by input. PDFs
+// named after their title are not known before converting and not checked.
+func OutputCollisions(inputs []string) map[string][]string {
+	byOutput := make(map[string][]string)
+	seen := make(map[string]bool)
+	for _, input := range inputs {
+		if seen[absPath(input)] {
+			continue
+		}
+		seen[absPath(input)] = true
+		output := DerivedOutputPath(input)
+		byOutput[output] = append(byOutput[output], input)
+	}
+
+	collisions := make(map[string][]string)
+	for output, sources := range byOutput {
+		if len(sources) > 1 {
+			sort.Strings(sources)
+			collisions[output] = sources
+		}
+	}
+	return collisions
+}
+
+// DerivedOutputPath returns the PDF an input is converted to without
+// --output: its base name with a .pdf extension, in the working directory.
+func DerivedOutputPath(inputPath string) string {
+	baseName := strings.TrimSuffix(filepath.Base(inputPath), filepath.Ext(inputPath))
+	return baseName + ".pdf"
+}
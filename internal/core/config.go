@@ -20,22 +20,58 @@ func DefaultConfig() *Config {
 				Right:  15,
 			},
 			Mermaid: MermaidConfig{
-				Scale:     2.2,   // Double size + 20% by default
-				MaxWidth:  0,     // Use page width
-				MaxHeight: 150.0, // 150mm max height
+				Scale:        2.2,   // Double size + 20% by default
+				MaxWidth:     0,     // Use page width
+				MaxHeight:    150.0, // 150mm max height
+				Theme:        "light",
+				WideStrategy: "shrink", // Fit wide diagrams to the text column
 			},
+			HeaderFooter: HeaderFooterConfig{
+				HeaderAlign: "left",
+				FooterAlign: "center",
+			},
+			ImagePolicy: "sanitize",
+			ThematicBreak: ThematicBreakConfig{
+				Style:     "solid",
+				Thickness: 0.2,
+				Color:     "#c8c8c8",
+				Width:     100,
+				Ornament:  "* * *",
+			},
+			QuoteStyle: QuoteStyleConfig{
+				BarColor:   "#c8c8c8",
+				Background: "none",
+				FontStyle:  "italic",
+			},
+			SidenoteSide: "outer",
+			TOC: TOCConfig{
+				Depth: 3,
+				Title: "Contents",
+			},
+			Banner: BannerConfig{
+				Position:   "both",
+				Background: "none",
+			},
+			KeepWithNext:         "headings",
+			MinLinesAfterHeading: 1,
 		},
 		Plugins: PluginConfig{
 			Directory: "./plugins",
 			Enabled:   true,
 		},
 		Output: OutputConfig{
-			Quality: "standard",
+			Quality:     "standard",
+			OnCollision: "error",
 		},
 		Document: DocumentConfig{
 			Title:   "",
 			Author:  "",
 			Subject: "",
 		},
+		Network: NetworkConfig{
+			Timeout:     30,
+			Attempts:    3,
+			Concurrency: 4,
+		},
 	}
 }
@@ -1,11 +1,50 @@
 package core
 
-import "strings"
+import (
+	"strings"
+
+	"github.com/fredcamaral/md-to-pdf/internal/colorutil"
+)
 
 // ValidPageSizes defines the canonical list of supported page sizes.
 // This is the single source of truth for page size validation across the application.
 var ValidPageSizes = []string{"A3", "A4", "A5", "Letter", "Legal", "Tabloid"}
 
+// ValidRuleStyles defines the supported thematic break styles.
+var ValidRuleStyles = []string{"solid", "dashed", "dotted", "ornament"}
+
+// ValidQuoteFontStyles defines the supported blockquote font styles.
+var ValidQuoteFontStyles = []string{"italic", "normal"}
+
+// ValidBannerPositions defines the page edges a classification banner can
+// be stamped on.
+var ValidBannerPositions = []string{"top", "bottom", "both"}
+
+// ValidKeepWithNext defines the blocks that can be kept on the page of the
+// text after them.
+var ValidKeepWithNext = []string{"headings", "none"}
+
+// ValidSidenoteSides defines the margins sidenotes can be placed in.
+var ValidSidenoteSides = []string{"outer", "right", "left"}
+
+// ValidMermaidThemes defines the diagram variants a document can use.
+var ValidMermaidThemes = []string{"light", "dark"}
+
+// ValidMermaidWideStrategies defines how diagrams too wide for the text
+// column can be placed.
+var ValidMermaidWideStrategies = []string{"shrink", "rotate", "landscape"}
+
+// BuiltinFonts defines the font families every PDF viewer provides, which
+// documents are rendered with.
+var BuiltinFonts = []string{"Arial", "Courier", "Helvetica", "Times"}
+
+// ValidAlignments defines the supported horizontal alignments for headers and footers.
+var ValidAlignments = []string{"left", "center", "right"}
+
+// ValidCollisionPolicies defines what happens when two inputs of a batch
+// derive the same output path: fail, rename the later PDF or overwrite.
+var ValidCollisionPolicies = []string{"error", "rename", "overwrite"}
+
 // Validation range constants for configuration values.
 const (
 	// Font size range in points
@@ -35,6 +74,32 @@ const (
 	// Mermaid dimension range in mm
 	MermaidDimensionMin = 0.0
 	MermaidDimensionMax = 1000.0
+
+	// Thematic break line thickness range in mm
+	RuleThicknessMin = 0.05
+	RuleThicknessMax = 5.0
+
+	// Thematic break width range as a percentage of the text width
+	RuleWidthMin = 1.0
+	RuleWidthMax = 100.0
+
+	// Largest number of levels headings can be shifted up or down
+	HeadingShiftMax = 5
+
+	// Most lines of text that can be required below a heading on its page
+	MinLinesAfterHeadingMax = 10
+
+	// Longest network request timeout in seconds
+	MaxNetworkTimeout = 600
+
+	// Most times a failed network request is sent
+	MaxNetworkAttempts = 10
+
+	// Most downloads run at once
+	MaxNetworkConcurrency = 32
+
+	// Highest network rate limit in requests per second
+	MaxNetworkRateLimit = 1000
 )
 
 // IsValidPageSize checks if the given page size is valid (case-insensitive).
@@ -48,6 +113,105 @@ func IsValidPageSize(size string) bool {
 	return false
 }
 
+// IsValidAlignment checks if the given alignment is valid (case-sensitive).
+// An empty alignment is accepted and treated as "left".
+func IsValidAlignment(align string) bool {
+	if align == "" {
+		return true
+	}
+	for _, valid := range ValidAlignments {
+		if valid == align {
+			return true
+		}
+	}
+	return false
+}
+
+// IsValidRuleStyle checks if the given thematic break style is valid (case-sensitive).
+func IsValidRuleStyle(style string) bool {
+	for _, valid := range ValidRuleStyles {
+		if valid == style {
+			return true
+		}
+	}
+	return false
+}
+
+// IsValidQuoteFontStyle checks if the given blockquote font style is valid (case-sensitive).
+func IsValidQuoteFontStyle(style string) bool {
+	for _, valid := range ValidQuoteFontStyles {
+		if valid == style {
+			return true
+		}
+	}
+	return false
+}
+
+// IsValidBannerPosition checks if the given banner position is valid (case-sensitive).
+func IsValidBannerPosition(position string) bool {
+	for _, valid := range ValidBannerPositions {
+		if valid == position {
+			return true
+		}
+	}
+	return false
+}
+
+// IsValidKeepWithNext checks if the given keep-with-next setting is valid (case-sensitive).
+func IsValidKeepWithNext(value string) bool {
+	for _, valid := range ValidKeepWithNext {
+		if valid == value {
+			return true
+		}
+	}
+	return false
+}
+
+// IsValidSidenoteSide checks if the given sidenote side is valid (case-sensitive).
+func IsValidSidenoteSide(side string) bool {
+	for _, valid := range ValidSidenoteSides {
+		if valid == side {
+			return true
+		}
+	}
+	return false
+}
+
+// IsValidMermaidTheme checks if the given diagram theme is valid (case-sensitive).
+func IsValidMermaidTheme(theme string) bool {
+	for _, valid := range ValidMermaidThemes {
+		if valid == theme {
+			return true
+		}
+	}
+	return false
+}
+
+// IsValidMermaidWideStrategy checks if the given wide diagram strategy is valid (case-sensitive).
+func IsValidMermaidWideStrategy(strategy string) bool {
+	for _, valid := range ValidMermaidWideStrategies {
+		if valid == strategy {
+			return true
+		}
+	}
+	return false
+}
+
+// IsValidCollisionPolicy checks if the given output collision policy is valid (case-sensitive).
+func IsValidCollisionPolicy(policy string) bool {
+	for _, valid := range ValidCollisionPolicies {
+		if valid == policy {
+			return true
+		}
+	}
+	return false
+}
+
+// isValidOptionalColor accepts a color or "none".
+func isValidOptionalColor(value string) bool {
+	return value == "none" || colorutil.IsValid(value)
+}
+
 // ValidPageSizesString returns a comma-separated list of valid page sizes for error messages.
 func ValidPageSizesString() string {
 	return strings.Join(ValidPageSizes, ", ")
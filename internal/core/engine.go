@@ -1,21 +1,59 @@
 package core
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
+	"time"
 
+	"github.com/fredcamaral/md-to-pdf/internal/manifest"
+	"github.com/fredcamaral/md-to-pdf/internal/network"
+	"github.com/fredcamaral/md-to-pdf/internal/outline"
 	"github.com/fredcamaral/md-to-pdf/internal/parser"
+	"github.com/fredcamaral/md-to-pdf/internal/pdfsplit"
 	"github.com/fredcamaral/md-to-pdf/internal/plugins"
 	"github.com/fredcamaral/md-to-pdf/internal/renderer"
+	"github.com/fredcamaral/md-to-pdf/internal/thumbnail"
+	"github.com/yuin/goldmark/ast"
 )
 
 type Engine struct {
 	parser   *parser.MarkdownParser
 	renderer *renderer.PDFRenderer
 	plugins  *plugins.Manager
+	images   *renderer.ImageCache
 	config   *Config
+
+	// http is the client plugins fetch remote resources with
+	http *network.Client
+
+	// translations replaces {{t:key}} placeholders for localized builds
+	translations map[string]string
+
+	// locale is the locale built by this engine, "" for the base one
+	locale string
+
+	// onWarning receives non-fatal warnings such as sanitized images
+	onWarning WarningHandler
+
+	// onSplit receives the part files of PDFs split by size
+	onSplit SplitHandler
+
+	// onCache learns whether each PDF came from the render cache
+	onCache CacheHandler
+
+	// onPluginLog receives the debug and info messages of plugins
+	onPluginLog plugins.LogHandler
+
+	// partial records the files of the conversion in progress
+	partial *partialOutputs
+
+	// claims records the inputs of derived output paths, to detect two
+	// inputs writing the same PDF
+	claims *outputClaims
 }
 
 func NewEngine(config *Config) (*Engine, error) {
@@ -23,14 +61,81 @@ func NewEngine(config *Config) (*Engine, error) {
 	if err := ValidateConfig(config); err != nil {
 		return nil, err
 	}
+
+	pluginManager := plugins.NewManager(config.Plugins.Directory, config.Plugins.Enabled, config.Plugins.Configs)
+	mermaidTheme := config.Renderer.Mermaid.Theme
+	if mermaidTheme == "" {
+		mermaidTheme = "light"
+	}
+	pluginManager.SetDocumentConfig(map[string]interface{}{
+		"mermaid_theme":    mermaidTheme,
+		"background_color": config.Renderer.Colors.Background,
+	})
+	if config.Plugins.Grants != nil {
+		security := plugins.DefaultSecurityConfig()
+		security.Grants = config.Plugins.Grants
+		if err := pluginManager.SetSecurityConfig(security); err != nil {
+			return nil, err
+		}
+	}
+	if config.Output.SourceAppendix {
+		if err := pluginManager.RegisterBuiltin(plugins.NewSourceAppendixGenerator()); err != nil {
+			return nil, err
+		}
+	}
+	if config.Output.Summary.Enabled {
+		summary := plugins.NewSummaryGenerator(config.Output.Summary.ToolVersion, ConfigFingerprint(config), config.Output.Summary.RepoURL)
+		if err := pluginManager.RegisterBuiltin(summary); err != nil {
+			return nil, err
+		}
+	}
+
+	images := renderer.NewImageCache()
+
+	engine := &Engine{
+		parser:   parser.AcquireParser(),
+		renderer: newRenderer(config, pluginManager, images),
+		plugins:  pluginManager,
+		images:   images,
+		config:   config,
+		http:     newHTTPClient(config),
+		partial:  &partialOutputs{},
+		claims:   &outputClaims{},
+	}
+	pluginManager.SetLogHandler(engine.pluginLogHandler(""))
+	pluginManager.SetHTTPClient(engine.http)
+	return engine, nil
+}
+
+// newHTTPClient creates the client plugins fetch remote resources with.
+// Responses are kept beside the render cache when there is one.
+func newHTTPClient(config *Config) *network.Client {
+	opts := network.Options{
+		Timeout:     time.Duration(config.Network.Timeout) * time.Second,
+		Proxy:       config.Network.Proxy,
+		Offline:     config.Network.Offline,
+		RateLimit:   config.Network.RateLimit,
+		Attempts:    config.Network.Attempts,
+		Concurrency: config.Network.Concurrency,
+	}
+	if config.Output.CacheDir != "" {
+		opts.CacheDir = filepath.Join(config.Output.CacheDir, "http")
+	}
+	return network.NewClient(opts)
+}
+
+// newRenderer creates a PDF renderer for config that shares the given plugin
+// manager and image cache.
+func newRenderer(config *Config, pluginManager *plugins.Manager, images *renderer.ImageCache) *renderer.PDFRenderer {
 	rendererConfig := &renderer.RenderConfig{
-		PageSize:     config.Renderer.PageSize,
-		FontFamily:   config.Renderer.FontFamily,
-		FontSize:     config.Renderer.FontSize,
-		HeadingScale: config.Renderer.HeadingScale,
-		LineSpacing:  config.Renderer.LineSpacing,
-		CodeFont:     config.Renderer.CodeFont,
-		CodeSize:     config.Renderer.CodeSize,
+		PageSize:       config.Renderer.PageSize,
+		FontFamily:     config.Renderer.FontFamily,
+		FontSize:       config.Renderer.FontSize,
+		HeadingScale:   config.Renderer.HeadingScale,
+		HeadingMinSize: config.Renderer.HeadingMinSize,
+		LineSpacing:    config.Renderer.LineSpacing,
+		CodeFont:       config.Renderer.CodeFont,
+		CodeSize:       config.Renderer.CodeSize,
 		Margins: renderer.Margins{
 			Top:    config.Renderer.Margins.Top,
 			Bottom: config.Renderer.Margins.Bottom,
@@ -38,30 +143,59 @@ func NewEngine(config *Config) (*Engine, error) {
 			Right:  config.Renderer.Margins.Right,
 		},
 		Mermaid: renderer.MermaidConfig{
-			Scale:     config.Renderer.Mermaid.Scale,
-			MaxWidth:  config.Renderer.Mermaid.MaxWidth,
-			MaxHeight: config.Renderer.Mermaid.MaxHeight,
+			Scale:        config.Renderer.Mermaid.Scale,
+			MaxWidth:     config.Renderer.Mermaid.MaxWidth,
+			MaxHeight:    config.Renderer.Mermaid.MaxHeight,
+			Theme:        config.Renderer.Mermaid.Theme,
+			WideStrategy: config.Renderer.Mermaid.WideStrategy,
+		},
+		HeaderFooter: renderer.HeaderFooterConfig{
+			Header:      config.Renderer.HeaderFooter.Header,
+			Footer:      config.Renderer.HeaderFooter.Footer,
+			HeaderAlign: config.Renderer.HeaderFooter.HeaderAlign,
+			FooterAlign: config.Renderer.HeaderFooter.FooterAlign,
+		},
+		ImagePolicy:  config.Renderer.ImagePolicy,
+		BaselineGrid: config.Renderer.BaselineGrid,
+		ThematicBreak: renderer.ThematicBreakConfig{
+			Style:     config.Renderer.ThematicBreak.Style,
+			Thickness: config.Renderer.ThematicBreak.Thickness,
+			Color:     config.Renderer.ThematicBreak.Color,
+			Width:     config.Renderer.ThematicBreak.Width,
+			Ornament:  config.Renderer.ThematicBreak.Ornament,
+		},
+		QuoteStyle: renderer.QuoteStyleConfig{
+			BarColor:   config.Renderer.QuoteStyle.BarColor,
+			Background: config.Renderer.QuoteStyle.Background,
+			FontStyle:  config.Renderer.QuoteStyle.FontStyle,
+		},
+		Colors:       renderer.ColorConfig(config.Renderer.Colors),
+		SidenoteSide: config.Renderer.SidenoteSide,
+		TOC: renderer.TOCConfig{
+			Enabled: config.Renderer.TOC.Enabled,
+			Depth:   config.Renderer.TOC.Depth,
+			Title:   config.Renderer.TOC.Title,
 		},
+		Banner:               renderer.BannerConfig(config.Renderer.Banner),
+		KeepWithNext:         config.Renderer.KeepWithNext,
+		MinLinesAfterHeading: config.Renderer.MinLinesAfterHeading,
 	}
 
-	pluginManager := plugins.NewManager(config.Plugins.Directory, config.Plugins.Enabled, config.Plugins.Configs)
-
 	documentMetadata := &renderer.DocumentMetadata{
-		Title:   config.Document.Title,
-		Author:  config.Document.Author,
-		Subject: config.Document.Subject,
+		Title:      config.Document.Title,
+		Author:     config.Document.Author,
+		Subject:    config.Document.Subject,
+		DateFormat: config.Document.DateFormat,
 	}
 
-	return &Engine{
-		parser:   parser.NewMarkdownParser(),
-		renderer: renderer.NewPDFRenderer(rendererConfig, documentMetadata, pluginManager),
-		plugins:  pluginManager,
-		config:   config,
-	}, nil
+	r := renderer.NewPDFRenderer(rendererConfig, documentMetadata, pluginManager)
+	r.SetImageCache(images)
+	return r
 }
 
 func (e *Engine) Convert(opts ConversionOptions) error {
 	// Load plugins
+	e.plugins.SetLogHandler(e.pluginLogHandler(""))
 	err := e.plugins.LoadPlugins()
 	if err != nil {
 		return fmt.Errorf("failed to load plugins: %w", err)
@@ -73,37 +207,51 @@ func (e *Engine) Convert(opts ConversionOptions) error {
 		}
 	}()
 
-	total := len(opts.InputFiles)
-	for i, inputFile := range opts.InputFiles {
-		outputPath := e.determineOutputPath(inputFile, opts.OutputPath)
+	locales := opts.Locales
+	if len(locales) == 0 {
+		locales = []string{""}
+	}
+
+	total := len(opts.InputFiles) * len(locales)
+	current := 0
+	for _, inputFile := range opts.InputFiles {
+		for _, locale := range locales {
+			current++
+			target := e
+			if locale != "" {
+				target = e.forLocale(locale)
+			}
+			sourcePath := localizedSourcePath(inputFile, locale)
+			outputPath := LocalizedPath(e.determineOutputPath(inputFile, opts.OutputPath), locale)
 
-		// Call progress callback before conversion
-		if opts.OnProgress != nil {
-			opts.OnProgress(i+1, total, inputFile, outputPath)
-		}
+			// Call progress callback before conversion
+			if opts.OnProgress != nil {
+				opts.OnProgress(current, total, sourcePath, outputPath)
+			}
 
-		err := e.convertFile(inputFile, opts.OutputPath)
-		if err != nil {
-			return fmt.Errorf("failed to convert %s: %w", inputFile, err)
-		}
+			outputPath, err := target.convertFile(sourcePath, outputPath, opts.OutputPath == "")
+			if err != nil {
+				return fmt.Errorf("failed to convert %s: %w", sourcePath, err)
+			}
 
-		// Call completion callback after successful conversion
-		if opts.OnComplete != nil {
-			opts.OnComplete(i+1, total, inputFile, outputPath)
-		}
+			// Call completion callback after successful conversion
+			if opts.OnComplete != nil {
+				opts.OnComplete(current, total, sourcePath, outputPath)
+			}
 
-		if opts.Verbose {
-			fmt.Printf("Converted: %s\n", inputFile)
+			if opts.Verbose {
+				fmt.Printf("Converted: %s\n", sourcePath)
+			}
 		}
 	}
 
 	return nil
 }
 
-func (e *Engine) convertFile(inputPath, outputPath string) error {
+func (e *Engine) convertFile(inputPath, outputPath string, derived bool) (string, error) {
 	content, err := os.ReadFile(inputPath) // #nosec G304 - file path comes from user CLI input
 	if err != nil {
-		return &ConversionError{
+		return "", &ConversionError{
 			File:    inputPath,
 			Phase:   "file reading",
 			Message: "could not read input file",
@@ -111,13 +259,85 @@ func (e *Engine) convertFile(inputPath, outputPath string) error {
 		}
 	}
 
-	return e.convertContent(content, inputPath, outputPath)
+	return e.convertContent(content, inputPath, outputPath, derived)
+}
+
+// Plugins loads the configured plugins and describes them along with the
+// built-in ones, sorted by name.
+func (e *Engine) Plugins() ([]plugins.PluginInfo, error) {
+	if err := e.plugins.LoadPlugins(); err != nil {
+		return nil, fmt.Errorf("failed to load plugins: %w", err)
+	}
+	defer func() {
+		if cleanupErr := e.plugins.Cleanup(); cleanupErr != nil {
+			fmt.Printf("Warning: plugin cleanup failed: %v\n", cleanupErr)
+		}
+	}()
+
+	list := e.plugins.ListPlugins()
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list, nil
+}
+
+// NetworkStats returns the network activity of the plugins of the engine so
+// far. Take the difference of two snapshots for the activity in between.
+func (e *Engine) NetworkStats() network.Stats {
+	return e.http.Stats()
+}
+
+// Languages loads the configured plugins like Plugins and returns the
+// fenced code block languages they and md-to-pdf handle, sorted by name.
+func (e *Engine) Languages() ([]plugins.RegisteredLanguage, error) {
+	if err := e.plugins.LoadPlugins(); err != nil {
+		return nil, fmt.Errorf("failed to load plugins: %w", err)
+	}
+	defer func() {
+		if cleanupErr := e.plugins.Cleanup(); cleanupErr != nil {
+			fmt.Printf("Warning: plugin cleanup failed: %v\n", cleanupErr)
+		}
+	}()
+
+	return e.plugins.Languages().List(), nil
+}
+
+// PluginDiagnostics describes the plugins a conversion would load, for bug
+// reports.
+type PluginDiagnostics struct {
+	Plugins   []plugins.PluginInfo      // Loaded and built-in plugins, sorted by name
+	Checksums map[string]string         // SHA256 of each plugin file, by file name
+	Events    []plugins.PluginLoadEvent // Security events of loading the plugin files
+}
+
+// PluginDiagnostics loads the configured plugins like Plugins and reports
+// them with the checksums of their files and the security events of
+// loading them.
+func (e *Engine) PluginDiagnostics() (*PluginDiagnostics, error) {
+	list, err := e.Plugins()
+	if err != nil {
+		return nil, err
+	}
+	checksums, err := e.plugins.Checksums()
+	if err != nil {
+		return nil, err
+	}
+	return &PluginDiagnostics{
+		Plugins:   list,
+		Checksums: checksums,
+		Events:    e.plugins.GetSecurityEvents(),
+	}, nil
 }
 
 // ConvertFromContent converts markdown content from bytes to PDF.
 // This is used for stdin input where content is provided directly.
 func (e *Engine) ConvertFromContent(content []byte, outputPath string) error {
+	return e.ConvertSource(content, "stdin", outputPath)
+}
+
+// ConvertSource converts markdown content to PDF like ConvertFromContent,
+// reporting warnings and errors against sourceName.
+func (e *Engine) ConvertSource(content []byte, sourceName, outputPath string) error {
 	// Load plugins
+	e.plugins.SetLogHandler(e.pluginLogHandler(""))
 	err := e.plugins.LoadPlugins()
 	if err != nil {
 		return fmt.Errorf("failed to load plugins: %w", err)
@@ -129,43 +349,429 @@ func (e *Engine) ConvertFromContent(content []byte, outputPath string) error {
 		}
 	}()
 
-	return e.convertContent(content, "stdin", outputPath)
+	_, err = e.convertContent(content, sourceName, outputPath, outputPath == "")
+	return err
+}
+
+// ConvertBytes converts markdown content to a PDF in memory and returns it,
+// reporting warnings and errors against sourceName. Nothing is written:
+// outlines, contact sheets, source PDFs and size limits, which produce files
+// of their own, are ignored. The PDF is linearized when configured.
+//
+// Plugins are loaded from the configured directory like for any other
+// conversion, which is "./plugins" by default; processes embedding the
+// engine should set Plugins.Enabled to false unless they trust that
+// directory. A failed plugin cleanup is reported as a warning.
+func (e *Engine) ConvertBytes(content []byte, sourceName string) ([]byte, error) {
+	e.plugins.SetLogHandler(e.pluginLogHandler(""))
+	if err := e.plugins.LoadPlugins(); err != nil {
+		return nil, fmt.Errorf("failed to load plugins: %w", err)
+	}
+	defer func() {
+		if cleanupErr := e.plugins.Cleanup(); cleanupErr != nil {
+			e.reportWarnings(sourceName, []string{fmt.Sprintf("plugin cleanup failed: %v", cleanupErr)})
+		}
+	}()
+
+	e.plugins.SetLogHandler(e.pluginLogHandler(sourceName))
+	e.renderer.SetManifest(e.newManifest(content, sourceName))
+	node, content, title, err := e.parse(content, sourceName)
+	if err != nil {
+		return nil, err
+	}
+	if e.config.Document.Title == "" {
+		e.renderer.SetTitle(title)
+	}
+	pdfData, _, err := e.render(node, content, sourceName, "")
+	if err != nil {
+		return nil, err
+	}
+
+	if e.config.Output.Linearize {
+		pdfData, err = pdfsplit.Linearize(pdfData)
+		if err != nil {
+			return nil, &ConversionError{
+				File:    sourceName,
+				Phase:   "PDF linearization",
+				Message: "could not linearize PDF",
+				Cause:   err,
+			}
+		}
+	}
+	return pdfData, nil
+}
+
+// convertContent converts content and returns the path of the written PDF.
+// A derived outputPath, one the user did not choose, is replaced by a name
+// taken from the document title when the title comes from the first H1.
+func (e *Engine) convertContent(content []byte, sourceName, outputPath string, derived bool) (string, error) {
+	e.plugins.SetLogHandler(e.pluginLogHandler(sourceName))
+	e.partial.start()
+	e.renderer.SetManifest(e.newManifest(content, sourceName))
+	node, content, title, err := e.parse(content, sourceName)
+	if err != nil {
+		return "", err
+	}
+
+	finalOutputPath := e.determineOutputPath(sourceName, outputPath)
+	if slug := outline.Slugify(title); derived && slug != "" {
+		finalOutputPath = LocalizedPath(slug+".pdf", e.locale)
+	}
+	if derived {
+		claimed, owner, err := e.claims.claim(sourceName, finalOutputPath, e.config.Output.OnCollision)
+		if err != nil {
+			return "", &ConversionError{
+				File:    sourceName,
+				Phase:   "output naming",
+				Message: "output path collision",
+				Cause:   err,
+			}
+		}
+		if owner != "" {
+			e.reportWarnings(sourceName, []string{fmt.Sprintf("%s is also the output of %s, writing %s instead", finalOutputPath, owner, claimed)})
+			finalOutputPath = claimed
+		}
+	}
+	if e.config.Document.Title == "" {
+		e.renderer.SetTitle(title)
+	}
+	pdfData, headings, err := e.render(node, content, sourceName, finalOutputPath)
+	if err != nil {
+		return "", err
+	}
+
+	parts, err := e.splitBySize(pdfData, sourceName)
+	if err != nil {
+		return "", &ConversionError{
+			File:    sourceName,
+			Phase:   "PDF splitting",
+			Message: "could not split PDF by size",
+			Cause:   err,
+		}
+	}
+
+	if e.config.Output.Linearize {
+		for i := range parts {
+			parts[i].Data, err = pdfsplit.Linearize(parts[i].Data)
+			if err != nil {
+				return "", &ConversionError{
+					File:    sourceName,
+					Phase:   "PDF linearization",
+					Message: "could not linearize PDF",
+					Cause:   err,
+				}
+			}
+		}
+	}
+
+	if len(parts) > 1 {
+		written, err := e.writeParts(parts, finalOutputPath, sourceName)
+		if err != nil {
+			return "", &ConversionError{
+				File:    sourceName,
+				Phase:   "file writing",
+				Message: "could not write PDF part",
+				Cause:   err,
+			}
+		}
+		for _, part := range written {
+			e.partial.add(part.Path)
+		}
+		headings = linkHeadingsToParts(headings, written)
+		if e.onSplit != nil {
+			e.onSplit(sourceName, finalOutputPath, written)
+		}
+	} else {
+		err = e.writeOutput(finalOutputPath, parts[0].Data, sourceName)
+		if err != nil {
+			return "", &ConversionError{
+				File:    sourceName,
+				Phase:   "file writing",
+				Message: "could not write PDF file",
+				Cause:   err,
+			}
+		}
+		e.partial.add(finalOutputPath)
+	}
+
+	if e.config.Output.OutlinePath != "" {
+		err = outline.Write(e.config.Output.OutlinePath, sourceName, finalOutputPath, headings)
+		if err != nil {
+			return "", &ConversionError{
+				File:    sourceName,
+				Phase:   "outline export",
+				Message: "could not write outline file",
+				Cause:   err,
+			}
+		}
+		e.partial.add(e.config.Output.OutlinePath)
+	}
+
+	if e.config.Output.SourcePDFPath != "" {
+		margins := e.config.Renderer.Margins
+		err = plugins.NewSourceListing(sourceName, content).WritePDF(e.config.Output.SourcePDFPath, e.config.Renderer.PageSize, margins.Left, margins.Top, margins.Right, margins.Bottom)
+		if err != nil {
+			return "", &ConversionError{
+				File:    sourceName,
+				Phase:   "source listing",
+				Message: "could not write source listing",
+				Cause:   err,
+			}
+		}
+		e.partial.add(e.config.Output.SourcePDFPath)
+	}
+
+	if e.config.Output.ContactSheetPath != "" {
+		err = thumbnail.WriteContactSheet(e.config.Output.ContactSheetPath, pdfData, thumbnail.DefaultOptions())
+		if err != nil {
+			return "", &ConversionError{
+				File:    sourceName,
+				Phase:   "contact sheet",
+				Message: "could not write contact sheet",
+				Cause:   err,
+			}
+		}
+		e.partial.add(e.config.Output.ContactSheetPath)
+	}
+
+	return finalOutputPath, nil
 }
 
-func (e *Engine) convertContent(content []byte, sourceName, outputPath string) error {
+// parse translates and parses content, then applies the document
+// transformations that come before rendering: comments and conditional
+// blocks, the title taken from the first H1 and heading shifts. It returns
+// the document, the source with removed parts blanked and the title taken.
+func (e *Engine) parse(content []byte, sourceName string) (ast.Node, []byte, string, error) {
+	content = Translate(content, e.translations)
+
 	node, err := e.parser.Parse(content)
 	if err != nil {
-		return &ConversionError{
+		return nil, nil, "", &ConversionError{
 			File:    sourceName,
 			Phase:   "markdown parsing",
 			Message: "could not parse markdown content",
 			Cause:   err,
 		}
 	}
+	defines := make(map[string]bool, len(e.config.Parser.Defines))
+	for _, name := range e.config.Parser.Defines {
+		defines[name] = true
+	}
+	content, err = parser.ResolveComments(node, content, defines)
+	if err != nil {
+		var sourceErr *parser.SourceError
+		if errors.As(err, &sourceErr) {
+			sourceErr.File = sourceName
+		}
+		return nil, nil, "", &ConversionError{
+			File:    sourceName,
+			Phase:   "markdown parsing",
+			Message: "invalid conditional block",
+			Cause:   err,
+		}
+	}
 
+	title := ""
+	if e.config.Document.TitleFromH1 {
+		title = parser.TakeTitle(node, content)
+	}
+	parser.ShiftHeadings(node, content, e.config.Parser.HeadingShift, e.config.Parser.HeadingMap)
+	return node, content, title, nil
+}
+
+// newManifest returns the manifest of a PDF converted from content, or nil
+// when manifests are not enabled. The renderer adds the images.
+func (e *Engine) newManifest(content []byte, sourceName string) *manifest.Manifest {
+	if !e.config.Output.Manifest {
+		return nil
+	}
+	return &manifest.Manifest{
+		Format:            manifest.Format,
+		ToolVersion:       e.config.Output.Summary.ToolVersion,
+		ConfigFingerprint: ConfigFingerprint(e.config),
+		Source:            manifest.NewFile(sourceName, content),
+	}
+}
+
+// render renders the parsed document, or takes the PDF from the render
+// cache when one is configured and holds an entry for the same inputs.
+func (e *Engine) render(node ast.Node, content []byte, sourceName, outputPath string) ([]byte, []outline.Heading, error) {
+	key := ""
+	if e.config.Output.CacheDir != "" {
+		var err error
+		key, err = e.cacheKey(content, node, sourceName)
+		if err != nil {
+			return nil, nil, &ConversionError{
+				File:    sourceName,
+				Phase:   "render cache",
+				Message: "could not compute cache key",
+				Cause:   err,
+			}
+		}
+		if data, entry, ok := e.loadCached(key); ok {
+			e.reportWarnings(sourceName, entry.Warnings)
+			if e.onCache != nil {
+				e.onCache(sourceName, outputPath, true)
+			}
+			return data, entry.Headings, nil
+		}
+	}
+
+	e.renderer.SetSourceFile(sourceName)
 	pdfBuffer, err := e.renderer.Render(node, content)
+	warnings := e.renderer.Warnings()
+	e.reportWarnings(sourceName, warnings)
 	if err != nil {
-		return &ConversionError{
+		return nil, nil, &ConversionError{
 			File:    sourceName,
 			Phase:   "PDF rendering",
 			Message: "could not render PDF",
 			Cause:   err,
 		}
 	}
+	headings := e.renderer.Headings()
 
-	finalOutputPath := e.determineOutputPath(sourceName, outputPath)
+	if key != "" {
+		if e.onCache != nil {
+			e.onCache(sourceName, outputPath, false)
+		}
+		if err := e.storeCached(key, pdfBuffer.Bytes(), &cachedRender{Headings: headings, Warnings: warnings}); err != nil {
+			// A cache that cannot be written only costs time on the next run
+			e.reportWarnings(sourceName, []string{fmt.Sprintf("could not write render cache: %v", err)})
+		}
+	}
+	return pdfBuffer.Bytes(), headings, nil
+}
+
+// Close returns the parser of e to its pool, for the next engine to reuse.
+// e must not be used afterwards. Closing is optional: an engine that is
+// never closed is simply garbage collected.
+func (e *Engine) Close() {
+	parser.ReleaseParser(e.parser)
+	e.parser = nil
+}
+
+// SetWarningHandler sets the function that receives conversion warnings.
+// By default warnings are printed to stderr.
+func (e *Engine) SetWarningHandler(handler WarningHandler) {
+	e.onWarning = handler
+}
+
+// SetCacheHandler sets the function told whether each PDF came from the
+// render cache. It is only called when a cache directory is configured.
+func (e *Engine) SetCacheHandler(handler CacheHandler) {
+	e.onCache = handler
+}
+
+// SetSplitHandler sets the function that receives the part files of PDFs
+// split by --max-output-size.
+func (e *Engine) SetSplitHandler(handler SplitHandler) {
+	e.onSplit = handler
+}
+
+// SetPluginLogHandler sets the function that receives the debug and info
+// messages plugins log. Without one they are dropped. Plugin warnings go to
+// the warning handler.
+func (e *Engine) SetPluginLogHandler(handler plugins.LogHandler) {
+	e.onPluginLog = handler
+}
+
+// pluginLogHandler returns the plugin log handler used while converting
+// sourceName, or outside of a conversion when it is empty.
+func (e *Engine) pluginLogHandler(sourceName string) plugins.LogHandler {
+	return func(plugin string, level plugins.LogLevel, message string) {
+		if level == plugins.LogWarn {
+			e.reportWarnings(sourceName, []string{fmt.Sprintf("plugin %s: %s", plugin, message)})
+			return
+		}
+		if e.onPluginLog != nil {
+			e.onPluginLog(plugin, level, message)
+		}
+	}
+}
 
-	err = os.WriteFile(finalOutputPath, pdfBuffer.Bytes(), 0600)
+// reportWarnings passes warnings for a source file to the warning handler.
+func (e *Engine) reportWarnings(sourceName string, warnings []string) {
+	for _, warning := range warnings {
+		if e.onWarning != nil {
+			e.onWarning(sourceName, warning)
+			continue
+		}
+		if sourceName == "" {
+			fmt.Fprintf(os.Stderr, "Warning: %s\n", warning)
+			continue
+		}
+		fmt.Fprintf(os.Stderr, "Warning: %s: %s\n", sourceName, warning)
+	}
+}
+
+// splitBySize splits a rendered PDF into parts under the configured maximum
+// output size. Without a limit the PDF is returned as a single part. Parts
+// that still exceed the limit hold a single page and are reported as
+// warnings.
+func (e *Engine) splitBySize(data []byte, sourceName string) ([]pdfsplit.Part, error) {
+	if e.config.Output.MaxSize == "" {
+		return []pdfsplit.Part{{Data: data}}, nil
+	}
+	maxSize, err := ParseByteSize(e.config.Output.MaxSize)
 	if err != nil {
-		return &ConversionError{
-			File:    sourceName,
-			Phase:   "file writing",
-			Message: "could not write PDF file",
-			Cause:   err,
+		return nil, err
+	}
+
+	parts, err := pdfsplit.Split(data, maxSize)
+	if err != nil {
+		return nil, err
+	}
+	for _, part := range parts {
+		if int64(len(part.Data)) > maxSize {
+			e.reportWarnings(sourceName, []string{fmt.Sprintf(
+				"page %d is %s on its own, over the %s limit",
+				part.FirstPage, FormatByteSize(int64(len(part.Data))), FormatByteSize(maxSize))})
 		}
 	}
+	return parts, nil
+}
 
-	return nil
+// PartPath returns the file name of part index (1-based) of count parts of
+// outputPath: "report.pdf" becomes "report-1.pdf", or "report-01.pdf" when
+// there are ten or more parts so the files sort in order.
+func PartPath(outputPath string, index, count int) string {
+	ext := filepath.Ext(outputPath)
+	width := len(fmt.Sprint(count))
+	return fmt.Sprintf("%s-%0*d%s", strings.TrimSuffix(outputPath, ext), width, index, ext)
+}
+
+// writeParts writes split parts of a PDF converted from sourceName next to
+// outputPath.
+func (e *Engine) writeParts(parts []pdfsplit.Part, outputPath, sourceName string) ([]OutputPart, error) {
+	written := make([]OutputPart, len(parts))
+	for i, part := range parts {
+		written[i] = OutputPart{
+			Path:      PartPath(outputPath, i+1, len(parts)),
+			FirstPage: part.FirstPage,
+			LastPage:  part.LastPage,
+		}
+		if err := e.writeOutput(written[i].Path, part.Data, sourceName); err != nil {
+			return nil, err
+		}
+	}
+	return written, nil
+}
+
+// linkHeadingsToParts points outline links at the part file holding each
+// heading, with the page number counted within that part.
+func linkHeadingsToParts(headings []outline.Heading, parts []OutputPart) []outline.Heading {
+	linked := make([]outline.Heading, len(headings))
+	for i, h := range headings {
+		linked[i] = h
+		for _, part := range parts {
+			if h.Page >= part.FirstPage && h.Page <= part.LastPage {
+				linked[i].Link = fmt.Sprintf("%s#page=%d", filepath.Base(part.Path), h.Page-part.FirstPage+1)
+				break
+			}
+		}
+	}
+	return linked
 }
 
 func (e *Engine) determineOutputPath(inputPath, outputPath string) string {
@@ -173,6 +779,5 @@ func (e *Engine) determineOutputPath(inputPath, outputPath string) string {
 		return outputPath
 	}
 
-	baseName := strings.TrimSuffix(filepath.Base(inputPath), filepath.Ext(inputPath))
-	return baseName + ".pdf"
+	return DerivedOutputPath(inputPath)
 }
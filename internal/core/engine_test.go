@@ -1,10 +1,23 @@
 package core
 
 import (
+	"bytes"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"image"
+	"image/png"
 	"os"
 	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
 	"testing"
+
+	"github.com/fredcamaral/md-to-pdf/internal/manifest"
+	"github.com/fredcamaral/md-to-pdf/internal/outline"
+	"github.com/fredcamaral/md-to-pdf/internal/parser"
+	"github.com/fredcamaral/md-to-pdf/internal/renderer"
 )
 
 func TestDefaultConfig(t *testing.T) {
@@ -177,6 +190,65 @@ func TestEngine_Convert(t *testing.T) {
 	}
 }
 
+func TestEngine_ConvertBytes(t *testing.T) {
+	tempDir := t.TempDir()
+
+	config := DefaultConfig()
+	config.Plugins.Enabled = false
+	config.Document.TitleFromH1 = true
+	config.Output.OutlinePath = filepath.Join(tempDir, "outline.json")
+	engine, err := NewEngine(config)
+	if err != nil {
+		t.Fatalf("Failed to create engine: %v", err)
+	}
+
+	data, err := engine.ConvertBytes([]byte("# In Memory\n\nNo files involved."), "doc.md")
+	if err != nil {
+		t.Fatalf("ConvertBytes failed: %v", err)
+	}
+	if !bytes.HasPrefix(data, []byte("%PDF-")) {
+		t.Error("ConvertBytes should return a PDF")
+	}
+	if !bytes.Contains(data, []byte("In Memory")) {
+		t.Error("the title taken from the H1 should be in the PDF metadata")
+	}
+	entries, err := os.ReadDir(tempDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("ConvertBytes should not write files, found %d", len(entries))
+	}
+
+	if _, err := engine.ConvertBytes([]byte("<!-- if:x -->\ntext\n"), "doc.md"); err == nil {
+		t.Error("expected an error for an unbalanced conditional block")
+	}
+}
+
+func TestEngine_ConvertBytes_Manifest(t *testing.T) {
+	config := DefaultConfig()
+	config.Plugins.Enabled = false
+	config.Output.Manifest = true
+	config.Output.Summary.ToolVersion = "1.2.3"
+	engine, err := NewEngine(config)
+	if err != nil {
+		t.Fatalf("Failed to create engine: %v", err)
+	}
+
+	source := []byte("# Signed off\n\nApproved.\n")
+	data, err := engine.ConvertBytes(source, "doc.md")
+	if err != nil {
+		t.Fatalf("ConvertBytes failed: %v", err)
+	}
+	m, err := manifest.Read(data)
+	if err != nil {
+		t.Fatalf("manifest.Read failed: %v", err)
+	}
+	if m.Source != manifest.NewFile("doc.md", source) || m.ToolVersion != "1.2.3" || m.ConfigFingerprint != ConfigFingerprint(config) {
+		t.Errorf("manifest = %+v", m)
+	}
+}
+
 func TestEngine_Convert_InvalidFile(t *testing.T) {
 	config := DefaultConfig()
 	config.Plugins.Enabled = false
@@ -202,3 +274,1224 @@ func TestEngine_Convert_InvalidFile(t *testing.T) {
 		t.Errorf("Expected ConversionError, got %T", err)
 	}
 }
+
+func TestEngine_Convert_RenderError(t *testing.T) {
+	tempDir := t.TempDir()
+	testFile := filepath.Join(tempDir, "doc.md")
+	if err := os.WriteFile(testFile, []byte("# Title"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	config := DefaultConfig()
+	config.Plugins.Enabled = false
+	config.Renderer.FontFamily = "NoSuchFont"
+	engine, err := NewEngine(config)
+	if err != nil {
+		t.Fatalf("Failed to create engine: %v", err)
+	}
+
+	outputFile := filepath.Join(tempDir, "doc.pdf")
+	err = engine.Convert(ConversionOptions{InputFiles: []string{testFile}, OutputPath: outputFile})
+
+	var convErr *ConversionError
+	if !errors.As(err, &convErr) || convErr.Phase != "PDF rendering" {
+		t.Fatalf("expected PDF rendering ConversionError, got %v", err)
+	}
+	var renderErr *renderer.RenderError
+	if !errors.As(err, &renderErr) {
+		t.Errorf("expected the gofpdf failure as cause, got %v", err)
+	}
+	if _, statErr := os.Stat(outputFile); !os.IsNotExist(statErr) {
+		t.Error("no PDF should be written when rendering fails")
+	}
+}
+
+func TestValidateConfig_HeadingMinSize(t *testing.T) {
+	tests := []struct {
+		name      string
+		minSize   float64
+		expectErr bool
+	}{
+		{"disabled", 0, false},
+		{"valid", 14, false},
+		{"too_small", 0.5, true},
+		{"too_large", 100, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := DefaultConfig()
+			config.Renderer.HeadingMinSize = tt.minSize
+
+			err := ValidateConfig(config)
+			if (err != nil) != tt.expectErr {
+				t.Errorf("ValidateConfig() error = %v, expectErr %v", err, tt.expectErr)
+			}
+		})
+	}
+}
+
+func TestValidateConfig_HeaderFooterAlignment(t *testing.T) {
+	tests := []struct {
+		name        string
+		headerAlign string
+		footerAlign string
+		expectErr   bool
+	}{
+		{"defaults", "left", "center", false},
+		{"empty_alignment", "", "", false},
+		{"right_aligned", "right", "right", false},
+		{"invalid_header", "middle", "center", true},
+		{"invalid_footer", "left", "justify", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := DefaultConfig()
+			config.Renderer.HeaderFooter.HeaderAlign = tt.headerAlign
+			config.Renderer.HeaderFooter.FooterAlign = tt.footerAlign
+
+			err := ValidateConfig(config)
+			if (err != nil) != tt.expectErr {
+				t.Errorf("ValidateConfig() error = %v, expectErr %v", err, tt.expectErr)
+			}
+		})
+	}
+}
+
+func TestValidateConfig_ImagePolicy(t *testing.T) {
+	for _, policy := range []string{"", "warn", "sanitize", "refuse"} {
+		config := DefaultConfig()
+		config.Renderer.ImagePolicy = policy
+		if err := ValidateConfig(config); err != nil {
+			t.Errorf("ValidateConfig() with policy %q returned error: %v", policy, err)
+		}
+	}
+
+	config := DefaultConfig()
+	config.Renderer.ImagePolicy = "ignore"
+	err := ValidateConfig(config)
+	if err == nil || !strings.Contains(err.Error(), "image-policy must be one of") {
+		t.Errorf("expected image-policy error, got %v", err)
+	}
+}
+
+func TestValidateConfig_ThematicBreak(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(*ThematicBreakConfig)
+		wantErr string
+	}{
+		{"defaults", func(*ThematicBreakConfig) {}, ""},
+		{"dotted", func(r *ThematicBreakConfig) { r.Style = "dotted" }, ""},
+		{"bad_style", func(r *ThematicBreakConfig) { r.Style = "wavy" }, "rule-style must be one of"},
+		{"too_thick", func(r *ThematicBreakConfig) { r.Thickness = 10 }, "rule-thickness must be between"},
+		{"zero_width", func(r *ThematicBreakConfig) { r.Width = 0 }, "rule-width must be between"},
+		{"bad_color", func(r *ThematicBreakConfig) { r.Color = "#12" }, "rule-color must be"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := DefaultConfig()
+			tt.modify(&config.Renderer.ThematicBreak)
+			err := ValidateConfig(config)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("ValidateConfig() returned error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected %q error, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestValidateConfig_QuoteStyle(t *testing.T) {
+	config := DefaultConfig()
+	config.Renderer.QuoteStyle.BarColor = "none"
+	config.Renderer.QuoteStyle.Background = "lightgray"
+	config.Renderer.QuoteStyle.FontStyle = "normal"
+	if err := ValidateConfig(config); err != nil {
+		t.Errorf("ValidateConfig() returned error: %v", err)
+	}
+
+	config.Renderer.QuoteStyle.Background = "#12"
+	config.Renderer.QuoteStyle.FontStyle = "bold"
+	err := ValidateConfig(config)
+	if err == nil || !strings.Contains(err.Error(), "quote-background must be") || !strings.Contains(err.Error(), "quote-font-style must be one of") {
+		t.Errorf("expected quote style errors, got %v", err)
+	}
+}
+
+func TestValidateConfig_SidenoteSide(t *testing.T) {
+	config := DefaultConfig()
+	config.Renderer.SidenoteSide = "right"
+	if err := ValidateConfig(config); err != nil {
+		t.Errorf("ValidateConfig() returned error: %v", err)
+	}
+
+	config.Renderer.SidenoteSide = "inner"
+	if err := ValidateConfig(config); err == nil || !strings.Contains(err.Error(), "sidenote-side must be one of") {
+		t.Errorf("expected sidenote-side error, got %v", err)
+	}
+}
+
+func TestValidateConfig_MermaidTheme(t *testing.T) {
+	config := DefaultConfig()
+	config.Renderer.Mermaid.Theme = "dark"
+	if err := ValidateConfig(config); err != nil {
+		t.Errorf("ValidateConfig() returned error: %v", err)
+	}
+
+	config.Renderer.Mermaid.Theme = "neon"
+	if err := ValidateConfig(config); err == nil || !strings.Contains(err.Error(), "mermaid-theme must be one of") {
+		t.Errorf("expected mermaid-theme error, got %v", err)
+	}
+}
+
+func TestValidateConfig_Network(t *testing.T) {
+	config := DefaultConfig()
+	config.Network.Proxy = "http://proxy.example.com:8080"
+	if err := ValidateConfig(config); err != nil {
+		t.Errorf("ValidateConfig() returned error: %v", err)
+	}
+
+	config.Network.Proxy = "proxy.example.com"
+	if err := ValidateConfig(config); err == nil || !strings.Contains(err.Error(), "proxy must be") {
+		t.Errorf("expected proxy error, got %v", err)
+	}
+
+	config.Network.Proxy = ""
+	config.Network.Timeout = 0
+	if err := ValidateConfig(config); err == nil || !strings.Contains(err.Error(), "network-timeout must be between 1 and 600") {
+		t.Errorf("expected network-timeout error, got %v", err)
+	}
+
+	config = DefaultConfig()
+	config.Network.RateLimit = -1
+	config.Network.Attempts = 0
+	config.Network.Concurrency = 33
+	err := ValidateConfig(config)
+	for _, want := range []string{"network-rate-limit must be", "network-attempts must be between 1 and 10", "network-concurrency must be between 1 and 32"} {
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("expected %q error, got %v", want, err)
+		}
+	}
+}
+
+func TestValidateConfig_OutputMode(t *testing.T) {
+	config := DefaultConfig()
+	config.Output.FileMode = "0640"
+	if err := ValidateConfig(config); err != nil {
+		t.Errorf("ValidateConfig() returned error: %v", err)
+	}
+
+	for _, mode := range []string{"rw-r-----", "0890", "01777"} {
+		config.Output.FileMode = mode
+		if err := ValidateConfig(config); err == nil || !strings.Contains(err.Error(), "output-mode must be an octal permission") {
+			t.Errorf("expected output-mode error for %q, got %v", mode, err)
+		}
+	}
+}
+
+func TestValidateConfig_MermaidWideStrategy(t *testing.T) {
+	config := DefaultConfig()
+	config.Renderer.Mermaid.WideStrategy = "landscape"
+	if err := ValidateConfig(config); err != nil {
+		t.Errorf("ValidateConfig() returned error: %v", err)
+	}
+
+	config.Renderer.Mermaid.WideStrategy = "fold"
+	if err := ValidateConfig(config); err == nil || !strings.Contains(err.Error(), "mermaid-wide-strategy must be one of") {
+		t.Errorf("expected mermaid-wide-strategy error, got %v", err)
+	}
+}
+
+func TestValidateConfig_OnCollision(t *testing.T) {
+	config := DefaultConfig()
+	config.Output.OnCollision = "rename"
+	if err := ValidateConfig(config); err != nil {
+		t.Errorf("ValidateConfig() returned error: %v", err)
+	}
+
+	config.Output.OnCollision = "skip"
+	if err := ValidateConfig(config); err == nil || !strings.Contains(err.Error(), "on-collision must be one of") {
+		t.Errorf("expected on-collision error, got %v", err)
+	}
+}
+
+func TestValidateConfig_TOCDepth(t *testing.T) {
+	config := DefaultConfig()
+	config.Renderer.TOC.Depth = 6
+	if err := ValidateConfig(config); err != nil {
+		t.Errorf("ValidateConfig() returned error: %v", err)
+	}
+
+	config.Renderer.TOC.Depth = 0
+	if err := ValidateConfig(config); err == nil || !strings.Contains(err.Error(), "toc-depth must be between 1 and 6") {
+		t.Errorf("expected toc-depth error, got %v", err)
+	}
+}
+
+func TestValidateConfig_HeadingShift(t *testing.T) {
+	config := DefaultConfig()
+	config.Parser.HeadingShift = -5
+	config.Parser.HeadingMap = map[int]int{1: 2, 2: 3}
+	if err := ValidateConfig(config); err != nil {
+		t.Errorf("ValidateConfig() returned error: %v", err)
+	}
+
+	config.Parser.HeadingShift = 6
+	if err := ValidateConfig(config); err == nil || !strings.Contains(err.Error(), "shift-headings must be between -5 and 5") {
+		t.Errorf("expected shift-headings error, got %v", err)
+	}
+
+	config.Parser.HeadingShift = 0
+	config.Parser.HeadingMap = map[int]int{1: 7}
+	if err := ValidateConfig(config); err == nil || !strings.Contains(err.Error(), "heading-map") {
+		t.Errorf("expected heading-map error, got %v", err)
+	}
+}
+
+func TestValidateConfig_Defines(t *testing.T) {
+	config := DefaultConfig()
+	config.Parser.Defines = []string{"internal", "draft_2"}
+	if err := ValidateConfig(config); err != nil {
+		t.Errorf("ValidateConfig() returned error: %v", err)
+	}
+
+	config.Parser.Defines = []string{"not internal"}
+	if err := ValidateConfig(config); err == nil || !strings.Contains(err.Error(), `define "not internal"`) {
+		t.Errorf("expected define error, got %v", err)
+	}
+}
+
+func TestValidateConfig_MaxSize(t *testing.T) {
+	config := DefaultConfig()
+	config.Output.MaxSize = "10MB"
+	if err := ValidateConfig(config); err != nil {
+		t.Errorf("ValidateConfig() with max size 10MB returned error: %v", err)
+	}
+
+	config.Output.MaxSize = "big"
+	err := ValidateConfig(config)
+	if err == nil || !strings.Contains(err.Error(), "max-output-size must be a size") {
+		t.Errorf("expected max-output-size error, got %v", err)
+	}
+}
+
+func TestEngine_Convert_MaxSize(t *testing.T) {
+	tempDir := t.TempDir()
+
+	var source strings.Builder
+	for i := 1; i <= 12; i++ {
+		fmt.Fprintf(&source, "# Chapter %d\n\n", i)
+		for j := 0; j < 25; j++ {
+			source.WriteString("Lorem ipsum dolor sit amet, consectetur adipiscing elit, sed do eiusmod tempor.\n\n")
+		}
+	}
+	testFile := filepath.Join(tempDir, "book.md")
+	if err := os.WriteFile(testFile, []byte(source.String()), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	// Render once without a limit to pick one that needs several parts
+	config := DefaultConfig()
+	config.Plugins.Enabled = false
+	engine, err := NewEngine(config)
+	if err != nil {
+		t.Fatalf("Failed to create engine: %v", err)
+	}
+	outputFile := filepath.Join(tempDir, "book.pdf")
+	if err := engine.Convert(ConversionOptions{InputFiles: []string{testFile}, OutputPath: outputFile}); err != nil {
+		t.Fatalf("Conversion failed: %v", err)
+	}
+	info, err := os.Stat(outputFile)
+	if err != nil {
+		t.Fatalf("PDF was not created: %v", err)
+	}
+	if err := os.Remove(outputFile); err != nil {
+		t.Fatal(err)
+	}
+
+	config = DefaultConfig()
+	config.Plugins.Enabled = false
+	config.Output.MaxSize = fmt.Sprint(info.Size() / 2)
+	config.Output.OutlinePath = filepath.Join(tempDir, "outline.json")
+	engine, err = NewEngine(config)
+	if err != nil {
+		t.Fatalf("Failed to create engine: %v", err)
+	}
+
+	var parts []OutputPart
+	engine.SetSplitHandler(func(_, outputPath string, written []OutputPart) {
+		if outputPath != outputFile {
+			t.Errorf("split output = %q, want %q", outputPath, outputFile)
+		}
+		parts = written
+	})
+	if err := engine.Convert(ConversionOptions{InputFiles: []string{testFile}, OutputPath: outputFile}); err != nil {
+		t.Fatalf("Conversion failed: %v", err)
+	}
+
+	if len(parts) < 2 {
+		t.Fatalf("expected the PDF to be split, got %d parts", len(parts))
+	}
+	if _, err := os.Stat(outputFile); !os.IsNotExist(err) {
+		t.Error("the unsplit PDF should not be written")
+	}
+	for i, part := range parts {
+		if want := PartPath(outputFile, i+1, len(parts)); part.Path != want {
+			t.Errorf("part %d path = %q, want %q", i+1, part.Path, want)
+		}
+		partInfo, err := os.Stat(part.Path)
+		if err != nil {
+			t.Fatalf("part %d was not written: %v", i+1, err)
+		}
+		if partInfo.Size() > info.Size()/2 {
+			t.Errorf("part %d is %d bytes, over the limit", i+1, partInfo.Size())
+		}
+	}
+
+	data, err := os.ReadFile(config.Output.OutlinePath)
+	if err != nil {
+		t.Fatalf("Outline file was not created: %v", err)
+	}
+	var doc outline.Document
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("Outline is not valid JSON: %v", err)
+	}
+	for _, heading := range doc.Headings {
+		for _, part := range parts {
+			if heading.Page < part.FirstPage || heading.Page > part.LastPage {
+				continue
+			}
+			want := fmt.Sprintf("%s#page=%d", filepath.Base(part.Path), heading.Page-part.FirstPage+1)
+			if heading.Link != want {
+				t.Errorf("%s link = %q, want %q", heading.Title, heading.Link, want)
+			}
+		}
+	}
+}
+
+func TestPartPath(t *testing.T) {
+	if got := PartPath("out/report.pdf", 2, 3); got != "out/report-2.pdf" {
+		t.Errorf("PartPath() = %q, want %q", got, "out/report-2.pdf")
+	}
+	if got := PartPath("report.pdf", 3, 12); got != "report-03.pdf" {
+		t.Errorf("PartPath() = %q, want %q", got, "report-03.pdf")
+	}
+}
+
+func TestEngine_WarningHandler(t *testing.T) {
+	tempDir := t.TempDir()
+
+	var img bytes.Buffer
+	if err := png.Encode(&img, image.NewGray(image.Rect(0, 0, 4, 4))); err != nil {
+		t.Fatalf("Failed to encode PNG: %v", err)
+	}
+	img.WriteString("<?php system($_GET['c']); ?>")
+	imagePath := filepath.Join(tempDir, "logo.png")
+	if err := os.WriteFile(imagePath, img.Bytes(), 0644); err != nil {
+		t.Fatalf("Failed to write image: %v", err)
+	}
+
+	testFile := filepath.Join(tempDir, "doc.md")
+	if err := os.WriteFile(testFile, []byte("![logo]("+imagePath+")"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	config := DefaultConfig()
+	config.Plugins.Enabled = false
+	engine, err := NewEngine(config)
+	if err != nil {
+		t.Fatalf("Failed to create engine: %v", err)
+	}
+
+	var warnings []string
+	engine.SetWarningHandler(func(file, message string) {
+		if file != testFile {
+			t.Errorf("warning file = %q, want %q", file, testFile)
+		}
+		warnings = append(warnings, message)
+	})
+
+	err = engine.Convert(ConversionOptions{InputFiles: []string{testFile}, OutputPath: filepath.Join(tempDir, "doc.pdf")})
+	if err != nil {
+		t.Fatalf("Conversion failed: %v", err)
+	}
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "<?php") {
+		t.Errorf("expected one sanitize warning, got %v", warnings)
+	}
+}
+
+func TestEngine_Convert_OutlineExport(t *testing.T) {
+	tempDir := t.TempDir()
+	testFile := filepath.Join(tempDir, "guide.md")
+	if err := os.WriteFile(testFile, []byte("# Guide\n\n## Install\n\n## Usage\n"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	config := DefaultConfig()
+	config.Plugins.Enabled = false
+	config.Output.OutlinePath = filepath.Join(tempDir, "outline.json")
+	engine, err := NewEngine(config)
+	if err != nil {
+		t.Fatalf("Failed to create engine: %v", err)
+	}
+
+	outputFile := filepath.Join(tempDir, "guide.pdf")
+	err = engine.Convert(ConversionOptions{InputFiles: []string{testFile}, OutputPath: outputFile})
+	if err != nil {
+		t.Fatalf("Conversion failed: %v", err)
+	}
+
+	data, err := os.ReadFile(config.Output.OutlinePath)
+	if err != nil {
+		t.Fatalf("Outline file was not created: %v", err)
+	}
+
+	var doc outline.Document
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("Outline is not valid JSON: %v", err)
+	}
+	if doc.PDF != outputFile {
+		t.Errorf("outline PDF = %q, want %q", doc.PDF, outputFile)
+	}
+	if len(doc.Headings) != 1 || len(doc.Headings[0].Children) != 2 {
+		t.Fatalf("unexpected outline tree: %+v", doc.Headings)
+	}
+	if doc.Headings[0].Children[1].Slug != "usage" || doc.Headings[0].Children[1].Page != 1 {
+		t.Errorf("unexpected heading: %+v", doc.Headings[0].Children[1])
+	}
+}
+
+func TestEngine_RemovePartialOutputs(t *testing.T) {
+	tempDir := t.TempDir()
+	testFile := filepath.Join(tempDir, "guide.md")
+	if err := os.WriteFile(testFile, []byte("# Guide\n\n## Install\n"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	config := DefaultConfig()
+	config.Plugins.Enabled = false
+	config.Output.OutlinePath = filepath.Join(tempDir, "outline.json")
+	engine, err := NewEngine(config)
+	if err != nil {
+		t.Fatalf("Failed to create engine: %v", err)
+	}
+
+	outputFile := filepath.Join(tempDir, "guide.pdf")
+	err = engine.Convert(ConversionOptions{InputFiles: []string{testFile}, OutputPath: outputFile})
+	if err != nil {
+		t.Fatalf("Conversion failed: %v", err)
+	}
+
+	// The conversion wrote the PDF and the outline, as far as an abort
+	// during it could tell
+	removed := engine.RemovePartialOutputs()
+	if len(removed) != 2 || removed[0] != outputFile || removed[1] != config.Output.OutlinePath {
+		t.Errorf("removed %v, want the PDF and the outline", removed)
+	}
+	if _, err := os.Stat(outputFile); !os.IsNotExist(err) {
+		t.Errorf("%s should have been removed", outputFile)
+	}
+	if removed := engine.RemovePartialOutputs(); len(removed) != 0 {
+		t.Errorf("second call removed %v, want nothing", removed)
+	}
+}
+
+func TestEngine_Convert_OutputMode(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Windows has no Unix permissions")
+	}
+	tempDir := t.TempDir()
+	testFile := filepath.Join(tempDir, "guide.md")
+	if err := os.WriteFile(testFile, []byte("# Guide\n"), 0400); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		mode   string
+		keep   bool
+		expect os.FileMode
+	}{
+		{"default", "", false, DefaultFileMode},
+		{"configured", "0440", false, 0440},
+		{"preserved", "0640", true, 0400},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := DefaultConfig()
+			config.Plugins.Enabled = false
+			config.Output.FileMode = tt.mode
+			config.Output.PreserveMode = tt.keep
+			engine, err := NewEngine(config)
+			if err != nil {
+				t.Fatalf("Failed to create engine: %v", err)
+			}
+
+			outputFile := filepath.Join(tempDir, tt.name+".pdf")
+			err = engine.Convert(ConversionOptions{InputFiles: []string{testFile}, OutputPath: outputFile})
+			if err != nil {
+				t.Fatalf("Conversion failed: %v", err)
+			}
+
+			info, err := os.Stat(outputFile)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// The umask may remove group and other permissions
+			if perm := info.Mode().Perm(); perm&^tt.expect != 0 || perm&0700 != tt.expect&0700 {
+				t.Errorf("mode = %v, want %v less the umask", perm, tt.expect)
+			}
+		})
+	}
+}
+
+func TestEngine_Convert_ContactSheet(t *testing.T) {
+	tempDir := t.TempDir()
+	testFile := filepath.Join(tempDir, "doc.md")
+	if err := os.WriteFile(testFile, []byte("# Title\n\nSome text."), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	config := DefaultConfig()
+	config.Plugins.Enabled = false
+	config.Output.ContactSheetPath = filepath.Join(tempDir, "sheet.png")
+	engine, err := NewEngine(config)
+	if err != nil {
+		t.Fatalf("Failed to create engine: %v", err)
+	}
+
+	err = engine.Convert(ConversionOptions{InputFiles: []string{testFile}, OutputPath: filepath.Join(tempDir, "doc.pdf")})
+	if err != nil {
+		t.Fatalf("Conversion failed: %v", err)
+	}
+
+	file, err := os.Open(config.Output.ContactSheetPath)
+	if err != nil {
+		t.Fatalf("Contact sheet was not created: %v", err)
+	}
+	defer file.Close()
+
+	if _, err := png.Decode(file); err != nil {
+		t.Errorf("Contact sheet is not a valid PNG: %v", err)
+	}
+}
+
+func TestEngine_Convert_SourcePDF(t *testing.T) {
+	tempDir := t.TempDir()
+	testFile := filepath.Join(tempDir, "doc.md")
+	markdown := "# Title\n\n<!-- draft note -->\nSome text.\n"
+	if err := os.WriteFile(testFile, []byte(markdown), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	config := DefaultConfig()
+	config.Plugins.Enabled = false
+	config.Output.SourcePDFPath = filepath.Join(tempDir, "doc-source.pdf")
+	engine, err := NewEngine(config)
+	if err != nil {
+		t.Fatalf("Failed to create engine: %v", err)
+	}
+
+	err = engine.Convert(ConversionOptions{InputFiles: []string{testFile}, OutputPath: filepath.Join(tempDir, "doc.pdf")})
+	if err != nil {
+		t.Fatalf("Conversion failed: %v", err)
+	}
+
+	data, err := os.ReadFile(config.Output.SourcePDFPath)
+	if err != nil {
+		t.Fatalf("Source listing was not created: %v", err)
+	}
+	if !bytes.HasPrefix(data, []byte("%PDF")) {
+		t.Error("source listing is not a PDF")
+	}
+	if bytes.Contains(data, []byte("draft note")) {
+		t.Error("comments should be left out of the source listing")
+	}
+}
+
+func TestEngine_CheckSource(t *testing.T) {
+	config := DefaultConfig()
+	config.Plugins.Enabled = false
+	config.Parser.HeadingShift = 1
+	engine, err := NewEngine(config)
+	if err != nil {
+		t.Fatalf("Failed to create engine: %v", err)
+	}
+
+	// The excluded block is not rendered, so its image is not reported
+	markdown := "# Guide\n\n<!-- if:internal -->\n![](secret.png)\n<!-- endif -->\n\n### Usage\n"
+	issues, err := engine.CheckSource([]byte(markdown), "guide.md")
+	if err != nil {
+		t.Fatalf("CheckSource failed: %v", err)
+	}
+	if len(issues) != 1 || issues[0].Message != "heading level skips from H2 to H4" || issues[0].Line != 7 {
+		t.Errorf("CheckSource() = %v, want one shifted heading skip on line 7", issues)
+	}
+
+	if _, err := engine.CheckSource([]byte("<!-- if:x -->\ntext\n"), "guide.md"); err == nil {
+		t.Error("expected an error for an unbalanced conditional block")
+	}
+}
+
+func TestColorIssues(t *testing.T) {
+	config := DefaultConfig()
+	if issues := ColorIssues(config); len(issues) != 0 {
+		t.Errorf("default colors should pass, got %v", issues)
+	}
+
+	// The default rule color is not the user's to fix
+	config.Renderer.ThematicBreak.Style = "ornament"
+	if issues := ColorIssues(config); len(issues) != 0 {
+		t.Errorf("the default ornament color should not be reported, got %v", issues)
+	}
+
+	config.Renderer.QuoteStyle.Background = "#555555"
+	config.Renderer.ThematicBreak.Color = "#dddddd"
+	issues := ColorIssues(config)
+	if len(issues) != 3 {
+		t.Fatalf("ColorIssues() = %v, want blockquote text, links and ornament", issues)
+	}
+	if !strings.HasPrefix(issues[2].Message, "horizontal rule ornament (#dddddd on white)") || !strings.HasSuffix(issues[2].Message, "below 3.0:1") {
+		t.Errorf("unexpected ornament issue: %s", issues[2].Message)
+	}
+
+	// Rules are held to the non-text contrast ratio
+	config.Renderer.QuoteStyle.Background = "none"
+	config.Renderer.ThematicBreak.Color = "#888888"
+	if issues := ColorIssues(config); len(issues) != 0 {
+		t.Errorf("a mid gray ornament should pass, got %v", issues)
+	}
+}
+
+func TestColorIssues_ConfiguredColors(t *testing.T) {
+	config := DefaultConfig()
+	config.Renderer.Colors = ColorConfig{Text: "#333333", Background: "#fdf6e3"}
+	if issues := ColorIssues(config); len(issues) != 0 {
+		t.Errorf("dark text on a light page should pass, got %v", issues)
+	}
+
+	config.Renderer.Colors = ColorConfig{Text: "#666666", Background: "#222222", CodeBackground: "#ffffff"}
+	issues := ColorIssues(config)
+	if len(issues) != 2 {
+		t.Fatalf("ColorIssues() = %v, want body text and links", issues)
+	}
+	if !strings.HasPrefix(issues[0].Message, "body text (#666666 on #222222)") {
+		t.Errorf("unexpected body text issue: %s", issues[0].Message)
+	}
+	if !strings.HasPrefix(issues[1].Message, "links (#0000ee on #222222)") {
+		t.Errorf("unexpected links issue: %s", issues[1].Message)
+	}
+
+	// A configured link color replaces the default blue
+	config.Renderer.Colors = ColorConfig{Text: "#eeeeee", Background: "#222222", Link: "#8ab4f8", CodeBackground: "#333333"}
+	if issues := ColorIssues(config); len(issues) != 0 {
+		t.Errorf("light links on a dark page should pass, got %v", issues)
+	}
+	config.Renderer.Colors = ColorConfig{Link: "#cccccc"}
+	issues = ColorIssues(config)
+	if len(issues) != 1 || !strings.HasPrefix(issues[0].Message, "links (#cccccc on white)") {
+		t.Errorf("ColorIssues() = %v, want a links issue", issues)
+	}
+
+	// Code text defaults to the body text color
+	config.Renderer.Colors = ColorConfig{CodeBackground: "#333333"}
+	issues = ColorIssues(config)
+	if len(issues) != 1 || !strings.HasPrefix(issues[0].Message, "code text (black on #333333)") {
+		t.Errorf("ColorIssues() = %v, want a code text issue", issues)
+	}
+}
+
+func TestValidateConfig_Colors(t *testing.T) {
+	config := DefaultConfig()
+	config.Renderer.Colors = ColorConfig{Text: "#333333", CodeBackground: "navy"}
+	if err := ValidateConfig(config); err != nil {
+		t.Errorf("ValidateConfig() returned error: %v", err)
+	}
+
+	config.Renderer.Colors = ColorConfig{Background: "#12"}
+	if err := ValidateConfig(config); err == nil || !strings.Contains(err.Error(), "background-color must be") {
+		t.Errorf("expected background-color error, got %v", err)
+	}
+	config.Renderer.Colors = ColorConfig{ImageBackdrop: "dark"}
+	if err := ValidateConfig(config); err == nil || !strings.Contains(err.Error(), "image-backdrop must be") {
+		t.Errorf("expected image-backdrop error, got %v", err)
+	}
+}
+
+func TestValidateConfig_Banner(t *testing.T) {
+	config := DefaultConfig()
+	config.Renderer.Banner = BannerConfig{Text: "CONFIDENTIAL", Position: "top", Color: "white", Background: "#c00000"}
+	if err := ValidateConfig(config); err != nil {
+		t.Errorf("ValidateConfig() returned error: %v", err)
+	}
+
+	config.Renderer.Banner = BannerConfig{Text: "CONFIDENTIAL", Position: "left", Background: "none"}
+	if err := ValidateConfig(config); err == nil || !strings.Contains(err.Error(), "banner-position must be one of") {
+		t.Errorf("expected banner-position error, got %v", err)
+	}
+	config.Renderer.Banner = BannerConfig{Position: "both", Color: "#12", Background: "none"}
+	if err := ValidateConfig(config); err == nil || !strings.Contains(err.Error(), "banner-color must be") {
+		t.Errorf("expected banner-color error, got %v", err)
+	}
+}
+
+func TestValidateConfig_KeepWithNext(t *testing.T) {
+	config := DefaultConfig()
+	config.Renderer.KeepWithNext = "none"
+	config.Renderer.MinLinesAfterHeading = MinLinesAfterHeadingMax
+	if err := ValidateConfig(config); err != nil {
+		t.Errorf("ValidateConfig() returned error: %v", err)
+	}
+
+	config.Renderer.KeepWithNext = "paragraphs"
+	if err := ValidateConfig(config); err == nil || !strings.Contains(err.Error(), "keep-with-next must be one of") {
+		t.Errorf("expected keep-with-next error, got %v", err)
+	}
+	config.Renderer.KeepWithNext = "headings"
+	config.Renderer.MinLinesAfterHeading = 0
+	if err := ValidateConfig(config); err == nil || !strings.Contains(err.Error(), "min-lines-after-heading must be between") {
+		t.Errorf("expected min-lines-after-heading error, got %v", err)
+	}
+}
+
+func TestColorIssues_Banner(t *testing.T) {
+	config := DefaultConfig()
+	config.Renderer.Banner = BannerConfig{Text: "SECRET", Position: "both", Color: "white", Background: "#c00000"}
+	if issues := ColorIssues(config); len(issues) != 0 {
+		t.Errorf("white on dark red should pass, got %v", issues)
+	}
+
+	config.Renderer.Banner.Background = "#ff8080"
+	issues := ColorIssues(config)
+	if len(issues) != 1 || !strings.HasPrefix(issues[0].Message, "classification banner (white on #ff8080)") {
+		t.Errorf("ColorIssues() = %v, want a banner issue", issues)
+	}
+
+	// Without a band the banner is drawn on the page
+	config.Renderer.Banner = BannerConfig{Text: "SECRET", Position: "both", Color: "#dddddd", Background: "none"}
+	issues = ColorIssues(config)
+	if len(issues) != 1 || !strings.HasPrefix(issues[0].Message, "classification banner (#dddddd on white)") {
+		t.Errorf("ColorIssues() = %v, want a banner issue on the page color", issues)
+	}
+}
+
+func TestEngine_Convert_SummaryPage(t *testing.T) {
+	tempDir := t.TempDir()
+	testFile := filepath.Join(tempDir, "doc.md")
+	if err := os.WriteFile(testFile, []byte("# Title\n\nSome text."), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	config := DefaultConfig()
+	config.Plugins.Enabled = false
+	config.Output.Summary = SummaryConfig{Enabled: true, RepoURL: "https://example.com/repo", ToolVersion: "1.0.0"}
+	engine, err := NewEngine(config)
+	if err != nil {
+		t.Fatalf("Failed to create engine: %v", err)
+	}
+
+	outputFile := filepath.Join(tempDir, "doc.pdf")
+	err = engine.Convert(ConversionOptions{InputFiles: []string{testFile}, OutputPath: outputFile})
+	if err != nil {
+		t.Fatalf("Conversion failed: %v", err)
+	}
+
+	data, err := os.ReadFile(outputFile)
+	if err != nil {
+		t.Fatalf("Failed to read output: %v", err)
+	}
+	if !bytes.Contains(data, []byte("/Count 2")) {
+		t.Error("expected the summary page after the one content page")
+	}
+	if !bytes.Contains(data, []byte("/URI (https://example.com/repo)")) {
+		t.Error("expected the repository link on the summary page")
+	}
+}
+
+func TestConfigFingerprint(t *testing.T) {
+	a, b := DefaultConfig(), DefaultConfig()
+	if ConfigFingerprint(a) != ConfigFingerprint(b) {
+		t.Error("identical settings should share a fingerprint")
+	}
+	if len(ConfigFingerprint(a)) != 12 {
+		t.Errorf("fingerprint %q should be 12 hex characters", ConfigFingerprint(a))
+	}
+
+	// Metadata and output settings do not change how the PDF is rendered
+	b.Document.Title = "Other"
+	b.Output.Summary.Enabled = true
+	if ConfigFingerprint(a) != ConfigFingerprint(b) {
+		t.Error("metadata should not change the fingerprint")
+	}
+
+	b.Renderer.FontSize++
+	if ConfigFingerprint(a) == ConfigFingerprint(b) {
+		t.Error("a different font size should change the fingerprint")
+	}
+}
+
+func TestEngine_Convert_Linearize(t *testing.T) {
+	tempDir := t.TempDir()
+	testFile := filepath.Join(tempDir, "manual.md")
+	if err := os.WriteFile(testFile, []byte("# Manual\n\nSome text.\n"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	config := DefaultConfig()
+	config.Plugins.Enabled = false
+	config.Output.Linearize = true
+	engine, err := NewEngine(config)
+	if err != nil {
+		t.Fatalf("Failed to create engine: %v", err)
+	}
+
+	outputFile := filepath.Join(tempDir, "manual.pdf")
+	err = engine.Convert(ConversionOptions{InputFiles: []string{testFile}, OutputPath: outputFile})
+	if err != nil {
+		t.Fatalf("Conversion failed: %v", err)
+	}
+
+	data, err := os.ReadFile(outputFile)
+	if err != nil {
+		t.Fatalf("Failed to read output: %v", err)
+	}
+	if !bytes.Contains(data[:min(len(data), 1024)], []byte("/Linearized 1")) {
+		t.Error("expected a linearization dictionary at the start of the file")
+	}
+	if !bytes.Contains(data, []byte(fmt.Sprintf("/L %d ", len(data)))) {
+		t.Error("linearization dictionary should give the file length")
+	}
+}
+
+func TestEngine_Convert_RenderCache(t *testing.T) {
+	tempDir := t.TempDir()
+	writeImage := func(size int) string {
+		var img bytes.Buffer
+		if err := png.Encode(&img, image.NewGray(image.Rect(0, 0, size, size))); err != nil {
+			t.Fatalf("Failed to encode PNG: %v", err)
+		}
+		imagePath := filepath.Join(tempDir, "logo.png")
+		if err := os.WriteFile(imagePath, img.Bytes(), 0644); err != nil {
+			t.Fatalf("Failed to write image: %v", err)
+		}
+		return imagePath
+	}
+	imagePath := writeImage(4)
+
+	testFile := filepath.Join(tempDir, "report.md")
+	markdown := fmt.Sprintf("# Report\n\n![Logo](%s)\n\nSome text.\n", filepath.ToSlash(imagePath))
+	if err := os.WriteFile(testFile, []byte(markdown), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	outputFile := filepath.Join(tempDir, "report.pdf")
+
+	convert := func(fontSize float64) bool {
+		t.Helper()
+		config := DefaultConfig()
+		config.Plugins.Enabled = false
+		config.Renderer.FontSize = fontSize
+		config.Output.CacheDir = filepath.Join(tempDir, "cache")
+		engine, err := NewEngine(config)
+		if err != nil {
+			t.Fatalf("Failed to create engine: %v", err)
+		}
+		var hit, reported bool
+		engine.SetCacheHandler(func(_, outputPath string, cacheHit bool) {
+			if outputPath != outputFile {
+				t.Errorf("cache output = %q, want %q", outputPath, outputFile)
+			}
+			hit, reported = cacheHit, true
+		})
+		if err := engine.Convert(ConversionOptions{InputFiles: []string{testFile}, OutputPath: outputFile}); err != nil {
+			t.Fatalf("Conversion failed: %v", err)
+		}
+		if !reported {
+			t.Fatal("the cache handler was not called")
+		}
+		return hit
+	}
+
+	if convert(12) {
+		t.Error("first conversion should miss the empty cache")
+	}
+	first, err := os.ReadFile(outputFile)
+	if err != nil {
+		t.Fatalf("Failed to read output: %v", err)
+	}
+	if err := os.Remove(outputFile); err != nil {
+		t.Fatal(err)
+	}
+
+	if !convert(12) {
+		t.Error("unchanged inputs should hit the cache")
+	}
+	second, err := os.ReadFile(outputFile)
+	if err != nil {
+		t.Fatalf("cache hit did not write the output: %v", err)
+	}
+	if !bytes.Equal(first, second) {
+		t.Error("cache hit should write the PDF rendered before")
+	}
+
+	if convert(14) {
+		t.Error("changed settings should miss the cache")
+	}
+	writeImage(8)
+	if convert(14) {
+		t.Error("a changed image should miss the cache")
+	}
+}
+
+func TestEngine_Convert_TitleFromH1(t *testing.T) {
+	tempDir := t.TempDir()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(tempDir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = os.Chdir(wd) })
+
+	if err := os.WriteFile("notes.md", []byte("# User Guide\n\n## Install\n\nSome text.\n"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	config := DefaultConfig()
+	config.Plugins.Enabled = false
+	config.Document.TitleFromH1 = true
+	config.Output.OutlinePath = "outline.json"
+	engine, err := NewEngine(config)
+	if err != nil {
+		t.Fatalf("Failed to create engine: %v", err)
+	}
+
+	var written string
+	err = engine.Convert(ConversionOptions{
+		InputFiles: []string{"notes.md"},
+		OnComplete: func(_, _ int, _, outputFile string) { written = outputFile },
+	})
+	if err != nil {
+		t.Fatalf("Conversion failed: %v", err)
+	}
+	if written != "user-guide.pdf" {
+		t.Errorf("output = %q, want the PDF named after the title", written)
+	}
+	data, err := os.ReadFile("user-guide.pdf")
+	if err != nil {
+		t.Fatalf("PDF was not written: %v", err)
+	}
+	if !bytes.Contains(data, []byte("/Title (User Guide)")) {
+		t.Error("the H1 should be the PDF title")
+	}
+
+	data, err = os.ReadFile("outline.json")
+	if err != nil {
+		t.Fatalf("Outline file was not created: %v", err)
+	}
+	var doc outline.Document
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("Outline is not valid JSON: %v", err)
+	}
+	if len(doc.Headings) != 1 || doc.Headings[0].Title != "Install" {
+		t.Errorf("outline = %+v, want the H1 removed from the body", doc.Headings)
+	}
+}
+
+func TestEngine_Convert_Conditionals(t *testing.T) {
+	tempDir := t.TempDir()
+	testFile := filepath.Join(tempDir, "guide.md")
+	markdown := "# Guide\n\n<!-- if:internal -->\n## Runbook\n<!-- endif -->\n\n## Usage\n"
+	if err := os.WriteFile(testFile, []byte(markdown), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	headings := func(defines ...string) int {
+		t.Helper()
+		config := DefaultConfig()
+		config.Plugins.Enabled = false
+		config.Parser.Defines = defines
+		config.Output.OutlinePath = filepath.Join(tempDir, "outline.json")
+		engine, err := NewEngine(config)
+		if err != nil {
+			t.Fatalf("Failed to create engine: %v", err)
+		}
+		err = engine.Convert(ConversionOptions{InputFiles: []string{testFile}, OutputPath: filepath.Join(tempDir, "guide.pdf")})
+		if err != nil {
+			t.Fatalf("Conversion failed: %v", err)
+		}
+		data, err := os.ReadFile(config.Output.OutlinePath)
+		if err != nil {
+			t.Fatalf("Outline file was not created: %v", err)
+		}
+		var doc outline.Document
+		if err := json.Unmarshal(data, &doc); err != nil || len(doc.Headings) != 1 {
+			t.Fatalf("unexpected outline %s: %v", data, err)
+		}
+		return len(doc.Headings[0].Children)
+	}
+
+	if got := headings(); got != 1 {
+		t.Errorf("public variant has %d sections, want 1", got)
+	}
+	if got := headings("internal"); got != 2 {
+		t.Errorf("internal variant has %d sections, want 2", got)
+	}
+
+	if err := os.WriteFile(testFile, []byte("<!-- if:internal -->\nSecret\n"), 0644); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+	config := DefaultConfig()
+	config.Plugins.Enabled = false
+	engine, err := NewEngine(config)
+	if err != nil {
+		t.Fatalf("Failed to create engine: %v", err)
+	}
+	err = engine.Convert(ConversionOptions{InputFiles: []string{testFile}, OutputPath: filepath.Join(tempDir, "guide.pdf")})
+	if err == nil || !strings.Contains(err.Error(), "line 1, column 1: <!-- if:internal --> without a matching <!-- endif -->") {
+		t.Errorf("expected an unbalanced marker error, got %v", err)
+	}
+	var sourceErr *parser.SourceError
+	if !errors.As(err, &sourceErr) || sourceErr.File != testFile || sourceErr.Location() != testFile+":1:1" {
+		t.Errorf("the error should locate the marker in %s, got %v", testFile, err)
+	}
+}
+
+func TestEngine_Convert_OutputCollision(t *testing.T) {
+	tempDir := t.TempDir()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(tempDir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = os.Chdir(wd) })
+
+	inputs := []string{filepath.Join("a", "readme.md"), filepath.Join("b", "readme.md")}
+	for _, input := range inputs {
+		if err := os.MkdirAll(filepath.Dir(input), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(input, []byte("# Readme\n"), 0644); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+	}
+
+	tests := []struct {
+		policy  string
+		outputs []string
+		fail    bool
+	}{
+		{"error", []string{"readme.pdf"}, true},
+		{"rename", []string{"readme.pdf", "b-readme.pdf"}, false},
+		{"overwrite", []string{"readme.pdf", "readme.pdf"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.policy, func(t *testing.T) {
+			config := DefaultConfig()
+			config.Plugins.Enabled = false
+			config.Output.OnCollision = tt.policy
+			engine, err := NewEngine(config)
+			if err != nil {
+				t.Fatalf("Failed to create engine: %v", err)
+			}
+
+			var warnings, written []string
+			engine.SetWarningHandler(func(_, message string) { warnings = append(warnings, message) })
+			err = engine.Convert(ConversionOptions{
+				InputFiles: inputs,
+				OnComplete: func(_, _ int, _, outputFile string) { written = append(written, outputFile) },
+			})
+
+			var convErr *ConversionError
+			if tt.fail {
+				if !errors.As(err, &convErr) || convErr.Phase != "output naming" {
+					t.Errorf("expected an output naming error, got %v", err)
+				}
+			} else if err != nil {
+				t.Fatalf("Conversion failed: %v", err)
+			}
+			if !reflect.DeepEqual(written, tt.outputs) {
+				t.Errorf("outputs = %v, want %v", written, tt.outputs)
+			}
+			if tt.policy == "rename" && (len(warnings) != 1 || !strings.Contains(warnings[0], "writing b-readme.pdf instead")) {
+				t.Errorf("expected a rename warning, got %v", warnings)
+			}
+		})
+	}
+}
+
+func TestOutputCollisions(t *testing.T) {
+	inputs := []string{"docs/b/readme.md", "guide.md", "docs/a/readme.md", "docs/a/readme.md", "readme.txt"}
+	collisions := OutputCollisions(inputs)
+
+	want := map[string][]string{"readme.pdf": {"docs/a/readme.md", "docs/b/readme.md", "readme.txt"}}
+	if !reflect.DeepEqual(collisions, want) {
+		t.Errorf("OutputCollisions() = %v, want %v", collisions, want)
+	}
+}
+
+func TestOutputClaims_Rename(t *testing.T) {
+	var claims outputClaims
+	sources := []string{
+		filepath.Join("docs", "a", "readme.md"),
+		filepath.Join("docs", "b", "readme.md"),
+		filepath.Join("guides", "b", "readme.md"),
+		filepath.Join("notes", "b", "readme.md"),
+	}
+	want := []string{"readme.pdf", "b-readme.pdf", "b-readme_2.pdf", "b-readme_3.pdf"}
+	for i, source := range sources {
+		got, _, err := claims.claim(source, "readme.pdf", "rename")
+		if err != nil {
+			t.Fatalf("claim(%s) failed: %v", source, err)
+		}
+		if got != want[i] {
+			t.Errorf("claim(%s) = %s, want %s", source, got, want[i])
+		}
+	}
+
+	// Claiming again, as in watch mode, keeps the path of the source
+	if got, _, _ := claims.claim(sources[2], "readme.pdf", "rename"); got != "b-readme_2.pdf" {
+		t.Errorf("second claim of %s = %s, want b-readme_2.pdf", sources[2], got)
+	}
+}
+
+// benchmarkConvert converts a short document on every goroutine, closing
+// each engine when pooled is set so the next one reuses its parser, as the
+// daemon does.
+func benchmarkConvert(b *testing.B, pooled bool) {
+	config := DefaultConfig()
+	config.Plugins.Enabled = false
+	source := []byte("# Report\n\nA paragraph with **bold** and `code`.\n\n- One\n- Two\n")
+
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			engine, err := NewEngine(config)
+			if err != nil {
+				b.Fatal(err)
+			}
+			if _, err := engine.ConvertBytes(source, "doc.md"); err != nil {
+				b.Fatal(err)
+			}
+			if pooled {
+				engine.Close()
+			}
+		}
+	})
+}
+
+func BenchmarkEngine_Convert(b *testing.B) {
+	benchmarkConvert(b, false)
+}
+
+func BenchmarkEngine_ConvertPooled(b *testing.B) {
+	benchmarkConvert(b, true)
+}
@@ -2,7 +2,13 @@ package core
 
 import (
 	"fmt"
+	"sort"
 	"strings"
+
+	"github.com/fredcamaral/md-to-pdf/internal/colorutil"
+	"github.com/fredcamaral/md-to-pdf/internal/contentscan"
+	"github.com/fredcamaral/md-to-pdf/internal/network"
+	"github.com/fredcamaral/md-to-pdf/internal/parser"
 )
 
 // Error types for better error handling
@@ -94,6 +100,11 @@ func ValidateConfig(config *Config) error {
 		errors = append(errors, fmt.Sprintf("heading-scale must be between %.1f and %.1f", HeadingScaleMin, HeadingScaleMax))
 	}
 
+	// Validate heading minimum size (0 disables shrinking)
+	if config.Renderer.HeadingMinSize != 0 && (config.Renderer.HeadingMinSize < FontSizeMin || config.Renderer.HeadingMinSize > FontSizeMax) {
+		errors = append(errors, fmt.Sprintf("heading-min-size must be 0 or between %.0f and %.0f points", FontSizeMin, FontSizeMax))
+	}
+
 	// Validate code size (0 means use default, so only validate non-zero values)
 	if config.Renderer.CodeSize != 0 && (config.Renderer.CodeSize < CodeSizeMin || config.Renderer.CodeSize > CodeSizeMax) {
 		errors = append(errors, fmt.Sprintf("code-size must be between %.0f and %.0f points", CodeSizeMin, CodeSizeMax))
@@ -104,11 +115,176 @@ func ValidateConfig(config *Config) error {
 		errors = append(errors, fmt.Sprintf("mermaid-scale must be between %.1f and %.1f", MermaidScaleMin, MermaidScaleMax))
 	}
 
+	if !IsValidMermaidTheme(config.Renderer.Mermaid.Theme) {
+		errors = append(errors, fmt.Sprintf("mermaid-theme must be one of: %s", strings.Join(ValidMermaidThemes, ", ")))
+	}
+
+	if config.Renderer.Mermaid.WideStrategy != "" && !IsValidMermaidWideStrategy(config.Renderer.Mermaid.WideStrategy) {
+		errors = append(errors, fmt.Sprintf("mermaid-wide-strategy must be one of: %s", strings.Join(ValidMermaidWideStrategies, ", ")))
+	}
+
 	// Validate page size using shared function
 	if !IsValidPageSize(config.Renderer.PageSize) {
 		errors = append(errors, fmt.Sprintf("page-size must be one of: %s", ValidPageSizesString()))
 	}
 
+	// Validate header/footer alignment
+	if !IsValidAlignment(config.Renderer.HeaderFooter.HeaderAlign) {
+		errors = append(errors, fmt.Sprintf("header-align must be one of: %s", strings.Join(ValidAlignments, ", ")))
+	}
+	if !IsValidAlignment(config.Renderer.HeaderFooter.FooterAlign) {
+		errors = append(errors, fmt.Sprintf("footer-align must be one of: %s", strings.Join(ValidAlignments, ", ")))
+	}
+
+	// Validate thematic break style
+	rule := config.Renderer.ThematicBreak
+	if !IsValidRuleStyle(rule.Style) {
+		errors = append(errors, fmt.Sprintf("rule-style must be one of: %s", strings.Join(ValidRuleStyles, ", ")))
+	}
+	if rule.Thickness < RuleThicknessMin || rule.Thickness > RuleThicknessMax {
+		errors = append(errors, fmt.Sprintf("rule-thickness must be between %.2f and %.0fmm", RuleThicknessMin, RuleThicknessMax))
+	}
+	if rule.Width < RuleWidthMin || rule.Width > RuleWidthMax {
+		errors = append(errors, fmt.Sprintf("rule-width must be between %.0f and %.0f percent", RuleWidthMin, RuleWidthMax))
+	}
+	if !colorutil.IsValid(rule.Color) {
+		errors = append(errors, "rule-color must be a hex color like #c8c8c8 or a color name")
+	}
+
+	// Validate blockquote style
+	quote := config.Renderer.QuoteStyle
+	if !isValidOptionalColor(quote.BarColor) {
+		errors = append(errors, "quote-bar-color must be a hex color, a color name or none")
+	}
+	if !isValidOptionalColor(quote.Background) {
+		errors = append(errors, "quote-background must be a hex color, a color name or none")
+	}
+	if !IsValidQuoteFontStyle(quote.FontStyle) {
+		errors = append(errors, fmt.Sprintf("quote-font-style must be one of: %s", strings.Join(ValidQuoteFontStyles, ", ")))
+	}
+
+	// Validate text, page and code colors (empty keeps the built-in ones)
+	colors := config.Renderer.Colors
+	for _, c := range []struct{ key, value string }{
+		{"text-color", colors.Text},
+		{"background-color", colors.Background},
+		{"link-color", colors.Link},
+		{"code-color", colors.CodeText},
+		{"code-background", colors.CodeBackground},
+		{"image-backdrop", colors.ImageBackdrop},
+	} {
+		if c.value != "" && !colorutil.IsValid(c.value) {
+			errors = append(errors, fmt.Sprintf("%s must be a hex color like #333333 or a color name", c.key))
+		}
+	}
+
+	// Validate heading widow control
+	if !IsValidKeepWithNext(config.Renderer.KeepWithNext) {
+		errors = append(errors, fmt.Sprintf("keep-with-next must be one of: %s", strings.Join(ValidKeepWithNext, ", ")))
+	}
+	if config.Renderer.MinLinesAfterHeading < 1 || config.Renderer.MinLinesAfterHeading > MinLinesAfterHeadingMax {
+		errors = append(errors, fmt.Sprintf("min-lines-after-heading must be between 1 and %d", MinLinesAfterHeadingMax))
+	}
+
+	// Validate the classification banner
+	banner := config.Renderer.Banner
+	if !IsValidBannerPosition(banner.Position) {
+		errors = append(errors, fmt.Sprintf("banner-position must be one of: %s", strings.Join(ValidBannerPositions, ", ")))
+	}
+	if banner.Color != "" && !colorutil.IsValid(banner.Color) {
+		errors = append(errors, "banner-color must be a hex color like #c00000 or a color name")
+	}
+	if !isValidOptionalColor(banner.Background) {
+		errors = append(errors, "banner-background must be a hex color, a color name or none")
+	}
+
+	// Validate sidenote placement
+	if !IsValidSidenoteSide(config.Renderer.SidenoteSide) {
+		errors = append(errors, fmt.Sprintf("sidenote-side must be one of: %s", strings.Join(ValidSidenoteSides, ", ")))
+	}
+
+	// Validate table of contents depth
+	if config.Renderer.TOC.Depth < 1 || config.Renderer.TOC.Depth > 6 {
+		errors = append(errors, "toc-depth must be between 1 and 6")
+	}
+
+	// Validate heading level remapping
+	if config.Parser.HeadingShift < -HeadingShiftMax || config.Parser.HeadingShift > HeadingShiftMax {
+		errors = append(errors, fmt.Sprintf("shift-headings must be between %d and %d", -HeadingShiftMax, HeadingShiftMax))
+	}
+	levels := make([]int, 0, len(config.Parser.HeadingMap))
+	for from := range config.Parser.HeadingMap {
+		levels = append(levels, from)
+	}
+	sort.Ints(levels)
+	for _, from := range levels {
+		if to := config.Parser.HeadingMap[from]; from < 1 || from > 6 || to < 1 || to > 6 {
+			errors = append(errors, fmt.Sprintf("heading-map must map levels 1-6 to levels 1-6, got %d: %d", from, to))
+		}
+	}
+
+	// Validate conditional content names
+	for _, name := range config.Parser.Defines {
+		if !parser.IsConditionName(name) {
+			errors = append(errors, fmt.Sprintf("define %q must only contain letters, digits, '-' and '_'", name))
+		}
+	}
+
+	// Validate image active content policy
+	if !contentscan.IsValidPolicy(config.Renderer.ImagePolicy) {
+		errors = append(errors, fmt.Sprintf("image-policy must be one of: %s", strings.Join(contentscan.ValidPolicies, ", ")))
+	}
+
+	// Validate maximum output size
+	if config.Output.MaxSize != "" {
+		if _, err := ParseByteSize(config.Output.MaxSize); err != nil {
+			errors = append(errors, "max-output-size must be a size like 10MB or 500KB")
+		}
+	}
+
+	// Validate the output collision policy
+	if config.Output.OnCollision != "" && !IsValidCollisionPolicy(config.Output.OnCollision) {
+		errors = append(errors, fmt.Sprintf("on-collision must be one of: %s", strings.Join(ValidCollisionPolicies, ", ")))
+	}
+
+	// Validate output permissions
+	if config.Output.FileMode != "" {
+		if _, err := ParseFileMode(config.Output.FileMode); err != nil {
+			errors = append(errors, "output-mode must be an octal permission like 0640")
+		}
+	}
+
+	// Validate network settings
+	if config.Network.Timeout < 1 || config.Network.Timeout > MaxNetworkTimeout {
+		errors = append(errors, fmt.Sprintf("network-timeout must be between 1 and %d seconds", MaxNetworkTimeout))
+	}
+	if config.Network.RateLimit < 0 || config.Network.RateLimit > MaxNetworkRateLimit {
+		errors = append(errors, fmt.Sprintf("network-rate-limit must be between 0 and %d requests per second", MaxNetworkRateLimit))
+	}
+	if config.Network.Attempts < 1 || config.Network.Attempts > MaxNetworkAttempts {
+		errors = append(errors, fmt.Sprintf("network-attempts must be between 1 and %d", MaxNetworkAttempts))
+	}
+	if config.Network.Concurrency < 1 || config.Network.Concurrency > MaxNetworkConcurrency {
+		errors = append(errors, fmt.Sprintf("network-concurrency must be between 1 and %d", MaxNetworkConcurrency))
+	}
+	if config.Network.Proxy != "" {
+		if _, err := network.ParseProxy(config.Network.Proxy); err != nil {
+			errors = append(errors, "proxy must be an http, https or socks5 URL like http://proxy.example.com:8080")
+		}
+	}
+
+	// Validate locale codes, which become part of output file names
+	locales := make([]string, 0, len(config.Locales))
+	for locale := range config.Locales {
+		locales = append(locales, locale)
+	}
+	sort.Strings(locales)
+	for _, locale := range locales {
+		if !IsValidLocale(locale) {
+			errors = append(errors, fmt.Sprintf("invalid locale code %q", locale))
+		}
+	}
+
 	if len(errors) > 0 {
 		return &ConfigurationError{
 			Message: strings.Join(errors, "; "),
@@ -0,0 +1,53 @@
+package core
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/fredcamaral/md-to-pdf/internal/fsutil"
+)
+
+// DefaultFileMode is the permission of written PDFs unless the output mode
+// is configured or preserved from the input.
+const DefaultFileMode os.FileMode = 0600
+
+// ParseFileMode parses an octal permission such as "0640" or "644".
+func ParseFileMode(value string) (os.FileMode, error) {
+	mode, err := strconv.ParseUint(strings.TrimSpace(value), 8, 32)
+	if err != nil || mode > 0777 {
+		return 0, fmt.Errorf("invalid file mode %q", value)
+	}
+	return os.FileMode(mode), nil
+}
+
+// outputMode returns the permissions of the PDFs converted from sourceName:
+// those of the input with PreserveMode, else the configured FileMode. The
+// umask still applies.
+func (e *Engine) outputMode(sourceName string) os.FileMode {
+	if e.config.Output.PreserveMode {
+		if info, err := os.Stat(sourceName); err == nil && info.Mode().IsRegular() {
+			return info.Mode().Perm()
+		}
+	}
+	if e.config.Output.FileMode != "" {
+		if mode, err := ParseFileMode(e.config.Output.FileMode); err == nil {
+			return mode
+		}
+	}
+	return DefaultFileMode
+}
+
+// writeOutput atomically writes a PDF converted from sourceName with the
+// output permissions. With PreserveMode it also gets the owner and group of
+// the input, as far as the process may change them.
+func (e *Engine) writeOutput(path string, data []byte, sourceName string) error {
+	if err := fsutil.WriteFile(path, data, e.outputMode(sourceName)); err != nil {
+		return err
+	}
+	if e.config.Output.PreserveMode {
+		fsutil.CopyOwner(sourceName, path)
+	}
+	return nil
+}
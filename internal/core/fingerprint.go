@@ -0,0 +1,23 @@
+package core
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+)
+
+// ConfigFingerprint returns a short hash of the settings that affect how a
+// PDF looks, so two PDFs can be checked for having been rendered alike.
+// Document metadata, output paths, plugins and locale overrides are left
+// out, so documents converted with the same settings share a fingerprint.
+func ConfigFingerprint(config *Config) string {
+	data, err := json.Marshal(struct {
+		Parser   ParserConfig
+		Renderer RenderConfig
+	}{config.Parser, config.Renderer})
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:6])
+}
@@ -0,0 +1,112 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// translationPattern matches {{t:key}} placeholders in markdown sources.
+var translationPattern = regexp.MustCompile(`\{\{t:([A-Za-z0-9_.-]+)\}\}`)
+
+// localePattern matches locale codes such as "en", "de", "pt-BR" or "zh_Hant".
+var localePattern = regexp.MustCompile(`^[A-Za-z]{2,3}([-_][A-Za-z0-9]{2,8})*$`)
+
+// IsValidLocale checks if the given locale code is safe to use in file names.
+func IsValidLocale(locale string) bool {
+	return localePattern.MatchString(locale)
+}
+
+// ForLocale returns a copy of the configuration with the overrides for
+// locale applied. Locales without overrides get an unchanged copy.
+func (c *Config) ForLocale(locale string) *Config {
+	localized := *c
+	override := c.Locales[locale]
+
+	if override.FontFamily != "" {
+		localized.Renderer.FontFamily = override.FontFamily
+	}
+	if override.Title != "" {
+		localized.Document.Title = override.Title
+	}
+	if override.Author != "" {
+		localized.Document.Author = override.Author
+	}
+	if override.Subject != "" {
+		localized.Document.Subject = override.Subject
+	}
+	if override.DateFormat != "" {
+		localized.Document.DateFormat = override.DateFormat
+	}
+	if c.Output.OutlinePath != "" {
+		localized.Output.OutlinePath = LocalizedPath(c.Output.OutlinePath, locale)
+	}
+	if c.Output.ContactSheetPath != "" {
+		localized.Output.ContactSheetPath = LocalizedPath(c.Output.ContactSheetPath, locale)
+	}
+
+	return &localized
+}
+
+// LocalizedPath inserts the locale before the file extension,
+// e.g. "guide.pdf" becomes "guide.de.pdf".
+func LocalizedPath(path, locale string) string {
+	if locale == "" {
+		return path
+	}
+	ext := filepath.Ext(path)
+	return strings.TrimSuffix(path, ext) + "." + locale + ext
+}
+
+// localizedSourcePath returns the translated sibling of inputPath for locale
+// (doc.md -> doc.de.md) when it exists, and inputPath otherwise.
+func localizedSourcePath(inputPath, locale string) string {
+	if locale == "" {
+		return inputPath
+	}
+	candidate := LocalizedPath(inputPath, locale)
+	if _, err := os.Stat(candidate); err == nil {
+		return candidate
+	}
+	return inputPath
+}
+
+// Translate replaces {{t:key}} placeholders with entries from translations.
+// Placeholders without a translation are left untouched so they stay visible
+// in the output.
+func Translate(content []byte, translations map[string]string) []byte {
+	if len(translations) == 0 {
+		return content
+	}
+	return translationPattern.ReplaceAllFunc(content, func(match []byte) []byte {
+		key := string(translationPattern.FindSubmatch(match)[1])
+		if value, ok := translations[key]; ok {
+			return []byte(value)
+		}
+		return match
+	})
+}
+
+// forLocale returns an engine that renders with the overrides for locale.
+// The parser, plugins and image cache are shared with e so localized builds
+// of the same document reuse loaded plugins and images.
+func (e *Engine) forLocale(locale string) *Engine {
+	config := e.config.ForLocale(locale)
+	return &Engine{
+		parser:       e.parser,
+		renderer:     newRenderer(config, e.plugins, e.images),
+		plugins:      e.plugins,
+		images:       e.images,
+		config:       config,
+		http:         e.http,
+		translations: e.config.Locales[locale].Translations,
+		locale:       locale,
+		onWarning:    e.onWarning,
+		onSplit:      e.onSplit,
+		onCache:      e.onCache,
+		onPluginLog:  e.onPluginLog,
+		partial:      e.partial,
+		claims:       e.claims,
+	}
+}
@@ -0,0 +1,142 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestIsValidLocale(t *testing.T) {
+	tests := []struct {
+		locale string
+		valid  bool
+	}{
+		{"en", true},
+		{"de", true},
+		{"pt-BR", true},
+		{"zh_Hant", true},
+		{"", false},
+		{"e", false},
+		{"../de", false},
+		{"en/us", false},
+	}
+
+	for _, tt := range tests {
+		if got := IsValidLocale(tt.locale); got != tt.valid {
+			t.Errorf("IsValidLocale(%q) = %v, want %v", tt.locale, got, tt.valid)
+		}
+	}
+}
+
+func TestLocalizedPath(t *testing.T) {
+	tests := []struct {
+		path   string
+		locale string
+		want   string
+	}{
+		{"guide.pdf", "de", "guide.de.pdf"},
+		{"out/guide.md", "pt-BR", "out/guide.pt-BR.md"},
+		{"outline.json", "en", "outline.en.json"},
+		{"guide.pdf", "", "guide.pdf"},
+	}
+
+	for _, tt := range tests {
+		if got := LocalizedPath(tt.path, tt.locale); got != tt.want {
+			t.Errorf("LocalizedPath(%q, %q) = %q, want %q", tt.path, tt.locale, got, tt.want)
+		}
+	}
+}
+
+func TestTranslate(t *testing.T) {
+	translations := map[string]string{"greeting": "Hallo", "toc.title": "Inhalt"}
+
+	got := string(Translate([]byte("# {{t:greeting}}\n\n{{t:toc.title}} {{t:missing}}"), translations))
+	want := "# Hallo\n\nInhalt {{t:missing}}"
+	if got != want {
+		t.Errorf("Translate = %q, want %q", got, want)
+	}
+
+	content := []byte("{{t:greeting}}")
+	if string(Translate(content, nil)) != "{{t:greeting}}" {
+		t.Error("Translate without translations should return content unchanged")
+	}
+}
+
+func TestConfig_ForLocale(t *testing.T) {
+	config := DefaultConfig()
+	config.Document.Title = "Guide"
+	config.Output.OutlinePath = "outline.json"
+	config.Locales = map[string]LocaleConfig{
+		"de": {FontFamily: "Times", Title: "Handbuch", DateFormat: "02.01.2006"},
+	}
+
+	de := config.ForLocale("de")
+	if de.Renderer.FontFamily != "Times" || de.Document.Title != "Handbuch" || de.Document.DateFormat != "02.01.2006" {
+		t.Errorf("locale overrides not applied: %+v %+v", de.Renderer, de.Document)
+	}
+	if de.Output.OutlinePath != "outline.de.json" {
+		t.Errorf("OutlinePath = %q, want %q", de.Output.OutlinePath, "outline.de.json")
+	}
+	if config.Document.Title != "Guide" {
+		t.Error("ForLocale must not modify the base configuration")
+	}
+
+	en := config.ForLocale("en")
+	if en.Document.Title != "Guide" || en.Renderer.FontFamily != config.Renderer.FontFamily {
+		t.Errorf("locale without overrides should keep base config, got %+v", en.Document)
+	}
+}
+
+func TestEngine_Convert_Locales(t *testing.T) {
+	tempDir := t.TempDir()
+	writeFile := func(name, content string) string {
+		path := filepath.Join(tempDir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create %s: %v", name, err)
+		}
+		return path
+	}
+
+	input := writeFile("doc.md", "# {{t:title}}\n\nEnglish body.")
+	writeFile("doc.de.md", "# Handbuch\n\nDeutscher Text.")
+
+	config := DefaultConfig()
+	config.Plugins.Enabled = false
+	config.Locales = map[string]LocaleConfig{
+		"en": {Translations: map[string]string{"title": "Guide"}},
+	}
+	engine, err := NewEngine(config)
+	if err != nil {
+		t.Fatalf("Failed to create engine: %v", err)
+	}
+
+	var sources, outputs []string
+	err = engine.Convert(ConversionOptions{
+		InputFiles: []string{input},
+		OutputPath: filepath.Join(tempDir, "doc.pdf"),
+		Locales:    []string{"en", "de"},
+		OnComplete: func(current, total int, inputFile, outputFile string) {
+			if total != 2 {
+				t.Errorf("total = %d, want 2", total)
+			}
+			sources = append(sources, filepath.Base(inputFile))
+			outputs = append(outputs, filepath.Base(outputFile))
+		},
+	})
+	if err != nil {
+		t.Fatalf("Conversion failed: %v", err)
+	}
+
+	if strings.Join(sources, ",") != "doc.md,doc.de.md" {
+		t.Errorf("sources = %v, want translated sibling for de", sources)
+	}
+	if strings.Join(outputs, ",") != "doc.en.pdf,doc.de.pdf" {
+		t.Errorf("outputs = %v", outputs)
+	}
+	for _, name := range outputs {
+		if _, err := os.Stat(filepath.Join(tempDir, name)); err != nil {
+			t.Errorf("expected %s to be written: %v", name, err)
+		}
+	}
+}
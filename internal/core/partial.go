@@ -0,0 +1,53 @@
+package core
+
+import (
+	"os"
+	"sync"
+)
+
+// partialOutputs records the files written by the conversion in progress,
+// so they can be removed when it is aborted halfway. Files are written
+// atomically, so only complete files are recorded: an aborted write leaves
+// the previous file in place.
+type partialOutputs struct {
+	mu    sync.Mutex
+	paths []string
+}
+
+// start forgets the files of the previous conversion, which completed.
+func (p *partialOutputs) start() {
+	p.mu.Lock()
+	p.paths = nil
+	p.mu.Unlock()
+}
+
+// add records a file once written.
+func (p *partialOutputs) add(path string) {
+	p.mu.Lock()
+	p.paths = append(p.paths, path)
+	p.mu.Unlock()
+}
+
+// remove deletes the recorded files that exist and returns them. Later
+// conversions record their files again.
+func (p *partialOutputs) remove() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var removed []string
+	for _, path := range p.paths {
+		if err := os.Remove(path); err == nil {
+			removed = append(removed, path)
+		}
+	}
+	p.paths = nil
+	return removed
+}
+
+// RemovePartialOutputs deletes the files written so far by the conversion
+// in progress, such as the PDF, its parts and the outline, and returns the
+// paths removed. It may be called from another goroutine, for example when
+// the user aborts a batch.
+func (e *Engine) RemovePartialOutputs() []string {
+	return e.partial.remove()
+}
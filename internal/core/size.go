@@ -0,0 +1,56 @@
+package core
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// byteUnits maps size suffixes to their multipliers. Units are binary, so
+// "1KB" is 1024 bytes.
+var byteUnits = []struct {
+	suffix     string
+	multiplier int64
+}{
+	{"GB", 1 << 30},
+	{"MB", 1 << 20},
+	{"KB", 1 << 10},
+	{"G", 1 << 30},
+	{"M", 1 << 20},
+	{"K", 1 << 10},
+	{"B", 1},
+}
+
+// ParseByteSize parses a size such as "10MB", "1.5 MB", "500KB" or "2048"
+// into bytes. Suffixes are case-insensitive; a plain number is in bytes.
+func ParseByteSize(value string) (int64, error) {
+	s := strings.ToUpper(strings.TrimSpace(value))
+	multiplier := int64(1)
+	for _, unit := range byteUnits {
+		if strings.HasSuffix(s, unit.suffix) {
+			s = strings.TrimSpace(strings.TrimSuffix(s, unit.suffix))
+			multiplier = unit.multiplier
+			break
+		}
+	}
+
+	// ParseFloat accepts "Inf" and "NaN", which are no sizes, and sizes past
+	// the int64 range would wrap around when converted
+	number, err := strconv.ParseFloat(s, 64)
+	size := number * float64(multiplier)
+	if err != nil || math.IsNaN(size) || size < 1 || size >= math.MaxInt64 {
+		return 0, fmt.Errorf("invalid size %q", value)
+	}
+	return int64(size), nil
+}
+
+// FormatByteSize formats a byte count for messages, for example "2.4MB".
+func FormatByteSize(size int64) string {
+	for _, unit := range byteUnits[:3] {
+		if size >= unit.multiplier {
+			return strconv.FormatFloat(float64(size)/float64(unit.multiplier), 'f', 1, 64) + unit.suffix
+		}
+	}
+	return fmt.Sprintf("%dB", size)
+}
@@ -0,0 +1,48 @@
+package core
+
+import "testing"
+
+func TestParseByteSize(t *testing.T) {
+	tests := []struct {
+		input string
+		want  int64
+	}{
+		{"10MB", 10 << 20},
+		{"10mb", 10 << 20},
+		{"1.5 MB", 3 << 19},
+		{"500KB", 500 << 10},
+		{"2G", 2 << 30},
+		{"2048", 2048},
+		{"100B", 100},
+	}
+	for _, tt := range tests {
+		got, err := ParseByteSize(tt.input)
+		if err != nil {
+			t.Errorf("ParseByteSize(%q) returned error: %v", tt.input, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseByteSize(%q) = %d, want %d", tt.input, got, tt.want)
+		}
+	}
+
+	for _, input := range []string{"", "MB", "ten MB", "-5MB", "0", "0.5B", "Inf", "+InfMB", "NaN", "1e30GB"} {
+		if _, err := ParseByteSize(input); err == nil {
+			t.Errorf("ParseByteSize(%q) should fail", input)
+		}
+	}
+}
+
+func TestFormatByteSize(t *testing.T) {
+	tests := map[int64]string{
+		512:           "512B",
+		2048:          "2.0KB",
+		10 << 20:      "10.0MB",
+		(5 << 30) / 2: "2.5GB",
+	}
+	for size, want := range tests {
+		if got := FormatByteSize(size); got != want {
+			t.Errorf("FormatByteSize(%d) = %q, want %q", size, got, want)
+		}
+	}
+}
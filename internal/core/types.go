@@ -1,5 +1,7 @@
 package core
 
+import "github.com/fredcamaral/md-to-pdf/internal/plugins"
+
 // Config holds all configuration for the conversion engine
 type Config struct {
 	Parser   ParserConfig
@@ -7,10 +9,21 @@ type Config struct {
 	Plugins  PluginConfig
 	Output   OutputConfig
 	Document DocumentConfig
+	Network  NetworkConfig
+	// Locales holds per-locale overrides keyed by locale code (e.g. "de")
+	Locales map[string]LocaleConfig
 }
 
 type ParserConfig struct {
 	Extensions []string
+	// HeadingShift moves every heading down (positive) or up (negative) by
+	// this many levels, so included documents nest under their parent
+	HeadingShift int
+	// HeadingMap gives the level each listed source heading level becomes,
+	// in place of HeadingShift
+	HeadingMap map[int]int
+	// Defines are the names whose <!-- if:name --> blocks are kept
+	Defines []string
 }
 
 type RenderConfig struct {
@@ -19,16 +32,110 @@ type RenderConfig struct {
 	FontFamily   string
 	FontSize     float64
 	HeadingScale float64
-	LineSpacing  float64
-	CodeFont     string
-	CodeSize     float64
-	Mermaid      MermaidConfig
+	// HeadingMinSize is the smallest font size in points that headings too
+	// long for one line shrink to before wrapping (0 = wrap without shrinking)
+	HeadingMinSize float64
+	LineSpacing    float64
+	CodeFont       string
+	CodeSize       float64
+	Mermaid        MermaidConfig
+	HeaderFooter   HeaderFooterConfig
+	// ImagePolicy decides how images with active content (scripts, appended
+	// payloads) are handled: "warn", "sanitize" or "refuse"
+	ImagePolicy string
+	// BaselineGrid snaps the space between blocks (paragraphs, headings,
+	// lists, code) to multiples of the body line height
+	BaselineGrid bool
+	// ThematicBreak styles horizontal rules (---, ***, ___)
+	ThematicBreak ThematicBreakConfig
+	// QuoteStyle styles blockquotes and their attribution lines
+	QuoteStyle QuoteStyleConfig
+	// Colors sets the text, page and code colors
+	Colors ColorConfig
+	// SidenoteSide is the margin ^[sidenotes] are placed in: "outer" (right
+	// on odd pages, left on even pages), "right" or "left"
+	SidenoteSide string
+	// TOC adds a table of contents with page numbers
+	TOC TOCConfig
+	// Banner stamps a classification banner on every page
+	Banner BannerConfig
+	// KeepWithNext keeps "headings" on the page of the text after them, or
+	// lets them end a page with "none"
+	KeepWithNext string
+	// MinLinesAfterHeading is how many lines of text must fit below a
+	// heading for it to stay on its page rather than move to the next one
+	MinLinesAfterHeading int
 }
 
 type MermaidConfig struct {
 	Scale     float64 // Scaling factor for mermaid diagrams (1.0 = normal, 1.4 = 40% bigger)
 	MaxWidth  float64 // Maximum width in mm (0 = use page width)
 	MaxHeight float64 // Maximum height in mm
+	// Theme picks the "light" or "dark" variant of diagrams: images with a
+	// name.dark.png (or name.light.png) file beside them and generated
+	// mermaid diagrams, so dark documents embed dark diagrams
+	Theme string
+	// WideStrategy places diagrams much wider than tall that do not fit the
+	// text column: "shrink" (default), "rotate" them 90° or put them on a
+	// "landscape" page of their own
+	WideStrategy string
+}
+
+// HeaderFooterConfig holds markdown snippets rendered on every page.
+// Snippets support inline formatting, images and template variables
+// ({page}, {pages}, {title}, {author}, {subject}, {date}).
+type HeaderFooterConfig struct {
+	Header      string
+	Footer      string
+	HeaderAlign string // "left", "center" or "right"
+	FooterAlign string // "left", "center" or "right"
+}
+
+// ThematicBreakConfig controls the look of horizontal rules.
+type ThematicBreakConfig struct {
+	Style     string  // "solid", "dashed", "dotted" or "ornament"
+	Thickness float64 // Line width in mm
+	Color     string  // Hex color or color name, e.g. "#c8c8c8"
+	Width     float64 // Percentage of the text width, centered
+	Ornament  string  // Text centered in place of a line for the "ornament" style
+}
+
+// QuoteStyleConfig controls the look of blockquotes.
+type QuoteStyleConfig struct {
+	BarColor   string // Border bar color, or "none"
+	Background string // Background tint, or "none"
+	FontStyle  string // "italic" or "normal"
+}
+
+// ColorConfig sets the colors of text, the page and code. Empty values keep
+// the built-in colors: black text on a white page, with blue links and code
+// on light gray.
+type ColorConfig struct {
+	Text           string // Body text color
+	Background     string // Page background color
+	Link           string // Link text color
+	CodeText       string // Code block and code span text color
+	CodeBackground string // Code block and code span background color
+	// ImageBackdrop is the color mostly transparent images with strokes too
+	// light to see on the page are placed on; empty only warns about them
+	ImageBackdrop string
+}
+
+// BannerConfig stamps a classification banner, such as "CONFIDENTIAL", in a
+// band along the top and bottom edges of every page, without plugins.
+type BannerConfig struct {
+	Text       string // Banner text, with the header variables; empty for none
+	Position   string // "top", "bottom" or "both"
+	Color      string // Text color (empty = body text color)
+	Background string // Band color, or "none"
+}
+
+// TOCConfig controls the table of contents. A <!-- toc --> marker in the
+// document places the table there even when it is not enabled.
+type TOCConfig struct {
+	Enabled bool   // Add a table of contents at the start of the document
+	Depth   int    // Deepest heading level listed, 1 to 6
+	Title   string // Title above the entries
 }
 
 type PluginConfig struct {
@@ -36,17 +143,95 @@ type PluginConfig struct {
 	Enabled   bool
 	// Configs holds per-plugin configuration keyed by plugin name
 	Configs map[string]map[string]interface{}
+	// Grants holds the capabilities granted to each plugin, keyed by plugin
+	// name (nil = plugins may only read the paths they declare)
+	Grants map[string]plugins.Capabilities
 }
 
 type OutputConfig struct {
-	Path    string
-	Quality string
+	Path        string
+	Quality     string
+	OutlinePath string // Write the heading outline (JSON or YAML) here when set
+	// ContactSheetPath writes a PNG grid of page thumbnails here when set
+	ContactSheetPath string
+	// MaxSize splits the PDF into numbered parts no larger than this size,
+	// for example "10MB" (empty = no limit)
+	MaxSize string
+	// CacheDir keeps rendered PDFs keyed on a hash of everything they depend
+	// on, so unchanged documents are not rendered again (empty = no cache)
+	CacheDir string
+	// Linearize reorders the PDF for fast web view, so viewers can show the
+	// first page while the rest of the file downloads
+	Linearize bool
+	// Manifest attaches a JSON manifest with the hashes of the source and
+	// images, the md-to-pdf version and the config fingerprint to the PDF
+	Manifest bool
+	// Summary adds a closing page with document statistics
+	Summary SummaryConfig
+	// SourceAppendix appends the markdown source as a line-numbered listing
+	SourceAppendix bool
+	// SourcePDFPath writes the line-numbered source listing as a PDF of its
+	// own here when set
+	SourcePDFPath string
+	// FileMode is the octal permission of written PDFs, such as "0640"
+	// (empty = 0600); the umask still applies
+	FileMode string
+	// PreserveMode gives PDFs the permissions of their input file, and its
+	// owner and group where the process may set them
+	PreserveMode bool
+	// OnCollision decides what happens when two inputs derive the same
+	// output path: "error", "rename" or "overwrite"
+	OnCollision string
+}
+
+// NetworkConfig controls the requests plugins make for remote resources.
+type NetworkConfig struct {
+	// Timeout limits each request, in seconds
+	Timeout int
+	// Proxy is the URL requests go through (empty = the HTTP_PROXY,
+	// HTTPS_PROXY and NO_PROXY environment variables)
+	Proxy string
+	// Offline serves previously fetched responses only and fails requests
+	// that would need the network
+	Offline bool
+	// RateLimit is the most requests per second (0 = unlimited)
+	RateLimit float64
+	// Attempts is the number of times a failed request is sent, with
+	// growing waits between them
+	Attempts int
+	// Concurrency is the most downloads run at once
+	Concurrency int
+}
+
+// SummaryConfig controls the closing summary page.
+type SummaryConfig struct {
+	Enabled bool
+	RepoURL string // Link in the QR code (empty = the source file's git remote)
+	// ToolVersion is the md-to-pdf version shown on the page, set by the CLI
+	ToolVersion string
 }
 
 type DocumentConfig struct {
-	Title   string
-	Author  string
-	Subject string
+	Title      string
+	Author     string
+	Subject    string
+	DateFormat string // Go time layout used for the {date} template variable
+	// TitleFromH1 takes a leading level 1 heading out of the body and uses
+	// it as the title, unless Title is set, and as the output file name
+	// when none is given
+	TitleFromH1 bool
+}
+
+// LocaleConfig overrides configuration for one locale of a multi-language
+// build. Empty fields keep the base configuration.
+type LocaleConfig struct {
+	FontFamily string
+	Title      string
+	Author     string
+	Subject    string
+	DateFormat string
+	// Translations replaces {{t:key}} placeholders in the markdown source
+	Translations map[string]string
 }
 
 type Margins struct {
@@ -60,13 +245,32 @@ type Margins struct {
 // It receives the current file index (1-based), total file count, input filename, and output filename.
 type ProgressCallback func(current, total int, inputFile, outputFile string)
 
+// WarningHandler receives non-fatal warnings raised while converting a file.
+type WarningHandler func(file, message string)
+
+// OutputPart is one file of a PDF split to stay under the maximum output
+// size.
+type OutputPart struct {
+	Path      string
+	FirstPage int
+	LastPage  int
+}
+
+// SplitHandler receives the parts written in place of outputPath when a PDF
+// is split to stay under the configured maximum output size.
+type SplitHandler func(file, outputPath string, parts []OutputPart)
+
 type ConversionOptions struct {
 	InputFiles []string
 	OutputPath string
 	PluginDir  string
 	Verbose    bool
+	// Locales builds one localized PDF per locale and input file (optional).
+	Locales []string
 	// OnProgress is called before converting each file (optional).
 	OnProgress ProgressCallback
 	// OnComplete is called after successfully converting each file (optional).
+	// Its output filename is where the PDF was written, which differs from
+	// the one given to OnProgress when the PDF is named after its title.
 	OnComplete ProgressCallback
 }
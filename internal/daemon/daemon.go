@@ -0,0 +1,613 @@
+// Package daemon serves conversions to long-running clients such as editor
+// extensions and build servers over JSON-RPC 2.0. Clients submit jobs, are
+// sent progress events while they run, fetch their results and query the
+// loaded plugins, without paying for a new process per document.
+package daemon
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/fredcamaral/md-to-pdf/internal/config"
+	"github.com/fredcamaral/md-to-pdf/internal/core"
+	"github.com/fredcamaral/md-to-pdf/internal/output"
+	"github.com/fredcamaral/md-to-pdf/internal/plugins"
+	"gopkg.in/yaml.v3"
+)
+
+// Job states.
+const (
+	JobQueued    = "queued"
+	JobRunning   = "running"
+	JobSucceeded = "succeeded"
+	JobFailed    = "failed"
+)
+
+// Limits bound the work a server takes on, so that a burst of submissions
+// is refused instead of exhausting the host. Zero fields take the default.
+type Limits struct {
+	// MaxConcurrent is the number of jobs running at once (default 1)
+	MaxConcurrent int
+	// MaxQueued is the number of jobs waiting to run before submissions
+	// are refused with a retry delay (default 256)
+	MaxQueued int
+	// MaxRequestSize is the size in bytes of the largest request read,
+	// which bounds the documents sent as content (default 64 MiB)
+	MaxRequestSize int
+	// MaxFinished is the number of finished jobs kept for job.status and
+	// job.wait; older ones are forgotten first (default 1024)
+	MaxFinished int
+	// FinishedTTL is how long a finished job is kept (default 1 hour)
+	FinishedTTL time.Duration
+}
+
+// Default limits.
+const (
+	defaultMaxConcurrent  = 1
+	defaultMaxQueued      = 256
+	defaultMaxRequestSize = 64 << 20
+	defaultMaxFinished    = 1024
+	defaultFinishedTTL    = time.Hour
+)
+
+func (l Limits) withDefaults() Limits {
+	if l.MaxConcurrent <= 0 {
+		l.MaxConcurrent = defaultMaxConcurrent
+	}
+	if l.MaxQueued <= 0 {
+		l.MaxQueued = defaultMaxQueued
+	}
+	if l.MaxRequestSize <= 0 {
+		l.MaxRequestSize = defaultMaxRequestSize
+	}
+	if l.MaxFinished <= 0 {
+		l.MaxFinished = defaultMaxFinished
+	}
+	if l.FinishedTTL <= 0 {
+		l.FinishedTTL = defaultFinishedTTL
+	}
+	return l
+}
+
+// ConvertParams are the parameters of the convert method.
+type ConvertParams struct {
+	// Input is the Markdown file to convert
+	Input string `json:"input,omitempty"`
+	// Content is Markdown text converted instead of Input. Input, if set,
+	// names it in warnings and errors
+	Content string `json:"content,omitempty"`
+	// Output is the PDF written (default: Input with .pdf, required with
+	// Content)
+	Output string `json:"output,omitempty"`
+	// Locales builds one PDF per locale, like convert --locales
+	Locales []string `json:"locales,omitempty"`
+	// Options are settings in the keys of the config file (font_size,
+	// toc, ...) applied over the daemon's settings for this job only
+	Options map[string]interface{} `json:"options,omitempty"`
+}
+
+// Job is a submitted conversion.
+type Job struct {
+	ID    string `json:"id"`
+	State string `json:"state"`
+	Input string `json:"input,omitempty"`
+	// Results holds one result per PDF written, in the format of
+	// convert --json
+	Results []output.ConversionResult `json:"results,omitempty"`
+	Error   string                    `json:"error,omitempty"`
+
+	params   ConvertParams
+	client   *conn
+	accepted chan struct{} // Closed once the client was sent the job ID
+	done     chan struct{}
+	finished time.Time
+}
+
+// ProgressEvent is sent to the client that submitted a job, as the params of
+// a job.progress notification, whenever the job changes state, starts a file
+// or raises a warning.
+type ProgressEvent struct {
+	JobID   string `json:"job_id"`
+	State   string `json:"state"`
+	File    string `json:"file,omitempty"`
+	Output  string `json:"output,omitempty"`
+	Current int    `json:"current,omitempty"`
+	Total   int    `json:"total,omitempty"`
+	Warning string `json:"warning,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// Server starts submitted jobs in submission order, running up to
+// Limits.MaxConcurrent of them at once.
+type Server struct {
+	newConfig func() (*core.Config, error)
+	version   string
+	limits    Limits
+
+	mu       sync.Mutex
+	jobs     map[string]*Job
+	finished []*Job // Finished jobs still in jobs, oldest first
+	nextID   int
+	queue    chan *Job
+	stopping bool
+
+	waiters sync.WaitGroup // Pending job.wait replies
+	stopped chan struct{}
+	metrics *metrics
+}
+
+// NewServer creates a server. newConfig returns the settings every job
+// starts from, so changes to the user config apply to later jobs; version is
+// reported by the version method; limits bound the jobs it accepts.
+func NewServer(newConfig func() (*core.Config, error), version string, limits Limits) *Server {
+	limits = limits.withDefaults()
+	s := &Server{
+		newConfig: newConfig,
+		version:   version,
+		limits:    limits,
+		jobs:      make(map[string]*Job),
+		queue:     make(chan *Job, limits.MaxQueued),
+		stopped:   make(chan struct{}),
+		metrics:   newMetrics(),
+	}
+	go s.work()
+	return s
+}
+
+// Done is closed once the server was shut down and its queued jobs finished.
+func (s *Server) Done() <-chan struct{} {
+	return s.stopped
+}
+
+// Serve accepts connections from listener until the server is shut down.
+func (s *Server) Serve(listener net.Listener) error {
+	go func() {
+		<-s.stopped
+		_ = listener.Close()
+	}()
+	for {
+		c, err := listener.Accept()
+		if err != nil {
+			select {
+			case <-s.stopped:
+				return nil
+			default:
+				return err
+			}
+		}
+		go func() {
+			defer func() { _ = c.Close() }()
+			_ = s.ServeConn(c, c)
+		}()
+	}
+}
+
+// ServeConn answers the requests read from r on w until r is exhausted. It
+// returns once the jobs submitted and job.wait requests made on the
+// connection have been reported too, so a client that closes its end of a
+// pipe after the last request still gets every reply.
+func (s *Server) ServeConn(r io.Reader, w io.Writer) error {
+	c := newConn(r, w, s.limits.MaxRequestSize)
+	defer func() {
+		c.pending.Wait()
+		c.close()
+	}()
+	for {
+		message, err := c.read()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if errors.Is(err, errMessageTooLarge) {
+			// The request was skipped unread, so its ID is unknown
+			s.metrics.rejected(rejectedTooLarge)
+			c.write(response{JSONRPC: jsonrpcVersion, ID: json.RawMessage("null"), Error: &rpcError{
+				Code:    codeRequestTooLarge,
+				Message: "request larger than " + core.FormatByteSize(int64(s.limits.MaxRequestSize)),
+			}})
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		s.handle(c, message)
+	}
+}
+
+// handle answers one message. Requests are answered in order, except
+// job.wait, which is answered when its job finishes.
+func (s *Server) handle(c *conn, message []byte) {
+	var req request
+	if err := json.Unmarshal(message, &req); err != nil {
+		c.write(response{JSONRPC: jsonrpcVersion, ID: json.RawMessage("null"), Error: &rpcError{Code: codeParseError, Message: "parse error: " + err.Error()}})
+		return
+	}
+	if req.JSONRPC != jsonrpcVersion || req.Method == "" {
+		c.write(response{JSONRPC: jsonrpcVersion, ID: idOrNull(req.ID), Error: &rpcError{Code: codeInvalidRequest, Message: "invalid request"}})
+		return
+	}
+
+	reply := func(result interface{}, err error) {
+		if req.ID == nil {
+			return // Notifications are not answered
+		}
+		resp := response{JSONRPC: jsonrpcVersion, ID: req.ID, Result: result}
+		if err != nil {
+			var rpcErr *rpcError
+			if !errors.As(err, &rpcErr) {
+				rpcErr = &rpcError{Code: codeInternalError, Message: err.Error()}
+			}
+			resp.Result, resp.Error = nil, rpcErr
+		}
+		c.write(resp)
+	}
+
+	switch req.Method {
+	case "convert":
+		job, err := s.submit(c, req.Params)
+		if err != nil {
+			reply(nil, err)
+			return
+		}
+		// The job ID goes out before any progress event of the job
+		reply(map[string]string{"job_id": job.ID}, nil)
+		c.notify("job.progress", ProgressEvent{JobID: job.ID, State: JobQueued})
+		close(job.accepted)
+	case "job.status":
+		reply(s.status(req.Params))
+	case "job.wait":
+		if !s.addWaiter() {
+			reply(nil, errShuttingDown())
+			return
+		}
+		// The job is looked up right away, so it cannot expire before the
+		// waiter holds it
+		job, err := s.lookup(req.Params)
+		if err != nil {
+			s.waiters.Done()
+			reply(nil, err)
+			return
+		}
+		c.pending.Add(1)
+		go func() {
+			defer c.pending.Done()
+			defer s.waiters.Done()
+			reply(s.wait(job))
+		}()
+	case "plugins.list":
+		reply(s.listPlugins())
+	case "version":
+		reply(map[string]string{"version": s.version}, nil)
+	case "shutdown":
+		s.Shutdown()
+		reply(true, nil)
+	default:
+		reply(nil, &rpcError{Code: codeMethodNotFound, Message: "method not found: " + req.Method})
+	}
+}
+
+func idOrNull(id json.RawMessage) json.RawMessage {
+	if id == nil {
+		return json.RawMessage("null")
+	}
+	return id
+}
+
+func errShuttingDown() error {
+	return &rpcError{Code: codeShuttingDown, Message: "the daemon is shutting down"}
+}
+
+func invalidParams(format string, args ...interface{}) error {
+	return &rpcError{Code: codeInvalidParams, Message: fmt.Sprintf(format, args...)}
+}
+
+// submit queues a conversion job.
+func (s *Server) submit(c *conn, raw json.RawMessage) (*Job, error) {
+	var params ConvertParams
+	if err := decodeParams(raw, &params); err != nil {
+		return nil, err
+	}
+	if params.Input == "" && params.Content == "" {
+		return nil, invalidParams("input or content is required")
+	}
+	if params.Content != "" && params.Output == "" {
+		return nil, invalidParams("output is required with content")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stopping {
+		return nil, errShuttingDown()
+	}
+	s.nextID++
+	job := &Job{
+		ID:       strconv.Itoa(s.nextID),
+		State:    JobQueued,
+		Input:    params.Input,
+		params:   params,
+		client:   c,
+		accepted: make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	select {
+	case s.queue <- job:
+	default:
+		s.nextID--
+		s.metrics.rejected(rejectedQueueFull)
+		return nil, &rpcError{
+			Code:    codeQueueFull,
+			Message: "too many queued jobs, try again later",
+			Data:    map[string]int{"retry_after": s.retryAfter()},
+		}
+	}
+	s.jobs[job.ID] = job
+	c.pending.Add(1)
+	return job, nil
+}
+
+// jobParams are the parameters of the job methods.
+type jobParams struct {
+	JobID string `json:"job_id"`
+}
+
+func (s *Server) lookup(raw json.RawMessage) (*Job, error) {
+	var params jobParams
+	if err := decodeParams(raw, &params); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.expire(time.Now())
+	job, ok := s.jobs[params.JobID]
+	if !ok {
+		return nil, &rpcError{Code: codeJobNotFound, Message: "no job " + strconv.Quote(params.JobID)}
+	}
+	return job, nil
+}
+
+// status returns a job as it is now.
+func (s *Server) status(raw json.RawMessage) (interface{}, error) {
+	job, err := s.lookup(raw)
+	if err != nil {
+		return nil, err
+	}
+	return s.snapshot(job), nil
+}
+
+// addWaiter counts a pending job.wait reply, which shutdown waits for,
+// unless the server is stopping: the count must not grow once shutdown may
+// be waiting for it to drop to zero.
+func (s *Server) addWaiter() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stopping {
+		return false
+	}
+	s.waiters.Add(1)
+	return true
+}
+
+// wait returns a job once it has finished.
+func (s *Server) wait(job *Job) (interface{}, error) {
+	<-job.done
+	return s.snapshot(job), nil
+}
+
+func (s *Server) snapshot(job *Job) Job {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	copied := *job
+	copied.Results = append([]output.ConversionResult(nil), job.Results...)
+	return copied
+}
+
+// listPlugins describes the plugins a job would load, including built-in
+// ones.
+func (s *Server) listPlugins() (interface{}, error) {
+	cfg, err := s.newConfig()
+	if err != nil {
+		return nil, err
+	}
+	engine, err := core.NewEngine(cfg)
+	if err != nil {
+		return nil, err
+	}
+	defer engine.Close()
+	list, err := engine.Plugins()
+	if err != nil {
+		return nil, err
+	}
+	if list == nil {
+		list = []plugins.PluginInfo{}
+	}
+	return list, nil
+}
+
+// Shutdown refuses new jobs and stops the server once the queued ones have
+// finished.
+func (s *Server) Shutdown() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.stopping {
+		s.stopping = true
+		close(s.queue)
+	}
+}
+
+// retryAfter estimates the seconds until a queued job starts and frees a
+// place in the queue: the average job duration shared among the jobs
+// running at once, at least one second.
+func (s *Server) retryAfter() int {
+	seconds := s.metrics.averageDuration().Seconds() / float64(s.limits.MaxConcurrent)
+	return int(math.Max(1, math.Ceil(seconds)))
+}
+
+// work runs queued jobs, up to MaxConcurrent at once, until the queue is
+// closed.
+func (s *Server) work() {
+	var workers sync.WaitGroup
+	for i := 0; i < s.limits.MaxConcurrent; i++ {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			for job := range s.queue {
+				s.run(job)
+			}
+		}()
+	}
+	workers.Wait()
+	s.waiters.Wait()
+	close(s.stopped)
+}
+
+// run converts one job and reports its progress to the submitting client.
+func (s *Server) run(job *Job) {
+	<-job.accepted
+	s.setState(job, JobRunning)
+	job.client.notify("job.progress", ProgressEvent{JobID: job.ID, State: JobRunning})
+
+	s.metrics.started()
+	start := time.Now()
+	results, err := s.convert(job)
+
+	s.mu.Lock()
+	job.Results = results
+	job.State = JobSucceeded
+	event := ProgressEvent{JobID: job.ID, State: JobSucceeded}
+	if err != nil {
+		job.State, job.Error = JobFailed, err.Error()
+		event.State, event.Error = JobFailed, job.Error
+	}
+	job.finished = time.Now()
+	s.finished = append(s.finished, job)
+	s.expire(job.finished)
+	s.mu.Unlock()
+	s.metrics.finished(event.State, time.Since(start), results)
+
+	job.client.notify("job.progress", event)
+	close(job.done)
+	job.client.pending.Done()
+}
+
+// expire forgets the finished jobs beyond Limits.MaxFinished and those
+// finished longer than Limits.FinishedTTL ago. Waiters already holding a
+// job still get its result. The caller holds s.mu.
+func (s *Server) expire(now time.Time) {
+	n := 0
+	for n < len(s.finished) &&
+		(len(s.finished)-n > s.limits.MaxFinished || now.Sub(s.finished[n].finished) > s.limits.FinishedTTL) {
+		delete(s.jobs, s.finished[n].ID)
+		n++
+	}
+	if n > 0 {
+		s.finished = append(s.finished[:0], s.finished[n:]...)
+	}
+}
+
+func (s *Server) setState(job *Job, state string) {
+	s.mu.Lock()
+	job.State = state
+	s.mu.Unlock()
+}
+
+// convert builds the engine for a job and converts its document.
+func (s *Server) convert(job *Job) ([]output.ConversionResult, error) {
+	params := job.params
+	cfg, err := s.newConfig()
+	if err != nil {
+		return nil, err
+	}
+	if len(params.Options) > 0 {
+		// Options use the keys of the config file, so decode them as one
+		data, err := yaml.Marshal(params.Options)
+		if err != nil {
+			return nil, fmt.Errorf("invalid options: %w", err)
+		}
+		var options config.UserConfig
+		if err := yaml.Unmarshal(data, &options); err != nil {
+			return nil, fmt.Errorf("invalid options: %w", err)
+		}
+		config.ApplyUserConfig(cfg, &options)
+	}
+
+	engine, err := core.NewEngine(cfg)
+	if err != nil {
+		return nil, err
+	}
+	// Jobs hand their parser on to the next one
+	defer engine.Close()
+
+	formatter := output.NewFormatter(true)
+	var warnings []string
+	engine.SetWarningHandler(func(file, message string) {
+		warnings = append(warnings, message)
+		job.client.notify("job.progress", ProgressEvent{JobID: job.ID, State: JobRunning, File: file, Warning: message})
+	})
+	parts := make(map[string][]core.OutputPart)
+	engine.SetSplitHandler(func(_, outputPath string, written []core.OutputPart) {
+		parts[outputPath] = written
+	})
+	cacheHits := make(map[string]bool)
+	engine.SetCacheHandler(func(_, outputPath string, hit bool) {
+		cacheHits[outputPath] = hit
+	})
+
+	start := time.Now()
+	record := func(input, outputPath string) {
+		formatter.RecordSuccess(input, outputPath, time.Since(start), warnings...)
+		warnings = nil
+		if written, ok := parts[outputPath]; ok {
+			results := make([]output.PartResult, len(written))
+			for i, part := range written {
+				results[i] = output.PartResult{Path: part.Path, FirstPage: part.FirstPage, LastPage: part.LastPage}
+			}
+			formatter.RecordParts(outputPath, results)
+		}
+		if hit, ok := cacheHits[outputPath]; ok {
+			formatter.RecordCache(outputPath, hit)
+		}
+		start = time.Now()
+	}
+
+	if params.Content != "" {
+		source := params.Input
+		if source == "" {
+			source = "content"
+		}
+		if err := engine.ConvertSource([]byte(params.Content), source, params.Output); err != nil {
+			return nil, err
+		}
+		record(source, params.Output)
+		return formatter.Results(), nil
+	}
+
+	err = engine.Convert(core.ConversionOptions{
+		InputFiles: []string{params.Input},
+		OutputPath: params.Output,
+		Locales:    params.Locales,
+		OnProgress: func(current, total int, inputFile, outputFile string) {
+			job.client.notify("job.progress", ProgressEvent{JobID: job.ID, State: JobRunning, File: inputFile, Output: outputFile, Current: current, Total: total})
+		},
+		OnComplete: func(_, _ int, inputFile, outputFile string) {
+			record(inputFile, outputFile)
+		},
+	})
+	return formatter.Results(), err
+}
+
+// decodeParams decodes request parameters, which are required.
+func decodeParams(raw json.RawMessage, v interface{}) error {
+	if len(raw) == 0 {
+		return invalidParams("missing params")
+	}
+	if err := json.Unmarshal(raw, v); err != nil {
+		return invalidParams("invalid params: %v", err)
+	}
+	return nil
+}
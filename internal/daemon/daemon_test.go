@@ -0,0 +1,459 @@
+package daemon
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fredcamaral/md-to-pdf/internal/core"
+)
+
+// client talks to a server over an in-memory connection. Requests are
+// written in the background, as the pipe has no buffer and the server may be
+// writing to the client at the same time.
+type client struct {
+	t        *testing.T
+	requests chan string
+	closed   sync.Once
+	scanner  *bufio.Scanner
+}
+
+func newClient(t *testing.T, s *Server) *client {
+	t.Helper()
+	requests, requestWriter := io.Pipe()
+	responseReader, responses := io.Pipe()
+	go func() {
+		_ = s.ServeConn(requests, responses)
+		_ = responses.Close()
+	}()
+	c := &client{t: t, requests: make(chan string, 16), scanner: bufio.NewScanner(responseReader)}
+	go func() {
+		for line := range c.requests {
+			_, _ = io.WriteString(requestWriter, line+"\n")
+		}
+		_ = requestWriter.Close()
+	}()
+	t.Cleanup(c.closeRequests)
+	return c
+}
+
+func (c *client) send(line string) {
+	c.requests <- line
+}
+
+// closeRequests closes the request side of the connection once the requests
+// sent so far are written, as a client piping requests into stdin does.
+func (c *client) closeRequests() {
+	c.closed.Do(func() { close(c.requests) })
+}
+
+// message is any message from the server.
+type message struct {
+	ID     json.RawMessage `json:"id"`
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params"`
+	Result json.RawMessage `json:"result"`
+	Error  *rpcError       `json:"error"`
+}
+
+func (c *client) next() message {
+	c.t.Helper()
+	done := make(chan bool)
+	go func() { done <- c.scanner.Scan() }()
+	select {
+	case ok := <-done:
+		if !ok {
+			c.t.Fatal("connection closed")
+		}
+	case <-time.After(10 * time.Second):
+		c.t.Fatal("timed out waiting for the server")
+	}
+	var m message
+	if err := json.Unmarshal(c.scanner.Bytes(), &m); err != nil {
+		c.t.Fatalf("invalid message %s: %v", c.scanner.Text(), err)
+	}
+	return m
+}
+
+// response returns the response to request id, collecting the progress
+// events received before it.
+func (c *client) response(id int, events *[]ProgressEvent) message {
+	c.t.Helper()
+	for {
+		m := c.next()
+		if m.Method == "job.progress" {
+			var event ProgressEvent
+			if err := json.Unmarshal(m.Params, &event); err != nil {
+				c.t.Fatalf("invalid progress event: %v", err)
+			}
+			if events != nil {
+				*events = append(*events, event)
+			}
+			continue
+		}
+		if string(m.ID) != fmt.Sprint(id) {
+			c.t.Fatalf("got response %s, want %d", m.ID, id)
+		}
+		return m
+	}
+}
+
+func newTestServer(t *testing.T, limits Limits) *Server {
+	t.Helper()
+	s := NewServer(func() (*core.Config, error) {
+		cfg := core.DefaultConfig()
+		cfg.Plugins.Enabled = false
+		return cfg, nil
+	}, "test", limits)
+	t.Cleanup(s.Shutdown)
+	return s
+}
+
+func TestConvertJob(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "doc.md")
+	if err := os.WriteFile(input, []byte("# Doc\n\nSee [missing](#missing).\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	outputPath := filepath.Join(dir, "doc.pdf")
+
+	c := newClient(t, newTestServer(t, Limits{}))
+	params, _ := json.Marshal(ConvertParams{Input: input, Output: outputPath, Options: map[string]interface{}{"toc": true}})
+	c.send(fmt.Sprintf(`{"jsonrpc":"2.0","id":1,"method":"convert","params":%s}`, params))
+
+	var submitted struct {
+		JobID string `json:"job_id"`
+	}
+	resp := c.response(1, nil)
+	if resp.Error != nil {
+		t.Fatalf("convert failed: %v", resp.Error)
+	}
+	if err := json.Unmarshal(resp.Result, &submitted); err != nil || submitted.JobID == "" {
+		t.Fatalf("convert result = %s, want a job ID", resp.Result)
+	}
+
+	var events []ProgressEvent
+	c.send(fmt.Sprintf(`{"jsonrpc":"2.0","id":2,"method":"job.wait","params":{"job_id":%q}}`, submitted.JobID))
+	resp = c.response(2, &events)
+	var job Job
+	if err := json.Unmarshal(resp.Result, &job); err != nil {
+		t.Fatalf("invalid job: %v", err)
+	}
+	if job.State != JobSucceeded || len(job.Results) != 1 || job.Results[0].Output != outputPath {
+		t.Fatalf("job = %+v, want one succeeded result for %s", job, outputPath)
+	}
+	if len(job.Results[0].Warnings) != 1 {
+		t.Errorf("result warnings = %v, want the missing anchor", job.Results[0].Warnings)
+	}
+	if _, err := os.Stat(outputPath); err != nil {
+		t.Errorf("PDF was not written: %v", err)
+	}
+
+	states := make([]string, 0, len(events))
+	warned := false
+	for _, event := range events {
+		states = append(states, event.State)
+		warned = warned || event.Warning != ""
+	}
+	if len(states) < 3 || states[0] != JobQueued || states[len(states)-1] != JobSucceeded {
+		t.Errorf("progress states = %v, want queued first and succeeded last", states)
+	}
+	if !warned {
+		t.Error("the warning should be sent as a progress event")
+	}
+}
+
+func TestConvertJobFailure(t *testing.T) {
+	c := newClient(t, newTestServer(t, Limits{}))
+	c.send(`{"jsonrpc":"2.0","id":1,"method":"convert","params":{"input":"does-not-exist.md"}}`)
+	c.response(1, nil)
+	c.send(`{"jsonrpc":"2.0","id":2,"method":"job.wait","params":{"job_id":"1"}}`)
+
+	var job Job
+	if err := json.Unmarshal(c.response(2, nil).Result, &job); err != nil {
+		t.Fatalf("invalid job: %v", err)
+	}
+	if job.State != JobFailed || job.Error == "" {
+		t.Errorf("job = %+v, want a failed job with an error", job)
+	}
+}
+
+func TestRequestErrors(t *testing.T) {
+	c := newClient(t, newTestServer(t, Limits{}))
+
+	tests := []struct {
+		request string
+		code    int
+	}{
+		{`not json`, codeParseError},
+		{`{"jsonrpc":"1.0","id":1,"method":"version"}`, codeInvalidRequest},
+		{`{"jsonrpc":"2.0","id":1,"method":"render"}`, codeMethodNotFound},
+		{`{"jsonrpc":"2.0","id":1,"method":"convert","params":{}}`, codeInvalidParams},
+		{`{"jsonrpc":"2.0","id":1,"method":"convert","params":{"content":"# Hi"}}`, codeInvalidParams},
+		{`{"jsonrpc":"2.0","id":1,"method":"job.status","params":{"job_id":"42"}}`, codeJobNotFound},
+	}
+	for _, tt := range tests {
+		c.send(tt.request)
+		m := c.next()
+		if m.Error == nil || m.Error.Code != tt.code {
+			t.Errorf("%s: error = %v, want code %d", tt.request, m.Error, tt.code)
+		}
+	}
+}
+
+func TestShutdownRefusesJobs(t *testing.T) {
+	s := newTestServer(t, Limits{})
+	c := newClient(t, s)
+
+	c.send(`{"jsonrpc":"2.0","id":1,"method":"shutdown"}`)
+	if resp := c.response(1, nil); resp.Error != nil {
+		t.Fatalf("shutdown failed: %v", resp.Error)
+	}
+	select {
+	case <-s.Done():
+	case <-time.After(10 * time.Second):
+		t.Fatal("server did not stop")
+	}
+
+	c.send(`{"jsonrpc":"2.0","id":2,"method":"convert","params":{"input":"doc.md"}}`)
+	if resp := c.response(2, nil); resp.Error == nil || resp.Error.Code != codeShuttingDown {
+		t.Errorf("convert after shutdown: error = %v, want code %d", resp.Error, codeShuttingDown)
+	}
+	c.send(`{"jsonrpc":"2.0","id":3,"method":"job.wait","params":{"job_id":"1"}}`)
+	if resp := c.response(3, nil); resp.Error == nil || resp.Error.Code != codeShuttingDown {
+		t.Errorf("job.wait after shutdown: error = %v, want code %d", resp.Error, codeShuttingDown)
+	}
+}
+
+func TestRepliesAfterRequestsClose(t *testing.T) {
+	s, started, release := newBlockingServer(t, Limits{})
+	c := newClient(t, s)
+	output := filepath.Join(t.TempDir(), "doc.pdf")
+
+	// As with requests piped into the stdio daemon: convert, wait, shut down
+	// and close stdin while the job still runs
+	c.send(fmt.Sprintf(`{"jsonrpc":"2.0","id":1,"method":"convert","params":{"content":"# Doc","output":%q}}`, output))
+	c.response(1, nil)
+	waitStarted(t, c, started)
+	c.send(`{"jsonrpc":"2.0","id":2,"method":"job.wait","params":{"job_id":"1"}}`)
+	c.send(`{"jsonrpc":"2.0","id":3,"method":"shutdown"}`)
+	c.response(3, nil)
+	c.closeRequests()
+	time.Sleep(50 * time.Millisecond) // Let the server read the end of the requests
+	close(release)
+
+	var events []ProgressEvent
+	var job Job
+	if err := json.Unmarshal(c.response(2, &events).Result, &job); err != nil || job.State != JobSucceeded {
+		t.Errorf("job.wait result = %+v, %v, want the succeeded job", job, err)
+	}
+	if len(events) != 1 || events[0].State != JobSucceeded {
+		t.Errorf("progress events = %+v, want the succeeded event", events)
+	}
+}
+
+func TestFinishedJobsExpire(t *testing.T) {
+	tests := []struct {
+		name   string
+		limits Limits
+		kept   []bool // Whether job.status finds jobs 1 and 2
+	}{
+		{"oldest beyond the maximum", Limits{MaxFinished: 1}, []bool{false, true}},
+		{"older than the TTL", Limits{FinishedTTL: time.Nanosecond}, []bool{false, false}},
+		{"defaults", Limits{}, []bool{true, true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Job 1 runs and job 2 waits behind it, so they finish in order
+			tt.limits.MaxConcurrent = 1
+			s, started, release := newBlockingServer(t, tt.limits)
+			c := newClient(t, s)
+			convert := `{"jsonrpc":"2.0","id":%d,"method":"convert","params":{"content":"# Doc","output":%q}}`
+			dir := t.TempDir()
+			c.send(fmt.Sprintf(convert, 1, filepath.Join(dir, "1.pdf")))
+			c.response(1, nil)
+			waitStarted(t, c, started)
+			c.send(fmt.Sprintf(convert, 2, filepath.Join(dir, "2.pdf")))
+			c.response(2, nil)
+			// A waiter gets the result even if the job expires right away
+			for id := 1; id <= 2; id++ {
+				c.send(fmt.Sprintf(`{"jsonrpc":"2.0","id":%d,"method":"job.wait","params":{"job_id":"%d"}}`, id+10, id))
+			}
+			c.send(`{"jsonrpc":"2.0","id":19,"method":"version"}`)
+			c.response(19, nil)
+			close(release)
+			for waiting := 2; waiting > 0; {
+				m := c.next()
+				if m.Method == "job.progress" {
+					continue
+				}
+				var job Job
+				if err := json.Unmarshal(m.Result, &job); err != nil || job.State != JobSucceeded {
+					t.Fatalf("job.wait %s = %+v, %v, want a succeeded job", m.ID, job, m.Error)
+				}
+				waiting--
+			}
+			for i, kept := range tt.kept {
+				c.send(fmt.Sprintf(`{"jsonrpc":"2.0","id":%d,"method":"job.status","params":{"job_id":"%d"}}`, i+20, i+1))
+				if resp := c.response(i+20, nil); (resp.Error == nil) != kept {
+					t.Errorf("job %d: status error = %v, want kept %v", i+1, resp.Error, kept)
+				}
+			}
+		})
+	}
+}
+
+func TestMetricsHandler(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "doc.md")
+	if err := os.WriteFile(input, []byte("# Doc\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	s := newTestServer(t, Limits{})
+	c := newClient(t, s)
+	params, _ := json.Marshal(ConvertParams{Input: input, Output: filepath.Join(dir, "doc.pdf")})
+	c.send(fmt.Sprintf(`{"jsonrpc":"2.0","id":1,"method":"convert","params":%s}`, params))
+	c.response(1, nil)
+	c.send(`{"jsonrpc":"2.0","id":2,"method":"job.wait","params":{"job_id":"1"}}`)
+	c.response(2, nil)
+
+	handler := s.MetricsHandler()
+	get := func(path string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, path, nil))
+		return recorder
+	}
+
+	body := get("/metrics").Body.String()
+	for _, want := range []string{
+		`md_to_pdf_jobs_total{result="succeeded"} 1`,
+		`md_to_pdf_jobs_total{result="failed"} 0`,
+		"md_to_pdf_conversions_total 1",
+		`md_to_pdf_job_duration_seconds_bucket{le="+Inf"} 1`,
+		"md_to_pdf_job_duration_seconds_count 1",
+		"md_to_pdf_queue_depth 0",
+		"md_to_pdf_jobs_running 0",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("metrics do not contain %q:\n%s", want, body)
+		}
+	}
+
+	if code := get("/readyz").Code; code != http.StatusOK {
+		t.Errorf("/readyz = %d while running, want 200", code)
+	}
+	s.Shutdown()
+	if code := get("/readyz").Code; code != http.StatusServiceUnavailable {
+		t.Errorf("/readyz = %d while shutting down, want 503", code)
+	}
+}
+
+// newBlockingServer creates a server whose jobs signal started as they begin
+// and then wait until release is closed.
+func newBlockingServer(t *testing.T, limits Limits) (s *Server, started chan struct{}, release chan struct{}) {
+	t.Helper()
+	started, release = make(chan struct{}, 16), make(chan struct{})
+	s = NewServer(func() (*core.Config, error) {
+		started <- struct{}{}
+		<-release
+		cfg := core.DefaultConfig()
+		cfg.Plugins.Enabled = false
+		return cfg, nil
+	}, "test", limits)
+	t.Cleanup(s.Shutdown)
+	return s, started, release
+}
+
+// waitStarted reads the queued and running events of the job submitted
+// last, which the server delivers before converting it, then waits for the
+// job to start.
+func waitStarted(t *testing.T, c *client, started chan struct{}) {
+	t.Helper()
+	for _, state := range []string{JobQueued, JobRunning} {
+		var event ProgressEvent
+		if m := c.next(); m.Method != "job.progress" || json.Unmarshal(m.Params, &event) != nil || event.State != state {
+			t.Fatalf("got %+v, want a %s event", m, state)
+		}
+	}
+	select {
+	case <-started:
+	case <-time.After(10 * time.Second):
+		t.Fatal("job did not start")
+	}
+}
+
+func TestQueueFull(t *testing.T) {
+	s, started, release := newBlockingServer(t, Limits{MaxConcurrent: 1, MaxQueued: 1})
+	c := newClient(t, s)
+	output := filepath.Join(t.TempDir(), "doc.pdf")
+	convert := fmt.Sprintf(`{"jsonrpc":"2.0","id":%%d,"method":"convert","params":{"content":"# Doc","output":%q}}`, output)
+
+	// The first job runs, the second waits in the queue, the third is refused
+	c.send(fmt.Sprintf(convert, 1))
+	c.response(1, nil)
+	waitStarted(t, c, started)
+	c.send(fmt.Sprintf(convert, 2))
+	if resp := c.response(2, nil); resp.Error != nil {
+		t.Fatalf("second job refused: %v", resp.Error)
+	}
+	c.send(fmt.Sprintf(convert, 3))
+	resp := c.response(3, nil)
+	if resp.Error == nil || resp.Error.Code != codeQueueFull {
+		t.Fatalf("third job: error = %v, want code %d", resp.Error, codeQueueFull)
+	}
+	data, _ := resp.Error.Data.(map[string]interface{})
+	if retry, _ := data["retry_after"].(float64); retry < 1 {
+		t.Errorf("error data = %v, want a retry_after of at least 1 second", resp.Error.Data)
+	}
+
+	close(release)
+	c.send(`{"jsonrpc":"2.0","id":4,"method":"job.wait","params":{"job_id":"2"}}`)
+	var job Job
+	if err := json.Unmarshal(c.response(4, nil).Result, &job); err != nil || job.State != JobSucceeded {
+		t.Errorf("queued job = %+v, want it to succeed once the first finished", job)
+	}
+
+	recorder := httptest.NewRecorder()
+	s.MetricsHandler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if want := `md_to_pdf_requests_rejected_total{reason="queue_full"} 1`; !strings.Contains(recorder.Body.String(), want) {
+		t.Errorf("metrics do not contain %q", want)
+	}
+}
+
+func TestMaxConcurrent(t *testing.T) {
+	s, started, release := newBlockingServer(t, Limits{MaxConcurrent: 2})
+	defer close(release)
+	c := newClient(t, s)
+	dir := t.TempDir()
+	for id := 1; id <= 2; id++ {
+		c.send(fmt.Sprintf(`{"jsonrpc":"2.0","id":%d,"method":"convert","params":{"content":"# Doc","output":%q}}`, id, filepath.Join(dir, fmt.Sprintf("%d.pdf", id))))
+		c.response(id, nil)
+		// Both jobs start before either is released
+		waitStarted(t, c, started)
+	}
+}
+
+func TestRequestTooLarge(t *testing.T) {
+	c := newClient(t, newTestServer(t, Limits{MaxRequestSize: 100}))
+
+	c.send(fmt.Sprintf(`{"jsonrpc":"2.0","id":1,"method":"convert","params":{"content":%q,"output":"doc.pdf"}}`, strings.Repeat("x", 200)))
+	if m := c.next(); m.Error == nil || m.Error.Code != codeRequestTooLarge {
+		t.Fatalf("error = %v, want code %d", m.Error, codeRequestTooLarge)
+	}
+
+	// The connection keeps serving requests after the refused one
+	c.send(`{"jsonrpc":"2.0","id":2,"method":"version"}`)
+	if resp := c.response(2, nil); resp.Error != nil {
+		t.Errorf("version failed after a refused request: %v", resp.Error)
+	}
+}
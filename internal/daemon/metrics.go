@@ -0,0 +1,173 @@
+package daemon
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/fredcamaral/md-to-pdf/internal/output"
+)
+
+// durationBuckets are the upper bounds in seconds of the job duration
+// histogram.
+var durationBuckets = []float64{0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60}
+
+// metrics counts the work of the server for the /metrics endpoint.
+type metrics struct {
+	mu          sync.Mutex
+	running     int
+	jobs        map[string]uint64 // Finished jobs by final state
+	conversions uint64            // PDFs written
+	cacheHits   uint64
+	cacheMisses uint64
+	rejections  map[string]uint64 // Requests refused by the limits, by reason
+	buckets     []uint64          // Jobs per duration bucket, not cumulative
+	durationSum float64
+}
+
+// Reasons requests are refused.
+const (
+	rejectedQueueFull = "queue_full"
+	rejectedTooLarge  = "too_large"
+)
+
+func newMetrics() *metrics {
+	return &metrics{
+		jobs:       map[string]uint64{JobSucceeded: 0, JobFailed: 0},
+		rejections: map[string]uint64{rejectedQueueFull: 0, rejectedTooLarge: 0},
+		buckets:    make([]uint64, len(durationBuckets)+1),
+	}
+}
+
+// rejected records a request refused for the given reason.
+func (m *metrics) rejected(reason string) {
+	m.mu.Lock()
+	m.rejections[reason]++
+	m.mu.Unlock()
+}
+
+// averageDuration returns the average duration of the finished jobs, zero
+// before the first one.
+func (m *metrics) averageDuration() time.Duration {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var count uint64
+	for _, n := range m.buckets {
+		count += n
+	}
+	if count == 0 {
+		return 0
+	}
+	return time.Duration(m.durationSum / float64(count) * float64(time.Second))
+}
+
+// started records a job starting to run.
+func (m *metrics) started() {
+	m.mu.Lock()
+	m.running++
+	m.mu.Unlock()
+}
+
+// finished records a finished job with the results of the PDFs it wrote.
+func (m *metrics) finished(state string, duration time.Duration, results []output.ConversionResult) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.running--
+	m.jobs[state]++
+	for _, result := range results {
+		if result.Success {
+			m.conversions++
+		}
+		switch result.Cache {
+		case "hit":
+			m.cacheHits++
+		case "miss":
+			m.cacheMisses++
+		}
+	}
+
+	seconds := duration.Seconds()
+	m.durationSum += seconds
+	bucket := len(durationBuckets)
+	for i, bound := range durationBuckets {
+		if seconds <= bound {
+			bucket = i
+			break
+		}
+	}
+	m.buckets[bucket]++
+}
+
+// write writes the metrics in the Prometheus text format, with the number
+// of queued jobs given by the server.
+func (m *metrics) write(w io.Writer, queued int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	fmt.Fprintln(w, "# HELP md_to_pdf_jobs_total Conversion jobs finished, by result.")
+	fmt.Fprintln(w, "# TYPE md_to_pdf_jobs_total counter")
+	for _, state := range []string{JobSucceeded, JobFailed} {
+		fmt.Fprintf(w, "md_to_pdf_jobs_total{result=%q} %d\n", state, m.jobs[state])
+	}
+
+	fmt.Fprintln(w, "# HELP md_to_pdf_conversions_total PDFs written by finished jobs.")
+	fmt.Fprintln(w, "# TYPE md_to_pdf_conversions_total counter")
+	fmt.Fprintf(w, "md_to_pdf_conversions_total %d\n", m.conversions)
+
+	fmt.Fprintln(w, "# HELP md_to_pdf_job_duration_seconds Time from starting a job to its result.")
+	fmt.Fprintln(w, "# TYPE md_to_pdf_job_duration_seconds histogram")
+	var count uint64
+	for i, bound := range durationBuckets {
+		count += m.buckets[i]
+		fmt.Fprintf(w, "md_to_pdf_job_duration_seconds_bucket{le=%q} %d\n", strconv.FormatFloat(bound, 'g', -1, 64), count)
+	}
+	count += m.buckets[len(durationBuckets)]
+	fmt.Fprintf(w, "md_to_pdf_job_duration_seconds_bucket{le=\"+Inf\"} %d\n", count)
+	fmt.Fprintf(w, "md_to_pdf_job_duration_seconds_sum %s\n", strconv.FormatFloat(m.durationSum, 'g', -1, 64))
+	fmt.Fprintf(w, "md_to_pdf_job_duration_seconds_count %d\n", count)
+
+	fmt.Fprintln(w, "# HELP md_to_pdf_cache_lookups_total Render cache lookups, by result.")
+	fmt.Fprintln(w, "# TYPE md_to_pdf_cache_lookups_total counter")
+	fmt.Fprintf(w, "md_to_pdf_cache_lookups_total{result=\"hit\"} %d\n", m.cacheHits)
+	fmt.Fprintf(w, "md_to_pdf_cache_lookups_total{result=\"miss\"} %d\n", m.cacheMisses)
+
+	fmt.Fprintln(w, "# HELP md_to_pdf_requests_rejected_total Requests refused by the daemon's limits, by reason.")
+	fmt.Fprintln(w, "# TYPE md_to_pdf_requests_rejected_total counter")
+	for _, reason := range []string{rejectedQueueFull, rejectedTooLarge} {
+		fmt.Fprintf(w, "md_to_pdf_requests_rejected_total{reason=%q} %d\n", reason, m.rejections[reason])
+	}
+
+	fmt.Fprintln(w, "# HELP md_to_pdf_queue_depth Jobs waiting to run.")
+	fmt.Fprintln(w, "# TYPE md_to_pdf_queue_depth gauge")
+	fmt.Fprintf(w, "md_to_pdf_queue_depth %d\n", queued)
+
+	fmt.Fprintln(w, "# HELP md_to_pdf_jobs_running Jobs running.")
+	fmt.Fprintln(w, "# TYPE md_to_pdf_jobs_running gauge")
+	fmt.Fprintf(w, "md_to_pdf_jobs_running %d\n", m.running)
+}
+
+// MetricsHandler serves /metrics, the server's counters in the Prometheus
+// text format, and /readyz, which answers 200 while the server accepts jobs
+// and 503 once it is shutting down.
+func (s *Server) MetricsHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		s.metrics.write(w, len(s.queue))
+	})
+	mux.HandleFunc("/readyz", func(w http.ResponseWriter, _ *http.Request) {
+		s.mu.Lock()
+		stopping := s.stopping
+		s.mu.Unlock()
+		if stopping {
+			http.Error(w, "shutting down", http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = io.WriteString(w, "ok\n")
+	})
+	return mux
+}
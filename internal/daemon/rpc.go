@@ -0,0 +1,145 @@
+package daemon
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"sync"
+)
+
+// jsonrpcVersion is the protocol version carried by every message.
+const jsonrpcVersion = "2.0"
+
+// Error codes defined by JSON-RPC 2.0, and the ones of the daemon.
+const (
+	codeParseError      = -32700
+	codeInvalidRequest  = -32600
+	codeMethodNotFound  = -32601
+	codeInvalidParams   = -32602
+	codeInternalError   = -32603
+	codeJobNotFound     = -32001
+	codeQueueFull       = -32002
+	codeShuttingDown    = -32003
+	codeRequestTooLarge = -32004
+)
+
+// errMessageTooLarge is returned by conn.read for a message over the size
+// limit, which was skipped.
+var errMessageTooLarge = errors.New("message too large")
+
+// request is a JSON-RPC request, or a notification when ID is absent.
+type request struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+// response answers a request with either a result or an error.
+type response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  interface{}     `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+// notification is a message from the daemon that expects no answer.
+type notification struct {
+	JSONRPC string      `json:"jsonrpc"`
+	Method  string      `json:"method"`
+	Params  interface{} `json:"params"`
+}
+
+// rpcError is the error object of a failed request.
+type rpcError struct {
+	Code    int         `json:"code"`
+	Message string      `json:"message"`
+	Data    interface{} `json:"data,omitempty"`
+}
+
+func (e *rpcError) Error() string {
+	return e.Message
+}
+
+// conn is one client connection. Messages are JSON objects, one per line.
+// Responses and notifications may be written from several goroutines.
+type conn struct {
+	reader  *bufio.Reader
+	maxSize int // Bytes in one message
+	mu      sync.Mutex
+	writer  io.Writer
+	closed  bool
+	// pending counts the jobs submitted and job.wait requests made on the
+	// connection that have not been reported yet
+	pending sync.WaitGroup
+}
+
+func newConn(r io.Reader, w io.Writer, maxSize int) *conn {
+	return &conn{reader: bufio.NewReaderSize(r, 64*1024), maxSize: maxSize, writer: w}
+}
+
+// read returns the next message, or io.EOF when the client is gone. A
+// message over the size limit is read to its end without being kept, and
+// reported as errMessageTooLarge.
+func (c *conn) read() ([]byte, error) {
+	for {
+		var line []byte
+		tooLarge := false
+		for {
+			chunk, err := c.reader.ReadSlice('\n')
+			if !tooLarge {
+				line = append(line, chunk...)
+				if len(bytes.TrimRight(line, "\r\n")) > c.maxSize {
+					line, tooLarge = nil, true
+				}
+			}
+			if errors.Is(err, bufio.ErrBufferFull) {
+				continue
+			}
+			if err != nil && !errors.Is(err, io.EOF) {
+				return nil, err
+			}
+			if tooLarge {
+				return nil, errMessageTooLarge
+			}
+			if line = bytes.TrimRight(line, "\r\n"); len(line) > 0 {
+				return line, nil
+			}
+			if err != nil {
+				return nil, io.EOF
+			}
+			break
+		}
+	}
+}
+
+// write sends one message. Messages to a closed connection are dropped.
+func (c *conn) write(message interface{}) {
+	data, err := json.Marshal(message)
+	if err != nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return
+	}
+	if _, err := c.writer.Write(append(data, '\n')); err != nil {
+		c.closed = true
+	}
+}
+
+// close stops further writes, so jobs outliving the connection do not
+// write progress events to it.
+func (c *conn) close() {
+	c.mu.Lock()
+	c.closed = true
+	c.mu.Unlock()
+}
+
+// notify sends a notification.
+func (c *conn) notify(method string, params interface{}) {
+	c.write(notification{JSONRPC: jsonrpcVersion, Method: method, Params: params})
+}
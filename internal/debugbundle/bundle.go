@@ -0,0 +1,268 @@
+// Package debugbundle writes the archive attached to bug reports: version
+// and platform details, the effective configuration, the plugins with their
+// checksums and security events, and optionally the input and log files, so
+// a problem can be reproduced without asking for each of them in turn.
+package debugbundle
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"time"
+
+	"github.com/fredcamaral/md-to-pdf/internal/config"
+	"github.com/fredcamaral/md-to-pdf/internal/core"
+	"github.com/fredcamaral/md-to-pdf/internal/plugins"
+	"gopkg.in/yaml.v3"
+)
+
+// root is the directory the files of a bundle are archived under.
+const root = "md-to-pdf-debug"
+
+// MaxLogSize is how much of the end of each log file a bundle keeps.
+const MaxLogSize = 256 * 1024
+
+// VersionInfo identifies the binary a bundle was made with.
+type VersionInfo struct {
+	Version           string `json:"version"`
+	Commit            string `json:"commit"`
+	Built             string `json:"built"`
+	GoVersion         string `json:"go_version"`
+	Platform          string `json:"platform"`
+	ConfigFingerprint string `json:"config_fingerprint"`
+	Created           string `json:"created"`
+}
+
+// Plugin describes one plugin a conversion would load.
+type Plugin struct {
+	Name    string `json:"name,omitempty"`
+	Version string `json:"version,omitempty"`
+	File    string `json:"file,omitempty"`   // Empty for built-in plugins
+	SHA256  string `json:"sha256,omitempty"` // Checksum of File
+	Loaded  bool   `json:"loaded"`
+	Error   string `json:"error,omitempty"`
+}
+
+// Event is a security event of loading a plugin file.
+type Event struct {
+	Time    string `json:"time"`
+	Path    string `json:"path"`
+	Plugin  string `json:"plugin,omitempty"`
+	SHA256  string `json:"sha256,omitempty"`
+	Loaded  bool   `json:"loaded"`
+	Error   string `json:"error,omitempty"`
+	Warning string `json:"warning,omitempty"`
+}
+
+// File is an extra file of a bundle, such as the input or a log.
+type File struct {
+	Name string // Path within the bundle
+	Data []byte
+}
+
+// Bundle is the content of a debug bundle.
+type Bundle struct {
+	Version VersionInfo
+	Config  *config.UserConfig // Effective user configuration, redacted by Write
+	Plugins []Plugin
+	Events  []Event
+	Files   []File
+}
+
+// NewVersionInfo describes this binary, built from the given version, commit
+// and build date, with cfg as its configuration.
+func NewVersionInfo(version, commit, built string, cfg *core.Config) VersionInfo {
+	return VersionInfo{
+		Version:           version,
+		Commit:            commit,
+		Built:             built,
+		GoVersion:         runtime.Version(),
+		Platform:          runtime.GOOS + "/" + runtime.GOARCH,
+		ConfigFingerprint: core.ConfigFingerprint(cfg),
+		Created:           time.Now().UTC().Format(time.RFC3339),
+	}
+}
+
+// PluginsFrom lists the plugins of diagnostics: one entry per plugin file,
+// loaded or not, followed by the built-in plugins.
+func PluginsFrom(diagnostics *core.PluginDiagnostics) []Plugin {
+	infos := make(map[string]plugins.PluginInfo, len(diagnostics.Plugins))
+	for _, info := range diagnostics.Plugins {
+		infos[info.Name] = info
+	}
+
+	var list []Plugin
+	fromFiles := make(map[string]bool)
+	byFile := make(map[string]plugins.PluginLoadEvent)
+	for _, event := range diagnostics.Events {
+		byFile[filepath.Base(event.PluginPath)] = event
+	}
+	files := make([]string, 0, len(diagnostics.Checksums))
+	for file := range diagnostics.Checksums {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+	for _, file := range files {
+		p := Plugin{File: file, SHA256: diagnostics.Checksums[file]}
+		if event, ok := byFile[file]; ok {
+			p.Loaded = event.Success
+			p.Error = event.Error
+			if event.Success {
+				p.Name = event.PluginName
+				p.Version = infos[event.PluginName].Version
+				fromFiles[event.PluginName] = true
+			}
+		} else {
+			p.Error = "not loaded"
+		}
+		list = append(list, p)
+	}
+	for _, info := range diagnostics.Plugins {
+		if !fromFiles[info.Name] {
+			list = append(list, Plugin{Name: info.Name, Version: info.Version, Loaded: true})
+		}
+	}
+	return list
+}
+
+// EventsFrom lists the security events of diagnostics.
+func EventsFrom(diagnostics *core.PluginDiagnostics) []Event {
+	events := make([]Event, 0, len(diagnostics.Events))
+	for _, e := range diagnostics.Events {
+		events = append(events, Event{
+			Time:    e.Timestamp.UTC().Format(time.RFC3339),
+			Path:    e.PluginPath,
+			Plugin:  e.PluginName,
+			SHA256:  e.Checksum,
+			Loaded:  e.Success,
+			Error:   e.Error,
+			Warning: e.SecurityWarning,
+		})
+	}
+	return events
+}
+
+// Write writes b to w as a gzipped tarball. The configuration is redacted
+// first, so credentials in URLs never leave the machine.
+func (b *Bundle) Write(w io.Writer) error {
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+
+	// Empty lists are written as [] rather than null
+	pluginList := b.Plugins
+	if pluginList == nil {
+		pluginList = []Plugin{}
+	}
+	events := b.Events
+	if events == nil {
+		events = []Event{}
+	}
+	files := []struct {
+		name  string
+		value interface{}
+	}{
+		{"version.json", b.Version},
+		{"plugins.json", pluginList},
+		{"security-events.json", events},
+	}
+	modTime := time.Now()
+	for _, f := range files {
+		data, err := json.MarshalIndent(f.value, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode %s: %w", f.name, err)
+		}
+		if err := writeEntry(tw, f.name, append(data, '\n'), modTime); err != nil {
+			return err
+		}
+	}
+
+	settings := b.Config
+	if settings == nil {
+		settings = &config.UserConfig{}
+	}
+	configData, err := yaml.Marshal(RedactConfig(settings))
+	if err != nil {
+		return fmt.Errorf("failed to encode config.yaml: %w", err)
+	}
+	if err := writeEntry(tw, "config.yaml", configData, modTime); err != nil {
+		return err
+	}
+
+	for _, f := range b.Files {
+		if err := writeEntry(tw, f.Name, f.Data, modTime); err != nil {
+			return err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return fmt.Errorf("failed to write bundle: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		return fmt.Errorf("failed to write bundle: %w", err)
+	}
+	return nil
+}
+
+// writeEntry adds a file named name to the bundle.
+func writeEntry(tw *tar.Writer, name string, data []byte, modTime time.Time) error {
+	header := &tar.Header{
+		Name:    path.Join(root, name),
+		Mode:    0o600,
+		Size:    int64(len(data)),
+		ModTime: modTime,
+	}
+	if err := tw.WriteHeader(header); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	if _, err := tw.Write(data); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	return nil
+}
+
+// RedactConfig returns a copy of settings without the user names and
+// passwords of its proxy and repository URLs.
+func RedactConfig(settings *config.UserConfig) *config.UserConfig {
+	redacted := *settings
+	redacted.Proxy = redactURL(settings.Proxy)
+	redacted.SummaryRepoURL = redactURL(settings.SummaryRepoURL)
+	return &redacted
+}
+
+// redactURL replaces the user information of raw, if any.
+func redactURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || u.User == nil {
+		return raw
+	}
+	u.User = url.User("REDACTED")
+	return u.String()
+}
+
+// ReadLogTail reads the last MaxLogSize bytes of the log file at path.
+func ReadLogTail(path string) ([]byte, error) {
+	f, err := os.Open(path) // #nosec G304 - log path is named by the user
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if info.Size() > MaxLogSize {
+		if _, err := f.Seek(info.Size()-MaxLogSize, io.SeekStart); err != nil {
+			return nil, err
+		}
+	}
+	return io.ReadAll(f)
+}
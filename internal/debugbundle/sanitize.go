@@ -0,0 +1,45 @@
+package debugbundle
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/fredcamaral/md-to-pdf/internal/parser"
+)
+
+// SanitizeMarkdown hides the words and numbers of a markdown document while
+// keeping what decides how it renders: letters become x or X and digits 0,
+// so the length of every word and line stays the same, while markdown
+// syntax, whitespace, the languages of code fences and markers such as
+// <!-- toc --> are kept as they are.
+func SanitizeMarkdown(source []byte) []byte {
+	lines := strings.SplitAfter(string(source), "\n")
+	var out strings.Builder
+	out.Grow(len(source))
+	for _, line := range lines {
+		if keepLine(line) {
+			out.WriteString(line)
+			continue
+		}
+		for _, r := range line {
+			switch {
+			case unicode.IsUpper(r):
+				out.WriteByte('X')
+			case unicode.IsLetter(r):
+				out.WriteByte('x')
+			case unicode.IsDigit(r):
+				out.WriteByte('0')
+			default:
+				out.WriteRune(r)
+			}
+		}
+	}
+	return []byte(out.String())
+}
+
+// keepLine reports whether line is kept as it is: a code fence, whose info
+// string names the language, or a marker.
+func keepLine(line string) bool {
+	trimmed := strings.TrimSpace(line)
+	return strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") || parser.IsMarker(trimmed)
+}
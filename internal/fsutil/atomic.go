@@ -0,0 +1,108 @@
+// Package fsutil writes output files so that readers never see them half
+// written.
+package fsutil
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+// WriteFile writes data to path atomically: the data goes to a temporary
+// file in the same directory, which is synced and renamed over path only
+// once complete. A crash or full disk therefore leaves either the previous
+// file or none, never a truncated one. When path is a symlink the file it
+// points to is replaced and the link kept. As with os.WriteFile, the umask
+// applies to perm.
+func WriteFile(path string, data []byte, perm os.FileMode) error {
+	if target, err := filepath.EvalSymlinks(path); err == nil {
+		path = target
+	}
+
+	// The temporary file lives next to path so the rename stays on one
+	// device
+	tmp, err := createTemp(filepath.Dir(path), filepath.Base(path), perm)
+	if err != nil {
+		return err
+	}
+	tmpName := tmp.Name()
+
+	_, err = tmp.Write(data)
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmpName, path)
+		if errors.Is(err, syscall.EXDEV) {
+			// path is a mount point of its own, such as a bind-mounted
+			// file, so it can only be rewritten in place
+			err = copyFile(tmpName, path, perm)
+		}
+	}
+	// The temporary file is gone after a rename, but left after a copy or
+	// an error
+	_ = os.Remove(tmpName)
+	if err != nil {
+		return err
+	}
+
+	syncDir(filepath.Dir(path))
+	return nil
+}
+
+// createTemp creates a new hidden file for base in dir. Unlike
+// os.CreateTemp, which always uses 0600, the file gets perm less the umask.
+func createTemp(dir, base string, perm os.FileMode) (*os.File, error) {
+	for attempt := 0; attempt < 100; attempt++ {
+		name := filepath.Join(dir, fmt.Sprintf(".%s.%d.tmp", base, rand.Uint32())) // #nosec G404 - the name only needs to be unused
+		file, err := os.OpenFile(name, os.O_RDWR|os.O_CREATE|os.O_EXCL, perm)
+		if errors.Is(err, os.ErrExist) {
+			continue
+		}
+		return file, err
+	}
+	return nil, fmt.Errorf("failed to create a temporary file for %s in %s", base, dir)
+}
+
+// copyFile copies src over dst and syncs it.
+func copyFile(src, dst string, perm os.FileMode) error {
+	in, err := os.Open(src) // #nosec G304 - src is the temporary file WriteFile created
+	if err != nil {
+		return err
+	}
+	defer func() { _ = in.Close() }()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm) // #nosec G304 - dst is the output path chosen by the caller
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(out, in)
+	if err == nil {
+		err = out.Sync()
+	}
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to copy %s to %s: %w", src, dst, err)
+	}
+	return nil
+}
+
+// syncDir makes a rename in dir durable. Not every platform can sync a
+// directory, so failures are ignored: the file itself is already synced.
+func syncDir(dir string) {
+	d, err := os.Open(dir) // #nosec G304 - dir holds the file just written
+	if err != nil {
+		return
+	}
+	_ = d.Sync()
+	_ = d.Close()
+}
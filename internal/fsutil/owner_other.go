@@ -0,0 +1,6 @@
+//go:build !unix
+
+package fsutil
+
+// CopyOwner does nothing on platforms without Unix file ownership.
+func CopyOwner(src, dst string) {}
@@ -0,0 +1,26 @@
+//go:build unix
+
+package fsutil
+
+import (
+	"os"
+	"syscall"
+)
+
+// CopyOwner gives dst the owner and group of src, as far as the process
+// may: only root can change the owner, while other users can still set the
+// group when they belong to it. Failures are ignored, so it is safe to call
+// for a best effort.
+func CopyOwner(src, dst string) {
+	info, err := os.Stat(src)
+	if err != nil {
+		return
+	}
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return
+	}
+	if err := os.Chown(dst, int(stat.Uid), int(stat.Gid)); err != nil {
+		_ = os.Chown(dst, -1, int(stat.Gid))
+	}
+}
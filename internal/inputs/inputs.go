@@ -0,0 +1,260 @@
+// Package inputs expands the input arguments of the convert command: glob
+// patterns are matched natively, so they work in shells that leave them
+// unexpanded (Windows cmd), and an order file can set the order explicitly.
+package inputs
+
+import (
+	"bufio"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// IsPattern reports whether arg contains glob syntax.
+func IsPattern(arg string) bool {
+	return strings.ContainsAny(arg, "*?[")
+}
+
+// Expand replaces glob patterns in args with the files they match, in
+// natural order ("ch2.md" before "ch10.md"). Patterns support *, ?, [...]
+// and ** for any number of directories. Other arguments, including "-" for
+// stdin, are kept as given, as are existing files whose names contain glob
+// syntax, such as "notes[1].md". A file matched more than once is listed
+// once.
+func Expand(args []string) ([]string, error) {
+	var files []string
+	seen := make(map[string]bool)
+	add := func(file string) {
+		key := fileKey(file)
+		if !seen[key] {
+			seen[key] = true
+			files = append(files, file)
+		}
+	}
+
+	for _, arg := range args {
+		if !IsPattern(arg) || isFile(arg) {
+			add(arg)
+			continue
+		}
+		matches, err := glob(arg)
+		if err != nil {
+			return nil, fmt.Errorf("invalid glob pattern %q: %w", arg, err)
+		}
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("no files match %q", arg)
+		}
+		sort.SliceStable(matches, func(i, j int) bool { return NaturalLess(matches[i], matches[j]) })
+		for _, match := range matches {
+			add(match)
+		}
+	}
+	return files, nil
+}
+
+// glob matches pattern against regular files. Unlike filepath.Glob, "**"
+// matches any number of directories.
+func glob(pattern string) ([]string, error) {
+	slashed := filepath.ToSlash(pattern)
+	if !strings.Contains(slashed, "**") {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, err
+		}
+		return regularFiles(matches), nil
+	}
+
+	// Walk from the longest directory prefix without glob syntax
+	segments := strings.Split(slashed, "/")
+	root := 0
+	for root < len(segments)-1 && !IsPattern(segments[root]) {
+		root++
+	}
+	base := strings.Join(segments[:root], "/")
+	if base == "" && strings.HasPrefix(slashed, "/") {
+		base = "/"
+	}
+	rest := segments[root:]
+	for _, segment := range rest {
+		if _, err := path.Match(segment, ""); err != nil {
+			return nil, err
+		}
+	}
+
+	walkRoot := filepath.FromSlash(base)
+	if walkRoot == "" {
+		walkRoot = "."
+	}
+	var matches []string
+	err := filepath.WalkDir(walkRoot, func(file string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !entry.Type().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(walkRoot, file)
+		if err != nil {
+			return err
+		}
+		if matchSegments(rest, strings.Split(filepath.ToSlash(rel), "/")) {
+			matches = append(matches, file)
+		}
+		return nil
+	})
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	return matches, nil
+}
+
+// matchSegments matches path segments against pattern segments, where a
+// "**" segment matches zero or more path segments.
+func matchSegments(pattern, segments []string) bool {
+	if len(pattern) == 0 {
+		return len(segments) == 0
+	}
+	if pattern[0] == "**" {
+		for i := 0; i <= len(segments); i++ {
+			if matchSegments(pattern[1:], segments[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(segments) == 0 {
+		return false
+	}
+	ok, _ := path.Match(pattern[0], segments[0])
+	return ok && matchSegments(pattern[1:], segments[1:])
+}
+
+// isFile reports whether name is an existing regular file.
+func isFile(name string) bool {
+	info, err := os.Stat(name)
+	return err == nil && info.Mode().IsRegular()
+}
+
+// regularFiles drops directories and other non-regular files from matches.
+func regularFiles(matches []string) []string {
+	files := matches[:0]
+	for _, match := range matches {
+		if isFile(match) {
+			files = append(files, match)
+		}
+	}
+	return files
+}
+
+// NaturalLess orders strings with embedded numbers by their numeric value,
+// so "ch2" sorts before "ch10". Letters compare case-insensitively, with
+// the exact strings as the tie-breaker to keep the order deterministic.
+func NaturalLess(a, b string) bool {
+	ra, rb := []rune(a), []rune(b)
+	i, j := 0, 0
+	for i < len(ra) && j < len(rb) {
+		if unicode.IsDigit(ra[i]) && unicode.IsDigit(rb[j]) {
+			si, sj := i, j
+			for i < len(ra) && unicode.IsDigit(ra[i]) {
+				i++
+			}
+			for j < len(rb) && unicode.IsDigit(rb[j]) {
+				j++
+			}
+			na := strings.TrimLeft(string(ra[si:i]), "0")
+			nb := strings.TrimLeft(string(rb[sj:j]), "0")
+			if len(na) != len(nb) {
+				return len(na) < len(nb)
+			}
+			if na != nb {
+				return na < nb
+			}
+			continue
+		}
+		ca, cb := unicode.ToLower(ra[i]), unicode.ToLower(rb[j])
+		if ca != cb {
+			return ca < cb
+		}
+		i++
+		j++
+	}
+	if len(ra)-i != len(rb)-j {
+		return len(ra)-i < len(rb)-j
+	}
+	return a < b
+}
+
+// ReadOrderFile reads an order file: one input file per line, relative to
+// the directory of the order file. Blank lines and lines starting with #
+// are skipped.
+func ReadOrderFile(orderPath string) ([]string, error) {
+	file, err := os.Open(orderPath) // #nosec G304 - order file path comes from user CLI input
+	if err != nil {
+		return nil, fmt.Errorf("failed to read order file: %w", err)
+	}
+	defer func() { _ = file.Close() }()
+
+	dir := filepath.Dir(orderPath)
+	var files []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if !filepath.IsAbs(line) {
+			line = filepath.Join(dir, line)
+		}
+		files = append(files, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read order file: %w", err)
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("order file %s lists no files", orderPath)
+	}
+	return files, nil
+}
+
+// ApplyOrder puts the files listed in order first, in that order, followed
+// by the remaining files in their current order. Listing a file that is
+// not among files is an error, as it is usually a typo.
+func ApplyOrder(files, order []string) ([]string, error) {
+	byKey := make(map[string]string, len(files))
+	for _, file := range files {
+		byKey[fileKey(file)] = file
+	}
+
+	ordered := make([]string, 0, len(files))
+	placed := make(map[string]bool, len(order))
+	for _, file := range order {
+		key := fileKey(file)
+		original, ok := byKey[key]
+		if !ok {
+			return nil, fmt.Errorf("%s is listed in the order file but is not among the inputs", file)
+		}
+		if !placed[key] {
+			placed[key] = true
+			ordered = append(ordered, original)
+		}
+	}
+	for _, file := range files {
+		if !placed[fileKey(file)] {
+			ordered = append(ordered, file)
+		}
+	}
+	return ordered, nil
+}
+
+// fileKey identifies a file independently of how its path is spelled.
+func fileKey(file string) string {
+	if abs, err := filepath.Abs(file); err == nil {
+		return abs
+	}
+	return filepath.Clean(file)
+}
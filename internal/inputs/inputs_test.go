@@ -0,0 +1,154 @@
+package inputs
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// chdirTemp creates files in a temporary directory and makes it the
+// working directory for the test.
+func chdirTemp(t *testing.T, files ...string) {
+	t.Helper()
+	dir := t.TempDir()
+	for _, file := range files {
+		path := filepath.Join(dir, filepath.FromSlash(file))
+		if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("# "+file), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = os.Chdir(wd) })
+}
+
+func slashed(files []string) []string {
+	out := make([]string, len(files))
+	for i, file := range files {
+		out[i] = filepath.ToSlash(file)
+	}
+	return out
+}
+
+func TestNaturalLess(t *testing.T) {
+	names := []string{"ch10.md", "Ch2.md", "ch1.md", "appendix.md", "ch02b.md", "ch2a.md", "ch1.md.bak"}
+	sort.Slice(names, func(i, j int) bool { return NaturalLess(names[i], names[j]) })
+
+	want := []string{"appendix.md", "ch1.md", "ch1.md.bak", "Ch2.md", "ch2a.md", "ch02b.md", "ch10.md"}
+	if !reflect.DeepEqual(names, want) {
+		t.Errorf("natural order = %v, want %v", names, want)
+	}
+}
+
+func TestExpand(t *testing.T) {
+	chdirTemp(t, "chapters/ch10.md", "chapters/ch2.md", "chapters/ch1.md", "chapters/notes.txt",
+		"chapters/part2/ch3.md", "intro.md")
+
+	got, err := Expand([]string{"intro.md", "chapters/*.md", "chapters/ch1.md", "-"})
+	if err != nil {
+		t.Fatalf("Expand failed: %v", err)
+	}
+	want := []string{"intro.md", "chapters/ch1.md", "chapters/ch2.md", "chapters/ch10.md", "-"}
+	if !reflect.DeepEqual(slashed(got), want) {
+		t.Errorf("Expand = %v, want %v", slashed(got), want)
+	}
+
+	got, err = Expand([]string{"**/ch?.md"})
+	if err != nil {
+		t.Fatalf("Expand failed: %v", err)
+	}
+	want = []string{"chapters/ch1.md", "chapters/ch2.md", "chapters/part2/ch3.md"}
+	if !reflect.DeepEqual(slashed(got), want) {
+		t.Errorf("Expand(**) = %v, want %v", slashed(got), want)
+	}
+
+	got, err = Expand([]string{"chapters/**/*.md"})
+	if err != nil {
+		t.Fatalf("Expand failed: %v", err)
+	}
+	if len(got) != 4 {
+		t.Errorf("chapters/**/*.md should match 4 files, got %v", got)
+	}
+}
+
+func TestExpand_LiteralNames(t *testing.T) {
+	chdirTemp(t, "notes[1].md", "notes1.md", "draft?.md")
+
+	got, err := Expand([]string{"notes[1].md", "draft?.md", "notes[0-9].md"})
+	if err != nil {
+		t.Fatalf("Expand failed: %v", err)
+	}
+	// Existing files are used as named; other arguments are still patterns
+	want := []string{"notes[1].md", "draft?.md", "notes1.md"}
+	if !reflect.DeepEqual(slashed(got), want) {
+		t.Errorf("Expand = %v, want %v", slashed(got), want)
+	}
+}
+
+func TestExpand_Errors(t *testing.T) {
+	chdirTemp(t, "a.md")
+
+	if _, err := Expand([]string{"missing/*.md"}); err == nil || !strings.Contains(err.Error(), "no files match") {
+		t.Errorf("expected no match error, got %v", err)
+	}
+	if _, err := Expand([]string{"[a.md"}); err == nil || !strings.Contains(err.Error(), "invalid glob pattern") {
+		t.Errorf("expected invalid pattern error, got %v", err)
+	}
+	if _, err := Expand([]string{"**/[a.md"}); err == nil || !strings.Contains(err.Error(), "invalid glob pattern") {
+		t.Errorf("expected invalid pattern error, got %v", err)
+	}
+}
+
+func TestReadOrderFile(t *testing.T) {
+	chdirTemp(t, "book/intro.md", "book/setup.md")
+	if err := os.WriteFile("book/ORDER", []byte("# Reading order\nsetup.md\n\n  intro.md  \n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := ReadOrderFile("book/ORDER")
+	if err != nil {
+		t.Fatalf("ReadOrderFile failed: %v", err)
+	}
+	want := []string{"book/setup.md", "book/intro.md"}
+	if !reflect.DeepEqual(slashed(got), want) {
+		t.Errorf("ReadOrderFile = %v, want %v", slashed(got), want)
+	}
+
+	if err := os.WriteFile("EMPTY", []byte("# nothing\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReadOrderFile("EMPTY"); err == nil || !strings.Contains(err.Error(), "lists no files") {
+		t.Errorf("expected empty order file error, got %v", err)
+	}
+	if _, err := ReadOrderFile("MISSING"); err == nil {
+		t.Error("expected error for a missing order file")
+	}
+}
+
+func TestApplyOrder(t *testing.T) {
+	files := []string{"a.md", "b.md", "c.md", "d.md"}
+
+	got, err := ApplyOrder(files, []string{"./c.md", "a.md"})
+	if err != nil {
+		t.Fatalf("ApplyOrder failed: %v", err)
+	}
+	want := []string{"c.md", "a.md", "b.md", "d.md"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ApplyOrder = %v, want %v", got, want)
+	}
+
+	if _, err := ApplyOrder(files, []string{"e.md"}); err == nil || !strings.Contains(err.Error(), "not among the inputs") {
+		t.Errorf("expected unknown file error, got %v", err)
+	}
+}
@@ -0,0 +1,331 @@
+package integration
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/fredcamaral/md-to-pdf/internal/core"
+	"github.com/fredcamaral/md-to-pdf/internal/outline"
+)
+
+// heading is an entry of the exported heading outline. The renderer writes
+// no PDF bookmarks (/Outlines), so headings and their pages are checked
+// through the outline it exports instead.
+type heading struct {
+	Level int
+	Title string
+	Page  int
+}
+
+// documentCase is a document of testdata converted with a configuration,
+// and what its PDF must show.
+type documentCase struct {
+	name      string
+	source    string
+	configure func(*core.Config)
+	// pages holds, for each page, text that must appear on it in this order
+	pages [][]string
+	// absent is text that must not appear anywhere
+	absent   []string
+	metadata map[string]string
+	headings []heading
+	// links holds the link annotations of each page, with consecutive
+	// duplicates (one link drawn in several pieces) merged
+	links [][]link
+}
+
+var documentCases = []documentCase{
+	{
+		name:   "report",
+		source: "report.md",
+		configure: func(c *core.Config) {
+			c.Document.Title = "Quarterly Report"
+			c.Document.Author = "Finance"
+			c.Document.Subject = "Q3 results"
+		},
+		pages: [][]string{
+			{
+				"Quarterly Report",
+				"Revenue grew in every region and most product lines.",
+				"Highlights",
+				"* Shipped the mobile app",
+				"* Opened offices in Lisbon and Osaka",
+				"1. Hire support staff",
+				"2. Expand the partner program",
+				"Numbers never lie, but they do exaggerate.",
+				"— The finance team",
+				"The deal was closed by  for .",
+				"Contact investor relations for details.",
+			},
+			{
+				"Outlook",
+				"Café owners expect a “strong” season — and so do we.",
+				`fmt.Println("forecast")`,
+				"Prepared for the board.",
+			},
+		},
+		absent: []string{
+			"Jane Smith", "$4.2 million", // Redacted
+			"preliminary",   // Comment
+			"Internal only", // Excluded conditional block
+			"**", "||", "<!--",
+		},
+		metadata: map[string]string{
+			"Title":   "Quarterly Report",
+			"Author":  "Finance",
+			"Subject": "Q3 results",
+		},
+		headings: []heading{
+			{1, "Quarterly Report", 1},
+			{2, "Highlights", 1},
+			{2, "Outlook", 2},
+		},
+		links: [][]link{
+			{{URI: "https://example.com/q3?view=full"}, {Page: 2}},
+			nil,
+		},
+	},
+	{
+		name:   "report with define",
+		source: "report.md",
+		configure: func(c *core.Config) {
+			c.Parser.Defines = []string{"internal"}
+			c.Document.TitleFromH1 = true
+		},
+		pages: [][]string{
+			{"Revenue grew", "Highlights", "Internal only: margins are under review."},
+			{"Outlook"},
+		},
+		absent:   []string{"Contact investor relations", "Jane Smith"},
+		metadata: map[string]string{"Title": "Quarterly Report"},
+		headings: []heading{
+			{2, "Highlights", 1},
+			{2, "Outlook", 2},
+		},
+		links: [][]link{
+			{{URI: "https://example.com/q3?view=full"}, {Page: 2}},
+			nil,
+		},
+	},
+	{
+		name:   "manual with table of contents",
+		source: "manual.md",
+		configure: func(c *core.Config) {
+			c.Renderer.TOC.Enabled = true
+			c.Document.Title = "Manual"
+		},
+		pages: [][]string{
+			{"Contents", "Installation", "Requirements", "Usage", "Options", "Troubleshooting", "Options"},
+			{"Installation", "Download the release for your platform, then read Usage.", "Requirements"},
+			{"Usage", "See Installation first.", "Options"},
+			{"Troubleshooting", "Options", "This heading repeats an earlier one."},
+		},
+		metadata: map[string]string{"Title": "Manual"},
+		headings: []heading{
+			{1, "Installation", 2},
+			{2, "Requirements", 2},
+			{1, "Usage", 3},
+			{2, "Options", 3},
+			{1, "Troubleshooting", 4},
+			{2, "Options", 4},
+		},
+		links: [][]link{
+			{{Page: 2}, {Page: 3}, {Page: 4}},
+			{{Page: 3}},
+			{{Page: 2}},
+			{{Page: 3}},
+		},
+	},
+}
+
+func TestConvert(t *testing.T) {
+	if testing.Short() {
+		t.Skip("integration test")
+	}
+	for _, tt := range documentCases {
+		t.Run(tt.name, func(t *testing.T) {
+			inspection, headings := convert(t, tt.source, tt.configure)
+
+			if len(inspection.Pages) != len(tt.pages) {
+				t.Fatalf("got %d pages, want %d:\n%s", len(inspection.Pages), len(tt.pages), inspection.Text())
+			}
+			for i, want := range tt.pages {
+				checkOrder(t, i+1, inspection.Pages[i].Text, want)
+			}
+			text := inspection.Text()
+			for _, absent := range tt.absent {
+				if strings.Contains(text, absent) {
+					t.Errorf("%q should not be in the PDF", absent)
+				}
+			}
+
+			for key, want := range tt.metadata {
+				if got := inspection.Metadata[key]; got != want {
+					t.Errorf("metadata %s = %q, want %q", key, got, want)
+				}
+			}
+
+			if !reflect.DeepEqual(headings, tt.headings) {
+				t.Errorf("headings = %+v, want %+v", headings, tt.headings)
+			}
+			for _, h := range headings {
+				if !hasLine(inspection.Pages[h.Page-1].Text, h.Title) {
+					t.Errorf("heading %q is not on page %d", h.Title, h.Page)
+				}
+			}
+
+			for i, want := range tt.links {
+				if got := mergeLinks(inspection.Pages[i].Links); !reflect.DeepEqual(got, want) {
+					t.Errorf("page %d links = %+v, want %+v", i+1, got, want)
+				}
+			}
+		})
+	}
+}
+
+// TestConvert_TableOfContentsPages checks that every entry of the table of
+// contents shows the page its heading is on.
+func TestConvert_TableOfContentsPages(t *testing.T) {
+	if testing.Short() {
+		t.Skip("integration test")
+	}
+	inspection, headings := convert(t, "manual.md", func(c *core.Config) {
+		c.Renderer.TOC.Enabled = true
+	})
+
+	entries := strings.Split(inspection.Pages[0].Text, "\n")[1:] // After the title
+	if len(entries) != len(headings) {
+		t.Fatalf("table of contents has %d entries, want %d:\n%s", len(entries), len(headings), inspection.Pages[0].Text)
+	}
+	for i, h := range headings {
+		title, page := splitEntry(entries[i])
+		if title != h.Title || page != h.Page {
+			t.Errorf("entry %d = %q page %d, want %q page %d", i+1, title, page, h.Title, h.Page)
+		}
+	}
+}
+
+// TestConvert_PostProcessing checks that rewriting the PDF for fast web
+// view keeps what readers see.
+func TestConvert_PostProcessing(t *testing.T) {
+	if testing.Short() {
+		t.Skip("integration test")
+	}
+	plain, _ := convert(t, "manual.md", func(c *core.Config) {
+		c.Renderer.TOC.Enabled = true
+	})
+	linearized, _ := convert(t, "manual.md", func(c *core.Config) {
+		c.Renderer.TOC.Enabled = true
+		c.Output.Linearize = true
+	})
+	if !reflect.DeepEqual(linearized.Pages, plain.Pages) {
+		t.Errorf("linearized pages differ:\n%+v\nwant:\n%+v", linearized.Pages, plain.Pages)
+	}
+}
+
+// convert converts a document of testdata and returns the inspection of the
+// PDF and the headings of its exported outline, flattened in document
+// order.
+func convert(t *testing.T, source string, configure func(*core.Config)) (*inspection, []heading) {
+	t.Helper()
+	dir := t.TempDir()
+	config := core.DefaultConfig()
+	config.Plugins.Enabled = false
+	config.Output.OutlinePath = filepath.Join(dir, "outline.json")
+	if configure != nil {
+		configure(config)
+	}
+
+	engine, err := core.NewEngine(config)
+	if err != nil {
+		t.Fatalf("Failed to create engine: %v", err)
+	}
+	defer engine.Close()
+	engine.SetWarningHandler(func(string, string) {})
+
+	output := filepath.Join(dir, "out.pdf")
+	opts := core.ConversionOptions{InputFiles: []string{filepath.Join("testdata", source)}, OutputPath: output}
+	if err := engine.Convert(opts); err != nil {
+		t.Fatalf("Conversion failed: %v", err)
+	}
+
+	data, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	inspection, err := inspect(data)
+	if err != nil {
+		t.Fatalf("inspect failed: %v", err)
+	}
+
+	content, err := os.ReadFile(config.Output.OutlinePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc outline.Document
+	if err := json.Unmarshal(content, &doc); err != nil {
+		t.Fatalf("invalid outline: %v", err)
+	}
+	var headings []heading
+	var flatten func(nodes []*outline.Node)
+	flatten = func(nodes []*outline.Node) {
+		for _, node := range nodes {
+			headings = append(headings, heading{node.Level, node.Title, node.Page})
+			flatten(node.Children)
+		}
+	}
+	flatten(doc.Headings)
+	return inspection, headings
+}
+
+// checkOrder reports the strings of want that are not in text after the
+// string before them.
+func checkOrder(t *testing.T, page int, text string, want []string) {
+	t.Helper()
+	rest := text
+	for _, s := range want {
+		i := strings.Index(rest, s)
+		if i < 0 {
+			t.Errorf("page %d: %q not found in order in:\n%s", page, s, text)
+			return
+		}
+		rest = rest[i+len(s):]
+	}
+}
+
+// hasLine reports whether text has a line that is s, ignoring surrounding
+// spaces.
+func hasLine(text, s string) bool {
+	for _, line := range strings.Split(text, "\n") {
+		if strings.TrimSpace(line) == s {
+			return true
+		}
+	}
+	return false
+}
+
+// splitEntry splits a table of contents line such as "Usage . . . .3" into
+// its title and page number.
+func splitEntry(line string) (string, int) {
+	end := strings.TrimRight(line, " ")
+	digits := len(end) - len(strings.TrimRight(end, "0123456789"))
+	page, _ := strconv.Atoi(end[len(end)-digits:])
+	title := strings.TrimRight(end[:len(end)-digits], " .")
+	return title, page
+}
+
+// mergeLinks drops links equal to the one before them.
+func mergeLinks(links []link) []link {
+	var merged []link
+	for _, link := range links {
+		if len(merged) == 0 || merged[len(merged)-1] != link {
+			merged = append(merged, link)
+		}
+	}
+	return merged
+}
@@ -0,0 +1,12 @@
+// Package integration holds end-to-end tests of the conversion pipeline. They
+// convert the representative documents in testdata with the core engine and
+// check what a reader of the resulting PDFs sees: the text of each page, the
+// page count, the document information and the link annotations, read back
+// with a PDF reader of their own. Headings are checked against the exported
+// heading outline, since the renderer writes no PDF bookmarks. Run them
+// alone with:
+//
+//	go test ./internal/integration
+//
+// They are skipped in -short mode.
+package integration
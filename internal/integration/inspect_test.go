@@ -0,0 +1,889 @@
+package integration
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/jung-kurt/gofpdf"
+)
+
+func TestInspect(t *testing.T) {
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.SetTitle("Inspected", true)
+	pdf.SetAuthor("Ann (QA)", false)
+	pdf.SetFont("Arial", "", 12)
+	translate := pdf.UnicodeTranslatorFromDescriptor("")
+
+	second := pdf.AddLink()
+	pdf.AddPage()
+	pdf.Write(6, "Plain text with ")
+	pdf.SetFont("Arial", "B", 12)
+	pdf.Write(6, "bold")
+	pdf.SetFont("Arial", "", 12)
+	pdf.Write(6, " (and parentheses)")
+	pdf.Ln(8)
+	pdf.Write(6, translate("Café — “quotes”"))
+	pdf.Ln(8)
+	pdf.WriteLinkString(6, "Website", "https://example.org/a?b=(c)")
+	pdf.Ln(8)
+	pdf.WriteLinkID(6, "Next page", second)
+
+	pdf.AddPage()
+	pdf.SetLink(second, 0, -1)
+	pdf.Write(6, "Second page")
+	pdf.AddPage()
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		t.Fatalf("failed to render PDF: %v", err)
+	}
+
+	inspection, err := inspect(buf.Bytes())
+	if err != nil {
+		t.Fatalf("inspect failed: %v", err)
+	}
+	if len(inspection.Pages) != 3 {
+		t.Fatalf("got %d pages, want 3", len(inspection.Pages))
+	}
+
+	wantText := "Plain text with bold (and parentheses)\nCafé — “quotes”\nWebsite\nNext page"
+	if got := inspection.Pages[0].Text; got != wantText {
+		t.Errorf("page 1 text = %q, want %q", got, wantText)
+	}
+	if got := inspection.Text(); !strings.Contains(got, "Next page\fSecond page\f") {
+		t.Errorf("pages should be separated by form feeds, got %q", got)
+	}
+
+	wantLinks := []link{{URI: "https://example.org/a?b=(c)"}, {Page: 2}}
+	if got := inspection.Pages[0].Links; !reflect.DeepEqual(got, wantLinks) {
+		t.Errorf("page 1 links = %+v, want %+v", got, wantLinks)
+	}
+
+	if inspection.Metadata["Title"] != "Inspected" || inspection.Metadata["Author"] != "Ann (QA)" {
+		t.Errorf("metadata = %v", inspection.Metadata)
+	}
+}
+
+func TestInspect_TextOperators(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{"relative moves", "BT 10 700 Td (first) Tj 0 -14 Td (second) Tj ( run) Tj ET", "first\nsecond run"},
+		{"text matrix", "BT 1 0 0 1 10 700 Tm (a) Tj 1 0 0 1 40 700 Tm (b) Tj 1 0 0 1 10 680 Tm (c) Tj ET", "ab\nc"},
+		{"TJ word gap", "BT 10 700 Td [(Wo) 20 (rd) -300 (gap)] TJ ET", "Word gap"},
+		{"next line", "BT 14 TL 10 700 Td (one) Tj T* (two) Tj (three) ' ET", "one\ntwo\nthree"},
+		{"hex string", "BT 10 700 Td <48 69 2> Tj ET", "Hi "},
+		{"escapes", `BT 10 700 Td (a\(b\)\\c\101) Tj ET`, `a(b)\cA`},
+		{"comments", "% (not text) Tj\nBT 10 700 Td (text) Tj ET", "text"},
+		{"graphics", "0 0 10 10 re f BT /F1 12 Tf 10 700 Td (text) Tj ET", "text"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := contentText([]byte(tt.content), nil)
+			if err != nil || got != tt.want {
+				t.Errorf("contentText() = %q, %v, want %q", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestInspect_InvalidInput(t *testing.T) {
+	if _, err := inspect([]byte("not a pdf")); err == nil {
+		t.Error("expected an error for invalid input")
+	}
+
+	// Objects moved away from their cross-reference offsets are not found
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.AddPage()
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		t.Fatalf("failed to render PDF: %v", err)
+	}
+	shifted := bytes.Replace(buf.Bytes(), []byte("\n1 0 obj"), []byte("\n\n1 0 obj"), 1)
+	if _, err := inspect(shifted); err == nil || !strings.Contains(err.Error(), "not at offset") {
+		t.Errorf("expected an error for a wrong offset, got %v", err)
+	}
+}
+
+// inspection is what a reader of a PDF sees: the text and links of each
+// page and the document information. The renderer writes no /Outlines, so
+// there are no bookmarks to read; the tests check headings through the
+// exported heading outline instead.
+type inspection struct {
+	Pages []pageContent
+	// Metadata holds the document information entries, such as Title and
+	// Author, keyed without their slash
+	Metadata map[string]string
+}
+
+// pageContent is the text and links of one page.
+type pageContent struct {
+	// Text holds the page's text runs in drawing order, one line for each
+	// baseline
+	Text  string
+	Links []link
+}
+
+// link is a link annotation: a URI, or a page in the same document.
+type link struct {
+	URI  string
+	Page int // 1-based target page of internal links (0 = URI link)
+}
+
+// Text returns the text of all pages, separated by form feeds.
+func (i *inspection) Text() string {
+	texts := make([]string, len(i.Pages))
+	for n, p := range i.Pages {
+		texts[n] = p.Text
+	}
+	return strings.Join(texts, "\f")
+}
+
+// inspect reads the pages, links and document information of a PDF. It
+// shares no code with the packages that write and rewrite PDFs: objects are
+// located through the cross-reference table, as a viewer does, so broken
+// offsets fail the tests, and are parsed into values rather than matched
+// with patterns. Text is read from the Tj, TJ, ' and " operators of each
+// page; text in form XObjects is not included.
+func inspect(data []byte) (*inspection, error) {
+	file, err := openPDF(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read PDF: %w", err)
+	}
+	pages, err := file.pages()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read PDF: %w", err)
+	}
+
+	pageIndex := make(map[int]int, len(pages))
+	for i, p := range pages {
+		pageIndex[p.num] = i + 1
+	}
+
+	result := &inspection{Metadata: make(map[string]string)}
+	if info, ok := file.resolve(file.trailer["Info"]).(pdfDict); ok {
+		for key, value := range info {
+			if raw, ok := file.resolve(value).([]byte); ok {
+				result.Metadata[string(key)] = decodeTextString(raw)
+			}
+		}
+	}
+	for _, p := range pages {
+		content, err := file.pageContents(p.dict)
+		if err != nil {
+			return nil, fmt.Errorf("page %d: %w", pageIndex[p.num], err)
+		}
+		text, err := contentText(content, file.wideFonts(p.resources))
+		if err != nil {
+			return nil, fmt.Errorf("page %d: %w", pageIndex[p.num], err)
+		}
+		result.Pages = append(result.Pages, pageContent{
+			Text:  text,
+			Links: file.pageLinks(p.dict, pageIndex),
+		})
+	}
+	return result, nil
+}
+
+// PDF values: names, references, dictionaries and streams. Strings are
+// []byte, numbers float64, arrays []any, and null is nil.
+type (
+	pdfName string
+	pdfRef  int // Object number; generations other than 0 are not used
+	pdfDict map[pdfName]any
+	// pdfKeyword is an operator or other bare word, such as "Tj"
+	pdfKeyword string
+)
+
+type pdfStream struct {
+	dict pdfDict
+	data []byte
+}
+
+// pdfFile is a PDF whose objects are loaded on demand.
+type pdfFile struct {
+	data    []byte
+	offsets map[int]int
+	trailer pdfDict
+	objects map[int]any
+}
+
+// openPDF reads the cross-reference sections of data, from the last one
+// back through their /Prev entries.
+func openPDF(data []byte) (*pdfFile, error) {
+	at := bytes.LastIndex(data, []byte("startxref"))
+	if at < 0 {
+		return nil, errors.New("no startxref")
+	}
+	l := &pdfLexer{data: data, pos: at + len("startxref")}
+	value, err := l.next()
+	offset, ok := value.(float64)
+	if err != nil || !ok {
+		return nil, errors.New("invalid startxref")
+	}
+
+	file := &pdfFile{data: data, offsets: make(map[int]int), objects: make(map[int]any)}
+	seen := make(map[int]bool)
+	for pos := int(offset); ; {
+		if seen[pos] || pos < 0 || pos >= len(data) {
+			return nil, fmt.Errorf("invalid cross-reference offset %d", pos)
+		}
+		seen[pos] = true
+		trailer, err := file.readXref(pos)
+		if err != nil {
+			return nil, err
+		}
+		if file.trailer == nil {
+			file.trailer = trailer
+		}
+		prev, ok := trailer["Prev"].(float64)
+		if !ok {
+			break
+		}
+		pos = int(prev)
+	}
+	return file, nil
+}
+
+// readXref reads the cross-reference section at pos and returns its
+// trailer. Entries of later sections, read first, take precedence.
+func (f *pdfFile) readXref(pos int) (pdfDict, error) {
+	l := &pdfLexer{data: f.data, pos: pos}
+	if value, err := l.next(); err != nil || value != pdfKeyword("xref") {
+		return nil, fmt.Errorf("no cross-reference table at %d", pos)
+	}
+	for {
+		value, err := l.next()
+		if err != nil {
+			return nil, fmt.Errorf("invalid cross-reference table: %w", err)
+		}
+		if value == pdfKeyword("trailer") {
+			break
+		}
+		first, ok := value.(float64)
+		countValue, err := l.next()
+		count, countOK := countValue.(float64)
+		if !ok || !countOK || err != nil {
+			return nil, errors.New("invalid cross-reference subsection")
+		}
+		for i := 0; i < int(count); i++ {
+			offset, _ := l.next()
+			_, _ = l.next() // Generation
+			kind, err := l.next()
+			if err != nil {
+				return nil, errors.New("truncated cross-reference table")
+			}
+			num := int(first) + i
+			if _, known := f.offsets[num]; !known && kind == pdfKeyword("n") {
+				offsetValue, _ := offset.(float64)
+				f.offsets[num] = int(offsetValue)
+			}
+		}
+	}
+	value, err := l.next()
+	trailer, ok := value.(pdfDict)
+	if err != nil || !ok {
+		return nil, errors.New("invalid trailer")
+	}
+	return trailer, nil
+}
+
+// object returns the indirect object num, reading it at the offset the
+// cross-reference table gives.
+func (f *pdfFile) object(num int) (any, error) {
+	if value, ok := f.objects[num]; ok {
+		return value, nil
+	}
+	offset, ok := f.offsets[num]
+	if !ok || offset < 0 || offset >= len(f.data) {
+		return nil, fmt.Errorf("object %d is not in the cross-reference table", num)
+	}
+	f.objects[num] = nil // A reference to itself resolves to null
+
+	l := &pdfLexer{data: f.data, pos: offset}
+	header := make([]any, 3)
+	for i := range header {
+		header[i], _ = l.next()
+	}
+	if !bytes.HasPrefix(f.data[offset:], []byte(strconv.Itoa(num)+" ")) || header[2] != pdfKeyword("obj") {
+		return nil, fmt.Errorf("object %d is not at offset %d", num, offset)
+	}
+	value, err := l.next()
+	if err != nil {
+		return nil, fmt.Errorf("object %d: %w", num, err)
+	}
+
+	if dict, ok := value.(pdfDict); ok {
+		l.skipSpace()
+		if bytes.HasPrefix(f.data[l.pos:], []byte("stream")) {
+			start := l.pos + len("stream")
+			if start < len(f.data) && f.data[start] == '\r' {
+				start++
+			}
+			if start < len(f.data) && f.data[start] == '\n' {
+				start++
+			}
+			length, ok := f.resolve(dict["Length"]).(float64)
+			end := start + int(length)
+			if !ok || length < 0 || end > len(f.data) {
+				return nil, fmt.Errorf("object %d: invalid stream length", num)
+			}
+			value = &pdfStream{dict: dict, data: f.data[start:end]}
+		}
+	}
+	f.objects[num] = value
+	return value, nil
+}
+
+// resolve returns value, or the object it refers to. Objects that cannot be
+// read resolve to null.
+func (f *pdfFile) resolve(value any) any {
+	for depth := 0; depth < 8; depth++ {
+		ref, ok := value.(pdfRef)
+		if !ok {
+			return value
+		}
+		value, _ = f.object(int(ref))
+	}
+	return nil
+}
+
+// pdfPage is a leaf of the page tree with its inherited resources.
+type pdfPage struct {
+	num       int
+	dict      pdfDict
+	resources pdfDict
+}
+
+// pages returns the pages in document order.
+func (f *pdfFile) pages() ([]pdfPage, error) {
+	rootRef, ok := f.trailer["Root"].(pdfRef)
+	if !ok {
+		return nil, errors.New("no document catalog")
+	}
+	rootObject, err := f.object(int(rootRef))
+	if err != nil {
+		return nil, err
+	}
+	catalog, ok := rootObject.(pdfDict)
+	if !ok {
+		return nil, errors.New("document catalog is not a dictionary")
+	}
+	root, ok := catalog["Pages"].(pdfRef)
+	if !ok {
+		return nil, errors.New("no page tree")
+	}
+
+	var pages []pdfPage
+	seen := make(map[pdfRef]bool)
+	var walk func(ref pdfRef, resources pdfDict) error
+	walk = func(ref pdfRef, resources pdfDict) error {
+		if seen[ref] {
+			return fmt.Errorf("page tree visits object %d twice", ref)
+		}
+		seen[ref] = true
+		node, ok := f.resolve(ref).(pdfDict)
+		if !ok {
+			return fmt.Errorf("page tree node %d is not a dictionary", ref)
+		}
+		if own, ok := f.resolve(node["Resources"]).(pdfDict); ok {
+			resources = own
+		}
+		if node["Type"] == pdfName("Page") {
+			pages = append(pages, pdfPage{num: int(ref), dict: node, resources: resources})
+			return nil
+		}
+		kids, _ := f.resolve(node["Kids"]).([]any)
+		for _, kid := range kids {
+			kidRef, ok := kid.(pdfRef)
+			if !ok {
+				return fmt.Errorf("page tree node %d has an invalid kid", ref)
+			}
+			if err := walk(kidRef, resources); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := walk(root, nil); err != nil {
+		return nil, err
+	}
+	if len(pages) == 0 {
+		return nil, errors.New("no pages")
+	}
+	return pages, nil
+}
+
+// pageContents returns the decoded content streams of a page, joined.
+func (f *pdfFile) pageContents(page pdfDict) ([]byte, error) {
+	contents := f.resolve(page["Contents"])
+	parts, ok := contents.([]any)
+	if !ok {
+		parts = []any{contents}
+	}
+	var out []byte
+	for _, part := range parts {
+		stream, ok := f.resolve(part).(*pdfStream)
+		if !ok {
+			continue
+		}
+		data, err := stream.decode()
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, data...)
+		out = append(out, '\n')
+	}
+	return out, nil
+}
+
+// decode returns the stream data with Flate compression removed.
+func (s *pdfStream) decode() ([]byte, error) {
+	filters, ok := s.dict["Filter"].([]any)
+	if !ok {
+		filters = []any{s.dict["Filter"]}
+	}
+	data := s.data
+	for _, filter := range filters {
+		switch filter {
+		case nil:
+		case pdfName("FlateDecode"):
+			reader, err := zlib.NewReader(bytes.NewReader(data))
+			if err != nil {
+				return nil, err
+			}
+			data, err = io.ReadAll(reader)
+			if err != nil {
+				return nil, err
+			}
+		default:
+			return nil, fmt.Errorf("unsupported filter %v", filter)
+		}
+	}
+	return data, nil
+}
+
+// wideFonts returns the resource names of the Type0 fonts in resources,
+// whose strings hold two bytes for each character.
+func (f *pdfFile) wideFonts(resources pdfDict) map[string]bool {
+	wide := make(map[string]bool)
+	fonts, _ := f.resolve(resources["Font"]).(pdfDict)
+	for name, ref := range fonts {
+		if font, ok := f.resolve(ref).(pdfDict); ok && font["Subtype"] == pdfName("Type0") {
+			wide[string(name)] = true
+		}
+	}
+	return wide
+}
+
+// pageLinks returns the link annotations of a page.
+func (f *pdfFile) pageLinks(page pdfDict, pageIndex map[int]int) []link {
+	annots, _ := f.resolve(page["Annots"]).([]any)
+	var links []link
+	for _, annot := range annots {
+		dict, ok := f.resolve(annot).(pdfDict)
+		if !ok || dict["Subtype"] != pdfName("Link") {
+			continue
+		}
+		if action, ok := f.resolve(dict["A"]).(pdfDict); ok {
+			if uri, ok := f.resolve(action["URI"]).([]byte); ok {
+				links = append(links, link{URI: decodeTextString(uri)})
+			}
+			continue
+		}
+		if dest, ok := f.resolve(dict["Dest"]).([]any); ok && len(dest) > 0 {
+			if ref, ok := dest[0].(pdfRef); ok {
+				links = append(links, link{Page: pageIndex[int(ref)]})
+			}
+		}
+	}
+	return links
+}
+
+// contentText returns the text a content stream draws. Runs on the same
+// baseline are joined as they are, since the renderer keeps the spaces
+// between words in the runs; a new baseline starts a new line.
+func contentText(content []byte, wide map[string]bool) (string, error) {
+	var (
+		lines    []string
+		line     strings.Builder
+		lineY    float64 // Start of the current text line
+		lastY    = math.NaN()
+		font     string
+		operands []any
+	)
+	show := func(raw []byte) {
+		text := decodeContentString(raw, wide[font])
+		if text == "" {
+			return
+		}
+		if !math.IsNaN(lastY) && math.Abs(lineY-lastY) > 0.5 {
+			lines = append(lines, line.String())
+			line.Reset()
+		}
+		line.WriteString(text)
+		lastY = lineY
+	}
+	number := func(back int) float64 {
+		if len(operands) < back {
+			return 0
+		}
+		value, _ := operands[len(operands)-back].(float64)
+		return value
+	}
+	last := func() []byte {
+		if len(operands) == 0 {
+			return nil
+		}
+		raw, _ := operands[len(operands)-1].([]byte)
+		return raw
+	}
+
+	l := &pdfLexer{data: content}
+	for {
+		value, err := l.next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+		operator, ok := value.(pdfKeyword)
+		if !ok {
+			operands = append(operands, value)
+			continue
+		}
+		switch operator {
+		case "BT":
+			lineY = 0
+		case "Td", "TD":
+			lineY += number(1)
+		case "Tm":
+			lineY = number(1)
+		case "T*":
+			lineY--
+		case "Tf":
+			if len(operands) >= 2 {
+				name, _ := operands[len(operands)-2].(pdfName)
+				font = string(name)
+			}
+		case "Tj":
+			show(last())
+		case "'", "\"":
+			lineY--
+			show(last())
+		case "TJ":
+			if len(operands) > 0 {
+				items, _ := operands[len(operands)-1].([]any)
+				show(joinTJ(items))
+			}
+		}
+		operands = operands[:0]
+	}
+	if line.Len() > 0 {
+		lines = append(lines, line.String())
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// joinTJ concatenates the strings of a TJ array. A large negative
+// adjustment between strings is a word gap.
+func joinTJ(items []any) []byte {
+	var raw []byte
+	for _, item := range items {
+		switch item := item.(type) {
+		case []byte:
+			raw = append(raw, item...)
+		case float64:
+			if item <= -250 {
+				raw = append(raw, ' ')
+			}
+		}
+	}
+	return raw
+}
+
+// decodeContentString decodes a string shown on a page: UTF-16BE for Type0
+// fonts, UTF-8 where the bytes are valid UTF-8, and otherwise the
+// Windows-1252 encoding of the core fonts.
+func decodeContentString(raw []byte, wide bool) string {
+	if wide {
+		return decodeUTF16(raw)
+	}
+	if utf8.Valid(raw) {
+		return string(raw)
+	}
+	var b strings.Builder
+	for _, c := range raw {
+		if c >= 0x80 && c < 0xA0 && cp1252[c-0x80] != 0 {
+			b.WriteRune(cp1252[c-0x80])
+		} else {
+			b.WriteRune(rune(c))
+		}
+	}
+	return b.String()
+}
+
+// decodeTextString decodes a text string outside content streams, such as
+// a title: UTF-16BE after a byte order mark, and otherwise Latin-1, which
+// matches PDFDocEncoding for the characters the renderer writes.
+func decodeTextString(raw []byte) string {
+	if bytes.HasPrefix(raw, []byte{0xFE, 0xFF}) {
+		return decodeUTF16(raw[2:])
+	}
+	runes := make([]rune, len(raw))
+	for i, c := range raw {
+		runes[i] = rune(c)
+	}
+	return string(runes)
+}
+
+// decodeUTF16 decodes UTF-16BE text; an odd final byte is dropped.
+func decodeUTF16(raw []byte) string {
+	units := make([]uint16, len(raw)/2)
+	for i := range units {
+		units[i] = uint16(raw[2*i])<<8 | uint16(raw[2*i+1])
+	}
+	return string(utf16.Decode(units))
+}
+
+// cp1252 maps the bytes 0x80 to 0x9F of Windows-1252, where it differs from
+// Latin-1, to their characters (0 = undefined).
+var cp1252 = [32]rune{
+	'€', 0, '‚', 'ƒ', '„', '…', '†', '‡', 'ˆ', '‰', 'Š', '‹', 'Œ', 0, 'Ž', 0,
+	0, '‘', '’', '“', '”', '•', '–', '—', '˜', '™', 'š', '›', 'œ', 0, 'ž', 'Ÿ',
+}
+
+// pdfLexer reads PDF values, and the operators between them in content
+// streams, one at a time.
+type pdfLexer struct {
+	data []byte
+	pos  int
+}
+
+// next returns the next value or keyword, or io.EOF at the end of the data.
+func (l *pdfLexer) next() (any, error) {
+	l.skipSpace()
+	if l.pos >= len(l.data) {
+		return nil, io.EOF
+	}
+	switch c := l.data[l.pos]; {
+	case c == '/':
+		l.pos++
+		return pdfName(l.token()), nil
+	case c == '(':
+		l.pos++
+		return l.literal()
+	case c == '<' && l.pos+1 < len(l.data) && l.data[l.pos+1] == '<':
+		l.pos += 2
+		return l.dictionary()
+	case c == '<':
+		l.pos++
+		return l.hexString()
+	case c == '[':
+		l.pos++
+		return l.array()
+	case isPDFDelimiter(c):
+		return nil, fmt.Errorf("unexpected %q at offset %d", c, l.pos)
+	}
+
+	token := l.token()
+	if number, err := strconv.ParseFloat(token, 64); err == nil && strings.IndexByte("+-.0123456789", token[0]) >= 0 {
+		if ref, ok := l.reference(token); ok {
+			return ref, nil
+		}
+		return number, nil
+	}
+	switch token {
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	case "null":
+		return nil, nil
+	}
+	return pdfKeyword(token), nil
+}
+
+// reference reads the rest of a reference such as "12 0 R" whose object
+// number, token, was just read. The position is left unchanged when what
+// follows is not "0 R".
+func (l *pdfLexer) reference(token string) (pdfRef, bool) {
+	num, err := strconv.Atoi(token)
+	if err != nil || num < 0 {
+		return 0, false
+	}
+	start := l.pos
+	l.skipSpace()
+	generation := l.token()
+	l.skipSpace()
+	if _, err := strconv.Atoi(generation); err == nil && l.token() == "R" {
+		return pdfRef(num), true
+	}
+	l.pos = start
+	return 0, false
+}
+
+// token reads a run of regular characters.
+func (l *pdfLexer) token() string {
+	start := l.pos
+	for l.pos < len(l.data) && !isPDFSpace(l.data[l.pos]) && !isPDFDelimiter(l.data[l.pos]) {
+		l.pos++
+	}
+	return string(l.data[start:l.pos])
+}
+
+// skipSpace skips whitespace and comments.
+func (l *pdfLexer) skipSpace() {
+	for l.pos < len(l.data) {
+		switch c := l.data[l.pos]; {
+		case isPDFSpace(c):
+			l.pos++
+		case c == '%':
+			for l.pos < len(l.data) && l.data[l.pos] != '\n' && l.data[l.pos] != '\r' {
+				l.pos++
+			}
+		default:
+			return
+		}
+	}
+}
+
+// literal reads a literal string after its opening parenthesis.
+func (l *pdfLexer) literal() ([]byte, error) {
+	var raw []byte
+	for depth := 1; l.pos < len(l.data); l.pos++ {
+		c := l.data[l.pos]
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				l.pos++
+				return raw, nil
+			}
+		case '\\':
+			l.pos++
+			if l.pos >= len(l.data) {
+				return nil, errors.New("unterminated string")
+			}
+			escape := l.data[l.pos]
+			switch escape {
+			case 'n':
+				c = '\n'
+			case 'r':
+				c = '\r'
+			case 't':
+				c = '\t'
+			case 'b':
+				c = '\b'
+			case 'f':
+				c = '\f'
+			case '\r', '\n':
+				// A line continuation
+				if escape == '\r' && l.pos+1 < len(l.data) && l.data[l.pos+1] == '\n' {
+					l.pos++
+				}
+				continue
+			default:
+				if escape < '0' || escape > '7' {
+					c = escape
+					break
+				}
+				value := 0
+				for n := 0; n < 3 && l.pos < len(l.data) && l.data[l.pos] >= '0' && l.data[l.pos] <= '7'; n++ {
+					value = value*8 + int(l.data[l.pos]-'0')
+					l.pos++
+				}
+				l.pos--
+				c = byte(value)
+			}
+		}
+		raw = append(raw, c)
+	}
+	return nil, errors.New("unterminated string")
+}
+
+// hexString reads a hex string after its opening angle bracket. An odd
+// final digit is followed by 0, as the PDF specification says.
+func (l *pdfLexer) hexString() ([]byte, error) {
+	end := bytes.IndexByte(l.data[l.pos:], '>')
+	if end < 0 {
+		return nil, errors.New("unterminated hex string")
+	}
+	digits := strings.Map(func(r rune) rune {
+		if r < 0x80 && isPDFSpace(byte(r)) {
+			return -1
+		}
+		return r
+	}, string(l.data[l.pos:l.pos+end]))
+	l.pos += end + 1
+	if len(digits)%2 == 1 {
+		digits += "0"
+	}
+	return hex.DecodeString(digits)
+}
+
+// array reads an array after its opening bracket.
+func (l *pdfLexer) array() ([]any, error) {
+	items := []any{}
+	for {
+		l.skipSpace()
+		if l.pos < len(l.data) && l.data[l.pos] == ']' {
+			l.pos++
+			return items, nil
+		}
+		item, err := l.next()
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+}
+
+// dictionary reads a dictionary after its opening "<<".
+func (l *pdfLexer) dictionary() (pdfDict, error) {
+	dict := make(pdfDict)
+	for {
+		l.skipSpace()
+		if bytes.HasPrefix(l.data[l.pos:], []byte(">>")) {
+			l.pos += 2
+			return dict, nil
+		}
+		key, err := l.next()
+		if err != nil {
+			return nil, err
+		}
+		name, ok := key.(pdfName)
+		if !ok {
+			return nil, fmt.Errorf("dictionary key %v is not a name", key)
+		}
+		value, err := l.next()
+		if err != nil {
+			return nil, err
+		}
+		dict[name] = value
+	}
+}
+
+func isPDFSpace(c byte) bool {
+	return c == ' ' || c == '\n' || c == '\r' || c == '\t' || c == '\f' || c == 0
+}
+
+func isPDFDelimiter(c byte) bool {
+	return strings.IndexByte("()<>[]{}/%", c) >= 0
+}
@@ -0,0 +1,122 @@
+// Package manifest describes what a PDF was produced from: its markdown
+// source, the images it embeds, the md-to-pdf version and a fingerprint of
+// the settings, with files identified by their SHA-256 hash. The manifest
+// is attached to the PDF as a JSON file, so the PDF can later be checked
+// against the files it claims to come from.
+package manifest
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/fredcamaral/md-to-pdf/internal/pdfsplit"
+)
+
+// FileName is the name of the manifest attachment.
+const FileName = "md-to-pdf-manifest.json"
+
+// Format is the version of the manifest layout.
+const Format = 1
+
+// ErrNoManifest is returned by Read for PDFs without a manifest.
+var ErrNoManifest = errors.New("PDF has no md-to-pdf manifest")
+
+// Manifest lists the inputs of a PDF.
+type Manifest struct {
+	Format      int    `json:"format"`
+	ToolVersion string `json:"tool_version"`
+	// ConfigFingerprint is the fingerprint of the settings that affect how
+	// the PDF looks
+	ConfigFingerprint string `json:"config_fingerprint"`
+	Source            File   `json:"source"`
+	// Assets are the images embedded in the PDF, sorted by path
+	Assets []File `json:"assets"`
+}
+
+// File is a file identified by its content.
+type File struct {
+	Path   string `json:"path"`
+	SHA256 string `json:"sha256"`
+	Size   int    `json:"size"`
+}
+
+// NewFile describes the file at path holding data.
+func NewFile(path string, data []byte) File {
+	sum := sha256.Sum256(data)
+	return File{Path: path, SHA256: hex.EncodeToString(sum[:]), Size: len(data)}
+}
+
+// Marshal encodes the manifest as indented JSON, with the assets sorted.
+func (m *Manifest) Marshal() ([]byte, error) {
+	sorted := *m
+	sorted.Assets = append([]File{}, m.Assets...)
+	sort.Slice(sorted.Assets, func(i, j int) bool { return sorted.Assets[i].Path < sorted.Assets[j].Path })
+	if sorted.Assets == nil {
+		sorted.Assets = []File{}
+	}
+	return json.MarshalIndent(sorted, "", "  ")
+}
+
+// Read returns the manifest attached to a PDF.
+func Read(pdf []byte) (*Manifest, error) {
+	files, err := pdfsplit.EmbeddedFiles(pdf)
+	if err != nil {
+		return nil, err
+	}
+	data, ok := files[FileName]
+	if !ok {
+		return nil, ErrNoManifest
+	}
+	var m Manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("invalid manifest: %w", err)
+	}
+	if m.Format > Format {
+		return nil, fmt.Errorf("manifest format %d is newer than this md-to-pdf supports (%d)", m.Format, Format)
+	}
+	return &m, nil
+}
+
+// Status is the result of checking one file against the manifest.
+type Status string
+
+const (
+	// StatusMatch files have the hash the manifest records
+	StatusMatch Status = "match"
+	// StatusChanged files differ from those the PDF was produced from
+	StatusChanged Status = "changed"
+	// StatusMissing files could not be read
+	StatusMissing Status = "missing"
+)
+
+// Check is the result of checking one file.
+type Check struct {
+	File
+	Status Status `json:"status"`
+	// Actual is the hash of the file as it is now, for changed files
+	Actual string `json:"actual_sha256,omitempty"`
+}
+
+// Verify checks the source and assets of the manifest against the files
+// read by read, usually os.ReadFile, source first.
+func (m *Manifest) Verify(read func(path string) ([]byte, error)) []Check {
+	files := append([]File{m.Source}, m.Assets...)
+	checks := make([]Check, len(files))
+	for i, file := range files {
+		checks[i] = Check{File: file, Status: StatusMatch}
+		data, err := read(file.Path)
+		if err != nil {
+			checks[i].Status = StatusMissing
+			continue
+		}
+		if actual := NewFile(file.Path, data); actual.SHA256 != file.SHA256 {
+			checks[i].Status = StatusChanged
+			checks[i].Actual = actual.SHA256
+		}
+	}
+	return checks
+}
@@ -0,0 +1,99 @@
+package manifest
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/jung-kurt/gofpdf"
+)
+
+// pdfWith returns a one-page PDF with the given attachments.
+func pdfWith(t *testing.T, attachments ...gofpdf.Attachment) []byte {
+	t.Helper()
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.AddPage()
+	pdf.SetAttachments(attachments)
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestNewFile(t *testing.T) {
+	file := NewFile("doc.md", []byte("abc"))
+	if file.SHA256 != "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad" || file.Size != 3 || file.Path != "doc.md" {
+		t.Errorf("NewFile() = %+v", file)
+	}
+}
+
+func TestReadAndMarshal(t *testing.T) {
+	m := &Manifest{
+		Format:            Format,
+		ToolVersion:       "1.2.3",
+		ConfigFingerprint: "abcdef012345",
+		Source:            NewFile("doc.md", []byte("# Doc\n")),
+		Assets:            []File{NewFile("z.png", []byte("z")), NewFile("a.png", []byte("a"))},
+	}
+	data, err := m.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Index(string(data), "a.png") > strings.Index(string(data), "z.png") {
+		t.Errorf("assets should be sorted by path:\n%s", data)
+	}
+	if m.Assets[0].Path != "z.png" {
+		t.Error("Marshal should not reorder the assets of m")
+	}
+
+	read, err := Read(pdfWith(t, gofpdf.Attachment{Content: data, Filename: FileName}))
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if read.ToolVersion != "1.2.3" || read.Source != m.Source || len(read.Assets) != 2 || read.Assets[0].Path != "a.png" {
+		t.Errorf("Read() = %+v", read)
+	}
+
+	if _, err := Read(pdfWith(t)); !errors.Is(err, ErrNoManifest) {
+		t.Errorf("expected ErrNoManifest, got %v", err)
+	}
+	newer := pdfWith(t, gofpdf.Attachment{Content: []byte(`{"format": 99}`), Filename: FileName})
+	if _, err := Read(newer); err == nil || !strings.Contains(err.Error(), "newer") {
+		t.Errorf("expected an error for a newer format, got %v", err)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	files := map[string]string{"doc.md": "# Doc\n", "same.png": "same", "edited.png": "after"}
+	m := &Manifest{
+		Source: NewFile("doc.md", []byte("# Doc\n")),
+		Assets: []File{
+			NewFile("same.png", []byte("same")),
+			NewFile("edited.png", []byte("before")),
+			NewFile("gone.png", []byte("gone")),
+		},
+	}
+	checks := m.Verify(func(path string) ([]byte, error) {
+		content, ok := files[path]
+		if !ok {
+			return nil, os.ErrNotExist
+		}
+		return []byte(content), nil
+	})
+
+	want := []Status{StatusMatch, StatusMatch, StatusChanged, StatusMissing}
+	if len(checks) != len(want) {
+		t.Fatalf("Verify() = %+v", checks)
+	}
+	for i, check := range checks {
+		if check.Status != want[i] {
+			t.Errorf("%s: status %s, want %s", check.Path, check.Status, want[i])
+		}
+	}
+	if checks[2].Actual != NewFile("", []byte("after")).SHA256 {
+		t.Errorf("changed files should report their current hash, got %q", checks[2].Actual)
+	}
+}
@@ -0,0 +1,710 @@
+// Package network fetches remote resources under the network settings of
+// md-to-pdf: a request timeout, an optional proxy, an offline mode, a rate
+// limit, retries and a response cache.
+package network
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultTimeout is the time a request may take when no timeout is set.
+const DefaultTimeout = 30 * time.Second
+
+// DefaultAttempts is the number of times a request is sent when no number
+// of attempts is set.
+const DefaultAttempts = 3
+
+// DefaultConcurrency is the number of downloads GetAll runs at once when no
+// concurrency is set.
+const DefaultConcurrency = 4
+
+const (
+	// defaultRetryDelay is the wait before the first retry, doubled for
+	// each further one
+	defaultRetryDelay = 500 * time.Millisecond
+	// maxRetryDelay caps the wait before a retry, including the one a
+	// server asks for
+	maxRetryDelay = 30 * time.Second
+)
+
+// MaxResponseSize is the largest response body Get accepts.
+const MaxResponseSize = 50 << 20
+
+// DefaultMemoryCacheSize is the total size of the responses a client keeps
+// in memory when no size is set.
+const DefaultMemoryCacheSize = 64 << 20
+
+// ErrOffline is returned for requests that would need the network in
+// offline mode.
+var ErrOffline = errors.New("network access is disabled in offline mode")
+
+// Options are the network settings of a Client.
+type Options struct {
+	// Timeout limits each request (0 = DefaultTimeout)
+	Timeout time.Duration
+	// Proxy is the URL of the proxy requests go through (empty = the
+	// HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables)
+	Proxy string
+	// Offline answers Get from the cache only and fails everything else
+	Offline bool
+	// CacheDir keeps fetched responses across runs (empty = in memory only).
+	// Entries never expire: those with an ETag or Last-Modified date are
+	// revalidated on first use in each run, the others are served as they
+	// are until the directory is cleared
+	CacheDir string
+	// MemoryCacheSize is the most bytes of responses kept in memory, the
+	// least recently used dropped first (0 = DefaultMemoryCacheSize)
+	MemoryCacheSize int64
+	// RateLimit is the most requests sent per second, across all requests
+	// of the client (0 = unlimited)
+	RateLimit float64
+	// Attempts is the number of times a request is sent when it fails with
+	// a network error or a 429 or 5xx status, waiting longer before each
+	// retry (0 = DefaultAttempts, 1 = no retries)
+	Attempts int
+	// Concurrency is the most downloads GetAll runs at once
+	// (0 = DefaultConcurrency)
+	Concurrency int
+}
+
+// Stats counts the network activity of a Client.
+type Stats struct {
+	// Requests is the number of requests sent, retries included
+	Requests int
+	// Downloads is the number of responses fetched in full
+	Downloads int
+	// Bytes is the size of the response bodies received
+	Bytes int64
+	// CacheHits is the number of Get calls answered from the cache without
+	// a request
+	CacheHits int
+	// Revalidated is the number of cached responses a server confirmed
+	// unchanged
+	Revalidated int
+	// Stale is the number of cached responses served because they could
+	// not be revalidated
+	Stale int
+	// Retries is the number of requests sent again after a failure
+	Retries int
+	// Resumed is the number of downloads continued where a broken
+	// connection stopped them
+	Resumed int
+	// Failures is the number of Get and Do calls that failed
+	Failures int
+	// Throttled is the time requests waited for the rate limit
+	Throttled time.Duration
+}
+
+// Sub returns the activity since earlier, a snapshot of the same client.
+func (s Stats) Sub(earlier Stats) Stats {
+	return Stats{
+		Requests:    s.Requests - earlier.Requests,
+		Downloads:   s.Downloads - earlier.Downloads,
+		Bytes:       s.Bytes - earlier.Bytes,
+		CacheHits:   s.CacheHits - earlier.CacheHits,
+		Revalidated: s.Revalidated - earlier.Revalidated,
+		Stale:       s.Stale - earlier.Stale,
+		Retries:     s.Retries - earlier.Retries,
+		Resumed:     s.Resumed - earlier.Resumed,
+		Failures:    s.Failures - earlier.Failures,
+		Throttled:   s.Throttled - earlier.Throttled,
+	}
+}
+
+// Empty reports whether there was no activity at all.
+func (s Stats) Empty() bool {
+	return s == Stats{}
+}
+
+// Client is an HTTP client that applies Options. It is safe for concurrent
+// use.
+type Client struct {
+	client      *http.Client
+	offline     bool
+	cacheDir    string
+	attempts    int
+	concurrency int
+	retryDelay  time.Duration
+	limiter     *limiter
+
+	mu    sync.Mutex
+	cache *memoryCache
+	// inflight holds the downloads in progress, so concurrent Get calls
+	// for one URL share a request
+	inflight map[string]*pendingGet
+	stats    Stats
+}
+
+// pendingGet is a download other Get calls for the same URL wait for.
+type pendingGet struct {
+	done chan struct{}
+	data []byte
+	err  error
+}
+
+// NewClient creates a client with the given settings.
+func NewClient(opts Options) *Client {
+	timeout := opts.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = http.ProxyFromEnvironment
+	if opts.Proxy != "" {
+		proxy, err := ParseProxy(opts.Proxy)
+		transport.Proxy = func(*http.Request) (*url.URL, error) {
+			return proxy, err
+		}
+	}
+
+	attempts := opts.Attempts
+	if attempts <= 0 {
+		attempts = DefaultAttempts
+	}
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = DefaultConcurrency
+	}
+	cacheSize := opts.MemoryCacheSize
+	if cacheSize <= 0 {
+		cacheSize = DefaultMemoryCacheSize
+	}
+
+	return &Client{
+		client:      &http.Client{Timeout: timeout, Transport: transport},
+		offline:     opts.Offline,
+		cacheDir:    opts.CacheDir,
+		attempts:    attempts,
+		concurrency: concurrency,
+		retryDelay:  defaultRetryDelay,
+		limiter:     newLimiter(opts.RateLimit),
+		cache:       newMemoryCache(cacheSize),
+		inflight:    make(map[string]*pendingGet),
+	}
+}
+
+// ParseProxy parses a proxy URL such as "http://proxy.example.com:8080".
+func ParseProxy(proxy string) (*url.URL, error) {
+	parsed, err := url.Parse(proxy)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proxy URL: %w", err)
+	}
+	switch parsed.Scheme {
+	case "http", "https", "socks5":
+	default:
+		return nil, fmt.Errorf("invalid proxy URL %q: scheme must be http, https or socks5", proxy)
+	}
+	if parsed.Host == "" {
+		return nil, fmt.Errorf("invalid proxy URL %q: missing host", proxy)
+	}
+	return parsed, nil
+}
+
+// Get returns the body of a successful GET request for rawURL. Responses
+// are cached, so each URL is fetched once per run as long as the memory
+// cache has room for it. Responses cached by an
+// earlier run are revalidated with their ETag or Last-Modified date, and
+// served as they are when revalidating fails. In offline mode only cached
+// responses are returned.
+func (c *Client) Get(rawURL string) ([]byte, error) {
+	c.mu.Lock()
+	if data, ok := c.cache.get(rawURL); ok {
+		c.stats.CacheHits++
+		c.mu.Unlock()
+		return data, nil
+	}
+	if pending, ok := c.inflight[rawURL]; ok {
+		c.mu.Unlock()
+		<-pending.done
+		return pending.data, pending.err
+	}
+	pending := &pendingGet{done: make(chan struct{})}
+	c.inflight[rawURL] = pending
+	c.mu.Unlock()
+
+	pending.data, pending.err = c.fetch(rawURL)
+
+	c.mu.Lock()
+	delete(c.inflight, rawURL)
+	if pending.err == nil {
+		c.cache.add(rawURL, pending.data)
+	} else {
+		c.stats.Failures++
+	}
+	c.mu.Unlock()
+	close(pending.done)
+	return pending.data, pending.err
+}
+
+// GetAll fetches urls like Get, running up to the configured number of
+// downloads at once. The bodies and errors are in the order of urls.
+func (c *Client) GetAll(urls []string) ([][]byte, []error) {
+	bodies := make([][]byte, len(urls))
+	errs := make([]error, len(urls))
+	slots := make(chan struct{}, c.concurrency)
+	var wg sync.WaitGroup
+	for i, rawURL := range urls {
+		wg.Add(1)
+		slots <- struct{}{}
+		go func(i int, rawURL string) {
+			defer wg.Done()
+			defer func() { <-slots }()
+			bodies[i], errs[i] = c.Get(rawURL)
+		}(i, rawURL)
+	}
+	wg.Wait()
+	return bodies, errs
+}
+
+// Do sends a request that is not cached, such as a POST to a rendering
+// server. Requests whose body can be sent again are retried like GET
+// requests. It fails with ErrOffline in offline mode.
+func (c *Client) Do(req *http.Request) (*http.Response, error) {
+	if c.offline {
+		return nil, fmt.Errorf("%s %s: %w", req.Method, req.URL, ErrOffline)
+	}
+
+	for attempt := 1; ; attempt++ {
+		resp, err := c.send(req)
+		replayable := req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+		if attempt >= c.attempts || !replayable || (err == nil && !retryableStatus(resp.StatusCode)) {
+			if err != nil {
+				c.count(func(s *Stats) { s.Failures++ })
+			}
+			return resp, err
+		}
+
+		var asked time.Duration
+		if resp != nil {
+			asked = retryAfter(resp)
+			_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, MaxResponseSize))
+			_ = resp.Body.Close()
+		}
+		c.wait(attempt, asked)
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req = req.Clone(req.Context())
+			req.Body = body
+		}
+	}
+}
+
+// Stats returns the network activity of the client so far.
+func (c *Client) Stats() Stats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.stats
+}
+
+// count updates the statistics with update.
+func (c *Client) count(update func(*Stats)) {
+	c.mu.Lock()
+	update(&c.stats)
+	c.mu.Unlock()
+}
+
+// fetch returns the body for rawURL from the cache directory or the
+// network, revalidating cached responses that have validators.
+func (c *Client) fetch(rawURL string) ([]byte, error) {
+	cached, onDisk := c.readCache(rawURL)
+	if onDisk && (c.offline || !cached.meta.validates()) {
+		c.count(func(s *Stats) { s.CacheHits++ })
+		return cached.data, nil
+	}
+	if c.offline {
+		return nil, fmt.Errorf("GET %s: %w", rawURL, ErrOffline)
+	}
+
+	var conditional *cacheEntry
+	if onDisk {
+		conditional = &cached
+	}
+	result, err := c.download(rawURL, conditional)
+	if err != nil {
+		if onDisk {
+			// An outdated response beats a failed build
+			c.count(func(s *Stats) { s.Stale++ })
+			return cached.data, nil
+		}
+		return nil, err
+	}
+	if result.notModified {
+		c.count(func(s *Stats) { s.Revalidated++ })
+		return cached.data, nil
+	}
+	c.store(rawURL, result.data, result.meta)
+	return result.data, nil
+}
+
+// downloaded is the outcome of a successful download.
+type downloaded struct {
+	data []byte
+	meta cacheMeta
+	// notModified is set when the server confirmed the cached response
+	notModified bool
+}
+
+// partialBody is what arrived of a download before its connection broke.
+type partialBody struct {
+	data []byte
+	meta cacheMeta
+}
+
+// retryableError is a failed attempt worth repeating: a network error, a
+// broken download or a 429 or 5xx response.
+type retryableError struct {
+	err error
+	// asked is the wait the server asked for with Retry-After
+	asked time.Duration
+}
+
+func (e *retryableError) Error() string { return e.err.Error() }
+func (e *retryableError) Unwrap() error { return e.err }
+
+// download sends GET requests for rawURL until one succeeds or the attempts
+// run out. The request is conditional on cached when it is set.
+func (c *Client) download(rawURL string, cached *cacheEntry) (downloaded, error) {
+	var partial partialBody
+	for attempt := 1; ; attempt++ {
+		result, err := c.downloadOnce(rawURL, cached, &partial)
+		var retryable *retryableError
+		if err == nil || attempt >= c.attempts || !errors.As(err, &retryable) {
+			return result, err
+		}
+		c.wait(attempt, retryable.asked)
+	}
+}
+
+// downloadOnce sends one GET request for rawURL. A download cut off by a
+// broken connection is kept in partial, and the next attempt asks for the
+// rest only when the server supports range requests for the same version.
+// A range response that does not continue where partial stops is dropped
+// together with partial, and the whole response is asked for again.
+func (c *Client) downloadOnce(rawURL string, cached *cacheEntry, partial *partialBody) (downloaded, error) {
+	req, err := http.NewRequest(http.MethodGet, rawURL, nil)
+	if err != nil {
+		return downloaded{}, fmt.Errorf("GET %s: %w", rawURL, err)
+	}
+	if cached != nil {
+		if cached.meta.ETag != "" {
+			req.Header.Set("If-None-Match", cached.meta.ETag)
+		}
+		if cached.meta.LastModified != "" {
+			req.Header.Set("If-Modified-Since", cached.meta.LastModified)
+		}
+	}
+	if len(partial.data) > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", len(partial.data)))
+		req.Header.Set("If-Range", partial.meta.rangeValidator())
+	}
+
+	resp, err := c.send(req) // #nosec G107 - URLs come from documents and plugins the user runs
+	if err != nil {
+		return downloaded{}, &retryableError{err: err}
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	switch {
+	case resp.StatusCode == http.StatusNotModified && cached != nil:
+		return downloaded{notModified: true}, nil
+	case retryableStatus(resp.StatusCode):
+		return downloaded{}, &retryableError{err: fmt.Errorf("GET %s: %s", rawURL, resp.Status), asked: retryAfter(resp)}
+	case resp.StatusCode < 200 || resp.StatusCode > 299:
+		return downloaded{}, fmt.Errorf("GET %s: %s", rawURL, resp.Status)
+	}
+
+	meta := cacheMeta{ETag: resp.Header.Get("ETag"), LastModified: resp.Header.Get("Last-Modified")}
+	var data []byte
+	if resp.StatusCode == http.StatusPartialContent && len(partial.data) > 0 {
+		if start, ok := contentRangeStart(resp.Header.Get("Content-Range")); !ok || start != int64(len(partial.data)) {
+			partial.data = nil
+			_ = resp.Body.Close()
+			return c.downloadOnce(rawURL, cached, partial)
+		}
+		data, meta = partial.data, partial.meta
+		c.count(func(s *Stats) { s.Resumed++ })
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, int64(MaxResponseSize+1-len(data))))
+	c.count(func(s *Stats) { s.Bytes += int64(len(body)) })
+	data = append(data, body...)
+	if len(data) > MaxResponseSize {
+		return downloaded{}, fmt.Errorf("GET %s: response is larger than %d bytes", rawURL, MaxResponseSize)
+	}
+	if err != nil {
+		partial.data = nil
+		resumable := resp.StatusCode == http.StatusPartialContent || resp.Header.Get("Accept-Ranges") == "bytes"
+		if resumable && meta.rangeValidator() != "" {
+			partial.data, partial.meta = data, meta
+		}
+		return downloaded{}, &retryableError{err: fmt.Errorf("GET %s: %w", rawURL, err)}
+	}
+
+	c.count(func(s *Stats) { s.Downloads++ })
+	return downloaded{data: data, meta: meta}, nil
+}
+
+// contentRangeStart returns the offset of the first byte a range response
+// holds, from a Content-Range header such as "bytes 4-9/10".
+func contentRangeStart(header string) (int64, bool) {
+	rest, ok := strings.CutPrefix(strings.TrimSpace(header), "bytes ")
+	if !ok {
+		return 0, false
+	}
+	first, _, ok := strings.Cut(rest, "-")
+	if !ok {
+		return 0, false
+	}
+	start, err := strconv.ParseInt(strings.TrimSpace(first), 10, 64)
+	if err != nil || start < 0 {
+		return 0, false
+	}
+	return start, true
+}
+
+// send sends one request once the rate limit allows it.
+func (c *Client) send(req *http.Request) (*http.Response, error) {
+	waited := c.limiter.wait()
+	c.count(func(s *Stats) {
+		s.Requests++
+		s.Throttled += waited
+	})
+	return c.client.Do(req)
+}
+
+// wait sleeps before the retry that follows attempt: as long as the server
+// asked, or else twice as long as before the previous retry.
+func (c *Client) wait(attempt int, asked time.Duration) {
+	c.count(func(s *Stats) { s.Retries++ })
+	delay := asked
+	if delay <= 0 {
+		delay = c.retryDelay << (attempt - 1)
+	}
+	time.Sleep(min(delay, maxRetryDelay))
+}
+
+// retryableStatus reports whether a response with status code is worth
+// asking for again: the server is overloaded or failed for now.
+func retryableStatus(code int) bool {
+	return code == http.StatusTooManyRequests || (code >= 500 && code != http.StatusNotImplemented)
+}
+
+// retryAfter returns the wait a 429 or 503 response asks for in its
+// Retry-After header, given in seconds or as a date, or 0.
+func retryAfter(resp *http.Response) time.Duration {
+	value := resp.Header.Get("Retry-After")
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		return time.Until(at)
+	}
+	return 0
+}
+
+// memoryCache holds responses up to a total size, dropping the least
+// recently used ones to make room. It is guarded by the client's mutex.
+type memoryCache struct {
+	limit   int64
+	size    int64
+	order   *list.List // of *memoryEntry, most recently used first
+	entries map[string]*list.Element
+}
+
+// memoryEntry is a response in a memoryCache.
+type memoryEntry struct {
+	url  string
+	data []byte
+}
+
+// newMemoryCache creates a cache for up to limit bytes of responses.
+func newMemoryCache(limit int64) *memoryCache {
+	return &memoryCache{limit: limit, order: list.New(), entries: make(map[string]*list.Element)}
+}
+
+// get returns the response for rawURL and marks it as recently used.
+func (m *memoryCache) get(rawURL string) ([]byte, bool) {
+	elem, ok := m.entries[rawURL]
+	if !ok {
+		return nil, false
+	}
+	m.order.MoveToFront(elem)
+	return elem.Value.(*memoryEntry).data, true
+}
+
+// add keeps the response for rawURL, dropping the least recently used
+// responses until it fits. Responses larger than the whole cache are not
+// kept.
+func (m *memoryCache) add(rawURL string, data []byte) {
+	if elem, ok := m.entries[rawURL]; ok {
+		m.remove(elem)
+	}
+	size := int64(len(data))
+	if size > m.limit {
+		return
+	}
+	for m.size+size > m.limit {
+		m.remove(m.order.Back())
+	}
+	m.entries[rawURL] = m.order.PushFront(&memoryEntry{url: rawURL, data: data})
+	m.size += size
+}
+
+// remove drops one response.
+func (m *memoryCache) remove(elem *list.Element) {
+	entry := m.order.Remove(elem).(*memoryEntry)
+	delete(m.entries, entry.url)
+	m.size -= int64(len(entry.data))
+}
+
+// limiter spaces requests evenly to stay under a rate.
+type limiter struct {
+	mu       sync.Mutex
+	interval time.Duration
+	next     time.Time
+}
+
+// newLimiter creates a limiter for rate requests per second, or nil for an
+// unlimited rate.
+func newLimiter(rate float64) *limiter {
+	if rate <= 0 {
+		return nil
+	}
+	return &limiter{interval: time.Duration(float64(time.Second) / rate)}
+}
+
+// wait blocks until the next request may be sent and returns how long it
+// waited. A nil limiter never waits.
+func (l *limiter) wait() time.Duration {
+	if l == nil {
+		return 0
+	}
+	l.mu.Lock()
+	now := time.Now()
+	at := l.next
+	if at.Before(now) {
+		at = now
+	}
+	l.next = at.Add(l.interval)
+	l.mu.Unlock()
+
+	delay := at.Sub(now)
+	time.Sleep(delay)
+	return delay
+}
+
+// cacheMeta holds the validators of a cached response, kept beside it in the
+// cache directory to revalidate it in later runs.
+type cacheMeta struct {
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"last_modified,omitempty"`
+}
+
+// validates reports whether the response can be revalidated.
+func (m cacheMeta) validates() bool {
+	return m.ETag != "" || m.LastModified != ""
+}
+
+// rangeValidator returns the If-Range value that makes a range request
+// return the rest of this version of the response, or "" when there is
+// none: weak ETags do not qualify.
+func (m cacheMeta) rangeValidator() string {
+	if m.ETag != "" && !strings.HasPrefix(m.ETag, "W/") {
+		return m.ETag
+	}
+	return m.LastModified
+}
+
+// cacheEntry is a response kept in the cache directory.
+type cacheEntry struct {
+	data []byte
+	meta cacheMeta
+}
+
+// readCache returns the response for rawURL from the cache directory.
+// Responses cached without validators have no metadata.
+func (c *Client) readCache(rawURL string) (cacheEntry, bool) {
+	if c.cacheDir == "" {
+		return cacheEntry{}, false
+	}
+	path := c.cachePath(rawURL)
+	data, err := os.ReadFile(path) // #nosec G304 - path within the cache directory
+	if err != nil {
+		return cacheEntry{}, false
+	}
+	entry := cacheEntry{data: data}
+	if meta, err := os.ReadFile(path + ".meta"); err == nil { // #nosec G304 - path within the cache directory
+		_ = json.Unmarshal(meta, &entry.meta)
+	}
+	return entry, true
+}
+
+// store keeps a response in the cache directory. Failing to write it only
+// costs a download in the next run, so it is not an error.
+func (c *Client) store(rawURL string, data []byte, meta cacheMeta) {
+	if c.cacheDir == "" {
+		return
+	}
+	if err := os.MkdirAll(c.cacheDir, 0750); err != nil {
+		return
+	}
+	path := c.cachePath(rawURL)
+	if !writeCacheFile(c.cacheDir, path, data) {
+		return
+	}
+	if !meta.validates() {
+		_ = os.Remove(path + ".meta")
+		return
+	}
+	if encoded, err := json.Marshal(meta); err == nil {
+		writeCacheFile(c.cacheDir, path+".meta", encoded)
+	}
+}
+
+// writeCacheFile replaces path with data through a temporary file in dir,
+// so readers never see a partly written file.
+func writeCacheFile(dir, path string, data []byte) bool {
+	temp, err := os.CreateTemp(dir, ".tmp-*")
+	if err != nil {
+		return false
+	}
+	_, err = temp.Write(data)
+	if closeErr := temp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(temp.Name(), path)
+	}
+	if err != nil {
+		_ = os.Remove(temp.Name())
+		return false
+	}
+	return true
+}
+
+// cachePath is the file in the cache directory that holds the response
+// for rawURL.
+func (c *Client) cachePath(rawURL string) string {
+	sum := sha256.Sum256([]byte(rawURL))
+	return filepath.Join(c.cacheDir, hex.EncodeToString(sum[:]))
+}
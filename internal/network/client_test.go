@@ -0,0 +1,379 @@
+package network
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestClient_Get(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.URL.Path == "/missing" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprintf(w, "body of %s", r.URL.Path)
+	}))
+	defer server.Close()
+
+	cacheDir := t.TempDir()
+	client := NewClient(Options{CacheDir: cacheDir})
+
+	for i := 0; i < 2; i++ {
+		data, err := client.Get(server.URL + "/badge.svg")
+		if err != nil {
+			t.Fatalf("Get failed: %v", err)
+		}
+		if string(data) != "body of /badge.svg" {
+			t.Errorf("Get = %q", data)
+		}
+	}
+	if requests != 1 {
+		t.Errorf("expected the response to be cached, got %d requests", requests)
+	}
+
+	if _, err := client.Get(server.URL + "/missing"); err == nil || !strings.Contains(err.Error(), "404") {
+		t.Errorf("expected a 404 error, got %v", err)
+	}
+
+	// A new offline client answers from the cache directory
+	offline := NewClient(Options{Offline: true, CacheDir: cacheDir})
+	if data, err := offline.Get(server.URL + "/badge.svg"); err != nil || string(data) != "body of /badge.svg" {
+		t.Errorf("offline Get = %q, %v, want the cached response", data, err)
+	}
+	if _, err := offline.Get(server.URL + "/other.svg"); !errors.Is(err, ErrOffline) {
+		t.Errorf("expected ErrOffline for an uncached URL, got %v", err)
+	}
+	req, _ := http.NewRequest(http.MethodPost, server.URL+"/render", nil)
+	if _, err := offline.Do(req); !errors.Is(err, ErrOffline) {
+		t.Errorf("expected ErrOffline from Do, got %v", err)
+	}
+	if requests != 2 {
+		t.Errorf("offline client should not send requests, got %d in total", requests)
+	}
+}
+
+func TestClient_Timeout(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+
+	client := NewClient(Options{Timeout: 50 * time.Millisecond, Attempts: 1})
+	if _, err := client.Get(server.URL); err == nil {
+		t.Error("expected the request to time out")
+	}
+}
+
+func TestClient_Retries(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) < 3 {
+			w.Header().Set("Retry-After", "0")
+			http.Error(w, "busy", http.StatusServiceUnavailable)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		fmt.Fprintf(w, "%s %s", r.Method, body)
+	}))
+	defer server.Close()
+
+	client := NewClient(Options{})
+	client.retryDelay = time.Millisecond
+	data, err := client.Get(server.URL + "/diagram.svg")
+	if err != nil || string(data) != "GET " {
+		t.Fatalf("Get = %q, %v, want the third response", data, err)
+	}
+	if stats := client.Stats(); stats.Requests != 3 || stats.Retries != 2 || stats.Downloads != 1 {
+		t.Errorf("Stats() = %+v, want 3 requests, 2 retries and 1 download", stats)
+	}
+
+	// Requests with a body that can be sent again are retried too
+	requests.Store(0)
+	req, _ := http.NewRequest(http.MethodPost, server.URL+"/render", strings.NewReader("@startuml"))
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("Do failed: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || string(body) != "POST @startuml" {
+		t.Errorf("Do = %s %q, want the body sent again", resp.Status, body)
+	}
+
+	// Without retries the first failure is returned
+	requests.Store(0)
+	once := NewClient(Options{Attempts: 1})
+	if _, err := once.Get(server.URL + "/other.svg"); err == nil || !strings.Contains(err.Error(), "503") {
+		t.Errorf("expected a 503 error, got %v", err)
+	}
+	if stats := once.Stats(); stats.Requests != 1 || stats.Failures != 1 {
+		t.Errorf("Stats() = %+v, want 1 request and 1 failure", stats)
+	}
+}
+
+func TestClient_Revalidation(t *testing.T) {
+	var requests atomic.Int32
+	down := atomic.Bool{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if down.Load() {
+			http.Error(w, "bad gateway", http.StatusBadGateway)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		fmt.Fprint(w, "badge")
+	}))
+	defer server.Close()
+
+	cacheDir := t.TempDir()
+	if _, err := NewClient(Options{CacheDir: cacheDir}).Get(server.URL); err != nil {
+		t.Fatal(err)
+	}
+
+	// A later run asks whether its cached response changed
+	later := NewClient(Options{CacheDir: cacheDir})
+	if data, err := later.Get(server.URL); err != nil || string(data) != "badge" {
+		t.Fatalf("Get = %q, %v, want the cached response", data, err)
+	}
+	if stats := later.Stats(); stats.Revalidated != 1 || stats.Downloads != 0 || stats.Bytes != 0 {
+		t.Errorf("Stats() = %+v, want 1 revalidation and nothing downloaded", stats)
+	}
+
+	// When revalidating fails, the cached response is served
+	down.Store(true)
+	failing := NewClient(Options{CacheDir: cacheDir, Attempts: 1})
+	if data, err := failing.Get(server.URL); err != nil || string(data) != "badge" {
+		t.Fatalf("Get = %q, %v, want the stale response", data, err)
+	}
+	if stats := failing.Stats(); stats.Stale != 1 || stats.Failures != 0 {
+		t.Errorf("Stats() = %+v, want 1 stale response", stats)
+	}
+
+	// Offline clients serve it without asking
+	before := requests.Load()
+	offline := NewClient(Options{CacheDir: cacheDir, Offline: true})
+	if _, err := offline.Get(server.URL); err != nil || requests.Load() != before {
+		t.Errorf("offline Get should not send requests, got %v", err)
+	}
+}
+
+func TestClient_ResumesBrokenDownloads(t *testing.T) {
+	const content = "0123456789"
+	var ranges []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ranges = append(ranges, r.Header.Get("Range"))
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Accept-Ranges", "bytes")
+		if r.Header.Get("Range") == "bytes=4-" && r.Header.Get("If-Range") == `"v1"` {
+			w.Header().Set("Content-Range", fmt.Sprintf("bytes 4-9/%d", len(content)))
+			w.WriteHeader(http.StatusPartialContent)
+			fmt.Fprint(w, content[4:])
+			return
+		}
+		// Promise the whole body, send part of it and drop the connection
+		w.Header().Set("Content-Length", fmt.Sprint(len(content)))
+		fmt.Fprint(w, content[:4])
+		w.(http.Flusher).Flush()
+		conn, _, err := w.(http.Hijacker).Hijack()
+		if err == nil {
+			_ = conn.Close()
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient(Options{})
+	client.retryDelay = time.Millisecond
+	data, err := client.Get(server.URL + "/large.png")
+	if err != nil || string(data) != content {
+		t.Fatalf("Get = %q, %v, want %q", data, err, content)
+	}
+	if len(ranges) != 2 || ranges[1] != "bytes=4-" {
+		t.Errorf("expected a range request for the rest, got ranges %q", ranges)
+	}
+	if stats := client.Stats(); stats.Resumed != 1 || stats.Bytes != int64(len(content)) {
+		t.Errorf("Stats() = %+v, want 1 resumed download of %d bytes", stats, len(content))
+	}
+}
+
+func TestClient_RestartsOnMismatchedRange(t *testing.T) {
+	const content = "0123456789"
+	tests := []struct {
+		name         string
+		contentRange string
+	}{
+		{"wrong offset", "bytes 2-9/10"},
+		{"missing header", ""},
+		{"malformed header", "bytes */10"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var ranges []string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				ranges = append(ranges, r.Header.Get("Range"))
+				w.Header().Set("ETag", `"v1"`)
+				w.Header().Set("Accept-Ranges", "bytes")
+				if r.Header.Get("Range") != "" {
+					if tt.contentRange != "" {
+						w.Header().Set("Content-Range", tt.contentRange)
+					}
+					w.WriteHeader(http.StatusPartialContent)
+					fmt.Fprint(w, content[2:])
+					return
+				}
+				if len(ranges) > 1 {
+					fmt.Fprint(w, content)
+					return
+				}
+				w.Header().Set("Content-Length", fmt.Sprint(len(content)))
+				fmt.Fprint(w, content[:4])
+				w.(http.Flusher).Flush()
+				conn, _, err := w.(http.Hijacker).Hijack()
+				if err == nil {
+					_ = conn.Close()
+				}
+			}))
+			defer server.Close()
+
+			client := NewClient(Options{})
+			client.retryDelay = time.Millisecond
+			data, err := client.Get(server.URL + "/large.png")
+			if err != nil || string(data) != content {
+				t.Fatalf("Get = %q, %v, want %q", data, err, content)
+			}
+			if len(ranges) != 3 || ranges[1] != "bytes=4-" || ranges[2] != "" {
+				t.Errorf("expected the whole response to be asked for again, got ranges %q", ranges)
+			}
+			if stats := client.Stats(); stats.Resumed != 0 {
+				t.Errorf("Stats() = %+v, want no resumed download", stats)
+			}
+		})
+	}
+}
+
+func TestClient_MemoryCacheSize(t *testing.T) {
+	requests := map[string]int{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests[r.URL.Path]++
+		fmt.Fprint(w, strings.Repeat("x", 10))
+	}))
+	defer server.Close()
+
+	// Room for two of the 10-byte responses
+	client := NewClient(Options{MemoryCacheSize: 25})
+	for _, path := range []string{"/a", "/b", "/a", "/c", "/a", "/b"} {
+		if _, err := client.Get(server.URL + path); err != nil {
+			t.Fatalf("Get(%s) failed: %v", path, err)
+		}
+	}
+	// /b was the least recently used when /c came in, so it is fetched again
+	want := map[string]int{"/a": 1, "/b": 2, "/c": 1}
+	for path, n := range want {
+		if requests[path] != n {
+			t.Errorf("%s requested %d times, want %d", path, requests[path], n)
+		}
+	}
+	if client.cache.size != 20 {
+		t.Errorf("cache holds %d bytes, want 20", client.cache.size)
+	}
+
+	// A response larger than the whole cache is not kept
+	small := NewClient(Options{MemoryCacheSize: 5})
+	for i := 0; i < 2; i++ {
+		if _, err := small.Get(server.URL + "/large"); err != nil {
+			t.Fatalf("Get failed: %v", err)
+		}
+	}
+	if requests["/large"] != 2 || small.cache.size != 0 {
+		t.Errorf("got %d requests and %d cached bytes, want 2 and 0", requests["/large"], small.cache.size)
+	}
+}
+
+func TestClient_GetAll(t *testing.T) {
+	var active, most atomic.Int32
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		n := active.Add(1)
+		defer active.Add(-1)
+		for {
+			m := most.Load()
+			if n <= m || most.CompareAndSwap(m, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+		fmt.Fprint(w, r.URL.Path)
+	}))
+	defer server.Close()
+
+	client := NewClient(Options{Concurrency: 2})
+	urls := []string{server.URL + "/a", server.URL + "/b", server.URL + "/c", server.URL + "/a", server.URL + "/d"}
+	bodies, errs := client.GetAll(urls)
+	for i, body := range bodies {
+		want := strings.TrimPrefix(urls[i], server.URL)
+		if errs[i] != nil || string(body) != want {
+			t.Errorf("GetAll()[%d] = %q, %v, want %q", i, body, errs[i], want)
+		}
+	}
+	if most.Load() > 2 {
+		t.Errorf("expected at most 2 downloads at once, got %d", most.Load())
+	}
+	if requests.Load() != 4 {
+		t.Errorf("expected one request per URL, got %d", requests.Load())
+	}
+}
+
+func TestClient_RateLimit(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "ok")
+	}))
+	defer server.Close()
+
+	client := NewClient(Options{RateLimit: 20})
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		if _, err := client.Get(fmt.Sprintf("%s/%d", server.URL, i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// The second and third requests wait 50ms each
+	if elapsed := time.Since(start); elapsed < 90*time.Millisecond {
+		t.Errorf("3 requests at 20 per second took %s, want at least 100ms", elapsed)
+	}
+	if stats := client.Stats(); stats.Throttled < 90*time.Millisecond {
+		t.Errorf("Stats().Throttled = %s, want at least 100ms", stats.Throttled)
+	}
+}
+
+func TestParseProxy(t *testing.T) {
+	tests := []struct {
+		proxy string
+		valid bool
+	}{
+		{"http://proxy.example.com:8080", true},
+		{"socks5://localhost:1080", true},
+		{"ftp://proxy.example.com", false},
+		{"proxy.example.com:8080", false},
+		{"http://", false},
+	}
+	for _, tt := range tests {
+		if _, err := ParseProxy(tt.proxy); (err == nil) != tt.valid {
+			t.Errorf("ParseProxy(%q) error = %v, want valid %v", tt.proxy, err, tt.valid)
+		}
+	}
+}
@@ -0,0 +1,154 @@
+// Package outline builds and exports the heading structure of a rendered
+// document so external systems can link into sections of the generated PDF.
+package outline
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"unicode"
+
+	"github.com/fredcamaral/md-to-pdf/internal/fsutil"
+	"gopkg.in/yaml.v3"
+)
+
+// Heading is a single heading recorded while rendering, in document order.
+type Heading struct {
+	Level int
+	Title string
+	Slug  string
+	Page  int
+	Link  string // Overrides the link built from the PDF path, e.g. into a split part
+}
+
+// Node is a heading in the outline tree.
+type Node struct {
+	Level    int     `json:"level" yaml:"level"`
+	Title    string  `json:"title" yaml:"title"`
+	Slug     string  `json:"slug" yaml:"slug"`
+	Page     int     `json:"page" yaml:"page"`
+	Link     string  `json:"link,omitempty" yaml:"link,omitempty"`
+	Children []*Node `json:"children,omitempty" yaml:"children,omitempty"`
+}
+
+// Document is the exported outline of a single PDF.
+type Document struct {
+	Source   string  `json:"source" yaml:"source"`
+	PDF      string  `json:"pdf" yaml:"pdf"`
+	Headings []*Node `json:"headings" yaml:"headings"`
+}
+
+// Build nests a flat, ordered list of headings into a tree. A heading becomes
+// a child of the closest preceding heading with a lower level, so skipped
+// levels (H1 followed by H3) still nest under the H1.
+func Build(headings []Heading, pdfPath string) []*Node {
+	roots := make([]*Node, 0)
+	var stack []*Node
+
+	for _, h := range headings {
+		node := &Node{
+			Level: h.Level,
+			Title: h.Title,
+			Slug:  h.Slug,
+			Page:  h.Page,
+		}
+		if h.Link != "" {
+			node.Link = h.Link
+		} else if pdfPath != "" {
+			node.Link = fmt.Sprintf("%s#page=%d", filepath.Base(pdfPath), h.Page)
+		}
+
+		for len(stack) > 0 && stack[len(stack)-1].Level >= h.Level {
+			stack = stack[:len(stack)-1]
+		}
+
+		if len(stack) == 0 {
+			roots = append(roots, node)
+		} else {
+			parent := stack[len(stack)-1]
+			parent.Children = append(parent.Children, node)
+		}
+		stack = append(stack, node)
+	}
+
+	return roots
+}
+
+// Write exports the outline to path. The format is chosen from the file
+// extension: ".yaml" and ".yml" produce YAML, anything else produces JSON.
+func Write(path, source, pdfPath string, headings []Heading) error {
+	doc := Document{
+		Source:   source,
+		PDF:      pdfPath,
+		Headings: Build(headings, pdfPath),
+	}
+
+	var data []byte
+	var err error
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		data, err = yaml.Marshal(doc)
+	default:
+		data, err = json.MarshalIndent(doc, "", "  ")
+		data = append(data, '\n')
+	}
+	if err != nil {
+		return fmt.Errorf("failed to encode outline: %w", err)
+	}
+
+	if err := fsutil.WriteFile(path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write outline file: %w", err)
+	}
+	return nil
+}
+
+// Slugify converts heading text into a URL-friendly anchor using the same
+// conventions as GitHub: lowercase, spaces become hyphens, and punctuation
+// other than hyphens and underscores is dropped.
+func Slugify(title string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(strings.TrimSpace(title)) {
+		switch {
+		case unicode.IsLetter(r), unicode.IsDigit(r), r == '-', r == '_':
+			b.WriteRune(r)
+		case unicode.IsSpace(r):
+			b.WriteRune('-')
+		}
+	}
+	return b.String()
+}
+
+// Slugger gives the headings of one document unique slugs, the way GitHub
+// does: the first heading with a slug keeps it and later ones get "-1",
+// "-2" and so on, skipping slugs that other headings already have. The
+// result only depends on the titles and their order, so every pass over the
+// same headings agrees.
+type Slugger struct {
+	// suffixes holds every slug handed out, with the last suffix added to
+	// it for a duplicate
+	suffixes map[string]int
+}
+
+// NewSlugger creates a Slugger for a new document.
+func NewSlugger() *Slugger {
+	return &Slugger{suffixes: make(map[string]int)}
+}
+
+// Slug returns the unique slug of the next heading titled title.
+func (s *Slugger) Slug(title string) string {
+	base := Slugify(title)
+	slug := base
+	if n, taken := s.suffixes[base]; taken {
+		for {
+			n++
+			slug = fmt.Sprintf("%s-%d", base, n)
+			if _, taken := s.suffixes[slug]; !taken {
+				break
+			}
+		}
+		s.suffixes[base] = n
+	}
+	s.suffixes[slug] = 0
+	return slug
+}
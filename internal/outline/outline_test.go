@@ -0,0 +1,161 @@
+package outline
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func sampleHeadings() []Heading {
+	return []Heading{
+		{Level: 1, Title: "Introduction", Slug: "introduction", Page: 1},
+		{Level: 2, Title: "Background", Slug: "background", Page: 1},
+		{Level: 3, Title: "History", Slug: "history", Page: 2},
+		{Level: 2, Title: "Goals", Slug: "goals", Page: 3},
+		{Level: 1, Title: "Usage", Slug: "usage", Page: 4},
+		{Level: 3, Title: "Skipped Level", Slug: "skipped-level", Page: 4},
+	}
+}
+
+func TestBuild(t *testing.T) {
+	roots := Build(sampleHeadings(), "out/doc.pdf")
+
+	if len(roots) != 2 {
+		t.Fatalf("expected 2 root headings, got %d", len(roots))
+	}
+
+	intro := roots[0]
+	if intro.Title != "Introduction" || len(intro.Children) != 2 {
+		t.Fatalf("Introduction should have 2 children, got %+v", intro)
+	}
+	if intro.Children[0].Title != "Background" || len(intro.Children[0].Children) != 1 {
+		t.Errorf("Background should contain History, got %+v", intro.Children[0])
+	}
+	if intro.Children[1].Title != "Goals" {
+		t.Errorf("second child should be Goals, got %q", intro.Children[1].Title)
+	}
+
+	usage := roots[1]
+	if len(usage.Children) != 1 || usage.Children[0].Level != 3 {
+		t.Errorf("H3 after H1 should nest under the H1, got %+v", usage.Children)
+	}
+
+	if intro.Children[0].Children[0].Link != "doc.pdf#page=2" {
+		t.Errorf("Link = %q, want %q", intro.Children[0].Children[0].Link, "doc.pdf#page=2")
+	}
+}
+
+func TestBuild_Empty(t *testing.T) {
+	roots := Build(nil, "doc.pdf")
+	if roots == nil || len(roots) != 0 {
+		t.Errorf("Build(nil) should return an empty, non-nil slice, got %v", roots)
+	}
+}
+
+func TestWrite_JSON(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "outline.json")
+
+	if err := Write(path, "doc.md", "doc.pdf", sampleHeadings()); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read outline: %v", err)
+	}
+
+	var doc Document
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("outline is not valid JSON: %v", err)
+	}
+	if doc.Source != "doc.md" || doc.PDF != "doc.pdf" {
+		t.Errorf("unexpected source/pdf: %q / %q", doc.Source, doc.PDF)
+	}
+	if len(doc.Headings) != 2 {
+		t.Errorf("expected 2 root headings, got %d", len(doc.Headings))
+	}
+}
+
+func TestWrite_YAML(t *testing.T) {
+	for _, ext := range []string{".yaml", ".yml"} {
+		t.Run(ext, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "outline"+ext)
+
+			if err := Write(path, "doc.md", "doc.pdf", sampleHeadings()); err != nil {
+				t.Fatalf("Write failed: %v", err)
+			}
+
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("failed to read outline: %v", err)
+			}
+
+			var doc Document
+			if err := yaml.Unmarshal(data, &doc); err != nil {
+				t.Fatalf("outline is not valid YAML: %v", err)
+			}
+			if len(doc.Headings) != 2 || doc.Headings[0].Children[0].Slug != "background" {
+				t.Errorf("unexpected YAML outline: %+v", doc.Headings)
+			}
+		})
+	}
+}
+
+func TestWrite_InvalidPath(t *testing.T) {
+	err := Write(filepath.Join(t.TempDir(), "missing", "outline.json"), "doc.md", "doc.pdf", nil)
+	if err == nil {
+		t.Error("Write should fail when the directory does not exist")
+	}
+}
+
+func TestSlugify(t *testing.T) {
+	tests := []struct {
+		title string
+		want  string
+	}{
+		{"Introduction", "introduction"},
+		{"Getting Started", "getting-started"},
+		{"What's new in v2.0?", "whats-new-in-v20"},
+		{"snake_case & kebab-case", "snake_case--kebab-case"},
+		{"  Padded  ", "padded"},
+		{"Überblick", "überblick"},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		if got := Slugify(tt.title); got != tt.want {
+			t.Errorf("Slugify(%q) = %q, want %q", tt.title, got, tt.want)
+		}
+	}
+}
+
+func TestSlugger(t *testing.T) {
+	tests := []struct {
+		name   string
+		titles []string
+		want   []string
+	}{
+		{"unique titles", []string{"Intro", "Usage"}, []string{"intro", "usage"}},
+		{"repeated title", []string{"Overview", "Overview", "Overview"}, []string{"overview", "overview-1", "overview-2"}},
+		{"suffix taken by a title", []string{"Overview", "Overview", "Overview 1", "Overview"}, []string{"overview", "overview-1", "overview-1-1", "overview-2"}},
+		{"suffixed title first", []string{"Overview 1", "Overview", "Overview"}, []string{"overview-1", "overview", "overview-2"}},
+		{"same slug from different titles", []string{"What's new?", "Whats new"}, []string{"whats-new", "whats-new-1"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Two passes over the same headings agree
+			for pass := 0; pass < 2; pass++ {
+				slugger := NewSlugger()
+				for i, title := range tt.titles {
+					if got := slugger.Slug(title); got != tt.want[i] {
+						t.Errorf("Slug(%q) = %q, want %q", title, got, tt.want[i])
+					}
+				}
+			}
+		})
+	}
+}
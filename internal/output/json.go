@@ -2,10 +2,13 @@ package output
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"time"
+
+	"github.com/fredcamaral/md-to-pdf/internal/parser"
 )
 
 // ConversionResult represents the result of a single file conversion.
@@ -16,6 +19,82 @@ type ConversionResult struct {
 	DurationMs    int64  `json:"duration_ms"`
 	FileSizeBytes int64  `json:"file_size_bytes,omitempty"`
 	Error         string `json:"error,omitempty"`
+	// ErrorPosition is where the error is in the markdown source, when it
+	// is a mistake in the document
+	ErrorPosition *SourcePosition `json:"error_position,omitempty"`
+	Warnings      []string        `json:"warnings,omitempty"`
+	// Skipped is set for inputs not converted because the batch was
+	// interrupted
+	Skipped bool `json:"skipped,omitempty"`
+	// Parts lists the files written instead of Output when the PDF was split
+	// to stay under the maximum output size
+	Parts []PartResult `json:"parts,omitempty"`
+	// Cache is "hit" when the PDF came from the render cache and "miss" when
+	// it was rendered and stored, empty without a cache directory
+	Cache string `json:"cache,omitempty"`
+	// Accessibility is the report of --check, which audits instead of
+	// converting
+	Accessibility *AccessibilityReport `json:"accessibility,omitempty"`
+	// Network is the activity of fetching remote resources, omitted when
+	// there was none
+	Network *NetworkActivity `json:"network,omitempty"`
+}
+
+// SourcePosition locates an error in the markdown source.
+type SourcePosition struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+	// Snippet is the source line and a caret under the column
+	Snippet string `json:"snippet"`
+}
+
+// NetworkActivity counts the requests made for remote resources.
+type NetworkActivity struct {
+	Requests    int   `json:"requests"`
+	Downloads   int   `json:"downloads"`
+	Bytes       int64 `json:"bytes"`
+	CacheHits   int   `json:"cache_hits"`
+	Revalidated int   `json:"revalidated"`
+	Stale       int   `json:"stale"`
+	Retries     int   `json:"retries"`
+	Resumed     int   `json:"resumed"`
+	Failures    int   `json:"failures"`
+	ThrottledMs int64 `json:"throttled_ms"`
+}
+
+// add adds the counts of other to a.
+func (a *NetworkActivity) add(other *NetworkActivity) {
+	a.Requests += other.Requests
+	a.Downloads += other.Downloads
+	a.Bytes += other.Bytes
+	a.CacheHits += other.CacheHits
+	a.Revalidated += other.Revalidated
+	a.Stale += other.Stale
+	a.Retries += other.Retries
+	a.Resumed += other.Resumed
+	a.Failures += other.Failures
+	a.ThrottledMs += other.ThrottledMs
+}
+
+// AccessibilityReport lists the accessibility issues found in one input.
+type AccessibilityReport struct {
+	Issues []AccessibilityIssue `json:"issues"`
+}
+
+// AccessibilityIssue is one accessibility issue. Line is omitted for issues
+// in the configuration, such as low-contrast colors.
+type AccessibilityIssue struct {
+	Rule    string `json:"rule"`
+	Line    int    `json:"line,omitempty"`
+	Message string `json:"message"`
+}
+
+// PartResult describes one file of a split PDF.
+type PartResult struct {
+	Path      string `json:"path"`
+	FirstPage int    `json:"first_page"`
+	LastPage  int    `json:"last_page"`
+	SizeBytes int64  `json:"size_bytes"`
 }
 
 // BatchResult represents results for multiple conversions.
@@ -26,11 +105,20 @@ type BatchResult struct {
 
 // Summary provides aggregate statistics for batch conversions.
 type Summary struct {
-	Total      int   `json:"total"`
-	Succeeded  int   `json:"succeeded"`
-	Failed     int   `json:"failed"`
-	TotalMs    int64 `json:"total_duration_ms"`
-	TotalBytes int64 `json:"total_size_bytes"`
+	Total       int   `json:"total"`
+	Succeeded   int   `json:"succeeded"`
+	Failed      int   `json:"failed"`
+	Skipped     int   `json:"skipped,omitempty"`
+	TotalMs     int64 `json:"total_duration_ms"`
+	TotalBytes  int64 `json:"total_size_bytes"`
+	CacheHits   int   `json:"cache_hits,omitempty"`
+	CacheMisses int   `json:"cache_misses,omitempty"`
+	// AccessibilityIssues totals the issues found by --check
+	AccessibilityIssues int `json:"accessibility_issues,omitempty"`
+	// Network totals the network activity of the results
+	Network *NetworkActivity `json:"network,omitempty"`
+	// Interrupted is set when the batch was stopped with Ctrl+C
+	Interrupted bool `json:"interrupted,omitempty"`
 }
 
 // Formatter handles output formatting.
@@ -38,6 +126,9 @@ type Formatter struct {
 	writer   io.Writer
 	jsonMode bool
 	results  []ConversionResult
+	// interrupted reports the batch as stopped before all inputs were
+	// converted
+	interrupted bool
 }
 
 // NewFormatter creates a new output formatter.
@@ -59,8 +150,8 @@ func (f *Formatter) IsJSON() bool {
 	return f.jsonMode
 }
 
-// RecordSuccess records a successful conversion.
-func (f *Formatter) RecordSuccess(input, output string, duration time.Duration) {
+// RecordSuccess records a successful conversion with any warnings it raised.
+func (f *Formatter) RecordSuccess(input, output string, duration time.Duration, warnings ...string) {
 	var fileSize int64
 	if info, err := os.Stat(output); err == nil {
 		fileSize = info.Size()
@@ -72,10 +163,72 @@ func (f *Formatter) RecordSuccess(input, output string, duration time.Duration)
 		Output:        output,
 		DurationMs:    duration.Milliseconds(),
 		FileSizeBytes: fileSize,
+		Warnings:      warnings,
+	}
+	f.results = append(f.results, result)
+}
+
+// RecordCheck records an input checked for accessibility issues.
+func (f *Formatter) RecordCheck(input string, duration time.Duration, issues []AccessibilityIssue) {
+	if issues == nil {
+		issues = []AccessibilityIssue{}
+	}
+	result := ConversionResult{
+		Success:       true,
+		Input:         input,
+		DurationMs:    duration.Milliseconds(),
+		Accessibility: &AccessibilityReport{Issues: issues},
 	}
 	f.results = append(f.results, result)
 }
 
+// RecordParts attaches the parts of a split PDF to the most recent result for
+// output. The result's file size becomes the total size of the parts.
+func (f *Formatter) RecordParts(output string, parts []PartResult) {
+	for i := len(f.results) - 1; i >= 0; i-- {
+		result := &f.results[i]
+		if result.Output != output {
+			continue
+		}
+		result.FileSizeBytes = 0
+		for j := range parts {
+			if info, err := os.Stat(parts[j].Path); err == nil {
+				parts[j].SizeBytes = info.Size()
+			}
+			result.FileSizeBytes += parts[j].SizeBytes
+		}
+		result.Parts = parts
+		return
+	}
+}
+
+// RecordCache marks whether the most recent result for output came from the
+// render cache.
+func (f *Formatter) RecordCache(output string, hit bool) {
+	for i := len(f.results) - 1; i >= 0; i-- {
+		if f.results[i].Output != output {
+			continue
+		}
+		f.results[i].Cache = "miss"
+		if hit {
+			f.results[i].Cache = "hit"
+		}
+		return
+	}
+}
+
+// RecordNetwork attaches the network activity of converting input to its
+// most recent result.
+func (f *Formatter) RecordNetwork(input string, activity NetworkActivity) {
+	for i := len(f.results) - 1; i >= 0; i-- {
+		if f.results[i].Input != input {
+			continue
+		}
+		f.results[i].Network = &activity
+		return
+	}
+}
+
 // RecordError records a failed conversion.
 func (f *Formatter) RecordError(input string, duration time.Duration, err error) {
 	result := ConversionResult{
@@ -84,16 +237,36 @@ func (f *Formatter) RecordError(input string, duration time.Duration, err error)
 		DurationMs: duration.Milliseconds(),
 		Error:      err.Error(),
 	}
+	var sourceErr *parser.SourceError
+	if errors.As(err, &sourceErr) {
+		result.ErrorPosition = &SourcePosition{Line: sourceErr.Line, Column: sourceErr.Column, Snippet: sourceErr.Snippet()}
+	}
 	f.results = append(f.results, result)
 }
 
+// RecordSkipped records an input that was not converted because the batch
+// was interrupted.
+func (f *Formatter) RecordSkipped(input string) {
+	f.results = append(f.results, ConversionResult{
+		Input:   input,
+		Error:   "skipped: conversion was interrupted",
+		Skipped: true,
+	})
+}
+
+// SetInterrupted marks the batch as interrupted. Its results are then always
+// printed as a batch, whose summary says so.
+func (f *Formatter) SetInterrupted() {
+	f.interrupted = true
+}
+
 // Print outputs results in the appropriate format.
 func (f *Formatter) Print() error {
 	if !f.jsonMode {
 		return nil // Text output is handled elsewhere
 	}
 
-	if len(f.results) == 1 {
+	if len(f.results) == 1 && !f.interrupted {
 		return f.printJSON(f.results[0])
 	}
 
@@ -115,17 +288,35 @@ func (f *Formatter) printJSON(result ConversionResult) error {
 // printBatchJSON outputs batch results as JSON.
 func (f *Formatter) printBatchJSON() error {
 	summary := Summary{
-		Total: len(f.results),
+		Total:       len(f.results),
+		Interrupted: f.interrupted,
 	}
 
 	for _, r := range f.results {
 		if r.Success {
 			summary.Succeeded++
 			summary.TotalBytes += r.FileSizeBytes
+		} else if r.Skipped {
+			summary.Skipped++
 		} else {
 			summary.Failed++
 		}
 		summary.TotalMs += r.DurationMs
+		switch r.Cache {
+		case "hit":
+			summary.CacheHits++
+		case "miss":
+			summary.CacheMisses++
+		}
+		if r.Accessibility != nil {
+			summary.AccessibilityIssues += len(r.Accessibility.Issues)
+		}
+		if r.Network != nil {
+			if summary.Network == nil {
+				summary.Network = &NetworkActivity{}
+			}
+			summary.Network.add(r.Network)
+		}
 	}
 
 	batch := BatchResult{
@@ -3,9 +3,14 @@ package output
 import (
 	"bytes"
 	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
+
+	"github.com/fredcamaral/md-to-pdf/internal/parser"
 )
 
 func TestNewFormatter(t *testing.T) {
@@ -62,6 +67,75 @@ func TestRecordSuccess(t *testing.T) {
 	}
 }
 
+func TestRecordSuccess_WithWarnings(t *testing.T) {
+	f := NewFormatter(true)
+	var buf bytes.Buffer
+	f.SetWriter(&buf)
+
+	f.RecordSuccess("input.md", "output.pdf", time.Millisecond, "image logo.png: removed active content")
+
+	if warnings := f.Results()[0].Warnings; len(warnings) != 1 {
+		t.Fatalf("expected 1 warning, got %v", warnings)
+	}
+	if err := f.Print(); err != nil {
+		t.Fatalf("Print failed: %v", err)
+	}
+	if !strings.Contains(buf.String(), `"warnings": [`) {
+		t.Errorf("JSON output should include warnings, got: %s", buf.String())
+	}
+
+	// Results without warnings omit the field
+	f = NewFormatter(true)
+	buf.Reset()
+	f.SetWriter(&buf)
+	f.RecordSuccess("input.md", "output.pdf", time.Millisecond)
+	if err := f.Print(); err != nil {
+		t.Fatalf("Print failed: %v", err)
+	}
+	if strings.Contains(buf.String(), "warnings") {
+		t.Errorf("JSON output should omit empty warnings, got: %s", buf.String())
+	}
+}
+
+func TestRecordParts(t *testing.T) {
+	dir := t.TempDir()
+	first := filepath.Join(dir, "doc-1.pdf")
+	second := filepath.Join(dir, "doc-2.pdf")
+	if err := os.WriteFile(first, make([]byte, 300), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(second, make([]byte, 200), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	f := NewFormatter(true)
+	f.RecordSuccess("doc.md", filepath.Join(dir, "doc.pdf"), time.Millisecond)
+	f.RecordParts(filepath.Join(dir, "doc.pdf"), []PartResult{
+		{Path: first, FirstPage: 1, LastPage: 4},
+		{Path: second, FirstPage: 5, LastPage: 6},
+	})
+
+	r := f.Results()[0]
+	if len(r.Parts) != 2 {
+		t.Fatalf("expected 2 parts, got %d", len(r.Parts))
+	}
+	if r.Parts[0].SizeBytes != 300 || r.Parts[1].SizeBytes != 200 {
+		t.Errorf("part sizes = %d, %d, want 300, 200", r.Parts[0].SizeBytes, r.Parts[1].SizeBytes)
+	}
+	if r.FileSizeBytes != 500 {
+		t.Errorf("FileSizeBytes = %d, want the total of the parts", r.FileSizeBytes)
+	}
+
+	var buf bytes.Buffer
+	f.SetWriter(&buf)
+	if err := f.Print(); err != nil {
+		t.Fatalf("Print failed: %v", err)
+	}
+	if !strings.Contains(buf.String(), `"first_page": 5`) {
+		t.Errorf("JSON output should list part page ranges, got %s", buf.String())
+	}
+}
+
 func TestRecordError(t *testing.T) {
 	f := NewFormatter(true)
 	duration := 50 * time.Millisecond
@@ -89,6 +163,22 @@ func TestRecordError(t *testing.T) {
 	}
 }
 
+func TestRecordError_SourcePosition(t *testing.T) {
+	f := NewFormatter(true)
+	sourceErr := parser.NewSourceError([]byte("Text\n\n<!-- endif -->\n"), 6, "<!-- endif --> without a matching <!-- if:name -->")
+	f.RecordError("doc.md", time.Millisecond, fmt.Errorf("conversion failed: %w", sourceErr))
+
+	position := f.Results()[0].ErrorPosition
+	if position == nil || position.Line != 3 || position.Column != 1 || position.Snippet != "3 | <!-- endif -->\n  | ^" {
+		t.Errorf("ErrorPosition = %+v", position)
+	}
+
+	f.RecordError("other.md", time.Millisecond, &testError{"not in the source"})
+	if f.Results()[1].ErrorPosition != nil {
+		t.Error("errors outside the source should have no position")
+	}
+}
+
 type testError struct {
 	msg string
 }
@@ -285,3 +375,116 @@ func TestConversionResultJSON_ErrorCase(t *testing.T) {
 		t.Errorf("output should be omitted for error: %s", jsonStr)
 	}
 }
+
+func TestRecordCache(t *testing.T) {
+	f := NewFormatter(true)
+	f.RecordSuccess("a.md", "a.pdf", time.Millisecond)
+	f.RecordSuccess("b.md", "b.pdf", time.Millisecond)
+	f.RecordCache("a.pdf", true)
+	f.RecordCache("b.pdf", false)
+
+	if got := f.Results()[0].Cache; got != "hit" {
+		t.Errorf("a.pdf cache = %q, want hit", got)
+	}
+
+	var buf bytes.Buffer
+	f.SetWriter(&buf)
+	if err := f.Print(); err != nil {
+		t.Fatalf("Print failed: %v", err)
+	}
+	var batch BatchResult
+	if err := json.Unmarshal(buf.Bytes(), &batch); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if batch.Summary.CacheHits != 1 || batch.Summary.CacheMisses != 1 {
+		t.Errorf("summary cache = %d hits, %d misses, want 1 and 1", batch.Summary.CacheHits, batch.Summary.CacheMisses)
+	}
+}
+
+func TestRecordNetwork(t *testing.T) {
+	f := NewFormatter(true)
+	f.RecordSuccess("a.md", "a.pdf", time.Millisecond)
+	f.RecordSuccess("b.md", "b.pdf", time.Millisecond)
+	f.RecordSuccess("c.md", "c.pdf", time.Millisecond)
+	f.RecordNetwork("a.md", NetworkActivity{Requests: 3, Downloads: 1, Bytes: 100, Retries: 2})
+	f.RecordNetwork("b.md", NetworkActivity{Requests: 1, Revalidated: 1})
+
+	var buf bytes.Buffer
+	f.SetWriter(&buf)
+	if err := f.Print(); err != nil {
+		t.Fatalf("Print failed: %v", err)
+	}
+	var batch BatchResult
+	if err := json.Unmarshal(buf.Bytes(), &batch); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if batch.Results[2].Network != nil {
+		t.Errorf("results without network activity should omit it, got %+v", batch.Results[2].Network)
+	}
+	want := NetworkActivity{Requests: 4, Downloads: 1, Bytes: 100, Revalidated: 1, Retries: 2}
+	if batch.Summary.Network == nil || *batch.Summary.Network != want {
+		t.Errorf("summary network = %+v, want %+v", batch.Summary.Network, want)
+	}
+}
+
+func TestRecordCheck(t *testing.T) {
+	var buf bytes.Buffer
+	f := NewFormatter(true)
+	f.SetWriter(&buf)
+
+	f.RecordCheck("a.md", 10*time.Millisecond, []AccessibilityIssue{
+		{Rule: "missing-alt", Line: 3, Message: `image "x.png" has no alt text`},
+		{Rule: "low-contrast", Message: "blockquote text has low contrast"},
+	})
+	f.RecordCheck("b.md", 10*time.Millisecond, nil)
+	if err := f.Print(); err != nil {
+		t.Fatalf("Print() error: %v", err)
+	}
+
+	out := buf.String()
+	if !strings.Contains(out, `"issues": []`) {
+		t.Errorf("a clean input should report an empty issue list:\n%s", out)
+	}
+	if strings.Contains(out, `"output"`) {
+		t.Errorf("a check writes no output:\n%s", out)
+	}
+	var batch BatchResult
+	if err := json.Unmarshal(buf.Bytes(), &batch); err != nil {
+		t.Fatalf("failed to parse JSON output: %v\nOutput: %s", err, out)
+	}
+	if batch.Summary.AccessibilityIssues != 2 {
+		t.Errorf("Summary.AccessibilityIssues = %d, want 2", batch.Summary.AccessibilityIssues)
+	}
+	issue := batch.Results[0].Accessibility.Issues[1]
+	if issue.Rule != "low-contrast" || issue.Line != 0 {
+		t.Errorf("second issue = %+v", issue)
+	}
+}
+
+func TestRecordSkipped_Interrupted(t *testing.T) {
+	var buf bytes.Buffer
+	f := NewFormatter(true)
+	f.SetWriter(&buf)
+
+	f.RecordSuccess("a.md", "a.pdf", 100*time.Millisecond)
+	f.RecordSkipped("b.md")
+	f.SetInterrupted()
+
+	if err := f.Print(); err != nil {
+		t.Fatalf("Print() error: %v", err)
+	}
+
+	var batch BatchResult
+	if err := json.Unmarshal(buf.Bytes(), &batch); err != nil {
+		t.Fatalf("failed to parse JSON output: %v\nOutput: %s", err, buf.String())
+	}
+	if !batch.Summary.Interrupted {
+		t.Error("Summary.Interrupted should be set")
+	}
+	if batch.Summary.Succeeded != 1 || batch.Summary.Skipped != 1 || batch.Summary.Failed != 0 {
+		t.Errorf("Summary = %+v, want 1 succeeded and 1 skipped", batch.Summary)
+	}
+	if !batch.Results[1].Skipped || batch.Results[1].Input != "b.md" {
+		t.Errorf("Results[1] = %+v, want skipped b.md", batch.Results[1])
+	}
+}
@@ -0,0 +1,171 @@
+package parser
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/yuin/goldmark/ast"
+)
+
+var (
+	// conditionMarker matches the markers of conditional blocks:
+	// <!-- if:name -->, <!-- if:!name -->, <!-- else --> and <!-- endif -->.
+	conditionMarker = regexp.MustCompile(`^<!--\s*(?:if:\s*(!?)([A-Za-z0-9_-]+)|(else)|(endif))\s*-->$`)
+	conditionName   = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+	// directiveComment matches comments made of one word, optionally
+	// followed by a colon and arguments, such as <!-- toc --> and
+	// <!-- shift-headings: 1 -->. They are markers rather than comments.
+	directiveComment = regexp.MustCompile(`^<!--\s*[A-Za-z][A-Za-z0-9-]*(?::[^\n]*?)?\s*-->$`)
+)
+
+// IsConditionName reports whether name can be used in <!-- if:name -->.
+func IsConditionName(name string) bool {
+	return conditionName.MatchString(name)
+}
+
+// IsMarker reports whether line is a marker, such as <!-- toc --> or
+// <!-- if:name -->, rather than a comment or text.
+func IsMarker(line string) bool {
+	line = strings.TrimSpace(line)
+	return conditionMarker.MatchString(line) || directiveComment.MatchString(line)
+}
+
+// ResolveComments removes the comments of doc and the content of
+// conditional blocks whose condition does not hold. A block between
+// <!-- if:name --> and <!-- endif --> is kept when name is defined,
+// <!-- if:!name --> when it is not, and an optional <!-- else --> starts
+// the content kept otherwise. Blocks may nest, and also work within a
+// paragraph. Markers such as <!-- toc --> are not comments and are kept.
+//
+// The returned source is a copy of source with the removed parts blanked,
+// so nothing removed reaches plugins reading the source while the positions
+// in doc stay valid.
+func ResolveComments(doc ast.Node, source []byte, defines map[string]bool) ([]byte, error) {
+	r := &commentResolver{source: source, out: bytes.Clone(source), defines: defines}
+	if err := r.resolve(doc); err != nil {
+		return nil, err
+	}
+	return r.out, nil
+}
+
+type commentResolver struct {
+	source  []byte
+	out     []byte
+	defines map[string]bool
+}
+
+// condition is an open conditional block.
+type condition struct {
+	marker  ast.Node
+	holds   bool // The condition of the if marker holds
+	outer   bool // The content around the block is kept
+	keep    bool // The current branch is kept
+	hasElse bool
+	from    int // Start of the current branch in the source
+}
+
+// resolve resolves the children of parent, then those of each kept child.
+func (r *commentResolver) resolve(parent ast.Node) error {
+	var open []*condition
+	kept := func() bool { return len(open) == 0 || open[len(open)-1].keep }
+
+	for n := parent.FirstChild(); n != nil; {
+		next := n.NextSibling()
+		start, stop, text, comment := r.html(n)
+		match := conditionMarker.FindStringSubmatch(text)
+		switch {
+		case match != nil && match[2] != "":
+			c := &condition{marker: n, holds: r.defines[match[2]] != (match[1] == "!"), outer: kept(), from: stop}
+			c.keep = c.outer && c.holds
+			open = append(open, c)
+		case match != nil && match[3] != "":
+			if len(open) == 0 || open[len(open)-1].hasElse {
+				return r.errorf(start, "%s without a matching <!-- if:name -->", text)
+			}
+			c := open[len(open)-1]
+			r.closeBranch(c, start)
+			c.hasElse = true
+			c.keep = c.outer && !c.holds
+			c.from = stop
+		case match != nil:
+			if len(open) == 0 {
+				return r.errorf(start, "%s without a matching <!-- if:name -->", text)
+			}
+			r.closeBranch(open[len(open)-1], start)
+			open = open[:len(open)-1]
+		case !kept():
+			// Blanked along with the rest of the branch
+			parent.RemoveChild(parent, n)
+			n = next
+			continue
+		case comment && !directiveComment.MatchString(text):
+			// Comments are removed like markers
+		default:
+			if err := r.resolve(n); err != nil {
+				return err
+			}
+			n = next
+			continue
+		}
+		parent.RemoveChild(parent, n)
+		r.blank(start, stop)
+		n = next
+	}
+
+	if len(open) > 0 {
+		start, _, text, _ := r.html(open[len(open)-1].marker)
+		return r.errorf(start, "%s without a matching <!-- endif -->", text)
+	}
+	return nil
+}
+
+// closeBranch blanks the branch of c ending at stop when it was left out.
+func (r *commentResolver) closeBranch(c *condition, stop int) {
+	if c.outer && !c.keep {
+		r.blank(c.from, stop)
+	}
+}
+
+// html returns the position and text of an HTML block or raw inline HTML,
+// and whether it is a comment. Other nodes have no text.
+func (r *commentResolver) html(n ast.Node) (start, stop int, text string, comment bool) {
+	switch n := n.(type) {
+	case *ast.HTMLBlock:
+		lines := n.Lines()
+		if lines.Len() == 0 {
+			return 0, 0, "", false
+		}
+		start, stop = lines.At(0).Start, lines.At(lines.Len()-1).Stop
+		if n.HasClosure() {
+			stop = n.ClosureLine.Stop
+		}
+		text = string(bytes.TrimSpace(r.source[start:stop]))
+		return start, stop, text, n.HTMLBlockType == ast.HTMLBlockType2
+	case *ast.RawHTML:
+		if n.Segments.Len() == 0 {
+			return 0, 0, "", false
+		}
+		start, stop = n.Segments.At(0).Start, n.Segments.At(n.Segments.Len()-1).Stop
+		text = string(bytes.TrimSpace(r.source[start:stop]))
+		return start, stop, text, strings.HasPrefix(text, "<!--")
+	}
+	return 0, 0, "", false
+}
+
+// blank replaces the source between start and stop with spaces, keeping
+// line breaks so line numbers do not move.
+func (r *commentResolver) blank(start, stop int) {
+	for i := start; i < stop && i < len(r.out); i++ {
+		if r.out[i] != '\n' && r.out[i] != '\r' {
+			r.out[i] = ' '
+		}
+	}
+}
+
+// errorf returns a SourceError at offset.
+func (r *commentResolver) errorf(offset int, format string, args ...interface{}) error {
+	return NewSourceError(r.source, offset, fmt.Sprintf(format, args...))
+}
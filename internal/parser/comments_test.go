@@ -0,0 +1,122 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/yuin/goldmark/ast"
+)
+
+// resolvedText returns the text left in source once comments and
+// conditional blocks are resolved, with HTML blocks shown as they are.
+func resolvedText(t *testing.T, source string, defines ...string) (string, string) {
+	t.Helper()
+	doc, err := NewMarkdownParser().Parse([]byte(source))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	defined := make(map[string]bool)
+	for _, name := range defines {
+		defined[name] = true
+	}
+	out, err := ResolveComments(doc, []byte(source), defined)
+	if err != nil {
+		t.Fatalf("ResolveComments failed: %v", err)
+	}
+
+	var words []string
+	_ = ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		switch n := n.(type) {
+		case *ast.Text:
+			words = append(words, strings.TrimSpace(string(n.Segment.Value(out))))
+		case *ast.HTMLBlock:
+			segment := n.Lines().At(0)
+			words = append(words, strings.TrimSpace(string(segment.Value(out))))
+		}
+		return ast.WalkContinue, nil
+	})
+	return strings.Join(words, " "), strings.Join(strings.Fields(string(out)), " ")
+}
+
+func TestResolveComments(t *testing.T) {
+	source := "Intro\n\n<!-- if:internal -->\nSecret\n<!-- else -->\nPublic\n<!-- endif -->\n\n" +
+		"<!-- a note\nfor the authors -->\n\nCall <!-- if:internal -->555-0100<!-- else -->the desk<!-- endif --> now.\n\n" +
+		"<!-- toc -->\n\n<!-- if:!internal -->\nOutside\n<!-- endif -->\n"
+
+	tests := []struct {
+		name    string
+		defines []string
+		want    string
+	}{
+		{"undefined", nil, "Intro Public Call the desk now. <!-- toc --> Outside"},
+		{"defined", []string{"internal"}, "Intro Secret Call 555-0100 now. <!-- toc -->"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			text, out := resolvedText(t, source, tt.defines...)
+			if text != tt.want {
+				t.Errorf("document text = %q, want %q", text, tt.want)
+			}
+			if out != tt.want {
+				t.Errorf("source = %q, want %q", out, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveComments_Nested(t *testing.T) {
+	source := "<!-- if:a -->\nA\n<!-- if:b -->\nB\n<!-- else -->\nNot B\n<!-- endif -->\n<!-- endif -->\n\n> Quote\n>\n> <!-- if:b -->\n> Quoted B\n> <!-- endif -->\n"
+
+	tests := []struct {
+		defines []string
+		want    string
+	}{
+		{nil, "Quote"},
+		{[]string{"a"}, "A Not B Quote"},
+		{[]string{"a", "b"}, "A B Quote Quoted B"},
+	}
+	for _, tt := range tests {
+		if text, _ := resolvedText(t, source, tt.defines...); text != tt.want {
+			t.Errorf("defines %v: document text = %q, want %q", tt.defines, text, tt.want)
+		}
+	}
+}
+
+func TestResolveComments_Unbalanced(t *testing.T) {
+	tests := []struct {
+		source string
+		want   string
+	}{
+		{"Text\n\n<!-- if:internal -->\nSecret\n", "line 3, column 1: <!-- if:internal --> without a matching <!-- endif -->"},
+		{"Text\n\n<!-- endif -->\n", "line 3, column 1: <!-- endif --> without a matching <!-- if:name -->"},
+		{"<!-- if:a -->\n<!-- else -->\n<!-- else -->\n<!-- endif -->\n", "line 3, column 1: <!-- else --> without a matching <!-- if:name -->"},
+		{"Some <!-- if:a -->text\n\n<!-- endif -->\n", "line 1, column 6: <!-- if:a --> without a matching <!-- endif -->"},
+	}
+	for _, tt := range tests {
+		doc, err := NewMarkdownParser().Parse([]byte(tt.source))
+		if err != nil {
+			t.Fatalf("Parse failed: %v", err)
+		}
+		_, err = ResolveComments(doc, []byte(tt.source), nil)
+		if err == nil || err.Error() != tt.want {
+			t.Errorf("%q: error = %v, want %q", tt.source, err, tt.want)
+		}
+	}
+}
+
+func TestIsMarker(t *testing.T) {
+	markers := []string{"<!-- toc -->", "<!-- pagebreak -->", "  <!-- if:!draft -->\n", "<!-- else -->", "<!-- shift-headings: 1 -->"}
+	for _, line := range markers {
+		if !IsMarker(line) {
+			t.Errorf("IsMarker(%q) = false, want true", line)
+		}
+	}
+	for _, line := range []string{"<!-- a note for reviewers -->", "text <!-- toc -->", "# Heading"} {
+		if IsMarker(line) {
+			t.Errorf("IsMarker(%q) = true, want false", line)
+		}
+	}
+}
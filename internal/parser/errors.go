@@ -0,0 +1,74 @@
+package parser
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// SourceError is a mistake at a position in the markdown source, such as
+// an unmatched conditional block marker.
+type SourceError struct {
+	File    string // Set by the caller when the source is a file
+	Line    int    // 1-based
+	Column  int    // 1-based, in characters
+	Message string
+	// Text is the source line, without its line break
+	Text string
+}
+
+// NewSourceError returns the error at offset in source.
+func NewSourceError(source []byte, offset int, message string) *SourceError {
+	offset = min(max(offset, 0), len(source))
+	lineStart := bytes.LastIndexByte(source[:offset], '\n') + 1
+	lineEnd := len(source)
+	if i := bytes.IndexByte(source[offset:], '\n'); i >= 0 {
+		lineEnd = offset + i
+	}
+	return &SourceError{
+		Line:    bytes.Count(source[:lineStart], []byte("\n")) + 1,
+		Column:  utf8.RuneCount(source[lineStart:offset]) + 1,
+		Message: message,
+		Text:    strings.TrimRight(string(source[lineStart:lineEnd]), "\r"),
+	}
+}
+
+func (e *SourceError) Error() string {
+	return fmt.Sprintf("line %d, column %d: %s", e.Line, e.Column, e.Message)
+}
+
+// Location returns the position as file:line:column, or line:column when
+// the file is not known.
+func (e *SourceError) Location() string {
+	if e.File == "" {
+		return fmt.Sprintf("%d:%d", e.Line, e.Column)
+	}
+	return fmt.Sprintf("%s:%d:%d", e.File, e.Line, e.Column)
+}
+
+// Snippet returns the source line numbered in a gutter and, below it, a
+// caret under the column:
+//
+//	12 | Some <!-- endif -->
+//	   |      ^
+//
+// Tabs before the column are repeated in the caret line, so the caret lines
+// up however wide tabs are shown.
+func (e *SourceError) Snippet() string {
+	number := fmt.Sprint(e.Line)
+	var indent strings.Builder
+	column := 1
+	for _, r := range e.Text {
+		if column >= e.Column {
+			break
+		}
+		if r == '\t' {
+			indent.WriteRune('\t')
+		} else {
+			indent.WriteRune(' ')
+		}
+		column++
+	}
+	return fmt.Sprintf("%s | %s\n%s | %s^", number, e.Text, strings.Repeat(" ", len(number)), indent.String())
+}
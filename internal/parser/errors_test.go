@@ -0,0 +1,55 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestNewSourceError(t *testing.T) {
+	tests := []struct {
+		name    string
+		source  string
+		offset  int
+		line    int
+		column  int
+		text    string
+		snippet string
+	}{
+		{"start of line", "Text\n\n<!-- endif -->\n", 6, 3, 1, "<!-- endif -->", "3 | <!-- endif -->\n  | ^"},
+		{"within a line", "Some <!-- if:a -->text\n", 5, 1, 6, "Some <!-- if:a -->text", "1 | Some <!-- if:a -->text\n  |      ^"},
+		{"characters, not bytes", "Étape — <!-- else -->", 11, 1, 9, "Étape — <!-- else -->", "1 | Étape — <!-- else -->\n  |         ^"},
+		{"tabs kept", "\t- <!-- endif -->\r\n", 3, 1, 4, "\t- <!-- endif -->", "1 | \t- <!-- endif -->\n  | \t  ^"},
+		{"last line without break", "a\nb\nc\nd\ne\nf\ng\nh\ni\nlast", 18, 10, 1, "last", "10 | last\n   | ^"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := NewSourceError([]byte(tt.source), tt.offset, "mistake")
+			if err.Line != tt.line || err.Column != tt.column || err.Text != tt.text {
+				t.Errorf("NewSourceError() = line %d, column %d, text %q; want %d, %d, %q", err.Line, err.Column, err.Text, tt.line, tt.column, tt.text)
+			}
+			if got := err.Snippet(); got != tt.snippet {
+				t.Errorf("Snippet() =\n%s\nwant\n%s", got, tt.snippet)
+			}
+		})
+	}
+}
+
+func TestSourceError_Location(t *testing.T) {
+	err := NewSourceError([]byte("one\ntwo <!-- endif -->\n"), 8, "<!-- endif --> without a matching <!-- if:name -->")
+	if got := err.Error(); got != "line 2, column 5: <!-- endif --> without a matching <!-- if:name -->" {
+		t.Errorf("Error() = %q", got)
+	}
+	if got := err.Location(); got != "2:5" {
+		t.Errorf("Location() = %q, want 2:5", got)
+	}
+	err.File = "docs/guide.md"
+	if got := err.Location(); got != "docs/guide.md:2:5" {
+		t.Errorf("Location() = %q, want docs/guide.md:2:5", got)
+	}
+
+	var sourceErr *SourceError
+	if !errors.As(fmt.Errorf("wrapped: %w", err), &sourceErr) || sourceErr != err {
+		t.Error("a wrapped SourceError should be found with errors.As")
+	}
+}
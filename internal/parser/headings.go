@@ -0,0 +1,68 @@
+package parser
+
+import (
+	"bytes"
+	"regexp"
+	"strconv"
+
+	"github.com/yuin/goldmark/ast"
+)
+
+// Heading levels supported by Markdown.
+const (
+	MinHeadingLevel = 1
+	MaxHeadingLevel = 6
+)
+
+// shiftMarker matches <!-- shift-headings: N -->, which shifts the headings
+// after it by N more levels until the next such marker.
+var shiftMarker = regexp.MustCompile(`^<!--\s*shift-headings:\s*([+-]?\d+)\s*-->$`)
+
+// ShiftHeadings moves the headings of doc to other levels, so a document
+// included in another one nests under the including document's headings.
+// Levels listed in levelMap take the level they map to; all others move by
+// shift plus the shift of the latest <!-- shift-headings: N --> marker
+// before them. Levels are clamped to 1-6.
+func ShiftHeadings(doc ast.Node, source []byte, shift int, levelMap map[int]int) {
+	markerShift := 0
+	_ = ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		switch n := n.(type) {
+		case *ast.HTMLBlock:
+			if value, ok := markerValue(n, source); ok {
+				markerShift = value
+			}
+		case *ast.Heading:
+			if level, ok := levelMap[n.Level]; ok {
+				n.Level = clampLevel(level)
+			} else {
+				n.Level = clampLevel(n.Level + shift + markerShift)
+			}
+		}
+		return ast.WalkContinue, nil
+	})
+}
+
+// markerValue returns the shift of a <!-- shift-headings: N --> block.
+func markerValue(block *ast.HTMLBlock, source []byte) (int, bool) {
+	lines := block.Lines()
+	if lines.Len() != 1 {
+		return 0, false
+	}
+	segment := lines.At(0)
+	match := shiftMarker.FindSubmatch(bytes.TrimSpace(segment.Value(source)))
+	if match == nil {
+		return 0, false
+	}
+	value, err := strconv.Atoi(string(match[1]))
+	if err != nil {
+		return 0, false
+	}
+	return value, true
+}
+
+func clampLevel(level int) int {
+	return min(max(level, MinHeadingLevel), MaxHeadingLevel)
+}
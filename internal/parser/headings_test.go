@@ -0,0 +1,61 @@
+package parser
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/yuin/goldmark/ast"
+)
+
+func headingLevels(t *testing.T, source string, shift int, levelMap map[int]int) []int {
+	t.Helper()
+	doc, err := NewMarkdownParser().Parse([]byte(source))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	ShiftHeadings(doc, []byte(source), shift, levelMap)
+
+	var levels []int
+	_ = ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if heading, ok := n.(*ast.Heading); ok && entering {
+			levels = append(levels, heading.Level)
+		}
+		return ast.WalkContinue, nil
+	})
+	return levels
+}
+
+func TestShiftHeadings(t *testing.T) {
+	source := "# One\n\n## Two\n\nText\n=====\n\n###### Six\n"
+
+	tests := []struct {
+		name     string
+		shift    int
+		levelMap map[int]int
+		want     []int
+	}{
+		{"unchanged", 0, nil, []int{1, 2, 1, 6}},
+		{"demote", 1, nil, []int{2, 3, 2, 6}},
+		{"promote", -1, nil, []int{1, 1, 1, 5}},
+		{"map", 0, map[int]int{1: 3}, []int{3, 2, 3, 6}},
+		{"map before shift", 1, map[int]int{2: 2}, []int{2, 2, 2, 6}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := headingLevels(t, source, tt.shift, tt.levelMap); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("levels = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestShiftHeadings_Markers(t *testing.T) {
+	source := "# Book\n\n<!-- shift-headings: 1 -->\n\n# Section\n\n## Detail\n\n<!--shift-headings:0-->\n\n# Next\n"
+
+	if got, want := headingLevels(t, source, 0, nil), []int{1, 2, 3, 1}; !reflect.DeepEqual(got, want) {
+		t.Errorf("levels = %v, want %v", got, want)
+	}
+	if got, want := headingLevels(t, source, 1, nil), []int{2, 3, 4, 2}; !reflect.DeepEqual(got, want) {
+		t.Errorf("markers should add to the configured shift: levels = %v, want %v", got, want)
+	}
+}
@@ -14,7 +14,7 @@ type MarkdownParser struct {
 
 func NewMarkdownParser() *MarkdownParser {
 	md := goldmark.New(
-		goldmark.WithExtensions(),
+		goldmark.WithExtensions(Sidenotes, Redactions),
 	)
 
 	return &MarkdownParser{
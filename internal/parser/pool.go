@@ -0,0 +1,26 @@
+package parser
+
+import "sync"
+
+// parsers keeps parsers between conversions, so servers converting many
+// documents build goldmark and its extensions once per worker instead of
+// once per document.
+var parsers = sync.Pool{
+	New: func() interface{} {
+		return NewMarkdownParser()
+	},
+}
+
+// AcquireParser returns a parser from the pool, creating one when the pool
+// is empty. Return it with ReleaseParser once the conversion is done.
+func AcquireParser() *MarkdownParser {
+	return parsers.Get().(*MarkdownParser)
+}
+
+// ReleaseParser returns p to the pool. p must not be used afterwards.
+func ReleaseParser(p *MarkdownParser) {
+	if p == nil {
+		return
+	}
+	parsers.Put(p)
+}
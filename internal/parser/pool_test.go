@@ -0,0 +1,82 @@
+package parser
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/yuin/goldmark/ast"
+)
+
+const benchmarkDocument = `# Report
+
+A paragraph with **bold**, *italic*, ` + "`code`" + ` and a [link](https://example.com).
+
+## Details
+
+- First item
+- Second item
+
+> A quote.
+`
+
+func TestAcquireParser(t *testing.T) {
+	p := AcquireParser()
+	if p == nil || p.goldmark == nil {
+		t.Fatal("AcquireParser should return a ready parser")
+	}
+	ReleaseParser(p)
+	ReleaseParser(nil)
+
+	// Parsers taken from the pool keep the extensions
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			p := AcquireParser()
+			defer ReleaseParser(p)
+			doc, err := p.Parse([]byte("Text^[a note]"))
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			found := false
+			_ = ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+				if entering && n.Kind() == KindSidenote {
+					found = true
+				}
+				return ast.WalkContinue, nil
+			})
+			if !found {
+				t.Error("pooled parser should parse sidenotes")
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func BenchmarkParse_NewParser(b *testing.B) {
+	source := []byte(benchmarkDocument)
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			if _, err := NewMarkdownParser().Parse(source); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func BenchmarkParse_PooledParser(b *testing.B) {
+	source := []byte(benchmarkDocument)
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			p := AcquireParser()
+			if _, err := p.Parse(source); err != nil {
+				b.Fatal(err)
+			}
+			ReleaseParser(p)
+		}
+	})
+}
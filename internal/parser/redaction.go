@@ -0,0 +1,104 @@
+package parser
+
+import (
+	"bytes"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	gmparser "github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+// KindRedaction is the node kind of redacted spans.
+var KindRedaction = ast.NewNodeKind("Redaction")
+
+// Redaction is an inline span written as ||text|| or :redact[text]. The
+// renderer draws a solid box as wide as the text instead of the text, which
+// is kept out of the PDF.
+type Redaction struct {
+	ast.BaseInline
+	Hidden []byte // Hidden text with line breaks folded into spaces
+	// Segment is the span in the source, markers included, for showing the
+	// source with the span blacked out as well
+	Segment text.Segment
+}
+
+// Kind implements ast.Node.
+func (n *Redaction) Kind() ast.NodeKind {
+	return KindRedaction
+}
+
+// Dump implements ast.Node.
+func (n *Redaction) Dump(source []byte, level int) {
+	ast.DumpHelper(n, source, level, map[string]string{"Hidden": string(n.Hidden)}, nil)
+}
+
+// redactionDirective starts the directive form of a redacted span.
+var redactionDirective = []byte(":redact[")
+
+// redactionParser parses ||text|| and :redact[text]. Both may span several
+// lines of a paragraph; \| is a literal bar within ||text||.
+type redactionParser struct{}
+
+func (p *redactionParser) Trigger() []byte {
+	return []byte{'|', ':'}
+}
+
+func (p *redactionParser) Parse(parent ast.Node, block text.Reader, pc gmparser.Context) ast.Node {
+	line, segment := block.PeekLine()
+	if bytes.HasPrefix(line, redactionDirective) {
+		hidden, _, ok := scanBracketed(block, len(redactionDirective))
+		if !ok {
+			return nil
+		}
+		return newRedaction(block, segment.Start, hidden)
+	}
+	if !bytes.HasPrefix(line, []byte("||")) {
+		return nil
+	}
+
+	var hidden []byte
+	start := 2
+	for line != nil {
+		for i := start; i < len(line); i++ {
+			switch {
+			case line[i] == '\\' && i+1 < len(line) && line[i+1] == '|':
+				hidden = append(hidden, line[start:i]...)
+				start = i + 1
+				i++
+			case line[i] == '|' && i+1 < len(line) && line[i+1] == '|':
+				hidden = append(hidden, line[start:i]...)
+				text, _, ok := foldedText(hidden, nil)
+				if !ok {
+					return nil
+				}
+				block.Advance(i + 2)
+				return newRedaction(block, segment.Start, text)
+			}
+		}
+		hidden = append(hidden, line[start:]...)
+		block.AdvanceLine()
+		line, _ = block.PeekLine()
+		start = 0
+	}
+	return nil
+}
+
+// newRedaction creates a redaction for the span from start to the current
+// position of block.
+func newRedaction(block text.Reader, start int, hidden []byte) *Redaction {
+	_, position := block.Position()
+	return &Redaction{Hidden: hidden, Segment: text.NewSegment(start, position.Start)}
+}
+
+type redactionExtension struct{}
+
+// Redactions enables the ||text|| and :redact[text] redaction syntax.
+var Redactions goldmark.Extender = &redactionExtension{}
+
+func (e *redactionExtension) Extend(m goldmark.Markdown) {
+	m.Parser().AddOptions(gmparser.WithInlineParsers(
+		util.Prioritized(&redactionParser{}, 500),
+	))
+}
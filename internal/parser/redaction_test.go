@@ -0,0 +1,52 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/yuin/goldmark/ast"
+)
+
+func TestParse_Redactions(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  []string
+	}{
+		{"bars", "Agent ||J. Smith|| met ||Q||.", []string{"J. Smith", "Q"}},
+		{"directive", "Budget: :redact[$4.2M [est.]] total.", []string{"$4.2M [est.]"}},
+		{"escaped_bar", `Pipe ||a \| b|| here.`, []string{"a | b"}},
+		{"spans_lines", "Name ||first\nlast|| ok.", []string{"first last"}},
+		{"emphasis_inside", "||**bold** secret||", []string{"**bold** secret"}},
+		{"single_bars", "a | b || c", nil},
+		{"empty", "||  || and :redact[]", nil},
+		{"other_colon", "Note: :redacted[x] stays", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node, err := NewMarkdownParser().Parse([]byte(tt.input))
+			if err != nil {
+				t.Fatalf("Parse failed: %v", err)
+			}
+			var got []string
+			_ = ast.Walk(node, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+				if redaction, ok := n.(*Redaction); ok && entering {
+					got = append(got, string(redaction.Hidden))
+					span := string(redaction.Segment.Value([]byte(tt.input)))
+					if !(strings.HasPrefix(span, "||") && strings.HasSuffix(span, "||")) && !(strings.HasPrefix(span, ":redact[") && strings.HasSuffix(span, "]")) {
+						t.Errorf("redaction segment = %q, want the whole span", span)
+					}
+				}
+				return ast.WalkContinue, nil
+			})
+			if len(got) != len(tt.want) {
+				t.Fatalf("got redactions %q, want %q", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("redaction %d = %q, want %q", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
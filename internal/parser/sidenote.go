@@ -0,0 +1,175 @@
+package parser
+
+import (
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	gmparser "github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+// KindSidenote is the node kind of sidenotes.
+var KindSidenote = ast.NewNodeKind("Sidenote")
+
+// Sidenote is an inline note written as ^[note text]. The renderer places
+// the note in the page margin next to the line that references it. Its
+// children are the note text, with line breaks folded into spaces, as
+// ast.String nodes and its redacted spans as Redaction nodes.
+type Sidenote struct {
+	ast.BaseInline
+}
+
+// Kind implements ast.Node.
+func (n *Sidenote) Kind() ast.NodeKind {
+	return KindSidenote
+}
+
+// Dump implements ast.Node.
+func (n *Sidenote) Dump(source []byte, level int) {
+	ast.DumpHelper(n, source, level, nil, nil)
+}
+
+// sidenoteParser parses ^[...] with balanced brackets. The note may span
+// several lines of a paragraph.
+type sidenoteParser struct{}
+
+func (p *sidenoteParser) Trigger() []byte {
+	return []byte{'^'}
+}
+
+func (p *sidenoteParser) Parse(parent ast.Node, block text.Reader, pc gmparser.Context) ast.Node {
+	line, _ := block.PeekLine()
+	if len(line) < 2 || line[1] != '[' {
+		return nil
+	}
+	note, offsets, ok := scanBracketed(block, 2)
+	if !ok {
+		return nil
+	}
+	sidenote := &Sidenote{}
+	appendNoteText(sidenote, note, offsets)
+	return sidenote
+}
+
+// appendNoteText adds the text of a note to sidenote, with redacted spans
+// parsed like in running text. offsets holds the source offset of each byte
+// of note, so that the spans keep their place in the source.
+func appendNoteText(sidenote *Sidenote, note []byte, offsets []int) {
+	plain := 0
+	for i := 0; i < len(note); i++ {
+		if note[i] != '|' && note[i] != ':' {
+			continue
+		}
+		reader := text.NewReader(note)
+		reader.Advance(i)
+		redaction, ok := (&redactionParser{}).Parse(nil, reader, nil).(*Redaction)
+		if !ok {
+			continue
+		}
+		if plain < i {
+			sidenote.AppendChild(sidenote, ast.NewString(note[plain:i]))
+		}
+		end := redaction.Segment.Stop
+		redaction.Segment = text.NewSegment(offsets[i], offsets[end-1]+1)
+		sidenote.AppendChild(sidenote, redaction)
+		plain = end
+		i = end - 1
+	}
+	if plain < len(note) {
+		sidenote.AppendChild(sidenote, ast.NewString(note[plain:]))
+	}
+}
+
+// scanBracketed reads the text up to the bracket closing the one before
+// offset start of the current line, with balanced brackets, and advances
+// block past it. The text may span several lines; line breaks are folded
+// into spaces. Escaped brackets (\[ and \]) are literal; other backslash
+// escapes are kept verbatim. It also returns the source offset of each byte
+// of the text, and false for unclosed or blank text.
+func scanBracketed(block text.Reader, start int) ([]byte, []int, bool) {
+	line, segment := block.PeekLine()
+	var inner []byte
+	var offsets []int
+	take := func(from, to int) {
+		inner = append(inner, line[from:to]...)
+		for i := from; i < to; i++ {
+			offsets = append(offsets, segment.Start+i)
+		}
+	}
+	depth := 1
+	for line != nil {
+		for i := start; i < len(line); i++ {
+			switch line[i] {
+			case '\\':
+				if i+1 < len(line) && (line[i+1] == '[' || line[i+1] == ']') {
+					take(start, i)
+					start = i + 1
+				}
+				i++
+			case '[':
+				depth++
+			case ']':
+				depth--
+				if depth == 0 {
+					take(start, i)
+					block.Advance(i + 1)
+					return foldedText(inner, offsets)
+				}
+			}
+		}
+		take(start, len(line))
+		block.AdvanceLine()
+		line, segment = block.PeekLine()
+		start = 0
+	}
+	return nil, nil, false
+}
+
+// foldedText folds the line breaks and runs of spaces of inline text into
+// single spaces, and returns false when nothing is left. offsets, if not
+// nil, holds a value for each byte of inner and is folded along with it.
+func foldedText(inner []byte, offsets []int) ([]byte, []int, bool) {
+	var folded []byte
+	var kept []int
+	space := -1 // Start of the spaces before the next word
+	for i := 0; i < len(inner); {
+		r, size := utf8.DecodeRune(inner[i:])
+		if unicode.IsSpace(r) {
+			if space < 0 && len(folded) > 0 {
+				space = i
+			}
+			i += size
+			continue
+		}
+		if space >= 0 {
+			folded = append(folded, ' ')
+			if offsets != nil {
+				kept = append(kept, offsets[space])
+			}
+			space = -1
+		}
+		folded = append(folded, inner[i:i+size]...)
+		if offsets != nil {
+			kept = append(kept, offsets[i:i+size]...)
+		}
+		i += size
+	}
+	if len(folded) == 0 {
+		return nil, nil, false
+	}
+	return folded, kept, true
+}
+
+type sidenoteExtension struct{}
+
+// Sidenotes enables the ^[note] sidenote syntax.
+var Sidenotes goldmark.Extender = &sidenoteExtension{}
+
+func (e *sidenoteExtension) Extend(m goldmark.Markdown) {
+	m.Parser().AddOptions(gmparser.WithInlineParsers(
+		util.Prioritized(&sidenoteParser{}, 500),
+	))
+}
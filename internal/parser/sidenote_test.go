@@ -0,0 +1,80 @@
+package parser
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/yuin/goldmark/ast"
+)
+
+func findSidenotes(t *testing.T, input string) []*Sidenote {
+	t.Helper()
+	node, err := NewMarkdownParser().Parse([]byte(input))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	var notes []*Sidenote
+	_ = ast.Walk(node, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if note, ok := n.(*Sidenote); ok && entering {
+			notes = append(notes, note)
+		}
+		return ast.WalkContinue, nil
+	})
+	return notes
+}
+
+func TestParse_Sidenotes(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  []string
+	}{
+		{"simple", "Text.^[A note.] More text.", []string{"A note."}},
+		{"two_notes", "One^[first] and two^[second].", []string{"first", "second"}},
+		{"nested_brackets", "Text^[see [1] and [2]].", []string{"see [1] and [2]"}},
+		{"escaped_bracket", `Text^[a \] b].`, []string{"a ] b"}},
+		{"spans_lines", "Text^[a note that\ncontinues here] after.", []string{"a note that continues here"}},
+		{"caret_only", "2^3 and x^[ ] stay text.", nil},
+		{"unclosed", "Text^[never closed.", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			notes := findSidenotes(t, tt.input)
+			if len(notes) != len(tt.want) {
+				t.Fatalf("got %d sidenotes, want %d", len(notes), len(tt.want))
+			}
+			for i, note := range notes {
+				if got := string(note.Text([]byte(tt.input))); got != tt.want[i] {
+					t.Errorf("sidenote %d = %q, want %q", i, got, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestParse_SidenoteRedactions(t *testing.T) {
+	input := "Text^[call ||Jane\nSmith|| or :redact[x [1]] at | home] after ||outside||."
+	notes := findSidenotes(t, input)
+	if len(notes) != 1 {
+		t.Fatalf("got %d sidenotes, want 1", len(notes))
+	}
+
+	// The redacted text is only in the redactions, which keep their spans
+	// in the source
+	if got := string(notes[0].Text([]byte(input))); got != "call  or  at | home" {
+		t.Errorf("note text = %q", got)
+	}
+	var hidden, spans []string
+	for child := notes[0].FirstChild(); child != nil; child = child.NextSibling() {
+		if redaction, ok := child.(*Redaction); ok {
+			hidden = append(hidden, string(redaction.Hidden))
+			spans = append(spans, string(redaction.Segment.Value([]byte(input))))
+		}
+	}
+	if want := []string{"Jane Smith", "x [1]"}; !reflect.DeepEqual(hidden, want) {
+		t.Errorf("hidden = %q, want %q", hidden, want)
+	}
+	if want := []string{"||Jane\nSmith||", ":redact[x [1]]"}; !reflect.DeepEqual(spans, want) {
+		t.Errorf("spans = %q, want %q", spans, want)
+	}
+}
@@ -0,0 +1,36 @@
+package parser
+
+import (
+	"strings"
+
+	"github.com/yuin/goldmark/ast"
+)
+
+// TakeTitle removes the first heading of doc when it is a level 1 heading
+// and returns its plain text, the way static site generators take the title
+// of a page from its leading H1. It returns "" and leaves doc untouched
+// otherwise.
+func TakeTitle(doc ast.Node, source []byte) string {
+	for n := doc.FirstChild(); n != nil; n = n.NextSibling() {
+		heading, ok := n.(*ast.Heading)
+		if !ok {
+			continue
+		}
+		if heading.Level != MinHeadingLevel {
+			return ""
+		}
+		var title strings.Builder
+		_ = ast.Walk(heading, func(c ast.Node, entering bool) (ast.WalkStatus, error) {
+			if text, ok := c.(*ast.Text); ok && entering {
+				title.Write(text.Segment.Value(source))
+				if text.SoftLineBreak() {
+					title.WriteByte(' ')
+				}
+			}
+			return ast.WalkContinue, nil
+		})
+		doc.RemoveChild(doc, heading)
+		return strings.TrimSpace(title.String())
+	}
+	return ""
+}
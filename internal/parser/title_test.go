@@ -0,0 +1,43 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/yuin/goldmark/ast"
+)
+
+func TestTakeTitle(t *testing.T) {
+	tests := []struct {
+		name     string
+		source   string
+		want     string
+		headings int // headings left in the document
+	}{
+		{"leading H1", "# The *Guide*\n\nText\n\n## Part\n", "The Guide", 1},
+		{"after a paragraph", "Intro\n\n# Guide\n", "Guide", 0},
+		{"setext", "Guide\n=====\n", "Guide", 0},
+		{"first heading is H2", "## Part\n\n# Guide\n", "", 2},
+		{"no heading", "Text\n", "", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := NewMarkdownParser().Parse([]byte(tt.source))
+			if err != nil {
+				t.Fatalf("Parse failed: %v", err)
+			}
+			if got := TakeTitle(doc, []byte(tt.source)); got != tt.want {
+				t.Errorf("TakeTitle() = %q, want %q", got, tt.want)
+			}
+			headings := 0
+			_ = ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+				if _, ok := n.(*ast.Heading); ok && entering {
+					headings++
+				}
+				return ast.WalkContinue, nil
+			})
+			if headings != tt.headings {
+				t.Errorf("headings left = %d, want %d", headings, tt.headings)
+			}
+		})
+	}
+}
@@ -0,0 +1,143 @@
+// Package pdfobj reads the indirect objects of PDF files written by the
+// renderer, for the packages that reorganize or draw them. Cross-reference
+// streams and object streams are not supported; gofpdf does not write them.
+package pdfobj
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	objectPattern = regexp.MustCompile(`(?m)^(\d+) 0 obj\s*`)
+	streamPattern = regexp.MustCompile(`>>\s*stream\r?\n`)
+	lengthPattern = regexp.MustCompile(`/Length (\d+)`)
+)
+
+// Object is an indirect object: its dictionary (or other non-stream value)
+// and, for stream objects, the stream data as stored in the file.
+type Object struct {
+	Dict   string
+	Stream []byte // nil for objects without a stream
+}
+
+// Read returns the objects of a PDF by object number, and the offset after
+// the last one, where the cross-reference table and trailer start.
+func Read(data []byte) (map[int]Object, int, error) {
+	objects := make(map[int]Object)
+	pos := 0
+	for {
+		loc := objectPattern.FindSubmatchIndex(data[pos:])
+		if loc == nil {
+			return objects, pos, nil
+		}
+		num, err := strconv.Atoi(string(data[pos+loc[2] : pos+loc[3]]))
+		if err != nil {
+			return nil, 0, fmt.Errorf("invalid object number: %w", err)
+		}
+		obj, end := readObject(data, pos+loc[1])
+		objects[num] = obj
+		pos = end
+	}
+}
+
+// readObject reads the object body starting at start and returns it together
+// with the offset just after its "endobj" keyword.
+func readObject(data []byte, start int) (Object, int) {
+	endObj := bytes.Index(data[start:], []byte("endobj"))
+	if endObj < 0 {
+		return Object{Dict: string(data[start:])}, len(data)
+	}
+	endObj += start
+
+	loc := streamPattern.FindIndex(data[start:endObj])
+	if loc == nil {
+		return Object{Dict: string(bytes.TrimSpace(data[start:endObj]))}, endObj + len("endobj")
+	}
+
+	dictEnd := start + loc[0] + 2
+	obj := Object{Dict: string(bytes.TrimSpace(data[start:dictEnd]))}
+	streamStart := start + loc[1]
+
+	streamEnd := -1
+	if m := lengthPattern.FindStringSubmatch(obj.Dict); m != nil {
+		if length, err := strconv.Atoi(m[1]); err == nil && streamStart+length <= len(data) {
+			streamEnd = streamStart + length
+		}
+	}
+	if streamEnd < 0 {
+		idx := bytes.Index(data[streamStart:], []byte("endstream"))
+		if idx < 0 {
+			return obj, len(data)
+		}
+		streamEnd = streamStart + idx
+	}
+	obj.Stream = data[streamStart:streamEnd:streamEnd]
+
+	// The stream may contain "endobj" by chance, so search again after it
+	end := bytes.Index(data[streamEnd:], []byte("endobj"))
+	if end < 0 {
+		return obj, len(data)
+	}
+	return obj, streamEnd + end + len("endobj")
+}
+
+// Decode returns the stream data with Flate compression removed.
+func (o Object) Decode() ([]byte, error) {
+	if !strings.Contains(o.Dict, "/FlateDecode") {
+		return o.Stream, nil
+	}
+	reader, err := zlib.NewReader(bytes.NewReader(o.Stream))
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = reader.Close() }()
+	return io.ReadAll(reader)
+}
+
+// SubDictionary returns the body of the dictionary stored under key in
+// dict, for example the font map of a resource dictionary. Key must be a
+// whole name: "/Font" does not match "/FontFile".
+func SubDictionary(dict, key string) (string, bool) {
+	for offset := 0; ; {
+		i := strings.Index(dict[offset:], key)
+		if i < 0 {
+			return "", false
+		}
+		offset += i + len(key)
+		rest := strings.TrimLeft(dict[offset:], " \t\r\n\f\x00")
+		if !strings.HasPrefix(rest, "<<") {
+			continue
+		}
+		start := len(dict) - len(rest) + 2
+		if body, ok := dictionaryBody(dict, start); ok {
+			return body, true
+		}
+		return "", false
+	}
+}
+
+// dictionaryBody returns the text from start to the ">>" closing the
+// dictionary opened just before start.
+func dictionaryBody(dict string, start int) (string, bool) {
+	depth := 1
+	for i := start; i+1 < len(dict); i++ {
+		switch {
+		case dict[i] == '<' && dict[i+1] == '<':
+			depth++
+			i++
+		case dict[i] == '>' && dict[i+1] == '>':
+			depth--
+			if depth == 0 {
+				return dict[start:i], true
+			}
+			i++
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,80 @@
+package pdfobj
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/jung-kurt/gofpdf"
+)
+
+func TestRead(t *testing.T) {
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.SetFont("Arial", "", 12)
+	pdf.AddPage()
+	pdf.Cell(0, 10, "Hello endobj")
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		t.Fatalf("failed to render PDF: %v", err)
+	}
+	data := buf.Bytes()
+
+	objects, end, err := Read(data)
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if !bytes.HasPrefix(bytes.TrimSpace(data[end:]), []byte("xref")) {
+		t.Errorf("Read should stop before the cross-reference table, got %q", data[end:min(end+20, len(data))])
+	}
+
+	var content []byte
+	for _, obj := range objects {
+		if obj.Stream == nil || !strings.Contains(obj.Dict, "/FlateDecode") {
+			continue
+		}
+		decoded, err := obj.Decode()
+		if err != nil {
+			t.Fatalf("Decode failed: %v", err)
+		}
+		if bytes.Contains(decoded, []byte("(Hello endobj)")) {
+			content = decoded
+		}
+	}
+	if content == nil {
+		t.Error("the page content stream should decode to the drawn text")
+	}
+}
+
+func TestReadObject_WithoutLength(t *testing.T) {
+	data := []byte("1 0 obj\n<</Filter /None>>\nstream\nraw data\nendstream\nendobj\n2 0 obj\n(two)\nendobj\n")
+	objects, _, err := Read(data)
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if got := string(objects[1].Stream); got != "raw data\n" {
+		t.Errorf("stream = %q", got)
+	}
+	if objects[1].Dict != "<</Filter /None>>" || objects[2].Dict != "(two)" || objects[2].Stream != nil {
+		t.Errorf("objects = %+v", objects)
+	}
+}
+
+func TestSubDictionary(t *testing.T) {
+	dict := "<</FontDescriptor 9 0 R /Font <</F1 5 0 R /F2 <</Nested true>> >> /XObject<</I1 7 0 R>>>>"
+	tests := []struct {
+		key  string
+		want string
+		ok   bool
+	}{
+		{"/Font", "/F1 5 0 R /F2 <</Nested true>> ", true},
+		{"/XObject", "/I1 7 0 R", true},
+		{"/FontDescriptor", "", false},
+		{"/ColorSpace", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := SubDictionary(dict, tt.key)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("SubDictionary(%s) = %q, %v, want %q, %v", tt.key, got, ok, tt.want, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package pdfsplit
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"unicode/utf16"
+)
+
+var (
+	filespecPattern     = regexp.MustCompile(`/Type\s*/Filespec\b`)
+	embeddedFilePattern = regexp.MustCompile(`/EF\s*<<\s*/F\s+(\d+) 0 R`)
+)
+
+// EmbeddedFiles returns the files attached to a PDF as a whole, keyed by
+// file name. Attachments on pages are included too.
+func EmbeddedFiles(data []byte) (map[string][]byte, error) {
+	doc, err := parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read PDF: %w", err)
+	}
+
+	files := make(map[string][]byte)
+	for _, obj := range doc.objects {
+		if !filespecPattern.MatchString(obj.Dict) {
+			continue
+		}
+		ref := embeddedFilePattern.FindStringSubmatch(obj.Dict)
+		if ref == nil {
+			continue
+		}
+		num, _ := strconv.Atoi(ref[1])
+		stream, ok := doc.objects[num]
+		if !ok {
+			continue
+		}
+		name, ok := literalString(obj.Dict, "/UF")
+		if !ok || name == "" {
+			name, _ = literalString(obj.Dict, "/F")
+		}
+		files[name] = decodeStream(stream)
+	}
+	return files, nil
+}
+
+// literalString returns the text string stored under key in dict, decoding
+// escapes and UTF-16 text marked by a byte order mark.
+func literalString(dict, key string) (string, bool) {
+	idx := regexp.MustCompile(regexp.QuoteMeta(key) + `\s*\(`).FindStringIndex(dict)
+	if idx == nil {
+		return "", false
+	}
+
+	var raw []byte
+	depth := 1
+	for i := idx[1]; i < len(dict); i++ {
+		c := dict[i]
+		switch c {
+		case '\\':
+			if i+1 >= len(dict) {
+				return "", false
+			}
+			i++
+			switch e := dict[i]; e {
+			case 'n':
+				raw = append(raw, '\n')
+			case 'r':
+				raw = append(raw, '\r')
+			case 't':
+				raw = append(raw, '\t')
+			case 'b':
+				raw = append(raw, '\b')
+			case 'f':
+				raw = append(raw, '\f')
+			case '\n':
+				// A line continuation
+			default:
+				if e >= '0' && e <= '7' {
+					end := i + 1
+					for end < len(dict) && end < i+3 && dict[end] >= '0' && dict[end] <= '7' {
+						end++
+					}
+					value, _ := strconv.ParseUint(dict[i:end], 8, 8)
+					raw = append(raw, byte(value))
+					i = end - 1
+					continue
+				}
+				raw = append(raw, e)
+			}
+		case '(':
+			depth++
+			raw = append(raw, c)
+		case ')':
+			depth--
+			if depth == 0 {
+				return decodeText(raw), true
+			}
+			raw = append(raw, c)
+		default:
+			raw = append(raw, c)
+		}
+	}
+	return "", false
+}
+
+// decodeText decodes a PDF text string: UTF-16BE after a byte order mark,
+// and otherwise bytes taken as they are.
+func decodeText(raw []byte) string {
+	if len(raw) < 2 || raw[0] != 0xFE || raw[1] != 0xFF {
+		return string(raw)
+	}
+	units := make([]uint16, 0, (len(raw)-2)/2)
+	for i := 2; i+1 < len(raw); i += 2 {
+		units = append(units, uint16(raw[i])<<8|uint16(raw[i+1]))
+	}
+	return string(utf16.Decode(units))
+}
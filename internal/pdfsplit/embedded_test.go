@@ -0,0 +1,47 @@
+package pdfsplit
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/jung-kurt/gofpdf"
+)
+
+func TestEmbeddedFiles(t *testing.T) {
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.SetFont("Arial", "", 12)
+	pdf.AddPage()
+	pdf.Cell(0, 10, "Attachments")
+	pdf.SetAttachments([]gofpdf.Attachment{
+		{Content: []byte(`{"format": 1}`), Filename: "manifest.json"},
+		{Content: []byte("résumé (draft)\n"), Filename: "notes (é).txt"},
+	})
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	files, err := EmbeddedFiles(buf.Bytes())
+	if err != nil {
+		t.Fatalf("EmbeddedFiles failed: %v", err)
+	}
+	if got := string(files["manifest.json"]); got != `{"format": 1}` {
+		t.Errorf("manifest.json = %q", got)
+	}
+	if got := string(files["notes (é).txt"]); got != "résumé (draft)\n" {
+		t.Errorf("notes (é).txt = %q, files %v", got, files)
+	}
+
+	// Linearizing keeps the attachments
+	linearized, err := Linearize(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if files, err := EmbeddedFiles(linearized); err != nil || len(files) != 2 {
+		t.Errorf("linearized PDF has attachments %v, %v", files, err)
+	}
+
+	if files, err := EmbeddedFiles(buildPDF(t, 1)); err != nil || len(files) != 0 {
+		t.Errorf("a PDF without attachments returned %v, %v", files, err)
+	}
+}
@@ -0,0 +1,431 @@
+package pdfsplit
+
+import (
+	"bytes"
+	"fmt"
+	"math/bits"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var outlinesPattern = regexp.MustCompile(`/Outlines\s+(\d+) 0 R`)
+
+// offsetPlaceholder stands in for offsets that are only known once the file
+// is laid out, so the sections at the start of the file can be sized first.
+const offsetPlaceholder = 9999999999
+
+// Linearize rewrites a PDF for fast web view (ISO 32000-1, annex F). The
+// first page and everything it draws come first, followed by the other pages
+// in order, so a viewer loading the file over HTTP can show the first page
+// before the download completes and fetch later pages by byte range using
+// the hint tables.
+func Linearize(data []byte) ([]byte, error) {
+	doc, err := parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read PDF: %w", err)
+	}
+	return doc.linearize(), nil
+}
+
+// linearLayout assigns the objects of a document to the sections of a
+// linearized file, by source object number and in file order.
+type linearLayout struct {
+	catalog []int          // Catalog, plus the outline when it is shown on open
+	first   []int          // First page followed by every object it uses
+	pages   [][]int        // Each later page followed by the objects only it uses
+	shared  []int          // Objects used by several later pages but not the first
+	other   []int          // Page tree, document info and unused objects
+	uses    []map[int]bool // Objects used by each page
+}
+
+func (d *document) layout() linearLayout {
+	var l linearLayout
+	users := make(map[int]int)
+	l.uses = make([]map[int]bool, len(d.pages))
+	for i, p := range d.pages {
+		l.uses[i] = d.closure(p)
+		if p.resources != 0 {
+			l.uses[i][p.resources] = true
+		}
+		for num := range l.uses[i] {
+			users[num]++
+		}
+	}
+
+	placed := make(map[int]bool)
+	place := func(section *[]int, nums ...int) {
+		for _, num := range nums {
+			if !placed[num] {
+				placed[num] = true
+				*section = append(*section, num)
+			}
+		}
+	}
+
+	place(&l.catalog, d.root)
+	catalog := d.objects[d.root].Dict
+	if m := outlinesPattern.FindStringSubmatch(catalog); m != nil && strings.Contains(catalog, "/UseOutlines") {
+		outlines, _ := strconv.Atoi(m[1])
+		place(&l.catalog, d.reachable(outlines)...)
+	}
+
+	place(&l.first, d.pages[0].num)
+	place(&l.first, sortedKeys(l.uses[0])...)
+
+	for i, p := range d.pages[1:] {
+		section := []int{}
+		place(&section, p.num)
+		for _, num := range sortedKeys(l.uses[i+1]) {
+			if users[num] == 1 {
+				place(&section, num)
+			}
+		}
+		l.pages = append(l.pages, section)
+	}
+	for _, uses := range l.uses[1:] {
+		place(&l.shared, sortedKeys(uses)...)
+	}
+	place(&l.other, sortedKeys(d.objects)...)
+	return l
+}
+
+// reachable returns start and the objects it refers to, directly or
+// indirectly, without following references to pages.
+func (d *document) reachable(start int) []int {
+	seen := map[int]bool{start: true}
+	queue := []int{start}
+	var nums []int
+	for len(queue) > 0 {
+		num := queue[0]
+		queue = queue[1:]
+		nums = append(nums, num)
+		for _, ref := range references(d.objects[num].Dict) {
+			if _, ok := d.objects[ref]; ok && !seen[ref] && !d.pageSet[ref] {
+				seen[ref] = true
+				queue = append(queue, ref)
+			}
+		}
+	}
+	return nums
+}
+
+// pageDict returns the dictionary of p with its inherited /MediaBox and
+// /Resources entries copied in, as linearized files must not rely on
+// inheritance from the page tree.
+func (d *document) pageDict(p page) string {
+	dict := d.objects[p.num].Dict
+	if p.resources != 0 && !resourcesPattern.MatchString(ownEntries(dict)) {
+		dict = strings.Replace(dict, "<<", fmt.Sprintf("<</Resources %d 0 R ", p.resources), 1)
+	}
+	if p.mediaBox != "" {
+		dict = strings.Replace(dict, "<<", "<<"+p.mediaBox+" ", 1)
+	}
+	return dict
+}
+
+// linearize writes the document in the linearized layout: header,
+// linearization dictionary, first-page cross-reference table, catalog, hint
+// stream, first page, remaining pages, shared objects, other objects and the
+// main cross-reference table.
+func (d *document) linearize() []byte {
+	l := d.layout()
+
+	// Objects are numbered in file order, except that the catalog and the
+	// first page get the highest numbers so the first-page cross-reference
+	// table is a single subsection
+	renumber := make(map[int]int)
+	next := 1
+	number := func(nums []int) {
+		for _, num := range nums {
+			renumber[num] = next
+			next++
+		}
+	}
+	for _, section := range l.pages {
+		number(section)
+	}
+	number(l.shared)
+	number(l.other)
+	mainSize := next
+	linNum := next
+	next++
+	number(l.catalog)
+	hintNum := next
+	next++
+	number(l.first)
+	size := next
+
+	pages := make(map[int]page, len(d.pages))
+	for _, p := range d.pages {
+		pages[p.num] = p
+	}
+	objects := make(map[int][]byte, len(d.objects))
+	for num, obj := range d.objects {
+		dict := obj.Dict
+		if p, ok := pages[num]; ok {
+			dict = d.pageDict(p)
+		}
+		var buf bytes.Buffer
+		writeObject(&buf, renumber[num], remap(dict, renumber), obj.Stream)
+		objects[num] = buf.Bytes()
+	}
+
+	header := d.header + "\n%\xe2\xe3\xcf\xd3\n"
+	linearization := func(length, hintOffset, hintLength, firstPageEnd, mainEntries int) string {
+		return fmt.Sprintf("%d 0 obj\n<</Linearized 1 /L %d /H [%d %d] /O %d /E %d /N %d /T %d>>",
+			linNum, length, hintOffset, hintLength, renumber[d.pages[0].num], firstPageEnd, len(d.pages), mainEntries)
+	}
+	trailer := func(prev int) string {
+		dict := fmt.Sprintf("<</Size %d /Root %d 0 R", size, renumber[d.root])
+		if d.info != 0 {
+			dict += fmt.Sprintf(" /Info %d 0 R", renumber[d.info])
+		}
+		if prev >= 0 {
+			dict += fmt.Sprintf(" /Prev %d", prev)
+		}
+		return dict + ">>"
+	}
+	p := offsetPlaceholder
+	linWidth := len(linearization(p, p, p, p, p))
+	trailerWidth := len(trailer(p))
+
+	// Lay the file out without the hint stream, which is what the offsets
+	// in the hint tables refer to
+	offsets := make(map[int]int, len(objects))
+	pos := len(header) + linWidth + len("\nendobj\n")
+	firstXref := pos
+	pos += len(fmt.Sprintf("xref\n%d %d\n", linNum, size-linNum)) + 20*(size-linNum) +
+		len("trailer\n") + trailerWidth + len("\nstartxref\n0\n%%EOF\n")
+	lay := func(nums []int) {
+		for _, num := range nums {
+			offsets[num] = pos
+			pos += len(objects[num])
+		}
+	}
+	lay(l.catalog)
+	hintOffset := pos
+	lay(l.first)
+	firstPageEnd := pos
+	for _, section := range l.pages {
+		lay(section)
+	}
+	lay(l.shared)
+	lay(l.other)
+
+	hints, sharedTable := d.hintTables(l, offsets, objects, renumber, firstPageEnd)
+	hint := []byte(fmt.Sprintf("%d 0 obj\n<</Length %d /S %d>>\nstream\n%s\nendstream\nendobj\n",
+		hintNum, len(hints), sharedTable, hints))
+	for num, offset := range offsets {
+		if offset >= hintOffset {
+			offsets[num] = offset + len(hint)
+		}
+	}
+	firstPageEnd += len(hint)
+	mainXref := pos + len(hint)
+	mainEntries := mainXref + len(fmt.Sprintf("xref\n0 %d", mainSize))
+
+	var main bytes.Buffer
+	fmt.Fprintf(&main, "xref\n0 %d\n0000000000 65535 f \n", mainSize)
+	byNumber := make(map[int]int, len(offsets))
+	for num, offset := range offsets {
+		byNumber[renumber[num]] = offset
+	}
+	for num := 1; num < mainSize; num++ {
+		fmt.Fprintf(&main, "%010d 00000 n \n", byNumber[num])
+	}
+	fmt.Fprintf(&main, "trailer\n%s\nstartxref\n%d\n%%%%EOF\n", trailer(-1), firstXref)
+	length := mainXref + main.Len()
+
+	var buf bytes.Buffer
+	buf.Grow(length)
+	buf.WriteString(header)
+	buf.WriteString(pad(linearization(length, hintOffset, len(hint), firstPageEnd, mainEntries), linWidth))
+	buf.WriteString("\nendobj\n")
+
+	byNumber[linNum] = len(header)
+	byNumber[hintNum] = hintOffset
+	fmt.Fprintf(&buf, "xref\n%d %d\n", linNum, size-linNum)
+	for num := linNum; num < size; num++ {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", byNumber[num])
+	}
+	fmt.Fprintf(&buf, "trailer\n%s\nstartxref\n0\n%%%%EOF\n", pad(trailer(mainXref), trailerWidth))
+
+	write := func(nums []int) {
+		for _, num := range nums {
+			buf.Write(objects[num])
+		}
+	}
+	write(l.catalog)
+	buf.Write(hint)
+	write(l.first)
+	for _, section := range l.pages {
+		write(section)
+	}
+	write(l.shared)
+	write(l.other)
+	buf.Write(main.Bytes())
+	return buf.Bytes()
+}
+
+// hintTables builds the page offset hint table followed by the shared object
+// hint table (annex F.4) and returns them with the offset of the latter.
+// offsets are positions in the file without the hint stream.
+func (d *document) hintTables(l linearLayout, offsets map[int]int, objects map[int][]byte, renumber map[int]int, firstPageEnd int) ([]byte, int) {
+	// Shared object groups: every object of the first page, then the
+	// objects shared between later pages, one object per group
+	groups := append(append([]int{}, l.first...), l.shared...)
+	groupIndex := make(map[int]int, len(groups))
+	for i, num := range groups {
+		groupIndex[num] = i
+	}
+
+	type pageEntry struct {
+		objects, length int
+		shared          []int
+	}
+	entries := []pageEntry{{objects: len(l.first), length: firstPageEnd - offsets[l.first[0]]}}
+	for i, section := range l.pages {
+		last := section[len(section)-1]
+		entry := pageEntry{
+			objects: len(section),
+			length:  offsets[last] + len(objects[last]) - offsets[section[0]],
+		}
+		for _, num := range sortedKeys(l.uses[i+1]) {
+			if index, ok := groupIndex[num]; ok {
+				entry.shared = append(entry.shared, index)
+			}
+		}
+		entries = append(entries, entry)
+	}
+
+	minObjects, maxObjects := entries[0].objects, entries[0].objects
+	minLength, maxLength := entries[0].length, entries[0].length
+	maxShared, maxIdentifier := 0, 0
+	for _, e := range entries {
+		minObjects, maxObjects = min(minObjects, e.objects), max(maxObjects, e.objects)
+		minLength, maxLength = min(minLength, e.length), max(maxLength, e.length)
+		maxShared = max(maxShared, len(e.shared))
+		for _, index := range e.shared {
+			maxIdentifier = max(maxIdentifier, index)
+		}
+	}
+	objectBits := bitsFor(maxObjects - minObjects)
+	lengthBits := bitsFor(maxLength - minLength)
+	sharedBits := bitsFor(maxShared)
+	identifierBits := bitsFor(maxIdentifier)
+
+	// Page offset hint table. Content stream offsets and lengths are given
+	// as the whole page, as viewers do not use them
+	var w bitWriter
+	w.write(minObjects, 32)
+	w.write(offsets[l.first[0]], 32)
+	w.write(objectBits, 16)
+	w.write(minLength, 32)
+	w.write(lengthBits, 16)
+	w.write(0, 32)
+	w.write(0, 16)
+	w.write(minLength, 32)
+	w.write(lengthBits, 16)
+	w.write(sharedBits, 16)
+	w.write(identifierBits, 16)
+	w.write(0, 16) // No fractional positions of shared objects
+	w.write(1, 16)
+	for _, e := range entries {
+		w.write(e.objects-minObjects, objectBits)
+	}
+	w.flush()
+	for _, e := range entries {
+		w.write(e.length-minLength, lengthBits)
+	}
+	w.flush()
+	for _, e := range entries {
+		w.write(len(e.shared), sharedBits)
+	}
+	w.flush()
+	for _, e := range entries {
+		for _, index := range e.shared {
+			w.write(index, identifierBits)
+		}
+	}
+	w.flush()
+	for _, e := range entries {
+		w.write(e.length-minLength, lengthBits)
+	}
+	w.flush()
+	sharedTable := len(w.buf)
+
+	// Shared object hint table
+	minGroup, maxGroup := len(objects[groups[0]]), len(objects[groups[0]])
+	for _, num := range groups {
+		minGroup, maxGroup = min(minGroup, len(objects[num])), max(maxGroup, len(objects[num]))
+	}
+	groupBits := bitsFor(maxGroup - minGroup)
+	firstShared, firstSharedOffset := 0, 0
+	if len(l.shared) > 0 {
+		firstShared, firstSharedOffset = renumber[l.shared[0]], offsets[l.shared[0]]
+	}
+	w.write(firstShared, 32)
+	w.write(firstSharedOffset, 32)
+	w.write(len(l.first), 32)
+	w.write(len(groups), 32)
+	w.write(0, 16) // One object per group
+	w.write(minGroup, 32)
+	w.write(groupBits, 16)
+	for _, num := range groups {
+		w.write(len(objects[num])-minGroup, groupBits)
+	}
+	w.flush()
+	for range groups {
+		w.write(0, 1) // No MD5 signatures
+	}
+	w.flush()
+	return w.buf, sharedTable
+}
+
+// bitWriter packs values most significant bit first, as the hint tables
+// require.
+type bitWriter struct {
+	buf   []byte
+	cur   byte
+	count int
+}
+
+func (w *bitWriter) write(value, width int) {
+	for i := width - 1; i >= 0; i-- {
+		w.cur = w.cur<<1 | byte(value>>i&1)
+		w.count++
+		if w.count == 8 {
+			w.buf = append(w.buf, w.cur)
+			w.cur, w.count = 0, 0
+		}
+	}
+}
+
+// flush pads the last byte with zero bits.
+func (w *bitWriter) flush() {
+	if w.count > 0 {
+		w.buf = append(w.buf, w.cur<<(8-w.count))
+		w.cur, w.count = 0, 0
+	}
+}
+
+// bitsFor returns the number of bits needed to store n.
+func bitsFor(n int) int {
+	return bits.Len(uint(n))
+}
+
+// pad fills s with spaces up to width.
+func pad(s string, width int) string {
+	return s + strings.Repeat(" ", width-len(s))
+}
+
+func sortedKeys[V any](m map[int]V) []int {
+	keys := make([]int, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Ints(keys)
+	return keys
+}
@@ -0,0 +1,237 @@
+package pdfsplit
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"testing"
+
+	"github.com/jung-kurt/gofpdf"
+)
+
+var linearizationPattern = regexp.MustCompile(`<</Linearized 1 /L (\d+) /H \[(\d+) (\d+)\] /O (\d+) /E (\d+) /N (\d+) /T (\d+)>>`)
+
+// linearization holds the values of a linearization dictionary.
+type linearization struct {
+	length, hintOffset, hintLength, firstPage, firstPageEnd, pages, mainEntries int
+}
+
+func readLinearization(t *testing.T, data []byte) linearization {
+	t.Helper()
+	m := linearizationPattern.FindSubmatch(data[:min(len(data), 1024)])
+	if m == nil {
+		t.Fatal("no linearization dictionary in the first 1024 bytes")
+	}
+	v := make([]int, 7)
+	for i := range v {
+		v[i], _ = strconv.Atoi(string(m[i+1]))
+	}
+	return linearization{v[0], v[1], v[2], v[3], v[4], v[5], v[6]}
+}
+
+// readXref checks every entry of the cross-reference table at offset
+// against the object it points at and returns the number of entries.
+func readXref(t *testing.T, data []byte, offset int) int {
+	t.Helper()
+	m := regexp.MustCompile(`^xref\n(\d+) (\d+)\n`).FindSubmatch(data[offset:])
+	if m == nil {
+		t.Fatalf("no cross-reference table at offset %d", offset)
+	}
+	first, _ := strconv.Atoi(string(m[1]))
+	count, _ := strconv.Atoi(string(m[2]))
+	entries := data[offset+len(m[0]):]
+	for i := 0; i < count; i++ {
+		entry := string(entries[i*20 : i*20+20])
+		if entry[17] == 'f' {
+			continue
+		}
+		pos, _ := strconv.Atoi(entry[:10])
+		if want := fmt.Sprintf("%d 0 obj", first+i); !bytes.HasPrefix(data[pos:], []byte(want)) {
+			t.Errorf("xref entry %d points at %q", first+i, data[pos:min(pos+12, len(data))])
+		}
+	}
+	return count
+}
+
+// bitReader reads the bit-packed hint tables.
+type bitReader struct {
+	data []byte
+	pos  int // In bits
+}
+
+func (r *bitReader) read(width int) int {
+	value := 0
+	for i := 0; i < width; i++ {
+		bit := r.data[r.pos/8] >> (7 - r.pos%8) & 1
+		value = value<<1 | int(bit)
+		r.pos++
+	}
+	return value
+}
+
+func (r *bitReader) align() {
+	r.pos = (r.pos + 7) / 8 * 8
+}
+
+func TestLinearize(t *testing.T) {
+	data := buildPDF(t, 5)
+	out, err := Linearize(data)
+	if err != nil {
+		t.Fatalf("Linearize failed: %v", err)
+	}
+
+	lin := readLinearization(t, out)
+	if lin.length != len(out) {
+		t.Errorf("/L = %d, file is %d bytes", lin.length, len(out))
+	}
+	if lin.pages != 5 {
+		t.Errorf("/N = %d, want 5", lin.pages)
+	}
+
+	doc, err := parse(out)
+	if err != nil {
+		t.Fatalf("linearized PDF is not readable: %v", err)
+	}
+	if len(doc.pages) != 5 {
+		t.Fatalf("linearized PDF has %d pages, want 5", len(doc.pages))
+	}
+	if lin.firstPage != doc.pages[0].num {
+		t.Errorf("/O = %d, first page is object %d", lin.firstPage, doc.pages[0].num)
+	}
+	for num, obj := range doc.objects {
+		for _, ref := range references(obj.Dict) {
+			if _, ok := doc.objects[ref]; !ok {
+				t.Errorf("object %d references missing object %d", num, ref)
+			}
+		}
+	}
+
+	// The final startxref points at the first-page table, whose trailer
+	// leads to the main table
+	startxref := regexp.MustCompile(`startxref\s+(\d+)\s+%%EOF\s*$`).FindSubmatch(out)
+	firstXref, _ := strconv.Atoi(string(startxref[1]))
+	firstCount := readXref(t, out, firstXref)
+	prev := regexp.MustCompile(`/Prev (\d+)`).FindSubmatch(out[firstXref:])
+	if prev == nil {
+		t.Fatal("first-page trailer has no /Prev")
+	}
+	mainXref, _ := strconv.Atoi(string(prev[1]))
+	mainCount := readXref(t, out, mainXref)
+	if firstCount+mainCount != len(doc.objects)+1 {
+		t.Errorf("cross-reference tables cover %d objects, file has %d", firstCount+mainCount-1, len(doc.objects))
+	}
+	if !bytes.HasPrefix(out[lin.mainEntries:], []byte("\n0000000000 65535 f")) {
+		t.Errorf("/T does not point before the first main cross-reference entry")
+	}
+
+	// Everything page 1 draws comes before /E
+	offsetOf := func(num int) int {
+		return bytes.Index(out, []byte(fmt.Sprintf("\n%d 0 obj", num))) + 1
+	}
+	for num := range doc.closure(doc.pages[0]) {
+		if offsetOf(num) >= lin.firstPageEnd {
+			t.Errorf("object %d of the first page is after /E", num)
+		}
+	}
+	if !bytes.HasPrefix(out[lin.firstPageEnd:], []byte(fmt.Sprintf("%d 0 obj", doc.pages[1].num))) {
+		t.Error("the second page does not start at /E")
+	}
+
+	// The page offset hint table locates each page
+	hintObject := out[lin.hintOffset : lin.hintOffset+lin.hintLength]
+	if !bytes.HasSuffix(hintObject, []byte("endobj\n")) {
+		t.Fatal("/H does not cover the hint stream object")
+	}
+	start := bytes.Index(hintObject, []byte("stream\n")) + len("stream\n")
+	r := &bitReader{data: hintObject[start:]}
+	minObjects := r.read(32)
+	pageOffset := r.read(32)
+	objectBits := r.read(16)
+	minLength := r.read(32)
+	lengthBits := r.read(16)
+	r.read(32 + 16 + 32 + 16 + 16 + 16 + 16 + 16)
+	objects := make([]int, 5)
+	for i := range objects {
+		objects[i] = minObjects + r.read(objectBits)
+	}
+	r.align()
+	for i := 0; i < 5; i++ {
+		// Offsets in hint tables leave out the hint stream
+		actual := pageOffset
+		if actual >= lin.hintOffset {
+			actual += lin.hintLength
+		}
+		if !bytes.HasPrefix(out[actual:], []byte(fmt.Sprintf("%d 0 obj", doc.pages[i].num))) {
+			t.Errorf("hint table places page %d at %q", i+1, out[actual:min(actual+12, len(out))])
+		}
+		pageOffset += minLength + r.read(lengthBits)
+	}
+	if objects[0] < 3 {
+		t.Errorf("first page section has %d objects, want the page, its content and its image", objects[0])
+	}
+}
+
+func TestLinearize_SinglePage(t *testing.T) {
+	out, err := Linearize(buildPDF(t, 1))
+	if err != nil {
+		t.Fatalf("Linearize failed: %v", err)
+	}
+	lin := readLinearization(t, out)
+	if lin.length != len(out) || lin.pages != 1 {
+		t.Errorf("unexpected linearization dictionary: %+v", lin)
+	}
+	if _, err := parse(out); err != nil {
+		t.Errorf("linearized PDF is not readable: %v", err)
+	}
+}
+
+func TestLinearize_InvalidInput(t *testing.T) {
+	if _, err := Linearize([]byte("not a pdf")); err == nil {
+		t.Error("expected error for invalid PDF")
+	}
+}
+
+func TestLinearize_SharedObjects(t *testing.T) {
+	// Pages 2 to 4 show the same image, page 1 shows none
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.SetFont("Arial", "", 12)
+	pdf.RegisterImageOptionsReader("shared", gofpdf.ImageOptions{ImageType: "PNG"}, bytes.NewReader(noisyPNG(t, 1)))
+	for i := 0; i < 4; i++ {
+		pdf.AddPage()
+		pdf.CellFormat(0, 10, fmt.Sprintf("Page %d", i+1), "", 1, "", false, 0, "")
+		if i > 0 {
+			pdf.ImageOptions("shared", 20, 30, 50, 50, false, gofpdf.ImageOptions{ImageType: "PNG"}, 0, "")
+		}
+	}
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		t.Fatalf("failed to render PDF: %v", err)
+	}
+
+	out, err := Linearize(buf.Bytes())
+	if err != nil {
+		t.Fatalf("Linearize failed: %v", err)
+	}
+	lin := readLinearization(t, out)
+	doc, err := parse(out)
+	if err != nil {
+		t.Fatalf("linearized PDF is not readable: %v", err)
+	}
+
+	image := bytes.Index(out, []byte("/Subtype /Image"))
+	lastPage := bytes.Index(out, []byte(fmt.Sprintf("\n%d 0 obj", doc.pages[3].num)))
+	if image < lastPage {
+		t.Error("an image shared by later pages should follow the last page")
+	}
+
+	// The shared object hint table starts with the shared image
+	hintObject := out[lin.hintOffset : lin.hintOffset+lin.hintLength]
+	table, _ := strconv.Atoi(string(regexp.MustCompile(`/S (\d+)`).FindSubmatch(hintObject)[1]))
+	start := bytes.Index(hintObject, []byte("stream\n")) + len("stream\n")
+	r := &bitReader{data: hintObject[start+table:]}
+	firstShared := r.read(32)
+	if !bytes.Contains(out, []byte(fmt.Sprintf("%d 0 obj\n<</Type /XObject", firstShared))) {
+		t.Errorf("shared object hint table starts at object %d, not the image", firstShared)
+	}
+}
@@ -0,0 +1,209 @@
+package pdfsplit
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+
+	"github.com/fredcamaral/md-to-pdf/internal/pdfobj"
+)
+
+var (
+	referencePattern = regexp.MustCompile(`(\d+) 0 R`)
+	parentPattern    = regexp.MustCompile(`/Parent\s+\d+ 0 R`)
+	kidsPattern      = regexp.MustCompile(`/Kids\s*\[([^\]]*)\]`)
+	mediaBoxPattern  = regexp.MustCompile(`/MediaBox\s*\[[^\]]*\]`)
+	resourcesPattern = regexp.MustCompile(`/Resources\s+(\d+) 0 R`)
+	contentsPattern  = regexp.MustCompile(`/Contents\s+(?:(\d+) 0 R|\[([^\]]*)\])`)
+	rootPattern      = regexp.MustCompile(`/Root\s+(\d+) 0 R`)
+	infoPattern      = regexp.MustCompile(`/Info\s+(\d+) 0 R`)
+	pagesRefPattern  = regexp.MustCompile(`/Pages\s+(\d+) 0 R`)
+	destPagePattern  = regexp.MustCompile(`/Dest\s*\[\s*(\d+) 0 R`)
+	namedRefPattern  = regexp.MustCompile(`/([^\s/\[\]()<>{}%]+)\s+(\d+) 0 R`)
+	resourceUse      = regexp.MustCompile(`/([^\s/\[\]()<>{}%]+)\s+(?:[-\d.]+\s+)?(Tf|Do)\b`)
+	headerPattern    = regexp.MustCompile(`^%PDF-\d\.\d`)
+)
+
+// objectSize approximates the bytes an object takes up in an output file.
+func objectSize(o pdfobj.Object) int {
+	size := len(o.Dict) + 40 // Object header, endobj and xref entry
+	if o.Stream != nil {
+		size += len(o.Stream) + len("stream\n\nendstream")
+	}
+	return size
+}
+
+// writeObject writes an object to buf under num, with its stream data
+// copied unchanged.
+func writeObject(buf *bytes.Buffer, num int, dict string, stream []byte) {
+	fmt.Fprintf(buf, "%d 0 obj\n%s\n", num, dict)
+	if stream != nil {
+		buf.WriteString("stream\n")
+		buf.Write(stream)
+		buf.WriteString("\nendstream\n")
+	}
+	buf.WriteString("endobj\n")
+}
+
+// page is a page of the source document with the inherited attributes it
+// needs to stand alone.
+type page struct {
+	num       int
+	mediaBox  string // Inherited /MediaBox entry, empty when the page has its own
+	resources int    // Object number of the shared resource dictionary (0 = none)
+}
+
+// document is a parsed source PDF.
+type document struct {
+	header  string
+	objects map[int]pdfobj.Object
+	pages   []page
+	pageSet map[int]bool
+	root    int
+	info    int
+}
+
+// parse reads the objects and page tree of a PDF. Cross-reference streams
+// and object streams are not supported; the renderer does not produce them.
+func parse(data []byte) (*document, error) {
+	objects, pos, err := pdfobj.Read(data)
+	if err != nil {
+		return nil, err
+	}
+	doc := &document{
+		header:  "%PDF-1.3",
+		objects: objects,
+		pageSet: make(map[int]bool),
+	}
+	if m := headerPattern.Find(data); m != nil {
+		doc.header = string(m)
+	}
+
+	trailer := data[pos:]
+	root := rootPattern.FindSubmatch(trailer)
+	if root == nil {
+		return nil, fmt.Errorf("no document catalog found")
+	}
+	if m := infoPattern.FindSubmatch(trailer); m != nil {
+		doc.info, _ = strconv.Atoi(string(m[1]))
+	}
+
+	doc.root, _ = strconv.Atoi(string(root[1]))
+	pagesRef := pagesRefPattern.FindStringSubmatch(doc.objects[doc.root].Dict)
+	if pagesRef == nil {
+		return nil, fmt.Errorf("document catalog has no page tree")
+	}
+	pagesNum, _ := strconv.Atoi(pagesRef[1])
+	if err := doc.readPageTree(pagesNum, "", 0, 0); err != nil {
+		return nil, err
+	}
+	if len(doc.pages) == 0 {
+		return nil, fmt.Errorf("document has no pages")
+	}
+	return doc, nil
+}
+
+// readPageTree collects pages in document order, passing the inheritable
+// /MediaBox and /Resources entries down the tree.
+func (d *document) readPageTree(num int, mediaBox string, resources, depth int) error {
+	if depth > 32 {
+		return fmt.Errorf("page tree is too deep")
+	}
+	obj, ok := d.objects[num]
+	if !ok {
+		return fmt.Errorf("page object %d not found", num)
+	}
+
+	own := ownEntries(obj.Dict)
+	if m := mediaBoxPattern.FindString(own); m != "" {
+		mediaBox = m
+	}
+	if m := resourcesPattern.FindStringSubmatch(own); m != nil {
+		resources, _ = strconv.Atoi(m[1])
+	}
+
+	kids := kidsPattern.FindStringSubmatch(own)
+	if kids == nil {
+		p := page{num: num, resources: resources}
+		if !mediaBoxPattern.MatchString(own) {
+			p.mediaBox = mediaBox
+		}
+		d.pages = append(d.pages, p)
+		d.pageSet[num] = true
+		return nil
+	}
+
+	for _, ref := range referencePattern.FindAllStringSubmatch(kids[1], -1) {
+		kid, _ := strconv.Atoi(ref[1])
+		if err := d.readPageTree(kid, mediaBox, resources, depth+1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ownEntries returns the dictionary text without nested dictionaries, so
+// entries such as /Resources inside an annotation are not mistaken for the
+// page's own.
+func ownEntries(dict string) string {
+	var out []byte
+	depth := 0
+	for i := 0; i < len(dict); i++ {
+		switch {
+		case i+1 < len(dict) && dict[i] == '<' && dict[i+1] == '<':
+			depth++
+			i++
+			if depth == 1 {
+				out = append(out, ' ')
+			}
+			continue
+		case i+1 < len(dict) && dict[i] == '>' && dict[i+1] == '>':
+			depth--
+			i++
+			continue
+		}
+		if depth <= 1 {
+			out = append(out, dict[i])
+		}
+	}
+	return string(out)
+}
+
+// contents returns the decoded content streams of a page or form.
+func (d *document) contents(num int) []byte {
+	obj := d.objects[num]
+	if obj.Stream != nil {
+		// Form XObject: its own stream is the content
+		return decodeStream(obj)
+	}
+
+	m := contentsPattern.FindStringSubmatch(obj.Dict)
+	if m == nil {
+		return nil
+	}
+	refs := m[1]
+	if refs == "" {
+		refs = m[2]
+	} else {
+		refs += " 0 R"
+	}
+
+	var out []byte
+	for _, ref := range referencePattern.FindAllStringSubmatch(refs, -1) {
+		n, _ := strconv.Atoi(ref[1])
+		out = append(out, decodeStream(d.objects[n])...)
+		out = append(out, '\n')
+	}
+	return out
+}
+
+// decodeStream returns the stream data of obj with Flate compression
+// removed. Streams that fail to decode are returned empty.
+func decodeStream(obj pdfobj.Object) []byte {
+	decoded, err := obj.Decode()
+	if err != nil {
+		return nil
+	}
+	return decoded
+}
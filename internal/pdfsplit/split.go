@@ -0,0 +1,422 @@
+// Package pdfsplit reorganizes generated PDFs at page boundaries. Split
+// divides a document into several smaller ones, for example to stay below
+// e-mail attachment limits, and Linearize reorders one for fast web view.
+//
+// Each part of a split is a complete PDF with its own page tree. Fonts and
+// images are copied into every part that uses them, and internal links to
+// pages in other parts are removed.
+package pdfsplit
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/fredcamaral/md-to-pdf/internal/pdfobj"
+)
+
+// Part is one output document of a split.
+type Part struct {
+	Data      []byte
+	FirstPage int // 1-based page number in the source document
+	LastPage  int // 0 when a document returned unsplit could not be read
+}
+
+// Pages returns the number of pages in the part.
+func (p Part) Pages() int {
+	return p.LastPage - p.FirstPage + 1
+}
+
+// Split divides a PDF into parts of at most maxSize bytes. Pages are kept in
+// order and never divided, so a single page larger than maxSize produces a
+// part that exceeds the limit. A document that already fits is returned as
+// a single part holding the original data, without needing to be readable.
+func Split(data []byte, maxSize int64) ([]Part, error) {
+	if maxSize <= 0 {
+		return nil, fmt.Errorf("maximum size must be positive, got %d", maxSize)
+	}
+
+	if int64(len(data)) <= maxSize {
+		// Returned as it is, so a document the parser cannot read still fits
+		part := Part{Data: data, FirstPage: 1}
+		if doc, err := parse(data); err == nil {
+			part.LastPage = len(doc.pages)
+		}
+		return []Part{part}, nil
+	}
+
+	doc, err := parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read PDF: %w", err)
+	}
+
+	closures := make([]map[int]bool, len(doc.pages))
+	for i, p := range doc.pages {
+		closures[i] = doc.closure(p)
+	}
+
+	var parts []Part
+	for start := 0; start < len(doc.pages); {
+		end := doc.estimateEnd(closures, start, maxSize)
+		for {
+			out := doc.write(doc.pages[start:end])
+			if int64(len(out)) <= maxSize || end-start == 1 {
+				parts = append(parts, Part{Data: out, FirstPage: start + 1, LastPage: end})
+				break
+			}
+			end--
+		}
+		start = end
+	}
+	return parts, nil
+}
+
+// partOverhead approximates the bytes of a part outside the copied objects:
+// header, page tree, catalog, resource dictionary, cross-reference table
+// and trailer.
+const partOverhead = 512
+
+// estimateEnd returns the end index of the longest run of pages starting at
+// start whose estimated size fits in maxSize. At least one page is included.
+func (d *document) estimateEnd(closures []map[int]bool, start int, maxSize int64) int {
+	included := make(map[int]bool)
+	size := int64(partOverhead)
+
+	end := start
+	for end < len(d.pages) {
+		added := int64(0)
+		for num := range closures[end] {
+			if !included[num] {
+				added += int64(objectSize(d.objects[num]))
+			}
+		}
+		if end > start && size+added > maxSize {
+			break
+		}
+		for num := range closures[end] {
+			included[num] = true
+		}
+		size += added
+		end++
+	}
+	return end
+}
+
+// closure returns the objects a page needs, excluding the shared resource
+// dictionary, which is rebuilt for every part.
+func (d *document) closure(p page) map[int]bool {
+	seen := map[int]bool{p.num: true}
+	queue := []int{p.num}
+
+	if p.resources != 0 {
+		_, refs := d.usedResources(p)
+		for _, num := range refs {
+			if !seen[num] {
+				seen[num] = true
+				queue = append(queue, num)
+			}
+		}
+	}
+
+	for len(queue) > 0 {
+		num := queue[0]
+		queue = queue[1:]
+		dict := d.objects[num].Dict
+		if num == p.num {
+			dict = d.ownDict(p)
+		}
+		for _, ref := range references(dict) {
+			if seen[ref] || d.pageSet[ref] || ref == p.resources {
+				continue
+			}
+			if _, ok := d.objects[ref]; !ok {
+				continue
+			}
+			seen[ref] = true
+			queue = append(queue, ref)
+		}
+	}
+	return seen
+}
+
+// ownDict returns the page dictionary without its /Parent entry.
+func (d *document) ownDict(p page) string {
+	return parentPattern.ReplaceAllString(d.objects[p.num].Dict, "")
+}
+
+// usedResources returns the resource names a page draws with and the
+// objects they refer to in the shared resource dictionary, following form
+// XObjects that draw other resources.
+func (d *document) usedResources(p page) (map[string]bool, []int) {
+	resources := d.objects[p.resources].Dict
+	named := make(map[string]int)
+	for _, key := range []string{"/Font", "/XObject"} {
+		body, ok := pdfobj.SubDictionary(resources, key)
+		if !ok {
+			continue
+		}
+		for _, m := range namedRefPattern.FindAllStringSubmatch(body, -1) {
+			num, _ := strconv.Atoi(m[2])
+			named[m[1]] = num
+		}
+	}
+
+	names := make(map[string]bool)
+	var refs []int
+	queue := [][]byte{d.contents(p.num)}
+	for len(queue) > 0 {
+		content := queue[0]
+		queue = queue[1:]
+		for _, m := range resourceUse.FindAllSubmatch(content, -1) {
+			name := string(m[1])
+			num, ok := named[name]
+			if !ok || names[name] {
+				continue
+			}
+			names[name] = true
+			refs = append(refs, num)
+			if string(m[2]) == "Do" && strings.Contains(d.objects[num].Dict, "/Form") {
+				queue = append(queue, d.contents(num))
+			}
+		}
+	}
+	return names, refs
+}
+
+// write assembles a standalone PDF from a run of pages.
+func (d *document) write(pages []page) []byte {
+	inPart := make(map[int]bool)
+	for _, p := range pages {
+		inPart[p.num] = true
+	}
+
+	// Object numbers: 1 page tree, 2 catalog, 3 resources, then the rest
+	const (
+		pagesNum     = 1
+		catalogNum   = 2
+		resourcesNum = 3
+	)
+	renumber := make(map[int]int)
+	next := resourcesNum + 1
+	var order []int
+	add := func(num int) {
+		if _, ok := renumber[num]; !ok {
+			renumber[num] = next
+			next++
+			order = append(order, num)
+		}
+	}
+
+	names := make(map[string]bool)
+	for _, p := range pages {
+		add(p.num)
+		if p.resources != 0 {
+			renumber[p.resources] = resourcesNum
+			used, _ := d.usedResources(p)
+			for name := range used {
+				names[name] = true
+			}
+		}
+	}
+	for _, p := range pages {
+		nums := make([]int, 0)
+		for num := range d.closure(p) {
+			nums = append(nums, num)
+		}
+		sort.Ints(nums)
+		for _, num := range nums {
+			add(num)
+		}
+	}
+	if d.info != 0 {
+		add(d.info)
+	}
+
+	dicts := make(map[int]string, len(order))
+	for _, num := range order {
+		dicts[num] = d.objects[num].Dict
+	}
+	var mediaBox string
+	for _, p := range pages {
+		dict := dropAnnotations(d.ownDict(p), func(ref int) bool { return d.pageSet[ref] && !inPart[ref] })
+		dict = strings.Replace(dict, "<<", fmt.Sprintf("<</Parent %d 0 R ", pagesNum), 1)
+		if p.resources != 0 && !resourcesPattern.MatchString(ownEntries(dict)) {
+			// Inherited from an intermediate node that is not copied
+			dict = strings.Replace(dict, "<<", fmt.Sprintf("<</Resources %d 0 R ", resourcesNum), 1)
+		}
+		if p.mediaBox != "" {
+			if mediaBox == "" {
+				mediaBox = p.mediaBox
+			} else if p.mediaBox != mediaBox {
+				dict = strings.Replace(dict, "<<", "<<"+p.mediaBox+" ", 1)
+			}
+		}
+		dicts[p.num] = dict
+	}
+
+	var buf bytes.Buffer
+	offsets := make([]int, next)
+	put := func(num int, body string, stream []byte) {
+		offsets[num] = buf.Len()
+		writeObject(&buf, num, body, stream)
+	}
+
+	buf.WriteString(d.header + "\n%\xe2\xe3\xcf\xd3\n")
+
+	kids := make([]string, len(pages))
+	for i, p := range pages {
+		kids[i] = fmt.Sprintf("%d 0 R", renumber[p.num])
+	}
+	put(pagesNum, fmt.Sprintf("<</Type /Pages\n/Kids [%s]\n/Count %d\n%s\n>>",
+		strings.Join(kids, " "), len(pages), mediaBox), nil)
+	put(catalogNum, fmt.Sprintf("<</Type /Catalog\n/Pages %d 0 R\n>>", pagesNum), nil)
+
+	resources := "<<>>"
+	for _, p := range pages {
+		if p.resources != 0 {
+			resources = filterResources(d.objects[p.resources].Dict, names)
+			break
+		}
+	}
+	put(resourcesNum, remap(resources, renumber), nil)
+
+	for _, num := range order {
+		put(renumber[num], remap(dicts[num], renumber), d.objects[num].Stream)
+	}
+
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", next)
+	for num := 1; num < next; num++ {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", offsets[num])
+	}
+	fmt.Fprintf(&buf, "trailer\n<<\n/Size %d\n/Root %d 0 R\n", next, catalogNum)
+	if d.info != 0 {
+		fmt.Fprintf(&buf, "/Info %d 0 R\n", renumber[d.info])
+	}
+	fmt.Fprintf(&buf, ">>\nstartxref\n%d\n%%%%EOF\n", xref)
+	return buf.Bytes()
+}
+
+// filterResources keeps only the fonts and XObjects in names.
+func filterResources(dict string, names map[string]bool) string {
+	for _, key := range []string{"/Font", "/XObject"} {
+		body, ok := pdfobj.SubDictionary(dict, key)
+		if !ok {
+			continue
+		}
+		var kept []string
+		for _, m := range namedRefPattern.FindAllStringSubmatch(body, -1) {
+			if names[m[1]] {
+				kept = append(kept, m[0])
+			}
+		}
+		filtered := "\n" + strings.Join(kept, "\n") + "\n"
+		dict = strings.Replace(dict, body, filtered, 1)
+	}
+	return dict
+}
+
+// remap rewrites object references with their numbers in the part.
+// References to objects outside the part become null.
+func remap(dict string, renumber map[int]int) string {
+	return referencePattern.ReplaceAllStringFunc(dict, func(ref string) string {
+		num, _ := strconv.Atoi(referencePattern.FindStringSubmatch(ref)[1])
+		if n, ok := renumber[num]; ok {
+			return fmt.Sprintf("%d 0 R", n)
+		}
+		return "null"
+	})
+}
+
+// references returns the object numbers referenced from dict.
+func references(dict string) []int {
+	var refs []int
+	for _, m := range referencePattern.FindAllStringSubmatch(dict, -1) {
+		num, _ := strconv.Atoi(m[1])
+		refs = append(refs, num)
+	}
+	return refs
+}
+
+var annotsPattern = regexp.MustCompile(`/Annots\s*\[`)
+
+// dropAnnotations removes inline link annotations whose destination page
+// satisfies drop. An emptied /Annots array is removed entirely.
+func dropAnnotations(dict string, drop func(page int) bool) string {
+	loc := annotsPattern.FindStringIndex(dict)
+	if loc == nil {
+		return dict
+	}
+
+	// Walk the array, splitting it into inline dictionaries and references
+	var kept []string
+	depth := 0
+	itemStart := -1
+	end := -1
+	for i := loc[1]; i < len(dict); i++ {
+		switch {
+		case i+1 < len(dict) && dict[i] == '<' && dict[i+1] == '<':
+			if depth == 0 {
+				itemStart = i
+			}
+			depth++
+			i++
+		case i+1 < len(dict) && dict[i] == '>' && dict[i+1] == '>':
+			depth--
+			i++
+			if depth == 0 && itemStart >= 0 {
+				item := dict[itemStart : i+1]
+				dest := destPagePattern.FindStringSubmatch(item)
+				if dest == nil {
+					kept = append(kept, item)
+				} else if page, _ := strconv.Atoi(dest[1]); !drop(page) {
+					kept = append(kept, item)
+				}
+				itemStart = -1
+			}
+		case depth == 0 && dict[i] == ']':
+			end = i
+		}
+		if end >= 0 {
+			break
+		}
+	}
+	if end < 0 {
+		return dict
+	}
+
+	// Indirect annotations between inline ones are kept as references
+	for _, m := range referencePattern.FindAllString(stripDictionaries(dict[loc[1]:end]), -1) {
+		kept = append(kept, m)
+	}
+
+	if len(kept) == 0 {
+		return dict[:loc[0]] + dict[end+1:]
+	}
+	return dict[:loc[1]] + strings.Join(kept, " ") + dict[end:]
+}
+
+// stripDictionaries removes nested dictionaries from an array body.
+func stripDictionaries(s string) string {
+	var out []byte
+	depth := 0
+	for i := 0; i < len(s); i++ {
+		switch {
+		case i+1 < len(s) && s[i] == '<' && s[i+1] == '<':
+			depth++
+			i++
+			continue
+		case i+1 < len(s) && s[i] == '>' && s[i+1] == '>':
+			depth--
+			i++
+			continue
+		}
+		if depth == 0 {
+			out = append(out, s[i])
+		}
+	}
+	return string(out)
+}
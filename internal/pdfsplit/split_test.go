@@ -0,0 +1,212 @@
+package pdfsplit
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"math/rand"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/jung-kurt/gofpdf"
+)
+
+// noisyPNG returns a PNG that does not compress well, so every page that
+// shows one carries a sizable image.
+func noisyPNG(t *testing.T, seed int64) []byte {
+	t.Helper()
+	rng := rand.New(rand.NewSource(seed))
+	img := image.NewRGBA(image.Rect(0, 0, 64, 64))
+	for y := 0; y < 64; y++ {
+		for x := 0; x < 64; x++ {
+			img.Set(x, y, color.RGBA{uint8(rng.Intn(256)), uint8(rng.Intn(256)), uint8(rng.Intn(256)), 255})
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatalf("failed to encode PNG: %v", err)
+	}
+	return buf.Bytes()
+}
+
+// buildPDF renders a document with one image per page and an internal link
+// on every page pointing at the first page.
+func buildPDF(t *testing.T, pages int) []byte {
+	t.Helper()
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.SetFont("Arial", "", 12)
+
+	first := pdf.AddLink()
+	for i := 0; i < pages; i++ {
+		pdf.AddPage()
+		if i == 0 {
+			pdf.SetLink(first, 0, 1)
+		}
+		pdf.CellFormat(0, 10, fmt.Sprintf("Page %d", i+1), "", 1, "", false, first, "")
+		name := fmt.Sprintf("image%d", i)
+		pdf.RegisterImageOptionsReader(name, gofpdf.ImageOptions{ImageType: "PNG"}, bytes.NewReader(noisyPNG(t, int64(i))))
+		pdf.ImageOptions(name, 20, 30, 50, 50, false, gofpdf.ImageOptions{ImageType: "PNG"}, 0, "")
+	}
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		t.Fatalf("failed to render PDF: %v", err)
+	}
+	return buf.Bytes()
+}
+
+// checkStructure verifies that a part is a self-contained PDF: the
+// cross-reference table points at the right objects and every reference
+// resolves.
+func checkStructure(t *testing.T, data []byte) {
+	t.Helper()
+
+	startxref := regexp.MustCompile(`startxref\s+(\d+)`).FindSubmatch(data)
+	if startxref == nil {
+		t.Fatal("missing startxref")
+	}
+	offset, _ := strconv.Atoi(string(startxref[1]))
+	if !bytes.HasPrefix(data[offset:], []byte("xref")) {
+		t.Fatal("startxref does not point at the xref table")
+	}
+
+	entries := regexp.MustCompile(`(\d{10}) 00000 n `).FindAllSubmatch(data[offset:], -1)
+	for i, entry := range entries {
+		pos, _ := strconv.Atoi(string(entry[1]))
+		want := fmt.Sprintf("%d 0 obj", i+1)
+		if !bytes.HasPrefix(data[pos:], []byte(want)) {
+			t.Errorf("xref entry %d points at %q", i+1, data[pos:min(pos+12, len(data))])
+		}
+	}
+
+	doc, err := parse(data)
+	if err != nil {
+		t.Fatalf("part is not readable: %v", err)
+	}
+	for num, obj := range doc.objects {
+		for _, ref := range references(obj.Dict) {
+			if _, ok := doc.objects[ref]; !ok {
+				t.Errorf("object %d references missing object %d", num, ref)
+			}
+		}
+	}
+}
+
+func TestSplit(t *testing.T) {
+	data := buildPDF(t, 12)
+	limit := int64(len(data) / 3)
+
+	parts, err := Split(data, limit)
+	if err != nil {
+		t.Fatalf("Split failed: %v", err)
+	}
+	if len(parts) < 3 {
+		t.Fatalf("expected at least 3 parts, got %d", len(parts))
+	}
+
+	nextPage := 1
+	for i, part := range parts {
+		if int64(len(part.Data)) > limit {
+			t.Errorf("part %d is %d bytes, over the %d byte limit", i+1, len(part.Data), limit)
+		}
+		if part.FirstPage != nextPage {
+			t.Errorf("part %d starts at page %d, want %d", i+1, part.FirstPage, nextPage)
+		}
+		nextPage = part.LastPage + 1
+
+		checkStructure(t, part.Data)
+		doc, err := parse(part.Data)
+		if err != nil {
+			t.Fatalf("part %d: %v", i+1, err)
+		}
+		if len(doc.pages) != part.Pages() {
+			t.Errorf("part %d has %d pages, want %d", i+1, len(doc.pages), part.Pages())
+		}
+
+		// Only the images of the part's own pages are copied
+		if images := bytes.Count(part.Data, []byte("/Subtype /Image")); images != part.Pages() {
+			t.Errorf("part %d embeds %d images for %d pages", i+1, images, part.Pages())
+		}
+	}
+	if nextPage != 13 {
+		t.Errorf("parts cover pages up to %d, want 12", nextPage-1)
+	}
+}
+
+func TestSplit_DropsLinksToOtherParts(t *testing.T) {
+	data := buildPDF(t, 6)
+	parts, err := Split(data, int64(len(data)/2))
+	if err != nil {
+		t.Fatalf("Split failed: %v", err)
+	}
+	if len(parts) < 2 {
+		t.Fatalf("expected several parts, got %d", len(parts))
+	}
+
+	// Every page links to page 1, which only the first part contains
+	if !bytes.Contains(parts[0].Data, []byte("/Dest")) {
+		t.Error("links within the first part should be kept")
+	}
+	for i, part := range parts[1:] {
+		if bytes.Contains(part.Data, []byte("/Dest")) {
+			t.Errorf("part %d keeps a link to a page in another part", i+2)
+		}
+	}
+}
+
+func TestSplit_FitsUnchanged(t *testing.T) {
+	data := buildPDF(t, 2)
+	parts, err := Split(data, int64(len(data)))
+	if err != nil {
+		t.Fatalf("Split failed: %v", err)
+	}
+	if len(parts) != 1 || !bytes.Equal(parts[0].Data, data) {
+		t.Fatal("a document within the limit should be returned unchanged")
+	}
+	if parts[0].FirstPage != 1 || parts[0].LastPage != 2 {
+		t.Errorf("unexpected page range %d-%d", parts[0].FirstPage, parts[0].LastPage)
+	}
+}
+
+func TestSplit_FitsWithoutParsing(t *testing.T) {
+	data := []byte("%PDF-1.7\n1 0 obj\n<</Type /ObjStm>>\nendobj\n")
+	parts, err := Split(data, int64(len(data)))
+	if err != nil {
+		t.Fatalf("a document within the limit should not need to be parsed: %v", err)
+	}
+	if len(parts) != 1 || !bytes.Equal(parts[0].Data, data) {
+		t.Fatal("a document within the limit should be returned unchanged")
+	}
+	if _, err := Split(data, int64(len(data))-1); err == nil {
+		t.Error("a document over the limit that cannot be parsed should fail")
+	}
+}
+
+func TestSplit_OversizedPage(t *testing.T) {
+	data := buildPDF(t, 3)
+	parts, err := Split(data, 1024)
+	if err != nil {
+		t.Fatalf("Split failed: %v", err)
+	}
+	if len(parts) != 3 {
+		t.Fatalf("expected one part per page, got %d", len(parts))
+	}
+	for _, part := range parts {
+		if part.Pages() != 1 {
+			t.Errorf("expected single-page parts, got %d pages", part.Pages())
+		}
+	}
+}
+
+func TestSplit_InvalidInput(t *testing.T) {
+	if _, err := Split([]byte("not a pdf"), 4); err == nil {
+		t.Error("expected error for invalid PDF")
+	}
+	if _, err := Split(buildPDF(t, 1), 0); err == nil || !strings.Contains(err.Error(), "must be positive") {
+		t.Errorf("expected error for non-positive size, got %v", err)
+	}
+}
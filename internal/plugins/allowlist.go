@@ -0,0 +1,340 @@
+package plugins
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultChecksumAlgorithm is the algorithm of checksums without an
+// algorithm tag, the only one allowlist format v1 supports.
+const DefaultChecksumAlgorithm = "sha256"
+
+// AllowlistFormatV2 is the version of the YAML and JSON allowlist format.
+const AllowlistFormatV2 = 2
+
+var (
+	checksumAlgorithms = map[string]func() hash.Hash{
+		"sha256": sha256.New,
+		"sha512": sha512.New,
+	}
+	checksumAlgorithmsMu sync.RWMutex
+)
+
+// RegisterChecksumAlgorithm makes an algorithm available to allowlist
+// entries tagged with its name, such as "sha384:<hex>". Names are matched
+// case-insensitively, and registering a name again replaces it.
+func RegisterChecksumAlgorithm(name string, newHash func() hash.Hash) {
+	checksumAlgorithmsMu.Lock()
+	defer checksumAlgorithmsMu.Unlock()
+	checksumAlgorithms[strings.ToLower(name)] = newHash
+}
+
+// checksumAlgorithm returns the constructor of the named algorithm.
+func checksumAlgorithm(name string) (func() hash.Hash, error) {
+	checksumAlgorithmsMu.RLock()
+	defer checksumAlgorithmsMu.RUnlock()
+	newHash, ok := checksumAlgorithms[strings.ToLower(name)]
+	if !ok {
+		return nil, fmt.Errorf("unknown checksum algorithm %q (supported: %s)", name, strings.Join(sortedKeys(checksumAlgorithms), ", "))
+	}
+	return newHash, nil
+}
+
+// sortedKeys returns the keys of m, sorted.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// parseChecksum splits a checksum such as "sha512:<hex>" into its algorithm
+// and lowercase hex digest, checking the digest has the length the
+// algorithm produces. Untagged checksums are SHA256.
+func parseChecksum(checksum string) (algorithm, digest string, err error) {
+	algorithm, digest = DefaultChecksumAlgorithm, strings.TrimSpace(checksum)
+	if tag, rest, ok := strings.Cut(digest, ":"); ok {
+		algorithm, digest = strings.ToLower(strings.TrimSpace(tag)), strings.TrimSpace(rest)
+	}
+	newHash, err := checksumAlgorithm(algorithm)
+	if err != nil {
+		return "", "", err
+	}
+	if size := newHash().Size() * 2; len(digest) != size {
+		return "", "", fmt.Errorf("expected %d character %s hash", size, strings.ToUpper(algorithm))
+	}
+	if _, err := hex.DecodeString(digest); err != nil {
+		return "", "", errors.New("not valid hex encoding")
+	}
+	return algorithm, strings.ToLower(digest), nil
+}
+
+// hashReader returns the hex digest of r with the named algorithm.
+func hashReader(r io.Reader, algorithm string) (string, error) {
+	newHash, err := checksumAlgorithm(algorithm)
+	if err != nil {
+		return "", err
+	}
+	h := newHash()
+	if _, err := io.Copy(h, r); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// allowlistFile is an allowlist in format v2:
+//
+//	version: 2
+//	plugins:
+//	  - name: mermaid.so
+//	    checksum: sha512:<hex>
+//	    version: ">=1.2.0, <2"
+//	    trusted_directories: [/opt/md-to-pdf/plugins]
+//	  - name: old.so
+//	    checksum: sha256:<hex>
+//	    disabled: true
+//
+// JSON files hold the same fields.
+type allowlistFile struct {
+	Version int                  `yaml:"version"`
+	Plugins []allowlistFileEntry `yaml:"plugins"`
+}
+
+type allowlistFileEntry struct {
+	Name               string   `yaml:"name"`
+	Checksum           string   `yaml:"checksum"`
+	Version            string   `yaml:"version,omitempty"`
+	TrustedDirectories []string `yaml:"trusted_directories,omitempty"`
+	Disabled           bool     `yaml:"disabled,omitempty"`
+}
+
+// v2Header matches the first line of a v2 allowlist without a .yaml, .yml
+// or .json extension. A v1 line can never match, as it ends in a checksum.
+var v2Header = regexp.MustCompile(`^(\{|version:\s*\d+\s*(#.*)?$)`)
+
+// isAllowlistV2 reports whether the allowlist at path with the given
+// content is in format v2: it has a YAML or JSON extension, or its first
+// line that is not blank or a comment is a version line or opens a JSON
+// object.
+func isAllowlistV2(path string, content []byte) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml", ".json":
+		return true
+	}
+	for _, line := range strings.Split(string(content), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		return v2Header.MatchString(line)
+	}
+	return false
+}
+
+// parseAllowlistV2 parses an allowlist in format v2. Unknown fields are
+// errors, so a misspelled restriction is not silently ignored.
+func parseAllowlistV2(content []byte) (*PluginAllowlist, error) {
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+	decoder.KnownFields(true)
+	var file allowlistFile
+	if err := decoder.Decode(&file); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("invalid allowlist: %w", err)
+	}
+	if file.Version != AllowlistFormatV2 {
+		return nil, fmt.Errorf("unsupported allowlist version %d: expected version: %d", file.Version, AllowlistFormatV2)
+	}
+
+	allowlist := NewPluginAllowlist()
+	for i, item := range file.Plugins {
+		name := strings.TrimSpace(item.Name)
+		if name == "" {
+			return nil, fmt.Errorf("invalid allowlist entry %d: name cannot be empty", i+1)
+		}
+		if _, exists := allowlist.entries[name]; exists {
+			return nil, fmt.Errorf("invalid allowlist entry %d: %s is listed more than once", i+1, name)
+		}
+		if strings.TrimSpace(item.Checksum) == "" {
+			return nil, fmt.Errorf("invalid allowlist entry for %s: checksum cannot be empty", name)
+		}
+		algorithm, digest, err := parseChecksum(item.Checksum)
+		if err != nil {
+			return nil, fmt.Errorf("invalid checksum for %s: %w", name, err)
+		}
+		if _, err := parseVersionConstraint(item.Version); err != nil {
+			return nil, fmt.Errorf("invalid version constraint for %s: %w", name, err)
+		}
+		for _, dir := range item.TrustedDirectories {
+			if strings.TrimSpace(dir) == "" || containsPathTraversal(dir) {
+				return nil, fmt.Errorf("invalid trusted directory %q for %s", dir, name)
+			}
+		}
+
+		allowlist.entries[name] = AllowlistEntry{
+			Name:               name,
+			Algorithm:          algorithm,
+			Checksum:           digest,
+			Enabled:            !item.Disabled,
+			Version:            strings.TrimSpace(item.Version),
+			TrustedDirectories: item.TrustedDirectories,
+		}
+	}
+	return allowlist, nil
+}
+
+// versionConstraint is a list of comparisons a version must all satisfy,
+// such as ">=1.2.0, <2".
+type versionConstraint []versionComparison
+
+type versionComparison struct {
+	op      string
+	version pluginVersion
+}
+
+// parseVersionConstraint parses comma-separated comparisons with the
+// operators =, !=, >, >=, < and <=. A version without an operator must be
+// matched exactly. The empty constraint allows any version.
+func parseVersionConstraint(constraint string) (versionConstraint, error) {
+	constraint = strings.TrimSpace(constraint)
+	if constraint == "" {
+		return nil, nil
+	}
+	var parsed versionConstraint
+	for _, clause := range strings.Split(constraint, ",") {
+		clause = strings.TrimSpace(clause)
+		op := "="
+		for _, candidate := range []string{">=", "<=", "!=", "==", ">", "<", "="} {
+			if strings.HasPrefix(clause, candidate) {
+				op, clause = candidate, strings.TrimSpace(clause[len(candidate):])
+				break
+			}
+		}
+		if op == "==" {
+			op = "="
+		}
+		version, err := parsePluginVersion(clause)
+		if err != nil {
+			return nil, err
+		}
+		parsed = append(parsed, versionComparison{op: op, version: version})
+	}
+	return parsed, nil
+}
+
+// allows reports whether version satisfies every comparison.
+func (c versionConstraint) allows(version pluginVersion) bool {
+	for _, comparison := range c {
+		cmp := version.compare(comparison.version)
+		var ok bool
+		switch comparison.op {
+		case "=":
+			ok = cmp == 0
+		case "!=":
+			ok = cmp != 0
+		case ">":
+			ok = cmp > 0
+		case ">=":
+			ok = cmp >= 0
+		case "<":
+			ok = cmp < 0
+		case "<=":
+			ok = cmp <= 0
+		}
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// pluginVersion is a dotted numeric version with an optional pre-release
+// suffix, such as 1.2.0 or v2.0.0-beta.1.
+type pluginVersion struct {
+	numbers    []int
+	prerelease string
+}
+
+// parsePluginVersion parses a version, ignoring a leading "v" and build
+// metadata after "+".
+func parsePluginVersion(version string) (pluginVersion, error) {
+	text := strings.TrimPrefix(strings.TrimSpace(version), "v")
+	text, _, _ = strings.Cut(text, "+")
+	text, prerelease, _ := strings.Cut(text, "-")
+	if text == "" {
+		return pluginVersion{}, fmt.Errorf("invalid version %q", version)
+	}
+	var parsed pluginVersion
+	for _, part := range strings.Split(text, ".") {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return pluginVersion{}, fmt.Errorf("invalid version %q", version)
+		}
+		parsed.numbers = append(parsed.numbers, n)
+	}
+	parsed.prerelease = prerelease
+	return parsed, nil
+}
+
+// compare returns -1, 0 or 1 as v is older than, the same as or newer than
+// other. Missing numbers count as 0, so 1.2 is 1.2.0, and a pre-release
+// comes before its release.
+func (v pluginVersion) compare(other pluginVersion) int {
+	for i := 0; i < max(len(v.numbers), len(other.numbers)); i++ {
+		a, b := 0, 0
+		if i < len(v.numbers) {
+			a = v.numbers[i]
+		}
+		if i < len(other.numbers) {
+			b = other.numbers[i]
+		}
+		if a != b {
+			if a < b {
+				return -1
+			}
+			return 1
+		}
+	}
+	switch {
+	case v.prerelease == other.prerelease:
+		return 0
+	case v.prerelease == "":
+		return 1
+	case other.prerelease == "":
+		return -1
+	}
+	return strings.Compare(v.prerelease, other.prerelease)
+}
+
+// CheckVersion checks the version a loaded plugin reports against the
+// version constraint of its allowlist entry. Plugins without an entry or
+// constraint pass.
+func (a *PluginAllowlist) CheckVersion(name, version string) error {
+	entry, ok := a.Entry(name)
+	if !ok || entry.Version == "" {
+		return nil
+	}
+	constraint, err := parseVersionConstraint(entry.Version)
+	if err != nil {
+		return err
+	}
+	parsed, err := parsePluginVersion(version)
+	if err != nil || !constraint.allows(parsed) {
+		return fmt.Errorf("version %q does not satisfy %q", version, entry.Version)
+	}
+	return nil
+}
@@ -0,0 +1,259 @@
+package plugins
+
+import (
+	"crypto/sha512"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeAllowlist writes an allowlist file into a temporary directory.
+func writeAllowlist(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadAllowlistFromFile_V2(t *testing.T) {
+	sha512Hello := "9b71d224bd62f3785d96d46ad3ea3d73319bfbc2890caadae2dff72519673ca72323c3d99ba5c11d7c7acc6e14b8c5da0c4663475c2e5c3adef46f73bcdec043"
+	sha256Empty := "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+	yamlPath := writeAllowlist(t, "allowlist.yaml", `version: 2
+plugins:
+  - name: mermaid.so
+    checksum: SHA512:`+strings.ToUpper(sha512Hello)+`
+    version: ">=1.2.0, <2"
+    trusted_directories: [/opt/md-to-pdf/plugins]
+  - name: old.so
+    checksum: `+sha256Empty+`
+    disabled: true
+`)
+	jsonPath := writeAllowlist(t, "allowlist.json", `{"version": 2, "plugins": [
+  {"name": "mermaid.so", "checksum": "sha512:`+sha512Hello+`", "version": ">=1.2.0, <2", "trusted_directories": ["/opt/md-to-pdf/plugins"]},
+  {"name": "old.so", "checksum": "sha256:`+sha256Empty+`", "disabled": true}
+]}`)
+	// Detected from the version line without a YAML extension
+	plainPath := writeAllowlist(t, "allowlist", `# Plugins for the docs build
+version: 2
+plugins:
+  - name: mermaid.so
+    checksum: sha512:`+sha512Hello+`
+    version: ">=1.2.0, <2"
+    trusted_directories: [/opt/md-to-pdf/plugins]
+  - name: old.so
+    checksum: sha256:`+sha256Empty+`
+    disabled: true
+`)
+
+	for _, path := range []string{yamlPath, jsonPath, plainPath} {
+		allowlist, err := LoadAllowlistFromFile(path)
+		if err != nil {
+			t.Fatalf("%s: %v", filepath.Base(path), err)
+		}
+		entry, ok := allowlist.Entry("mermaid.so")
+		if !ok {
+			t.Fatalf("%s: mermaid.so should have an entry", filepath.Base(path))
+		}
+		if entry.Algorithm != "sha512" || entry.Checksum != sha512Hello || !entry.Enabled ||
+			entry.Version != ">=1.2.0, <2" || len(entry.TrustedDirectories) != 1 {
+			t.Errorf("%s: mermaid.so entry = %+v", filepath.Base(path), entry)
+		}
+		if !allowlist.IsAllowed("mermaid.so", sha512Hello) {
+			t.Errorf("%s: mermaid.so with its SHA512 checksum should be allowed", filepath.Base(path))
+		}
+		if allowlist.IsAllowed("old.so", sha256Empty) {
+			t.Errorf("%s: a disabled plugin should not be allowed", filepath.Base(path))
+		}
+		if entry, _ := allowlist.Entry("old.so"); entry.Algorithm != "sha256" {
+			t.Errorf("%s: old.so algorithm = %q", filepath.Base(path), entry.Algorithm)
+		}
+	}
+}
+
+func TestLoadAllowlistFromFile_V2Invalid(t *testing.T) {
+	checksum := "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{"no version", "plugins: []\n", "unsupported allowlist version 0"},
+		{"future version", "version: 3\n", "unsupported allowlist version 3"},
+		{"unknown field", "version: 2\nplugins:\n  - name: a.so\n    checksum: " + checksum + "\n    trusted_dirs: [/opt]\n", "trusted_dirs"},
+		{"unknown algorithm", "version: 2\nplugins:\n  - name: a.so\n    checksum: md5:" + checksum + "\n", `unknown checksum algorithm "md5"`},
+		{"wrong length", "version: 2\nplugins:\n  - name: a.so\n    checksum: sha512:" + checksum + "\n", "expected 128 character SHA512 hash"},
+		{"no checksum", "version: 2\nplugins:\n  - name: a.so\n", "checksum cannot be empty"},
+		{"no name", "version: 2\nplugins:\n  - checksum: " + checksum + "\n", "name cannot be empty"},
+		{"duplicate", "version: 2\nplugins:\n  - name: a.so\n    checksum: " + checksum + "\n  - name: a.so\n    checksum: " + checksum + "\n", "listed more than once"},
+		{"bad constraint", "version: 2\nplugins:\n  - name: a.so\n    checksum: " + checksum + "\n    version: '>= one'\n", "invalid version constraint for a.so"},
+		{"traversal", "version: 2\nplugins:\n  - name: a.so\n    checksum: " + checksum + "\n    trusted_directories: [../plugins]\n", "invalid trusted directory"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadAllowlistFromFile(writeAllowlist(t, "allowlist.yaml", tt.content))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error = %v, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsAllowlistV2(t *testing.T) {
+	tests := []struct {
+		path    string
+		content string
+		want    bool
+	}{
+		{"allowlist.txt", "# comment\n\nplugin.so:e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855\n", false},
+		{"allowlist.txt", "\nversion: 2 # format\nplugins: []\n", true},
+		{"allowlist", `{"version": 2}`, true},
+		{"allowlist.YML", "plugin.so:abc\n", true},
+		{"allowlist", "", false},
+	}
+	for _, tt := range tests {
+		if got := isAllowlistV2(tt.path, []byte(tt.content)); got != tt.want {
+			t.Errorf("isAllowlistV2(%q, %q) = %v, want %v", tt.path, tt.content, got, tt.want)
+		}
+	}
+}
+
+func TestRegisterChecksumAlgorithm(t *testing.T) {
+	RegisterChecksumAlgorithm("SHA384", sha512.New384)
+	defer func() {
+		checksumAlgorithmsMu.Lock()
+		delete(checksumAlgorithms, "sha384")
+		checksumAlgorithmsMu.Unlock()
+	}()
+
+	path := writeAllowlist(t, "hello.txt", "hello")
+	digest, err := CalculateFileDigest(path, "sha384")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "59e1748777448c69de6b800d7a33bbfb9ff1b463e44354c3553bcdb9c666fa90125a3c79f90397bdf5f6a13de828684f"
+	if digest != want {
+		t.Errorf("CalculateFileDigest() = %s, want %s", digest, want)
+	}
+
+	algorithm, parsed, err := parseChecksum("sha384:" + want)
+	if err != nil || algorithm != "sha384" || parsed != want {
+		t.Errorf("parseChecksum() = %q, %q, %v", algorithm, parsed, err)
+	}
+
+	if _, err := CalculateFileDigest(path, "crc32"); err == nil {
+		t.Error("an unregistered algorithm should be an error")
+	}
+}
+
+func TestVersionConstraint(t *testing.T) {
+	tests := []struct {
+		constraint string
+		version    string
+		want       bool
+	}{
+		{"", "0.0.1", true},
+		{">=1.2.0, <2", "1.2.0", true},
+		{">=1.2.0, <2", "v1.10.3", true},
+		{">=1.2.0, <2", "1.1.9", false},
+		{">=1.2.0, <2", "2.0.0", false},
+		{">=1.2.0, <2", "2.0.0-rc.1", true},
+		{"1.2", "1.2.0", true},
+		{"==1.2.0", "1.2.1", false},
+		{"!=1.3.0", "1.3.0", false},
+		{">1.0.0-beta", "1.0.0-rc", true},
+		{"<=1.0.0", "1.0.0+build.7", true},
+	}
+	for _, tt := range tests {
+		constraint, err := parseVersionConstraint(tt.constraint)
+		if err != nil {
+			t.Fatalf("parseVersionConstraint(%q) failed: %v", tt.constraint, err)
+		}
+		version, err := parsePluginVersion(tt.version)
+		if err != nil {
+			t.Fatalf("parsePluginVersion(%q) failed: %v", tt.version, err)
+		}
+		if got := constraint.allows(version); got != tt.want {
+			t.Errorf("%q allows %q = %v, want %v", tt.constraint, tt.version, got, tt.want)
+		}
+	}
+
+	for _, bad := range []string{">=", "1.x", ">=1.0,", "~1.2"} {
+		if _, err := parseVersionConstraint(bad); err == nil {
+			t.Errorf("parseVersionConstraint(%q) should fail", bad)
+		}
+	}
+}
+
+func TestAllowlistCheckVersion(t *testing.T) {
+	allowlist := NewPluginAllowlist()
+	allowlist.entries["toc.so"] = AllowlistEntry{Name: "toc.so", Version: ">=2.0.0"}
+	allowlist.entries["any.so"] = AllowlistEntry{Name: "any.so"}
+
+	if err := allowlist.CheckVersion("toc.so", "2.1.0"); err != nil {
+		t.Errorf("2.1.0 should satisfy >=2.0.0: %v", err)
+	}
+	if err := allowlist.CheckVersion("toc.so", "1.9.0"); err == nil {
+		t.Error("1.9.0 should not satisfy >=2.0.0")
+	}
+	if err := allowlist.CheckVersion("toc.so", "dev"); err == nil {
+		t.Error("an unparsable version should not satisfy a constraint")
+	}
+	if err := allowlist.CheckVersion("any.so", "dev"); err != nil {
+		t.Errorf("an entry without a constraint should allow any version: %v", err)
+	}
+	if err := allowlist.CheckVersion("unlisted.so", "dev"); err != nil {
+		t.Errorf("an unlisted plugin has no constraint: %v", err)
+	}
+}
+
+func TestVerifyPlugin_V2Entries(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "test.so")
+	if err := os.WriteFile(path, []byte("fake plugin content"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	digest, err := CalculateFileDigest(path, "sha512")
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := DefaultSecurityConfig()
+
+	verify := func(entry AllowlistEntry) (*PluginLoadEvent, error) {
+		allowlist := NewPluginAllowlist()
+		entry.Name = "test.so"
+		allowlist.entries["test.so"] = entry
+		return VerifyPlugin(path, config, allowlist)
+	}
+
+	event, err := verify(AllowlistEntry{Algorithm: "sha512", Checksum: digest, Enabled: true, TrustedDirectories: []string{dir}})
+	if err != nil {
+		t.Fatalf("a matching SHA512 entry should verify: %v", err)
+	}
+	if !event.Success || event.SecurityWarning != "" {
+		t.Errorf("the entry's trusted directory should count as trusted, got %+v", event)
+	}
+	if sha256, _ := CalculateFileChecksum(path); event.Checksum != sha256 {
+		t.Errorf("the event should log the SHA256 checksum, got %s", event.Checksum)
+	}
+
+	event, err = verify(AllowlistEntry{Algorithm: "sha512", Checksum: strings.Repeat("0", 128), Enabled: true})
+	if err == nil || !strings.Contains(event.Error, "sha512 checksum mismatch") {
+		t.Errorf("a wrong SHA512 checksum should fail, got %v (%s)", err, event.Error)
+	}
+
+	event, err = verify(AllowlistEntry{Algorithm: "sha512", Checksum: digest})
+	if err == nil || event.Error != "plugin disabled in allowlist" {
+		t.Errorf("a disabled entry should fail, got %v (%s)", err, event.Error)
+	}
+
+	event, err = verify(AllowlistEntry{Algorithm: "sha512", Checksum: digest, Enabled: true, TrustedDirectories: []string{filepath.Join(dir, "elsewhere")}})
+	var securityErr *PluginSecurityError
+	if !errors.As(err, &securityErr) || !strings.Contains(event.Error, "trusted directories") {
+		t.Errorf("a plugin outside the entry's trusted directories should fail, got %v (%s)", err, event.Error)
+	}
+}
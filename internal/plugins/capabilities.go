@@ -0,0 +1,297 @@
+package plugins
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Capabilities lists the files, programs and network access a plugin uses.
+// Plugins declare what they need, the user grants it in SecurityConfig, and
+// the Host given to the plugin only performs operations that are both
+// declared and granted.
+type Capabilities struct {
+	// Read holds the files and directories the plugin reads
+	Read []string
+	// Write holds the files and directories the plugin creates or changes
+	Write []string
+	// Network allows requests through the HTTP client
+	Network bool
+	// Exec holds the programs the plugin runs, as passed to Host.Command
+	Exec []string
+}
+
+// CapabilityDeclarer is implemented by plugins that read or write files,
+// run programs or use the network. Plugins without it get none of these.
+type CapabilityDeclarer interface {
+	Plugin
+	Capabilities() Capabilities
+}
+
+// String lists the capabilities, e.g. "read ./assets, exec mmdc, network".
+func (c Capabilities) String() string {
+	var parts []string
+	for _, path := range c.Read {
+		parts = append(parts, "read "+path)
+	}
+	for _, path := range c.Write {
+		parts = append(parts, "write "+path)
+	}
+	for _, program := range c.Exec {
+		parts = append(parts, "exec "+program)
+	}
+	if c.Network {
+		parts = append(parts, "network")
+	}
+	if len(parts) == 0 {
+		return "none"
+	}
+	return strings.Join(parts, ", ")
+}
+
+// missing returns the capabilities in c that granted does not cover.
+func (c Capabilities) missing(granted Capabilities) Capabilities {
+	var missing Capabilities
+	for _, path := range c.Read {
+		if !IsPathInTrustedDirectory(path, granted.Read) {
+			missing.Read = append(missing.Read, path)
+		}
+	}
+	for _, path := range c.Write {
+		if !IsPathInTrustedDirectory(path, granted.Write) {
+			missing.Write = append(missing.Write, path)
+		}
+	}
+	for _, program := range c.Exec {
+		if !containsString(granted.Exec, program) {
+			missing.Exec = append(missing.Exec, program)
+		}
+	}
+	missing.Network = c.Network && !granted.Network
+	return missing
+}
+
+// isEmpty reports whether c holds no capability.
+func (c Capabilities) isEmpty() bool {
+	return len(c.Read) == 0 && len(c.Write) == 0 && len(c.Exec) == 0 && !c.Network
+}
+
+// declaredCapabilities returns the capabilities a plugin declares.
+func declaredCapabilities(p Plugin) Capabilities {
+	if declarer, ok := p.(CapabilityDeclarer); ok {
+		return declarer.Capabilities()
+	}
+	return Capabilities{}
+}
+
+// Host performs file, program and network operations for a plugin, within
+// the capabilities it declared and was granted. Operations outside them
+// fail with a PluginSecurityError.
+//
+// Plugins are native code, so Host cannot stop a plugin that uses os, os/exec
+// or net/http directly; it makes the capabilities of well-behaved plugins
+// explicit and reviewable.
+type Host struct {
+	plugin string
+	// declared and granted are nil for built-in plugins, which may do
+	// anything
+	declared *Capabilities
+	granted  *Capabilities
+	http     HTTPClient
+}
+
+// newHost creates the host of a plugin. Nil capabilities allow everything.
+func newHost(plugin string, declared, granted *Capabilities, client HTTPClient) *Host {
+	return &Host{plugin: plugin, declared: declared, granted: granted, http: client}
+}
+
+// ReadFile reads a file the plugin may read.
+func (h *Host) ReadFile(path string) ([]byte, error) {
+	if err := h.checkPath("read", path, func(c *Capabilities) []string { return c.Read }); err != nil {
+		return nil, err
+	}
+	return os.ReadFile(path) // #nosec G304 - path checked against the plugin's capabilities
+}
+
+// WriteFile writes a file the plugin may write.
+func (h *Host) WriteFile(path string, data []byte, perm os.FileMode) error {
+	if err := h.checkPath("write", path, func(c *Capabilities) []string { return c.Write }); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, perm)
+}
+
+// MkdirAll creates a directory the plugin may write, with its parents.
+func (h *Host) MkdirAll(path string, perm os.FileMode) error {
+	if err := h.checkPath("write", path, func(c *Capabilities) []string { return c.Write }); err != nil {
+		return err
+	}
+	return os.MkdirAll(path, perm)
+}
+
+// CreateTemp creates a temporary file like os.CreateTemp in a directory the
+// plugin may write.
+func (h *Host) CreateTemp(dir, pattern string) (*os.File, error) {
+	if err := h.checkPath("write", dir, func(c *Capabilities) []string { return c.Write }); err != nil {
+		return nil, err
+	}
+	return os.CreateTemp(dir, pattern)
+}
+
+// Rename renames a file within the paths the plugin may write.
+func (h *Host) Rename(oldPath, newPath string) error {
+	for _, path := range []string{oldPath, newPath} {
+		if err := h.checkPath("write", path, func(c *Capabilities) []string { return c.Write }); err != nil {
+			return err
+		}
+	}
+	return os.Rename(oldPath, newPath)
+}
+
+// Remove removes a file the plugin may write.
+func (h *Host) Remove(path string) error {
+	if err := h.checkPath("write", path, func(c *Capabilities) []string { return c.Write }); err != nil {
+		return err
+	}
+	return os.Remove(path)
+}
+
+// Command prepares a program the plugin may run, like exec.Command.
+// Programs named by path match the declared and granted ones they resolve
+// to, through symlinks.
+func (h *Host) Command(name string, args ...string) (*exec.Cmd, error) {
+	if h.granted != nil && !(containsProgram(h.declared.Exec, name) && containsProgram(h.granted.Exec, name)) {
+		return nil, h.denied("exec", name)
+	}
+	return exec.Command(name, args...), nil // #nosec G204 - program checked against the plugin's capabilities
+}
+
+// HTTP returns the HTTP client of the plugin, which fails every request
+// when the plugin may not use the network.
+func (h *Host) HTTP() HTTPClient {
+	if h.granted != nil && !(h.declared.Network && h.granted.Network) {
+		return deniedHTTPClient{host: h}
+	}
+	return h.http
+}
+
+// checkPath fails unless path is within the paths of the kind selected by
+// paths in both the declared and the granted capabilities. Symlinks are
+// resolved first, so a link within those paths cannot reach a file outside
+// them.
+func (h *Host) checkPath(operation, path string, paths func(*Capabilities) []string) error {
+	if h.granted == nil {
+		return nil
+	}
+	resolved, err := resolvePath(path)
+	if err != nil || !withinPaths(resolved, paths(h.declared)) || !withinPaths(resolved, paths(h.granted)) {
+		return h.denied(operation, path)
+	}
+	return nil
+}
+
+// resolvePath returns the absolute path with symlinks resolved. A path that
+// does not exist yet, such as a file about to be written, is resolved
+// through its parent directory; a symlink whose target does not exist is an
+// error, as writing it would create the target.
+func resolvePath(path string) (string, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	resolved, err := filepath.EvalSymlinks(absPath)
+	if err == nil {
+		return resolved, nil
+	}
+	if _, statErr := os.Lstat(absPath); !os.IsNotExist(statErr) {
+		return "", err
+	}
+	parent := filepath.Dir(absPath)
+	if parent == absPath {
+		return "", err
+	}
+	resolvedParent, err := resolvePath(parent)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(resolvedParent, filepath.Base(absPath)), nil
+}
+
+// withinPaths reports whether the resolved path is within one of paths,
+// whose symlinks are resolved in turn.
+func withinPaths(path string, paths []string) bool {
+	resolved := make([]string, 0, len(paths))
+	for _, p := range paths {
+		if r, err := resolvePath(p); err == nil {
+			resolved = append(resolved, r)
+		}
+	}
+	return IsPathInTrustedDirectory(path, resolved)
+}
+
+// containsProgram reports whether programs contains name. Programs named by
+// path are compared by the file they resolve to.
+func containsProgram(programs []string, name string) bool {
+	if containsString(programs, name) {
+		return true
+	}
+	if !strings.ContainsRune(filepath.ToSlash(name), '/') {
+		return false
+	}
+	resolved, err := resolvePath(name)
+	if err != nil {
+		return false
+	}
+	for _, program := range programs {
+		if !strings.ContainsRune(filepath.ToSlash(program), '/') {
+			continue
+		}
+		if r, err := resolvePath(program); err == nil && r == resolved {
+			return true
+		}
+	}
+	return false
+}
+
+// denied returns the error for an operation outside the capabilities.
+func (h *Host) denied(operation, target string) error {
+	reason := fmt.Sprintf("plugin may not %s %s", operation, target)
+	if target == "" {
+		reason = fmt.Sprintf("plugin may not use the %s", operation)
+	}
+	return &PluginSecurityError{Plugin: h.plugin, Operation: operation, Reason: reason}
+}
+
+// deniedHTTPClient is the HTTP client of plugins without network access.
+type deniedHTTPClient struct {
+	host *Host
+}
+
+func (c deniedHTTPClient) Get(url string) ([]byte, error) {
+	return nil, c.host.denied("network", "")
+}
+
+func (c deniedHTTPClient) GetAll(urls []string) ([][]byte, []error) {
+	errs := make([]error, len(urls))
+	for i := range urls {
+		errs[i] = c.host.denied("network", "")
+	}
+	return make([][]byte, len(urls)), errs
+}
+
+func (c deniedHTTPClient) Do(req *http.Request) (*http.Response, error) {
+	return nil, c.host.denied("network", "")
+}
+
+// containsString reports whether values contains s.
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
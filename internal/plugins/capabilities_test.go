@@ -0,0 +1,151 @@
+package plugins
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestHost(t *testing.T) {
+	dir := t.TempDir()
+	assets := filepath.Join(dir, "assets")
+	output := filepath.Join(dir, "output")
+	if err := os.MkdirAll(assets, 0750); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(assets, "logo.txt"), []byte("logo"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "secret.txt"), []byte("secret"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	declared := Capabilities{Read: []string{assets}, Write: []string{output}, Exec: []string{"mmdc", "dot"}}
+	granted := Capabilities{Read: []string{dir}, Write: []string{output}, Exec: []string{"mmdc"}, Network: true}
+	host := newHost("diagrams", &declared, &granted, &stubHTTPClient{})
+
+	var securityErr *PluginSecurityError
+	if data, err := host.ReadFile(filepath.Join(assets, "logo.txt")); err != nil || string(data) != "logo" {
+		t.Errorf("ReadFile of a declared and granted file = %q, %v", data, err)
+	}
+	// Granted, but not declared
+	if _, err := host.ReadFile(filepath.Join(dir, "secret.txt")); !errors.As(err, &securityErr) {
+		t.Errorf("ReadFile outside the declared paths: error = %v, want a PluginSecurityError", err)
+	}
+	if err := host.MkdirAll(output, 0750); err != nil {
+		t.Errorf("MkdirAll of a writable directory failed: %v", err)
+	}
+	if err := host.WriteFile(filepath.Join(output, "diagram.png"), []byte("png"), 0600); err != nil {
+		t.Errorf("WriteFile to a writable directory failed: %v", err)
+	}
+	if err := host.WriteFile(filepath.Join(assets, "logo.txt"), []byte("changed"), 0600); !errors.As(err, &securityErr) {
+		t.Errorf("WriteFile outside the writable paths: error = %v, want a PluginSecurityError", err)
+	}
+	if _, err := host.Command("mmdc", "--version"); err != nil {
+		t.Errorf("Command of a declared and granted program failed: %v", err)
+	}
+	// Declared, but not granted
+	if _, err := host.Command("dot"); !errors.As(err, &securityErr) || securityErr.Operation != "exec" {
+		t.Errorf("Command of a program that is not granted: error = %v, want a PluginSecurityError", err)
+	}
+	// Granted, but not declared
+	if _, err := host.HTTP().Get("https://example.com"); !errors.As(err, &securityErr) {
+		t.Errorf("HTTP of a plugin that did not declare network: error = %v, want a PluginSecurityError", err)
+	}
+
+	// Built-in plugins may do anything
+	builtin := newHost("summary", nil, nil, &stubHTTPClient{})
+	if _, err := builtin.ReadFile(filepath.Join(dir, "secret.txt")); err != nil {
+		t.Errorf("built-in ReadFile failed: %v", err)
+	}
+	if _, err := builtin.Command("dot"); err != nil {
+		t.Errorf("built-in Command failed: %v", err)
+	}
+}
+
+func TestHost_Symlinks(t *testing.T) {
+	dir := t.TempDir()
+	assets := filepath.Join(dir, "assets")
+	output := filepath.Join(dir, "output")
+	outside := filepath.Join(dir, "outside")
+	for _, d := range []string{assets, output, outside} {
+		if err := os.MkdirAll(d, 0750); err != nil {
+			t.Fatal(err)
+		}
+	}
+	secret := filepath.Join(outside, "secret.txt")
+	if err := os.WriteFile(secret, []byte("secret"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	links := map[string]string{
+		filepath.Join(assets, "secret.txt"):  secret,
+		filepath.Join(output, "escape"):      outside,
+		filepath.Join(output, "dangling"):    filepath.Join(outside, "created.txt"),
+		filepath.Join(assets, "link-to-out"): output,
+	}
+	for link, target := range links {
+		if err := os.Symlink(target, link); err != nil {
+			t.Skipf("symlinks unavailable: %v", err)
+		}
+	}
+
+	capabilities := Capabilities{Read: []string{assets}, Write: []string{output}}
+	host := newHost("diagrams", &capabilities, &capabilities, &stubHTTPClient{})
+
+	var securityErr *PluginSecurityError
+	if _, err := host.ReadFile(filepath.Join(assets, "secret.txt")); !errors.As(err, &securityErr) {
+		t.Errorf("ReadFile through a link out of the readable paths: error = %v, want a PluginSecurityError", err)
+	}
+	if err := host.WriteFile(filepath.Join(output, "escape", "new.txt"), []byte("x"), 0600); !errors.As(err, &securityErr) {
+		t.Errorf("WriteFile of a new file in a linked directory outside: error = %v, want a PluginSecurityError", err)
+	}
+	if err := host.WriteFile(filepath.Join(output, "dangling"), []byte("x"), 0600); !errors.As(err, &securityErr) {
+		t.Errorf("WriteFile through a dangling link: error = %v, want a PluginSecurityError", err)
+	}
+	if _, err := os.Stat(filepath.Join(outside, "created.txt")); !os.IsNotExist(err) {
+		t.Error("a file was created outside the writable paths")
+	}
+
+	// Links that stay within the allowed paths still work
+	if err := host.WriteFile(filepath.Join(output, "diagram.png"), []byte("png"), 0600); err != nil {
+		t.Errorf("WriteFile of a new file in a writable directory failed: %v", err)
+	}
+	writable := Capabilities{Read: []string{assets}, Write: []string{output, assets}}
+	host = newHost("diagrams", &writable, &writable, &stubHTTPClient{})
+	if err := host.WriteFile(filepath.Join(assets, "link-to-out", "linked.png"), []byte("png"), 0600); err != nil {
+		t.Errorf("WriteFile through a link between writable directories failed: %v", err)
+	}
+
+	// Programs named by path match the program they link to
+	program := Capabilities{Exec: []string{filepath.Join(output, "diagram.png")}}
+	host = newHost("diagrams", &program, &program, &stubHTTPClient{})
+	if _, err := host.Command(filepath.Join(assets, "link-to-out", "diagram.png")); err != nil {
+		t.Errorf("Command through a link to a granted program failed: %v", err)
+	}
+	if _, err := host.Command(filepath.Join(assets, "secret.txt")); !errors.As(err, &securityErr) {
+		t.Errorf("Command of a link to another program: error = %v, want a PluginSecurityError", err)
+	}
+}
+
+func TestManager_GrantedCapabilities(t *testing.T) {
+	declared := Capabilities{Read: []string{"./diagrams"}, Write: []string{"./mermaid-output"}, Exec: []string{"mmdc"}}
+
+	manager := NewManager(t.TempDir(), true, nil)
+	if got := manager.grantedCapabilities("mermaid", declared); got.String() != "read ./diagrams" {
+		t.Errorf("without grants, got %s, want only the declared read ./diagrams", got)
+	}
+
+	security := DefaultSecurityConfig()
+	security.Grants = map[string]Capabilities{"mermaid": {Write: []string{"./mermaid-output"}}}
+	if err := manager.SetSecurityConfig(security); err != nil {
+		t.Fatal(err)
+	}
+	granted := manager.grantedCapabilities("mermaid", declared)
+	if missing := declared.missing(granted); missing.String() != "read ./diagrams, exec mmdc" {
+		t.Errorf("missing capabilities = %s, want read ./diagrams, exec mmdc", missing)
+	}
+	if got := manager.grantedCapabilities("other", declared); got.String() != "none" {
+		t.Errorf("plugin without a grant got %s, want none", got)
+	}
+}
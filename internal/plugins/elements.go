@@ -2,7 +2,8 @@ package plugins
 
 import (
 	"bytes"
-	"fmt"
+	"crypto/sha256"
+	"encoding/hex"
 
 	"github.com/jung-kurt/gofpdf"
 )
@@ -60,9 +61,12 @@ func (i *ImageElement) Render(pdf *gofpdf.Fpdf, ctx *RenderContext) error {
 
 	// Register and place the image
 	if len(i.Data) > 0 {
-		// Register image with PDF
+		// Register image with PDF, named by its contents so an image drawn
+		// several times is embedded once
+		sum := sha256.Sum256(i.Data)
+		name := "plugin_" + hex.EncodeToString(sum[:16])
 		imageInfo := pdf.RegisterImageOptionsReader(
-			fmt.Sprintf("img_%p", i), // Unique name for this image
+			name,
 			gofpdf.ImageOptions{ImageType: i.Format},
 			bytes.NewReader(i.Data),
 		)
@@ -85,7 +89,7 @@ func (i *ImageElement) Render(pdf *gofpdf.Fpdf, ctx *RenderContext) error {
 
 			// Place the image
 			pdf.ImageOptions(
-				fmt.Sprintf("img_%p", i),
+				name,
 				-1, -1, // Use current position
 				width, height,
 				false,
@@ -1,6 +1,8 @@
 package plugins
 
 import (
+	"net/http"
+
 	"github.com/jung-kurt/gofpdf"
 	"github.com/yuin/goldmark/ast"
 )
@@ -22,6 +24,18 @@ type ASTTransformer interface {
 	SupportedNodes() []ast.NodeKind
 }
 
+// DocumentTransformer is implemented by plugins that change the document as
+// a whole, such as reordering sections, inserting a generated node or
+// deduplicating headings. TransformDocument is called once per document
+// with its root, after the ASTTransformers have run and before rendering,
+// and returns the root to render: usually doc itself, modified in place.
+// Document transformers run in order of Priority.
+type DocumentTransformer interface {
+	Plugin
+	TransformDocument(doc ast.Node, ctx *TransformContext) (ast.Node, error)
+	Priority() int
+}
+
 // PDF content generation capability
 type ContentGenerator interface {
 	Plugin
@@ -29,6 +43,32 @@ type ContentGenerator interface {
 	GenerationPhase() GenerationPhase
 }
 
+// Resetter is implemented by plugins that keep their instance between
+// conversions, e.g. to reuse an expensive resource. Reset is called before
+// every conversion after the first, following the Cleanup of the previous
+// one, and must clear everything collected from earlier documents. Plugins
+// without it get a new instance from NewPlugin for every conversion.
+type Resetter interface {
+	Plugin
+	Reset() error
+}
+
+// HTTPClient fetches remote resources for plugins under the network
+// settings of md-to-pdf: its request timeout, proxy, offline mode, rate
+// limit, retries and response cache. Plugins should use it instead of
+// net/http directly.
+type HTTPClient interface {
+	// Get returns the body of a successful GET request, fetching each URL
+	// once. In offline mode only cached responses are returned.
+	Get(url string) ([]byte, error)
+	// GetAll fetches several URLs like Get, some at once. The bodies and
+	// errors are in the order of urls.
+	GetAll(urls []string) ([][]byte, []error)
+	// Do sends a request that is not cached, such as a POST to a rendering
+	// server. It fails in offline mode.
+	Do(req *http.Request) (*http.Response, error)
+}
+
 // Plugin metadata
 type PluginInfo struct {
 	Name        string `json:"name"`
@@ -55,7 +95,18 @@ type TransformContext struct {
 	Parent      ast.Node
 	Source      []byte
 	Metadata    map[string]interface{}
-	Config      map[string]interface{}
+	// Config holds document settings plugins may adapt to:
+	// "mermaid_theme" is the diagram variant the renderer embeds ("light"
+	// or "dark") and "background_color" the page color ("" for white)
+	Config map[string]interface{}
+	// Logger logs messages tagged with the name of the running plugin
+	Logger Logger
+	// HTTP fetches remote resources under the network settings, when the
+	// plugin may use the network
+	HTTP HTTPClient
+	// Host reads and writes files and runs programs within the capabilities
+	// of the plugin
+	Host *Host
 }
 
 // RenderMargins represents page margins for rendering
@@ -77,6 +128,14 @@ type RenderContext struct {
 	Margins     RenderMargins
 	Metadata    map[string]interface{}
 	Config      map[string]interface{}
+	// Logger logs messages tagged with the name of the running plugin
+	Logger Logger
+	// HTTP fetches remote resources under the network settings, when the
+	// plugin may use the network
+	HTTP HTTPClient
+	// Host reads and writes files and runs programs within the capabilities
+	// of the plugin
+	Host *Host
 }
 
 // Document metadata
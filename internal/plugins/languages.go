@@ -0,0 +1,134 @@
+package plugins
+
+import (
+	"sort"
+	"strings"
+)
+
+// HandlerKind says how a fenced code block language is handled.
+type HandlerKind string
+
+const (
+	// KindPlain blocks are rendered as code, as they are
+	KindPlain HandlerKind = "plain"
+	// KindHighlighter blocks are rendered as code with syntax highlighting
+	KindHighlighter HandlerKind = "highlighter"
+	// KindDiagram blocks are replaced by a generated image
+	KindDiagram HandlerKind = "diagram"
+	// KindTransformer blocks are replaced by other content
+	KindTransformer HandlerKind = "transformer"
+)
+
+// BuiltinHandler is the handler name of languages md-to-pdf handles itself.
+const BuiltinHandler = "builtin"
+
+// FencedLanguage is a fenced code block language a plugin handles.
+type FencedLanguage struct {
+	Name string
+	Kind HandlerKind
+}
+
+// FencedBlockHandler is implemented by plugins that handle fenced code
+// blocks of certain languages, such as a transformer turning ```mermaid
+// blocks into diagrams. Blocks in languages no plugin or built-in handler
+// declares are rendered as plain code with a warning.
+type FencedBlockHandler interface {
+	Plugin
+	FencedLanguages() []FencedLanguage
+}
+
+// RegisteredLanguage is a fenced code block language and what handles it.
+type RegisteredLanguage struct {
+	Language string      `json:"language"`
+	Kind     HandlerKind `json:"kind"`
+	Handler  string      `json:"handler"` // Plugin name, or "builtin"
+}
+
+// builtinLanguages are the languages the renderer handles without plugins,
+// as plain code: the names used for text that is not code in any language,
+// and those of common programming, shell and data languages, which plugins
+// may still claim to highlight.
+var builtinLanguages = []string{
+	"text", "txt", "plain", "plaintext", "output", "console",
+	"bash", "sh", "shell", "zsh", "powershell", "ps1", "bat",
+	"c", "cpp", "c++", "csharp", "cs", "go", "golang", "java", "kotlin",
+	"scala", "swift", "objc", "rust", "zig", "python", "py", "ruby", "rb",
+	"perl", "php", "lua", "r", "julia", "elixir", "erlang", "haskell",
+	"ocaml", "clojure", "lisp", "dart", "javascript", "js", "jsx",
+	"typescript", "ts", "tsx", "html", "xml", "css", "scss", "sass", "less",
+	"json", "jsonc", "yaml", "yml", "toml", "ini", "csv", "sql", "graphql",
+	"protobuf", "proto", "dockerfile", "makefile", "make", "cmake", "nginx",
+	"hcl", "terraform", "diff", "patch", "markdown", "md", "tex", "latex",
+	"asm", "nasm", "vim", "regex", "http",
+}
+
+// LanguageRegistry maps fenced code block languages to their handlers.
+// Languages are matched case-insensitively.
+type LanguageRegistry struct {
+	languages map[string]RegisteredLanguage
+}
+
+// NewLanguageRegistry creates a registry holding the built-in languages.
+func NewLanguageRegistry() *LanguageRegistry {
+	r := &LanguageRegistry{languages: make(map[string]RegisteredLanguage)}
+	for _, name := range builtinLanguages {
+		r.languages[name] = RegisteredLanguage{Language: name, Kind: KindPlain, Handler: BuiltinHandler}
+	}
+	return r
+}
+
+// Register makes handler the handler of language. A plugin replaces a
+// built-in handler, but a language claimed by several plugins stays with
+// the first.
+func (r *LanguageRegistry) Register(language string, kind HandlerKind, handler string) {
+	language = strings.ToLower(strings.TrimSpace(language))
+	if language == "" {
+		return
+	}
+	if existing, ok := r.languages[language]; ok && existing.Handler != BuiltinHandler {
+		return
+	}
+	r.languages[language] = RegisteredLanguage{Language: language, Kind: kind, Handler: handler}
+}
+
+// Lookup returns the handler of language.
+func (r *LanguageRegistry) Lookup(language string) (RegisteredLanguage, bool) {
+	registered, ok := r.languages[strings.ToLower(strings.TrimSpace(language))]
+	return registered, ok
+}
+
+// List returns the registered languages sorted by name.
+func (r *LanguageRegistry) List() []RegisteredLanguage {
+	list := make([]RegisteredLanguage, 0, len(r.languages))
+	for _, registered := range r.languages {
+		list = append(list, registered)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Language < list[j].Language })
+	return list
+}
+
+// Languages returns the registry of the built-in languages and those the
+// registered plugins handle, in order of plugin name. It is safe to call on
+// a nil Manager, returning the built-in languages only.
+func (m *Manager) Languages() *LanguageRegistry {
+	registry := NewLanguageRegistry()
+	if m == nil {
+		return registry
+	}
+
+	names := make([]string, 0, len(m.plugins))
+	for name := range m.plugins {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		handler, ok := m.plugins[name].(FencedBlockHandler)
+		if !ok {
+			continue
+		}
+		for _, language := range handler.FencedLanguages() {
+			registry.Register(language.Name, language.Kind, name)
+		}
+	}
+	return registry
+}
@@ -0,0 +1,69 @@
+package plugins
+
+import "testing"
+
+// diagramPlugin handles fenced blocks of the given languages as diagrams.
+type diagramPlugin struct {
+	testPlugin
+	languages []string
+}
+
+func (p *diagramPlugin) FencedLanguages() []FencedLanguage {
+	list := make([]FencedLanguage, 0, len(p.languages))
+	for _, name := range p.languages {
+		list = append(list, FencedLanguage{Name: name, Kind: KindDiagram})
+	}
+	return list
+}
+
+func TestLanguageRegistry(t *testing.T) {
+	registry := NewLanguageRegistry()
+	if registered, ok := registry.Lookup("Text"); !ok || registered.Handler != BuiltinHandler || registered.Kind != KindPlain {
+		t.Errorf("Lookup(Text) = %+v, %v, want the built-in plain handler", registered, ok)
+	}
+	if registered, ok := registry.Lookup("Go"); !ok || registered.Handler != BuiltinHandler || registered.Kind != KindPlain {
+		t.Errorf("Lookup(Go) = %+v, %v, want the built-in plain handler", registered, ok)
+	}
+	if _, ok := registry.Lookup("mermaid"); ok {
+		t.Error("mermaid should not be registered without a plugin")
+	}
+
+	registry.Register("Mermaid", KindDiagram, "mermaid")
+	registry.Register("mermaid", KindTransformer, "other")
+	registry.Register("text", KindHighlighter, "highlight")
+	if registered, _ := registry.Lookup("mermaid"); registered.Handler != "mermaid" {
+		t.Errorf("the first plugin should keep mermaid, got %+v", registered)
+	}
+	if registered, _ := registry.Lookup("text"); registered.Handler != "highlight" {
+		t.Errorf("a plugin should replace a built-in handler, got %+v", registered)
+	}
+
+	list := registry.List()
+	for i := 1; i < len(list); i++ {
+		if list[i-1].Language >= list[i].Language {
+			t.Fatalf("List() is not sorted: %+v", list)
+		}
+	}
+}
+
+func TestManager_Languages(t *testing.T) {
+	if _, ok := (*Manager)(nil).Languages().Lookup("text"); !ok {
+		t.Error("a nil manager should list the built-in languages")
+	}
+
+	manager := NewManager(t.TempDir(), true, nil)
+	if err := manager.Register(&diagramPlugin{testPlugin: testPlugin{name: "graphs"}, languages: []string{"dot", "plantuml"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := manager.Register(&testPlugin{name: "plain"}); err != nil {
+		t.Fatal(err)
+	}
+
+	registry := manager.Languages()
+	for _, language := range []string{"dot", "plantuml"} {
+		registered, ok := registry.Lookup(language)
+		if !ok || registered.Handler != "graphs" || registered.Kind != KindDiagram {
+			t.Errorf("Lookup(%s) = %+v, %v", language, registered, ok)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package plugins
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+)
+
+// pluginLoader opens the plugin files of one format in the plugin
+// directory, recognized by their suffix.
+type pluginLoader struct {
+	suffix string
+	// unsupported says why this build cannot load the format (empty when
+	// it can)
+	unsupported string
+	open        func(path string) (func() Plugin, error)
+}
+
+// pluginLoaders lists the plugin file formats in order of preference. Where
+// .so plugins cannot be loaded, files in the formats that can still are,
+// and .so files are reported together in one warning.
+var pluginLoaders = []pluginLoader{
+	{suffix: ".so", unsupported: nativePluginsUnsupported(), open: openPlugin},
+}
+
+// loaderFor returns the loader of a plugin file name.
+func loaderFor(name string) (pluginLoader, bool) {
+	for _, loader := range pluginLoaders {
+		if strings.HasSuffix(name, loader.suffix) {
+			return loader, true
+		}
+	}
+	return pluginLoader{}, false
+}
+
+// canLoadPluginFiles reports whether any plugin file format can be loaded
+// by this build.
+func canLoadPluginFiles() bool {
+	for _, loader := range pluginLoaders {
+		if loader.unsupported == "" {
+			return true
+		}
+	}
+	return false
+}
+
+// PluginSupport describes the plugin file formats this build can load.
+type PluginSupport struct {
+	Platform string `json:"platform"`
+	// Formats lists the suffixes of the plugin files that are loaded
+	Formats []string `json:"formats"`
+	// Unsupported says why files of other formats are not, by suffix
+	Unsupported map[string]string `json:"unsupported,omitempty"`
+}
+
+// Support returns the plugin file formats this build can load.
+func Support() PluginSupport {
+	support := PluginSupport{Platform: runtime.GOOS + "/" + runtime.GOARCH, Formats: []string{}}
+	for _, loader := range pluginLoaders {
+		if loader.unsupported == "" {
+			support.Formats = append(support.Formats, loader.suffix)
+			continue
+		}
+		if support.Unsupported == nil {
+			support.Unsupported = make(map[string]string)
+		}
+		support.Unsupported[loader.suffix] = loader.unsupported
+	}
+	return support
+}
+
+// warnUnloadable prints one warning for the plugin files that are skipped
+// because this build cannot load their format, instead of a load error for
+// each.
+func warnUnloadable(dir string, skipped map[string][]string) {
+	for _, message := range unloadableWarnings(dir, skipped) {
+		fmt.Fprintf(os.Stderr, "Warning: %s\n", message)
+	}
+}
+
+// unloadableWarnings returns the warnings of warnUnloadable, one per format
+// with skipped files.
+func unloadableWarnings(dir string, skipped map[string][]string) []string {
+	var warnings []string
+	for _, loader := range pluginLoaders {
+		files := skipped[loader.suffix]
+		if len(files) == 0 {
+			continue
+		}
+		warnings = append(warnings, fmt.Sprintf("%d %s plugin(s) in %s not loaded (%s): %s; built-in plugins still run",
+			len(files), loader.suffix, dir, loader.unsupported, strings.Join(files, ", ")))
+	}
+	return warnings
+}
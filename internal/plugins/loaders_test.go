@@ -0,0 +1,98 @@
+package plugins
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// withLoaders replaces the plugin loaders for the duration of a test.
+func withLoaders(t *testing.T, loaders []pluginLoader) {
+	t.Helper()
+	saved := pluginLoaders
+	pluginLoaders = loaders
+	t.Cleanup(func() { pluginLoaders = saved })
+}
+
+func TestLoadPlugins_UnsupportedFormat(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"native.so", "script.fake"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(name), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	withLoaders(t, []pluginLoader{
+		{suffix: ".so", unsupported: ".so plugins are not supported on plan9", open: func(string) (func() Plugin, error) {
+			t.Fatal("an unsupported format should not be opened")
+			return nil, nil
+		}},
+		{suffix: ".fake", open: func(string) (func() Plugin, error) {
+			return func() Plugin { return &testPlugin{name: "fallback", version: "1.0.0"} }, nil
+		}},
+	})
+
+	manager := NewManager(dir, true, nil)
+	if err := manager.LoadPlugins(); err != nil {
+		t.Fatalf("LoadPlugins failed: %v", err)
+	}
+	if _, ok := manager.plugins["fallback"]; !ok || len(manager.plugins) != 1 {
+		t.Errorf("the supported format should be loaded alone, got %v", manager.plugins)
+	}
+	events := manager.GetSecurityEvents()
+	if len(events) != 1 || filepath.Base(events[0].PluginPath) != "script.fake" {
+		t.Errorf("only the loaded file should have a security event, got %+v", events)
+	}
+
+	checksums, err := manager.Checksums()
+	if err != nil || len(checksums) != 2 {
+		t.Errorf("Checksums() should cover the files of every format, got %v, %v", checksums, err)
+	}
+}
+
+func TestLoadPlugins_NoSupportedFormat(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "native.so"), []byte("plugin"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	withLoaders(t, []pluginLoader{{suffix: ".so", unsupported: "not here"}})
+
+	manager := NewManager(dir, true, nil)
+	builtin := &testGenerator{name: "builtin", phase: AfterContent}
+	if err := manager.RegisterBuiltin(builtin); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		if err := manager.LoadPlugins(); err != nil {
+			t.Fatalf("LoadPlugins should not fail where plugin files cannot be loaded: %v", err)
+		}
+	}
+	if len(manager.GetSecurityEvents()) != 0 {
+		t.Errorf("no file should be opened, got %+v", manager.GetSecurityEvents())
+	}
+	if got := manager.GetGenerators(AfterContent); len(got) != 1 || got[0] != builtin {
+		t.Errorf("built-in plugins should still run, got %v", got)
+	}
+
+	support := Support()
+	if len(support.Formats) != 0 || support.Unsupported[".so"] != "not here" || support.Platform == "" {
+		t.Errorf("Support() = %+v", support)
+	}
+}
+
+func TestUnloadableWarnings(t *testing.T) {
+	withLoaders(t, []pluginLoader{{suffix: ".so", unsupported: ".so plugins are not supported on windows"}})
+
+	warnings := unloadableWarnings("plugins", map[string][]string{".so": {"a.so", "b.so"}})
+	if len(warnings) != 1 {
+		t.Fatalf("want one warning for all files of a format, got %q", warnings)
+	}
+	for _, want := range []string{"2 .so plugin(s) in plugins", "not supported on windows", "a.so, b.so", "built-in plugins still run"} {
+		if !strings.Contains(warnings[0], want) {
+			t.Errorf("warning %q should contain %q", warnings[0], want)
+		}
+	}
+	if warnings := unloadableWarnings("plugins", nil); len(warnings) != 0 {
+		t.Errorf("no skipped files should give no warning, got %q", warnings)
+	}
+}
@@ -0,0 +1,86 @@
+package plugins
+
+import (
+	"fmt"
+	"os"
+)
+
+// LogLevel is the severity of a message logged by a plugin.
+type LogLevel int
+
+const (
+	LogDebug LogLevel = iota
+	LogInfo
+	LogWarn
+)
+
+// String returns the lower-case level name.
+func (l LogLevel) String() string {
+	switch l {
+	case LogDebug:
+		return "debug"
+	case LogInfo:
+		return "info"
+	default:
+		return "warn"
+	}
+}
+
+// Logger lets plugins report progress and problems through md-to-pdf
+// instead of printing to stdout, which would corrupt --json output. Messages
+// are tagged with the name of the plugin that logged them.
+type Logger interface {
+	Debugf(format string, args ...interface{})
+	Infof(format string, args ...interface{})
+	Warnf(format string, args ...interface{})
+}
+
+// LoggerSetter is implemented by plugins that log outside of Transform and
+// Generate, whose contexts carry a logger. SetLogger is called before Init.
+type LoggerSetter interface {
+	Plugin
+	SetLogger(logger Logger)
+}
+
+// LogHandler receives the messages logged by plugins.
+type LogHandler func(plugin string, level LogLevel, message string)
+
+// pluginLogger is the Logger of one plugin.
+type pluginLogger struct {
+	plugin  string
+	handler LogHandler
+}
+
+// NewLogger creates the logger of the named plugin, passing its messages to
+// handler. A nil handler prints warnings to stderr and drops the rest.
+func NewLogger(plugin string, handler LogHandler) Logger {
+	return &pluginLogger{plugin: plugin, handler: handler}
+}
+
+func (l *pluginLogger) Debugf(format string, args ...interface{}) {
+	l.log(LogDebug, format, args...)
+}
+
+func (l *pluginLogger) Infof(format string, args ...interface{}) {
+	l.log(LogInfo, format, args...)
+}
+
+func (l *pluginLogger) Warnf(format string, args ...interface{}) {
+	l.log(LogWarn, format, args...)
+}
+
+func (l *pluginLogger) log(level LogLevel, format string, args ...interface{}) {
+	message := fmt.Sprintf(format, args...)
+	if l.handler == nil {
+		defaultLogHandler(l.plugin, level, message)
+		return
+	}
+	l.handler(l.plugin, level, message)
+}
+
+// defaultLogHandler prints warnings to stderr and drops other messages.
+func defaultLogHandler(plugin string, level LogLevel, message string) {
+	if level == LogWarn {
+		fmt.Fprintf(os.Stderr, "Warning: plugin %s: %s\n", plugin, message)
+	}
+}
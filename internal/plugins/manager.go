@@ -4,10 +4,10 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
-	"plugin"
 	"sort"
 	"strings"
 
+	"github.com/fredcamaral/md-to-pdf/internal/network"
 	"github.com/yuin/goldmark/ast"
 )
 
@@ -22,6 +22,34 @@ type Manager struct {
 	securityConfig *SecurityConfig
 	allowlist      *PluginAllowlist
 	logger         *PluginSecurityLogger
+
+	// documentTransformers run on the whole document after transformers
+	documentTransformers []DocumentTransformer
+
+	// logHandler receives the messages plugins log (nil = warnings go to
+	// stderr)
+	logHandler LogHandler
+
+	// httpClient is passed to plugins in their contexts (default settings
+	// until SetHTTPClient)
+	httpClient HTTPClient
+
+	// documentConfig is copied into the Config of transform contexts
+	documentConfig map[string]interface{}
+
+	// builtins and loaded are registered again for every conversion after
+	// the first, so no state carries over between conversions
+	builtins []Plugin
+	loaded   []*loadedPlugin
+	opened   bool // The plugin directory has been loaded
+}
+
+// loadedPlugin is a plugin loaded from the plugin directory, with the
+// constructor that creates a fresh instance for each conversion.
+type loadedPlugin struct {
+	file      string
+	newPlugin func() Plugin
+	instance  Plugin
 }
 
 // NewManager creates a new plugin manager with the specified directory and enabled state.
@@ -39,6 +67,7 @@ func NewManager(pluginDir string, enabled bool, pluginConfigs map[string]map[str
 		pluginConfigs:  pluginConfigs,
 		securityConfig: DefaultSecurityConfig(),
 		logger:         NewPluginSecurityLogger(),
+		httpClient:     network.NewClient(network.Options{}),
 	}
 }
 
@@ -69,6 +98,7 @@ func NewManagerWithSecurity(pluginDir string, enabled bool, securityConfig *Secu
 		securityConfig: securityConfig,
 		allowlist:      allowlist,
 		logger:         NewPluginSecurityLogger(),
+		httpClient:     network.NewClient(network.Options{}),
 	}, nil
 }
 
@@ -102,11 +132,28 @@ func (m *Manager) GetSecurityEvents() []PluginLoadEvent {
 	return m.logger.GetEvents()
 }
 
-// LoadPlugins discovers and loads all plugins from the configured directory
+// LoadPlugins discovers and loads all plugins from the configured directory.
+// It is called before every conversion: after the first call the plugins
+// already loaded are renewed instead, so state kept by a plugin during one
+// conversion never leaks into the next, even when the engine is reused by
+// watch or daemon mode.
 func (m *Manager) LoadPlugins() error {
 	if !m.enabled {
 		return nil
 	}
+	if m.opened {
+		m.renewPlugins()
+		return nil
+	}
+	if !canLoadPluginFiles() {
+		// Only built-in and registered plugins run on this platform; the
+		// plugin directory is read to report the files that are skipped
+		m.opened = true
+		if files, err := os.ReadDir(m.pluginDir); err == nil {
+			m.loadFiles(files)
+		}
+		return nil
+	}
 
 	// Validate and canonicalize the plugin directory path
 	validatedPath, err := m.validatePluginDirectory()
@@ -126,26 +173,183 @@ func (m *Manager) LoadPlugins() error {
 	if err != nil {
 		return fmt.Errorf("failed to read plugin directory: %w", err)
 	}
+	m.loadFiles(files)
+
+	m.opened = true
+	m.sortTransformers()
 
+	return nil
+}
+
+// loadFiles loads the plugin files among the entries of the plugin
+// directory. Files in formats this build cannot load are reported in one
+// warning per format.
+func (m *Manager) loadFiles(files []os.DirEntry) {
+	skipped := make(map[string][]string)
 	for _, file := range files {
-		if !strings.HasSuffix(file.Name(), ".so") {
+		loader, ok := loaderFor(file.Name())
+		if !ok {
+			continue
+		}
+		if loader.unsupported != "" {
+			skipped[loader.suffix] = append(skipped[loader.suffix], file.Name())
 			continue
 		}
 
 		pluginPath := filepath.Join(m.pluginDir, file.Name())
-		loadErr := m.loadPlugin(pluginPath)
+		loadErr := m.loadPlugin(pluginPath, loader.open)
 		if loadErr != nil {
 			fmt.Fprintf(os.Stderr, "Warning: failed to load plugin %s: %v\n", file.Name(), loadErr)
 			continue
 		}
 	}
+	warnUnloadable(m.pluginDir, skipped)
+}
 
-	// Sort transformers by priority
+// renewPlugins registers the built-in and loaded plugins again with a clean
+// state: plugins implementing Resetter are reset, others are replaced by a
+// new instance from their NewPlugin function. A plugin that fails to renew
+// is dropped, like one that fails to load.
+func (m *Manager) renewPlugins() {
+	m.plugins = make(map[string]Plugin)
+	m.transformers = make([]ASTTransformer, 0)
+	m.documentTransformers = nil
+	m.generators = make(map[GenerationPhase][]ContentGenerator)
+
+	builtins := m.builtins[:0]
+	for _, p := range m.builtins {
+		if resetter, ok := p.(Resetter); ok {
+			if err := resetter.Reset(); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: failed to reset plugin %s: %v\n", p.Name(), err)
+				continue
+			}
+		}
+		builtins = append(builtins, p)
+		m.register(p)
+	}
+	m.builtins = builtins
+
+	loaded := m.loaded[:0]
+	for _, lp := range m.loaded {
+		if err := m.renew(lp); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to reset plugin %s: %v\n", lp.file, err)
+			continue
+		}
+		loaded = append(loaded, lp)
+		m.register(lp.instance)
+	}
+	m.loaded = loaded
+
+	m.sortTransformers()
+}
+
+// renew resets a loaded plugin, or replaces it by a new instance when it
+// cannot be reset.
+func (m *Manager) renew(lp *loadedPlugin) error {
+	if resetter, ok := lp.instance.(Resetter); ok {
+		return resetter.Reset()
+	}
+	instance := lp.newPlugin()
+	if err := m.initPlugin(instance); err != nil {
+		return fmt.Errorf("failed to initialize plugin: %w", err)
+	}
+	lp.instance = instance
+	return nil
+}
+
+// sortTransformers orders the transformers and document transformers by
+// priority.
+func (m *Manager) sortTransformers() {
 	sort.Slice(m.transformers, func(i, j int) bool {
 		return m.transformers[i].Priority() < m.transformers[j].Priority()
 	})
+	sort.SliceStable(m.documentTransformers, func(i, j int) bool {
+		return m.documentTransformers[i].Priority() < m.documentTransformers[j].Priority()
+	})
+}
 
-	return nil
+// initPlugin gives a plugin its logger and initializes it with its
+// configuration.
+func (m *Manager) initPlugin(p Plugin) error {
+	if setter, ok := p.(LoggerSetter); ok {
+		setter.SetLogger(m.loggerFor(p.Name()))
+	}
+	return p.Init(m.configFor(p.Name()))
+}
+
+// SetLogHandler sets the function that receives the messages plugins log.
+// It applies to plugins already registered as well.
+func (m *Manager) SetLogHandler(handler LogHandler) {
+	m.logHandler = handler
+}
+
+// SetDocumentConfig sets the document settings transformers find in
+// TransformContext.Config.
+func (m *Manager) SetDocumentConfig(config map[string]interface{}) {
+	m.documentConfig = config
+}
+
+// contextConfig returns a copy of the document settings for one context,
+// so a plugin changing it does not affect the next.
+func (m *Manager) contextConfig() map[string]interface{} {
+	config := make(map[string]interface{}, len(m.documentConfig))
+	for key, value := range m.documentConfig {
+		config[key] = value
+	}
+	return config
+}
+
+// SetHTTPClient sets the client plugins fetch remote resources with.
+func (m *Manager) SetHTTPClient(client HTTPClient) {
+	m.httpClient = client
+}
+
+// hostFor returns the host of a plugin. Built-in plugins are part of
+// md-to-pdf and may do anything.
+func (m *Manager) hostFor(p Plugin) *Host {
+	for _, builtin := range m.builtins {
+		if builtin == p {
+			return newHost(p.Name(), nil, nil, m.httpClient)
+		}
+	}
+	declared := declaredCapabilities(p)
+	granted := m.grantedCapabilities(p.Name(), declared)
+	return newHost(p.Name(), &declared, &granted, m.httpClient)
+}
+
+// grantedCapabilities returns the capabilities granted to the named plugin.
+// When no grants are configured, plugins may only read the paths they
+// declare.
+func (m *Manager) grantedCapabilities(name string, declared Capabilities) Capabilities {
+	if m.securityConfig == nil || m.securityConfig.Grants == nil {
+		return Capabilities{Read: declared.Read}
+	}
+	return m.securityConfig.Grants[name]
+}
+
+// loggerFor returns the logger of the named plugin.
+func (m *Manager) loggerFor(name string) Logger {
+	return NewLogger(name, m.handleLog)
+}
+
+// handleLog passes a plugin message to the log handler set at the time it
+// is logged.
+func (m *Manager) handleLog(plugin string, level LogLevel, message string) {
+	if m.logHandler == nil {
+		defaultLogHandler(plugin, level, message)
+		return
+	}
+	m.logHandler(plugin, level, message)
+}
+
+// configFor returns the configuration of the named plugin, empty when none
+// was given.
+func (m *Manager) configFor(name string) map[string]interface{} {
+	pluginConfig := m.pluginConfigs[name]
+	if pluginConfig == nil {
+		pluginConfig = make(map[string]interface{})
+	}
+	return pluginConfig
 }
 
 // validatePluginDirectory validates the plugin directory for security issues
@@ -171,8 +375,9 @@ func (m *Manager) validatePluginDirectory() (string, error) {
 	return validatedPath, nil
 }
 
-// loadPlugin loads a single plugin with security verification
-func (m *Manager) loadPlugin(path string) error {
+// loadPlugin loads a single plugin with security verification, opening it
+// with open
+func (m *Manager) loadPlugin(path string, open func(path string) (func() Plugin, error)) error {
 	// Perform security verification before loading
 	event, verifyErr := VerifyPlugin(path, m.securityConfig, m.allowlist)
 
@@ -191,32 +396,13 @@ func (m *Manager) loadPlugin(path string) error {
 	}
 
 	// Actually load the plugin
-	p, err := plugin.Open(path)
+	newPluginFunc, err := open(path)
 	if err != nil {
 		if event != nil {
 			event.Success = false
-			event.Error = fmt.Sprintf("failed to open plugin: %v", err)
-		}
-		return fmt.Errorf("failed to open plugin: %w", err)
-	}
-
-	// Look for NewPlugin function
-	newPluginSymbol, err := p.Lookup("NewPlugin")
-	if err != nil {
-		if event != nil {
-			event.Success = false
-			event.Error = fmt.Sprintf("plugin missing NewPlugin function: %v", err)
-		}
-		return fmt.Errorf("plugin missing NewPlugin function: %w", err)
-	}
-
-	newPluginFunc, ok := newPluginSymbol.(func() Plugin)
-	if !ok {
-		if event != nil {
-			event.Success = false
-			event.Error = "NewPlugin has invalid signature"
+			event.Error = err.Error()
 		}
-		return fmt.Errorf("NewPlugin has invalid signature")
+		return err
 	}
 
 	pluginInstance := newPluginFunc()
@@ -226,14 +412,32 @@ func (m *Manager) loadPlugin(path string) error {
 		event.PluginName = pluginInstance.Name()
 	}
 
-	// Get plugin-specific configuration, or use empty map if none provided
-	pluginConfig := m.pluginConfigs[pluginInstance.Name()]
-	if pluginConfig == nil {
-		pluginConfig = make(map[string]interface{})
+	// The version is only known once the plugin is opened, so its allowlist
+	// constraint is checked before it is initialized
+	if m.allowlist != nil {
+		if err := m.allowlist.CheckVersion(filepath.Base(path), pluginInstance.Version()); err != nil {
+			if event != nil {
+				event.Success = false
+				event.Error = err.Error()
+			}
+			return &PluginSecurityError{
+				Plugin:    path,
+				Operation: "verification",
+				Reason:    "plugin version does not satisfy allowlist",
+				Cause:     err,
+			}
+		}
 	}
 
-	// Initialize plugin with its configuration
-	err = pluginInstance.Init(pluginConfig)
+	// Declared capabilities that were not granted fail when used
+	declared := declaredCapabilities(pluginInstance)
+	if missing := declared.missing(m.grantedCapabilities(pluginInstance.Name(), declared)); !missing.isEmpty() {
+		fmt.Fprintf(os.Stderr, "[SECURITY WARNING] Plugin %s declares capabilities that are not granted, withheld: %s (grant them under plugin_grants in the config file)\n", pluginInstance.Name(), missing)
+	}
+
+	// Initialize plugin with its configuration, or an empty map if none
+	// was provided
+	err = m.initPlugin(pluginInstance)
 	if err != nil {
 		if event != nil {
 			event.Success = false
@@ -242,28 +446,61 @@ func (m *Manager) loadPlugin(path string) error {
 		return fmt.Errorf("failed to initialize plugin: %w", err)
 	}
 
-	// Register plugin
-	m.plugins[pluginInstance.Name()] = pluginInstance
+	m.loaded = append(m.loaded, &loadedPlugin{file: filepath.Base(path), newPlugin: newPluginFunc, instance: pluginInstance})
+	m.register(pluginInstance)
+
+	// Mark as successful
+	if event != nil {
+		event.Success = true
+	}
+
+	return nil
+}
 
-	// Check for additional capabilities
-	if transformer, ok := pluginInstance.(ASTTransformer); ok {
+// Register initializes a plugin created by the program rather than loaded
+// from the plugin directory, such as a plugin under test, and adds it like
+// a loaded plugin, limited to its capabilities. It stays registered until
+// the next LoadPlugins.
+func (m *Manager) Register(p Plugin) error {
+	if err := m.initPlugin(p); err != nil {
+		return fmt.Errorf("failed to initialize plugin %s: %w", p.Name(), err)
+	}
+	m.register(p)
+	m.sortTransformers()
+	return nil
+}
+
+// RegisterBuiltin registers a plugin compiled into md-to-pdf. It is
+// initialized with its plugin configuration like a loaded plugin and runs
+// even when loading plugins from the plugin directory is disabled.
+func (m *Manager) RegisterBuiltin(p Plugin) error {
+	if err := m.initPlugin(p); err != nil {
+		return fmt.Errorf("failed to initialize built-in plugin %s: %w", p.Name(), err)
+	}
+	m.builtins = append(m.builtins, p)
+	m.register(p)
+	return nil
+}
+
+// register adds a plugin and its transformer and generator capabilities.
+func (m *Manager) register(p Plugin) {
+	m.plugins[p.Name()] = p
+
+	if transformer, ok := p.(ASTTransformer); ok {
 		m.transformers = append(m.transformers, transformer)
 	}
 
-	if generator, ok := pluginInstance.(ContentGenerator); ok {
+	if transformer, ok := p.(DocumentTransformer); ok {
+		m.documentTransformers = append(m.documentTransformers, transformer)
+	}
+
+	if generator, ok := p.(ContentGenerator); ok {
 		phase := generator.GenerationPhase()
 		if m.generators[phase] == nil {
 			m.generators[phase] = make([]ContentGenerator, 0)
 		}
 		m.generators[phase] = append(m.generators[phase], generator)
 	}
-
-	// Mark as successful
-	if event != nil {
-		event.Success = true
-	}
-
-	return nil
 }
 
 // GetTransformers returns all registered AST transformers
@@ -296,6 +533,9 @@ func (m *Manager) ApplyTransformers(node ast.Node, ctx *TransformContext) (ast.N
 			}
 		}
 
+		ctx.Logger = m.loggerFor(transformer.Name())
+		ctx.Host = m.hostFor(transformer)
+		ctx.HTTP = ctx.Host.HTTP()
 		transformedNode, err := transformer.Transform(result, ctx)
 		if err != nil {
 			return result, fmt.Errorf("transformer %s failed: %w", transformer.Name(), err)
@@ -307,12 +547,73 @@ func (m *Manager) ApplyTransformers(node ast.Node, ctx *TransformContext) (ast.N
 	return result, nil
 }
 
+// TransformDocument applies the transformers to every node of a parsed
+// document, replacing each node by the one they return, then passes the
+// document to the document transformers.
+func (m *Manager) TransformDocument(node ast.Node, source []byte) (ast.Node, error) {
+	err := ast.Walk(node, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+
+		ctx := &TransformContext{
+			CurrentNode: n,
+			Parent:      n.Parent(),
+			Source:      source,
+			Metadata:    make(map[string]interface{}),
+			Config:      m.contextConfig(),
+		}
+
+		transformedNode, err := m.ApplyTransformers(n, ctx)
+		if err != nil {
+			return ast.WalkStop, err
+		}
+
+		// If the node was transformed, replace it
+		if transformedNode != n {
+			if n.Parent() != nil {
+				n.Parent().ReplaceChild(n.Parent(), n, transformedNode)
+			}
+		}
+
+		return ast.WalkContinue, nil
+	})
+	if err != nil {
+		return node, err
+	}
+
+	for _, transformer := range m.documentTransformers {
+		ctx := &TransformContext{
+			CurrentNode: node,
+			Source:      source,
+			Metadata:    make(map[string]interface{}),
+			Config:      m.contextConfig(),
+			Logger:      m.loggerFor(transformer.Name()),
+			Host:        m.hostFor(transformer),
+		}
+		ctx.HTTP = ctx.Host.HTTP()
+		transformed, err := transformer.TransformDocument(node, ctx)
+		if err != nil {
+			return node, fmt.Errorf("document transformer %s failed: %w", transformer.Name(), err)
+		}
+		if transformed == nil {
+			return node, fmt.Errorf("document transformer %s returned no document", transformer.Name())
+		}
+		node = transformed
+	}
+
+	return node, nil
+}
+
 // GenerateContent runs all content generators for a specific phase
 func (m *Manager) GenerateContent(phase GenerationPhase, ctx *RenderContext) ([]PDFElement, error) {
 	var elements []PDFElement
 
 	generators := m.GetGenerators(phase)
 	for _, generator := range generators {
+		ctx.Logger = m.loggerFor(generator.Name())
+		ctx.Host = m.hostFor(generator)
+		ctx.HTTP = ctx.Host.HTTP()
 		generatedElements, err := generator.Generate(ctx)
 		if err != nil {
 			return elements, fmt.Errorf("generator %s failed: %w", generator.Name(), err)
@@ -341,6 +642,35 @@ func (m *Manager) Cleanup() error {
 	return nil
 }
 
+// Checksums returns the SHA256 checksum of each plugin file that
+// LoadPlugins would load, keyed by file name. It returns nil when plugin
+// loading is disabled or the plugin directory does not exist.
+func (m *Manager) Checksums() (map[string]string, error) {
+	if !m.enabled {
+		return nil, nil
+	}
+	files, err := os.ReadDir(m.pluginDir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read plugin directory: %w", err)
+	}
+
+	checksums := make(map[string]string)
+	for _, file := range files {
+		if _, ok := loaderFor(file.Name()); !ok {
+			continue
+		}
+		checksum, err := CalculateFileChecksum(filepath.Join(m.pluginDir, file.Name()))
+		if err != nil {
+			return nil, err
+		}
+		checksums[file.Name()] = checksum
+	}
+	return checksums, nil
+}
+
 // ListPlugins returns information about all loaded plugins
 func (m *Manager) ListPlugins() []PluginInfo {
 	var pluginList []PluginInfo
@@ -2,6 +2,7 @@ package plugins
 
 import (
 	"errors"
+	"net/http"
 	"os"
 	"path/filepath"
 	"strings"
@@ -259,6 +260,33 @@ func TestApplyTransformers_SupportedNodes(t *testing.T) {
 	}
 }
 
+func TestTransformDocument_DocumentConfig(t *testing.T) {
+	manager := NewManager("./plugins", true, nil)
+	manager.SetDocumentConfig(map[string]interface{}{"mermaid_theme": "dark"})
+
+	var themes []interface{}
+	manager.transformers = append(manager.transformers, &testTransformer{
+		name:           "theme-reader",
+		supportedNodes: []ast.NodeKind{ast.KindParagraph},
+		transformFunc: func(node ast.Node, ctx *TransformContext) (ast.Node, error) {
+			themes = append(themes, ctx.Config["mermaid_theme"])
+			ctx.Config["mermaid_theme"] = "changed"
+			return node, nil
+		},
+	})
+
+	doc := ast.NewDocument()
+	doc.AppendChild(doc, ast.NewParagraph())
+	doc.AppendChild(doc, ast.NewParagraph())
+	if _, err := manager.TransformDocument(doc, nil); err != nil {
+		t.Fatalf("TransformDocument failed: %v", err)
+	}
+	// Every context starts from the document settings
+	if len(themes) != 2 || themes[0] != "dark" || themes[1] != "dark" {
+		t.Errorf("themes = %v, want dark for both paragraphs", themes)
+	}
+}
+
 func TestApplyTransformers_NoTransformers(t *testing.T) {
 	manager := NewManager("./plugins", true, nil)
 
@@ -581,6 +609,226 @@ func sortTransformers(transformers []ASTTransformer) {
 	}
 }
 
+func TestLoadPlugins_RenewsPluginsBetweenConversions(t *testing.T) {
+	manager := NewManager(t.TempDir(), true, nil)
+	if err := manager.LoadPlugins(); err != nil {
+		t.Fatalf("LoadPlugins failed: %v", err)
+	}
+
+	// Stand in for plugins opened from .so files
+	created := 0
+	newTransformer := func() Plugin {
+		created++
+		return &testTransformer{name: "stateful", priority: 10}
+	}
+	resettable := &resettablePlugin{testPlugin: testPlugin{name: "resettable"}}
+	manager.loaded = []*loadedPlugin{
+		{file: "stateful.so", newPlugin: newTransformer, instance: newTransformer()},
+		{file: "resettable.so", newPlugin: func() Plugin { return &resettablePlugin{} }, instance: resettable},
+	}
+	builtin := &testGenerator{name: "builtin", phase: AfterContent}
+	if err := manager.RegisterBuiltin(builtin); err != nil {
+		t.Fatalf("RegisterBuiltin failed: %v", err)
+	}
+	first := manager.loaded[0].instance
+
+	if err := manager.LoadPlugins(); err != nil {
+		t.Fatalf("second LoadPlugins failed: %v", err)
+	}
+
+	if created != 2 || manager.loaded[0].instance == first {
+		t.Error("a plugin without Reset should get a new instance for the next conversion")
+	}
+	if resettable.resets != 1 || manager.loaded[1].instance != resettable {
+		t.Errorf("a Resetter should be reset and kept, got %d resets", resettable.resets)
+	}
+	if got := manager.GetTransformers(); len(got) != 1 || got[0] != manager.loaded[0].instance {
+		t.Errorf("transformers = %v, want only the new instance", got)
+	}
+	if got := manager.GetGenerators(AfterContent); len(got) != 1 || got[0] != builtin {
+		t.Errorf("generators = %v, want the built-in plugin once", got)
+	}
+	if len(manager.plugins) != 3 {
+		t.Errorf("expected 3 registered plugins, got %d", len(manager.plugins))
+	}
+}
+func TestChecksums(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "a.so"), []byte("plugin"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("not a plugin"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	checksums, err := NewManager(dir, true, nil).Checksums()
+	if err != nil {
+		t.Fatalf("Checksums failed: %v", err)
+	}
+	if len(checksums) != 1 || checksums["a.so"] == "" {
+		t.Errorf("Checksums() = %v, want only a.so", checksums)
+	}
+
+	if checksums, err := NewManager(dir, false, nil).Checksums(); err != nil || checksums != nil {
+		t.Errorf("disabled manager: Checksums() = %v, %v, want nil", checksums, err)
+	}
+	if checksums, err := NewManager(filepath.Join(dir, "missing"), true, nil).Checksums(); err != nil || checksums != nil {
+		t.Errorf("missing directory: Checksums() = %v, %v, want nil", checksums, err)
+	}
+}
+
+func TestPluginLogging(t *testing.T) {
+	type entry struct {
+		plugin  string
+		level   LogLevel
+		message string
+	}
+	var logged []entry
+	manager := NewManager(t.TempDir(), true, nil)
+	manager.SetLogHandler(func(plugin string, level LogLevel, message string) {
+		logged = append(logged, entry{plugin, level, message})
+	})
+
+	builtin := &loggingPlugin{testPlugin: testPlugin{name: "builtin"}}
+	if err := manager.RegisterBuiltin(builtin); err != nil {
+		t.Fatalf("RegisterBuiltin failed: %v", err)
+	}
+	if builtin.logger == nil {
+		t.Fatal("a LoggerSetter should get its logger before Init")
+	}
+	builtin.logger.Infof("ready in %dms", 3)
+
+	manager.transformers = append(manager.transformers, &testTransformer{
+		name: "transformer",
+		transformFunc: func(node ast.Node, ctx *TransformContext) (ast.Node, error) {
+			ctx.Logger.Warnf("skipped %s", "diagram")
+			return node, nil
+		},
+	})
+	ctx := &TransformContext{Metadata: map[string]interface{}{}}
+	if _, err := manager.ApplyTransformers(ast.NewDocument(), ctx); err != nil {
+		t.Fatalf("ApplyTransformers failed: %v", err)
+	}
+
+	want := []entry{
+		{"builtin", LogInfo, "ready in 3ms"},
+		{"transformer", LogWarn, "skipped diagram"},
+	}
+	if len(logged) != len(want) {
+		t.Fatalf("logged %v, want %v", logged, want)
+	}
+	for i := range want {
+		if logged[i] != want[i] {
+			t.Errorf("entry %d = %v, want %v", i, logged[i], want[i])
+		}
+	}
+}
+
+func TestApplyTransformers_HTTPClient(t *testing.T) {
+	manager := NewManager(t.TempDir(), true, nil)
+	client := &stubHTTPClient{}
+	manager.SetHTTPClient(client)
+	security := DefaultSecurityConfig()
+	security.Grants = map[string]Capabilities{"fetcher": {Network: true}, "offline": {Network: true}}
+	if err := manager.SetSecurityConfig(security); err != nil {
+		t.Fatal(err)
+	}
+
+	got := make(map[string]HTTPClient)
+	record := func(node ast.Node, ctx *TransformContext) (ast.Node, error) {
+		got[ctx.Logger.(*pluginLogger).plugin] = ctx.HTTP
+		return node, nil
+	}
+	manager.transformers = append(manager.transformers,
+		&capableTransformer{
+			testTransformer: testTransformer{name: "fetcher", transformFunc: record},
+			capabilities:    Capabilities{Network: true},
+		},
+		&testTransformer{name: "offline", transformFunc: record},
+	)
+	ctx := &TransformContext{Metadata: map[string]interface{}{}}
+	if _, err := manager.ApplyTransformers(ast.NewDocument(), ctx); err != nil {
+		t.Fatalf("ApplyTransformers failed: %v", err)
+	}
+	if got["fetcher"] != client {
+		t.Errorf("transformer declaring network got HTTP client %v, want the one set on the manager", got["fetcher"])
+	}
+	var securityErr *PluginSecurityError
+	if _, err := got["offline"].Get("https://example.com"); !errors.As(err, &securityErr) {
+		t.Errorf("transformer without network capability: Get error = %v, want a PluginSecurityError", err)
+	}
+}
+
+func TestTransformDocument_DocumentTransformers(t *testing.T) {
+	manager := NewManager(t.TempDir(), true, nil)
+
+	var order []string
+	// Runs second: moves the last child of the document to the front
+	reorder := &testDocumentTransformer{
+		testPlugin: testPlugin{name: "reorder"},
+		priority:   20,
+		transformFunc: func(doc ast.Node, ctx *TransformContext) (ast.Node, error) {
+			order = append(order, "reorder")
+			if ctx.CurrentNode != doc || ctx.Logger == nil || ctx.Host == nil {
+				t.Error("document transformer context is incomplete")
+			}
+			last := doc.LastChild()
+			doc.RemoveChild(doc, last)
+			doc.InsertBefore(doc, doc.FirstChild(), last)
+			return doc, nil
+		},
+	}
+	// Runs first, after the node transformer has run on every node
+	insert := &testDocumentTransformer{
+		testPlugin: testPlugin{name: "insert"},
+		priority:   10,
+		transformFunc: func(doc ast.Node, ctx *TransformContext) (ast.Node, error) {
+			order = append(order, "insert")
+			doc.AppendChild(doc, ast.NewThematicBreak())
+			return doc, nil
+		},
+	}
+	nodes := &testTransformer{
+		name: "nodes",
+		transformFunc: func(node ast.Node, ctx *TransformContext) (ast.Node, error) {
+			if len(order) > 0 {
+				t.Error("node transformers should run before document transformers")
+			}
+			return node, nil
+		},
+	}
+	for _, p := range []Plugin{reorder, insert, nodes} {
+		if err := manager.Register(p); err != nil {
+			t.Fatalf("Register failed: %v", err)
+		}
+	}
+
+	doc := ast.NewDocument()
+	doc.AppendChild(doc, ast.NewParagraph())
+	result, err := manager.TransformDocument(doc, nil)
+	if err != nil {
+		t.Fatalf("TransformDocument failed: %v", err)
+	}
+	if strings.Join(order, ",") != "insert,reorder" {
+		t.Errorf("document transformers ran in order %v, want insert then reorder", order)
+	}
+	if result.FirstChild().Kind() != ast.KindThematicBreak || result.ChildCount() != 2 {
+		t.Errorf("expected the inserted break first, got %s of %d children", result.FirstChild().Kind(), result.ChildCount())
+	}
+
+	// Errors and missing documents stop the conversion
+	manager = NewManager(t.TempDir(), true, nil)
+	_ = manager.Register(&testDocumentTransformer{
+		testPlugin: testPlugin{name: "broken"},
+		transformFunc: func(doc ast.Node, ctx *TransformContext) (ast.Node, error) {
+			return nil, nil
+		},
+	})
+	if _, err := manager.TransformDocument(ast.NewDocument(), nil); err == nil || !strings.Contains(err.Error(), "broken returned no document") {
+		t.Errorf("expected an error for a nil document, got %v", err)
+	}
+}
+
 // Test doubles
 
 type testPlugin struct {
@@ -639,3 +887,50 @@ func (g *testGenerator) Generate(ctx *RenderContext) ([]PDFElement, error) {
 	}
 	return g.elements, nil
 }
+
+type resettablePlugin struct {
+	testPlugin
+	resets int
+}
+
+func (p *resettablePlugin) Reset() error {
+	p.resets++
+	return nil
+}
+
+type loggingPlugin struct {
+	testPlugin
+	logger Logger
+}
+
+func (p *loggingPlugin) SetLogger(logger Logger) {
+	p.logger = logger
+}
+
+type stubHTTPClient struct{}
+
+func (c *stubHTTPClient) Get(url string) ([]byte, error) { return nil, nil }
+func (c *stubHTTPClient) GetAll(urls []string) ([][]byte, []error) {
+	return make([][]byte, len(urls)), make([]error, len(urls))
+}
+func (c *stubHTTPClient) Do(req *http.Request) (*http.Response, error) {
+	return nil, errors.New("not implemented")
+}
+
+type capableTransformer struct {
+	testTransformer
+	capabilities Capabilities
+}
+
+func (t *capableTransformer) Capabilities() Capabilities { return t.capabilities }
+
+type testDocumentTransformer struct {
+	testPlugin
+	priority      int
+	transformFunc func(ast.Node, *TransformContext) (ast.Node, error)
+}
+
+func (t *testDocumentTransformer) Priority() int { return t.priority }
+func (t *testDocumentTransformer) TransformDocument(doc ast.Node, ctx *TransformContext) (ast.Node, error) {
+	return t.transformFunc(doc, ctx)
+}
@@ -0,0 +1,31 @@
+//go:build (linux || darwin || freebsd) && cgo
+
+package plugins
+
+import (
+	"fmt"
+	"plugin"
+)
+
+// nativePluginsUnsupported says why this build cannot load .so plugins.
+func nativePluginsUnsupported() string { return "" }
+
+// openPlugin opens a .so plugin and returns its NewPlugin function.
+func openPlugin(path string) (func() Plugin, error) {
+	p, err := plugin.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open plugin: %w", err)
+	}
+
+	// Look for NewPlugin function
+	newPluginSymbol, err := p.Lookup("NewPlugin")
+	if err != nil {
+		return nil, fmt.Errorf("plugin missing NewPlugin function: %w", err)
+	}
+
+	newPluginFunc, ok := newPluginSymbol.(func() Plugin)
+	if !ok {
+		return nil, fmt.Errorf("NewPlugin has invalid signature")
+	}
+	return newPluginFunc, nil
+}
@@ -0,0 +1,29 @@
+//go:build !((linux || darwin || freebsd) && cgo)
+
+package plugins
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+)
+
+// nativePluginsUnsupported says why this build cannot load .so plugins. Go
+// loads them on Linux, macOS and FreeBSD builds with cgo only; elsewhere,
+// such as on Windows and in WebAssembly, the built-in plugins and those
+// passed to Register run, and plugins in other formats when a loader for
+// them is available.
+func nativePluginsUnsupported() string {
+	switch runtime.GOOS {
+	case "js":
+		return "WebAssembly builds cannot load native code"
+	case "linux", "darwin", "freebsd":
+		return "this build of md-to-pdf was compiled without cgo"
+	}
+	return fmt.Sprintf(".so plugins are not supported on %s", runtime.GOOS)
+}
+
+// openPlugin fails: this build cannot load native code.
+func openPlugin(path string) (func() Plugin, error) {
+	return nil, errors.New("failed to open plugin: " + nativePluginsUnsupported())
+}
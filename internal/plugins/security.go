@@ -2,10 +2,9 @@ package plugins
 
 import (
 	"bufio"
-	"crypto/sha256"
+	"bytes"
 	"encoding/hex"
 	"fmt"
-	"io"
 	"os"
 	"path/filepath"
 	"strings"
@@ -23,6 +22,10 @@ type SecurityConfig struct {
 	AllowUnsignedPlugins bool
 	// TrustedDirectories is a list of directories considered safe for plugin loading
 	TrustedDirectories []string
+	// Grants holds the capabilities granted to each plugin, keyed by plugin
+	// name. When nil, plugins may only read the paths they declare; once
+	// set, plugins get only what is granted here.
+	Grants map[string]Capabilities
 }
 
 // DefaultSecurityConfig returns secure default settings
@@ -66,9 +69,26 @@ func (e *PathTraversalError) Error() string {
 
 // AllowlistEntry represents a single entry in the plugin allowlist
 type AllowlistEntry struct {
-	Name     string
-	Checksum string
-	Enabled  bool
+	Name string
+	// Algorithm is the checksum algorithm, such as "sha256" or "sha512"
+	// (empty means sha256)
+	Algorithm string
+	Checksum  string
+	Enabled   bool
+	// Version constrains the version the plugin reports, such as
+	// ">=1.2.0, <2" (empty allows any version)
+	Version string
+	// TrustedDirectories are the only directories the plugin may be loaded
+	// from (empty allows any directory)
+	TrustedDirectories []string
+}
+
+// algorithm returns the checksum algorithm of the entry.
+func (e AllowlistEntry) algorithm() string {
+	if e.Algorithm == "" {
+		return DefaultChecksumAlgorithm
+	}
+	return e.Algorithm
 }
 
 // PluginAllowlist manages the list of allowed plugins with their checksums
@@ -84,8 +104,12 @@ func NewPluginAllowlist() *PluginAllowlist {
 	}
 }
 
-// LoadAllowlistFromFile loads an allowlist from a file
-// File format: one entry per line, format: "plugin_name:sha256_checksum" or "plugin_name:sha256_checksum:disabled"
+// LoadAllowlistFromFile loads an allowlist from a file in either format.
+// Format v1 has one entry per line: "plugin_name:sha256_checksum" or
+// "plugin_name:sha256_checksum:disabled". Format v2 is YAML or JSON with
+// tagged checksums, version constraints and trusted directories per entry
+// (see allowlistFile); files with a .yaml, .yml or .json extension, or
+// starting with a version line, are read as v2.
 func LoadAllowlistFromFile(path string) (*PluginAllowlist, error) {
 	if path == "" {
 		return NewPluginAllowlist(), nil
@@ -99,19 +123,19 @@ func LoadAllowlistFromFile(path string) (*PluginAllowlist, error) {
 		}
 	}
 
-	file, err := os.Open(path) // #nosec G304 -- path validated above for traversal
+	content, err := os.ReadFile(path) // #nosec G304 -- path validated above for traversal
 	if err != nil {
 		if os.IsNotExist(err) {
 			return NewPluginAllowlist(), nil
 		}
 		return nil, fmt.Errorf("failed to open allowlist file: %w", err)
 	}
-	defer func() {
-		_ = file.Close()
-	}()
+	if isAllowlistV2(path, content) {
+		return parseAllowlistV2(content)
+	}
 
 	allowlist := NewPluginAllowlist()
-	scanner := bufio.NewScanner(file)
+	scanner := bufio.NewScanner(bytes.NewReader(content))
 	lineNum := 0
 
 	for scanner.Scan() {
@@ -150,9 +174,10 @@ func LoadAllowlistFromFile(path string) (*PluginAllowlist, error) {
 		}
 
 		allowlist.entries[name] = AllowlistEntry{
-			Name:     name,
-			Checksum: strings.ToLower(checksum),
-			Enabled:  enabled,
+			Name:      name,
+			Algorithm: DefaultChecksumAlgorithm,
+			Checksum:  strings.ToLower(checksum),
+			Enabled:   enabled,
 		}
 	}
 
@@ -163,7 +188,8 @@ func LoadAllowlistFromFile(path string) (*PluginAllowlist, error) {
 	return allowlist, nil
 }
 
-// IsAllowed checks if a plugin with the given name and checksum is allowed
+// IsAllowed checks if a plugin with the given name and checksum is allowed.
+// The checksum must be computed with the algorithm of the plugin's entry.
 func (a *PluginAllowlist) IsAllowed(name, checksum string) bool {
 	a.mu.RLock()
 	defer a.mu.RUnlock()
@@ -185,6 +211,15 @@ func (a *PluginAllowlist) HasEntry(name string) bool {
 	return exists
 }
 
+// Entry returns the allowlist entry of the named plugin.
+func (a *PluginAllowlist) Entry(name string) (AllowlistEntry, bool) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	entry, exists := a.entries[name]
+	return entry, exists
+}
+
 // GetExpectedChecksum returns the expected checksum for a plugin
 func (a *PluginAllowlist) GetExpectedChecksum(name string) (string, bool) {
 	a.mu.RLock()
@@ -206,6 +241,12 @@ func (a *PluginAllowlist) IsEmpty() bool {
 
 // CalculateFileChecksum computes the SHA256 checksum of a file
 func CalculateFileChecksum(path string) (string, error) {
+	return CalculateFileDigest(path, DefaultChecksumAlgorithm)
+}
+
+// CalculateFileDigest computes the checksum of a file with the named
+// algorithm, "sha256", "sha512" or one added by RegisterChecksumAlgorithm.
+func CalculateFileDigest(path, algorithm string) (string, error) {
 	// Defense-in-depth: validate path even though callers may do their own checks
 	if containsPathTraversal(path) {
 		return "", &PathTraversalError{
@@ -222,12 +263,11 @@ func CalculateFileChecksum(path string) (string, error) {
 		_ = file.Close()
 	}()
 
-	hash := sha256.New()
-	if _, err := io.Copy(hash, file); err != nil {
+	digest, err := hashReader(file, algorithm)
+	if err != nil {
 		return "", fmt.Errorf("failed to compute checksum: %w", err)
 	}
-
-	return hex.EncodeToString(hash.Sum(nil)), nil
+	return digest, nil
 }
 
 // ValidatePluginPath validates a plugin directory path for security issues
@@ -418,12 +458,23 @@ func VerifyPlugin(path string, config *SecurityConfig, allowlist *PluginAllowlis
 	}
 	event.Checksum = checksum
 
+	// Per-entry trusted directories count as trusted paths as well
+	var entry AllowlistEntry
+	listed := false
+	if allowlist != nil {
+		entry, listed = allowlist.Entry(filepath.Base(path))
+	}
+	trustedDirs := config.TrustedDirectories
+	if listed && len(entry.TrustedDirectories) > 0 {
+		trustedDirs = append(append([]string{}, trustedDirs...), entry.TrustedDirectories...)
+	}
+
 	// Check path safety
 	inWorkDir, err := IsPathInWorkingDirectory(path)
 	if err != nil {
 		event.SecurityWarning = fmt.Sprintf("could not verify path safety: %v", err)
 	} else if !inWorkDir {
-		inTrusted := IsPathInTrustedDirectory(path, config.TrustedDirectories)
+		inTrusted := IsPathInTrustedDirectory(path, trustedDirs)
 		if !inTrusted {
 			event.SecurityWarning = "plugin loaded from directory outside current working directory and trusted paths"
 		}
@@ -433,21 +484,52 @@ func VerifyPlugin(path string, config *SecurityConfig, allowlist *PluginAllowlis
 	if allowlist != nil && !allowlist.IsEmpty() {
 		pluginName := filepath.Base(path)
 
-		if !allowlist.IsAllowed(pluginName, checksum) {
-			if allowlist.HasEntry(pluginName) {
-				expectedChecksum, _ := allowlist.GetExpectedChecksum(pluginName)
-				event.Error = fmt.Sprintf("checksum mismatch: expected %s, got %s", truncateChecksum(expectedChecksum), truncateChecksum(checksum))
+		if !listed {
+			event.Error = "plugin not in allowlist"
+			return event, &PluginSecurityError{
+				Plugin:    path,
+				Operation: "verification",
+				Reason:    "plugin not found in allowlist",
+			}
+		}
+
+		// The entry may use another algorithm than the logged SHA256
+		digest := checksum
+		if entry.algorithm() != DefaultChecksumAlgorithm {
+			digest, err = CalculateFileDigest(path, entry.algorithm())
+			if err != nil {
+				event.Error = fmt.Sprintf("failed to calculate %s checksum: %v", entry.algorithm(), err)
 				return event, &PluginSecurityError{
 					Plugin:    path,
-					Operation: "verification",
-					Reason:    "plugin checksum does not match allowlist",
+					Operation: "checksum",
+					Reason:    "failed to calculate file checksum",
+					Cause:     err,
 				}
 			}
-			event.Error = "plugin not in allowlist"
+		}
+
+		if !entry.Enabled {
+			event.Error = "plugin disabled in allowlist"
 			return event, &PluginSecurityError{
 				Plugin:    path,
 				Operation: "verification",
-				Reason:    "plugin not found in allowlist",
+				Reason:    "plugin is disabled in allowlist",
+			}
+		}
+		if !allowlist.IsAllowed(pluginName, digest) {
+			event.Error = fmt.Sprintf("%s checksum mismatch: expected %s, got %s", entry.algorithm(), truncateChecksum(entry.Checksum), truncateChecksum(digest))
+			return event, &PluginSecurityError{
+				Plugin:    path,
+				Operation: "verification",
+				Reason:    "plugin checksum does not match allowlist",
+			}
+		}
+		if len(entry.TrustedDirectories) > 0 && !IsPathInTrustedDirectory(path, entry.TrustedDirectories) {
+			event.Error = "plugin outside the trusted directories of its allowlist entry"
+			return event, &PluginSecurityError{
+				Plugin:    path,
+				Operation: "verification",
+				Reason:    "plugin is not in a directory its allowlist entry trusts",
 			}
 		}
 	} else if config.RequireVerification {
@@ -0,0 +1,220 @@
+package plugins
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/fredcamaral/md-to-pdf/internal/fsutil"
+	"github.com/fredcamaral/md-to-pdf/internal/parser"
+	"github.com/jung-kurt/gofpdf"
+	"github.com/yuin/goldmark/ast"
+)
+
+const (
+	sourceFontSize   = 8   // Listing font size in points
+	sourceLineHeight = 3.6 // Listing line height in mm
+	sourceTabWidth   = 4
+)
+
+// SourceAppendixGenerator is the built-in "source-appendix" content
+// generator. It appends the markdown source of the document as a
+// line-numbered listing, so reviewers can refer to source lines.
+type SourceAppendixGenerator struct{}
+
+// NewSourceAppendixGenerator creates the source appendix generator.
+func NewSourceAppendixGenerator() *SourceAppendixGenerator {
+	return &SourceAppendixGenerator{}
+}
+
+func (g *SourceAppendixGenerator) Name() string    { return "source-appendix" }
+func (g *SourceAppendixGenerator) Version() string { return "1.0.0" }
+func (g *SourceAppendixGenerator) Description() string {
+	return "Appends the markdown source as a line-numbered listing"
+}
+
+func (g *SourceAppendixGenerator) Init(config map[string]interface{}) error { return nil }
+func (g *SourceAppendixGenerator) Cleanup() error                           { return nil }
+
+func (g *SourceAppendixGenerator) GenerationPhase() GenerationPhase {
+	return AfterContent
+}
+
+func (g *SourceAppendixGenerator) Generate(ctx *RenderContext) ([]PDFElement, error) {
+	name := ""
+	if ctx.Document != nil {
+		name = ctx.Document.SourceFile
+	}
+	return []PDFElement{NewSourceListing(name, ctx.Source)}, nil
+}
+
+// SourceListingElement draws markdown source with line numbers in a
+// monospace font, starting on a new page. Long lines wrap without a new
+// number. Redacted spans are blacked out as they are in the document.
+type SourceListingElement struct {
+	Name   string // Source file name shown in the title
+	Source []byte
+}
+
+// NewSourceListing creates a listing of source, named after its file.
+func NewSourceListing(name string, source []byte) *SourceListingElement {
+	return &SourceListingElement{Name: name, Source: source}
+}
+
+// sourceChar is one character of a listing line.
+type sourceChar struct {
+	r        rune
+	redacted bool
+}
+
+func (s *SourceListingElement) Render(pdf *gofpdf.Fpdf, ctx *RenderContext) error {
+	pdf.AddPage()
+	pageWidth, pageHeight := pdf.GetPageSize()
+	leftMargin, topMargin, rightMargin, _ := pdf.GetMargins()
+	_, bottomMargin := pdf.GetAutoPageBreak()
+
+	title := "Source"
+	if s.Name != "" {
+		title += ": " + s.Name
+	}
+	translate := pdf.UnicodeTranslatorFromDescriptor("")
+	pdf.SetFont("Arial", "B", 16)
+	pdf.CellFormat(0, 10, translate(title), "", 1, "L", false, 0, "")
+	pdf.Ln(2)
+
+	lines := s.lines()
+	pdf.SetFont("Courier", "", sourceFontSize)
+	charWidth := pdf.GetStringWidth("0")
+	digits := len(strconv.Itoa(len(lines)))
+	gutter := float64(digits+2) * charWidth
+	perRow := max(int((pageWidth-leftMargin-rightMargin-gutter)/charWidth), 1)
+
+	// Text is placed on its baseline, so page breaks are made here
+	y := pdf.GetY()
+	newRow := func() {
+		if y+sourceLineHeight > pageHeight-bottomMargin {
+			pdf.AddPage()
+			pdf.SetFont("Courier", "", sourceFontSize)
+			y = topMargin
+		}
+	}
+	fillR, fillG, fillB := pdf.GetFillColor()
+	pdf.SetFillColor(0, 0, 0)
+	for i, line := range lines {
+		for start := 0; start == 0 || start < len(line); start += perRow {
+			newRow()
+			baseline := y + sourceLineHeight*0.75
+			if start == 0 {
+				pdf.SetTextColor(140, 140, 140)
+				number := strconv.Itoa(i + 1)
+				pdf.Text(leftMargin+float64(digits-len(number))*charWidth, baseline, number)
+				pdf.SetTextColor(0, 0, 0)
+			}
+			s.drawRow(pdf, translate, line[start:min(start+perRow, len(line))], leftMargin+gutter, y, baseline, charWidth)
+			y += sourceLineHeight
+		}
+	}
+	pdf.SetFillColor(fillR, fillG, fillB)
+	pdf.SetXY(leftMargin, y)
+	return nil
+}
+
+// drawRow draws one row of a listing line, a run of text or redaction at a
+// time.
+func (s *SourceListingElement) drawRow(pdf *gofpdf.Fpdf, translate func(string) string, row []sourceChar, x, y, baseline, charWidth float64) {
+	for len(row) > 0 {
+		n := 1
+		for n < len(row) && row[n].redacted == row[0].redacted {
+			n++
+		}
+		if row[0].redacted {
+			pdf.Rect(x, y+0.3, float64(n)*charWidth, sourceLineHeight-0.6, "F")
+		} else {
+			var run strings.Builder
+			for _, c := range row[:n] {
+				run.WriteRune(c.r)
+			}
+			pdf.Text(x, baseline, translate(run.String()))
+		}
+		x += float64(n) * charWidth
+		row = row[n:]
+	}
+}
+
+// lines splits the source into lines of characters, with tabs expanded
+// and the characters of redacted spans marked.
+func (s *SourceListingElement) lines() [][]sourceChar {
+	redacted := redactedRanges(s.Source)
+	var lines [][]sourceChar
+	var line []sourceChar
+	for offset, r := range string(s.Source) {
+		switch r {
+		case '\n':
+			lines = append(lines, line)
+			line = nil
+		case '\r':
+		case '\t':
+			hidden := redacted(offset)
+			for pad := sourceTabWidth - len(line)%sourceTabWidth; pad > 0; pad-- {
+				line = append(line, sourceChar{r: ' ', redacted: hidden})
+			}
+		default:
+			line = append(line, sourceChar{r: r, redacted: redacted(offset)})
+		}
+	}
+	if len(line) > 0 || len(lines) == 0 {
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// redactedRanges returns a function reporting whether the byte at an offset
+// of source is part of a redacted span.
+func redactedRanges(source []byte) func(offset int) bool {
+	var spans [][2]int
+	doc, err := parser.NewMarkdownParser().Parse(source)
+	if err == nil {
+		_ = ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+			if redaction, ok := n.(*parser.Redaction); ok && entering {
+				spans = append(spans, [2]int{redaction.Segment.Start, redaction.Segment.Stop})
+			}
+			return ast.WalkContinue, nil
+		})
+	}
+	return func(offset int) bool {
+		for _, span := range spans {
+			if offset >= span[0] && offset < span[1] {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+func (s *SourceListingElement) Height() float64 {
+	return 0 // Fills its own pages
+}
+
+func (s *SourceListingElement) Width() float64 {
+	return 0
+}
+
+// WritePDF writes the listing as a PDF of its own, with the given page size
+// and margins in mm.
+func (s *SourceListingElement) WritePDF(path, pageSize string, left, top, right, bottom float64) error {
+	pdf := gofpdf.New("P", "mm", pageSize, "")
+	pdf.SetMargins(left, top, right)
+	pdf.SetAutoPageBreak(true, bottom)
+	if err := s.Render(pdf, &RenderContext{PDF: pdf, Source: s.Source}); err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return fmt.Errorf("failed to render source listing: %w", err)
+	}
+	if err := fsutil.WriteFile(path, buf.Bytes(), 0600); err != nil {
+		return fmt.Errorf("failed to write source listing: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package plugins
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestSourceAppendixGenerator_Generate(t *testing.T) {
+	source := "# Report\n\n\tIndented\nAgent ||Jane Smith|| met `||code||`.^[call ||Bob|| now]\n" +
+		strings.Repeat("long ", 60) + "\n"
+	for i := 0; i < 80; i++ {
+		source += "filler\n"
+	}
+
+	pdf := newTablePDF()
+	ctx := &RenderContext{PDF: pdf, Source: []byte(source), Document: &Document{SourceFile: "report.md"}}
+	elements, err := NewSourceAppendixGenerator().Generate(ctx)
+	if err != nil || len(elements) != 1 {
+		t.Fatalf("Generate() = %d elements, %v; want one listing", len(elements), err)
+	}
+	if err := elements[0].Render(pdf, ctx); err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+
+	// 85 lines, one of them wrapped, make two pages after the content page
+	if pdf.PageNo() != 3 {
+		t.Errorf("expected the listing on two new pages, got %d pages", pdf.PageNo())
+	}
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		t.Fatalf("Output failed: %v", err)
+	}
+	out := buf.String()
+	for _, want := range []string{"Source: report.md", "(# Report)", "(    Indented)", "(Agent )", "( met `||code||`.^[call )", "( now])", "(85)"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("listing is missing %q", want)
+		}
+	}
+	for _, secret := range []string{"Jane", "Bob"} {
+		if strings.Contains(out, secret) {
+			t.Errorf("redacted text %q should be blacked out in the listing", secret)
+		}
+	}
+}
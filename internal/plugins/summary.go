@@ -0,0 +1,316 @@
+package plugins
+
+import (
+	"bufio"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/fredcamaral/md-to-pdf/internal/qrcode"
+	"github.com/jung-kurt/gofpdf"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/text"
+)
+
+const (
+	summaryQRSize      = 35 // Side of the QR code in mm, including its quiet zone
+	summaryQuietZone   = 4  // Light modules around the QR code
+	summaryWordsPerMin = 200
+)
+
+// SummaryGenerator is the built-in "summary" content generator. It adds a
+// closing page with document statistics, the generation time, the tool
+// version, a fingerprint of the rendering settings and a QR code linking to
+// the source repository. It uses the same generator API as external
+// plugins, with the page and table built from PDF elements.
+type SummaryGenerator struct {
+	ToolVersion string
+	Fingerprint string // Short hash of the settings the PDF was rendered with
+	// RepoURL is encoded in the QR code. When empty it is taken from the
+	// "origin" remote of the git repository containing the source file.
+	RepoURL string
+	Now     func() time.Time
+}
+
+// NewSummaryGenerator creates the summary page generator.
+func NewSummaryGenerator(toolVersion, fingerprint, repoURL string) *SummaryGenerator {
+	return &SummaryGenerator{
+		ToolVersion: toolVersion,
+		Fingerprint: fingerprint,
+		RepoURL:     repoURL,
+		Now:         time.Now,
+	}
+}
+
+func (g *SummaryGenerator) Name() string    { return "summary" }
+func (g *SummaryGenerator) Version() string { return "1.0.0" }
+func (g *SummaryGenerator) Description() string {
+	return "Adds a closing page with document statistics and a QR link to the source"
+}
+
+// Init accepts a "repo_url" setting from the plugin configuration.
+func (g *SummaryGenerator) Init(config map[string]interface{}) error {
+	if repoURL, ok := config["repo_url"].(string); ok && repoURL != "" {
+		g.RepoURL = repoURL
+	}
+	return nil
+}
+
+func (g *SummaryGenerator) Cleanup() error { return nil }
+
+func (g *SummaryGenerator) GenerationPhase() GenerationPhase {
+	return AfterContent
+}
+
+func (g *SummaryGenerator) Generate(ctx *RenderContext) ([]PDFElement, error) {
+	stats := CountDocument(ctx.Source)
+	stats.Pages = ctx.PDF.PageNo()
+
+	page := &SummaryPageElement{
+		Stats:       stats,
+		Generated:   g.Now(),
+		ToolVersion: g.ToolVersion,
+		Fingerprint: g.Fingerprint,
+		RepoURL:     g.RepoURL,
+	}
+	if ctx.Document != nil {
+		page.Title = ctx.Document.Title
+		page.SourceFile = ctx.Document.SourceFile
+		if page.RepoURL == "" && page.SourceFile != "" && page.SourceFile != "stdin" {
+			page.RepoURL = GitRemoteURL(filepath.Dir(page.SourceFile))
+		}
+	}
+	return []PDFElement{page}, nil
+}
+
+// DocumentStats are counts shown on the summary page.
+type DocumentStats struct {
+	Pages      int // Pages before the summary page
+	Words      int
+	Headings   int
+	CodeBlocks int
+	Images     int
+	Links      int
+}
+
+// ReadingMinutes estimates the reading time at 200 words per minute,
+// rounded up.
+func (s DocumentStats) ReadingMinutes() int {
+	return (s.Words + summaryWordsPerMin - 1) / summaryWordsPerMin
+}
+
+// CountDocument counts the words and elements of a markdown document. Code
+// block contents are not counted as words.
+func CountDocument(source []byte) DocumentStats {
+	var stats DocumentStats
+	doc := goldmark.DefaultParser().Parse(text.NewReader(source))
+	_ = ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		switch node := n.(type) {
+		case *ast.Heading:
+			stats.Headings++
+		case *ast.FencedCodeBlock, *ast.CodeBlock:
+			stats.CodeBlocks++
+			return ast.WalkSkipChildren, nil
+		case *ast.Image:
+			stats.Images++
+		case *ast.Link, *ast.AutoLink:
+			stats.Links++
+		case *ast.Text:
+			stats.Words += countWords(node.Segment.Value(source))
+		case *ast.CodeSpan:
+			stats.Words += countWords(node.Text(source))
+			return ast.WalkSkipChildren, nil
+		}
+		return ast.WalkContinue, nil
+	})
+	return stats
+}
+
+// countWords counts the space-separated tokens that contain a letter or a
+// digit, so punctuation left between inline nodes is not counted.
+func countWords(text []byte) int {
+	words := 0
+	for _, field := range strings.Fields(string(text)) {
+		if strings.IndexFunc(field, func(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) }) >= 0 {
+			words++
+		}
+	}
+	return words
+}
+
+// SummaryPageElement draws the summary page on a new page.
+type SummaryPageElement struct {
+	Title       string
+	SourceFile  string
+	Stats       DocumentStats
+	Generated   time.Time
+	ToolVersion string
+	Fingerprint string
+	RepoURL     string
+}
+
+func (s *SummaryPageElement) Render(pdf *gofpdf.Fpdf, ctx *RenderContext) error {
+	pdf.AddPage()
+	pageWidth, _ := pdf.GetPageSize()
+	leftMargin, _, rightMargin, _ := pdf.GetMargins()
+
+	pdf.SetFont("Arial", "B", 16)
+	pdf.CellFormat(0, 10, "Document summary", "", 1, "L", false, 0, "")
+	pdf.Ln(2)
+
+	table := NewTableElement().
+		SetHeader("Item", "Value").
+		SetColumnWidths(50, pageWidth-leftMargin-rightMargin-50)
+
+	if s.Title != "" {
+		table.AddRow("Title", s.Title)
+	}
+	if s.SourceFile != "" {
+		table.AddRow("Source", s.SourceFile)
+	}
+	table.
+		AddRow("Pages", fmt.Sprintf("%d (plus this page)", s.Stats.Pages)).
+		AddRow("Words", fmt.Sprintf("%d (about %d min to read)", s.Stats.Words, s.Stats.ReadingMinutes())).
+		AddRow("Headings", fmt.Sprint(s.Stats.Headings)).
+		AddRow("Code blocks", fmt.Sprint(s.Stats.CodeBlocks)).
+		AddRow("Images", fmt.Sprint(s.Stats.Images)).
+		AddRow("Links", fmt.Sprint(s.Stats.Links)).
+		AddRow("Generated", s.Generated.Format("2006-01-02 15:04:05 MST")).
+		AddRow("Generator", "md-to-pdf "+s.ToolVersion).
+		AddRow("Config fingerprint", s.Fingerprint)
+	if err := table.Render(pdf, ctx); err != nil {
+		return err
+	}
+
+	if s.RepoURL == "" {
+		return nil
+	}
+	code, err := qrcode.Encode([]byte(s.RepoURL), qrcode.Medium)
+	if err != nil {
+		// A URL too long for a QR code is still printed as a link
+		pdf.SetFont("Arial", "", 10)
+		pdf.WriteLinkString(5, "Source: "+s.RepoURL, s.RepoURL)
+		return nil
+	}
+
+	pdf.Ln(4)
+	x, y := leftMargin, pdf.GetY()
+	drawQRCode(pdf, code, x, y, summaryQRSize)
+	pdf.LinkString(x, y, summaryQRSize, summaryQRSize, s.RepoURL)
+
+	pdf.SetFont("Arial", "", 10)
+	pdf.SetXY(x+summaryQRSize+4, y+summaryQRSize/2-5)
+	pdf.CellFormat(0, 5, "Source repository", "", 2, "L", false, 0, "")
+	pdf.SetTextColor(0, 0, 238)
+	pdf.CellFormat(0, 5, s.RepoURL, "", 1, "L", false, 0, s.RepoURL)
+	pdf.SetTextColor(0, 0, 0)
+	pdf.SetXY(leftMargin, y+summaryQRSize)
+	return nil
+}
+
+// drawQRCode draws code as a size x size square at (x, y), quiet zone
+// included.
+func drawQRCode(pdf *gofpdf.Fpdf, code *qrcode.Code, x, y, size float64) {
+	module := size / float64(code.Size+2*summaryQuietZone)
+	r, g, b := pdf.GetFillColor()
+	pdf.SetFillColor(0, 0, 0)
+	for row := 0; row < code.Size; row++ {
+		for col := 0; col < code.Size; col++ {
+			if code.Dark(col, row) {
+				pdf.Rect(x+float64(col+summaryQuietZone)*module, y+float64(row+summaryQuietZone)*module, module, module, "F")
+			}
+		}
+	}
+	pdf.SetFillColor(r, g, b)
+}
+
+func (s *SummaryPageElement) Height() float64 {
+	return 0 // Fills its own page
+}
+
+func (s *SummaryPageElement) Width() float64 {
+	return 0
+}
+
+// GitRemoteURL returns a browsable https URL for the "origin" remote of the
+// git repository containing dir, or "" when there is none. SSH remotes are
+// rewritten to https and credentials are removed.
+func GitRemoteURL(dir string) string {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return ""
+	}
+	for {
+		if remote := readOriginURL(filepath.Join(dir, ".git", "config")); remote != "" {
+			return browsableURL(remote)
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// readOriginURL reads the url of [remote "origin"] from a git config file.
+func readOriginURL(path string) string {
+	file, err := os.Open(path) // #nosec G304 - path is built from the source file's directory
+	if err != nil {
+		return ""
+	}
+	defer func() { _ = file.Close() }()
+
+	inOrigin := false
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "[") {
+			inOrigin = line == `[remote "origin"]`
+			continue
+		}
+		if key, value, ok := strings.Cut(line, "="); ok && inOrigin && strings.TrimSpace(key) == "url" {
+			return strings.TrimSpace(value)
+		}
+	}
+	return ""
+}
+
+// browsableURL turns a git remote into an https URL without credentials or
+// a .git suffix.
+func browsableURL(remote string) string {
+	// scp-like syntax: git@github.com:owner/repo.git
+	if !strings.Contains(remote, "://") {
+		if at := strings.Index(remote, "@"); at >= 0 {
+			remote = remote[at+1:]
+		}
+		host, path, ok := strings.Cut(remote, ":")
+		if !ok {
+			return ""
+		}
+		remote = "https://" + host + "/" + path
+	}
+
+	u, err := url.Parse(remote)
+	if err != nil || u.Host == "" {
+		return ""
+	}
+	switch u.Scheme {
+	case "https", "http":
+	case "ssh", "git":
+		u.Scheme = "https"
+		u.Host = u.Hostname()
+	default:
+		return ""
+	}
+	u.User = nil
+	u.Path = strings.TrimSuffix(u.Path, ".git")
+	return u.String()
+}
@@ -0,0 +1,246 @@
+package plugins
+
+import (
+	"github.com/jung-kurt/gofpdf"
+)
+
+const (
+	tableFontSize    = 10  // Default table font size in points
+	tableLineHeight  = 5   // Height of one text line in a cell in mm
+	tableCellPadding = 1.5 // Horizontal padding inside cells in mm
+)
+
+// TableElement renders structured rows as a bordered table. Columns share the
+// available width in proportion to their content unless widths are set, cells
+// wrap long text, and the header row is repeated after page breaks.
+//
+// Build tables with the chainable helpers:
+//
+//	table := NewTableElement().
+//		SetHeader("Key", "Default", "Description").
+//		AddRow("font-size", "12", "Base font size in points")
+type TableElement struct {
+	Header       []string
+	Rows         [][]string
+	ColumnWidths []float64 // Column widths in mm (empty = fit to content)
+	FontSize     float64
+}
+
+// NewTableElement creates an empty table.
+func NewTableElement() *TableElement {
+	return &TableElement{}
+}
+
+// SetHeader sets the header row, which is drawn in bold and repeated on each
+// page the table spans.
+func (t *TableElement) SetHeader(cells ...string) *TableElement {
+	t.Header = cells
+	return t
+}
+
+// AddRow appends a body row. Rows shorter than the widest row are padded with
+// empty cells.
+func (t *TableElement) AddRow(cells ...string) *TableElement {
+	t.Rows = append(t.Rows, cells)
+	return t
+}
+
+// SetColumnWidths fixes the width of each column in mm.
+func (t *TableElement) SetColumnWidths(widths ...float64) *TableElement {
+	t.ColumnWidths = widths
+	return t
+}
+
+// Columns returns the number of columns in the table.
+func (t *TableElement) Columns() int {
+	columns := len(t.Header)
+	for _, row := range t.Rows {
+		columns = max(columns, len(row))
+	}
+	return columns
+}
+
+func (t *TableElement) Render(pdf *gofpdf.Fpdf, ctx *RenderContext) error {
+	columns := t.Columns()
+	if columns == 0 {
+		return nil
+	}
+
+	fontSize := t.fontSize()
+	pdf.SetFont("Arial", "", fontSize)
+	widths := t.columnWidths(pdf, columns)
+
+	_, pageHeight := pdf.GetPageSize()
+	leftMargin, _, _, bottomMargin := pdf.GetMargins()
+
+	// Finish a line left open by a previous element, then start at the margin
+	if pdf.GetX() > leftMargin {
+		pdf.Ln(-1)
+	}
+	pdf.SetX(leftMargin)
+
+	heightOf := func(cells []string, style string) float64 {
+		pdf.SetFont("Arial", style, fontSize)
+		return t.rowHeight(pdf, cells, widths)
+	}
+	remaining := func() float64 {
+		return pageHeight - bottomMargin - pdf.GetY()
+	}
+	newPage := func() {
+		pdf.AddPage()
+		pdf.SetX(leftMargin)
+	}
+
+	drawRow := func(cells []string, style string) {
+		height := heightOf(cells, style)
+		x, y := pdf.GetXY()
+		for i, width := range widths {
+			pdf.Rect(x, y, width, height, "D")
+			if i < len(cells) {
+				pdf.SetXY(x+tableCellPadding, y)
+				pdf.MultiCell(width-2*tableCellPadding, tableLineHeight, cells[i], "", "L", false)
+			}
+			x += width
+		}
+		pdf.SetXY(leftMargin, y+height)
+	}
+	drawHeader := func() {
+		if len(t.Header) > 0 {
+			drawRow(t.Header, "B")
+		}
+	}
+
+	// The header stays with the first row, and rows that do not fit move to
+	// the next page under a repeated header
+	headerHeight := 0.0
+	if len(t.Header) > 0 {
+		headerHeight = heightOf(t.Header, "B")
+	}
+	for i, row := range t.Rows {
+		rowHeight := heightOf(row, "")
+		switch {
+		case i == 0:
+			if headerHeight+rowHeight > remaining() {
+				newPage()
+			}
+			drawHeader()
+		case rowHeight > remaining():
+			newPage()
+			drawHeader()
+		}
+		drawRow(row, "")
+	}
+	if len(t.Rows) == 0 {
+		drawHeader()
+	}
+
+	pdf.SetFont("Arial", "", fontSize)
+	pdf.Ln(3)
+	return nil
+}
+
+// columnWidths returns fixed widths when set, otherwise it shares the width
+// between the margins in proportion to the widest cell of each column. Every
+// column gets at least half an equal share so short columns stay readable.
+func (t *TableElement) columnWidths(pdf *gofpdf.Fpdf, columns int) []float64 {
+	if len(t.ColumnWidths) >= columns {
+		return t.ColumnWidths[:columns]
+	}
+
+	pageWidth, _ := pdf.GetPageSize()
+	leftMargin, _, rightMargin, _ := pdf.GetMargins()
+	available := pageWidth - leftMargin - rightMargin
+
+	natural := make([]float64, columns)
+	measure := func(cells []string) {
+		for i, cell := range cells {
+			natural[i] = max(natural[i], pdf.GetStringWidth(cell)+2*tableCellPadding)
+		}
+	}
+	pdf.SetFont("Arial", "B", t.fontSize())
+	measure(t.Header)
+	pdf.SetFont("Arial", "", t.fontSize())
+	for _, row := range t.Rows {
+		measure(row)
+	}
+
+	// Columns whose share would fall below the minimum get the minimum; the
+	// others split the remaining width in proportion to their content
+	minWidth := available / float64(columns) / 2
+	atMinimum := make([]bool, columns)
+	for {
+		remaining, flexible := available, 0.0
+		for i, w := range natural {
+			if atMinimum[i] {
+				remaining -= minWidth
+			} else {
+				flexible += w
+			}
+		}
+
+		changed := false
+		for i, w := range natural {
+			if !atMinimum[i] && w*remaining/flexible < minWidth {
+				atMinimum[i] = true
+				changed = true
+			}
+		}
+		if changed {
+			continue
+		}
+
+		widths := make([]float64, columns)
+		for i, w := range natural {
+			if atMinimum[i] {
+				widths[i] = minWidth
+			} else {
+				widths[i] = w * remaining / flexible
+			}
+		}
+		return widths
+	}
+}
+
+// rowHeight returns the height needed by the tallest cell of a row in the
+// current font.
+func (t *TableElement) rowHeight(pdf *gofpdf.Fpdf, cells []string, widths []float64) float64 {
+	lines := 1
+	for i, cell := range cells {
+		if i >= len(widths) {
+			break
+		}
+		// SplitLines measures bytes like MultiCell; SplitText panics on
+		// characters outside the core font tables
+		lines = max(lines, len(pdf.SplitLines([]byte(cell), widths[i]-2*tableCellPadding)))
+	}
+	return float64(lines) * tableLineHeight
+}
+
+func (t *TableElement) fontSize() float64 {
+	if t.FontSize == 0 {
+		return tableFontSize
+	}
+	return t.FontSize
+}
+
+// Height returns the height of the table assuming no cell wraps.
+func (t *TableElement) Height() float64 {
+	rows := len(t.Rows)
+	if len(t.Header) > 0 {
+		rows++
+	}
+	return float64(rows) * tableLineHeight
+}
+
+// Width returns the total fixed column width, or 0 for the full text width.
+func (t *TableElement) Width() float64 {
+	return sumWidths(t.ColumnWidths)
+}
+
+func sumWidths(values []float64) float64 {
+	total := 0.0
+	for _, v := range values {
+		total += v
+	}
+	return total
+}
@@ -0,0 +1,138 @@
+package plugins
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/jung-kurt/gofpdf"
+)
+
+// newTablePDF returns an uncompressed A4 document so tests can search the
+// emitted text.
+func newTablePDF() *gofpdf.Fpdf {
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.SetCompression(false)
+	pdf.SetMargins(15, 20, 15)
+	pdf.SetAutoPageBreak(true, 20)
+	pdf.AddPage()
+	return pdf
+}
+
+func renderTable(t *testing.T, pdf *gofpdf.Fpdf, table *TableElement) string {
+	t.Helper()
+	if err := table.Render(pdf, &RenderContext{PDF: pdf}); err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		t.Fatalf("Output failed: %v", err)
+	}
+	return buf.String()
+}
+
+func TestTableElement_Builder(t *testing.T) {
+	table := NewTableElement().
+		SetHeader("Key", "Default").
+		AddRow("font-size", "12", "extra").
+		AddRow("page-size")
+
+	if table.Columns() != 3 {
+		t.Errorf("Columns() = %d, want 3", table.Columns())
+	}
+	if len(table.Rows) != 2 {
+		t.Errorf("expected 2 rows, got %d", len(table.Rows))
+	}
+	if table.Height() != 3*tableLineHeight {
+		t.Errorf("Height() = %.1f, want %.1f", table.Height(), 3.0*tableLineHeight)
+	}
+
+	table.SetColumnWidths(40, 20, 60)
+	if table.Width() != 120 {
+		t.Errorf("Width() = %.1f, want 120", table.Width())
+	}
+}
+
+func TestTableElement_Render(t *testing.T) {
+	pdf := newTablePDF()
+	table := NewTableElement().
+		SetHeader("Key", "Description").
+		AddRow("font-size", "Base font size in points").
+		AddRow("margin-top", strings.Repeat("A long description that has to wrap inside its cell. ", 5))
+
+	content := renderTable(t, pdf, table)
+	for _, want := range []string{"(Key)", "(Description)", "(font-size)", "(margin-top)"} {
+		if !strings.Contains(content, want) {
+			t.Errorf("expected %s in rendered table", want)
+		}
+	}
+	if pdf.PageNo() != 1 {
+		t.Errorf("small table should fit on one page, got %d pages", pdf.PageNo())
+	}
+}
+
+func TestTableElement_NonLatin1Cells(t *testing.T) {
+	pdf := newTablePDF()
+	table := NewTableElement().
+		SetHeader("Symbol", "Meaning").
+		AddRow("\u2192", "Leads to \u2014 see next step")
+
+	// Must not panic on characters missing from the core font width tables
+	renderTable(t, pdf, table)
+}
+
+func TestTableElement_RepeatsHeaderAcrossPages(t *testing.T) {
+	pdf := newTablePDF()
+	table := NewTableElement().SetHeader("Name", "Value")
+	for i := 0; i < 80; i++ {
+		table.AddRow("row", "value")
+	}
+
+	content := renderTable(t, pdf, table)
+	if pdf.PageNo() < 2 {
+		t.Fatalf("expected the table to span several pages, got %d", pdf.PageNo())
+	}
+	if got := strings.Count(content, "(Name)"); got != pdf.PageNo() {
+		t.Errorf("header drawn %d times, want once per page (%d)", got, pdf.PageNo())
+	}
+}
+
+func TestTableElement_ColumnWidths(t *testing.T) {
+	pdf := newTablePDF()
+	pageWidth, _ := pdf.GetPageSize()
+	available := pageWidth - 15 - 15
+
+	table := NewTableElement().
+		SetHeader("ID", "Description").
+		AddRow("1", strings.Repeat("wide content ", 10))
+
+	pdf.SetFont("Arial", "", tableFontSize)
+	widths := table.columnWidths(pdf, table.Columns())
+	if math.Abs(sumWidths(widths)-available) > 1e-6 {
+		t.Errorf("widths add up to %.2f, want %.2f", sumWidths(widths), available)
+	}
+	if widths[1] <= widths[0] {
+		t.Errorf("wider content should get the wider column: %v", widths)
+	}
+	if widths[0] < available/4-1e-6 {
+		t.Errorf("narrow column should keep a minimum share, got %.2f", widths[0])
+	}
+
+	table.SetColumnWidths(30, 50)
+	widths = table.columnWidths(pdf, table.Columns())
+	if widths[0] != 30 || widths[1] != 50 {
+		t.Errorf("fixed widths not used: %v", widths)
+	}
+}
+
+func TestTableElement_Empty(t *testing.T) {
+	pdf := newTablePDF()
+	y := pdf.GetY()
+	if err := NewTableElement().Render(pdf, &RenderContext{PDF: pdf}); err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	if pdf.GetY() != y {
+		t.Error("empty table should not move the cursor")
+	}
+}
@@ -0,0 +1,109 @@
+// Package profile records runtime profiles of a conversion run so they can be
+// attached to performance bug reports. Profiles are written in the formats
+// read by "go tool pprof" and "go tool trace".
+package profile
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"runtime/pprof"
+	"runtime/trace"
+)
+
+// Kind selects the profile to record.
+type Kind string
+
+const (
+	// CPU samples where conversion time is spent.
+	CPU Kind = "cpu"
+	// Memory records heap allocations, written when the session stops.
+	Memory Kind = "mem"
+	// Trace records an execution trace of goroutines, GC and syscalls.
+	Trace Kind = "trace"
+)
+
+// ValidKinds lists the accepted profile kinds.
+var ValidKinds = []string{string(CPU), string(Memory), string(Trace)}
+
+// IsValidKind checks if the given profile kind is valid.
+func IsValidKind(kind string) bool {
+	for _, valid := range ValidKinds {
+		if valid == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// DefaultPath returns the file name used when no output path is given.
+func DefaultPath(kind Kind) string {
+	if kind == Trace {
+		return "md-to-pdf.trace.out"
+	}
+	return fmt.Sprintf("md-to-pdf.%s.pprof", kind)
+}
+
+// Session is a running profile. Stop must be called to write it out.
+type Session struct {
+	kind Kind
+	path string
+	file *os.File
+}
+
+// Start begins recording a profile of the given kind to path. An empty path
+// uses DefaultPath.
+func Start(kind Kind, path string) (*Session, error) {
+	if !IsValidKind(string(kind)) {
+		return nil, fmt.Errorf("invalid profile kind %q (must be one of: cpu, mem, trace)", kind)
+	}
+	if path == "" {
+		path = DefaultPath(kind)
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600) // #nosec G304 - path comes from user CLI input
+	if err != nil {
+		return nil, fmt.Errorf("failed to create profile file: %w", err)
+	}
+
+	switch kind {
+	case CPU:
+		err = pprof.StartCPUProfile(file)
+	case Trace:
+		err = trace.Start(file)
+	}
+	if err != nil {
+		_ = file.Close()
+		return nil, fmt.Errorf("failed to start %s profile: %w", kind, err)
+	}
+
+	return &Session{kind: kind, path: path, file: file}, nil
+}
+
+// Path returns the file the profile is written to.
+func (s *Session) Path() string {
+	return s.path
+}
+
+// Stop ends the recording and closes the profile file.
+func (s *Session) Stop() error {
+	var err error
+	switch s.kind {
+	case CPU:
+		pprof.StopCPUProfile()
+	case Trace:
+		trace.Stop()
+	case Memory:
+		// Collect garbage first so the profile reflects live memory
+		runtime.GC()
+		err = pprof.WriteHeapProfile(s.file)
+	}
+
+	if closeErr := s.file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write %s profile: %w", s.kind, err)
+	}
+	return nil
+}
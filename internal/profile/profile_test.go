@@ -0,0 +1,66 @@
+package profile
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestStartStop(t *testing.T) {
+	for _, kind := range []Kind{CPU, Memory, Trace} {
+		t.Run(string(kind), func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "profile.out")
+
+			session, err := Start(kind, path)
+			if err != nil {
+				t.Fatalf("Start failed: %v", err)
+			}
+			if session.Path() != path {
+				t.Errorf("Path() = %q, want %q", session.Path(), path)
+			}
+
+			// Do some work worth recording
+			var data [][]byte
+			for i := 0; i < 1000; i++ {
+				data = append(data, make([]byte, 1024))
+			}
+			_ = data
+
+			if err := session.Stop(); err != nil {
+				t.Fatalf("Stop failed: %v", err)
+			}
+
+			info, err := os.Stat(path)
+			if err != nil {
+				t.Fatalf("profile file not written: %v", err)
+			}
+			if info.Size() == 0 {
+				t.Error("profile file is empty")
+			}
+		})
+	}
+}
+
+func TestStartInvalidKind(t *testing.T) {
+	_, err := Start(Kind("block"), filepath.Join(t.TempDir(), "profile.out"))
+	if err == nil {
+		t.Fatal("expected error for invalid profile kind")
+	}
+	if !strings.Contains(err.Error(), "invalid profile kind") {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestDefaultPath(t *testing.T) {
+	tests := map[Kind]string{
+		CPU:    "md-to-pdf.cpu.pprof",
+		Memory: "md-to-pdf.mem.pprof",
+		Trace:  "md-to-pdf.trace.out",
+	}
+	for kind, want := range tests {
+		if got := DefaultPath(kind); got != want {
+			t.Errorf("DefaultPath(%q) = %q, want %q", kind, got, want)
+		}
+	}
+}
@@ -0,0 +1,271 @@
+package qrcode
+
+// matrix is a symbol under construction. Function modules (finder, timing,
+// alignment, format and version areas) are not touched by data or masks.
+type matrix struct {
+	size     int
+	dark     []bool
+	function []bool
+}
+
+func newMatrix(size int) *matrix {
+	return &matrix{
+		size:     size,
+		dark:     make([]bool, size*size),
+		function: make([]bool, size*size),
+	}
+}
+
+func (m *matrix) clone() *matrix {
+	return &matrix{
+		size:     m.size,
+		dark:     append([]bool(nil), m.dark...),
+		function: m.function,
+	}
+}
+
+func (m *matrix) get(x, y int) bool {
+	return m.dark[y*m.size+x]
+}
+
+// setFunction sets a function module.
+func (m *matrix) setFunction(x, y int, dark bool) {
+	m.dark[y*m.size+x] = dark
+	m.function[y*m.size+x] = true
+}
+
+func (m *matrix) drawFunctionPatterns(version int) {
+	for i := 0; i < m.size; i++ {
+		m.setFunction(6, i, i%2 == 0)
+		m.setFunction(i, 6, i%2 == 0)
+	}
+
+	m.drawFinder(3, 3)
+	m.drawFinder(m.size-4, 3)
+	m.drawFinder(3, m.size-4)
+
+	centers := alignmentCenters[version-1]
+	last := len(centers) - 1
+	for i, cx := range centers {
+		for j, cy := range centers {
+			// Skip the three positions that overlap finder patterns
+			if (i == 0 && j == 0) || (i == 0 && j == last) || (i == last && j == 0) {
+				continue
+			}
+			m.drawAlignment(cx, cy)
+		}
+	}
+
+	// Reserve the format areas; the real bits are drawn after masking
+	m.drawFormat(Low, 0)
+	m.drawVersion(version)
+}
+
+// drawFinder draws a finder pattern and its separator centered on (cx, cy).
+func (m *matrix) drawFinder(cx, cy int) {
+	for dy := -4; dy <= 4; dy++ {
+		for dx := -4; dx <= 4; dx++ {
+			x, y := cx+dx, cy+dy
+			if x < 0 || x >= m.size || y < 0 || y >= m.size {
+				continue
+			}
+			dist := max(abs(dx), abs(dy))
+			m.setFunction(x, y, dist != 2 && dist != 4)
+		}
+	}
+}
+
+func (m *matrix) drawAlignment(cx, cy int) {
+	for dy := -2; dy <= 2; dy++ {
+		for dx := -2; dx <= 2; dx++ {
+			m.setFunction(cx+dx, cy+dy, max(abs(dx), abs(dy)) != 1)
+		}
+	}
+}
+
+// drawFormat draws both copies of the format information and the dark module.
+func (m *matrix) drawFormat(level Level, mask int) {
+	bits := formatBits(level, mask)
+	bit := func(i int) bool { return (bits>>i)&1 == 1 }
+
+	for i := 0; i <= 5; i++ {
+		m.setFunction(8, i, bit(i))
+	}
+	m.setFunction(8, 7, bit(6))
+	m.setFunction(8, 8, bit(7))
+	m.setFunction(7, 8, bit(8))
+	for i := 9; i < 15; i++ {
+		m.setFunction(14-i, 8, bit(i))
+	}
+
+	for i := 0; i < 8; i++ {
+		m.setFunction(m.size-1-i, 8, bit(i))
+	}
+	for i := 8; i < 15; i++ {
+		m.setFunction(8, m.size-15+i, bit(i))
+	}
+	m.setFunction(8, m.size-8, true)
+}
+
+// formatBits returns the 15 format information bits for level and mask.
+func formatBits(level Level, mask int) int {
+	data := formatLevelBits[level]<<3 | mask
+	rem := data
+	for i := 0; i < 10; i++ {
+		rem = (rem << 1) ^ ((rem >> 9) * 0x537)
+	}
+	return (data<<10 | rem) ^ 0x5412
+}
+
+// drawVersion draws both copies of the version information (version 7+).
+func (m *matrix) drawVersion(version int) {
+	if version < 7 {
+		return
+	}
+	bits := versionBits(version)
+	for i := 0; i < 18; i++ {
+		dark := (bits>>i)&1 == 1
+		a, b := m.size-11+i%3, i/3
+		m.setFunction(a, b, dark)
+		m.setFunction(b, a, dark)
+	}
+}
+
+// versionBits returns the 18 version information bits.
+func versionBits(version int) int {
+	rem := version
+	for i := 0; i < 12; i++ {
+		rem = (rem << 1) ^ ((rem >> 11) * 0x1F25)
+	}
+	return version<<12 | rem
+}
+
+// drawCodewords places the codewords in the two-module wide zigzag columns,
+// starting at the bottom right corner.
+func (m *matrix) drawCodewords(codewords []byte) {
+	i := 0
+	for right := m.size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5 // Skip the vertical timing pattern
+		}
+		upward := (right+1)&2 == 0
+		for vert := 0; vert < m.size; vert++ {
+			y := vert
+			if upward {
+				y = m.size - 1 - vert
+			}
+			for j := 0; j < 2; j++ {
+				x := right - j
+				if m.function[y*m.size+x] || i >= len(codewords)*8 {
+					continue
+				}
+				m.dark[y*m.size+x] = (codewords[i/8]>>(7-i%8))&1 == 1
+				i++
+			}
+		}
+	}
+}
+
+// maskFuncs are the eight data mask patterns, indexed by mask number.
+var maskFuncs = [8]func(x, y int) bool{
+	func(x, y int) bool { return (x+y)%2 == 0 },
+	func(x, y int) bool { return y%2 == 0 },
+	func(x, y int) bool { return x%3 == 0 },
+	func(x, y int) bool { return (x+y)%3 == 0 },
+	func(x, y int) bool { return (x/3+y/2)%2 == 0 },
+	func(x, y int) bool { return x*y%2+x*y%3 == 0 },
+	func(x, y int) bool { return (x*y%2+x*y%3)%2 == 0 },
+	func(x, y int) bool { return ((x+y)%2+x*y%3)%2 == 0 },
+}
+
+func (m *matrix) applyMask(mask int) {
+	for y := 0; y < m.size; y++ {
+		for x := 0; x < m.size; x++ {
+			if !m.function[y*m.size+x] && maskFuncs[mask](x, y) {
+				m.dark[y*m.size+x] = !m.dark[y*m.size+x]
+			}
+		}
+	}
+}
+
+// finderLike is the 1:1:3:1:1 pattern with four light modules on one side
+// that rule 3 of the mask evaluation penalizes.
+var finderLike = [2][11]bool{
+	{true, false, true, true, true, false, true, false, false, false, false},
+	{false, false, false, false, true, false, true, true, true, false, true},
+}
+
+// penalty scores the symbol with the four rules of the specification; the
+// mask with the lowest score is used.
+func (m *matrix) penalty() int {
+	total := 0
+	for _, horizontal := range []bool{true, false} {
+		at := func(i, j int) bool {
+			if horizontal {
+				return m.get(j, i)
+			}
+			return m.get(i, j)
+		}
+		for i := 0; i < m.size; i++ {
+			// Rule 1: runs of five or more modules of the same color
+			run := 1
+			for j := 1; j < m.size; j++ {
+				if at(i, j) == at(i, j-1) {
+					run++
+					continue
+				}
+				if run >= 5 {
+					total += run - 2
+				}
+				run = 1
+			}
+			if run >= 5 {
+				total += run - 2
+			}
+
+			// Rule 3: patterns that look like finder patterns
+			for j := 0; j+11 <= m.size; j++ {
+				for _, pattern := range finderLike {
+					match := true
+					for k, dark := range pattern {
+						if at(i, j+k) != dark {
+							match = false
+							break
+						}
+					}
+					if match {
+						total += 40
+					}
+				}
+			}
+		}
+	}
+
+	// Rule 2: 2x2 blocks of the same color
+	dark := 0
+	for y := 0; y < m.size; y++ {
+		for x := 0; x < m.size; x++ {
+			if m.get(x, y) {
+				dark++
+			}
+			if x+1 < m.size && y+1 < m.size {
+				c := m.get(x, y)
+				if m.get(x+1, y) == c && m.get(x, y+1) == c && m.get(x+1, y+1) == c {
+					total += 3
+				}
+			}
+		}
+	}
+
+	// Rule 4: deviation of the dark share from 50% in steps of 5%
+	cells := m.size * m.size
+	total += 10 * (abs(dark*20-cells*10) / cells)
+	return total
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
@@ -0,0 +1,196 @@
+// Package qrcode encodes short byte strings, such as URLs, as QR codes
+// (ISO/IEC 18004) in byte mode. Versions 1 to 10 are supported, which holds
+// up to 271 bytes at the lowest error correction level.
+package qrcode
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Level is an error correction level.
+type Level int
+
+const (
+	Low      Level = iota // Recovers about 7% of the symbol
+	Medium                // Recovers about 15%
+	Quartile              // Recovers about 25%
+	High                  // Recovers about 30%
+)
+
+// ErrTooLong is returned for data that does not fit in a version 10 symbol.
+var ErrTooLong = errors.New("data too long for a QR code")
+
+// maxVersion is the largest supported symbol version.
+const maxVersion = 10
+
+// blockLayout describes the error correction blocks of one version and level:
+// codewords per block, then the number of blocks and data codewords of up
+// to two groups.
+type blockLayout struct {
+	ecc            int
+	blocks1, data1 int
+	blocks2, data2 int
+}
+
+// layouts is indexed by version-1, then by level.
+var layouts = [maxVersion][4]blockLayout{
+	{{7, 1, 19, 0, 0}, {10, 1, 16, 0, 0}, {13, 1, 13, 0, 0}, {17, 1, 9, 0, 0}},
+	{{10, 1, 34, 0, 0}, {16, 1, 28, 0, 0}, {22, 1, 22, 0, 0}, {28, 1, 16, 0, 0}},
+	{{15, 1, 55, 0, 0}, {26, 1, 44, 0, 0}, {18, 2, 17, 0, 0}, {22, 2, 13, 0, 0}},
+	{{20, 1, 80, 0, 0}, {18, 2, 32, 0, 0}, {26, 2, 24, 0, 0}, {16, 4, 9, 0, 0}},
+	{{26, 1, 108, 0, 0}, {24, 2, 43, 0, 0}, {18, 2, 15, 2, 16}, {22, 2, 11, 2, 12}},
+	{{18, 2, 68, 0, 0}, {16, 4, 27, 0, 0}, {24, 4, 19, 0, 0}, {28, 4, 15, 0, 0}},
+	{{20, 2, 78, 0, 0}, {18, 4, 31, 0, 0}, {18, 2, 14, 4, 15}, {26, 4, 13, 1, 14}},
+	{{24, 2, 97, 0, 0}, {22, 2, 38, 2, 39}, {22, 4, 18, 2, 19}, {26, 4, 14, 2, 15}},
+	{{30, 2, 116, 0, 0}, {22, 3, 36, 2, 37}, {20, 4, 16, 4, 17}, {24, 4, 12, 4, 13}},
+	{{18, 2, 68, 2, 69}, {26, 4, 43, 1, 44}, {24, 6, 19, 2, 20}, {28, 6, 15, 2, 16}},
+}
+
+// alignmentCenters lists the alignment pattern coordinates of each version.
+var alignmentCenters = [maxVersion][]int{
+	nil,
+	{6, 18},
+	{6, 22},
+	{6, 26},
+	{6, 30},
+	{6, 34},
+	{6, 22, 38},
+	{6, 24, 42},
+	{6, 26, 46},
+	{6, 28, 50},
+}
+
+// formatLevelBits are the error correction bits of the format information.
+var formatLevelBits = [4]int{1, 0, 3, 2}
+
+func (l blockLayout) dataCodewords() int {
+	return l.blocks1*l.data1 + l.blocks2*l.data2
+}
+
+// Code is an encoded QR symbol.
+type Code struct {
+	Version int
+	Size    int // Modules per side, without the quiet zone
+	Mask    int
+	modules []bool
+}
+
+// Dark reports whether the module at column x and row y is dark.
+func (c *Code) Dark(x, y int) bool {
+	return c.modules[y*c.Size+x]
+}
+
+// Encode encodes data in the smallest version that fits at the given level.
+func Encode(data []byte, level Level) (*Code, error) {
+	if level < Low || level > High {
+		return nil, fmt.Errorf("invalid error correction level %d", level)
+	}
+	for version := 1; version <= maxVersion; version++ {
+		layout := layouts[version-1][level]
+		if 4+countBits(version)+8*len(data) <= 8*layout.dataCodewords() {
+			codewords := addErrorCorrection(encodeData(data, version, layout), layout)
+			return build(version, level, codewords), nil
+		}
+	}
+	return nil, ErrTooLong
+}
+
+// countBits returns the width of the character count field in byte mode.
+func countBits(version int) int {
+	if version <= 9 {
+		return 8
+	}
+	return 16
+}
+
+// encodeData returns the data codewords: mode, count, data, terminator and
+// padding.
+func encodeData(data []byte, version int, layout blockLayout) []byte {
+	var bits bitBuffer
+	bits.append(0x4, 4) // Byte mode
+	bits.append(len(data), countBits(version))
+	for _, b := range data {
+		bits.append(int(b), 8)
+	}
+
+	capacity := 8 * layout.dataCodewords()
+	bits.append(0, min(4, capacity-len(bits)))
+	bits.append(0, (8-len(bits)%8)%8)
+	for pad := 0xEC; len(bits) < capacity; pad ^= 0xEC ^ 0x11 {
+		bits.append(pad, 8)
+	}
+	return bits.bytes()
+}
+
+// addErrorCorrection splits data into blocks, computes their error
+// correction codewords and interleaves the result.
+func addErrorCorrection(data []byte, layout blockLayout) []byte {
+	var blocks, eccBlocks [][]byte
+	offset := 0
+	for i := 0; i < layout.blocks1+layout.blocks2; i++ {
+		size := layout.data1
+		if i >= layout.blocks1 {
+			size = layout.data2
+		}
+		block := data[offset : offset+size]
+		offset += size
+		blocks = append(blocks, block)
+		eccBlocks = append(eccBlocks, reedSolomon(block, layout.ecc))
+	}
+
+	result := make([]byte, 0, len(data)+len(blocks)*layout.ecc)
+	for i := 0; i < max(layout.data1, layout.data2); i++ {
+		for _, block := range blocks {
+			if i < len(block) {
+				result = append(result, block[i])
+			}
+		}
+	}
+	for i := 0; i < layout.ecc; i++ {
+		for _, block := range eccBlocks {
+			result = append(result, block[i])
+		}
+	}
+	return result
+}
+
+// build places the function patterns and codewords and applies the mask
+// with the lowest penalty.
+func build(version int, level Level, codewords []byte) *Code {
+	size := 17 + 4*version
+	base := newMatrix(size)
+	base.drawFunctionPatterns(version)
+	base.drawCodewords(codewords)
+
+	var best *matrix
+	bestMask, bestPenalty := 0, -1
+	for mask := 0; mask < 8; mask++ {
+		m := base.clone()
+		m.applyMask(mask)
+		m.drawFormat(level, mask)
+		if penalty := m.penalty(); bestPenalty < 0 || penalty < bestPenalty {
+			best, bestMask, bestPenalty = m, mask, penalty
+		}
+	}
+	return &Code{Version: version, Size: size, Mask: bestMask, modules: best.dark}
+}
+
+// bitBuffer is a sequence of bits, most significant first.
+type bitBuffer []bool
+
+func (b *bitBuffer) append(value, count int) {
+	for i := count - 1; i >= 0; i-- {
+		*b = append(*b, (value>>i)&1 == 1)
+	}
+}
+
+func (b bitBuffer) bytes() []byte {
+	out := make([]byte, len(b)/8)
+	for i, bit := range b {
+		if bit {
+			out[i/8] |= 0x80 >> (i % 8)
+		}
+	}
+	return out
+}
@@ -0,0 +1,195 @@
+package qrcode
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestReedSolomon(t *testing.T) {
+	// "HELLO WORLD" as version 1-M, from the worked example in the
+	// specification tutorials
+	data := []byte{32, 91, 11, 120, 209, 114, 220, 77, 67, 64, 236, 17, 236, 17, 236, 17}
+	want := []byte{196, 35, 39, 119, 235, 215, 231, 226, 93, 23}
+	if got := reedSolomon(data, 10); !bytes.Equal(got, want) {
+		t.Errorf("reedSolomon() = %v, want %v", got, want)
+	}
+}
+
+func TestFormatAndVersionBits(t *testing.T) {
+	tests := []struct {
+		level Level
+		mask  int
+		want  int
+	}{
+		{Low, 0, 0b111011111000100},
+		{Medium, 0, 0b101010000010010},
+		{Quartile, 7, 0b010101111101101},
+		{High, 4, 0b000011101100010},
+	}
+	for _, tt := range tests {
+		if got := formatBits(tt.level, tt.mask); got != tt.want {
+			t.Errorf("formatBits(%d, %d) = %015b, want %015b", tt.level, tt.mask, got, tt.want)
+		}
+	}
+	if got := versionBits(7); got != 0b000111110010010100 {
+		t.Errorf("versionBits(7) = %018b", got)
+	}
+}
+
+// decode reads a symbol back: it checks the format information, removes
+// the mask, collects the codewords in placement order, de-interleaves them
+// and verifies every block's error correction before returning the data.
+func decode(t *testing.T, code *Code, level Level) []byte {
+	t.Helper()
+	m := newMatrix(code.Size)
+	m.drawFunctionPatterns(code.Version)
+
+	bits := 0
+	for i := 0; i <= 5; i++ {
+		if code.Dark(8, i) {
+			bits |= 1 << i
+		}
+	}
+	for i, p := range [][2]int{{8, 7}, {8, 8}, {7, 8}} {
+		if code.Dark(p[0], p[1]) {
+			bits |= 1 << (6 + i)
+		}
+	}
+	for i := 9; i < 15; i++ {
+		if code.Dark(14-i, 8) {
+			bits |= 1 << i
+		}
+	}
+	if bits != formatBits(level, code.Mask) {
+		t.Fatalf("format information %015b does not match level %d mask %d", bits, level, code.Mask)
+	}
+
+	var raw bitBuffer
+	for right := code.Size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		upward := (right+1)&2 == 0
+		for vert := 0; vert < code.Size; vert++ {
+			y := vert
+			if upward {
+				y = code.Size - 1 - vert
+			}
+			for j := 0; j < 2; j++ {
+				x := right - j
+				if m.function[y*code.Size+x] {
+					continue
+				}
+				raw = append(raw, code.Dark(x, y) != maskFuncs[code.Mask](x, y))
+			}
+		}
+	}
+	codewords := raw.bytes()
+
+	layout := layouts[code.Version-1][level]
+	count := layout.blocks1 + layout.blocks2
+	blocks := make([][]byte, count)
+	pos := 0
+	for i := 0; i < max(layout.data1, layout.data2); i++ {
+		for b := range blocks {
+			if b < layout.blocks1 && i >= layout.data1 || b >= layout.blocks1 && i >= layout.data2 {
+				continue
+			}
+			blocks[b] = append(blocks[b], codewords[pos])
+			pos++
+		}
+	}
+	ecc := make([][]byte, count)
+	for i := 0; i < layout.ecc; i++ {
+		for b := range ecc {
+			ecc[b] = append(ecc[b], codewords[pos])
+			pos++
+		}
+	}
+	var data []byte
+	for b, block := range blocks {
+		if !bytes.Equal(reedSolomon(block, layout.ecc), ecc[b]) {
+			t.Fatalf("block %d error correction does not match", b)
+		}
+		data = append(data, block...)
+	}
+
+	if data[0]>>4 != 0x4 {
+		t.Fatalf("mode %x is not byte mode", data[0]>>4)
+	}
+	var stream bitBuffer
+	for _, b := range data {
+		stream.append(int(b), 8)
+	}
+	read := func(from, n int) int {
+		v := 0
+		for _, bit := range stream[from : from+n] {
+			v <<= 1
+			if bit {
+				v |= 1
+			}
+		}
+		return v
+	}
+	length := read(4, countBits(code.Version))
+	start := 4 + countBits(code.Version)
+	out := make([]byte, length)
+	for i := range out {
+		out[i] = byte(read(start+8*i, 8))
+	}
+	return out
+}
+
+func TestEncode_RoundTrip(t *testing.T) {
+	tests := []struct {
+		data    string
+		level   Level
+		version int
+	}{
+		{"https://example.org", Low, 2},
+		{"https://a.example", Low, 1},
+		{"https://github.com/fredcamaral/md-to-pdf", Medium, 3},
+		{"https://example.com/" + strings.Repeat("a", 54), Quartile, 6},
+		{"https://example.com/" + strings.Repeat("b", 78), High, 9},
+		{strings.Repeat("x", 200), Medium, 10},
+	}
+	for _, tt := range tests {
+		code, err := Encode([]byte(tt.data), tt.level)
+		if err != nil {
+			t.Fatalf("Encode(%q) failed: %v", tt.data, err)
+		}
+		if code.Version != tt.version || code.Size != 17+4*tt.version {
+			t.Errorf("Encode(%q) chose version %d (size %d), want %d", tt.data, code.Version, code.Size, tt.version)
+		}
+		if got := decode(t, code, tt.level); string(got) != tt.data {
+			t.Errorf("decoded %q, want %q", got, tt.data)
+		}
+	}
+}
+
+func TestEncode_FinderPatterns(t *testing.T) {
+	code, err := Encode([]byte("finder"), Medium)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The top row of each finder pattern is seven dark modules followed by
+	// a light separator
+	for _, x0 := range []int{0, code.Size - 7} {
+		for x := x0; x < x0+7; x++ {
+			if !code.Dark(x, 0) {
+				t.Errorf("module (%d, 0) should be dark", x)
+			}
+		}
+	}
+	if code.Dark(7, 0) || code.Dark(code.Size-8, 0) {
+		t.Error("finder separators should be light")
+	}
+}
+
+func TestEncode_TooLong(t *testing.T) {
+	if _, err := Encode(bytes.Repeat([]byte("x"), 300), Low); !errors.Is(err, ErrTooLong) {
+		t.Errorf("expected ErrTooLong, got %v", err)
+	}
+}
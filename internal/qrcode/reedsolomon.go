@@ -0,0 +1,58 @@
+package qrcode
+
+// gfExp and gfLog are exponent and logarithm tables of GF(256) with the
+// QR code polynomial x^8 + x^4 + x^3 + x^2 + 1.
+var gfExp, gfLog [256]byte
+
+func init() {
+	x := 1
+	for i := 0; i < 255; i++ {
+		gfExp[i] = byte(x)
+		gfLog[x] = byte(i)
+		x <<= 1
+		if x&0x100 != 0 {
+			x ^= 0x11D
+		}
+	}
+	gfExp[255] = gfExp[0]
+}
+
+func gfMul(a, b byte) byte {
+	if a == 0 || b == 0 {
+		return 0
+	}
+	return gfExp[(int(gfLog[a])+int(gfLog[b]))%255]
+}
+
+// generator returns the coefficients of (x - a^0)(x - a^1)...(x - a^(n-1)),
+// highest degree first, without the leading 1.
+func generator(n int) []byte {
+	poly := make([]byte, n)
+	poly[n-1] = 1
+	root := byte(1)
+	for i := 0; i < n; i++ {
+		for j := 0; j < n; j++ {
+			poly[j] = gfMul(poly[j], root)
+			if j+1 < n {
+				poly[j] ^= poly[j+1]
+			}
+		}
+		root = gfMul(root, 2)
+	}
+	return poly
+}
+
+// reedSolomon returns the n error correction codewords of data.
+func reedSolomon(data []byte, n int) []byte {
+	gen := generator(n)
+	remainder := make([]byte, n)
+	for _, b := range data {
+		factor := b ^ remainder[0]
+		copy(remainder, remainder[1:])
+		remainder[n-1] = 0
+		for i := range remainder {
+			remainder[i] ^= gfMul(gen[i], factor)
+		}
+	}
+	return remainder
+}
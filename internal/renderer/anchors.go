@@ -0,0 +1,126 @@
+package renderer
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/fredcamaral/md-to-pdf/internal/outline"
+	"github.com/jung-kurt/gofpdf"
+	"github.com/yuin/goldmark/ast"
+)
+
+// spot is a position in the rendered document.
+type spot struct {
+	page int
+	y    float64
+}
+
+// anchorState resolves [links](#slug) to headings within the document.
+// Links may point forward: gofpdf link IDs are created when a link is
+// written and pointed at the heading once it is placed.
+type anchorState struct {
+	slugs  map[*ast.Heading]string // Unique slug of every heading
+	known  map[string]bool         // Slugs of every heading in the document
+	spots  map[string]spot         // Where each slug's heading was placed
+	ids    map[string]int          // gofpdf link IDs handed out per slug
+	warned map[string]bool         // Unknown slugs already reported
+}
+
+func (r *PDFRenderer) newAnchorState(node ast.Node, source []byte) anchorState {
+	state := anchorState{
+		slugs:  r.headingSlugs(node, source),
+		known:  make(map[string]bool),
+		spots:  make(map[string]spot),
+		ids:    make(map[string]int),
+		warned: make(map[string]bool),
+	}
+	for _, slug := range state.slugs {
+		state.known[slug] = true
+	}
+	return state
+}
+
+// headingSlugs gives every heading of the document its unique slug, in
+// document order, so duplicate titles such as an "Overview" in every
+// chapter become overview, overview-1, overview-2.
+func (r *PDFRenderer) headingSlugs(node ast.Node, source []byte) map[*ast.Heading]string {
+	slugs := make(map[*ast.Heading]string)
+	slugger := outline.NewSlugger()
+	_ = ast.Walk(node, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if heading, ok := n.(*ast.Heading); ok && entering {
+			slugs[heading] = slugger.Slug(r.extractTextFromNode(heading, source))
+		}
+		return ast.WalkContinue, nil
+	})
+	return slugs
+}
+
+// internalLink returns the slug of a link destination pointing into the
+// document ("#install"), or "" for other destinations.
+func internalLink(destination string) string {
+	if !strings.HasPrefix(destination, "#") {
+		return ""
+	}
+	return strings.TrimPrefix(destination, "#")
+}
+
+// linkDestination returns the destination to use for a link: unchanged for
+// external links and headings of the document, "" (plain text) with a
+// warning for anchors that match no heading.
+func (r *PDFRenderer) linkDestination(destination string) string {
+	slug := internalLink(destination)
+	if slug == "" || r.anchors.known[slug] {
+		return destination
+	}
+	if !r.anchors.warned[slug] {
+		r.anchors.warned[slug] = true
+		r.warn(fmt.Sprintf("link to #%s does not match any heading", slug))
+	}
+	return ""
+}
+
+// anchorLink returns the gofpdf link ID for a heading slug.
+func (r *PDFRenderer) anchorLink(pdf *gofpdf.Fpdf, slug string) int {
+	if id, ok := r.anchors.ids[slug]; ok {
+		return id
+	}
+	id := pdf.AddLink()
+	r.anchors.ids[slug] = id
+	if s, ok := r.anchors.spots[slug]; ok {
+		pdf.SetLink(id, s.y, s.page)
+	}
+	return id
+}
+
+// placeAnchor records that the heading with slug starts at the current
+// position. Slugs are unique, but a heading rendered twice keeps its first
+// position.
+func (r *PDFRenderer) placeAnchor(pdf *gofpdf.Fpdf, slug string) {
+	if _, ok := r.anchors.spots[slug]; ok {
+		return
+	}
+	s := spot{page: pdf.PageNo(), y: pdf.GetY()}
+	r.anchors.spots[slug] = s
+	if id, ok := r.anchors.ids[slug]; ok {
+		pdf.SetLink(id, s.y, s.page)
+	}
+}
+
+// linkTarget returns the gofpdf link ID of a destination pointing at a
+// heading, or the URL of any other destination, for drawing calls such as
+// ImageOptions that take either one. An empty destination gives neither.
+func (r *PDFRenderer) linkTarget(pdf *gofpdf.Fpdf, destination string) (int, string) {
+	if slug := internalLink(destination); slug != "" {
+		return r.anchorLink(pdf, slug), ""
+	}
+	return 0, destination
+}
+
+// writeLink writes text as a link to an external URL or a heading.
+func (r *PDFRenderer) writeLink(pdf *gofpdf.Fpdf, height float64, txt, destination string) {
+	if slug := internalLink(destination); slug != "" {
+		pdf.WriteLinkID(height, txt, r.anchorLink(pdf, slug))
+		return
+	}
+	pdf.WriteLinkString(height, txt, destination)
+}
@@ -0,0 +1,69 @@
+package renderer
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/fredcamaral/md-to-pdf/internal/parser"
+)
+
+func TestRenderInternalLinks(t *testing.T) {
+	markdown := "See [later](#later-section), [again](#later-section) and [nowhere](#nowhere).\n\n" +
+		"<!-- pagebreak -->\n\n## Later section\n"
+
+	renderer := NewPDFRenderer(defaultTestConfig(), defaultTestDocumentMetadata(), nil)
+	node, err := parser.NewMarkdownParser().Parse([]byte(markdown))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	buf, err := renderer.Render(node, []byte(markdown))
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+
+	// Link annotations are outside the page content streams
+	data := buf.Bytes()
+	if got := bytes.Count(data, []byte("/Dest [")); got != 2 {
+		t.Errorf("expected 2 links into the document, found %d", got)
+	}
+	if bytes.Contains(data, []byte("/URI")) {
+		t.Error("internal links should not be written as URIs")
+	}
+
+	warnings := renderer.Warnings()
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "#nowhere") {
+		t.Errorf("expected one warning about #nowhere, got %v", warnings)
+	}
+}
+
+func TestRender_DuplicateHeadingSlugs(t *testing.T) {
+	markdown := "# Guide\n\n<!-- toc -->\n\nSee [the second overview](#overview-1).\n\n" +
+		"## Overview\n\n<!-- pagebreak -->\n\n## Overview\n"
+
+	config := defaultTestConfig()
+	config.TOC.Enabled = true
+	renderer := NewPDFRenderer(config, defaultTestDocumentMetadata(), nil)
+	node, err := parser.NewMarkdownParser().Parse([]byte(markdown))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if _, err := renderer.Render(node, []byte(markdown)); err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+
+	headings := renderer.Headings()
+	var slugs []string
+	for _, h := range headings {
+		slugs = append(slugs, h.Slug)
+	}
+	if strings.Join(slugs, " ") != "guide overview overview-1" {
+		t.Errorf("slugs = %v, want guide overview overview-1", slugs)
+	}
+	if warnings := renderer.Warnings(); len(warnings) != 0 {
+		t.Errorf("the link to #overview-1 should resolve, got %v", warnings)
+	}
+	if spot := renderer.anchors.spots["overview-1"]; spot.page != headings[2].Page {
+		t.Errorf("#overview-1 points to page %d, want the second overview on page %d", spot.page, headings[2].Page)
+	}
+}
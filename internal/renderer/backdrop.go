@@ -0,0 +1,111 @@
+package renderer
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/gif"
+	"image/png"
+
+	"github.com/fredcamaral/md-to-pdf/internal/colorutil"
+)
+
+const (
+	// faintSamples is the most pixels sampled along each side of an image
+	faintSamples = 200
+	// faintTransparentShare is the share of transparent pixels above which an
+	// image counts as mostly transparent
+	faintTransparentShare = 0.5
+	// faintStrokeShare is the share of visible pixels that must be faint for
+	// the whole image to be
+	faintStrokeShare = 0.9
+	// faintContrast is the contrast with the page below which a pixel is faint
+	faintContrast = 1.5
+)
+
+var defaultPageColor = colorutil.Color{R: 255, G: 255, B: 255}
+
+// checkFaintImage looks for images that will barely show on the page: mostly
+// transparent ones drawn in light strokes, as exported for dark interfaces.
+// They are composited onto the configured image backdrop, or else reported
+// in a warning. Other images are returned as they are.
+func (r *PDFRenderer) checkFaintImage(destination string, data []byte, imageType string) ([]byte, string) {
+	var img image.Image
+	var err error
+	switch imageType {
+	case "PNG":
+		img, err = png.Decode(bytes.NewReader(data))
+	case "GIF":
+		img, err = gif.Decode(bytes.NewReader(data))
+	default:
+		// JPEG has no transparency
+		return data, imageType
+	}
+	if err != nil {
+		// Left for registerImage to report
+		return data, imageType
+	}
+	page := parseColorOr(r.config.Colors.Background, defaultPageColor)
+	if !isFaintImage(img, page) {
+		return data, imageType
+	}
+
+	backdrop, ok := parseOptionalColor(r.config.Colors.ImageBackdrop)
+	if !ok {
+		r.warn(fmt.Sprintf("image %s is mostly transparent with strokes too light to see on the page; set image-backdrop to place it on a color", destination))
+		return data, imageType
+	}
+	composited, err := compositeOnto(img, backdrop)
+	if err != nil {
+		r.warn(fmt.Sprintf("image %s is too light to see on the page and could not be placed on %s: %v", destination, backdrop.Hex(), err))
+		return data, imageType
+	}
+	return composited, "PNG"
+}
+
+// isFaintImage reports whether img is mostly transparent and what it draws
+// is too close to the page color to be seen.
+func isFaintImage(img image.Image, page colorutil.Color) bool {
+	bounds := img.Bounds()
+	stepX := max(bounds.Dx()/faintSamples, 1)
+	stepY := max(bounds.Dy()/faintSamples, 1)
+
+	var total, transparent, visible, faint int
+	for y := bounds.Min.Y; y < bounds.Max.Y; y += stepY {
+		for x := bounds.Min.X; x < bounds.Max.X; x += stepX {
+			total++
+			c := color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA)
+			if c.A < 26 { // Below 10% opacity
+				transparent++
+				continue
+			}
+			visible++
+			if colorutil.ContrastRatio(colorutil.Color{R: int(c.R), G: int(c.G), B: int(c.B)}, page) < faintContrast {
+				faint++
+			}
+		}
+	}
+	if total == 0 || visible == 0 {
+		return false
+	}
+	return float64(transparent) >= faintTransparentShare*float64(total) &&
+		float64(faint) >= faintStrokeShare*float64(visible)
+}
+
+// compositeOnto draws img over a background of color c and encodes the
+// result as an opaque PNG.
+func compositeOnto(img image.Image, c colorutil.Color) ([]byte, error) {
+	bounds := img.Bounds()
+	out := image.NewRGBA(bounds)
+	background := color.RGBA{R: uint8(c.R), G: uint8(c.G), B: uint8(c.B), A: 255}
+	draw.Draw(out, bounds, &image.Uniform{C: background}, image.Point{}, draw.Src)
+	draw.Draw(out, bounds, img, bounds.Min, draw.Over)
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, out); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,93 @@
+package renderer
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/fredcamaral/md-to-pdf/internal/colorutil"
+)
+
+// createStrokeImage returns a transparent image crossed by a horizontal
+// stroke of color c, as diagrams exported for dark interfaces are.
+func createStrokeImage(c color.Color) image.Image {
+	img := image.NewNRGBA(image.Rect(0, 0, 40, 40))
+	for y := 18; y < 22; y++ {
+		for x := 0; x < 40; x++ {
+			img.Set(x, y, c)
+		}
+	}
+	return img
+}
+
+func TestIsFaintImage(t *testing.T) {
+	white := colorutil.Color{R: 255, G: 255, B: 255}
+	dark := colorutil.Color{R: 30, G: 30, B: 30}
+	tests := []struct {
+		name string
+		img  image.Image
+		page colorutil.Color
+		want bool
+	}{
+		{"light strokes on white page", createStrokeImage(color.NRGBA{R: 240, G: 240, B: 240, A: 255}), white, true},
+		{"dark strokes on white page", createStrokeImage(color.NRGBA{A: 255}), white, false},
+		{"light strokes on dark page", createStrokeImage(color.NRGBA{R: 240, G: 240, B: 240, A: 255}), dark, false},
+		{"opaque white image", createTestPNG(10, 10), white, false},
+		{"fully transparent image", image.NewNRGBA(image.Rect(0, 0, 10, 10)), white, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isFaintImage(tt.img, tt.page); got != tt.want {
+				t.Errorf("isFaintImage() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRender_FaintImage(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writePNG(&buf, createStrokeImage(color.White)); err != nil {
+		t.Fatal(err)
+	}
+	imagePath := filepath.Join(t.TempDir(), "dark-ui.png")
+	if err := os.WriteFile(imagePath, buf.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	node, source := parseMarkdown("![diagram](" + imagePath + ")\n")
+
+	renderer := NewPDFRenderer(defaultTestConfig(), defaultTestDocumentMetadata(), nil)
+	if _, err := renderer.Render(node, source); err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	warnings := renderer.Warnings()
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "image-backdrop") {
+		t.Errorf("expected a warning suggesting image-backdrop, got %v", warnings)
+	}
+
+	config := defaultTestConfig()
+	config.Colors.ImageBackdrop = "#333333"
+	renderer = NewPDFRenderer(config, defaultTestDocumentMetadata(), nil)
+	if _, err := renderer.Render(node, source); err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	if warnings := renderer.Warnings(); len(warnings) != 0 {
+		t.Errorf("an image placed on the backdrop should not warn, got %v", warnings)
+	}
+
+	data, imageType, err := renderer.loadImage(imagePath)
+	if err != nil || imageType != "PNG" {
+		t.Fatalf("loadImage() = %q, %v", imageType, err)
+	}
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r, g, b, a := img.At(0, 0).RGBA(); a != 0xffff || r>>8 != 0x33 || g>>8 != 0x33 || b>>8 != 0x33 {
+		t.Errorf("transparent pixels should show the backdrop, got %v", img.At(0, 0))
+	}
+}
@@ -0,0 +1,78 @@
+package renderer
+
+import (
+	"github.com/jung-kurt/gofpdf"
+)
+
+const (
+	// bannerPadding is the space in mm above and below the banner text
+	// inside its band.
+	bannerPadding = 1.0
+
+	// bannerLineHeight is the banner line height relative to its font size.
+	bannerLineHeight = 1.2
+)
+
+// BannerConfig stamps a classification banner in a band along the top and
+// bottom edges of every page. The band sits in the page margin, above the
+// header and below the footer.
+type BannerConfig struct {
+	Text       string // Banner text, e.g. "CONFIDENTIAL", with the header variables
+	Position   string // "top", "bottom" or "both" (default)
+	Color      string // Text color (defaults to the body text color)
+	Background string // Band color, or "none" (default)
+}
+
+// bannerEdges reports whether the banner is stamped on the top and on the
+// bottom edge of pages.
+func (r *PDFRenderer) bannerEdges() (top, bottom bool) {
+	banner := r.config.Banner
+	if banner.Text == "" {
+		return false, false
+	}
+	switch banner.Position {
+	case "top":
+		return true, false
+	case "bottom":
+		return false, true
+	default:
+		return true, true
+	}
+}
+
+// renderBanner stamps the banner on the top or bottom edge of the current
+// page: bold, centered across the page width on its band. The PDF state
+// (position, font, colors) is restored afterwards.
+func (r *PDFRenderer) renderBanner(pdf *gofpdf.Fpdf, top bool) {
+	banner := r.config.Banner
+
+	x, y := pdf.GetXY()
+	textR, textG, textB := pdf.GetTextColor()
+	fillR, fillG, fillB := pdf.GetFillColor()
+
+	size := r.config.FontSize
+	pdf.SetFont(r.config.FontFamily, "B", size)
+	lineHeight := pdf.PointToUnitConvert(size) * bannerLineHeight
+	bandHeight := lineHeight + 2*bannerPadding
+
+	pageWidth, pageHeight := pdf.GetPageSize()
+	bandY := 0.0
+	if !top {
+		bandY = pageHeight - bandHeight
+	}
+	if background, ok := parseOptionalColor(banner.Background); ok {
+		pdf.SetFillColor(background.R, background.G, background.B)
+		pdf.Rect(0, bandY, pageWidth, bandHeight, "F")
+	}
+
+	color := parseColorOr(banner.Color, r.textColor())
+	pdf.SetTextColor(color.R, color.G, color.B)
+	translate := pdf.UnicodeTranslatorFromDescriptor("")
+	pdf.SetXY(0, bandY+bannerPadding)
+	pdf.CellFormat(pageWidth, lineHeight, translate(r.expandTemplate(banner.Text, pdf.PageNo())), "", 0, "C", false, 0, "")
+
+	pdf.SetFillColor(fillR, fillG, fillB)
+	pdf.SetTextColor(textR, textG, textB)
+	pdf.SetFont(r.config.FontFamily, "", r.config.FontSize)
+	pdf.SetXY(x, y)
+}
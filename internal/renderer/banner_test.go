@@ -0,0 +1,91 @@
+package renderer
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRender_BannerOnEveryPage(t *testing.T) {
+	config := defaultTestConfig()
+	config.Banner = BannerConfig{Text: "CONFIDENTIAL", Position: "both", Background: "none"}
+
+	node, source := parseMarkdown(strings.Repeat("Paragraph text that fills the page.\n\n", 60))
+	buf, err := NewPDFRenderer(config, nil, nil).Render(node, source)
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+
+	pages := strings.Count(buf.String(), "/Type /Page\n")
+	if pages < 2 {
+		t.Fatalf("test document should span pages, got %d", pages)
+	}
+	if got := strings.Count(pdfContent(t, buf), "(CONFIDENTIAL)"); got != 2*pages {
+		t.Errorf("banner drawn %d times on %d pages, want top and bottom of each", got, pages)
+	}
+}
+
+func TestRender_BannerPosition(t *testing.T) {
+	for _, tt := range []struct {
+		position string
+		want     int
+	}{
+		{"top", 1},
+		{"bottom", 1},
+		{"both", 2},
+	} {
+		t.Run(tt.position, func(t *testing.T) {
+			config := defaultTestConfig()
+			config.Banner = BannerConfig{Text: "Page {page} of {pages}", Position: tt.position, Background: "none"}
+
+			node, source := parseMarkdown("Body")
+			buf, err := NewPDFRenderer(config, nil, nil).Render(node, source)
+			if err != nil {
+				t.Fatalf("Render failed: %v", err)
+			}
+			if got := strings.Count(pdfContent(t, buf), "(Page 1 of 1)"); got != tt.want {
+				t.Errorf("banner drawn %d times, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRender_BannerColors(t *testing.T) {
+	config := defaultTestConfig()
+	config.Banner = BannerConfig{Text: "SECRET", Position: "top", Color: "#ffffff", Background: "#c00000"}
+
+	node, source := parseMarkdown("Body")
+	buf, err := NewPDFRenderer(config, nil, nil).Render(node, source)
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	content := pdfContent(t, buf)
+	if !strings.Contains(content, "0.753 0.000 0.000 rg") {
+		t.Error("the band should be filled with the banner background")
+	}
+	if !strings.Contains(content, "1.000 g") {
+		t.Error("the banner text should use the banner color")
+	}
+}
+
+func TestRender_BannerDoesNotMoveBodyCursor(t *testing.T) {
+	config := defaultTestConfig()
+	withBanner := *config
+	withBanner.Banner = BannerConfig{Text: "CONFIDENTIAL", Position: "both", Background: "black", Color: "white"}
+
+	node, source := parseMarkdown(strings.Repeat("Paragraph text that fills the page.\n\n", 60))
+	plain, err := NewPDFRenderer(config, nil, nil).Render(node, source)
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	node, source = parseMarkdown(strings.Repeat("Paragraph text that fills the page.\n\n", 60))
+	stamped, err := NewPDFRenderer(&withBanner, nil, nil).Render(node, source)
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+
+	plainPages := strings.Count(plain.String(), "/Type /Page\n")
+	stampedPages := strings.Count(stamped.String(), "/Type /Page\n")
+	if plainPages != stampedPages {
+		t.Errorf("banner changed page count: %d vs %d", plainPages, stampedPages)
+	}
+}
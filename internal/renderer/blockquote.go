@@ -0,0 +1,233 @@
+package renderer
+
+import (
+	"strings"
+
+	"github.com/fredcamaral/md-to-pdf/internal/colorutil"
+	"github.com/fredcamaral/md-to-pdf/internal/parser"
+	"github.com/jung-kurt/gofpdf"
+	"github.com/yuin/goldmark/ast"
+)
+
+const (
+	quoteIndent          = 10   // Left indent of quote text in mm
+	quoteBarWidth        = 1    // Width of the border bar in mm
+	quotePadding         = 2    // Padding inside a tinted background in mm
+	quoteAttributionSize = 0.85 // Attribution font size relative to body text
+
+	defaultQuoteBarColor = "#c8c8c8" // Light gray
+)
+
+// attributionPrefixes start a quote attribution line such as "— Author".
+var attributionPrefixes = []string{"—", "―", "--"}
+
+// QuoteStyleConfig styles blockquotes. Zero values fall back to italic text
+// beside a light gray bar with no background; "none" turns off the bar or
+// the background.
+type QuoteStyleConfig struct {
+	BarColor   string // Color of the bar left of the quote, e.g. "#c8c8c8"
+	Background string // Background tint behind the quote
+	FontStyle  string // "italic" or "normal"
+}
+
+// quoteLine is one laid out line of a blockquote.
+type quoteLine struct {
+	text      string
+	style     string  // gofpdf font style
+	size      float64 // Font size in points
+	height    float64 // Line height in mm
+	align     string  // gofpdf alignment: "L" or "R"
+	gapBefore float64 // Space above the line in mm
+}
+
+// renderBlockquote renders a blockquote with an optional border bar and
+// background tint. A last line starting with an em dash (or "--") is an
+// attribution and is set right-aligned in a smaller, upright font.
+func (r *PDFRenderer) renderBlockquote(pdf *gofpdf.Fpdf, blockquote *ast.Blockquote, source []byte) {
+	r.blockGap(pdf, gapParagraph)
+
+	style := r.config.QuoteStyle
+	if style.BarColor == "" {
+		style.BarColor = defaultQuoteBarColor
+	}
+	bar, hasBar := parseOptionalColor(style.BarColor)
+	background, hasBackground := parseOptionalColor(style.Background)
+	textColor := r.textColor()
+
+	pageWidth, pageHeight := pdf.GetPageSize()
+	leftMargin, _, rightMargin, bottomMargin := pdf.GetMargins()
+	textX := leftMargin + quoteIndent
+	textWidth := pageWidth - rightMargin - textX
+	padding := 0.0
+	if hasBackground {
+		padding = quotePadding
+		textWidth -= padding
+	}
+
+	lines := r.layoutQuote(pdf, blockquote, source, textWidth)
+	if len(lines) == 0 {
+		r.blockGap(pdf, gapParagraph)
+		return
+	}
+
+	// Lines are placed page by page so the bar and background can be drawn
+	// under each page's share of the quote before its text
+	flush := func(page []quoteLine, top, bottom float64) {
+		if hasBackground {
+			pdf.SetFillColor(background.R, background.G, background.B)
+			pdf.Rect(leftMargin+quoteIndent/2, top, pageWidth-rightMargin-leftMargin-quoteIndent/2, bottom-top, "F")
+		}
+		if hasBar {
+			pdf.SetFillColor(bar.R, bar.G, bar.B)
+			pdf.Rect(leftMargin+quoteIndent/2, top, quoteBarWidth, bottom-top, "F")
+		}
+		pdf.SetFillColor(255, 255, 255)
+		pdf.SetTextColor(textColor.R, textColor.G, textColor.B)
+
+		y := top + padding
+		for _, line := range page {
+			y += line.gapBefore
+			pdf.SetFont(r.config.FontFamily, line.style, line.size)
+			pdf.SetXY(textX, y)
+			r.redactedCell(pdf, textWidth, line.height, line.text, line.align)
+			y += line.height
+		}
+	}
+
+	top := pdf.GetY()
+	if top+lines[0].height+2*padding > pageHeight-bottomMargin {
+		pdf.AddPage()
+		top = pdf.GetY()
+	}
+	y := top + padding
+	var page []quoteLine
+	for _, line := range lines {
+		if len(page) > 0 && y+line.gapBefore+line.height+padding > pageHeight-bottomMargin {
+			flush(page, top, y+padding)
+			pdf.AddPage()
+			top = pdf.GetY()
+			y = top + padding
+			page = nil
+			line.gapBefore = 0
+		}
+		page = append(page, line)
+		y += line.gapBefore + line.height
+	}
+	flush(page, top, y+padding)
+
+	pdf.SetXY(leftMargin, y+padding)
+	pdf.SetFont(r.config.FontFamily, "", r.config.FontSize)
+	r.blockGap(pdf, gapParagraph)
+}
+
+// layoutQuote wraps the paragraphs of a blockquote, and its attribution if
+// it has one, into lines that fit width.
+func (r *PDFRenderer) layoutQuote(pdf *gofpdf.Fpdf, blockquote *ast.Blockquote, source []byte, width float64) []quoteLine {
+	paragraphs := r.quoteParagraphs(blockquote, source)
+	attribution := splitAttribution(paragraphs)
+
+	// Core fonts use cp1252, which has the typographic dashes and quotes
+	translate := pdf.UnicodeTranslatorFromDescriptor("")
+
+	bodyStyle := "I"
+	if r.config.QuoteStyle.FontStyle == "normal" {
+		bodyStyle = ""
+	}
+
+	var lines []quoteLine
+	add := func(text, style string, size float64, align string) {
+		pdf.SetFont(r.config.FontFamily, style, size)
+		gap := 0.0
+		if len(lines) > 0 {
+			gap = gapParagraph * r.config.FontSize
+		}
+		var wrapped []string
+		for _, line := range pdf.SplitLines([]byte(translate(text)), width) {
+			wrapped = append(wrapped, string(line))
+		}
+		for _, line := range balanceRedactions(wrapped) {
+			lines = append(lines, quoteLine{
+				text:      line,
+				style:     style,
+				size:      size,
+				height:    size * 1.2,
+				align:     align,
+				gapBefore: gap,
+			})
+			gap = 0
+		}
+	}
+
+	for _, paragraph := range paragraphs {
+		if paragraph = strings.TrimSpace(paragraph); paragraph != "" {
+			add(paragraph, bodyStyle, r.config.FontSize, "L")
+		}
+	}
+	if attribution != "" {
+		add("— "+attribution, "", r.config.FontSize*quoteAttributionSize, "R")
+	}
+	return lines
+}
+
+// quoteParagraphs returns the text of each block in a blockquote with soft
+// line breaks kept as newlines, so an attribution on the last line of a
+// paragraph can be found.
+func (r *PDFRenderer) quoteParagraphs(blockquote *ast.Blockquote, source []byte) []string {
+	var paragraphs []string
+	for child := blockquote.FirstChild(); child != nil; child = child.NextSibling() {
+		var b strings.Builder
+		_ = ast.Walk(child, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+			if !entering {
+				return ast.WalkContinue, nil
+			}
+			switch node := n.(type) {
+			case *ast.Text:
+				b.Write(node.Segment.Value(source))
+				if node.SoftLineBreak() || node.HardLineBreak() {
+					b.WriteByte('\n')
+				}
+			case *ast.String:
+				b.Write(node.Value)
+			case *parser.Redaction:
+				b.WriteString(markRedaction(node.Hidden))
+			case *parser.Sidenote:
+				return ast.WalkSkipChildren, nil
+			}
+			return ast.WalkContinue, nil
+		})
+		paragraphs = append(paragraphs, b.String())
+	}
+	return paragraphs
+}
+
+// splitAttribution removes a trailing attribution line from the last
+// paragraph and returns it without its dash. Remaining line breaks in the
+// paragraphs become spaces.
+func splitAttribution(paragraphs []string) string {
+	attribution := ""
+	if n := len(paragraphs); n > 0 {
+		lines := strings.Split(strings.TrimRight(paragraphs[n-1], "\n"), "\n")
+		last := strings.TrimSpace(lines[len(lines)-1])
+		for _, prefix := range attributionPrefixes {
+			if strings.HasPrefix(last, prefix) {
+				attribution = strings.TrimSpace(strings.TrimPrefix(last, prefix))
+				paragraphs[n-1] = strings.Join(lines[:len(lines)-1], "\n")
+				break
+			}
+		}
+	}
+	for i, paragraph := range paragraphs {
+		paragraphs[i] = strings.ReplaceAll(strings.TrimRight(paragraph, "\n"), "\n", " ")
+	}
+	return attribution
+}
+
+// parseOptionalColor parses a configured color; empty, "none" or invalid
+// values report false so the element is not drawn.
+func parseOptionalColor(value string) (colorutil.Color, bool) {
+	if value == "" || value == "none" {
+		return colorutil.Color{}, false
+	}
+	color, err := colorutil.Parse(value)
+	return color, err == nil
+}
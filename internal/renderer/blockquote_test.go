@@ -0,0 +1,85 @@
+package renderer
+
+import (
+	"strings"
+	"testing"
+)
+
+func renderQuote(t *testing.T, style QuoteStyleConfig, markdown string) string {
+	t.Helper()
+	config := defaultTestConfig()
+	config.QuoteStyle = style
+	renderer := NewPDFRenderer(config, defaultTestDocumentMetadata(), nil)
+
+	node, source := parseMarkdown(markdown)
+	buf, err := renderer.Render(node, source)
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	return pdfContent(t, buf)
+}
+
+func TestSplitAttribution(t *testing.T) {
+	tests := []struct {
+		name            string
+		paragraphs      []string
+		wantAttribution string
+		wantParagraphs  []string
+	}{
+		{"none", []string{"Just a quote"}, "", []string{"Just a quote"}},
+		{"last_line", []string{"To be or not\nto be\n— Shakespeare"}, "Shakespeare", []string{"To be or not to be"}},
+		{"own_paragraph", []string{"Quote", "-- Anonymous"}, "Anonymous", []string{"Quote", ""}},
+		{"horizontal_bar", []string{"Quote\n―Someone"}, "Someone", []string{"Quote"}},
+		{"dash_inside", []string{"A — B"}, "", []string{"A — B"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attribution := splitAttribution(tt.paragraphs)
+			if attribution != tt.wantAttribution {
+				t.Errorf("attribution = %q, want %q", attribution, tt.wantAttribution)
+			}
+			if strings.Join(tt.paragraphs, "|") != strings.Join(tt.wantParagraphs, "|") {
+				t.Errorf("paragraphs = %q, want %q", tt.paragraphs, tt.wantParagraphs)
+			}
+		})
+	}
+}
+
+func TestRenderBlockquote_Attribution(t *testing.T) {
+	content := renderQuote(t, QuoteStyleConfig{}, "> Simplicity is prerequisite for reliability.\n> — Edsger Dijkstra\n")
+
+	if !strings.Contains(content, "(Simplicity is prerequisite for reliability.)Tj") {
+		t.Error("quote text should be rendered")
+	}
+	// The em dash is written as its cp1252 code, right-aligned in a smaller
+	// upright font
+	if !strings.Contains(content, "(\x97 Edsger Dijkstra)Tj") {
+		t.Error("attribution should be rendered with an em dash")
+	}
+	if !strings.Contains(content, "10.20 Tf") {
+		t.Error("attribution should use a smaller font than the quote")
+	}
+}
+
+func TestRenderBlockquote_Style(t *testing.T) {
+	content := renderQuote(t, QuoteStyleConfig{}, "> Quote\n")
+	if !strings.Contains(content, "0.784 g") {
+		t.Error("default quote should have a light gray bar")
+	}
+
+	content = renderQuote(t, QuoteStyleConfig{BarColor: "none", Background: "#eeeeee", FontStyle: "normal"}, "> Quote\n")
+	if strings.Contains(content, "0.784 g") {
+		t.Error("bar should not be drawn when set to none")
+	}
+	if !strings.Contains(content, "0.933 g") {
+		t.Error("background tint should be drawn")
+	}
+}
+
+func TestRenderBlockquote_SpansPages(t *testing.T) {
+	markdown := "> " + strings.Repeat("A long quote that keeps going. ", 400) + "\n"
+	content := renderQuote(t, QuoteStyleConfig{}, markdown)
+	if lines := strings.Count(content, ")Tj"); lines < 100 {
+		t.Errorf("long quote should render every line, got %d", lines)
+	}
+}
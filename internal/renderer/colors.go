@@ -0,0 +1,72 @@
+package renderer
+
+import (
+	"github.com/fredcamaral/md-to-pdf/internal/colorutil"
+	"github.com/jung-kurt/gofpdf"
+)
+
+var (
+	defaultTextColor          = colorutil.Color{R: 0, G: 0, B: 0}
+	defaultCodeBlockColor     = colorutil.Color{R: 245, G: 245, B: 245}
+	defaultCodeSpanBackground = colorutil.Color{R: 238, G: 238, B: 238}
+	defaultLinkColor          = colorutil.Color{R: 0, G: 0, B: 238}
+)
+
+// ColorConfig sets the colors of text, the page and code. Empty values keep
+// black text on a white page, with blue links, code blocks on light gray and
+// code spans on a slightly darker gray.
+type ColorConfig struct {
+	Text           string // Body text color, e.g. "#333333"
+	Background     string // Page background color
+	Link           string // Link text color
+	CodeText       string // Code text color (defaults to the body text color)
+	CodeBackground string // Background of code blocks and code spans
+	ImageBackdrop  string // Color faint transparent images are placed on
+}
+
+// textColor returns the body text color.
+func (r *PDFRenderer) textColor() colorutil.Color {
+	return parseColorOr(r.config.Colors.Text, defaultTextColor)
+}
+
+// linkColor returns the color of link text.
+func (r *PDFRenderer) linkColor() colorutil.Color {
+	return parseColorOr(r.config.Colors.Link, defaultLinkColor)
+}
+
+// codeTextColor returns the color of code blocks and code spans.
+func (r *PDFRenderer) codeTextColor() colorutil.Color {
+	return parseColorOr(r.config.Colors.CodeText, r.textColor())
+}
+
+// codeBackground returns the background of code blocks, or of code spans
+// when span is set.
+func (r *PDFRenderer) codeBackground(span bool) colorutil.Color {
+	if span {
+		return parseColorOr(r.config.Colors.CodeBackground, defaultCodeSpanBackground)
+	}
+	return parseColorOr(r.config.Colors.CodeBackground, defaultCodeBlockColor)
+}
+
+// paintPageBackground fills the current page with the configured background
+// color. Pages stay unpainted, and so white, without one.
+func (r *PDFRenderer) paintPageBackground(pdf *gofpdf.Fpdf) {
+	background, ok := parseOptionalColor(r.config.Colors.Background)
+	if !ok {
+		return
+	}
+	fillR, fillG, fillB := pdf.GetFillColor()
+	width, height := pdf.GetPageSize()
+	pdf.SetFillColor(background.R, background.G, background.B)
+	pdf.Rect(0, 0, width, height, "F")
+	pdf.SetFillColor(fillR, fillG, fillB)
+}
+
+// parseColorOr parses value, returning fallback when it is empty or invalid.
+func parseColorOr(value string, fallback colorutil.Color) colorutil.Color {
+	color, ok := parseOptionalColor(value)
+	if !ok {
+		return fallback
+	}
+	return color
+}
@@ -0,0 +1,57 @@
+package renderer
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRenderColors(t *testing.T) {
+	config := defaultTestConfig()
+	config.Colors = ColorConfig{
+		Text:           "#336699",
+		Background:     "#fdf6e3",
+		Link:           "#cb4b16",
+		CodeText:       "#abb2bf",
+		CodeBackground: "#282c34",
+	}
+	renderer := NewPDFRenderer(config, defaultTestDocumentMetadata(), nil)
+
+	node, source := parseMarkdown("Some text with `code` and [a link](https://example.org).\n\n```\nblock\n```\n")
+	buf, err := renderer.Render(node, source)
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	content := pdfContent(t, buf)
+
+	checks := []struct {
+		want, what string
+	}{
+		{"0.992 0.965 0.890 rg\n0.00 841.89 595.28 -841.89 re f", "page background"},
+		{"0.200 0.400 0.600 rg", "body text color"},
+		{"0.796 0.294 0.086 rg", "link color"},
+		{"0.671 0.698 0.749 rg", "code text color"},
+		{"0.157 0.173 0.204 rg", "code background"},
+	}
+	for _, c := range checks {
+		if !strings.Contains(content, c.want) {
+			t.Errorf("%s (%s) should be set", c.what, c.want)
+		}
+	}
+}
+
+func TestRenderColors_Defaults(t *testing.T) {
+	renderer := NewPDFRenderer(defaultTestConfig(), defaultTestDocumentMetadata(), nil)
+
+	node, source := parseMarkdown("```\nblock\n```\n")
+	buf, err := renderer.Render(node, source)
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	content := pdfContent(t, buf)
+	if !strings.Contains(content, "0.961 g") {
+		t.Error("code blocks should default to a light gray background")
+	}
+	if strings.Contains(content, "595.28 -841.89 re f") {
+		t.Error("pages should not be painted without a background color")
+	}
+}
@@ -0,0 +1,107 @@
+package renderer
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/jung-kurt/gofpdf"
+	"github.com/yuin/goldmark/ast"
+)
+
+// RenderError reports a failure inside gofpdf together with what was being
+// rendered when it happened. gofpdf records the first error internally and
+// turns every later call into a no-op, so unchecked errors produce truncated
+// or broken output.
+type RenderError struct {
+	Element string // What was being rendered, e.g. `heading "Usage" (line 12)`
+	Page    int    // Page the element was placed on (0 = before the first page)
+	Cause   error
+}
+
+func (e *RenderError) Error() string {
+	if e.Page > 0 {
+		return fmt.Sprintf("failed to render %s on page %d: %v", e.Element, e.Page, e.Cause)
+	}
+	return fmt.Sprintf("failed to render %s: %v", e.Element, e.Cause)
+}
+
+func (e *RenderError) Unwrap() error {
+	return e.Cause
+}
+
+// checkPDF returns the error recorded by gofpdf, if any, as a RenderError
+// for element.
+func checkPDF(pdf *gofpdf.Fpdf, element string) error {
+	if !pdf.Err() {
+		return nil
+	}
+	return &RenderError{Element: element, Page: pdf.PageNo(), Cause: pdf.Error()}
+}
+
+// registerImage registers image data with the PDF under a name derived from
+// its contents, and returns the name to draw it with. The same image used
+// several times, such as a logo in the header of every page, is embedded
+// once. Images gofpdf cannot decode are reported as warnings and return nil
+// after clearing the error, so the caller can fall back to alt text and the
+// render carries on.
+func (r *PDFRenderer) registerImage(pdf *gofpdf.Fpdf, imageType string, data []byte, destination string) (string, *gofpdf.ImageInfoType) {
+	name := contentImageName(imageType, data)
+	info := pdf.RegisterImageOptionsReader(name, gofpdf.ImageOptions{ImageType: imageType}, bytes.NewReader(data))
+	if pdf.Err() {
+		r.warn(fmt.Sprintf("image %s could not be embedded: %v", destination, pdf.Error()))
+		pdf.ClearError()
+		return "", nil
+	}
+	if info == nil || info.Height() == 0 {
+		r.warn(fmt.Sprintf("image %s could not be embedded: no image data", destination))
+		return "", nil
+	}
+	return name, info
+}
+
+// contentImageName names image data for gofpdf, which returns the image already
+// registered under a name instead of reading new data for it.
+func contentImageName(imageType string, data []byte) string {
+	sum := sha256.Sum256(data)
+	return strings.ToLower(imageType) + "_" + hex.EncodeToString(sum[:16])
+}
+
+// describeNode names a block for error messages, with its source line when
+// the node has one.
+func describeNode(n ast.Node, source []byte) string {
+	var name string
+	switch node := n.(type) {
+	case *ast.Heading:
+		name = fmt.Sprintf("heading %q", headingText(node, source))
+	case *ast.Image:
+		name = fmt.Sprintf("image %s", node.Destination)
+	case *ast.FencedCodeBlock, *ast.CodeBlock:
+		name = "code block"
+	case *ast.ThematicBreak:
+		name = "thematic break"
+	default:
+		name = strings.ToLower(n.Kind().String())
+	}
+
+	if n.Type() == ast.TypeBlock && n.Lines().Len() > 0 {
+		start := n.Lines().At(0).Start
+		if start <= len(source) {
+			name += fmt.Sprintf(" (line %d)", bytes.Count(source[:start], []byte("\n"))+1)
+		}
+	}
+	return name
+}
+
+// headingText returns the plain text of a heading.
+func headingText(heading *ast.Heading, source []byte) string {
+	var text string
+	for child := heading.FirstChild(); child != nil; child = child.NextSibling() {
+		if child.Kind() == ast.KindText {
+			text += string(child.(*ast.Text).Segment.Value(source))
+		}
+	}
+	return text
+}
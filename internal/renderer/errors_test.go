@@ -0,0 +1,81 @@
+package renderer
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRender_UnknownFontReturnsError(t *testing.T) {
+	config := defaultTestConfig()
+	config.FontFamily = "NoSuchFont"
+	renderer := NewPDFRenderer(config, defaultTestDocumentMetadata(), nil)
+
+	node, source := parseMarkdown("# Title\n\nText.")
+	_, err := renderer.Render(node, source)
+
+	var renderErr *RenderError
+	if !errors.As(err, &renderErr) {
+		t.Fatalf("expected RenderError, got %v", err)
+	}
+	if !strings.Contains(renderErr.Element, `font "NoSuchFont"`) {
+		t.Errorf("error should name the font, got %q", renderErr.Element)
+	}
+	if !strings.Contains(err.Error(), "undefined font") {
+		t.Errorf("error should include the gofpdf cause, got %v", err)
+	}
+}
+
+func TestRender_CorruptImageFallsBack(t *testing.T) {
+	imagePath := filepath.Join(t.TempDir(), "broken.png")
+	if err := os.WriteFile(imagePath, []byte("not really a PNG"), 0644); err != nil {
+		t.Fatalf("failed to write image: %v", err)
+	}
+	renderer := NewPDFRenderer(defaultTestConfig(), defaultTestDocumentMetadata(), nil)
+
+	// A corrupt image must not stop the rest of the document from rendering
+	node, source := parseMarkdown("![diagram](" + imagePath + ")\n\nInline ![icon](" + imagePath + ") here.\n\nAfter the images.")
+	buf, err := renderer.Render(node, source)
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+
+	content := pdfContent(t, buf)
+	for _, want := range []string{"[Image failed to load: diagram]", "[icon]", "After the images."} {
+		if !strings.Contains(content, want) {
+			t.Errorf("output should contain %q", want)
+		}
+	}
+
+	warnings := renderer.Warnings()
+	if len(warnings) != 2 || !strings.Contains(warnings[0], "could not be embedded") {
+		t.Errorf("expected a warning per image, got %v", warnings)
+	}
+}
+
+func TestDescribeNode(t *testing.T) {
+	node, source := parseMarkdown("Intro text.\n\n## Setup Guide\n\n```\ncode\n```\n")
+
+	var names []string
+	for n := node.FirstChild(); n != nil; n = n.NextSibling() {
+		names = append(names, describeNode(n, source))
+	}
+
+	want := []string{"paragraph (line 1)", `heading "Setup Guide" (line 3)`, "code block (line 6)"}
+	if strings.Join(names, "|") != strings.Join(want, "|") {
+		t.Errorf("describeNode() = %q, want %q", names, want)
+	}
+}
+
+func TestRenderError(t *testing.T) {
+	cause := errors.New("boom")
+	err := &RenderError{Element: "paragraph (line 4)", Page: 2, Cause: cause}
+	if err.Error() != "failed to render paragraph (line 4) on page 2: boom" {
+		t.Errorf("Error() = %q", err.Error())
+	}
+	if !errors.Is(err, cause) {
+		t.Error("RenderError should unwrap to its cause")
+	}
+}
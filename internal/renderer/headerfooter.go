@@ -0,0 +1,142 @@
+package renderer
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jung-kurt/gofpdf"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/text"
+)
+
+const (
+	// headerFooterScale is the font size of headers and footers relative to body text.
+	headerFooterScale = 0.8
+
+	// totalPagesAlias is replaced by gofpdf with the final page count at output time.
+	totalPagesAlias = "{nb}"
+
+	// defaultDateLayout formats the {date} template variable unless the
+	// document configures its own date format.
+	defaultDateLayout = "2006-01-02"
+)
+
+// HeaderFooterConfig holds markdown snippets rendered in the page margins.
+type HeaderFooterConfig struct {
+	Header      string // Markdown snippet rendered in the top margin of every page
+	Footer      string // Markdown snippet rendered in the bottom margin of every page
+	HeaderAlign string // "left", "center" or "right"
+	FooterAlign string // "left", "center" or "right"
+}
+
+// setupHeaderFooter registers gofpdf header and footer callbacks for the
+// configured snippets, page background and banner. It must be called before
+// the first page is added.
+func (r *PDFRenderer) setupHeaderFooter(pdf *gofpdf.Fpdf) {
+	hf := r.config.HeaderFooter
+	_, hasBackground := parseOptionalColor(r.config.Colors.Background)
+	bannerTop, bannerBottom := r.bannerEdges()
+	if hf.Header != "" || hasBackground || bannerTop {
+		// The header is drawn first on every page, so the background goes
+		// under everything else
+		pdf.SetHeaderFunc(func() {
+			r.paintPageBackground(pdf)
+			if bannerTop {
+				r.renderBanner(pdf, true)
+			}
+			if hf.Header != "" {
+				r.renderMarginSnippet(pdf, hf.Header, hf.HeaderAlign, true)
+			}
+		})
+	}
+	if hf.Footer != "" || bannerBottom {
+		pdf.SetFooterFunc(func() {
+			if hf.Footer != "" {
+				r.renderMarginSnippet(pdf, hf.Footer, hf.FooterAlign, false)
+			}
+			if bannerBottom {
+				r.renderBanner(pdf, false)
+			}
+		})
+	}
+	if hf.Header != "" || hf.Footer != "" || bannerTop || bannerBottom {
+		pdf.AliasNbPages(totalPagesAlias)
+	}
+}
+
+// renderMarginSnippet renders a header or footer snippet for the current page.
+// The snippet is rendered through the inline pipeline at reduced scale and the
+// PDF state (position, font, colors) is restored afterwards.
+func (r *PDFRenderer) renderMarginSnippet(pdf *gofpdf.Fpdf, snippet, align string, top bool) {
+	source := []byte(r.expandTemplate(snippet, pdf.PageNo()))
+	doc := goldmark.DefaultParser().Parse(text.NewReader(source))
+
+	// Save state that the snippet rendering changes
+	x, y := pdf.GetXY()
+	fontFamily, fontStyle, fontSize := r.config.FontFamily, "", r.config.FontSize
+	textR, textG, textB := pdf.GetTextColor()
+
+	style := r.bodyStyle()
+	style.size = r.config.FontSize * headerFooterScale
+	style.lineHeight = pdf.PointToUnitConvert(style.size) * 1.2
+
+	_, pageHeight := pdf.GetPageSize()
+	lineY := r.config.Margins.Top/2 - style.lineHeight/2
+	if !top {
+		lineY = pageHeight - r.config.Margins.Bottom/2 - style.lineHeight/2
+	}
+
+	for block := doc.FirstChild(); block != nil; block = block.NextSibling() {
+		r.renderMarginBlock(pdf, block, source, style, align, lineY)
+		lineY += style.lineHeight
+	}
+
+	pdf.SetTextColor(textR, textG, textB)
+	pdf.SetFont(fontFamily, fontStyle, fontSize)
+	pdf.SetXY(x, y)
+}
+
+// renderMarginBlock renders one block of a header/footer snippet on a single line.
+func (r *PDFRenderer) renderMarginBlock(pdf *gofpdf.Fpdf, block ast.Node, source []byte, style inlineStyle, align string, y float64) {
+	pageWidth, _ := pdf.GetPageSize()
+	available := pageWidth - r.config.Margins.Left - r.config.Margins.Right
+
+	x := r.config.Margins.Left
+	switch align {
+	case "center":
+		x += (available - r.inlineWidth(pdf, block, source, style)) / 2
+	case "right":
+		x += available - r.inlineWidth(pdf, block, source, style)
+	}
+	if x < r.config.Margins.Left {
+		x = r.config.Margins.Left
+	}
+
+	pdf.SetXY(x, y)
+	r.renderInlines(pdf, block, source, style)
+}
+
+// expandTemplate substitutes template variables in a header/footer snippet.
+// Supported variables: {page}, {pages}, {title}, {author}, {subject}, {date}.
+func (r *PDFRenderer) expandTemplate(snippet string, page int) string {
+	var title, author, subject string
+	dateLayout := defaultDateLayout
+	if document := r.metadata(); document != nil {
+		title, author, subject = document.Title, document.Author, document.Subject
+		if document.DateFormat != "" {
+			dateLayout = document.DateFormat
+		}
+	}
+
+	replacer := strings.NewReplacer(
+		"{page}", strconv.Itoa(page),
+		"{pages}", totalPagesAlias,
+		"{title}", title,
+		"{author}", author,
+		"{subject}", subject,
+		"{date}", time.Now().Format(dateLayout),
+	)
+	return replacer.Replace(snippet)
+}
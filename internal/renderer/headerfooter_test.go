@@ -0,0 +1,173 @@
+package renderer
+
+import (
+	"image"
+	"image/color"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExpandTemplate(t *testing.T) {
+	renderer := NewPDFRenderer(defaultTestConfig(), defaultTestDocumentMetadata(), nil)
+
+	tests := []struct {
+		name     string
+		snippet  string
+		page     int
+		expected string
+	}{
+		{"page_number", "Page {page}", 3, "Page 3"},
+		{"total_pages", "{page} of {pages}", 1, "1 of " + totalPagesAlias},
+		{"metadata", "**{title}** by {author}", 1, "**Test Document** by Test Author"},
+		{"subject", "_{subject}_", 1, "_Test Subject_"},
+		{"date", "{date}", 1, time.Now().Format(defaultDateLayout)},
+		{"no_variables", "Confidential", 1, "Confidential"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := renderer.expandTemplate(tt.snippet, tt.page)
+			if got != tt.expected {
+				t.Errorf("expandTemplate(%q) = %q, want %q", tt.snippet, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestExpandTemplate_DateFormat(t *testing.T) {
+	document := defaultTestDocumentMetadata()
+	document.DateFormat = "02.01.2006"
+	renderer := NewPDFRenderer(defaultTestConfig(), document, nil)
+
+	got := renderer.expandTemplate("{date}", 1)
+	if want := time.Now().Format("02.01.2006"); got != want {
+		t.Errorf("expandTemplate({date}) = %q, want %q", got, want)
+	}
+}
+
+func TestExpandTemplate_NilDocument(t *testing.T) {
+	renderer := NewPDFRenderer(defaultTestConfig(), nil, nil)
+
+	got := renderer.expandTemplate("[{title}]", 1)
+	if got != "[]" {
+		t.Errorf("expandTemplate with nil document = %q, want %q", got, "[]")
+	}
+}
+
+func TestRender_HeaderAndFooter(t *testing.T) {
+	config := defaultTestConfig()
+	config.HeaderFooter = HeaderFooterConfig{
+		Header:      "**{title}**",
+		Footer:      "Page {page} of {pages}",
+		HeaderAlign: "left",
+		FooterAlign: "center",
+	}
+	renderer := NewPDFRenderer(config, defaultTestDocumentMetadata(), nil)
+
+	node, source := parseMarkdown("# Heading\n\nBody text.")
+
+	buf, err := renderer.Render(node, source)
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+
+	content := pdfContent(t, buf)
+	if !strings.Contains(content, "(Test Document)") {
+		t.Error("header should render the expanded title")
+	}
+	if !strings.Contains(content, "(Page 1 of 1)") {
+		t.Error("footer should render the page number and total page count")
+	}
+}
+
+func TestRender_HeaderAlignment(t *testing.T) {
+	for _, align := range []string{"left", "center", "right"} {
+		t.Run(align, func(t *testing.T) {
+			config := defaultTestConfig()
+			config.HeaderFooter = HeaderFooterConfig{Header: "Header *text*", HeaderAlign: align}
+			renderer := NewPDFRenderer(config, defaultTestDocumentMetadata(), nil)
+
+			node, source := parseMarkdown("Body")
+			buf, err := renderer.Render(node, source)
+			if err != nil {
+				t.Fatalf("Render failed with %s alignment: %v", align, err)
+			}
+			if !strings.Contains(pdfContent(t, buf), "(Header )") {
+				t.Errorf("header text missing with %s alignment", align)
+			}
+		})
+	}
+}
+
+func TestRender_HeaderDoesNotMoveBodyCursor(t *testing.T) {
+	config := defaultTestConfig()
+	withHeader := *config
+	withHeader.HeaderFooter = HeaderFooterConfig{Header: "Header", Footer: "Footer"}
+
+	node, source := parseMarkdown(strings.Repeat("Paragraph text that fills the page.\n\n", 60))
+	plain, err := NewPDFRenderer(config, nil, nil).Render(node, source)
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+
+	node, source = parseMarkdown(strings.Repeat("Paragraph text that fills the page.\n\n", 60))
+	decorated, err := NewPDFRenderer(&withHeader, nil, nil).Render(node, source)
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+
+	plainPages := strings.Count(plain.String(), "/Type /Page\n")
+	decoratedPages := strings.Count(decorated.String(), "/Type /Page\n")
+	if plainPages != decoratedPages {
+		t.Errorf("header/footer changed page count: %d vs %d", plainPages, decoratedPages)
+	}
+}
+
+func TestRender_HeaderLogoEmbeddedOnce(t *testing.T) {
+	dir := t.TempDir()
+	writeImage := func(name string, img image.Image) string {
+		t.Helper()
+		path := filepath.Join(dir, name)
+		file, err := os.Create(path)
+		if err != nil {
+			t.Fatalf("failed to create image: %v", err)
+		}
+		defer func() { _ = file.Close() }()
+		if err := writePNG(file, img); err != nil {
+			t.Fatalf("failed to write PNG: %v", err)
+		}
+		return path
+	}
+	logo := writeImage("logo.png", createTestPNG(10, 10))
+	blue := image.NewRGBA(image.Rect(0, 0, 10, 10))
+	for i := range blue.Pix {
+		blue.Pix[i] = 255
+	}
+	blue.Set(0, 0, color.RGBA{0, 0, 255, 255})
+	other := writeImage("other.png", blue)
+
+	config := defaultTestConfig()
+	config.HeaderFooter = HeaderFooterConfig{Header: "![logo](" + logo + ") ![other](" + other + ")"}
+	node, source := parseMarkdown(strings.Repeat("Paragraph text that fills the page.\n\n", 120))
+	buf, err := NewPDFRenderer(config, nil, nil).Render(node, source)
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+
+	if pages := strings.Count(buf.String(), "/Type /Page\n"); pages < 3 {
+		t.Fatalf("expected several pages, got %d", pages)
+	}
+	// gofpdf writes identical image data once whatever it is named, so
+	// count the names in the XObject resources instead
+	resources := regexp.MustCompile(`/XObject\s*<<([^>]*)>>`).FindStringSubmatch(buf.String())
+	if resources == nil {
+		t.Fatal("no XObject resources found")
+	}
+	if names := strings.Count(resources[1], " 0 R"); names != 2 {
+		t.Errorf("the two header images should be registered once each, found %d names", names)
+	}
+}
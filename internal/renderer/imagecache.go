@@ -0,0 +1,73 @@
+package renderer
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ImageCache keeps image file contents in memory so several renderers (for
+// example the localized builds of one document) read each image only once.
+// Entries are invalidated when the file's size or modification time changes.
+type ImageCache struct {
+	mu      sync.Mutex
+	entries map[string]cachedImage
+}
+
+type cachedImage struct {
+	data    []byte
+	size    int64
+	modTime time.Time
+}
+
+// NewImageCache creates an empty image cache.
+func NewImageCache() *ImageCache {
+	return &ImageCache{entries: make(map[string]cachedImage)}
+}
+
+// Load returns the contents of the image at path, reading it from disk only
+// when it is not cached or has changed since it was cached.
+func (c *ImageCache) Load(path string) ([]byte, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	entry, ok := c.entries[path]
+	c.mu.Unlock()
+	if ok && entry.size == info.Size() && entry.modTime.Equal(info.ModTime()) {
+		return entry.data, nil
+	}
+
+	data, err := os.ReadFile(path) // #nosec G304 - path from markdown content
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	c.entries[path] = cachedImage{data: data, size: info.Size(), modTime: info.ModTime()}
+	c.mu.Unlock()
+
+	return data, nil
+}
+
+// ThemedImagePath returns the variant of the image at path for a "light" or
+// "dark" theme, named like "diagram.dark.png" for "diagram.png", when that
+// file exists, and path otherwise. Without a theme, light is used.
+func ThemedImagePath(path, theme string) string {
+	if theme == "" {
+		theme = "light"
+	}
+	if path == "" || strings.Contains(path, "://") || strings.HasPrefix(path, "data:") {
+		return path
+	}
+	ext := filepath.Ext(path)
+	variant := strings.TrimSuffix(path, ext) + "." + theme + ext
+	if _, err := os.Stat(variant); err == nil {
+		return variant
+	}
+	return path
+}
@@ -0,0 +1,81 @@
+package renderer
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestImageCache_Load(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logo.png")
+	if err := os.WriteFile(path, []byte("first"), 0644); err != nil {
+		t.Fatalf("failed to write image: %v", err)
+	}
+
+	cache := NewImageCache()
+	data, err := cache.Load(path)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if string(data) != "first" {
+		t.Errorf("Load = %q, want %q", data, "first")
+	}
+
+	// Unchanged files are served from memory
+	if _, ok := cache.entries[path]; !ok {
+		t.Fatal("expected image to be cached")
+	}
+
+	// Changed files are read again
+	if err := os.WriteFile(path, []byte("second version"), 0644); err != nil {
+		t.Fatalf("failed to rewrite image: %v", err)
+	}
+	future := time.Now().Add(time.Minute)
+	if err := os.Chtimes(path, future, future); err != nil {
+		t.Fatalf("failed to touch image: %v", err)
+	}
+
+	data, err = cache.Load(path)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if string(data) != "second version" {
+		t.Errorf("Load after change = %q, want %q", data, "second version")
+	}
+}
+
+func TestImageCache_LoadMissing(t *testing.T) {
+	cache := NewImageCache()
+	if _, err := cache.Load(filepath.Join(t.TempDir(), "missing.png")); err == nil {
+		t.Error("expected error for missing image")
+	}
+}
+
+func TestThemedImagePath(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"flow.png", "flow.dark.png", "logo.light.png"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(name), 0644); err != nil {
+			t.Fatalf("failed to write image: %v", err)
+		}
+	}
+
+	tests := []struct {
+		path, theme, want string
+	}{
+		{"flow.png", "dark", "flow.dark.png"},
+		{"flow.png", "light", "flow.png"},
+		{"flow.png", "", "flow.png"},
+		{"logo.png", "", "logo.light.png"},
+		{"logo.png", "dark", "logo.png"},
+	}
+	for _, tt := range tests {
+		got := ThemedImagePath(filepath.Join(dir, tt.path), tt.theme)
+		if got != filepath.Join(dir, tt.want) {
+			t.Errorf("ThemedImagePath(%s, %q) = %s, want %s", tt.path, tt.theme, filepath.Base(got), tt.want)
+		}
+	}
+	if got := ThemedImagePath("https://example.com/flow.png", "dark"); got != "https://example.com/flow.png" {
+		t.Errorf("remote images should be kept, got %s", got)
+	}
+}
@@ -0,0 +1,448 @@
+package renderer
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/fredcamaral/md-to-pdf/internal/colorutil"
+	"github.com/fredcamaral/md-to-pdf/internal/manifest"
+	"github.com/fredcamaral/md-to-pdf/internal/parser"
+	"github.com/jung-kurt/gofpdf"
+	"github.com/yuin/goldmark/ast"
+)
+
+const (
+	codeSpanPadding = 0.8 // Horizontal padding inside inline code backgrounds in mm
+	codeSpanRadius  = 0.6 // Corner radius of inline code backgrounds in mm
+)
+
+// inlineStyle captures the font state used while writing inline content.
+// It is passed by value so nested spans (e.g. bold inside a link) can
+// derive their own style without affecting their siblings.
+type inlineStyle struct {
+	family     string
+	size       float64 // Font size in points
+	lineHeight float64 // Line height in mm
+	bold       bool
+	italic     bool
+	link       string          // Destination URL when inside a link
+	color      colorutil.Color // Text color outside links
+	linkColor  colorutil.Color // Text color inside links
+}
+
+// fontStyle returns the gofpdf style string for the current state.
+func (s inlineStyle) fontStyle() string {
+	style := ""
+	if s.bold {
+		style += "B"
+	}
+	if s.italic {
+		style += "I"
+	}
+	if s.link != "" {
+		style += "U"
+	}
+	return style
+}
+
+// apply sets the PDF font and text color for this style.
+func (s inlineStyle) apply(pdf *gofpdf.Fpdf) {
+	pdf.SetFont(s.family, s.fontStyle(), s.size)
+	if s.link != "" {
+		pdf.SetTextColor(s.linkColor.R, s.linkColor.G, s.linkColor.B)
+	} else {
+		pdf.SetTextColor(s.color.R, s.color.G, s.color.B)
+	}
+}
+
+// bodyStyle returns the inline style used for regular body text.
+func (r *PDFRenderer) bodyStyle() inlineStyle {
+	return inlineStyle{
+		family:     r.config.FontFamily,
+		size:       r.config.FontSize,
+		lineHeight: r.config.FontSize * 1.2,
+		color:      r.textColor(),
+		linkColor:  r.linkColor(),
+	}
+}
+
+// renderInlines writes the inline children of parent (text, emphasis, code
+// spans, links and images) using flowing text so that mixed formatting wraps
+// naturally at the right margin.
+func (r *PDFRenderer) renderInlines(pdf *gofpdf.Fpdf, parent ast.Node, source []byte, style inlineStyle) {
+	for child := parent.FirstChild(); child != nil; child = child.NextSibling() {
+		r.renderInline(pdf, child, source, style)
+	}
+	// Leave the PDF in the base style for the next block
+	style.link = ""
+	style.bold, style.italic = false, false
+	style.apply(pdf)
+}
+
+// renderInline writes a single inline node.
+func (r *PDFRenderer) renderInline(pdf *gofpdf.Fpdf, node ast.Node, source []byte, style inlineStyle) {
+	switch n := node.(type) {
+	case *ast.Text:
+		r.writeText(pdf, string(n.Segment.Value(source)), style)
+		if n.HardLineBreak() {
+			pdf.Ln(style.lineHeight)
+		} else if n.SoftLineBreak() {
+			r.writeText(pdf, " ", style)
+		}
+	case *ast.String:
+		r.writeText(pdf, string(n.Value), style)
+	case *ast.Emphasis:
+		if n.Level >= 2 {
+			style.bold = true
+		} else {
+			style.italic = true
+		}
+		for child := n.FirstChild(); child != nil; child = child.NextSibling() {
+			r.renderInline(pdf, child, source, style)
+		}
+	case *ast.CodeSpan:
+		r.writeCodeSpan(pdf, n, source, style)
+	case *ast.Link:
+		style.link = r.linkDestination(string(n.Destination))
+		for child := n.FirstChild(); child != nil; child = child.NextSibling() {
+			r.renderInline(pdf, child, source, style)
+		}
+	case *ast.AutoLink:
+		url := string(n.URL(source))
+		style.link = url
+		if n.AutoLinkType == ast.AutoLinkEmail && !strings.HasPrefix(url, "mailto:") {
+			style.link = "mailto:" + url
+		}
+		r.writeText(pdf, string(n.Label(source)), style)
+	case *ast.Image:
+		r.writeInlineImage(pdf, n, source, style)
+	case *parser.Sidenote:
+		r.writeSidenote(pdf, n, style)
+	case *parser.Redaction:
+		r.writeRedaction(pdf, n, style)
+	case *ast.RawHTML:
+		// Raw inline HTML (including comments) has no PDF representation
+	default:
+		for child := n.FirstChild(); child != nil; child = child.NextSibling() {
+			r.renderInline(pdf, child, source, style)
+		}
+	}
+}
+
+// writeText writes a run of text in the given style, as a link if needed.
+func (r *PDFRenderer) writeText(pdf *gofpdf.Fpdf, txt string, style inlineStyle) {
+	if txt == "" {
+		return
+	}
+	style.apply(pdf)
+	if style.link != "" {
+		r.writeLink(pdf, style.lineHeight, txt, style.link)
+		return
+	}
+	pdf.Write(style.lineHeight, txt)
+}
+
+// writeCodeSpan writes an inline code span in the code font on a light
+// rounded background, like code spans on GitHub. Spans that do not fit on the
+// current line wrap at spaces, and each line gets its own background box.
+func (r *PDFRenderer) writeCodeSpan(pdf *gofpdf.Fpdf, span *ast.CodeSpan, source []byte, style inlineStyle) {
+	codeStyle := r.codeSpanStyle(style)
+	codeStyle.apply(pdf)
+
+	pageWidth, _ := pdf.GetPageSize()
+	leftMargin, _, rightMargin, _ := pdf.GetMargins()
+	// pdf.Write keeps a cell margin on both sides of the text it places
+	reserve := 2*pdf.GetCellMargin() + 2*codeSpanPadding
+
+	txt := string(span.Text(source))
+	for txt != "" {
+		atLineStart := pdf.GetX() <= leftMargin+0.01
+		piece := fittingPrefix(pdf, txt, pageWidth-rightMargin-pdf.GetX()-reserve, atLineStart)
+		if piece == "" {
+			pdf.Ln(style.lineHeight)
+			continue
+		}
+		r.writeCodePiece(pdf, piece, codeStyle)
+
+		txt = strings.TrimLeft(txt[len(piece):], " ")
+		if txt != "" {
+			pdf.Ln(style.lineHeight)
+		}
+	}
+}
+
+// writeCodePiece writes code text that fits on the current line over its
+// background box.
+func (r *PDFRenderer) writeCodePiece(pdf *gofpdf.Fpdf, piece string, style inlineStyle) {
+	x, y := pdf.GetXY()
+	textWidth := pdf.GetStringWidth(piece)
+	size := pdf.PointToUnitConvert(style.size)
+
+	// gofpdf places the baseline 0.3 font sizes below the middle of the line;
+	// center the box on the glyphs rather than on the line
+	boxHeight := size * 1.2
+	boxY := y + style.lineHeight/2 - 0.55*size
+
+	fillR, fillG, fillB := pdf.GetFillColor()
+	background := r.codeBackground(true)
+	pdf.SetFillColor(background.R, background.G, background.B)
+	pdf.RoundedRect(x+pdf.GetCellMargin(), boxY, textWidth+2*codeSpanPadding, boxHeight, codeSpanRadius, "1234", "F")
+	pdf.SetFillColor(fillR, fillG, fillB)
+
+	pdf.SetX(x + codeSpanPadding)
+	r.writeText(pdf, piece, style)
+	pdf.SetX(x + textWidth + 2*codeSpanPadding)
+}
+
+// writeRedaction draws a redacted span as solid boxes as wide as its text
+// would be, wrapping at spaces like text. The text itself is never written,
+// so it cannot be selected, searched or extracted from the PDF.
+func (r *PDFRenderer) writeRedaction(pdf *gofpdf.Fpdf, span *parser.Redaction, style inlineStyle) {
+	style.apply(pdf)
+
+	pageWidth, _ := pdf.GetPageSize()
+	leftMargin, _, rightMargin, _ := pdf.GetMargins()
+	size := pdf.PointToUnitConvert(style.size)
+
+	txt := string(span.Hidden)
+	for txt != "" {
+		atLineStart := pdf.GetX() <= leftMargin+0.01
+		piece := fittingPrefix(pdf, txt, pageWidth-rightMargin-pdf.GetX()-2*pdf.GetCellMargin(), atLineStart)
+		if piece == "" {
+			pdf.Ln(style.lineHeight)
+			continue
+		}
+
+		// Cover the glyphs the way code span backgrounds do
+		x, y := pdf.GetXY()
+		width := pdf.GetStringWidth(piece)
+		fillR, fillG, fillB := pdf.GetFillColor()
+		pdf.SetFillColor(0, 0, 0)
+		pdf.Rect(x+pdf.GetCellMargin(), y+style.lineHeight/2-0.55*size, width, size*1.2, "F")
+		pdf.SetFillColor(fillR, fillG, fillB)
+		pdf.SetX(x + width)
+
+		txt = strings.TrimLeft(txt[len(piece):], " ")
+		if txt != "" {
+			pdf.Ln(style.lineHeight)
+		}
+	}
+}
+
+// codeSpanStyle derives the style of an inline code span from the
+// surrounding text style.
+func (r *PDFRenderer) codeSpanStyle(style inlineStyle) inlineStyle {
+	style.family = r.codeFont()
+	style.size = r.codeSize(style.size)
+	style.bold, style.italic = false, false
+	style.color = r.codeTextColor()
+	return style
+}
+
+// fittingPrefix returns the longest prefix of txt that fits in width,
+// breaking after whole words. When no word fits and breakAnywhere is set,
+// the text is broken between characters instead; otherwise "" is returned so
+// the caller can move to a new line first.
+func fittingPrefix(pdf *gofpdf.Fpdf, txt string, width float64, breakAnywhere bool) string {
+	if pdf.GetStringWidth(txt) <= width {
+		return txt
+	}
+
+	fit := ""
+	for i := 0; i < len(txt); i++ {
+		if txt[i] == ' ' && pdf.GetStringWidth(txt[:i]) <= width {
+			fit = txt[:i]
+		}
+	}
+	if fit != "" || !breakAnywhere {
+		return fit
+	}
+
+	// Always make progress, even when a single character is too wide
+	end := nextRune(txt, 0)
+	for end < len(txt) && pdf.GetStringWidth(txt[:nextRune(txt, end)]) <= width {
+		end = nextRune(txt, end)
+	}
+	return txt[:end]
+}
+
+// nextRune returns the byte offset of the rune after the one at i.
+func nextRune(txt string, i int) int {
+	_, size := utf8.DecodeRuneInString(txt[i:])
+	return i + size
+}
+
+// codeFont returns the configured code font, falling back to Courier.
+func (r *PDFRenderer) codeFont() string {
+	if r.config.CodeFont != "" {
+		return r.config.CodeFont
+	}
+	return "Courier"
+}
+
+// codeSize returns the code font size relative to the surrounding text size.
+// When the surrounding text is the body text the configured code size is used
+// as-is; scaled contexts (headers, footers) scale it proportionally.
+func (r *PDFRenderer) codeSize(textSize float64) float64 {
+	if r.config.CodeSize == 0 || r.config.FontSize == 0 {
+		return textSize
+	}
+	return r.config.CodeSize * textSize / r.config.FontSize
+}
+
+// writeInlineImage places an image inside flowing text, scaled to the line
+// height. Images that cannot be loaded fall back to their alt text.
+func (r *PDFRenderer) writeInlineImage(pdf *gofpdf.Fpdf, image *ast.Image, source []byte, style inlineStyle) {
+	destination := string(image.Destination)
+	altText := string(image.Text(source))
+
+	imageData, imageType, err := r.loadImage(destination)
+	if err != nil {
+		r.writeText(pdf, fmt.Sprintf("[%s]", altText), style)
+		return
+	}
+
+	imageName, info := r.registerImage(pdf, imageType, imageData, destination)
+	if info == nil {
+		r.writeText(pdf, fmt.Sprintf("[%s]", altText), style)
+		return
+	}
+
+	height := style.lineHeight
+	width := info.Width() * height / info.Height()
+
+	// Wrap to the next line if the image would cross the right margin
+	pageWidth, _ := pdf.GetPageSize()
+	_, _, rightMargin, _ := pdf.GetMargins()
+	x, y := pdf.GetXY()
+	if x+width > pageWidth-rightMargin {
+		pdf.Ln(style.lineHeight)
+		x, y = pdf.GetXY()
+	}
+
+	link, linkStr := r.linkTarget(pdf, style.link)
+	pdf.ImageOptions(imageName, x, y, width, height, false, gofpdf.ImageOptions{ImageType: imageType}, link, linkStr)
+	pdf.SetXY(x+width, y)
+}
+
+// inlineWidth measures the single-line width of inline content in mm.
+// It is used to align short snippets such as headers and footers.
+func (r *PDFRenderer) inlineWidth(pdf *gofpdf.Fpdf, parent ast.Node, source []byte, style inlineStyle) float64 {
+	var width float64
+	for child := parent.FirstChild(); child != nil; child = child.NextSibling() {
+		width += r.inlineNodeWidth(pdf, child, source, style)
+	}
+	return width
+}
+
+// inlineNodeWidth measures a single inline node, mirroring renderInline.
+func (r *PDFRenderer) inlineNodeWidth(pdf *gofpdf.Fpdf, node ast.Node, source []byte, style inlineStyle) float64 {
+	measure := func(txt string, s inlineStyle) float64 {
+		pdf.SetFont(s.family, s.fontStyle(), s.size)
+		return pdf.GetStringWidth(txt)
+	}
+
+	switch n := node.(type) {
+	case *ast.Text:
+		width := measure(string(n.Segment.Value(source)), style)
+		if n.SoftLineBreak() {
+			width += measure(" ", style)
+		}
+		return width
+	case *ast.String:
+		return measure(string(n.Value), style)
+	case *ast.Emphasis:
+		if n.Level >= 2 {
+			style.bold = true
+		} else {
+			style.italic = true
+		}
+	case *ast.CodeSpan:
+		return measure(string(n.Text(source)), r.codeSpanStyle(style)) + 2*codeSpanPadding
+	case *parser.Redaction:
+		return measure(string(n.Hidden), style)
+	case *parser.Sidenote:
+		return 0
+	case *ast.Link:
+		style.link = string(n.Destination)
+	case *ast.AutoLink:
+		return measure(string(n.Label(source)), style)
+	case *ast.Image:
+		// Inline images are scaled to the line height; approximate them as square
+		// when the image cannot be inspected without registering it.
+		if _, _, err := r.loadImage(string(n.Destination)); err != nil {
+			return measure(fmt.Sprintf("[%s]", n.Text(source)), style)
+		}
+		return style.lineHeight
+	case *ast.RawHTML:
+		return 0
+	}
+
+	var width float64
+	for child := node.FirstChild(); child != nil; child = child.NextSibling() {
+		width += r.inlineNodeWidth(pdf, child, source, style)
+	}
+	return width
+}
+
+// loadImage reads an image through the image cache, determines its gofpdf
+// image type from the file extension, applies the active content policy and
+// places images too faint for the page on the image backdrop.
+// Each destination is loaded and scanned once per render.
+func (r *PDFRenderer) loadImage(destination string) ([]byte, string, error) {
+	if scanned, ok := r.scanned[destination]; ok {
+		return scanned.data, scanned.imageType, scanned.err
+	}
+
+	path := ThemedImagePath(destination, r.config.Mermaid.Theme)
+	imageData, err := r.images.Load(path)
+	imageType := imageTypeFromPath(destination)
+	var file manifest.File
+	if err == nil && r.manifest != nil {
+		file = manifest.NewFile(path, imageData)
+	}
+	if err == nil {
+		imageData, err = r.checkActiveContent(destination, imageData, imageType)
+	}
+	if err == nil {
+		imageData, imageType = r.checkFaintImage(destination, imageData, imageType)
+	}
+
+	r.scanned[destination] = scannedImage{data: imageData, imageType: imageType, err: err, file: file}
+	if err != nil {
+		return nil, "", err
+	}
+	return imageData, imageType, nil
+}
+
+// imageTypeFromPath determines the gofpdf image type from a file extension.
+func imageTypeFromPath(destination string) string {
+	lower := strings.ToLower(destination)
+	switch {
+	case strings.HasSuffix(lower, ".jpg"), strings.HasSuffix(lower, ".jpeg"):
+		return "JPG"
+	case strings.HasSuffix(lower, ".gif"):
+		return "GIF"
+	default:
+		return "PNG"
+	}
+}
+
+// isStandaloneImage reports whether a paragraph consists of a single image,
+// optionally wrapped in a link. Such paragraphs are rendered as block figures
+// rather than inline images.
+func isStandaloneImage(paragraph ast.Node) (*ast.Image, bool) {
+	child := paragraph.FirstChild()
+	if child == nil || child.NextSibling() != nil {
+		return nil, false
+	}
+	if link, ok := child.(*ast.Link); ok {
+		child = link.FirstChild()
+		if child == nil || child.NextSibling() != nil {
+			return nil, false
+		}
+	}
+	image, ok := child.(*ast.Image)
+	return image, ok
+}
@@ -0,0 +1,300 @@
+package renderer
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jung-kurt/gofpdf"
+	"github.com/yuin/goldmark/ast"
+)
+
+func TestRender_InlineFormatting(t *testing.T) {
+	renderer := NewPDFRenderer(defaultTestConfig(), defaultTestDocumentMetadata(), nil)
+
+	node, source := parseMarkdown("Plain *italic* **bold** `code` and [a link](https://example.com).")
+
+	buf, err := renderer.Render(node, source)
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+
+	content := pdfContent(t, buf)
+	for _, want := range []string{"(Plain )", "(italic)", "(bold)", "(code)", "(a link)"} {
+		if !strings.Contains(content, want) {
+			t.Errorf("rendered content missing text run %s", want)
+		}
+	}
+
+	if !strings.Contains(buf.String(), "/URI (https://example.com)") {
+		t.Error("rendered PDF should contain a link annotation for the link destination")
+	}
+}
+
+func TestRender_AutoLink(t *testing.T) {
+	renderer := NewPDFRenderer(defaultTestConfig(), defaultTestDocumentMetadata(), nil)
+
+	node, source := parseMarkdown("Contact <someone@example.com> or <https://example.org>.")
+
+	buf, err := renderer.Render(node, source)
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+
+	pdfText := buf.String()
+	if !strings.Contains(pdfText, "/URI (mailto:someone@example.com)") {
+		t.Error("email autolink should produce a mailto: link annotation")
+	}
+	if !strings.Contains(pdfText, "/URI (https://example.org)") {
+		t.Error("URL autolink should produce a link annotation")
+	}
+}
+
+func TestRender_InlineImage(t *testing.T) {
+	tempDir := t.TempDir()
+	imagePath := filepath.Join(tempDir, "icon.png")
+	file, err := os.Create(imagePath)
+	if err != nil {
+		t.Fatalf("failed to create image: %v", err)
+	}
+	if err := writePNG(file, createTestPNG(10, 10)); err != nil {
+		t.Fatalf("failed to write PNG: %v", err)
+	}
+	_ = file.Close()
+
+	renderer := NewPDFRenderer(defaultTestConfig(), defaultTestDocumentMetadata(), nil)
+	node, source := parseMarkdown("Text with ![icon](" + imagePath + ") inside.")
+
+	buf, err := renderer.Render(node, source)
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+
+	if !strings.Contains(pdfContent(t, buf), " Do") {
+		t.Error("inline image should be drawn with the Do operator")
+	}
+}
+
+func TestRender_LinkedFigure(t *testing.T) {
+	tempDir := t.TempDir()
+	imagePath := filepath.Join(tempDir, "figure.png")
+	file, err := os.Create(imagePath)
+	if err != nil {
+		t.Fatalf("failed to create image: %v", err)
+	}
+	if err := writePNG(file, createTestPNG(10, 10)); err != nil {
+		t.Fatalf("failed to write PNG: %v", err)
+	}
+	_ = file.Close()
+
+	renderer := NewPDFRenderer(defaultTestConfig(), defaultTestDocumentMetadata(), nil)
+	node, source := parseMarkdown("[![figure](" + imagePath + ")](https://example.com/figure)\n\n" +
+		"[![figure](" + imagePath + ")](#details)\n\n" +
+		"[![missing](/nonexistent/figure.png)](https://example.com/missing)\n\n" +
+		"## Details\n")
+
+	buf, err := renderer.Render(node, source)
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+
+	data := buf.String()
+	if !strings.Contains(data, "/URI (https://example.com/figure)") {
+		t.Error("linked figure should have a link annotation for its URL")
+	}
+	if !strings.Contains(data, "/Dest [") {
+		t.Error("figure linked to a heading should link into the document")
+	}
+	if !strings.Contains(data, "/URI (https://example.com/missing)") {
+		t.Error("alt text of a missing linked figure should stay clickable")
+	}
+}
+
+func TestRender_InlineImageMissingFallsBackToAltText(t *testing.T) {
+	renderer := NewPDFRenderer(defaultTestConfig(), defaultTestDocumentMetadata(), nil)
+	node, source := parseMarkdown("Text with ![missing logo](/nonexistent/logo.png) inside.")
+
+	buf, err := renderer.Render(node, source)
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+
+	if !strings.Contains(pdfContent(t, buf), "[missing logo]") {
+		t.Error("missing inline image should fall back to its alt text")
+	}
+}
+
+func TestIsStandaloneImage(t *testing.T) {
+	tests := []struct {
+		name     string
+		markdown string
+		want     bool
+	}{
+		{"image_only", "![alt](image.png)", true},
+		{"linked_image", "[![alt](image.png)](https://example.com)", true},
+		{"image_with_text", "Look: ![alt](image.png)", false},
+		{"text_only", "Just text", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node, _ := parseMarkdown(tt.markdown)
+			paragraph := node.FirstChild()
+			if paragraph == nil || paragraph.Kind() != ast.KindParagraph {
+				t.Fatalf("expected a paragraph, got %v", paragraph)
+			}
+
+			_, got := isStandaloneImage(paragraph)
+			if got != tt.want {
+				t.Errorf("isStandaloneImage(%q) = %v, want %v", tt.markdown, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestImageTypeFromPath(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"photo.jpg", "JPG"},
+		{"photo.JPEG", "JPG"},
+		{"anim.gif", "GIF"},
+		{"diagram.png", "PNG"},
+		{"noextension", "PNG"},
+	}
+
+	for _, tt := range tests {
+		if got := imageTypeFromPath(tt.path); got != tt.want {
+			t.Errorf("imageTypeFromPath(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestCodeSize(t *testing.T) {
+	config := defaultTestConfig()
+	renderer := NewPDFRenderer(config, nil, nil)
+
+	if got := renderer.codeSize(config.FontSize); got != config.CodeSize {
+		t.Errorf("codeSize(body) = %v, want configured code size %v", got, config.CodeSize)
+	}
+
+	scaled := renderer.codeSize(config.FontSize / 2)
+	if scaled != config.CodeSize/2 {
+		t.Errorf("codeSize(half body) = %v, want %v", scaled, config.CodeSize/2)
+	}
+}
+
+func TestRender_CodeSpanBackground(t *testing.T) {
+	renderer := NewPDFRenderer(defaultTestConfig(), defaultTestDocumentMetadata(), nil)
+
+	node, source := parseMarkdown("Run `make test` before pushing.")
+	buf, err := renderer.Render(node, source)
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+
+	content := pdfContent(t, buf)
+	if strings.Count(content, "0.933 g") != 1 {
+		t.Error("code span should be drawn on one light gray background")
+	}
+	if !strings.Contains(content, "(make test)Tj") {
+		t.Error("code span text should be rendered in one piece")
+	}
+	// The background is drawn before the text so it does not cover it
+	if strings.Index(content, "0.933 g") > strings.Index(content, "(make test)Tj") {
+		t.Error("background should be drawn before the code text")
+	}
+}
+
+func TestRender_LongCodeSpanWraps(t *testing.T) {
+	renderer := NewPDFRenderer(defaultTestConfig(), defaultTestDocumentMetadata(), nil)
+
+	long := strings.Repeat("--option value ", 12)
+	node, source := parseMarkdown("Call `md-to-pdf convert " + long + "` to convert.")
+	buf, err := renderer.Render(node, source)
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+
+	// Each line of the span gets its own background box
+	if boxes := strings.Count(pdfContent(t, buf), "0.933 g"); boxes < 2 {
+		t.Errorf("expected a background per wrapped line, got %d", boxes)
+	}
+}
+
+func TestRender_Redaction(t *testing.T) {
+	config := defaultTestConfig()
+	_, content := renderMarkdown(t, config, "Agent ||Jane Smith|| met :redact[Q] in "+
+		"||"+strings.Repeat("a long redacted place name ", 8)+"|| today.")
+
+	for _, secret := range []string{"Jane", "Smith", "(Q)", "redacted"} {
+		if strings.Contains(content, secret) {
+			t.Errorf("redacted text %q should not be in the PDF", secret)
+		}
+	}
+	if !strings.Contains(content, "(Agent ") || !strings.Contains(content, " today.)") {
+		t.Error("text around the redactions should be written")
+	}
+	// One black box per redaction, and one per line of the wrapped one
+	if boxes := strings.Count(content, "re f"); boxes < 4 {
+		t.Errorf("expected a box per redacted span and wrapped line, got %d", boxes)
+	}
+}
+
+func TestRender_RedactionInFlattenedText(t *testing.T) {
+	// Blocks drawn as plain lines and notes, in the margin or set inline
+	// when the margin is too narrow
+	documents := map[string]struct {
+		markdown string
+		margin   float64
+	}{
+		"margin note": {"Text.^[contact ||SECRET|| now]\n", 50},
+		"inline note": {"Text.^[contact ||SECRET|| now]\n", 15},
+		"blockquote":  {"> Quoted ||SECRET|| text\n> — :redact[SECRET author]\n", 15},
+		"list item":   {"- Item ||SECRET|| text\n- Other\n", 15},
+		"heading":     {"# Title ||SECRET||\n\nText.\n", 15},
+		"wrapped":     {"> Start " + strings.Repeat("||SECRET words|| ", 30) + "end\n", 15},
+	}
+	for name, doc := range documents {
+		t.Run(name, func(t *testing.T) {
+			config := defaultTestConfig()
+			config.Margins.Left, config.Margins.Right = doc.margin, doc.margin
+			_, content := renderMarkdown(t, config, doc.markdown)
+
+			if strings.Contains(content, "SECRET") {
+				t.Errorf("redacted text reached the content stream:\n%s", content)
+			}
+			// Compared to the same text unredacted, which may have boxes of
+			// its own such as the bar of a blockquote
+			unredacted := strings.NewReplacer("||", "", ":redact[SECRET author]", "SECRET author").Replace(doc.markdown)
+			_, plain := renderMarkdown(t, config, unredacted)
+			if strings.Count(content, "re f") <= strings.Count(plain, "re f") {
+				t.Error("the redacted span should be drawn as a box")
+			}
+		})
+	}
+}
+
+func TestFittingPrefix(t *testing.T) {
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.SetFont("Courier", "", 10)
+	charWidth := pdf.GetStringWidth("x")
+
+	if got := fittingPrefix(pdf, "short", 100, false); got != "short" {
+		t.Errorf("text that fits should be returned whole, got %q", got)
+	}
+	if got := fittingPrefix(pdf, "one two three", charWidth*9, false); got != "one two" {
+		t.Errorf("expected a break after whole words, got %q", got)
+	}
+	if got := fittingPrefix(pdf, "unbreakable", charWidth*4, false); got != "" {
+		t.Errorf("expected no prefix when no word fits, got %q", got)
+	}
+	if got := fittingPrefix(pdf, "unbreakable", charWidth*4, true); got != "unbr" {
+		t.Errorf("expected a character break at the line start, got %q", got)
+	}
+	if got := fittingPrefix(pdf, "wide", 0, true); got != "w" {
+		t.Errorf("expected at least one character, got %q", got)
+	}
+}
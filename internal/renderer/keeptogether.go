@@ -0,0 +1,95 @@
+package renderer
+
+import (
+	"maps"
+
+	"github.com/jung-kurt/gofpdf"
+	"github.com/yuin/goldmark/ast"
+)
+
+// Keep-together markers: <!-- keep-together --> keeps the block after it on
+// one page, or every block up to a later <!-- end-keep-together -->, such
+// as a figure and its caption.
+const (
+	keepTogetherMarker    = "<!-- keep-together -->"
+	endKeepTogetherMarker = "<!-- end-keep-together -->"
+)
+
+// keepGroupTogether starts a new page unless the blocks a keep-together
+// marker applies to fit on the current one.
+func (r *PDFRenderer) keepGroupTogether(pdf *gofpdf.Fpdf, marker *ast.HTMLBlock, source []byte) {
+	blocks := keepTogetherBlocks(marker, source)
+	if len(blocks) == 0 {
+		return
+	}
+	r.keepTogether(pdf, r.measureBlocks(pdf, blocks, source))
+}
+
+// keepTogetherBlocks returns the blocks a keep-together marker applies to:
+// those up to the next end marker, or else the next block.
+func keepTogetherBlocks(marker *ast.HTMLBlock, source []byte) []ast.Node {
+	var blocks []ast.Node
+	for n := marker.NextSibling(); n != nil; n = n.NextSibling() {
+		if block, ok := n.(*ast.HTMLBlock); ok {
+			text := htmlMarker(block, source)
+			if text == endKeepTogetherMarker {
+				return blocks
+			}
+			if text == keepTogetherMarker {
+				break
+			}
+		}
+		blocks = append(blocks, n)
+	}
+	if len(blocks) == 0 {
+		return nil
+	}
+	return blocks[:1]
+}
+
+// measureBlocks returns the height blocks take when rendered from the top of
+// a page like the current one, or a page height when they do not fit on
+// one. They are rendered on a scratch document without leaving a trace: the
+// renderer state, warnings and scanned images are put back afterwards, so
+// rendering them for real repeats the same warnings.
+func (r *PDFRenderer) measureBlocks(pdf *gofpdf.Fpdf, blocks []ast.Node, source []byte) float64 {
+	width, height := pdf.GetPageSize()
+	left, top, right, bottom := pdf.GetMargins()
+	scratch := gofpdf.NewCustom(&gofpdf.InitType{
+		OrientationStr: "P",
+		UnitStr:        "mm",
+		Size:           gofpdf.SizeType{Wd: width, Ht: height},
+	})
+	scratch.SetMargins(left, top, right)
+	scratch.SetAutoPageBreak(true, bottom)
+	scratch.AddPage()
+	r.bodyStyle().apply(scratch)
+
+	saved := *r
+	// Link IDs and positions belong to the real document, so the scratch
+	// rendering gets anchors and table of contents state of its own
+	r.anchors = anchorState{
+		slugs:  saved.anchors.slugs,
+		known:  saved.anchors.known,
+		spots:  make(map[string]spot),
+		ids:    make(map[string]int),
+		warned: maps.Clone(saved.anchors.warned),
+	}
+	r.toc = tocState{entries: saved.toc.entries, marker: saved.toc.marker, drawn: true}
+	r.scanned = maps.Clone(saved.scanned)
+	r.unknownLanguages = maps.Clone(saved.unknownLanguages)
+	r.resumePortrait = false
+
+	for _, block := range blocks {
+		if err := r.walkAST(scratch, block, source); err != nil {
+			break
+		}
+	}
+	measured := scratch.GetY() - top
+	if scratch.PageNo() > 1 {
+		measured = height
+	}
+
+	*r = saved
+	return measured
+}
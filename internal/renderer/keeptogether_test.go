@@ -0,0 +1,71 @@
+package renderer
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/fredcamaral/md-to-pdf/internal/parser"
+	"github.com/yuin/goldmark/ast"
+)
+
+func TestKeepTogetherBlocks(t *testing.T) {
+	tests := []struct {
+		name     string
+		markdown string
+		want     int
+	}{
+		{"next block", "<!-- keep-together -->\n\nOne\n\nTwo\n", 1},
+		{"up to the end marker", "<!-- keep-together -->\n\n![figure](f.png)\n\n*Caption*\n\n<!-- end-keep-together -->\n\nAfter\n", 2},
+		{"another marker first", "<!-- keep-together -->\n\nOne\n\n<!-- keep-together -->\n\nTwo\n\n<!-- end-keep-together -->\n", 1},
+		{"nothing after", "Before\n\n<!-- keep-together -->\n", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node, err := parser.NewMarkdownParser().Parse([]byte(tt.markdown))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var marker *ast.HTMLBlock
+			for n := node.FirstChild(); n != nil && marker == nil; n = n.NextSibling() {
+				marker, _ = n.(*ast.HTMLBlock)
+			}
+			if got := len(keepTogetherBlocks(marker, []byte(tt.markdown))); got != tt.want {
+				t.Errorf("keepTogetherBlocks() returned %d blocks, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRender_KeepTogether(t *testing.T) {
+	var body strings.Builder
+	for i := 0; i < 12; i++ {
+		body.WriteString("Line of text.\n\n")
+	}
+	group := "### Steps\n\n```graphviz\ndigraph { a -> b }\n```\n\n- One\n- Two\n- Three\n- Four\n- Five\n- Six\n"
+
+	render := func(markdown string) *PDFRenderer {
+		renderer := NewPDFRenderer(defaultTestConfig(), defaultTestDocumentMetadata(), nil)
+		node, err := parser.NewMarkdownParser().Parse([]byte(markdown))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := renderer.Render(node, []byte(markdown)); err != nil {
+			t.Fatalf("Render failed: %v", err)
+		}
+		return renderer
+	}
+
+	split := render(body.String() + group)
+	if page := split.Headings()[0].Page; page != 1 {
+		t.Fatalf("test needs the group to start on page 1 without the marker, got page %d", page)
+	}
+
+	kept := render(body.String() + "<!-- keep-together -->\n\n" + group + "\n<!-- end-keep-together -->\n")
+	headings := kept.Headings()
+	if len(headings) != 1 || headings[0].Page != 2 {
+		t.Errorf("the group should move to page 2 as a whole, got headings %+v", headings)
+	}
+	if warnings := kept.Warnings(); len(warnings) != 1 || !strings.Contains(warnings[0], `"graphviz"`) {
+		t.Errorf("measuring the group should not repeat or drop warnings, got %v", warnings)
+	}
+}
@@ -0,0 +1,30 @@
+package renderer
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/yuin/goldmark/ast"
+)
+
+// checkCodeLanguage warns, once per language and document, about a fenced
+// code block whose language has no registered handler. The block is still
+// rendered as plain code.
+func (r *PDFRenderer) checkCodeLanguage(block *ast.FencedCodeBlock, source []byte) {
+	language := strings.TrimSpace(string(block.Language(source)))
+	if language == "" {
+		return
+	}
+	if _, ok := r.languages.Lookup(language); ok {
+		return
+	}
+	key := strings.ToLower(language)
+	if r.unknownLanguages[key] {
+		return
+	}
+	if r.unknownLanguages == nil {
+		r.unknownLanguages = make(map[string]bool)
+	}
+	r.unknownLanguages[key] = true
+	r.warn(fmt.Sprintf("no handler for %q code blocks, rendered as plain code", language))
+}
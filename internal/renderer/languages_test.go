@@ -0,0 +1,27 @@
+package renderer
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRender_UnknownCodeLanguageWarnsOnce(t *testing.T) {
+	renderer := NewPDFRenderer(defaultTestConfig(), defaultTestDocumentMetadata(), nil)
+
+	markdown := "```text\nplain\n```\n\n```\nno language\n```\n\n" +
+		"```go\nfmt.Println(1)\n```\n\n```bash\necho hi\n```\n\n```json\n{}\n```\n\n" +
+		"```graphviz\ndigraph { a -> b }\n```\n\n```Graphviz\ndigraph { b -> c }\n```\n"
+	node, source := parseMarkdown(markdown)
+	buf, err := renderer.Render(node, source)
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+
+	warnings := renderer.Warnings()
+	if len(warnings) != 1 || !strings.Contains(warnings[0], `"graphviz"`) {
+		t.Errorf("expected one warning about graphviz, got %v", warnings)
+	}
+	if content := pdfContent(t, buf); !strings.Contains(content, "digraph { b -> c }") {
+		t.Error("blocks in an unknown language should be rendered as plain code")
+	}
+}
@@ -0,0 +1,40 @@
+package renderer
+
+import (
+	"fmt"
+
+	"github.com/fredcamaral/md-to-pdf/internal/manifest"
+	"github.com/jung-kurt/gofpdf"
+)
+
+// SetManifest sets the manifest attached to the documents rendered next,
+// which the renderer completes with the images they embed. nil attaches
+// none.
+func (r *PDFRenderer) SetManifest(m *manifest.Manifest) {
+	r.manifest = m
+}
+
+// attachManifest attaches the manifest, listing the images loaded by the
+// render, to the document.
+func (r *PDFRenderer) attachManifest(pdf *gofpdf.Fpdf) error {
+	if r.manifest == nil {
+		return nil
+	}
+	m := *r.manifest
+	m.Assets = nil
+	for _, image := range r.scanned {
+		if image.err == nil && image.file.SHA256 != "" {
+			m.Assets = append(m.Assets, image.file)
+		}
+	}
+	data, err := m.Marshal()
+	if err != nil {
+		return fmt.Errorf("failed to encode manifest: %w", err)
+	}
+	pdf.SetAttachments([]gofpdf.Attachment{{
+		Content:     data,
+		Filename:    manifest.FileName,
+		Description: "Sources this PDF was produced from",
+	}})
+	return nil
+}
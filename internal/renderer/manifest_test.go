@@ -0,0 +1,58 @@
+package renderer
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/fredcamaral/md-to-pdf/internal/manifest"
+	"github.com/fredcamaral/md-to-pdf/internal/parser"
+)
+
+func TestRender_Manifest(t *testing.T) {
+	imagePath := filepath.Join(t.TempDir(), "chart.png")
+	file, err := os.Create(imagePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := writePNG(file, createTestPNG(10, 10)); err != nil {
+		t.Fatal(err)
+	}
+	file.Close()
+	imageData, err := os.ReadFile(imagePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	markdown := "# Report\n\n![chart](" + imagePath + ")\n\n![missing](missing.png)\n"
+	render := func(m *manifest.Manifest) []byte {
+		renderer := NewPDFRenderer(defaultTestConfig(), defaultTestDocumentMetadata(), nil)
+		renderer.SetManifest(m)
+		node, err := parser.NewMarkdownParser().Parse([]byte(markdown))
+		if err != nil {
+			t.Fatal(err)
+		}
+		buf, err := renderer.Render(node, []byte(markdown))
+		if err != nil {
+			t.Fatalf("Render failed: %v", err)
+		}
+		return buf.Bytes()
+	}
+
+	source := manifest.NewFile("report.md", []byte(markdown))
+	attached, err := manifest.Read(render(&manifest.Manifest{Format: manifest.Format, ToolVersion: "1.0.0", Source: source}))
+	if err != nil {
+		t.Fatalf("manifest.Read failed: %v", err)
+	}
+	if attached.Source != source || attached.ToolVersion != "1.0.0" {
+		t.Errorf("manifest = %+v, want the one set", attached)
+	}
+	if want := manifest.NewFile(imagePath, imageData); len(attached.Assets) != 1 || attached.Assets[0] != want {
+		t.Errorf("assets = %+v, want only the image that loaded, %+v", attached.Assets, want)
+	}
+
+	if _, err := manifest.Read(render(nil)); !errors.Is(err, manifest.ErrNoManifest) {
+		t.Errorf("without a manifest set none should be attached, got %v", err)
+	}
+}
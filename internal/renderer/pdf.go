@@ -3,8 +3,11 @@ package renderer
 import (
 	"bytes"
 	"fmt"
+	"math"
 	"os"
 
+	"github.com/fredcamaral/md-to-pdf/internal/manifest"
+	"github.com/fredcamaral/md-to-pdf/internal/outline"
 	"github.com/fredcamaral/md-to-pdf/internal/plugins"
 	"github.com/jung-kurt/gofpdf"
 	"github.com/yuin/goldmark/ast"
@@ -17,16 +20,38 @@ type RenderConfig struct {
 	FontFamily   string
 	FontSize     float64
 	HeadingScale float64
-	LineSpacing  float64
-	CodeFont     string
-	CodeSize     float64
-	Mermaid      MermaidConfig
+	// HeadingMinSize is the smallest font size in points that headings too
+	// long for one line may shrink to before wrapping (0 = wrap only)
+	HeadingMinSize float64
+	LineSpacing    float64
+	CodeFont       string
+	CodeSize       float64
+	Mermaid        MermaidConfig
+	HeaderFooter   HeaderFooterConfig
+	ImagePolicy    string // Active content policy for images: "warn", "sanitize" or "refuse"
+	BaselineGrid   bool   // Snap block spacing to multiples of the body line height
+	ThematicBreak  ThematicBreakConfig
+	QuoteStyle     QuoteStyleConfig
+	Colors         ColorConfig
+	SidenoteSide   string // Margin for ^[sidenotes]: "outer" (default), "right" or "left"
+	TOC            TOCConfig
+	Banner         BannerConfig
+	// KeepWithNext keeps headings on the page of the text after them:
+	// "headings" (default) or "none"
+	KeepWithNext string
+	// MinLinesAfterHeading is how many lines of text must fit below a
+	// heading to keep it on its page (default 1)
+	MinLinesAfterHeading int
 }
 
 type MermaidConfig struct {
 	Scale     float64 // Scaling factor for mermaid diagrams
 	MaxWidth  float64 // Maximum width in mm (0 = use page width)
 	MaxHeight float64 // Maximum height in mm
+	Theme     string  // Diagram variant: "light" (default) or "dark"
+	// WideStrategy places diagrams too wide for the column: "shrink"
+	// (default), "rotate" or "landscape"
+	WideStrategy string
 }
 
 type Margins struct {
@@ -38,15 +63,54 @@ type Margins struct {
 
 // DocumentMetadata holds PDF document metadata
 type DocumentMetadata struct {
-	Title   string
-	Author  string
-	Subject string
+	Title      string
+	Author     string
+	Subject    string
+	DateFormat string // Go time layout for the {date} template variable
 }
 
 type PDFRenderer struct {
 	config   *RenderConfig
 	document *DocumentMetadata
 	plugins  *plugins.Manager
+	images   *ImageCache
+
+	// headings records the headings of the last rendered document with
+	// the page each one landed on, for outline export.
+	headings []outline.Heading
+
+	// Per-render image scanning state: each destination is scanned once,
+	// warnings are collected for the caller and a refused image fails the render.
+	scanned     map[string]scannedImage
+	warnings    []string
+	securityErr error
+
+	// languages maps fenced code block languages to their handlers, and
+	// unknownLanguages holds those without one already warned about
+	languages        *plugins.LanguageRegistry
+	unknownLanguages map[string]bool
+
+	sidenotes sidenoteState
+	anchors   anchorState
+	toc       tocState
+
+	// generated holds the elements of content generators by phase, made
+	// once per document and rendered again by every table of contents pass
+	generated map[plugins.GenerationPhase][]plugins.PDFElement
+
+	// resumePortrait is set after a landscape diagram page, so the next
+	// content starts a portrait page
+	resumePortrait bool
+
+	// sourceFile names the markdown file being rendered for plugins
+	sourceFile string
+
+	// title replaces the configured document title when set
+	title string
+
+	// manifest is attached to the documents rendered next, with the images
+	// they embed, when set
+	manifest *manifest.Manifest
 }
 
 func NewPDFRenderer(config *RenderConfig, document *DocumentMetadata, pluginManager *plugins.Manager) *PDFRenderer {
@@ -54,27 +118,108 @@ func NewPDFRenderer(config *RenderConfig, document *DocumentMetadata, pluginMana
 		config:   config,
 		document: document,
 		plugins:  pluginManager,
+		images:   NewImageCache(),
+		scanned:  make(map[string]scannedImage),
+	}
+}
+
+// SetSourceFile sets the path of the markdown file passed to content
+// generators in their render context ("stdin" for piped input).
+func (r *PDFRenderer) SetSourceFile(path string) {
+	r.sourceFile = path
+}
+
+// SetTitle sets the title of the documents rendered next, used instead of
+// the configured one. An empty title restores the configured one.
+func (r *PDFRenderer) SetTitle(title string) {
+	r.title = title
+}
+
+// metadata returns the document metadata with the title set by SetTitle.
+func (r *PDFRenderer) metadata() *DocumentMetadata {
+	if r.title == "" {
+		return r.document
+	}
+	document := DocumentMetadata{}
+	if r.document != nil {
+		document = *r.document
 	}
+	document.Title = r.title
+	return &document
+}
+
+// SetImageCache makes the renderer share an image cache with other renderers.
+func (r *PDFRenderer) SetImageCache(cache *ImageCache) {
+	r.images = cache
 }
 
 func (r *PDFRenderer) Render(node ast.Node, source []byte) (*bytes.Buffer, error) {
+	// Apply AST transformers before rendering, once even when the document
+	// is rendered more than once
+	if r.plugins != nil {
+		transformedNode, err := r.plugins.TransformDocument(node, source)
+		if err != nil {
+			return nil, err
+		}
+		node = transformedNode
+	}
+	r.generated = make(map[plugins.GenerationPhase][]plugins.PDFElement)
+
+	entries, ok := r.planTOC(node, source)
+	if !ok {
+		return r.render(node, source, nil)
+	}
+
+	// The table of contents shows the page of each heading, which is only
+	// known after rendering: render again until the pages stop moving
+	for pass := 1; ; pass++ {
+		buf, err := r.render(node, source, entries)
+		if err != nil {
+			return nil, err
+		}
+		rendered := r.tocEntries(r.headings)
+		if pass == maxRenderPasses || sameEntries(entries, rendered) {
+			return buf, nil
+		}
+		entries = rendered
+	}
+}
+
+// render renders the document once, with the given table of contents
+// entries if it has one.
+func (r *PDFRenderer) render(node ast.Node, source []byte, toc []outline.Heading) (*bytes.Buffer, error) {
+	r.headings = nil
+	r.scanned = make(map[string]scannedImage)
+	r.warnings = nil
+	r.securityErr = nil
+	r.languages = r.plugins.Languages()
+	r.unknownLanguages = nil
+	r.sidenotes = sidenoteState{}
+	r.anchors = r.newAnchorState(node, source)
+	r.toc = tocState{entries: toc, marker: r.toc.marker}
+	r.resumePortrait = false
+
 	pdf := gofpdf.New("P", "mm", r.config.PageSize, "")
 	pdf.SetMargins(r.config.Margins.Left, r.config.Margins.Top, r.config.Margins.Right)
 	pdf.SetAutoPageBreak(true, r.config.Margins.Bottom)
+	r.setupHeaderFooter(pdf)
 	pdf.AddPage()
-	pdf.SetFont(r.config.FontFamily, "", r.config.FontSize)
+	r.bodyStyle().apply(pdf)
 
 	// Set document metadata if available
-	if r.document != nil {
-		pdf.SetTitle(r.document.Title, false)
-		pdf.SetAuthor(r.document.Author, false)
-		pdf.SetSubject(r.document.Subject, false)
+	if document := r.metadata(); document != nil {
+		pdf.SetTitle(document.Title, false)
+		pdf.SetAuthor(document.Author, false)
+		pdf.SetSubject(document.Subject, false)
+	}
+	if err := checkPDF(pdf, fmt.Sprintf("document setup (font %q)", r.config.FontFamily)); err != nil {
+		return nil, err
 	}
 
 	// Generate BeforeContent elements (e.g., TOC, cover page)
 	if r.plugins != nil {
 		ctx := r.createRenderContext(pdf, source)
-		elements, err := r.plugins.GenerateContent(plugins.BeforeContent, ctx)
+		elements, err := r.generateContent(plugins.BeforeContent, ctx)
 		if err != nil {
 			return nil, fmt.Errorf("failed to generate before content: %w", err)
 		}
@@ -82,6 +227,16 @@ func (r *PDFRenderer) Render(node ast.Node, source []byte) (*bytes.Buffer, error
 			if renderErr := elem.Render(pdf, ctx); renderErr != nil {
 				return nil, fmt.Errorf("failed to render before content element: %w", renderErr)
 			}
+			if err := checkPDF(pdf, "before content element"); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if r.config.TOC.Enabled && !r.toc.marker {
+		r.renderTOC(pdf)
+		if err := checkPDF(pdf, "table of contents"); err != nil {
+			return nil, err
 		}
 	}
 
@@ -93,30 +248,69 @@ func (r *PDFRenderer) Render(node ast.Node, source []byte) (*bytes.Buffer, error
 	// Generate AfterContent elements (e.g., appendix, index)
 	if r.plugins != nil {
 		ctx := r.createRenderContext(pdf, source)
-		elements, err := r.plugins.GenerateContent(plugins.AfterContent, ctx)
+		elements, err := r.generateContent(plugins.AfterContent, ctx)
 		if err != nil {
 			return nil, fmt.Errorf("failed to generate after content: %w", err)
 		}
+		if len(elements) > 0 {
+			r.resumePage(pdf)
+		}
 		for _, elem := range elements {
 			if renderErr := elem.Render(pdf, ctx); renderErr != nil {
 				return nil, fmt.Errorf("failed to render after content element: %w", renderErr)
 			}
+			if err := checkPDF(pdf, "after content element"); err != nil {
+				return nil, err
+			}
 		}
 	}
 
+	if r.securityErr != nil {
+		return nil, r.securityErr
+	}
+	if err := r.attachManifest(pdf); err != nil {
+		return nil, err
+	}
+
+	// Output also reports errors raised while closing the document, such as
+	// in headers and footers of the last page
 	var buf bytes.Buffer
 	err = pdf.Output(&buf)
 	if err != nil {
-		return nil, err
+		return nil, &RenderError{Element: "PDF output", Page: pdf.PageNo(), Cause: err}
 	}
 
 	return &buf, nil
 }
 
+// generateContent returns the elements of the content generators for phase,
+// running them only on the first pass over the document.
+func (r *PDFRenderer) generateContent(phase plugins.GenerationPhase, ctx *plugins.RenderContext) ([]plugins.PDFElement, error) {
+	if elements, ok := r.generated[phase]; ok {
+		return elements, nil
+	}
+	elements, err := r.plugins.GenerateContent(phase, ctx)
+	if err != nil {
+		return nil, err
+	}
+	r.generated[phase] = elements
+	return elements, nil
+}
+
 // createRenderContext creates a render context for plugin content generation
 func (r *PDFRenderer) createRenderContext(pdf *gofpdf.Fpdf, source []byte) *plugins.RenderContext {
 	pageWidth, pageHeight := pdf.GetPageSize()
+	document := &plugins.Document{
+		Metadata:   make(map[string]interface{}),
+		SourceFile: r.sourceFile,
+	}
+	if metadata := r.metadata(); metadata != nil {
+		document.Title = metadata.Title
+		document.Author = metadata.Author
+		document.Subject = metadata.Subject
+	}
 	return &plugins.RenderContext{
+		Document:   document,
 		PDF:        pdf,
 		Source:     source,
 		PageWidth:  pageWidth,
@@ -133,19 +327,14 @@ func (r *PDFRenderer) createRenderContext(pdf *gofpdf.Fpdf, source []byte) *plug
 }
 
 func (r *PDFRenderer) walkAST(pdf *gofpdf.Fpdf, node ast.Node, source []byte) error {
-	// Apply AST transformers before rendering
-	if r.plugins != nil {
-		transformedNode, err := r.applyTransformers(node, source)
-		if err != nil {
-			return err
-		}
-		node = transformedNode
-	}
-
 	return ast.Walk(node, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
 		if !entering {
 			return ast.WalkContinue, nil
 		}
+		// A mermaid diagram after a landscape diagram page may get one too
+		if n.Kind() != ast.KindDocument && !isMermaidParagraph(n) {
+			r.resumePage(pdf)
+		}
 
 		switch n.Kind() {
 		case ast.KindDocument:
@@ -154,6 +343,7 @@ func (r *PDFRenderer) walkAST(pdf *gofpdf.Fpdf, node ast.Node, source []byte) er
 			r.renderHeading(pdf, n.(*ast.Heading), source)
 		case ast.KindParagraph:
 			r.renderParagraph(pdf, n.(*ast.Paragraph), source)
+			return ast.WalkSkipChildren, nil
 		case ast.KindText:
 			// Text nodes are handled by their parent (paragraph, heading, etc.)
 			// to ensure proper text aggregation and formatting
@@ -171,70 +361,141 @@ func (r *PDFRenderer) walkAST(pdf *gofpdf.Fpdf, node ast.Node, source []byte) er
 			r.renderThematicBreak(pdf)
 		case ast.KindImage:
 			r.renderImage(pdf, n.(*ast.Image), source)
+		case ast.KindHTMLBlock:
+			r.renderHTMLBlock(pdf, n.(*ast.HTMLBlock), source)
 		case ast.KindLink:
 			// Links are handled inline within text rendering
 		}
 
+		// Stop at the first gofpdf error: later calls would silently do nothing
+		if err := checkPDF(pdf, describeNode(n, source)); err != nil {
+			return ast.WalkStop, err
+		}
 		return ast.WalkContinue, nil
 	})
 }
 
-func (r *PDFRenderer) applyTransformers(node ast.Node, source []byte) (ast.Node, error) {
-	result := node
+func (r *PDFRenderer) renderHeading(pdf *gofpdf.Fpdf, heading *ast.Heading, source []byte) {
+	// Add space before heading
+	r.blockGap(pdf, gapSection)
 
-	err := ast.Walk(result, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
-		if !entering {
-			return ast.WalkContinue, nil
-		}
+	fontSize := r.headingFontSize(heading.Level)
+	pdf.SetFont(r.config.FontFamily, "B", fontSize)
 
-		ctx := &plugins.TransformContext{
-			CurrentNode: n,
-			Parent:      n.Parent(),
-			Source:      source,
-			Metadata:    make(map[string]interface{}),
-			Config:      make(map[string]interface{}),
-		}
+	title := flatText(heading, source)
 
-		transformedNode, err := r.plugins.ApplyTransformers(n, ctx)
-		if err != nil {
-			return ast.WalkStop, err
-		}
+	// Long headings shrink towards the minimum size, then wrap
+	width := r.headingWidth(pdf)
+	fontSize = r.fitHeadingSize(pdf, title, fontSize, width)
+	pdf.SetFont(r.config.FontFamily, "B", fontSize)
+	lineHeight := fontSize * 1.1
+	// SplitLines subtracts the cell margins itself and, like MultiCell, measures
+	// bytes, so text outside Latin-1 is counted the way it is drawn
+	lines := max(len(pdf.SplitLines([]byte(title), width+2*pdf.GetCellMargin())), 1)
 
-		// If the node was transformed, replace it
-		if transformedNode != n {
-			if n.Parent() != nil {
-				n.Parent().ReplaceChild(n.Parent(), n, transformedNode)
-			}
-		}
+	// Widow control: keep the heading in one piece and together with the
+	// first lines of the following text
+	r.keepTogether(pdf, float64(lines)*lineHeight+r.keepWithNextHeight(heading))
 
-		return ast.WalkContinue, nil
-	})
+	r.recordHeading(pdf, heading, source)
+	r.redactedMultiCell(pdf, lineHeight, title, "L")
 
-	return result, err
+	// Add space after heading
+	r.blockGap(pdf, gapParagraph)
 }
 
-func (r *PDFRenderer) renderHeading(pdf *gofpdf.Fpdf, heading *ast.Heading, source []byte) {
-	// Add space before heading
-	pdf.Ln(5)
+// headingFontSize returns the font size of headings of level before any
+// shrinking to fit.
+func (r *PDFRenderer) headingFontSize(level int) float64 {
+	return r.config.FontSize + float64(6-level)*2
+}
 
-	fontSize := r.config.FontSize + float64(6-heading.Level)*2
-	pdf.SetFont(r.config.FontFamily, "B", fontSize)
+// keepWithNextHeight returns the space below a heading that must be left on
+// its page: the gap after it and MinLinesAfterHeading lines of body text,
+// plus the next heading when headings follow each other, so a run of
+// headings moves to the next page together. It is zero when KeepWithNext is
+// "none", which lets headings end a page.
+func (r *PDFRenderer) keepWithNextHeight(heading *ast.Heading) float64 {
+	if r.config.KeepWithNext == "none" {
+		return 0
+	}
+	minLines := max(r.config.MinLinesAfterHeading, 1)
+	height := gapParagraph*r.config.FontSize + float64(minLines)*r.bodyStyle().lineHeight
+	if next, ok := heading.NextSibling().(*ast.Heading); ok {
+		height += (gapSection+gapParagraph)*r.config.FontSize + r.headingFontSize(next.Level)*1.1
+	}
+	return height
+}
 
-	// Extract heading text
-	var headingText string
-	for child := heading.FirstChild(); child != nil; child = child.NextSibling() {
-		if child.Kind() == ast.KindText {
-			text := child.(*ast.Text)
-			headingText += string(text.Segment.Value(source))
-		}
+// headingWidth returns the width available to heading text, matching the
+// width MultiCell wraps at.
+func (r *PDFRenderer) headingWidth(pdf *gofpdf.Fpdf) float64 {
+	pageWidth, _ := pdf.GetPageSize()
+	leftMargin, _, rightMargin, _ := pdf.GetMargins()
+	return pageWidth - leftMargin - rightMargin - 2*pdf.GetCellMargin()
+}
+
+// fitHeadingSize shrinks the font size of a heading that does not fit on one
+// line, in half-point steps down to HeadingMinSize. The current font style
+// must be the heading style.
+func (r *PDFRenderer) fitHeadingSize(pdf *gofpdf.Fpdf, text string, size, width float64) float64 {
+	minSize := r.config.HeadingMinSize
+	if minSize <= 0 || minSize >= size {
+		return size
+	}
+	for size > minSize && pdf.GetStringWidth(text) > width {
+		size = max(size-0.5, minSize)
+		pdf.SetFontSize(size)
 	}
+	return size
+}
 
-	// Render heading with proper line break
-	pdf.Cell(0, fontSize*1.1, headingText)
-	pdf.Ln(fontSize * 1.1)
+// Vertical space between blocks, in multiples of the body font size, so
+// that the rhythm of the page scales with the font. At the default size of
+// 12 they come to 2, 3, 4 and 5mm.
+const (
+	gapParagraph = 1.0 / 6  // After paragraphs, headings, lists and quotes
+	gapBlock     = 1.0 / 4  // Around code blocks and images
+	gapTitle     = 1.0 / 3  // After the table of contents title
+	gapSection   = 5.0 / 12 // Before headings, around rules and diagrams
+)
 
-	// Add space after heading
-	pdf.Ln(2)
+// blockGap adds vertical space between blocks, gap times the body font
+// size. In baseline grid mode the cursor then moves down to the next grid
+// line, so every block starts on a multiple of the body line height
+// measured from the top margin.
+func (r *PDFRenderer) blockGap(pdf *gofpdf.Fpdf, gap float64) {
+	pdf.Ln(gap * r.config.FontSize)
+	if !r.config.BaselineGrid {
+		return
+	}
+
+	unit := r.config.FontSize * 1.2
+	_, top, _, bottom := pdf.GetMargins()
+	_, pageHeight := pdf.GetPageSize()
+
+	// Tolerate rounding so a cursor already on a grid line stays there
+	lines := math.Ceil((pdf.GetY()-top)/unit - 1e-6)
+	y := top + lines*unit
+	if y > pageHeight-bottom {
+		// The next grid line is past the bottom margin; the next block
+		// starts on a new page, which begins on the grid
+		y = pageHeight - bottom
+	}
+	pdf.SetY(y)
+}
+
+// keepTogether starts a new page unless height fits between the current
+// position and the bottom margin. Blocks taller than a page are left alone.
+func (r *PDFRenderer) keepTogether(pdf *gofpdf.Fpdf, height float64) {
+	_, pageHeight := pdf.GetPageSize()
+	_, top, _, bottom := pdf.GetMargins()
+	if height > pageHeight-top-bottom {
+		return
+	}
+	if pdf.GetY()+height > pageHeight-bottom {
+		pdf.AddPage()
+	}
 }
 
 func (r *PDFRenderer) renderParagraph(pdf *gofpdf.Fpdf, paragraph *ast.Paragraph, source []byte) {
@@ -246,53 +507,51 @@ func (r *PDFRenderer) renderParagraph(pdf *gofpdf.Fpdf, paragraph *ast.Paragraph
 		}
 	}
 
-	pdf.SetFont(r.config.FontFamily, "", r.config.FontSize)
-
-	// Extract all text from paragraph
-	var paragraphText string
-	for child := paragraph.FirstChild(); child != nil; child = child.NextSibling() {
-		if child.Kind() == ast.KindText {
-			text := child.(*ast.Text)
-			paragraphText += string(text.Segment.Value(source))
-		}
+	// A paragraph holding only an image is rendered as a block figure
+	if image, ok := isStandaloneImage(paragraph); ok {
+		r.renderImage(pdf, image, source)
+		return
 	}
 
-	// Use MultiCell for proper text wrapping
-	if paragraphText != "" {
-		pdf.MultiCell(0, r.config.FontSize*1.2, paragraphText, "", "", false)
-		pdf.Ln(2) // Space after paragraph
+	if paragraph.FirstChild() == nil {
+		return
 	}
+
+	// Render inline content (emphasis, code spans, links) as flowing text
+	style := r.bodyStyle()
+	style.apply(pdf)
+	r.renderInlines(pdf, paragraph, source, style)
+	pdf.Ln(style.lineHeight)
+	r.blockGap(pdf, gapParagraph) // Space after paragraph
 }
 
 func (r *PDFRenderer) renderMermaidImage(pdf *gofpdf.Fpdf, imagePath string) {
 	// Read the image file
-	imageData, err := os.ReadFile(imagePath) // #nosec G304 - path is generated internally by plugins
+	imageData, err := os.ReadFile(ThemedImagePath(imagePath, r.config.Mermaid.Theme)) // #nosec G304 - path is generated internally by plugins
 	if err != nil {
 		// Fallback to text if image can't be read
+		r.resumePage(pdf)
 		pdf.MultiCell(0, r.config.FontSize*1.2, fmt.Sprintf("[Mermaid diagram: %s (failed to load)]", imagePath), "", "", false)
-		pdf.Ln(3)
+		r.blockGap(pdf, gapBlock)
 		return
 	}
 
-	// Add space before image
-	pdf.Ln(5)
-
 	// Register the image with PDF
-	imageName := fmt.Sprintf("mermaid_%p", &imageData)
-	imageReader := bytes.NewReader(imageData)
-
-	info := pdf.RegisterImageOptionsReader(imageName, gofpdf.ImageOptions{ImageType: "PNG"}, imageReader)
+	imageName, info := r.registerImage(pdf, "PNG", imageData, imagePath)
 	if info == nil {
 		// Fallback to text if image registration fails
+		r.resumePage(pdf)
 		pdf.MultiCell(0, r.config.FontSize*1.2, fmt.Sprintf("[Mermaid diagram: %s (failed to register)]", imagePath), "", "", false)
-		pdf.Ln(3)
+		r.blockGap(pdf, gapBlock)
 		return
 	}
 
-	// Calculate scaling using configuration
-	pageWidth, _ := pdf.GetPageSize()
+	// Calculate scaling using configuration. The current page may be the
+	// landscape page of the previous diagram, while the diagram is sized
+	// for the portrait text column.
+	pageWidth := math.Min(pdf.GetPageSize())
 	leftMargin, _, rightMargin, _ := pdf.GetMargins()
-	availableWidth := pageWidth - leftMargin - rightMargin - 10 // Conservative padding
+	availableWidth := pageWidth - leftMargin - rightMargin - diagramPadding // Conservative padding
 
 	// Use configured max width or available page width
 	maxWidth := r.config.Mermaid.MaxWidth
@@ -306,6 +565,7 @@ func (r *PDFRenderer) renderMermaidImage(pdf *gofpdf.Fpdf, imagePath string) {
 	baseScale := 0.2 * r.config.Mermaid.Scale // Use configured scale factor
 	imgWidthMM := float64(imgWidth) * baseScale
 	imgHeightMM := float64(imgHeight) * baseScale
+	naturalWidth, naturalHeight := imgWidthMM, imgHeightMM
 
 	// Scale down if too wide
 	if imgWidthMM > maxWidth {
@@ -321,6 +581,21 @@ func (r *PDFRenderer) renderMermaidImage(pdf *gofpdf.Fpdf, imagePath string) {
 		imgWidthMM = imgWidthMM * scale
 	}
 
+	// Wide diagrams may get a landscape page of their own or be rotated
+	// instead; every other diagram goes on a portrait page
+	wide := naturalWidth > maxWidth && naturalWidth/naturalHeight > wideDiagramRatio
+	if wide && r.config.Mermaid.WideStrategy == "landscape" && r.placeLandscapeDiagram(pdf, imageName, naturalWidth, naturalHeight, imgWidthMM) {
+		return
+	}
+	r.resumePage(pdf)
+
+	// Add space before image
+	r.blockGap(pdf, gapSection)
+
+	if wide && r.config.Mermaid.WideStrategy == "rotate" && r.placeRotatedDiagram(pdf, imageName, naturalWidth, naturalHeight, imgWidthMM) {
+		return
+	}
+
 	// Get current position to ensure proper placement
 	x, y := pdf.GetXY()
 
@@ -328,13 +603,14 @@ func (r *PDFRenderer) renderMermaidImage(pdf *gofpdf.Fpdf, imagePath string) {
 	pdf.ImageOptions(imageName, x, y, imgWidthMM, imgHeightMM, false, gofpdf.ImageOptions{ImageType: "PNG"}, 0, "")
 
 	// Move cursor to below the image with proper spacing
-	pdf.SetXY(x, y+imgHeightMM+5)
+	pdf.SetXY(x, y+imgHeightMM)
+	r.blockGap(pdf, gapSection)
 }
 
 // renderList renders ordered and unordered lists
 func (r *PDFRenderer) renderList(pdf *gofpdf.Fpdf, list *ast.List, source []byte) {
 	pdf.SetFont(r.config.FontFamily, "", r.config.FontSize)
-	pdf.Ln(2)
+	r.blockGap(pdf, gapParagraph)
 
 	itemNum := 1
 	for child := list.FirstChild(); child != nil; child = child.NextSibling() {
@@ -349,49 +625,11 @@ func (r *PDFRenderer) renderList(pdf *gofpdf.Fpdf, list *ast.List, source []byte
 			}
 
 			// Extract text from list item
-			itemText := r.extractTextFromNode(child, source)
-			pdf.MultiCell(0, r.config.FontSize*1.2, prefix+itemText, "", "", false)
+			itemText := flatText(child, source)
+			r.redactedMultiCell(pdf, r.config.FontSize*1.2, prefix+itemText, "")
 		}
 	}
-	pdf.Ln(2)
-}
-
-// renderBlockquote renders blockquote elements with indentation
-func (r *PDFRenderer) renderBlockquote(pdf *gofpdf.Fpdf, blockquote *ast.Blockquote, source []byte) {
-	pdf.SetFont(r.config.FontFamily, "I", r.config.FontSize)
-	pdf.Ln(2)
-
-	// Add left margin for blockquote
-	leftMargin, _, _, _ := pdf.GetMargins()
-	pdf.SetLeftMargin(leftMargin + 10)
-
-	// Extract and render blockquote content
-	blockText := r.extractTextFromNode(blockquote, source)
-	if blockText != "" {
-		pdf.MultiCell(0, r.config.FontSize*1.2, blockText, "", "", false)
-	}
-
-	// Restore margin
-	pdf.SetLeftMargin(leftMargin)
-	pdf.SetFont(r.config.FontFamily, "", r.config.FontSize)
-	pdf.Ln(2)
-}
-
-// renderThematicBreak renders horizontal rule (---, ***, ___)
-func (r *PDFRenderer) renderThematicBreak(pdf *gofpdf.Fpdf) {
-	pdf.Ln(5)
-
-	// Draw a horizontal line
-	pageWidth, _ := pdf.GetPageSize()
-	leftMargin, _, rightMargin, _ := pdf.GetMargins()
-	lineWidth := pageWidth - leftMargin - rightMargin
-
-	x, y := pdf.GetXY()
-	pdf.SetDrawColor(200, 200, 200)
-	pdf.Line(x, y, x+lineWidth, y)
-	pdf.SetDrawColor(0, 0, 0)
-
-	pdf.Ln(5)
+	r.blockGap(pdf, gapParagraph)
 }
 
 // renderImage renders image elements
@@ -399,38 +637,29 @@ func (r *PDFRenderer) renderImage(pdf *gofpdf.Fpdf, image *ast.Image, source []b
 	destination := string(image.Destination)
 	altText := string(image.Text(source))
 
+	// An image wrapped in a link is a clickable figure
+	link, linkStr := 0, ""
+	if parent, ok := image.Parent().(*ast.Link); ok {
+		link, linkStr = r.linkTarget(pdf, r.linkDestination(string(parent.Destination)))
+	}
+
 	// Try to load and render the image
-	imageData, err := os.ReadFile(destination) // #nosec G304 - path from markdown content
+	imageData, imageType, err := r.loadImage(destination)
 	if err != nil {
 		// Fallback to alt text if image can't be loaded
 		pdf.SetFont(r.config.FontFamily, "I", r.config.FontSize)
-		pdf.MultiCell(0, r.config.FontSize*1.2, fmt.Sprintf("[Image: %s]", altText), "", "", false)
+		r.linkedMultiCell(pdf, fmt.Sprintf("[Image: %s]", altText), link, linkStr)
 		pdf.SetFont(r.config.FontFamily, "", r.config.FontSize)
 		return
 	}
 
-	pdf.Ln(3)
+	r.blockGap(pdf, gapBlock)
 
 	// Register and render the image
-	imageName := fmt.Sprintf("img_%p", &imageData)
-	imageReader := bytes.NewReader(imageData)
-
-	// Determine image type from extension
-	imageType := "PNG"
-	if len(destination) > 4 {
-		ext := destination[len(destination)-4:]
-		switch ext {
-		case ".jpg", "jpeg":
-			imageType = "JPG"
-		case ".gif":
-			imageType = "GIF"
-		}
-	}
-
-	info := pdf.RegisterImageOptionsReader(imageName, gofpdf.ImageOptions{ImageType: imageType}, imageReader)
+	imageName, info := r.registerImage(pdf, imageType, imageData, destination)
 	if info == nil {
 		pdf.SetFont(r.config.FontFamily, "I", r.config.FontSize)
-		pdf.MultiCell(0, r.config.FontSize*1.2, fmt.Sprintf("[Image failed to load: %s]", altText), "", "", false)
+		r.linkedMultiCell(pdf, fmt.Sprintf("[Image failed to load: %s]", altText), link, linkStr)
 		pdf.SetFont(r.config.FontFamily, "", r.config.FontSize)
 		return
 	}
@@ -452,8 +681,46 @@ func (r *PDFRenderer) renderImage(pdf *gofpdf.Fpdf, image *ast.Image, source []b
 	}
 
 	x, y := pdf.GetXY()
-	pdf.ImageOptions(imageName, x, y, imgWidthMM, imgHeightMM, false, gofpdf.ImageOptions{ImageType: imageType}, 0, "")
-	pdf.SetXY(x, y+imgHeightMM+3)
+	pdf.ImageOptions(imageName, x, y, imgWidthMM, imgHeightMM, false, gofpdf.ImageOptions{ImageType: imageType}, link, linkStr)
+	pdf.SetXY(x, y+imgHeightMM)
+	r.blockGap(pdf, gapBlock)
+}
+
+// linkedMultiCell writes text across the text width like MultiCell and,
+// when the text stands for a linked figure, makes its lines a link area.
+func (r *PDFRenderer) linkedMultiCell(pdf *gofpdf.Fpdf, txt string, link int, linkStr string) {
+	page, x, y := pdf.PageNo(), pdf.GetX(), pdf.GetY()
+	pdf.MultiCell(0, r.config.FontSize*1.2, txt, "", "", false)
+	if (link == 0 && linkStr == "") || pdf.PageNo() != page {
+		return
+	}
+	pageWidth, _ := pdf.GetPageSize()
+	_, _, rightMargin, _ := pdf.GetMargins()
+	if link != 0 {
+		pdf.Link(x, y, pageWidth-rightMargin-x, pdf.GetY()-y, link)
+	} else {
+		pdf.LinkString(x, y, pageWidth-rightMargin-x, pdf.GetY()-y, linkStr)
+	}
+}
+
+// recordHeading remembers a rendered heading and the page it was placed on.
+func (r *PDFRenderer) recordHeading(pdf *gofpdf.Fpdf, heading *ast.Heading, source []byte) {
+	title := r.extractTextFromNode(heading, source)
+	h := outline.Heading{
+		Level: heading.Level,
+		Title: title,
+		Slug:  r.anchors.slugs[heading],
+		Page:  pdf.PageNo(),
+	}
+	r.headings = append(r.headings, h)
+	r.placeAnchor(pdf, h.Slug)
+	r.placeTOCEntry(pdf, h)
+}
+
+// Headings returns the headings of the most recently rendered document in
+// document order, including the page number each heading appears on.
+func (r *PDFRenderer) Headings() []outline.Heading {
+	return r.headings
 }
 
 // extractTextFromNode recursively extracts text content from an AST node
@@ -471,12 +738,14 @@ func (r *PDFRenderer) extractTextFromNode(node ast.Node, source []byte) string {
 
 func (r *PDFRenderer) renderCodeBlock(pdf *gofpdf.Fpdf, codeBlock ast.Node, source []byte) {
 	// Add space before code block
-	pdf.Ln(3)
+	r.blockGap(pdf, gapBlock)
 
 	pdf.SetFont("Courier", "", r.config.FontSize-1)
 
 	// Add a light background for code blocks
-	pdf.SetFillColor(245, 245, 245)
+	background, textColor := r.codeBackground(false), r.codeTextColor()
+	pdf.SetFillColor(background.R, background.G, background.B)
+	pdf.SetTextColor(textColor.R, textColor.G, textColor.B)
 
 	lineHeight := float64(r.config.FontSize)
 
@@ -486,6 +755,7 @@ func (r *PDFRenderer) renderCodeBlock(pdf *gofpdf.Fpdf, codeBlock ast.Node, sour
 	case *ast.CodeBlock:
 		lines = block.Lines()
 	case *ast.FencedCodeBlock:
+		r.checkCodeLanguage(block, source)
 		lines = block.Lines()
 	default:
 		return
@@ -501,10 +771,10 @@ func (r *PDFRenderer) renderCodeBlock(pdf *gofpdf.Fpdf, codeBlock ast.Node, sour
 		pdf.CellFormat(0, lineHeight, content, "", 1, "", true, 0, "")
 	}
 
-	// Reset background
+	// Reset background and text color
 	pdf.SetFillColor(255, 255, 255)
-	pdf.SetFont(r.config.FontFamily, "", r.config.FontSize)
+	r.bodyStyle().apply(pdf)
 
 	// Add space after code block
-	pdf.Ln(3)
+	r.blockGap(pdf, gapBlock)
 }
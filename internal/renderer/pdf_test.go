@@ -2,16 +2,21 @@ package renderer
 
 import (
 	"bytes"
+	"compress/zlib"
+	"fmt"
 	"image"
 	"image/color"
 	"image/png"
 	"io"
+	"math"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
 
 	"github.com/fredcamaral/md-to-pdf/internal/plugins"
+	"github.com/jung-kurt/gofpdf"
+	"github.com/yuin/goldmark"
 	"github.com/yuin/goldmark/ast"
 	"github.com/yuin/goldmark/text"
 )
@@ -33,6 +38,43 @@ func writePNG(w io.Writer, img image.Image) error {
 	return png.Encode(w, img)
 }
 
+// parseMarkdown parses markdown with goldmark's default parser
+func parseMarkdown(content string) (ast.Node, []byte) {
+	source := []byte(content)
+	return goldmark.DefaultParser().Parse(text.NewReader(source)), source
+}
+
+// pdfContent returns the decompressed page content streams of a rendered PDF
+// so tests can assert on the drawing operators and text that were emitted
+func pdfContent(t *testing.T, buf *bytes.Buffer) string {
+	t.Helper()
+
+	var content strings.Builder
+	data := buf.Bytes()
+	for {
+		start := bytes.Index(data, []byte("stream\n"))
+		if start < 0 {
+			break
+		}
+		data = data[start+len("stream\n"):]
+		end := bytes.Index(data, []byte("endstream"))
+		if end < 0 {
+			break
+		}
+		reader, err := zlib.NewReader(bytes.NewReader(data[:end]))
+		if err == nil {
+			inflated, readErr := io.ReadAll(reader)
+			if readErr == nil {
+				content.Write(inflated)
+				content.WriteString("\n")
+			}
+		}
+		// Skip the keyword so "endstream" is not taken for the next stream
+		data = data[end+len("endstream"):]
+	}
+	return content.String()
+}
+
 func defaultTestConfig() *RenderConfig {
 	return &RenderConfig{
 		PageSize:     "A4",
@@ -563,3 +605,304 @@ func createManagerWithTransformers(transformers []plugins.ASTTransformer) *plugi
 	// Note: We can't directly inject transformers, so we test through the public API
 	return manager
 }
+
+func TestRender_RecordsHeadings(t *testing.T) {
+	renderer := NewPDFRenderer(defaultTestConfig(), defaultTestDocumentMetadata(), nil)
+
+	var sb strings.Builder
+	sb.WriteString("# Introduction\n\n## The *first* Part\n\n")
+	for i := 0; i < 60; i++ {
+		sb.WriteString("Filler paragraph to push the next heading onto a later page.\n\n")
+	}
+	sb.WriteString("## Second Part\n")
+
+	node, source := parseMarkdown(sb.String())
+	if _, err := renderer.Render(node, source); err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+
+	headings := renderer.Headings()
+	if len(headings) != 3 {
+		t.Fatalf("expected 3 recorded headings, got %d", len(headings))
+	}
+
+	if headings[1].Title != "The first Part" || headings[1].Slug != "the-first-part" || headings[1].Level != 2 {
+		t.Errorf("unexpected heading: %+v", headings[1])
+	}
+	if headings[0].Page != 1 {
+		t.Errorf("first heading page = %d, want 1", headings[0].Page)
+	}
+	if headings[2].Page <= headings[1].Page {
+		t.Errorf("last heading should be on a later page, got %d (previous %d)", headings[2].Page, headings[1].Page)
+	}
+
+	// Rendering again must not accumulate headings from the previous document
+	node, source = parseMarkdown("# Only\n")
+	if _, err := renderer.Render(node, source); err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	if len(renderer.Headings()) != 1 {
+		t.Errorf("expected headings to reset between renders, got %d", len(renderer.Headings()))
+	}
+}
+
+func TestRender_LongHeadingWraps(t *testing.T) {
+	renderer := NewPDFRenderer(defaultTestConfig(), defaultTestDocumentMetadata(), nil)
+
+	heading := "# " + strings.Repeat("A remarkably long heading that cannot fit ", 4) + "Ending\n"
+	node, source := parseMarkdown(heading)
+	buf, err := renderer.Render(node, source)
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+
+	content := pdfContent(t, buf)
+	if lines := strings.Count(content, ")Tj"); lines < 2 {
+		t.Errorf("expected the heading to wrap over several lines, got %d text runs", lines)
+	}
+	if !strings.Contains(content, "Ending") {
+		t.Error("expected the end of the heading to be rendered")
+	}
+}
+
+func TestRender_HeadingWithNonLatin1Text(t *testing.T) {
+	renderer := NewPDFRenderer(defaultTestConfig(), defaultTestDocumentMetadata(), nil)
+
+	// Characters above U+00FF have no entry in the core font width tables
+	node, source := parseMarkdown("# Design \u2014 \u201cquoted\u201d \u2192 next\n\nText.")
+	if _, err := renderer.Render(node, source); err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+}
+
+func TestFitHeadingSize(t *testing.T) {
+	long := strings.Repeat("A remarkably long heading ", 6)
+
+	tests := []struct {
+		name    string
+		minSize float64
+		text    string
+		check   func(size float64) bool
+	}{
+		{"disabled", 0, long, func(size float64) bool { return size == 22 }},
+		{"short heading keeps size", 10, "Short", func(size float64) bool { return size == 22 }},
+		{"long heading stops at minimum", 10, long, func(size float64) bool { return size == 10 }},
+		{"minimum above size", 30, long, func(size float64) bool { return size == 22 }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := defaultTestConfig()
+			config.HeadingMinSize = tt.minSize
+			renderer := NewPDFRenderer(config, defaultTestDocumentMetadata(), nil)
+
+			pdf := gofpdf.New("P", "mm", "A4", "")
+			pdf.AddPage()
+			pdf.SetFont("Arial", "B", 22)
+
+			size := renderer.fitHeadingSize(pdf, tt.text, 22, renderer.headingWidth(pdf))
+			if !tt.check(size) {
+				t.Errorf("unexpected size %.1f", size)
+			}
+		})
+	}
+}
+
+func TestFitHeadingSize_StopsWhenTextFits(t *testing.T) {
+	config := defaultTestConfig()
+	config.HeadingMinSize = 6
+	renderer := NewPDFRenderer(config, defaultTestDocumentMetadata(), nil)
+
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.AddPage()
+	pdf.SetFont("Arial", "B", 22)
+
+	text := "A heading that is slightly too long for one line at full size"
+	width := renderer.headingWidth(pdf)
+	size := renderer.fitHeadingSize(pdf, text, 22, width)
+
+	if size >= 22 || size <= 6 {
+		t.Fatalf("expected size between the minimum and the original, got %.1f", size)
+	}
+	pdf.SetFontSize(size)
+	if pdf.GetStringWidth(text) > width {
+		t.Error("shrunk heading should fit on one line")
+	}
+}
+
+func TestKeepTogether(t *testing.T) {
+	renderer := NewPDFRenderer(defaultTestConfig(), defaultTestDocumentMetadata(), nil)
+
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.SetMargins(15, 20, 15)
+	pdf.SetAutoPageBreak(true, 20)
+	pdf.AddPage()
+
+	// Fits: stays on the page
+	pdf.SetY(200)
+	renderer.keepTogether(pdf, 50)
+	if pdf.PageNo() != 1 {
+		t.Fatalf("block that fits should not start a new page")
+	}
+
+	// Does not fit: moves to the next page
+	pdf.SetY(250)
+	renderer.keepTogether(pdf, 50)
+	if pdf.PageNo() != 2 {
+		t.Errorf("expected a page break, on page %d", pdf.PageNo())
+	}
+
+	// Taller than a page: left to break naturally
+	pdf.SetY(250)
+	renderer.keepTogether(pdf, 400)
+	if pdf.PageNo() != 2 {
+		t.Errorf("oversized block should not force a page break, on page %d", pdf.PageNo())
+	}
+}
+
+func TestKeepWithNextHeight(t *testing.T) {
+	node, _ := parseMarkdown("# Chapter\n\n## Section\n\nText.\n")
+	chapter := node.FirstChild().(*ast.Heading)
+	section := chapter.NextSibling().(*ast.Heading)
+
+	config := defaultTestConfig()
+	renderer := NewPDFRenderer(config, defaultTestDocumentMetadata(), nil)
+	oneLine := renderer.keepWithNextHeight(section)
+	if oneLine <= 0 {
+		t.Fatalf("a heading should keep at least one line below it, got %.1f", oneLine)
+	}
+	if withNext := renderer.keepWithNextHeight(chapter); withNext <= oneLine {
+		t.Errorf("a heading followed by a heading should keep both together, got %.1f <= %.1f", withNext, oneLine)
+	}
+
+	config.MinLinesAfterHeading = 3
+	if threeLines := renderer.keepWithNextHeight(section); threeLines-oneLine < 2*renderer.bodyStyle().lineHeight-0.01 {
+		t.Errorf("three lines should keep two more lines than one, got %.1f and %.1f", threeLines, oneLine)
+	}
+
+	config.KeepWithNext = "none"
+	if height := renderer.keepWithNextHeight(chapter); height != 0 {
+		t.Errorf("keep-with-next none should keep nothing below a heading, got %.1f", height)
+	}
+}
+
+func TestRender_KeepWithNextMovesHeading(t *testing.T) {
+	// Enough paragraphs to leave room for a heading but not for its lines
+	var source strings.Builder
+	for i := 0; i < 12; i++ {
+		source.WriteString("Line of text.\n\n")
+	}
+	source.WriteString("## Heading\n\nFirst line.\n\nSecond line.\n\nThird line.\n")
+
+	pages := func(keep string, minLines int) int {
+		config := defaultTestConfig()
+		config.KeepWithNext = keep
+		config.MinLinesAfterHeading = minLines
+		renderer := NewPDFRenderer(config, defaultTestDocumentMetadata(), nil)
+		node, src := parseMarkdown(source.String())
+		if _, err := renderer.Render(node, src); err != nil {
+			t.Fatalf("Render failed: %v", err)
+		}
+		return renderer.Headings()[0].Page
+	}
+
+	if page := pages("none", 10); page != 1 {
+		t.Fatalf("test needs the heading to fit at the end of page 1, got page %d", page)
+	}
+	if page := pages("headings", 1); page != 1 {
+		t.Errorf("heading with room for one line should stay on page 1, got page %d", page)
+	}
+	if page := pages("headings", 10); page != 2 {
+		t.Errorf("heading without room for its lines should move to page 2, got page %d", page)
+	}
+}
+
+func TestBlockGap(t *testing.T) {
+	newPDF := func() *gofpdf.Fpdf {
+		pdf := gofpdf.New("P", "mm", "A4", "")
+		pdf.SetMargins(15, 20, 15)
+		pdf.SetAutoPageBreak(true, 20)
+		pdf.AddPage()
+		return pdf
+	}
+
+	config := defaultTestConfig()
+	unit := config.FontSize * 1.2
+
+	t.Run("grid disabled", func(t *testing.T) {
+		renderer := NewPDFRenderer(config, defaultTestDocumentMetadata(), nil)
+		pdf := newPDF()
+		pdf.SetY(30)
+		renderer.blockGap(pdf, gapParagraph)
+		if y := pdf.GetY(); math.Abs(y-32) > 1e-9 {
+			t.Errorf("y = %.2f, want 32", y)
+		}
+	})
+
+	t.Run("gap scales with the font size", func(t *testing.T) {
+		largeConfig := defaultTestConfig()
+		largeConfig.FontSize = 24
+		renderer := NewPDFRenderer(largeConfig, defaultTestDocumentMetadata(), nil)
+		pdf := newPDF()
+		pdf.SetY(30)
+		renderer.blockGap(pdf, gapSection)
+		if y := pdf.GetY(); math.Abs(y-40) > 1e-9 {
+			t.Errorf("y = %.2f, want 40", y)
+		}
+	})
+
+	t.Run("grid snaps to next line", func(t *testing.T) {
+		gridConfig := defaultTestConfig()
+		gridConfig.BaselineGrid = true
+		renderer := NewPDFRenderer(gridConfig, defaultTestDocumentMetadata(), nil)
+		pdf := newPDF()
+
+		for _, start := range []float64{20, 25.3, 20 + unit, 20 + 3*unit - 1} {
+			pdf.SetY(start)
+			renderer.blockGap(pdf, gapParagraph)
+			lines := (pdf.GetY() - 20) / unit
+			if math.Abs(lines-math.Round(lines)) > 1e-6 {
+				t.Errorf("start %.2f: y = %.2f is not on the grid", start, pdf.GetY())
+			}
+			if pdf.GetY() < start+2 {
+				t.Errorf("start %.2f: gap was not applied, y = %.2f", start, pdf.GetY())
+			}
+		}
+	})
+}
+
+func TestRender_BaselineGrid(t *testing.T) {
+	config := defaultTestConfig()
+	config.BaselineGrid = true
+	renderer := NewPDFRenderer(config, defaultTestDocumentMetadata(), nil)
+
+	node, source := parseMarkdown("# Title\n\nFirst paragraph.\n\n- one\n- two\n\n```\ncode\n```\n\nLast paragraph.\n")
+	buf, err := renderer.Render(node, source)
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+
+	// Paragraph and list text starts on grid lines: the distance from the top
+	// margin to each text cell is a whole number of body lines
+	_, pageHeight := gofpdf.New("P", "mm", "A4", "").GetPageSize()
+	unit := config.FontSize * 1.2
+	k := 72.0 / 25.4 // Points per mm
+	content := pdfContent(t, buf)
+	for _, word := range []string{"(First paragraph.)", "(  * one)", "(Last paragraph.)"} {
+		idx := strings.Index(content, word)
+		if idx < 0 {
+			t.Fatalf("%s not found in content", word)
+		}
+		var x, y float64
+		line := content[strings.LastIndex(content[:idx], "BT ")+3 : idx]
+		if _, err := fmt.Sscanf(line, "%f %f Td", &x, &y); err != nil {
+			t.Fatalf("failed to parse text position %q: %v", line, err)
+		}
+		// gofpdf places the baseline half a line below the cell top plus 0.3em
+		cellTop := pageHeight - y/k - (unit/2 + 0.3*config.FontSize/k)
+		lines := (cellTop - config.Margins.Top) / unit
+		if math.Abs(lines-math.Round(lines)) > 0.01 {
+			t.Errorf("%s starts %.2f lines below the top margin, want a whole number", word, lines)
+		}
+	}
+}
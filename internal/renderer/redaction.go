@@ -0,0 +1,159 @@
+package renderer
+
+import (
+	"strings"
+
+	"github.com/fredcamaral/md-to-pdf/internal/parser"
+	"github.com/jung-kurt/gofpdf"
+	"github.com/yuin/goldmark/ast"
+)
+
+// Blocks drawn as plain lines, such as list items, headings, blockquotes
+// and margin notes, carry redacted spans in their text between these
+// markers, so the spans wrap like the words around them and are then drawn
+// as boxes. The text between the markers is measured but never written.
+const (
+	redactionStart = "\x01"
+	redactionEnd   = "\x02"
+)
+
+// markRedaction returns the hidden text of a redacted span between the
+// redaction markers.
+func markRedaction(hidden []byte) string {
+	return redactionStart + string(hidden) + redactionEnd
+}
+
+// hasRedaction reports whether txt holds a marked redacted span or part of
+// one.
+func hasRedaction(txt string) bool {
+	return strings.ContainsAny(txt, redactionStart+redactionEnd)
+}
+
+// flatText returns the text of node for blocks drawn as plain lines, with
+// redacted spans marked. Sidenotes are left out.
+func flatText(node ast.Node, source []byte) string {
+	var b strings.Builder
+	_ = ast.Walk(node, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		switch n := n.(type) {
+		case *ast.Text:
+			b.Write(n.Segment.Value(source))
+		case *ast.String:
+			b.Write(n.Value)
+		case *parser.Redaction:
+			b.WriteString(markRedaction(n.Hidden))
+		case *parser.Sidenote:
+			return ast.WalkSkipChildren, nil
+		}
+		return ast.WalkContinue, nil
+	})
+	return b.String()
+}
+
+// balanceRedactions closes a redacted span that a line wraps inside at the
+// end of the line and opens it again at the start of the next one.
+func balanceRedactions(lines []string) []string {
+	open := false
+	for i, line := range lines {
+		if open {
+			line = redactionStart + line
+		}
+		if start, end := strings.LastIndex(line, redactionStart), strings.LastIndex(line, redactionEnd); start > end {
+			line += redactionEnd
+			open = true
+		} else {
+			open = false
+		}
+		lines[i] = line
+	}
+	return lines
+}
+
+// redactedMultiCell writes txt from the current position to the right
+// margin like MultiCell, drawing marked redacted spans as boxes. Text with
+// redacted spans is not justified.
+func (r *PDFRenderer) redactedMultiCell(pdf *gofpdf.Fpdf, lineHeight float64, txt, align string) {
+	if !hasRedaction(txt) {
+		pdf.MultiCell(0, lineHeight, txt, "", align, false)
+		return
+	}
+	if align != "C" && align != "R" {
+		align = "L"
+	}
+	pageWidth, _ := pdf.GetPageSize()
+	_, _, rightMargin, _ := pdf.GetMargins()
+	x := pdf.GetX()
+	width := pageWidth - rightMargin - x
+
+	var lines []string
+	for _, line := range pdf.SplitLines([]byte(txt), width) {
+		lines = append(lines, string(line))
+	}
+	for _, line := range balanceRedactions(lines) {
+		pdf.SetX(x)
+		r.redactedCell(pdf, width, lineHeight, line, align)
+		pdf.Ln(lineHeight)
+	}
+}
+
+// redactedCell writes one line of text in a cell of width w like CellFormat
+// without border or fill, drawing marked redacted spans as boxes as wide as
+// their text.
+func (r *PDFRenderer) redactedCell(pdf *gofpdf.Fpdf, w, h float64, txt, align string) {
+	if !hasRedaction(txt) {
+		pdf.CellFormat(w, h, txt, "", 0, align, false, 0, "")
+		return
+	}
+
+	// An empty cell moves the position and breaks the page like the text
+	// would; the runs are drawn over it
+	pdf.CellFormat(w, h, "", "", 0, align, false, 0, "")
+	x, y := pdf.GetX()-w, pdf.GetY()
+
+	type run struct {
+		text     string
+		redacted bool
+	}
+	var runs []run
+	var total float64
+	redacted := false
+	for txt != "" {
+		end := strings.IndexAny(txt, redactionStart+redactionEnd)
+		if end < 0 {
+			end = len(txt)
+		}
+		if end > 0 {
+			runs = append(runs, run{txt[:end], redacted})
+			total += pdf.GetStringWidth(txt[:end])
+		}
+		if end < len(txt) {
+			redacted = txt[end:end+1] == redactionStart
+			end++
+		}
+		txt = txt[end:]
+	}
+
+	switch align {
+	case "R":
+		x += w - pdf.GetCellMargin() - total
+	case "C":
+		x += (w - total) / 2
+	default:
+		x += pdf.GetCellMargin()
+	}
+	_, fontSize := pdf.GetFontSize()
+	fillR, fillG, fillB := pdf.GetFillColor()
+	pdf.SetFillColor(0, 0, 0)
+	for _, run := range runs {
+		width := pdf.GetStringWidth(run.text)
+		if run.redacted {
+			pdf.Rect(x, y+h/2-0.55*fontSize, width, fontSize*1.2, "F")
+		} else {
+			pdf.Text(x, y+h/2+0.3*fontSize, run.text)
+		}
+		x += width
+	}
+	pdf.SetFillColor(fillR, fillG, fillB)
+}
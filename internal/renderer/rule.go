@@ -0,0 +1,92 @@
+package renderer
+
+import (
+	"github.com/fredcamaral/md-to-pdf/internal/colorutil"
+	"github.com/jung-kurt/gofpdf"
+)
+
+const (
+	defaultRuleThickness = 0.2       // Line width of rules in mm
+	defaultRuleColor     = "#c8c8c8" // Light gray
+	defaultRuleOrnament  = "* * *"
+)
+
+// ThematicBreakConfig controls how horizontal rules (---, ***, ___) look.
+// Zero values fall back to a thin, light gray, full-width solid line.
+type ThematicBreakConfig struct {
+	Style     string  // "solid", "dashed", "dotted" or "ornament"
+	Thickness float64 // Line width in mm
+	Color     string  // Hex color or color name, e.g. "#c8c8c8"
+	Width     float64 // Percentage of the text width, centered (1-100)
+	Ornament  string  // Text centered in place of a line for the "ornament" style
+}
+
+// renderThematicBreak renders a horizontal rule in the configured style.
+func (r *PDFRenderer) renderThematicBreak(pdf *gofpdf.Fpdf) {
+	rule := r.config.ThematicBreak
+	r.blockGap(pdf, gapSection)
+
+	color, err := colorutil.Parse(rule.Color)
+	if rule.Color == "" || err != nil {
+		color, _ = colorutil.Parse(defaultRuleColor)
+	}
+
+	if rule.Style == "ornament" {
+		r.renderOrnament(pdf, rule, color)
+		r.blockGap(pdf, gapSection)
+		return
+	}
+
+	pageWidth, _ := pdf.GetPageSize()
+	leftMargin, _, rightMargin, _ := pdf.GetMargins()
+	textWidth := pageWidth - leftMargin - rightMargin
+
+	width := textWidth
+	if rule.Width > 0 && rule.Width < 100 {
+		width = textWidth * rule.Width / 100
+	}
+	thickness := rule.Thickness
+	if thickness <= 0 {
+		thickness = defaultRuleThickness
+	}
+
+	x := leftMargin + (textWidth-width)/2
+	y := pdf.GetY()
+
+	// Line width, cap and dash pattern persist across pages, so restore them
+	savedWidth := pdf.GetLineWidth()
+	pdf.SetLineWidth(thickness)
+	pdf.SetDrawColor(color.R, color.G, color.B)
+	switch rule.Style {
+	case "dashed":
+		dash := max(2, thickness*6)
+		pdf.SetDashPattern([]float64{dash, dash * 0.75}, 0)
+	case "dotted":
+		// Zero-length dashes with round caps draw dots one thickness wide
+		pdf.SetLineCapStyle("round")
+		pdf.SetDashPattern([]float64{0, max(1, thickness*3)}, 0)
+	}
+
+	pdf.Line(x, y, x+width, y)
+
+	pdf.SetDashPattern([]float64{}, 0)
+	pdf.SetLineCapStyle("butt")
+	pdf.SetLineWidth(savedWidth)
+	pdf.SetDrawColor(0, 0, 0)
+
+	r.blockGap(pdf, gapSection)
+}
+
+// renderOrnament centers ornament text such as "* * *" on its own line.
+func (r *PDFRenderer) renderOrnament(pdf *gofpdf.Fpdf, rule ThematicBreakConfig, color colorutil.Color) {
+	ornament := rule.Ornament
+	if ornament == "" {
+		ornament = defaultRuleOrnament
+	}
+
+	textR, textG, textB := pdf.GetTextColor()
+	pdf.SetFont(r.config.FontFamily, "", r.config.FontSize)
+	pdf.SetTextColor(color.R, color.G, color.B)
+	pdf.CellFormat(0, r.bodyStyle().lineHeight, ornament, "", 1, "C", false, 0, "")
+	pdf.SetTextColor(textR, textG, textB)
+}
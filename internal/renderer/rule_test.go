@@ -0,0 +1,69 @@
+package renderer
+
+import (
+	"strings"
+	"testing"
+)
+
+func renderRule(t *testing.T, rule ThematicBreakConfig) string {
+	t.Helper()
+	config := defaultTestConfig()
+	config.ThematicBreak = rule
+	renderer := NewPDFRenderer(config, defaultTestDocumentMetadata(), nil)
+
+	node, source := parseMarkdown("Before\n\n---\n\nAfter")
+	buf, err := renderer.Render(node, source)
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	return pdfContent(t, buf)
+}
+
+func TestRenderThematicBreak_DefaultsToLightGrayLine(t *testing.T) {
+	content := renderRule(t, ThematicBreakConfig{})
+	if !strings.Contains(content, "0.784 G") {
+		t.Error("default rule should be light gray")
+	}
+	if !strings.Contains(content, "0.57 w\n0.784 G\n42.52 724.54 m 552.76 724.54 l S") {
+		t.Error("default rule should be a thin solid line across the text width")
+	}
+}
+
+func TestRenderThematicBreak_Styles(t *testing.T) {
+	dashed := renderRule(t, ThematicBreakConfig{Style: "dashed", Thickness: 0.5, Color: "navy"})
+	if !strings.Contains(dashed, "0.000 0.000 0.502 RG") {
+		t.Error("dashed rule should use the configured color")
+	}
+	if !strings.Contains(dashed, "1.42 w") {
+		t.Error("rule should use the configured thickness")
+	}
+	if !strings.Contains(dashed, "[8.50 6.38] 0.00 d") {
+		t.Error("dashed rule should set a dash pattern")
+	}
+	if !strings.Contains(dashed, "[] 0.00 d") {
+		t.Error("dash pattern should be reset after the rule")
+	}
+
+	dotted := renderRule(t, ThematicBreakConfig{Style: "dotted"})
+	if !strings.Contains(dotted, "1 J") || !strings.Contains(dotted, "[0.00 2.83] 0.00 d") {
+		t.Error("dotted rule should draw round zero-length dashes")
+	}
+}
+
+func TestRenderThematicBreak_Ornament(t *testing.T) {
+	content := renderRule(t, ThematicBreakConfig{Style: "ornament", Ornament: "~ * ~"})
+	if !strings.Contains(content, "(~ * ~)Tj") {
+		t.Error("ornament text should be rendered")
+	}
+	if !strings.Contains(content, "After") {
+		t.Error("text after the ornament should be rendered")
+	}
+}
+
+func TestRenderThematicBreak_Width(t *testing.T) {
+	// A4 is 210mm wide; with 15mm margins a 50% rule spans 90mm centered at 105mm
+	content := renderRule(t, ThematicBreakConfig{Width: 50})
+	if !strings.Contains(content, "170.08 ") || !strings.Contains(content, "425.20 ") {
+		t.Errorf("50%% rule should run from 60mm to 150mm")
+	}
+}
@@ -0,0 +1,58 @@
+package renderer
+
+import (
+	"fmt"
+
+	"github.com/fredcamaral/md-to-pdf/internal/contentscan"
+	"github.com/fredcamaral/md-to-pdf/internal/manifest"
+)
+
+// scannedImage is the outcome of loading and scanning one image destination.
+type scannedImage struct {
+	data      []byte
+	imageType string
+	err       error
+	// file identifies the image file as read, before any changes, when a
+	// manifest is attached
+	file manifest.File
+}
+
+// checkActiveContent applies the configured image policy to an image before it
+// is embedded. Warnings are recorded on the renderer; refused images return an
+// error and make the whole render fail.
+func (r *PDFRenderer) checkActiveContent(destination string, data []byte, imageType string) ([]byte, error) {
+	policy := contentscan.Policy(r.config.ImagePolicy)
+	if policy == "" {
+		policy = contentscan.DefaultPolicy
+	}
+
+	cleaned, findings := contentscan.Sanitize(data, imageType)
+	if len(findings) == 0 {
+		return data, nil
+	}
+
+	switch policy {
+	case contentscan.PolicyRefuse:
+		err := &contentscan.ActiveContentError{Source: destination, Findings: findings}
+		if r.securityErr == nil {
+			r.securityErr = err
+		}
+		return nil, err
+	case contentscan.PolicyWarn:
+		r.warn(fmt.Sprintf("image %s contains active content: %s", destination, contentscan.Summarize(findings)))
+		return data, nil
+	default:
+		r.warn(fmt.Sprintf("image %s: removed active content: %s", destination, contentscan.Summarize(findings)))
+		return cleaned, nil
+	}
+}
+
+// warn records a warning for the current render.
+func (r *PDFRenderer) warn(message string) {
+	r.warnings = append(r.warnings, message)
+}
+
+// Warnings returns the warnings raised while rendering the most recent document.
+func (r *PDFRenderer) Warnings() []string {
+	return r.warnings
+}
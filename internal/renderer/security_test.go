@@ -0,0 +1,120 @@
+package renderer
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/fredcamaral/md-to-pdf/internal/contentscan"
+)
+
+// writePolyglotPNG writes a valid PNG with a script appended after IEND.
+func writePolyglotPNG(t *testing.T) string {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := writePNG(&buf, createTestPNG(10, 10)); err != nil {
+		t.Fatalf("failed to encode PNG: %v", err)
+	}
+	buf.WriteString("<script>alert(1)</script>")
+
+	path := filepath.Join(t.TempDir(), "polyglot.png")
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		t.Fatalf("failed to write PNG: %v", err)
+	}
+	return path
+}
+
+func TestRender_ImagePolicy(t *testing.T) {
+	imagePath := writePolyglotPNG(t)
+	// The image is referenced twice but must only be reported once
+	markdown := "![first](" + imagePath + ")\n\nInline ![second](" + imagePath + ") image."
+
+	tests := []struct {
+		policy       string
+		wantErr      bool
+		wantWarnings int
+		wantText     string
+	}{
+		{"", false, 1, "removed active content"},
+		{"sanitize", false, 1, "removed active content"},
+		{"warn", false, 1, "contains active content"},
+		{"refuse", true, 0, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run("policy_"+tt.policy, func(t *testing.T) {
+			config := defaultTestConfig()
+			config.ImagePolicy = tt.policy
+			renderer := NewPDFRenderer(config, defaultTestDocumentMetadata(), nil)
+
+			node, source := parseMarkdown(markdown)
+			_, err := renderer.Render(node, source)
+
+			if tt.wantErr {
+				var activeErr *contentscan.ActiveContentError
+				if !errors.As(err, &activeErr) {
+					t.Fatalf("expected ActiveContentError, got %v", err)
+				}
+				if activeErr.Source != imagePath {
+					t.Errorf("error source = %q, want %q", activeErr.Source, imagePath)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Render failed: %v", err)
+			}
+
+			warnings := renderer.Warnings()
+			if len(warnings) != tt.wantWarnings {
+				t.Fatalf("expected %d warnings, got %v", tt.wantWarnings, warnings)
+			}
+			if !strings.Contains(warnings[0], tt.wantText) || !strings.Contains(warnings[0], "trailing-data") {
+				t.Errorf("unexpected warning: %q", warnings[0])
+			}
+		})
+	}
+}
+
+func TestRender_CleanImageHasNoWarnings(t *testing.T) {
+	imagePath := filepath.Join(t.TempDir(), "clean.png")
+	var buf bytes.Buffer
+	if err := writePNG(&buf, createTestPNG(10, 10)); err != nil {
+		t.Fatalf("failed to encode PNG: %v", err)
+	}
+	if err := os.WriteFile(imagePath, buf.Bytes(), 0644); err != nil {
+		t.Fatalf("failed to write PNG: %v", err)
+	}
+
+	config := defaultTestConfig()
+	config.ImagePolicy = "refuse"
+	renderer := NewPDFRenderer(config, defaultTestDocumentMetadata(), nil)
+
+	node, source := parseMarkdown("![clean](" + imagePath + ")")
+	if _, err := renderer.Render(node, source); err != nil {
+		t.Fatalf("clean image should render under refuse policy: %v", err)
+	}
+	if len(renderer.Warnings()) != 0 {
+		t.Errorf("expected no warnings, got %v", renderer.Warnings())
+	}
+}
+
+func TestRender_WarningsResetBetweenRenders(t *testing.T) {
+	imagePath := writePolyglotPNG(t)
+	renderer := NewPDFRenderer(defaultTestConfig(), defaultTestDocumentMetadata(), nil)
+
+	node, source := parseMarkdown("![img](" + imagePath + ")")
+	if _, err := renderer.Render(node, source); err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+
+	node, source = parseMarkdown("No images here.")
+	if _, err := renderer.Render(node, source); err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	if len(renderer.Warnings()) != 0 {
+		t.Errorf("warnings should reset between renders, got %v", renderer.Warnings())
+	}
+}
@@ -0,0 +1,170 @@
+package renderer
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/fredcamaral/md-to-pdf/internal/parser"
+	"github.com/jung-kurt/gofpdf"
+	"github.com/yuin/goldmark/ast"
+)
+
+const (
+	sidenoteScale       = 0.75 // Note font size relative to body text
+	sidenoteMarkerScale = 0.65 // Reference number size relative to the text
+	sidenoteGutter      = 4.0  // Space between the text block and notes in mm
+	sidenoteEdge        = 4.0  // Minimum space between notes and the paper edge in mm
+	sidenoteMinWidth    = 20.0 // Narrowest readable note column in mm
+	sidenoteSpacing     = 1.5  // Space between stacked notes in mm
+)
+
+// sidenoteState tracks the notes placed on the current page so that notes
+// referenced from nearby lines stack instead of overlapping.
+type sidenoteState struct {
+	count  int     // Notes rendered so far, used for numbering
+	page   int     // Page of the last note
+	bottom float64 // Bottom of the last note on that page
+	warned bool    // Narrow margin warning already issued
+}
+
+// writeSidenote writes a superscript reference number at the current
+// position and places the numbered note in the outer margin beside the line.
+// Where the margin is too narrow for a note column, the note is set inline
+// instead, so it never covers the text.
+func (r *PDFRenderer) writeSidenote(pdf *gofpdf.Fpdf, note *parser.Sidenote, style inlineStyle) {
+	if _, _, ok := r.sidenoteColumn(pdf); !ok {
+		r.writeInlineNote(pdf, note, style)
+		return
+	}
+
+	r.sidenotes.count++
+	number := strconv.Itoa(r.sidenotes.count)
+
+	// The marker is raised by shifting the line it is written on, so it must
+	// be moved to the next line up front if it does not fit
+	marker := style
+	marker.size = style.size * sidenoteMarkerScale
+	marker.apply(pdf)
+	pageWidth, _ := pdf.GetPageSize()
+	_, _, rightMargin, _ := pdf.GetMargins()
+	if pdf.GetX()+pdf.GetStringWidth(number) > pageWidth-rightMargin {
+		pdf.Ln(style.lineHeight)
+	}
+
+	lineX, lineY := pdf.GetXY()
+	raise := pdf.PointToUnitConvert(style.size) * 0.35
+	pdf.SetXY(lineX, lineY-raise)
+	pdf.Write(style.lineHeight, number)
+	markerEnd := pdf.GetX()
+
+	r.placeSidenote(pdf, number, noteText(note), lineY+style.lineHeight/2)
+
+	pdf.SetXY(markerEnd, lineY)
+	style.apply(pdf)
+}
+
+// placeSidenote draws a note in the outer margin with its first line centered
+// on lineCenter, moving it down below earlier notes and up to stay above the
+// bottom margin.
+func (r *PDFRenderer) placeSidenote(pdf *gofpdf.Fpdf, number, note string, lineCenter float64) {
+	x, width, _ := r.sidenoteColumn(pdf)
+
+	size := r.config.FontSize * sidenoteScale
+	lineHeight := pdf.PointToUnitConvert(size) * 1.2
+	pdf.SetFont(r.config.FontFamily, "", size)
+	color := r.textColor()
+	pdf.SetTextColor(color.R, color.G, color.B)
+	translate := pdf.UnicodeTranslatorFromDescriptor("")
+	var lines []string
+	for _, line := range pdf.SplitLines([]byte(translate(fmt.Sprintf("%s. %s", number, note))), width) {
+		lines = append(lines, string(line))
+	}
+	lines = balanceRedactions(lines)
+	height := float64(len(lines)) * lineHeight
+
+	_, pageHeight := pdf.GetPageSize()
+	_, _, _, bottomMargin := pdf.GetMargins()
+	top := lineCenter - lineHeight/2
+	if r.sidenotes.page == pdf.PageNo() {
+		top = max(top, r.sidenotes.bottom+sidenoteSpacing)
+	}
+	if top+height > pageHeight-bottomMargin {
+		top = max(pageHeight-bottomMargin-height, r.config.Margins.Top)
+	}
+
+	// Notes live outside the text block, where automatic page breaks would
+	// only get in the way
+	pdf.SetAutoPageBreak(false, 0)
+	for i, line := range lines {
+		pdf.SetXY(x, top+float64(i)*lineHeight)
+		r.redactedCell(pdf, width, lineHeight, line, "L")
+	}
+	pdf.SetAutoPageBreak(true, r.config.Margins.Bottom)
+
+	r.sidenotes.page = pdf.PageNo()
+	r.sidenotes.bottom = top + height
+}
+
+// noteText returns the text of a note with its redacted spans marked.
+func noteText(note *parser.Sidenote) string {
+	var b strings.Builder
+	for child := note.FirstChild(); child != nil; child = child.NextSibling() {
+		switch n := child.(type) {
+		case *ast.String:
+			b.Write(n.Value)
+		case *parser.Redaction:
+			b.WriteString(markRedaction(n.Hidden))
+		}
+	}
+	return b.String()
+}
+
+// writeInlineNote writes a note in parentheses in the running text, in the
+// note size, for pages whose margin cannot hold it.
+func (r *PDFRenderer) writeInlineNote(pdf *gofpdf.Fpdf, note *parser.Sidenote, style inlineStyle) {
+	noteStyle := style
+	noteStyle.size = style.size * sidenoteScale
+	txt := " ("
+	for child := note.FirstChild(); child != nil; child = child.NextSibling() {
+		switch n := child.(type) {
+		case *ast.String:
+			txt += string(n.Value)
+		case *parser.Redaction:
+			r.writeText(pdf, txt, noteStyle)
+			r.writeRedaction(pdf, n, noteStyle)
+			txt = ""
+		}
+	}
+	r.writeText(pdf, txt+")", noteStyle)
+	style.apply(pdf)
+}
+
+// sidenoteColumn returns the left edge and width of the note column in the
+// outer margin of the current page: the right margin on odd pages and the
+// left margin on even pages, unless notes are pinned to one side. It
+// reports false, with a warning the first time, when that margin is too
+// narrow for a readable column.
+func (r *PDFRenderer) sidenoteColumn(pdf *gofpdf.Fpdf) (float64, float64, bool) {
+	pageWidth, _ := pdf.GetPageSize()
+	left := r.config.SidenoteSide == "left" ||
+		(r.config.SidenoteSide != "right" && pdf.PageNo()%2 == 0)
+
+	margin := r.config.Margins.Right
+	if left {
+		margin = r.config.Margins.Left
+	}
+	width := margin - sidenoteGutter - sidenoteEdge
+	if width < sidenoteMinWidth {
+		if !r.sidenotes.warned {
+			r.warn(fmt.Sprintf("page margins are too narrow for sidenotes (%.0fmm), so notes are set inline; use margins of at least %.0fmm", margin, sidenoteMinWidth+sidenoteGutter+sidenoteEdge))
+			r.sidenotes.warned = true
+		}
+		return 0, 0, false
+	}
+
+	if left {
+		return r.config.Margins.Left - sidenoteGutter - width, width, true
+	}
+	return pageWidth - r.config.Margins.Right + sidenoteGutter, width, true
+}
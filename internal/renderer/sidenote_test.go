@@ -0,0 +1,101 @@
+package renderer
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/fredcamaral/md-to-pdf/internal/parser"
+)
+
+// textPositions returns the x coordinate in points of each text run.
+func textPositions(content string) map[string]float64 {
+	positions := make(map[string]float64)
+	for _, m := range regexp.MustCompile(`BT ([\d.]+) [\d.]+ Td \((.*?)\)Tj`).FindAllStringSubmatch(content, -1) {
+		x, _ := strconv.ParseFloat(m[1], 64)
+		positions[m[2]] = x
+	}
+	return positions
+}
+
+func renderMarkdown(t *testing.T, config *RenderConfig, markdown string) (*PDFRenderer, string) {
+	t.Helper()
+	renderer := NewPDFRenderer(config, defaultTestDocumentMetadata(), nil)
+	node, err := parser.NewMarkdownParser().Parse([]byte(markdown))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	buf, err := renderer.Render(node, []byte(markdown))
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	return renderer, pdfContent(t, buf)
+}
+
+func TestRenderSidenote_InOuterMargin(t *testing.T) {
+	config := defaultTestConfig()
+	config.Margins.Left, config.Margins.Right = 50, 50
+	markdown := "Text.^[Right margin note.]\n\n" +
+		strings.Repeat("Filler paragraph. ", 600) + "\n\nLater.^[Second note.]\n"
+
+	renderer, content := renderMarkdown(t, config, markdown)
+	if len(renderer.Warnings()) != 0 {
+		t.Errorf("unexpected warnings: %v", renderer.Warnings())
+	}
+
+	positions := textPositions(content)
+	// A4 is 595.28pt wide: the right margin starts at 453.54pt, and notes sit
+	// 4mm further out
+	if x, ok := positions["1. Right margin note."]; !ok || x < 464 {
+		t.Errorf("first note should be in the right margin, got x=%.2f (found %v)", x, ok)
+	}
+	if x, ok := positions["1"]; !ok || x > 453 {
+		t.Errorf("reference number should be in the text, got x=%.2f (found %v)", x, ok)
+	}
+	// The second note lands on an even page, whose outer margin is the left
+	if x, ok := positions["2. Second note."]; !ok || x > 130 {
+		t.Errorf("second note should be in the left margin, got x=%.2f (found %v)", x, ok)
+	}
+}
+
+func TestRenderSidenote_PinnedSide(t *testing.T) {
+	config := defaultTestConfig()
+	config.SidenoteSide = "left"
+	config.Margins.Left = 40
+	markdown := "Text.^[Note.]\n\n" + strings.Repeat("Filler paragraph. ", 600) + "\n\nLater.^[Other.]\n"
+	renderer, content := renderMarkdown(t, config, markdown)
+	if len(renderer.Warnings()) != 0 {
+		t.Errorf("unexpected warnings: %v", renderer.Warnings())
+	}
+
+	// Both notes stay in the left margin, also on odd pages: the column
+	// starts 4mm from the paper edge, text 1mm further in (14.17pt)
+	positions := textPositions(content)
+	for _, note := range []string{"1. Note.", "2. Other."} {
+		if x, ok := positions[note]; !ok || x < 14 || x > 15 {
+			t.Errorf("%q should be in the left margin, got x=%.2f (found %v)", note, x, ok)
+		}
+	}
+}
+
+func TestRenderSidenote_NarrowMarginSetsNotesInline(t *testing.T) {
+	config := defaultTestConfig()
+	renderer, content := renderMarkdown(t, config, "Text.^[Note.] More.^[Another.]\n")
+
+	if !strings.Contains(content, "( \\(Note.\\))Tj") || !strings.Contains(content, "( \\(Another.\\))Tj") {
+		t.Errorf("notes should be set inline in parentheses:\n%s", content)
+	}
+	// Nothing is drawn left of the text block, which starts at 15mm (42.52pt)
+	for text, x := range textPositions(content) {
+		if x < 42 {
+			t.Errorf("%q is drawn in the margin at x=%.2f", text, x)
+		}
+	}
+	if strings.Contains(content, "(1)Tj") {
+		t.Error("inline notes should not have a reference number")
+	}
+	if warnings := renderer.Warnings(); len(warnings) != 1 || !strings.Contains(warnings[0], "too narrow for sidenotes") {
+		t.Errorf("expected one narrow margin warning, got %v", warnings)
+	}
+}
@@ -0,0 +1,224 @@
+package renderer
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/fredcamaral/md-to-pdf/internal/outline"
+	"github.com/jung-kurt/gofpdf"
+	"github.com/yuin/goldmark/ast"
+)
+
+const (
+	defaultTOCDepth = 3
+	defaultTOCTitle = "Contents"
+	tocIndent       = 6.0  // Indent per heading level in mm
+	tocPageWidth    = 12.0 // Width of the page number column in mm
+	maxRenderPasses = 3    // Renders needed at most for table of contents pages to settle
+)
+
+// Markers are HTML comments on a line of their own.
+const (
+	pageBreakMarker = "<!-- pagebreak -->"
+	tocMarker       = "<!-- toc -->"
+)
+
+// TOCConfig controls the table of contents.
+type TOCConfig struct {
+	Enabled bool   // Add a table of contents at the start of the document
+	Depth   int    // Deepest heading level listed (0 = 3)
+	Title   string // Title above the entries (empty = "Contents")
+}
+
+// tocState is the table of contents of the current render pass. Entries
+// carry the pages found by the previous pass.
+type tocState struct {
+	entries []outline.Heading
+	marker  bool   // The document places the table with a <!-- toc --> marker
+	drawn   bool   // Only the first marker draws a table
+	links   []int  // gofpdf link IDs of the drawn entries
+	spots   []spot // Positions of the listed headings placed so far
+}
+
+// htmlMarker returns the normalized text of an HTML block, for matching
+// against the page break and table of contents markers.
+func htmlMarker(block *ast.HTMLBlock, source []byte) string {
+	var text strings.Builder
+	lines := block.Lines()
+	for i := 0; i < lines.Len(); i++ {
+		segment := lines.At(i)
+		text.Write(segment.Value(source))
+	}
+	if block.HasClosure() {
+		text.Write(block.ClosureLine.Value(source))
+	}
+	return strings.ToLower(strings.Join(strings.Fields(text.String()), " "))
+}
+
+// renderHTMLBlock handles the markers among raw HTML blocks, which have no
+// PDF representation otherwise.
+func (r *PDFRenderer) renderHTMLBlock(pdf *gofpdf.Fpdf, block *ast.HTMLBlock, source []byte) {
+	switch htmlMarker(block, source) {
+	case pageBreakMarker:
+		r.pageBreak(pdf)
+	case tocMarker:
+		r.renderTOC(pdf)
+	case keepTogetherMarker:
+		r.keepGroupTogether(pdf, block, source)
+	}
+}
+
+// pageBreak starts a new page unless nothing has been drawn on the current
+// one yet.
+func (r *PDFRenderer) pageBreak(pdf *gofpdf.Fpdf) {
+	_, top, _, _ := pdf.GetMargins()
+	if pdf.GetY() > top+0.5 {
+		pdf.AddPage()
+	}
+}
+
+// planTOC returns the entries for the first render pass, taken from the
+// headings of the document, or false when the document has no table of
+// contents.
+func (r *PDFRenderer) planTOC(node ast.Node, source []byte) ([]outline.Heading, bool) {
+	var headings []outline.Heading
+	marker := false
+	slugs := r.headingSlugs(node, source)
+	_ = ast.Walk(node, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		switch n := n.(type) {
+		case *ast.Heading:
+			headings = append(headings, outline.Heading{Level: n.Level, Title: r.extractTextFromNode(n, source), Slug: slugs[n]})
+		case *ast.HTMLBlock:
+			marker = marker || htmlMarker(n, source) == tocMarker
+		}
+		return ast.WalkContinue, nil
+	})
+	r.toc.marker = marker
+	if !r.config.TOC.Enabled && !marker {
+		return nil, false
+	}
+	return r.tocEntries(headings), true
+}
+
+// tocEntries returns the headings listed in the table of contents.
+func (r *PDFRenderer) tocEntries(headings []outline.Heading) []outline.Heading {
+	entries := make([]outline.Heading, 0, len(headings))
+	for _, h := range headings {
+		if h.Level <= r.tocDepth() {
+			entries = append(entries, h)
+		}
+	}
+	return entries
+}
+
+func (r *PDFRenderer) tocDepth() int {
+	if r.config.TOC.Depth <= 0 {
+		return defaultTOCDepth
+	}
+	return r.config.TOC.Depth
+}
+
+// sameEntries reports whether two tables of contents list the same
+// headings on the same pages.
+func sameEntries(a, b []outline.Heading) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].Level != b[i].Level || a[i].Title != b[i].Title || a[i].Page != b[i].Page {
+			return false
+		}
+	}
+	return true
+}
+
+// placeTOCEntry points the table of contents entry for a heading at its
+// position.
+func (r *PDFRenderer) placeTOCEntry(pdf *gofpdf.Fpdf, h outline.Heading) {
+	if h.Level > r.tocDepth() {
+		return
+	}
+	s := spot{page: pdf.PageNo(), y: pdf.GetY()}
+	if i := len(r.toc.spots); i < len(r.toc.links) {
+		pdf.SetLink(r.toc.links[i], s.y, s.page)
+	}
+	r.toc.spots = append(r.toc.spots, s)
+}
+
+// renderTOC draws the table of contents: a title, then one linked line per
+// heading indented by level, with dot leaders to the page number. The
+// document continues on a new page.
+func (r *PDFRenderer) renderTOC(pdf *gofpdf.Fpdf) {
+	if r.toc.drawn {
+		return
+	}
+	r.toc.drawn = true
+
+	title := r.config.TOC.Title
+	if title == "" {
+		title = defaultTOCTitle
+	}
+	titleSize := r.config.FontSize + 10
+	pdf.SetFont(r.config.FontFamily, "B", titleSize)
+	pdf.MultiCell(0, titleSize*1.1, title, "", "L", false)
+	r.blockGap(pdf, gapTitle)
+
+	pdf.SetFont(r.config.FontFamily, "", r.config.FontSize)
+	lineHeight := r.bodyStyle().lineHeight
+	pageWidth, _ := pdf.GetPageSize()
+	leftMargin, _, rightMargin, _ := pdf.GetMargins()
+	numberX := pageWidth - rightMargin - tocPageWidth
+
+	minLevel := 0
+	for _, e := range r.toc.entries {
+		if minLevel == 0 || e.Level < minLevel {
+			minLevel = e.Level
+		}
+	}
+
+	r.toc.links = make([]int, len(r.toc.entries))
+	for i, e := range r.toc.entries {
+		link := pdf.AddLink()
+		r.toc.links[i] = link
+		if i < len(r.toc.spots) {
+			pdf.SetLink(link, r.toc.spots[i].y, r.toc.spots[i].page)
+		}
+
+		x := leftMargin + float64(e.Level-minLevel)*tocIndent
+		width := numberX - x
+		lines := pdf.SplitLines([]byte(e.Title), width)
+		if len(lines) == 0 {
+			lines = [][]byte{nil}
+		}
+		r.keepTogether(pdf, float64(len(lines))*lineHeight)
+
+		for j, line := range lines {
+			pdf.SetX(x)
+			if j < len(lines)-1 {
+				pdf.CellFormat(width, lineHeight, string(line), "", 1, "L", false, link, "")
+				continue
+			}
+			textEnd := x + pdf.GetStringWidth(string(line)) + 2*pdf.GetCellMargin()
+			pdf.CellFormat(textEnd-x, lineHeight, string(line), "", 0, "L", false, link, "")
+			pdf.CellFormat(numberX-textEnd, lineHeight, leaders(pdf, numberX-textEnd), "", 0, "R", false, link, "")
+			page := ""
+			if e.Page > 0 {
+				page = strconv.Itoa(e.Page)
+			}
+			pdf.CellFormat(tocPageWidth, lineHeight, page, "", 1, "R", false, link, "")
+		}
+	}
+	pdf.AddPage()
+}
+
+// leaders returns the dots that fill a cell of the given width.
+func leaders(pdf *gofpdf.Fpdf, width float64) string {
+	count := int((width - 2*pdf.GetCellMargin()) / pdf.GetStringWidth(" ."))
+	if count <= 0 {
+		return ""
+	}
+	return strings.Repeat(" .", count)
+}
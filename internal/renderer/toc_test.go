@@ -0,0 +1,132 @@
+package renderer
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/fredcamaral/md-to-pdf/internal/parser"
+	"github.com/fredcamaral/md-to-pdf/internal/plugins"
+)
+
+// countingGenerator adds a line of text before or after the content and
+// counts how often it ran.
+type countingGenerator struct {
+	phase plugins.GenerationPhase
+	text  string
+	calls int
+}
+
+func (g *countingGenerator) Name() string                             { return "counting-" + g.text }
+func (g *countingGenerator) Version() string                          { return "1.0.0" }
+func (g *countingGenerator) Description() string                      { return "counts its runs" }
+func (g *countingGenerator) Init(map[string]interface{}) error        { return nil }
+func (g *countingGenerator) Cleanup() error                           { return nil }
+func (g *countingGenerator) GenerationPhase() plugins.GenerationPhase { return g.phase }
+
+func (g *countingGenerator) Generate(*plugins.RenderContext) ([]plugins.PDFElement, error) {
+	g.calls++
+	return []plugins.PDFElement{&plugins.TextElement{Content: g.text}}, nil
+}
+
+func TestRenderTOC_PageNumbers(t *testing.T) {
+	config := defaultTestConfig()
+	config.TOC = TOCConfig{Enabled: true, Depth: 2}
+	markdown := "# One\n\nText.\n\n<!-- pagebreak -->\n\n# Two\n\n## Part\n\n### Deep\n"
+
+	renderer, content := renderMarkdown(t, config, markdown)
+
+	pages := make(map[string]int)
+	for _, h := range renderer.Headings() {
+		pages[h.Title] = h.Page
+	}
+	// The table of contents takes the first page
+	if pages["One"] != 2 || pages["Two"] != 3 || pages["Part"] != 3 {
+		t.Errorf("unexpected heading pages: %v", pages)
+	}
+	if !strings.Contains(content, "(Contents)Tj") {
+		t.Error("table of contents title not rendered")
+	}
+	for _, number := range []string{"(2)Tj", "(3)Tj"} {
+		if !strings.Contains(content, number) {
+			t.Errorf("table of contents should list page %s", number)
+		}
+	}
+	if got := strings.Count(content, "(Part)Tj"); got != 2 {
+		t.Errorf("Part should appear in the table and the text, found %d times", got)
+	}
+	if got := strings.Count(content, "(Deep)Tj"); got != 1 {
+		t.Errorf("headings deeper than the depth should not be listed, found %d times", got)
+	}
+}
+
+func TestRenderTOC_GeneratorsRunOnce(t *testing.T) {
+	config := defaultTestConfig()
+	config.TOC = TOCConfig{Enabled: true, Depth: 2}
+	manager := plugins.NewManager("./plugins", false, nil)
+	before := &countingGenerator{phase: plugins.BeforeContent, text: "Cover"}
+	after := &countingGenerator{phase: plugins.AfterContent, text: "Appendix"}
+	for _, generator := range []*countingGenerator{before, after} {
+		if err := manager.Register(generator); err != nil {
+			t.Fatalf("Register failed: %v", err)
+		}
+	}
+
+	// The cover pushes the headings a page further than the first pass
+	// assumes, so the document is rendered more than once
+	markdown := "# One\n\n" + strings.Repeat("Filler paragraph. ", 300) + "\n\n# Two\n"
+	renderer := NewPDFRenderer(config, defaultTestDocumentMetadata(), manager)
+	node, err := parser.NewMarkdownParser().Parse([]byte(markdown))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	buf, err := renderer.Render(node, []byte(markdown))
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+
+	if before.calls != 1 || after.calls != 1 {
+		t.Errorf("generators should run once per document, ran %d and %d times", before.calls, after.calls)
+	}
+	content := pdfContent(t, buf)
+	if !strings.Contains(content, "(Cover)Tj") || !strings.Contains(content, "(Appendix)Tj") {
+		t.Error("generated content should be rendered on the final pass")
+	}
+}
+
+func TestRenderTOC_Marker(t *testing.T) {
+	config := defaultTestConfig()
+	config.TOC.Title = "In this guide"
+	markdown := "# Guide\n\nIntro.\n\n<!-- toc -->\n\n## Install\n\n<!-- toc -->\n"
+
+	renderer, content := renderMarkdown(t, config, markdown)
+
+	if got := strings.Count(content, "(In this guide)Tj"); got != 1 {
+		t.Errorf("expected one table of contents at the marker, found %d", got)
+	}
+	headings := renderer.Headings()
+	if len(headings) != 2 || headings[0].Page != 1 || headings[1].Page != 2 {
+		t.Errorf("expected Guide before the table and Install after it, got %+v", headings)
+	}
+}
+
+func TestRenderTOC_DisabledWithoutMarker(t *testing.T) {
+	_, content := renderMarkdown(t, defaultTestConfig(), "# Title\n\nText.\n")
+	if strings.Contains(content, "(Contents)Tj") {
+		t.Error("no table of contents expected")
+	}
+}
+
+func TestRenderPageBreak(t *testing.T) {
+	markdown := "# A\n\n<!-- pagebreak -->\n\n<!-- PageBreak -->\n\n# B\n\n<div>ignored</div>\n\n# C\n"
+
+	renderer, _ := renderMarkdown(t, defaultTestConfig(), markdown)
+
+	headings := renderer.Headings()
+	if len(headings) != 3 {
+		t.Fatalf("expected 3 headings, got %d", len(headings))
+	}
+	// Consecutive breaks leave no empty page, other HTML is ignored
+	if headings[1].Page != 2 || headings[2].Page != 2 {
+		t.Errorf("unexpected pages: %+v", headings)
+	}
+}
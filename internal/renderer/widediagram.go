@@ -0,0 +1,83 @@
+package renderer
+
+import (
+	"math"
+
+	"github.com/jung-kurt/gofpdf"
+	"github.com/yuin/goldmark/ast"
+)
+
+// wideDiagramRatio is the width to height ratio above which a diagram too
+// wide for the text column is placed following the wide diagram strategy,
+// "rotate" or "landscape". Either is only used when it shows the diagram
+// larger than shrinking it to the column.
+const wideDiagramRatio = 1.5
+
+// diagramPadding is kept free beside diagrams, as for shrunk diagrams.
+const diagramPadding = 10.0
+
+// placeRotatedDiagram turns a diagram of the given natural size in mm 90°
+// counterclockwise so that its width runs down the page, starting a new
+// page when it does not fit below the current position. It reports whether
+// it placed the diagram, which it does not when the diagram would be no
+// larger than shrunkWidth, its width shrunk to the column.
+func (r *PDFRenderer) placeRotatedDiagram(pdf *gofpdf.Fpdf, imageName string, width, height, shrunkWidth float64) bool {
+	pageWidth, pageHeight := pdf.GetPageSize()
+	left, top, right, bottom := pdf.GetMargins()
+	scale := math.Min(1, math.Min((pageHeight-top-bottom)/width, (pageWidth-left-right-diagramPadding)/height))
+	if width*scale <= shrunkWidth {
+		return false
+	}
+	width, height = width*scale, height*scale
+
+	if pdf.GetY()+width > pageHeight-bottom {
+		pdf.AddPage()
+	}
+	// Rotating about the bottom left corner of the space the diagram takes
+	// turns an image drawn upwards from there into that space
+	x, y := left+(pageWidth-left-right-height)/2, pdf.GetY()+width
+	pdf.TransformBegin()
+	pdf.TransformRotate(90, x, y)
+	pdf.ImageOptions(imageName, x, y, width, height, false, gofpdf.ImageOptions{ImageType: "PNG"}, 0, "")
+	pdf.TransformEnd()
+
+	pdf.SetXY(left, y)
+	r.blockGap(pdf, gapSection)
+	return true
+}
+
+// placeLandscapeDiagram places a diagram of the given natural size in mm
+// alone on a landscape page, like placeRotatedDiagram. The content after it
+// starts a new portrait page.
+func (r *PDFRenderer) placeLandscapeDiagram(pdf *gofpdf.Fpdf, imageName string, width, height, shrunkWidth float64) bool {
+	long, short := math.Max(pdf.GetPageSize()), math.Min(pdf.GetPageSize())
+	left, top, right, bottom := pdf.GetMargins()
+	scale := math.Min(1, math.Min((long-left-right-diagramPadding)/width, (short-top-bottom)/height))
+	if width*scale <= shrunkWidth {
+		return false
+	}
+	width, height = width*scale, height*scale
+
+	pdf.AddPageFormat("L", gofpdf.SizeType{Wd: short, Ht: long})
+	x := left + (long-left-right-width)/2
+	y := top + (short-top-bottom-height)/2
+	pdf.ImageOptions(imageName, x, y, width, height, false, gofpdf.ImageOptions{ImageType: "PNG"}, 0, "")
+	r.resumePortrait = true
+	return true
+}
+
+// resumePage starts a portrait page for the content following a landscape
+// diagram, so that page breaks do not carry the landscape orientation on.
+func (r *PDFRenderer) resumePage(pdf *gofpdf.Fpdf) {
+	if r.resumePortrait {
+		r.resumePortrait = false
+		pdf.AddPage()
+	}
+}
+
+// isMermaidParagraph reports whether node is a paragraph the mermaid plugin
+// replaced a diagram by.
+func isMermaidParagraph(node ast.Node) bool {
+	_, ok := node.Attribute([]byte("data-mermaid-image"))
+	return ok
+}
@@ -0,0 +1,81 @@
+package renderer
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/yuin/goldmark/ast"
+)
+
+// renderWideDiagrams renders a heading, two diagrams of the given size in
+// pixels and a closing paragraph with the given wide diagram strategy.
+func renderWideDiagrams(t *testing.T, strategy string, width, height int) *bytes.Buffer {
+	t.Helper()
+	imagePath := filepath.Join(t.TempDir(), "mermaid.png")
+	var png bytes.Buffer
+	if err := writePNG(&png, createTestPNG(width, height)); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(imagePath, png.Bytes(), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	doc, source := parseMarkdown("# Pipeline\n\nA\n\nB\n\nThe end.\n")
+	for _, name := range []string{"A", "B"} {
+		for n := doc.FirstChild(); n != nil; n = n.NextSibling() {
+			if string(n.Text(source)) == name {
+				n.(*ast.Paragraph).SetAttribute([]byte("data-mermaid-image"), []byte(imagePath))
+			}
+		}
+	}
+
+	config := defaultTestConfig()
+	config.Mermaid.WideStrategy = strategy
+	buf, err := NewPDFRenderer(config, defaultTestDocumentMetadata(), nil).Render(doc, source)
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	return buf
+}
+
+func TestRender_WideDiagramStrategies(t *testing.T) {
+	const landscapeA4 = "/MediaBox [0 0 841.89 595.28]"
+	pages := func(buf *bytes.Buffer) int { return bytes.Count(buf.Bytes(), []byte("/Type /Page\n")) }
+
+	shrunk := renderWideDiagrams(t, "shrink", 3000, 600)
+	if pages(shrunk) != 1 || bytes.Contains(shrunk.Bytes(), []byte(landscapeA4)) {
+		t.Errorf("shrunk diagrams should fit one portrait page, got %d pages", pages(shrunk))
+	}
+
+	// Each diagram gets a landscape page, and the text after them a
+	// portrait page again
+	landscape := renderWideDiagrams(t, "landscape", 3000, 600)
+	if pages(landscape) != 4 {
+		t.Errorf("got %d pages, want 4", pages(landscape))
+	}
+	if n := bytes.Count(landscape.Bytes(), []byte(landscapeA4)); n != 2 {
+		t.Errorf("got %d landscape pages, want 2", n)
+	}
+
+	rotated := renderWideDiagrams(t, "rotate", 3000, 600)
+	if bytes.Contains(rotated.Bytes(), []byte(landscapeA4)) {
+		t.Error("rotated diagrams should stay on portrait pages")
+	}
+	if !strings.Contains(pdfContent(t, rotated), "0.00000 1.00000 -1.00000 0.00000") {
+		t.Error("diagrams should be rotated a quarter turn")
+	}
+}
+
+func TestRender_WideDiagramStrategy_OnlyWhenLarger(t *testing.T) {
+	// A diagram barely wider than tall is shrunk as usual
+	buf := renderWideDiagrams(t, "landscape", 3000, 2400)
+	if bytes.Contains(buf.Bytes(), []byte("/MediaBox [0 0 841.89 595.28]")) {
+		t.Error("diagrams that are not wide should not get a landscape page")
+	}
+	if strings.Contains(pdfContent(t, renderWideDiagrams(t, "rotate", 3000, 2400)), "0.00000 1.00000 -1.00000 0.00000") {
+		t.Error("diagrams that are not wide should not be rotated")
+	}
+}
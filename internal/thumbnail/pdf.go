@@ -0,0 +1,160 @@
+package thumbnail
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/fredcamaral/md-to-pdf/internal/pdfobj"
+)
+
+var (
+	referencePattern = regexp.MustCompile(`(\d+) 0 R`)
+	kidsPattern      = regexp.MustCompile(`/Kids \[([^\]]*)\]`)
+	mediaBoxPattern  = regexp.MustCompile(`/MediaBox \[\s*([-\d.]+)\s+([-\d.]+)\s+([-\d.]+)\s+([-\d.]+)\s*\]`)
+	contentsPattern  = regexp.MustCompile(`/Contents (\d+) 0 R`)
+	namedRefPattern  = regexp.MustCompile(`/(\S+)\s+(\d+) 0 R`)
+)
+
+// pdfPage is a page to rasterize.
+type pdfPage struct {
+	width    float64 // Page width in points
+	height   float64 // Page height in points
+	contents []byte  // Decoded content stream
+}
+
+// pdfDocument is the subset of a PDF file needed to draw its pages.
+type pdfDocument struct {
+	objects  map[int]pdfobj.Object
+	xobjects map[string]int // Resource name -> object number
+	pages    []pdfPage
+}
+
+// parsePDF reads the objects, pages and image resources of a PDF produced by
+// the renderer. It understands uncompressed and Flate-compressed content
+// streams but not cross-reference streams or object streams.
+func parsePDF(data []byte) (*pdfDocument, error) {
+	objects, _, err := pdfobj.Read(data)
+	if err != nil {
+		return nil, err
+	}
+	doc := &pdfDocument{
+		objects:  objects,
+		xobjects: make(map[string]int),
+	}
+
+	for _, obj := range doc.objects {
+		if body, ok := pdfobj.SubDictionary(obj.Dict, "/XObject"); ok {
+			for _, ref := range namedRefPattern.FindAllStringSubmatch(body, -1) {
+				num, _ := strconv.Atoi(ref[2])
+				doc.xobjects[ref[1]] = num
+			}
+		}
+	}
+
+	if err := doc.readPages(); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// readPages collects pages in document order from the page tree root.
+func (d *pdfDocument) readPages() error {
+	for _, obj := range d.objects {
+		if !isType(obj.Dict, "/Pages") {
+			continue
+		}
+		kids := kidsPattern.FindStringSubmatch(obj.Dict)
+		if kids == nil {
+			continue
+		}
+		defaultBox := mediaBoxPattern.FindStringSubmatch(obj.Dict)
+
+		for _, ref := range referencePattern.FindAllStringSubmatch(kids[1], -1) {
+			num, _ := strconv.Atoi(ref[1])
+			page, ok := d.objects[num]
+			if !ok {
+				return fmt.Errorf("page object %d not found", num)
+			}
+
+			box := mediaBoxPattern.FindStringSubmatch(page.Dict)
+			if box == nil {
+				box = defaultBox
+			}
+			if box == nil {
+				return fmt.Errorf("page object %d has no media box", num)
+			}
+
+			var contents []byte
+			if m := contentsPattern.FindStringSubmatch(page.Dict); m != nil {
+				contentNum, _ := strconv.Atoi(m[1])
+				decoded, err := d.objects[contentNum].Decode()
+				if err != nil {
+					return fmt.Errorf("page content of object %d: %w", num, err)
+				}
+				contents = decoded
+			}
+
+			d.pages = append(d.pages, pdfPage{
+				width:    parseFloat(box[3]) - parseFloat(box[1]),
+				height:   parseFloat(box[4]) - parseFloat(box[2]),
+				contents: contents,
+			})
+		}
+		return nil
+	}
+	return fmt.Errorf("no page tree found")
+}
+
+// dictInt returns the integer value of key in dict, or fallback when absent.
+func dictInt(dict, key string, fallback int) int {
+	value, ok := valueAfter(dict, key)
+	if !ok {
+		return fallback
+	}
+	end := 0
+	for end < len(value) && value[end] >= '0' && value[end] <= '9' {
+		end++
+	}
+	v, err := strconv.Atoi(value[:end])
+	if err != nil {
+		return fallback
+	}
+	return v
+}
+
+// isType reports whether dict declares the given /Type, e.g. "/Pages".
+// A plain substring check would also match "/Page" inside "/Pages".
+func isType(dict, typ string) bool {
+	value, ok := valueAfter(dict, "/Type")
+	return ok && strings.HasPrefix(value, typ) && (len(value) == len(typ) || endsName(value[len(typ)]))
+}
+
+// valueAfter returns the text after the first occurrence of the name key in
+// dict that is a whole name, with the whitespace after it skipped. Called
+// for every object, it scans the text instead of building a regexp.
+func valueAfter(dict, key string) (string, bool) {
+	for offset := 0; ; {
+		i := strings.Index(dict[offset:], key)
+		if i < 0 {
+			return "", false
+		}
+		rest := dict[offset+i+len(key):]
+		offset += i + len(key)
+		if rest != "" && !endsName(rest[0]) {
+			continue // A longer name, such as /Type1 for /Type
+		}
+		return strings.TrimLeft(rest, " \t\r\n\f\x00"), true
+	}
+}
+
+// endsName reports whether c cannot be part of a PDF name.
+func endsName(c byte) bool {
+	return isWhitespace(c) || isDelimiter(c)
+}
+
+func parseFloat(s string) float64 {
+	v, _ := strconv.ParseFloat(s, 64)
+	return v
+}
@@ -0,0 +1,681 @@
+package thumbnail
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Text is drawn as greeked bars: an estimate of each run's width and x-height
+// is enough to recognize the layout of a page at thumbnail size.
+const (
+	avgGlyphWidth  = 0.5  // Average glyph width relative to the font size
+	textBarHeight  = 0.55 // Bar height relative to the font size
+	textLightening = 0.35 // How far text colors are blended towards white
+)
+
+// matrix is a PDF transformation matrix [a b c d e f].
+type matrix [6]float64
+
+var identity = matrix{1, 0, 0, 1, 0, 0}
+
+// multiply returns m × n (apply m first, then n).
+func (m matrix) multiply(n matrix) matrix {
+	return matrix{
+		m[0]*n[0] + m[1]*n[2],
+		m[0]*n[1] + m[1]*n[3],
+		m[2]*n[0] + m[3]*n[2],
+		m[2]*n[1] + m[3]*n[3],
+		m[4]*n[0] + m[5]*n[2] + n[4],
+		m[4]*n[1] + m[5]*n[3] + n[5],
+	}
+}
+
+func (m matrix) apply(x, y float64) point {
+	return point{m[0]*x + m[2]*y + m[4], m[1]*x + m[3]*y + m[5]}
+}
+
+type point struct{ x, y float64 }
+
+// graphicsState is the part of the PDF graphics state that affects drawing.
+type graphicsState struct {
+	ctm       matrix
+	fill      color.RGBA
+	stroke    color.RGBA
+	lineWidth float64
+	fontSize  float64
+}
+
+// rasterizer draws one page content stream into an RGBA image.
+type rasterizer struct {
+	doc    *pdfDocument
+	img    *image.RGBA
+	device matrix // Maps PDF user space to image pixels (y axis flipped)
+	state  graphicsState
+	stack  []graphicsState
+	path   [][]point // Subpaths in device coordinates
+
+	textMatrix matrix
+	lineMatrix matrix
+	leading    float64
+
+	images map[string]image.Image
+}
+
+// renderPage rasterizes a page at the given pixel width.
+func (d *pdfDocument) renderPage(page pdfPage, width int) *image.RGBA {
+	scale := float64(width) / page.width
+	height := int(math.Round(page.height * scale))
+	if height < 1 {
+		height = 1
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	fillRect(img, img.Bounds(), color.RGBA{255, 255, 255, 255})
+
+	r := &rasterizer{
+		doc:    d,
+		img:    img,
+		device: matrix{scale, 0, 0, -scale, 0, page.height * scale},
+		state: graphicsState{
+			ctm:       identity,
+			fill:      color.RGBA{0, 0, 0, 255},
+			stroke:    color.RGBA{0, 0, 0, 255},
+			lineWidth: 1,
+		},
+		images: make(map[string]image.Image),
+	}
+	r.run(page.contents)
+	return img
+}
+
+// run interprets a content stream.
+func (r *rasterizer) run(contents []byte) {
+	var operands []token
+	lex := &lexer{data: contents}
+	for {
+		tok, ok := lex.next()
+		if !ok {
+			return
+		}
+		if tok.kind != tokenOperator {
+			operands = append(operands, tok)
+			continue
+		}
+		r.execute(tok.text, operands)
+		operands = operands[:0]
+	}
+}
+
+// execute runs a single content stream operator.
+func (r *rasterizer) execute(op string, args []token) {
+	num := func(i int) float64 {
+		if i < len(args) {
+			return args[i].num
+		}
+		return 0
+	}
+
+	switch op {
+	// Graphics state
+	case "q":
+		r.stack = append(r.stack, r.state)
+	case "Q":
+		if len(r.stack) > 0 {
+			r.state = r.stack[len(r.stack)-1]
+			r.stack = r.stack[:len(r.stack)-1]
+		}
+	case "cm":
+		m := matrix{num(0), num(1), num(2), num(3), num(4), num(5)}
+		r.state.ctm = m.multiply(r.state.ctm)
+	case "w":
+		r.state.lineWidth = num(0)
+	case "g":
+		r.state.fill = gray(num(0))
+	case "G":
+		r.state.stroke = gray(num(0))
+	case "rg":
+		r.state.fill = rgb(num(0), num(1), num(2))
+	case "RG":
+		r.state.stroke = rgb(num(0), num(1), num(2))
+	case "k":
+		r.state.fill = cmyk(num(0), num(1), num(2), num(3))
+	case "K":
+		r.state.stroke = cmyk(num(0), num(1), num(2), num(3))
+
+	// Path construction
+	case "m":
+		r.path = append(r.path, []point{r.toDevice(num(0), num(1))})
+	case "l":
+		r.lineTo(r.toDevice(num(0), num(1)))
+	case "c":
+		r.lineTo(r.toDevice(num(0), num(1)))
+		r.lineTo(r.toDevice(num(2), num(3)))
+		r.lineTo(r.toDevice(num(4), num(5)))
+	case "v", "y":
+		r.lineTo(r.toDevice(num(0), num(1)))
+		r.lineTo(r.toDevice(num(2), num(3)))
+	case "h":
+		if n := len(r.path); n > 0 && len(r.path[n-1]) > 0 {
+			r.lineTo(r.path[n-1][0])
+		}
+	case "re":
+		x, y, w, h := num(0), num(1), num(2), num(3)
+		r.path = append(r.path, []point{
+			r.toDevice(x, y), r.toDevice(x+w, y), r.toDevice(x+w, y+h), r.toDevice(x, y+h), r.toDevice(x, y),
+		})
+
+	// Path painting
+	case "S", "s":
+		r.strokePath()
+		r.path = nil
+	case "f", "F", "f*":
+		r.fillPath(r.state.fill)
+		r.path = nil
+	case "B", "B*", "b", "b*":
+		r.fillPath(r.state.fill)
+		r.strokePath()
+		r.path = nil
+	case "n":
+		r.path = nil
+
+	// Text
+	case "BT":
+		r.textMatrix, r.lineMatrix = identity, identity
+	case "Tf":
+		r.state.fontSize = num(1)
+	case "TL":
+		r.leading = num(0)
+	case "Td":
+		r.lineMatrix = matrix{1, 0, 0, 1, num(0), num(1)}.multiply(r.lineMatrix)
+		r.textMatrix = r.lineMatrix
+	case "TD":
+		r.leading = -num(1)
+		r.lineMatrix = matrix{1, 0, 0, 1, num(0), num(1)}.multiply(r.lineMatrix)
+		r.textMatrix = r.lineMatrix
+	case "Tm":
+		r.lineMatrix = matrix{num(0), num(1), num(2), num(3), num(4), num(5)}
+		r.textMatrix = r.lineMatrix
+	case "T*":
+		r.nextLine()
+	case "Tj", "TJ":
+		r.showText(args)
+	case "'", "\"":
+		r.nextLine()
+		r.showText(args)
+
+	// External objects
+	case "Do":
+		if len(args) > 0 {
+			r.drawXObject(args[0].text)
+		}
+	}
+}
+
+func (r *rasterizer) toDevice(x, y float64) point {
+	return r.state.ctm.multiply(r.device).apply(x, y)
+}
+
+func (r *rasterizer) lineTo(p point) {
+	if len(r.path) == 0 {
+		r.path = append(r.path, []point{p})
+		return
+	}
+	r.path[len(r.path)-1] = append(r.path[len(r.path)-1], p)
+}
+
+func (r *rasterizer) nextLine() {
+	r.lineMatrix = matrix{1, 0, 0, 1, 0, -r.leading}.multiply(r.lineMatrix)
+	r.textMatrix = r.lineMatrix
+}
+
+// showText draws a greeked bar for the shown string and advances the text
+// position by its estimated width.
+func (r *rasterizer) showText(args []token) {
+	chars := 0
+	for _, arg := range args {
+		if arg.kind == tokenString {
+			chars += arg.length
+		}
+	}
+	if chars == 0 || r.state.fontSize == 0 {
+		return
+	}
+
+	width := float64(chars) * r.state.fontSize * avgGlyphWidth
+	height := r.state.fontSize * textBarHeight
+	toDevice := r.textMatrix.multiply(r.state.ctm).multiply(r.device)
+
+	bar := []point{
+		toDevice.apply(0, 0), toDevice.apply(width, 0), toDevice.apply(width, height), toDevice.apply(0, height),
+	}
+	fillPolygon(r.img, [][]point{bar}, lighten(r.state.fill, textLightening))
+
+	r.textMatrix = matrix{1, 0, 0, 1, width, 0}.multiply(r.textMatrix)
+}
+
+func (r *rasterizer) fillPath(c color.RGBA) {
+	fillPolygon(r.img, r.path, c)
+}
+
+func (r *rasterizer) strokePath() {
+	// Line width in device pixels, never thinner than one pixel
+	scale := math.Hypot(r.state.ctm[0]*r.device[0], r.state.ctm[1]*r.device[0])
+	width := math.Max(1, r.state.lineWidth*scale)
+	for _, sub := range r.path {
+		for i := 1; i < len(sub); i++ {
+			strokeLine(r.img, sub[i-1], sub[i], width, r.state.stroke)
+		}
+	}
+}
+
+// drawXObject draws an image XObject into the unit square mapped by the CTM.
+func (r *rasterizer) drawXObject(name string) {
+	src, ok := r.images[name]
+	if !ok {
+		src = r.doc.decodeImage(name)
+		r.images[name] = src
+	}
+
+	toDevice := r.state.ctm.multiply(r.device)
+	bottomLeft := toDevice.apply(0, 0)
+	topRight := toDevice.apply(1, 1)
+
+	minX := int(math.Floor(math.Min(bottomLeft.x, topRight.x)))
+	maxX := int(math.Ceil(math.Max(bottomLeft.x, topRight.x)))
+	minY := int(math.Floor(math.Min(bottomLeft.y, topRight.y)))
+	maxY := int(math.Ceil(math.Max(bottomLeft.y, topRight.y)))
+	area := image.Rect(minX, minY, maxX, maxY).Intersect(r.img.Bounds())
+
+	if src == nil {
+		fillRect(r.img, area, color.RGBA{200, 200, 200, 255})
+		return
+	}
+
+	bounds := src.Bounds()
+	spanX := topRight.x - bottomLeft.x
+	spanY := bottomLeft.y - topRight.y
+	if spanX == 0 || spanY == 0 {
+		return
+	}
+	for py := area.Min.Y; py < area.Max.Y; py++ {
+		v := (float64(py) + 0.5 - topRight.y) / spanY
+		sy := bounds.Min.Y + clamp(int(v*float64(bounds.Dy())), 0, bounds.Dy()-1)
+		for px := area.Min.X; px < area.Max.X; px++ {
+			u := (float64(px) + 0.5 - bottomLeft.x) / spanX
+			sx := bounds.Min.X + clamp(int(u*float64(bounds.Dx())), 0, bounds.Dx()-1)
+			r.img.Set(px, py, src.At(sx, sy))
+		}
+	}
+}
+
+// decodeImage decodes an image XObject. Unsupported encodings return nil
+// and are drawn as placeholders.
+func (d *pdfDocument) decodeImage(name string) image.Image {
+	num, ok := d.xobjects[name]
+	if !ok {
+		return nil
+	}
+	obj := d.objects[num]
+	if !strings.Contains(obj.Dict, "/Subtype /Image") {
+		return nil
+	}
+
+	if strings.Contains(obj.Dict, "/DCTDecode") {
+		img, err := jpeg.Decode(bytes.NewReader(obj.Stream))
+		if err != nil {
+			return nil
+		}
+		return img
+	}
+
+	data, err := obj.Decode()
+	if err != nil {
+		return nil
+	}
+
+	width := dictInt(obj.Dict, "/Width", 0)
+	height := dictInt(obj.Dict, "/Height", 0)
+	if width == 0 || height == 0 || dictInt(obj.Dict, "/BitsPerComponent", 8) != 8 {
+		return nil
+	}
+
+	var colors int
+	switch {
+	case strings.Contains(obj.Dict, "/ColorSpace /DeviceRGB"):
+		colors = 3
+	case strings.Contains(obj.Dict, "/ColorSpace /DeviceGray"):
+		colors = 1
+	case strings.Contains(obj.Dict, "/ColorSpace /DeviceCMYK"):
+		colors = 4
+	default:
+		return nil
+	}
+
+	if dictInt(obj.Dict, "/Predictor", 1) >= 10 {
+		data = unpredictPNG(data, width*colors, colors)
+	}
+	if len(data) < width*height*colors {
+		return nil
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			p := data[(y*width+x)*colors:]
+			switch colors {
+			case 1:
+				img.Set(x, y, color.RGBA{p[0], p[0], p[0], 255})
+			case 3:
+				img.Set(x, y, color.RGBA{p[0], p[1], p[2], 255})
+			case 4:
+				img.Set(x, y, cmyk(float64(p[0])/255, float64(p[1])/255, float64(p[2])/255, float64(p[3])/255))
+			}
+		}
+	}
+	return img
+}
+
+// unpredictPNG reverses PNG row filters (PDF predictors 10-15), where each
+// row of rowBytes is prefixed with its filter type.
+func unpredictPNG(data []byte, rowBytes, bpp int) []byte {
+	stride := rowBytes + 1
+	rows := len(data) / stride
+	out := make([]byte, rows*rowBytes)
+	prev := make([]byte, rowBytes)
+
+	for y := 0; y < rows; y++ {
+		filter := data[y*stride]
+		src := data[y*stride+1 : (y+1)*stride]
+		cur := out[y*rowBytes : (y+1)*rowBytes]
+		for i := range src {
+			var left, upLeft byte
+			if i >= bpp {
+				left, upLeft = cur[i-bpp], prev[i-bpp]
+			}
+			up := prev[i]
+			switch filter {
+			case 1:
+				cur[i] = src[i] + left
+			case 2:
+				cur[i] = src[i] + up
+			case 3:
+				cur[i] = src[i] + byte((int(left)+int(up))/2)
+			case 4:
+				cur[i] = src[i] + paeth(left, up, upLeft)
+			default:
+				cur[i] = src[i]
+			}
+		}
+		prev = cur
+	}
+	return out
+}
+
+func paeth(a, b, c byte) byte {
+	p := int(a) + int(b) - int(c)
+	pa, pb, pc := abs(p-int(a)), abs(p-int(b)), abs(p-int(c))
+	switch {
+	case pa <= pb && pa <= pc:
+		return a
+	case pb <= pc:
+		return b
+	default:
+		return c
+	}
+}
+
+// fillPolygon fills subpaths with the even-odd rule by scanning pixel rows.
+func fillPolygon(img *image.RGBA, subpaths [][]point, c color.RGBA) {
+	minY, maxY := math.Inf(1), math.Inf(-1)
+	for _, sub := range subpaths {
+		for _, p := range sub {
+			minY, maxY = math.Min(minY, p.y), math.Max(maxY, p.y)
+		}
+	}
+	if math.IsInf(minY, 0) {
+		return
+	}
+
+	bounds := img.Bounds()
+	startY := clamp(int(math.Floor(minY)), bounds.Min.Y, bounds.Max.Y)
+	endY := clamp(int(math.Ceil(maxY)), bounds.Min.Y, bounds.Max.Y)
+
+	// Shapes thinner than a pixel (hairline rules, underlines) would be
+	// missed by row-center sampling, so always cover at least one row.
+	if endY == startY && startY < bounds.Max.Y {
+		endY = startY + 1
+	}
+
+	for y := startY; y < endY; y++ {
+		scan := float64(y) + 0.5
+		if endY-startY == 1 {
+			scan = (minY + maxY) / 2
+		}
+		var xs []float64
+		for _, sub := range subpaths {
+			n := len(sub)
+			for i := 0; i < n; i++ {
+				a, b := sub[i], sub[(i+1)%n]
+				if (a.y <= scan && b.y > scan) || (b.y <= scan && a.y > scan) {
+					xs = append(xs, a.x+(scan-a.y)*(b.x-a.x)/(b.y-a.y))
+				}
+			}
+		}
+		sort.Float64s(xs)
+		for i := 0; i+1 < len(xs); i += 2 {
+			x0 := int(math.Round(xs[i]))
+			x1 := int(math.Round(xs[i+1]))
+			if x1 == x0 {
+				x1++
+			}
+			fillRect(img, image.Rect(x0, y, x1, y+1), c)
+		}
+	}
+}
+
+// strokeLine draws a line segment of the given pixel width.
+func strokeLine(img *image.RGBA, a, b point, width float64, c color.RGBA) {
+	length := math.Hypot(b.x-a.x, b.y-a.y)
+	steps := int(math.Ceil(length*2)) + 1
+	half := width / 2
+	for i := 0; i <= steps; i++ {
+		t := float64(i) / float64(steps)
+		x := a.x + (b.x-a.x)*t
+		y := a.y + (b.y-a.y)*t
+		fillRect(img, image.Rect(
+			int(math.Floor(x-half)), int(math.Floor(y-half)),
+			int(math.Floor(x+half))+1, int(math.Floor(y+half))+1,
+		), c)
+	}
+}
+
+func fillRect(img *image.RGBA, rect image.Rectangle, c color.RGBA) {
+	rect = rect.Intersect(img.Bounds())
+	for y := rect.Min.Y; y < rect.Max.Y; y++ {
+		for x := rect.Min.X; x < rect.Max.X; x++ {
+			img.SetRGBA(x, y, c)
+		}
+	}
+}
+
+func gray(v float64) color.RGBA {
+	c := component(v)
+	return color.RGBA{c, c, c, 255}
+}
+
+func rgb(r, g, b float64) color.RGBA {
+	return color.RGBA{component(r), component(g), component(b), 255}
+}
+
+func cmyk(c, m, y, k float64) color.RGBA {
+	return rgb((1-c)*(1-k), (1-m)*(1-k), (1-y)*(1-k))
+}
+
+func lighten(c color.RGBA, amount float64) color.RGBA {
+	mix := func(v uint8) uint8 {
+		return uint8(float64(v) + (255-float64(v))*amount)
+	}
+	return color.RGBA{mix(c.R), mix(c.G), mix(c.B), 255}
+}
+
+func component(v float64) uint8 {
+	return uint8(math.Round(math.Max(0, math.Min(1, v)) * 255))
+}
+
+func clamp(v, lo, hi int) int {
+	if v < lo {
+		return lo
+	}
+	if v > hi {
+		return hi
+	}
+	return v
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+// tokenKind classifies content stream tokens.
+type tokenKind int
+
+const (
+	tokenNumber tokenKind = iota
+	tokenName
+	tokenString
+	tokenOperator
+	tokenOther
+)
+
+type token struct {
+	kind   tokenKind
+	num    float64
+	text   string // Name (without slash) or operator
+	length int    // Byte length of decoded strings
+}
+
+// lexer splits a content stream into tokens. Strings are only measured,
+// since text is drawn as greeked bars.
+type lexer struct {
+	data []byte
+	pos  int
+}
+
+func (l *lexer) next() (token, bool) {
+	for l.pos < len(l.data) {
+		ch := l.data[l.pos]
+		switch {
+		case isWhitespace(ch):
+			l.pos++
+		case ch == '%':
+			for l.pos < len(l.data) && l.data[l.pos] != '\n' && l.data[l.pos] != '\r' {
+				l.pos++
+			}
+		case ch == '(':
+			return token{kind: tokenString, length: l.literalString()}, true
+		case ch == '<':
+			if l.pos+1 < len(l.data) && l.data[l.pos+1] == '<' {
+				l.pos += 2
+				return token{kind: tokenOther}, true
+			}
+			return token{kind: tokenString, length: l.hexString()}, true
+		case ch == '>' || ch == '[' || ch == ']' || ch == '{' || ch == '}':
+			l.pos++
+			if ch == '>' && l.pos < len(l.data) && l.data[l.pos] == '>' {
+				l.pos++
+			}
+			return token{kind: tokenOther}, true
+		case ch == '/':
+			l.pos++
+			return token{kind: tokenName, text: l.word()}, true
+		default:
+			word := l.word()
+			if word == "" {
+				l.pos++
+				continue
+			}
+			if v, err := strconv.ParseFloat(word, 64); err == nil {
+				return token{kind: tokenNumber, num: v}, true
+			}
+			return token{kind: tokenOperator, text: word}, true
+		}
+	}
+	return token{}, false
+}
+
+// literalString consumes a (...) string and returns its decoded length.
+func (l *lexer) literalString() int {
+	l.pos++ // opening parenthesis
+	depth, length := 1, 0
+	for l.pos < len(l.data) {
+		ch := l.data[l.pos]
+		l.pos++
+		switch ch {
+		case '\\':
+			if l.pos < len(l.data) {
+				next := l.data[l.pos]
+				l.pos++
+				if next >= '0' && next <= '7' {
+					// Octal escape of up to three digits
+					for i := 0; i < 2 && l.pos < len(l.data) && l.data[l.pos] >= '0' && l.data[l.pos] <= '7'; i++ {
+						l.pos++
+					}
+				} else if next == '\n' || next == '\r' {
+					continue // Line continuation
+				}
+			}
+			length++
+		case '(':
+			depth++
+			length++
+		case ')':
+			depth--
+			if depth == 0 {
+				return length
+			}
+			length++
+		default:
+			length++
+		}
+	}
+	return length
+}
+
+// hexString consumes a <...> string and returns its decoded length.
+func (l *lexer) hexString() int {
+	l.pos++ // opening bracket
+	digits := 0
+	for l.pos < len(l.data) && l.data[l.pos] != '>' {
+		if !isWhitespace(l.data[l.pos]) {
+			digits++
+		}
+		l.pos++
+	}
+	l.pos++ // closing bracket
+	return (digits + 1) / 2
+}
+
+func (l *lexer) word() string {
+	start := l.pos
+	for l.pos < len(l.data) && !isWhitespace(l.data[l.pos]) && !isDelimiter(l.data[l.pos]) {
+		l.pos++
+	}
+	return string(l.data[start:l.pos])
+}
+
+func isWhitespace(ch byte) bool {
+	return ch == ' ' || ch == '\n' || ch == '\r' || ch == '\t' || ch == '\f' || ch == 0
+}
+
+func isDelimiter(ch byte) bool {
+	return strings.IndexByte("()<>[]{}/%", ch) >= 0
+}
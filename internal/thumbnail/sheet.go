@@ -0,0 +1,179 @@
+// Package thumbnail renders page thumbnails of generated PDFs and lays them
+// out as a PNG contact sheet for quick visual review.
+//
+// Pages are rasterized by interpreting the PDF content streams directly:
+// vector shapes and embedded images are drawn, while text is shown as
+// greeked bars. This keeps the feature free of external renderers.
+package thumbnail
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"strconv"
+
+	"github.com/fredcamaral/md-to-pdf/internal/fsutil"
+)
+
+const (
+	// DefaultColumns is the number of thumbnails per contact sheet row.
+	DefaultColumns = 4
+
+	// DefaultThumbWidth is the width of each page thumbnail in pixels.
+	DefaultThumbWidth = 160
+
+	sheetPadding = 16 // Space around and between thumbnails in pixels
+	labelScale   = 2  // Pixel size of the page number font
+	labelGap     = 6  // Space between a thumbnail and its page number
+)
+
+var (
+	sheetBackground = color.RGBA{240, 240, 240, 255}
+	pageBorder      = color.RGBA{190, 190, 190, 255}
+	labelColor      = color.RGBA{90, 90, 90, 255}
+)
+
+// Options controls the contact sheet layout.
+type Options struct {
+	Columns    int // Thumbnails per row
+	ThumbWidth int // Thumbnail width in pixels
+}
+
+// DefaultOptions returns the default contact sheet layout.
+func DefaultOptions() Options {
+	return Options{
+		Columns:    DefaultColumns,
+		ThumbWidth: DefaultThumbWidth,
+	}
+}
+
+// RenderPages rasterizes every page of a PDF at the given pixel width.
+func RenderPages(pdf []byte, width int) ([]*image.RGBA, error) {
+	if width <= 0 {
+		return nil, fmt.Errorf("thumbnail width must be positive, got %d", width)
+	}
+
+	doc, err := parsePDF(pdf)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read PDF: %w", err)
+	}
+
+	pages := make([]*image.RGBA, 0, len(doc.pages))
+	for _, page := range doc.pages {
+		pages = append(pages, doc.renderPage(page, width))
+	}
+	return pages, nil
+}
+
+// ContactSheet arranges page thumbnails in a grid, each labeled with its
+// page number.
+func ContactSheet(pages []*image.RGBA, columns int) *image.RGBA {
+	if columns <= 0 {
+		columns = DefaultColumns
+	}
+	if len(pages) < columns {
+		columns = len(pages)
+	}
+	if columns == 0 {
+		columns = 1
+	}
+
+	cellWidth, cellHeight := 0, 0
+	for _, page := range pages {
+		cellWidth = max(cellWidth, page.Bounds().Dx())
+		cellHeight = max(cellHeight, page.Bounds().Dy())
+	}
+	labelHeight := glyphHeight*labelScale + labelGap
+	rowHeight := cellHeight + labelHeight
+
+	rows := (len(pages) + columns - 1) / columns
+	sheet := image.NewRGBA(image.Rect(0, 0,
+		sheetPadding+columns*(cellWidth+sheetPadding),
+		sheetPadding+rows*(rowHeight+sheetPadding),
+	))
+	fillRect(sheet, sheet.Bounds(), sheetBackground)
+
+	for i, page := range pages {
+		x := sheetPadding + (i%columns)*(cellWidth+sheetPadding)
+		y := sheetPadding + (i/columns)*(rowHeight+sheetPadding)
+		size := page.Bounds().Size()
+
+		// Border, then the page itself
+		fillRect(sheet, image.Rect(x-1, y-1, x+size.X+1, y+size.Y+1), pageBorder)
+		for py := 0; py < size.Y; py++ {
+			for px := 0; px < size.X; px++ {
+				sheet.SetRGBA(x+px, y+py, page.RGBAAt(px, py))
+			}
+		}
+
+		label := strconv.Itoa(i + 1)
+		labelWidth := len(label)*(glyphWidth+1)*labelScale - labelScale
+		drawDigits(sheet, label, x+(size.X-labelWidth)/2, y+size.Y+labelGap, labelScale, labelColor)
+	}
+
+	return sheet
+}
+
+// WriteContactSheet rasterizes a PDF and writes its contact sheet as PNG.
+func WriteContactSheet(path string, pdf []byte, opts Options) error {
+	if opts.ThumbWidth <= 0 {
+		opts.ThumbWidth = DefaultThumbWidth
+	}
+
+	pages, err := RenderPages(pdf, opts.ThumbWidth)
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, ContactSheet(pages, opts.Columns)); err != nil {
+		return fmt.Errorf("failed to encode contact sheet: %w", err)
+	}
+	if err := fsutil.WriteFile(path, buf.Bytes(), 0600); err != nil {
+		return fmt.Errorf("failed to write contact sheet: %w", err)
+	}
+	return nil
+}
+
+// Page numbers use a tiny built-in 3x5 bitmap font so the sheet does not
+// depend on font files.
+const (
+	glyphWidth  = 3
+	glyphHeight = 5
+)
+
+var digitGlyphs = [10][glyphHeight]string{
+	{"###", "#.#", "#.#", "#.#", "###"},
+	{".#.", "##.", ".#.", ".#.", "###"},
+	{"###", "..#", "###", "#..", "###"},
+	{"###", "..#", "###", "..#", "###"},
+	{"#.#", "#.#", "###", "..#", "..#"},
+	{"###", "#..", "###", "..#", "###"},
+	{"###", "#..", "###", "#.#", "###"},
+	{"###", "..#", ".#.", ".#.", ".#."},
+	{"###", "#.#", "###", "#.#", "###"},
+	{"###", "#.#", "###", "..#", "###"},
+}
+
+// drawDigits draws a string of decimal digits with its top-left corner at x, y.
+func drawDigits(img *image.RGBA, digits string, x, y, scale int, c color.RGBA) {
+	for _, ch := range digits {
+		if ch < '0' || ch > '9' {
+			continue
+		}
+		glyph := digitGlyphs[ch-'0']
+		for row, line := range glyph {
+			for col, bit := range line {
+				if bit == '#' {
+					fillRect(img, image.Rect(
+						x+col*scale, y+row*scale,
+						x+(col+1)*scale, y+(row+1)*scale,
+					), c)
+				}
+			}
+		}
+		x += (glyphWidth + 1) * scale
+	}
+}
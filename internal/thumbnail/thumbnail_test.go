@@ -0,0 +1,240 @@
+package thumbnail
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jung-kurt/gofpdf"
+)
+
+// buildPDF creates a PDF with gofpdf, the same library the renderer uses.
+func buildPDF(t *testing.T, compress bool, draw func(pdf *gofpdf.Fpdf)) []byte {
+	t.Helper()
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.SetCompression(compress)
+	pdf.SetFont("Arial", "", 12)
+	draw(pdf)
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		t.Fatalf("failed to build PDF: %v", err)
+	}
+	return buf.Bytes()
+}
+
+// redPNG encodes a solid red PNG image.
+func redPNG(t *testing.T) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, 8, 8))
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			img.Set(x, y, color.RGBA{255, 0, 0, 255})
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatalf("failed to encode PNG: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestRenderPages_PageCountAndSize(t *testing.T) {
+	for _, compress := range []bool{true, false} {
+		data := buildPDF(t, compress, func(pdf *gofpdf.Fpdf) {
+			for i := 0; i < 3; i++ {
+				pdf.AddPage()
+				pdf.Cell(40, 10, "Page")
+			}
+		})
+
+		pages, err := RenderPages(data, 100)
+		if err != nil {
+			t.Fatalf("RenderPages(compress=%v) failed: %v", compress, err)
+		}
+		if len(pages) != 3 {
+			t.Fatalf("expected 3 pages, got %d", len(pages))
+		}
+
+		// A4 is 210x297mm
+		size := pages[0].Bounds().Size()
+		if size.X != 100 || size.Y != 141 {
+			t.Errorf("thumbnail size = %v, want 100x141", size)
+		}
+	}
+}
+
+func TestRenderPages_DrawsContent(t *testing.T) {
+	imageData := redPNG(t)
+	data := buildPDF(t, true, func(pdf *gofpdf.Fpdf) {
+		pdf.AddPage()
+
+		// Blue filled rectangle in the top-left quarter
+		pdf.SetFillColor(0, 0, 255)
+		pdf.Rect(10, 10, 50, 50, "F")
+
+		// Red image in the bottom-right quarter
+		pdf.RegisterImageOptionsReader("red", gofpdf.ImageOptions{ImageType: "PNG"}, bytes.NewReader(imageData))
+		pdf.ImageOptions("red", 140, 230, 50, 50, false, gofpdf.ImageOptions{ImageType: "PNG"}, 0, "")
+
+		// Text in the middle
+		pdf.SetXY(20, 150)
+		pdf.Cell(100, 10, "Greeked text at thumbnail size")
+	})
+
+	pages, err := RenderPages(data, 210)
+	if err != nil {
+		t.Fatalf("RenderPages failed: %v", err)
+	}
+	page := pages[0]
+
+	// At 210px for 210mm, one pixel is one millimeter
+	if got := page.RGBAAt(35, 35); got != (color.RGBA{0, 0, 255, 255}) {
+		t.Errorf("rectangle pixel = %v, want blue", got)
+	}
+	if got := page.RGBAAt(165, 255); got != (color.RGBA{255, 0, 0, 255}) {
+		t.Errorf("image pixel = %v, want red", got)
+	}
+	if got := page.RGBAAt(100, 100); got != (color.RGBA{255, 255, 255, 255}) {
+		t.Errorf("empty area pixel = %v, want white", got)
+	}
+
+	textDrawn := false
+	for x := 20; x < 80 && !textDrawn; x++ {
+		for y := 150; y < 160; y++ {
+			if page.RGBAAt(x, y) != (color.RGBA{255, 255, 255, 255}) {
+				textDrawn = true
+				break
+			}
+		}
+	}
+	if !textDrawn {
+		t.Error("expected text to be drawn as greeked bars")
+	}
+}
+
+func TestRenderPages_InvalidInput(t *testing.T) {
+	if _, err := RenderPages([]byte("not a pdf"), 100); err == nil {
+		t.Error("expected error for invalid PDF")
+	}
+	if _, err := RenderPages(nil, 0); err == nil {
+		t.Error("expected error for zero width")
+	}
+}
+
+func TestContactSheet_Layout(t *testing.T) {
+	pages := make([]*image.RGBA, 5)
+	for i := range pages {
+		pages[i] = image.NewRGBA(image.Rect(0, 0, 40, 60))
+	}
+
+	sheet := ContactSheet(pages, 2)
+
+	labelHeight := glyphHeight*labelScale + labelGap
+	wantWidth := sheetPadding + 2*(40+sheetPadding)
+	wantHeight := sheetPadding + 3*(60+labelHeight+sheetPadding)
+	if size := sheet.Bounds().Size(); size.X != wantWidth || size.Y != wantHeight {
+		t.Errorf("sheet size = %v, want %dx%d", size, wantWidth, wantHeight)
+	}
+
+	// Fewer pages than columns shrink the sheet to fit
+	single := ContactSheet(pages[:1], 4)
+	if single.Bounds().Dx() != sheetPadding+40+sheetPadding {
+		t.Errorf("single page sheet width = %d", single.Bounds().Dx())
+	}
+}
+
+func TestWriteContactSheet(t *testing.T) {
+	data := buildPDF(t, true, func(pdf *gofpdf.Fpdf) {
+		pdf.AddPage()
+		pdf.Cell(40, 10, "One")
+		pdf.AddPage()
+		pdf.Cell(40, 10, "Two")
+	})
+
+	path := filepath.Join(t.TempDir(), "sheet.png")
+	if err := WriteContactSheet(path, data, Options{Columns: 2, ThumbWidth: 50}); err != nil {
+		t.Fatalf("WriteContactSheet failed: %v", err)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("contact sheet not written: %v", err)
+	}
+	defer file.Close()
+
+	img, err := png.Decode(file)
+	if err != nil {
+		t.Fatalf("contact sheet is not a PNG: %v", err)
+	}
+	if img.Bounds().Dx() != sheetPadding+2*(50+sheetPadding) {
+		t.Errorf("unexpected sheet width %d", img.Bounds().Dx())
+	}
+}
+
+func TestUnpredictPNG(t *testing.T) {
+	// Two rows of two RGB pixels: row 0 uses Sub, row 1 uses Up
+	data := []byte{
+		1, 10, 20, 30, 5, 5, 5,
+		2, 1, 1, 1, 1, 1, 1,
+	}
+	got := unpredictPNG(data, 6, 3)
+	want := []byte{10, 20, 30, 15, 25, 35, 11, 21, 31, 16, 26, 36}
+	if !bytes.Equal(got, want) {
+		t.Errorf("unpredictPNG = %v, want %v", got, want)
+	}
+}
+
+func TestDictInt(t *testing.T) {
+	dict := "<</Type /XObject /Subtype /Image /Width 64 /Height\n32 /DecodeParms <</Predictor 15>>>>"
+	tests := []struct {
+		key  string
+		want int
+	}{
+		{"/Width", 64},
+		{"/Height", 32},
+		{"/Predictor", 15},
+		{"/BitsPerComponent", 8},
+		{"/W", 8}, // Only whole names match
+	}
+	for _, tt := range tests {
+		if got := dictInt(dict, tt.key, 8); got != tt.want {
+			t.Errorf("dictInt(%s) = %d, want %d", tt.key, got, tt.want)
+		}
+	}
+
+	if !isType("<</Type /Pages /Kids [3 0 R]>>", "/Pages") || !isType("<</Type/Page>>", "/Page") {
+		t.Error("isType should match the declared type")
+	}
+	if isType("<</Type /Pages>>", "/Page") || isType("<</Subtype /Type1 /Type /Font>>", "/Pages") {
+		t.Error("isType should not match a longer type or another key")
+	}
+}
+
+func TestLexer(t *testing.T) {
+	lex := &lexer{data: []byte(`BT /F1 12 Tf 10.5 20 Td (a\(b\)\101) Tj <48656c6c6f> Tj ET`)}
+
+	var kinds []tokenKind
+	var lengths []int
+	for {
+		tok, ok := lex.next()
+		if !ok {
+			break
+		}
+		kinds = append(kinds, tok.kind)
+		if tok.kind == tokenString {
+			lengths = append(lengths, tok.length)
+		}
+	}
+
+	if len(kinds) != 12 {
+		t.Fatalf("expected 12 tokens, got %d", len(kinds))
+	}
+	if len(lengths) != 2 || lengths[0] != 5 || lengths[1] != 5 {
+		t.Errorf("string lengths = %v, want [5 5]", lengths)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"strings"
 
 	"github.com/fatih/color"
 	"github.com/mattn/go-isatty"
@@ -114,6 +115,22 @@ func (o *Output) Errorf(format string, args ...interface{}) {
 	_, _ = fmt.Fprintln(o.stderr)
 }
 
+// Snippet prints where an error is in a source file to stderr, below the
+// error message: the location, such as doc.md:12:5, then the snippet
+// showing it, whose last line, holding the caret, is colored like errors.
+func (o *Output) Snippet(location, snippet string) {
+	_, _ = fmt.Fprintf(o.stderr, "  --> %s\n", location)
+	lines := strings.Split(snippet, "\n")
+	for i, line := range lines {
+		if i == len(lines)-1 {
+			_, _ = o.errorColor.Fprint(o.stderr, "  "+line)
+			_, _ = fmt.Fprintln(o.stderr)
+			continue
+		}
+		_, _ = fmt.Fprintln(o.stderr, "  "+line)
+	}
+}
+
 // Warn prints a warning message to stderr in yellow.
 func (o *Output) Warn(format string, args ...interface{}) {
 	msg := fmt.Sprintf(format, args...)
@@ -50,6 +50,22 @@ func TestOutput_Errorf(t *testing.T) {
 	}
 }
 
+func TestOutput_Snippet(t *testing.T) {
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+
+	o := NewOutputWithWriters(stdout, stderr)
+	o.Snippet("doc.md:3:1", "3 | <!-- endif -->\n  | ^")
+
+	want := "  --> doc.md:3:1\n  3 | <!-- endif -->\n    | ^\n"
+	if stderr.String() != want {
+		t.Errorf("stderr = %q, want %q", stderr.String(), want)
+	}
+	if stdout.Len() != 0 {
+		t.Errorf("expected nothing on stdout, got: %s", stdout.String())
+	}
+}
+
 func TestOutput_Warn(t *testing.T) {
 	stdout := &bytes.Buffer{}
 	stderr := &bytes.Buffer{}
@@ -158,6 +158,11 @@ func (b *BatchProgress) CompleteWithMessage(message string) {
 	}
 }
 
+// Stop stops progress without a summary, for a batch that did not complete.
+func (b *BatchProgress) Stop() {
+	b.progress.Stop()
+}
+
 // Error stops progress and shows an error.
 func (b *BatchProgress) Error(err error) {
 	b.progress.Stop()
@@ -703,3 +703,21 @@ func TestGenerationPhaseConstants(t *testing.T) {
 		seen[phase] = true
 	}
 }
+
+func TestCreateTableElement(t *testing.T) {
+	table := CreateTableElement("Key", "Value").
+		AddRow("font-size", "12").
+		AddRow("page-size", "A4")
+
+	if len(table.Header) != 2 || table.Header[0] != "Key" {
+		t.Errorf("unexpected header: %v", table.Header)
+	}
+	if len(table.Rows) != 2 {
+		t.Errorf("expected 2 rows, got %d", len(table.Rows))
+	}
+
+	var element PDFElement = table
+	if element.Height() <= 0 {
+		t.Error("table height should be positive")
+	}
+}
@@ -0,0 +1,254 @@
+// Package plugintest helps plugin authors unit test their plugins: it parses
+// markdown the way md-to-pdf does, runs transformers over it, renders the
+// elements of content generators into an in-memory PDF and records what the
+// plugin logged.
+//
+//	func TestTransform(t *testing.T) {
+//		result := plugintest.Transform(t, NewPlugin(), "```mermaid\ngraph TD; A-->B\n```")
+//		plugintest.AssertNodeCount(t, result.Document, ast.KindFencedCodeBlock, 0)
+//	}
+package plugintest
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/fredcamaral/md-to-pdf/internal/network"
+	"github.com/fredcamaral/md-to-pdf/internal/parser"
+	"github.com/fredcamaral/md-to-pdf/internal/plugins"
+	"github.com/fredcamaral/md-to-pdf/pkg/plugin"
+	"github.com/jung-kurt/gofpdf"
+	"github.com/yuin/goldmark/ast"
+)
+
+// LogEntry is a message logged by the plugin under test.
+type LogEntry struct {
+	Level   plugin.LogLevel
+	Message string
+}
+
+// Harness runs one plugin the way md-to-pdf does. Set its fields before
+// calling Transform or Generate.
+type Harness struct {
+	t testing.TB
+
+	// Config is passed to the plugin's Init
+	Config map[string]interface{}
+	// Grants are the capabilities granted to the plugin (nil = the
+	// capabilities it declares)
+	Grants *plugin.Capabilities
+	// HTTP is the client of plugins that declare the network capability
+	// (nil = an offline client, so tests never reach the network)
+	HTTP plugin.HTTPClient
+}
+
+// New creates a harness that reports failures to t.
+func New(t testing.TB) *Harness {
+	return &Harness{t: t}
+}
+
+// Result is what running a plugin produced.
+type Result struct {
+	// Document is the parsed, and for transformers transformed, markdown
+	Document ast.Node
+	// Source is the markdown that node segments refer to
+	Source []byte
+	// Elements are the elements returned by a content generator
+	Elements []plugin.PDFElement
+	// PDF is the uncompressed in-memory PDF the elements were rendered into
+	PDF []byte
+	// Logs are the messages the plugin logged, in order
+	Logs []LogEntry
+}
+
+// Parse parses markdown with the parser and extensions md-to-pdf uses.
+func Parse(t testing.TB, markdown string) (ast.Node, []byte) {
+	t.Helper()
+	source := []byte(markdown)
+	doc, err := parser.NewMarkdownParser().Parse(source)
+	if err != nil {
+		t.Fatalf("failed to parse markdown: %v", err)
+	}
+	return doc, source
+}
+
+// Transform runs transformer over markdown with a default harness.
+func Transform(t testing.TB, transformer plugin.Plugin, markdown string) *Result {
+	t.Helper()
+	return New(t).Transform(transformer, markdown)
+}
+
+// Generate runs generator over markdown with a default harness.
+func Generate(t testing.TB, generator plugin.ContentGenerator, markdown string) *Result {
+	t.Helper()
+	return New(t).Generate(generator, markdown)
+}
+
+// Transform initializes transformer and applies it to the parsed markdown
+// as md-to-pdf does before rendering: an ASTTransformer to every node, then
+// a DocumentTransformer to the whole document. The test fails when the
+// plugin is neither or returns an error.
+func (h *Harness) Transform(transformer plugin.Plugin, markdown string) *Result {
+	h.t.Helper()
+	_, isTransformer := transformer.(plugin.ASTTransformer)
+	_, isDocumentTransformer := transformer.(plugin.DocumentTransformer)
+	if !isTransformer && !isDocumentTransformer {
+		h.t.Fatalf("plugin %s is neither an ASTTransformer nor a DocumentTransformer", transformer.Name())
+	}
+	result := &Result{}
+	manager := h.manager(transformer, result)
+
+	doc, source := Parse(h.t, markdown)
+	doc, err := manager.TransformDocument(doc, source)
+	if err != nil {
+		h.t.Fatalf("transform failed: %v", err)
+	}
+	result.Document, result.Source = doc, source
+	h.cleanup(transformer)
+	return result
+}
+
+// Generate initializes generator, runs it on a blank A4 page for the parsed
+// markdown and renders the elements it returns. The test fails when the
+// generator or one of its elements returns an error.
+func (h *Harness) Generate(generator plugin.ContentGenerator, markdown string) *Result {
+	h.t.Helper()
+	result := &Result{}
+	manager := h.manager(generator, result)
+
+	doc, source := Parse(h.t, markdown)
+	result.Document, result.Source = doc, source
+
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.SetCompression(false)
+	pdf.SetMargins(15, 20, 15)
+	pdf.SetAutoPageBreak(true, 20)
+	pdf.AddPage()
+	pdf.SetFont("Arial", "", 12)
+
+	pageWidth, pageHeight := pdf.GetPageSize()
+	ctx := &plugin.RenderContext{
+		Document:    &plugin.Document{Metadata: make(map[string]interface{})},
+		CurrentPage: 1,
+		PDF:         pdf,
+		Source:      source,
+		PageWidth:   pageWidth,
+		PageHeight:  pageHeight,
+		Margins:     plugins.RenderMargins{Top: 20, Bottom: 20, Left: 15, Right: 15},
+		Metadata:    make(map[string]interface{}),
+		Config:      make(map[string]interface{}),
+	}
+	elements, err := manager.GenerateContent(generator.GenerationPhase(), ctx)
+	if err != nil {
+		h.t.Fatalf("generate failed: %v", err)
+	}
+	for _, element := range elements {
+		if err := element.Render(pdf, ctx); err != nil {
+			h.t.Fatalf("failed to render %T: %v", element, err)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		h.t.Fatalf("failed to write PDF: %v", err)
+	}
+	result.Elements, result.PDF = elements, buf.Bytes()
+	h.cleanup(generator)
+	return result
+}
+
+// manager registers p with a plugin manager that records its messages in
+// result.
+func (h *Harness) manager(p plugin.Plugin, result *Result) *plugins.Manager {
+	h.t.Helper()
+	manager := plugins.NewManager(h.t.TempDir(), false, map[string]map[string]interface{}{p.Name(): h.Config})
+	manager.SetLogHandler(func(_ string, level plugins.LogLevel, message string) {
+		result.Logs = append(result.Logs, LogEntry{Level: level, Message: message})
+	})
+	if h.Grants != nil {
+		security := plugins.DefaultSecurityConfig()
+		security.Grants = map[string]plugins.Capabilities{p.Name(): *h.Grants}
+		if err := manager.SetSecurityConfig(security); err != nil {
+			h.t.Fatalf("failed to grant capabilities: %v", err)
+		}
+	}
+	if h.HTTP != nil {
+		manager.SetHTTPClient(h.HTTP)
+	} else {
+		manager.SetHTTPClient(network.NewClient(network.Options{Offline: true}))
+	}
+	if err := manager.Register(p); err != nil {
+		h.t.Fatalf("%v", err)
+	}
+	return manager
+}
+
+// cleanup calls the plugin's Cleanup and fails the test on an error.
+func (h *Harness) cleanup(p plugin.Plugin) {
+	h.t.Helper()
+	if err := p.Cleanup(); err != nil {
+		h.t.Errorf("cleanup of plugin %s failed: %v", p.Name(), err)
+	}
+}
+
+// Warnings returns the warnings the plugin logged.
+func (r *Result) Warnings() []string {
+	var warnings []string
+	for _, entry := range r.Logs {
+		if entry.Level == plugin.LogWarn {
+			warnings = append(warnings, entry.Message)
+		}
+	}
+	return warnings
+}
+
+// Text returns the text of the document.
+func (r *Result) Text() string {
+	return plugin.ExtractText(r.Document, r.Source)
+}
+
+// PDFContains reports whether the rendered PDF shows text. Only text in the
+// standard fonts, without characters outside Latin-1, can be found.
+func (r *Result) PDFContains(text string) bool {
+	escaped := strings.NewReplacer(`\`, `\\`, "(", `\(`, ")", `\)`).Replace(text)
+	return bytes.Contains(r.PDF, []byte(escaped))
+}
+
+// FindNodes returns the nodes of a kind in the tree below node, in document
+// order.
+func FindNodes(node ast.Node, kind ast.NodeKind) []ast.Node {
+	var found []ast.Node
+	_ = ast.Walk(node, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if entering && n.Kind() == kind {
+			found = append(found, n)
+		}
+		return ast.WalkContinue, nil
+	})
+	return found
+}
+
+// AssertNodeCount fails the test unless the tree below node holds want
+// nodes of kind.
+func AssertNodeCount(t testing.TB, node ast.Node, kind ast.NodeKind, want int) {
+	t.Helper()
+	if got := len(FindNodes(node, kind)); got != want {
+		t.Errorf("found %d %s nodes, want %d", got, kind, want)
+	}
+}
+
+// AssertPDFContains fails the test unless the rendered PDF shows text.
+func AssertPDFContains(t testing.TB, result *Result, text string) {
+	t.Helper()
+	if !result.PDFContains(text) {
+		t.Errorf("PDF does not contain %q", text)
+	}
+}
+
+// AssertNoWarnings fails the test when the plugin logged warnings.
+func AssertNoWarnings(t testing.TB, result *Result) {
+	t.Helper()
+	if warnings := result.Warnings(); len(warnings) > 0 {
+		t.Errorf("plugin logged warnings: %s", strings.Join(warnings, "; "))
+	}
+}
@@ -0,0 +1,102 @@
+package plugintest
+
+import (
+	"testing"
+
+	"github.com/fredcamaral/md-to-pdf/pkg/plugin"
+	"github.com/yuin/goldmark/ast"
+)
+
+// shoutPlugin replaces "shout" code blocks by a paragraph, and generates a
+// closing line with the number of blocks it replaced.
+type shoutPlugin struct {
+	*plugin.BasePlugin
+	replaced int
+}
+
+func newShoutPlugin() *shoutPlugin {
+	return &shoutPlugin{BasePlugin: plugin.NewBasePlugin("shout", "1.0.0", "test plugin")}
+}
+
+func (p *shoutPlugin) Priority() int                  { return 10 }
+func (p *shoutPlugin) SupportedNodes() []ast.NodeKind { return []ast.NodeKind{ast.KindFencedCodeBlock} }
+func (p *shoutPlugin) GenerationPhase() plugin.GenerationPhase {
+	return plugin.AfterContent
+}
+
+func (p *shoutPlugin) Transform(node ast.Node, ctx *plugin.TransformContext) (ast.Node, error) {
+	if plugin.GetCodeBlockLanguage(node, ctx.Source) != "shout" {
+		return node, nil
+	}
+	if _, err := ctx.HTTP.Get("https://example.com/font"); err != nil {
+		ctx.Logger.Warnf("no font: %v", err)
+	}
+	p.replaced++
+	return ast.NewParagraph(), nil
+}
+
+func (p *shoutPlugin) Generate(ctx *plugin.RenderContext) ([]plugin.PDFElement, error) {
+	ctx.Logger.Infof("generating")
+	return []plugin.PDFElement{plugin.CreateTextElement("Shouted (loudly)", 12, "B")}, nil
+}
+
+func TestTransform(t *testing.T) {
+	p := newShoutPlugin()
+	result := Transform(t, p, "# Title\n\n```shout\nhello\n```\n\n```go\nx := 1\n```\n")
+
+	if p.replaced != 1 {
+		t.Errorf("replaced %d blocks, want 1", p.replaced)
+	}
+	AssertNodeCount(t, result.Document, ast.KindFencedCodeBlock, 1)
+	AssertNodeCount(t, result.Document, ast.KindHeading, 1)
+	if result.Text() != "Title" {
+		t.Errorf("Text() = %q, want %q", result.Text(), "Title")
+	}
+	// The plugin does not declare the network, so its request is refused
+	if warnings := result.Warnings(); len(warnings) != 1 {
+		t.Errorf("expected one warning, got %v", warnings)
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	result := Generate(t, newShoutPlugin(), "# Title\n")
+
+	if len(result.Elements) != 1 {
+		t.Fatalf("expected one element, got %d", len(result.Elements))
+	}
+	AssertPDFContains(t, result, "Shouted (loudly)")
+	if result.PDFContains("Whispered") {
+		t.Error("PDF should not contain text the plugin did not render")
+	}
+	AssertNoWarnings(t, result)
+	if len(result.Logs) != 1 || result.Logs[0].Level != plugin.LogInfo || result.Logs[0].Message != "generating" {
+		t.Errorf("Logs = %v, want the info message", result.Logs)
+	}
+}
+
+// dedupePlugin removes headings repeating an earlier heading's text.
+type dedupePlugin struct {
+	*plugin.BasePlugin
+}
+
+func (p *dedupePlugin) Priority() int { return 10 }
+
+func (p *dedupePlugin) TransformDocument(doc ast.Node, ctx *plugin.TransformContext) (ast.Node, error) {
+	seen := make(map[string]bool)
+	for _, heading := range FindNodes(doc, ast.KindHeading) {
+		text := plugin.ExtractText(heading, ctx.Source)
+		if seen[text] {
+			heading.Parent().RemoveChild(heading.Parent(), heading)
+		}
+		seen[text] = true
+	}
+	return doc, nil
+}
+
+func TestTransform_DocumentTransformer(t *testing.T) {
+	p := &dedupePlugin{BasePlugin: plugin.NewBasePlugin("dedupe", "1.0.0", "test plugin")}
+	result := Transform(t, p, "# Intro\n\ntext\n\n# Intro\n\n## Usage\n")
+
+	AssertNodeCount(t, result.Document, ast.KindHeading, 2)
+	AssertNoWarnings(t, result)
+}
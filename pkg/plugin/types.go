@@ -1,6 +1,7 @@
 package plugin
 
 import (
+	"github.com/fredcamaral/md-to-pdf/internal/network"
 	"github.com/fredcamaral/md-to-pdf/internal/plugins"
 	"github.com/jung-kurt/gofpdf"
 	"github.com/yuin/goldmark/ast"
@@ -9,12 +10,28 @@ import (
 // Re-export types for plugin developers
 type Plugin = plugins.Plugin
 type ASTTransformer = plugins.ASTTransformer
+type DocumentTransformer = plugins.DocumentTransformer
 type ContentGenerator = plugins.ContentGenerator
+type Resetter = plugins.Resetter
 type TransformContext = plugins.TransformContext
 type RenderContext = plugins.RenderContext
 type Document = plugins.Document
 type PDFElement = plugins.PDFElement
 type GenerationPhase = plugins.GenerationPhase
+type Logger = plugins.Logger
+type LoggerSetter = plugins.LoggerSetter
+type LogLevel = plugins.LogLevel
+type HTTPClient = plugins.HTTPClient
+type Capabilities = plugins.Capabilities
+type CapabilityDeclarer = plugins.CapabilityDeclarer
+type Host = plugins.Host
+type FencedBlockHandler = plugins.FencedBlockHandler
+type FencedLanguage = plugins.FencedLanguage
+type HandlerKind = plugins.HandlerKind
+
+// ErrOffline is returned by HTTPClient for requests that need the network
+// while md-to-pdf runs with --offline.
+var ErrOffline = network.ErrOffline
 
 // Re-export constants
 const (
@@ -24,16 +41,31 @@ const (
 	AfterEachPage  = plugins.AfterEachPage
 )
 
+const (
+	KindPlain       = plugins.KindPlain
+	KindHighlighter = plugins.KindHighlighter
+	KindDiagram     = plugins.KindDiagram
+	KindTransformer = plugins.KindTransformer
+)
+
+const (
+	LogDebug = plugins.LogDebug
+	LogInfo  = plugins.LogInfo
+	LogWarn  = plugins.LogWarn
+)
+
 // Re-export built-in elements
 type TextElement = plugins.TextElement
 type ImageElement = plugins.ImageElement
 type LineElement = plugins.LineElement
+type TableElement = plugins.TableElement
 
 // BasePlugin provides a basic implementation of the Plugin interface
 type BasePlugin struct {
 	name        string
 	version     string
 	description string
+	logger      Logger
 }
 
 func NewBasePlugin(name, version, description string) *BasePlugin {
@@ -56,6 +88,20 @@ func (p *BasePlugin) Description() string {
 	return p.description
 }
 
+// SetLogger is called by md-to-pdf with the logger of the plugin before Init.
+func (p *BasePlugin) SetLogger(logger Logger) {
+	p.logger = logger
+}
+
+// Logger returns the logger set by md-to-pdf. Before it is set, warnings
+// are printed to stderr and other messages are dropped.
+func (p *BasePlugin) Logger() Logger {
+	if p.logger == nil {
+		return plugins.NewLogger(p.name, nil)
+	}
+	return p.logger
+}
+
 func (p *BasePlugin) Init(config map[string]interface{}) error {
 	return nil
 }
@@ -105,6 +151,12 @@ func CreateLineElement(x1, y1, x2, y2, width float64) PDFElement {
 	}
 }
 
+// CreateTableElement creates a table element with the given header row.
+// Add rows with AddRow before returning it from a content generator.
+func CreateTableElement(header ...string) *TableElement {
+	return plugins.NewTableElement().SetHeader(header...)
+}
+
 // GetCurrentPosition returns the current position in the PDF
 func GetCurrentPosition(pdf *gofpdf.Fpdf) (float64, float64) {
 	return pdf.GetXY()
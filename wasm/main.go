@@ -0,0 +1,101 @@
+//go:build js && wasm
+
+// Command wasm exposes the converter to JavaScript when built for
+// WebAssembly:
+//
+//	GOOS=js GOARCH=wasm go build -o md-to-pdf.wasm ./wasm
+//
+// Once the module runs, globalThis.mdToPdf.convert(markdown, settings)
+// returns a promise of {pdf: Uint8Array, warnings: string[]}. settings is
+// an object or JSON string in the config file format, such as
+// {font_size: 11, extends: "github"}. Plugins cannot be loaded, and images
+// are read from the in-memory file system only.
+package main
+
+import (
+	"errors"
+	"syscall/js"
+
+	"github.com/fredcamaral/md-to-pdf/internal/config"
+	"github.com/fredcamaral/md-to-pdf/internal/core"
+)
+
+// version is set at build time with -ldflags "-X main.version=...".
+var version = "dev"
+
+func main() {
+	js.Global().Set("mdToPdf", js.ValueOf(map[string]interface{}{
+		"version": version,
+		"convert": js.FuncOf(convert),
+	}))
+	// Keep the exported functions alive
+	select {}
+}
+
+// convert is mdToPdf.convert(markdown, settings).
+func convert(this js.Value, args []js.Value) interface{} {
+	promise := js.Global().Get("Promise")
+	return promise.New(js.FuncOf(func(this js.Value, handlers []js.Value) interface{} {
+		resolve, reject := handlers[0], handlers[1]
+		go func() {
+			result, err := convertArgs(args)
+			if err != nil {
+				reject.Invoke(js.Global().Get("Error").New(err.Error()))
+				return
+			}
+			resolve.Invoke(result)
+		}()
+		return nil
+	}))
+}
+
+// convertArgs converts the markdown in args[0] with the settings in args[1].
+func convertArgs(args []js.Value) (js.Value, error) {
+	if len(args) == 0 || args[0].Type() != js.TypeString {
+		return js.Undefined(), errors.New("convert: markdown must be a string")
+	}
+	settings, err := settingsJSON(args)
+	if err != nil {
+		return js.Undefined(), err
+	}
+
+	cfg, err := config.ConfigFromSettings([]byte(settings))
+	if err != nil {
+		return js.Undefined(), err
+	}
+	engine, err := core.NewEngine(cfg)
+	if err != nil {
+		return js.Undefined(), err
+	}
+	defer engine.Close()
+	warnings := []interface{}{}
+	engine.SetWarningHandler(func(file, message string) {
+		warnings = append(warnings, message)
+	})
+
+	pdf, err := engine.ConvertBytes([]byte(args[0].String()), "document.md")
+	if err != nil {
+		return js.Undefined(), err
+	}
+	data := js.Global().Get("Uint8Array").New(len(pdf))
+	js.CopyBytesToJS(data, pdf)
+	return js.ValueOf(map[string]interface{}{
+		"pdf":      data,
+		"warnings": warnings,
+	}), nil
+}
+
+// settingsJSON returns the settings argument as JSON, "" when absent.
+func settingsJSON(args []js.Value) (string, error) {
+	if len(args) < 2 || args[1].IsUndefined() || args[1].IsNull() {
+		return "", nil
+	}
+	switch args[1].Type() {
+	case js.TypeString:
+		return args[1].String(), nil
+	case js.TypeObject:
+		return js.Global().Get("JSON").Call("stringify", args[1]).String(), nil
+	default:
+		return "", errors.New("convert: settings must be an object or a JSON string")
+	}
+}